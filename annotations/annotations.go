@@ -0,0 +1,285 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package annotations defines the keys of all annotations that are managed by
+// issuer-lib, together with helpers to parse and validate their values.
+//
+// All annotation keys share the "issuer-lib.cert-manager.io/" prefix. The keys
+// are also available as constants in the api/v1alpha1 package; this package
+// groups them in one place and adds ValidateObjectAnnotations, which can be used
+// in admission webhooks to reject invalid values (or misspelled keys) before
+// they reach the controllers:
+//
+//	if errs := annotations.ValidateObjectAnnotations(obj, field.NewPath("metadata", "annotations")); len(errs) > 0 {
+//		return admission.Denied(errs.ToAggregate().Error())
+//	}
+package annotations
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+// Prefix is the prefix of all annotations that are managed by issuer-lib.
+const Prefix = "issuer-lib.cert-manager.io/"
+
+// Annotations that can be set by users.
+const (
+	// IssuerPaused is set to "true" to pause an issuer.
+	IssuerPaused = v1alpha1.IssuerPausedAnnotationKey
+	// IssuerMaintenanceWindow declares a window of planned CA downtime on an
+	// issuer, formatted as "<start>/<end>" (see v1alpha1.MaintenanceWindow).
+	IssuerMaintenanceWindow = v1alpha1.IssuerMaintenanceWindowAnnotationKey
+	// IssuerQuota limits the number of certificates issued by an issuer within
+	// a period, formatted as "<limit>/<period>" (see v1alpha1.IssuerQuota).
+	IssuerQuota = v1alpha1.IssuerQuotaAnnotationKey
+	// RequestPriorityClass is set to "high", "normal" or "low" on a request to
+	// change the order in which it is reconciled.
+	RequestPriorityClass = v1alpha1.RequestPriorityClassAnnotationKey
+	// RequestOptionPrefix is the prefix of the annotations that pass options
+	// to the Sign function, eg. "issuer-lib.cert-manager.io/option.profile".
+	RequestOptionPrefix = v1alpha1.RequestOptionAnnotationPrefix
+	// InjectCAFromIssuer is set on webhook configurations, CRDs and APIServices
+	// to the issuer whose CA bundle is injected, formatted as
+	// "<issuer type identifier>/<namespace>/<name>" or "<issuer type identifier>/<name>".
+	InjectCAFromIssuer = v1alpha1.InjectCAFromIssuerAnnotationKey
+	// RequestIssuerOverride is set on a request to route it to a different issuer
+	// than the one it references, formatted like InjectCAFromIssuer. It should be
+	// guarded by an admission webhook that calls AuthorizeIssuerOverride.
+	RequestIssuerOverride = v1alpha1.RequestIssuerOverrideAnnotationKey
+	// RequestCorrelationID contains the correlation ID of a request. It is set
+	// by the controllers, unless it was set to a valid ID beforehand (see
+	// ValidateCorrelationID).
+	RequestCorrelationID = v1alpha1.RequestCorrelationIDAnnotationKey
+)
+
+// Annotations that are set by the controllers.
+const (
+	// IssuerSecretHash is a hash of the Secrets that an issuer depends on.
+	IssuerSecretHash = v1alpha1.IssuerSecretHashAnnotationKey
+	// IssuerRevocationInfo contains the revocation endpoints of the requests
+	// of an issuer.
+	IssuerRevocationInfo = v1alpha1.IssuerRevocationInfoAnnotationKey
+	// RequestTimeInState contains the time that a request spent in each state.
+	RequestTimeInState = v1alpha1.RequestTimeInStateAnnotationKey
+	// RequestOCSPServer and RequestCRLDistributionPoint contain the revocation
+	// endpoints of the certificate of a request.
+	RequestOCSPServer           = v1alpha1.RequestOCSPServerAnnotationKey
+	RequestCRLDistributionPoint = v1alpha1.RequestCRLDistributionPointAnnotationKey
+	// RequestIssuanceClaim is the claim of a controller replica on the right to
+	// call the Sign function for a request (see v1alpha1.IssuanceClaim).
+	RequestIssuanceClaim = v1alpha1.RequestIssuanceClaimAnnotationKey
+	// RequestSignedBy, RequestSignedByVersion and RequestSignedByIssuerLibVersion
+	// record the controller instance that called the Sign function for a request.
+	RequestSignedBy                 = v1alpha1.RequestSignedByAnnotationKey
+	RequestSignedByVersion          = v1alpha1.RequestSignedByVersionAnnotationKey
+	RequestSignedByIssuerLibVersion = v1alpha1.RequestSignedByIssuerLibVersionAnnotationKey
+)
+
+// validators contains a function that validates the value of each known
+// annotation. Annotations without a validator accept any value.
+var validators = map[string]func(value string) error{
+	IssuerPaused: func(value string) error {
+		_, err := ParsePaused(value)
+		return err
+	},
+	IssuerMaintenanceWindow: func(value string) error {
+		_, err := v1alpha1.ParseMaintenanceWindow(value)
+		return err
+	},
+	IssuerQuota: func(value string) error {
+		_, err := v1alpha1.ParseIssuerQuota(value)
+		return err
+	},
+	RequestPriorityClass: func(value string) error {
+		_, err := ParsePriorityClass(value)
+		return err
+	},
+	InjectCAFromIssuer: func(value string) error {
+		_, err := ParseIssuerReference(value)
+		return err
+	},
+	RequestIssuerOverride: func(value string) error {
+		_, err := ParseIssuerReference(value)
+		return err
+	},
+	RequestCorrelationID: ValidateCorrelationID,
+	RequestIssuanceClaim: func(value string) error {
+		_, err := v1alpha1.ParseIssuanceClaim(value)
+		return err
+	},
+	IssuerSecretHash:                nil,
+	IssuerRevocationInfo:            nil,
+	RequestTimeInState:              nil,
+	RequestOCSPServer:               nil,
+	RequestCRLDistributionPoint:     nil,
+	RequestSignedBy:                 nil,
+	RequestSignedByVersion:          nil,
+	RequestSignedByIssuerLibVersion: nil,
+}
+
+// IsManaged returns true if the annotation key has the issuer-lib prefix.
+func IsManaged(key string) bool {
+	return strings.HasPrefix(key, Prefix)
+}
+
+// ParsePaused parses the value of the IssuerPaused annotation, which must be
+// "true" or "false".
+func ParsePaused(value string) (bool, error) {
+	switch value {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	default:
+		return false, fmt.Errorf("paused value %q is not \"true\" or \"false\"", value)
+	}
+}
+
+// ParsePriorityClass parses the value of the RequestPriorityClass annotation,
+// which must be "high", "normal" or "low". PriorityClassNormal is returned for
+// invalid values.
+func ParsePriorityClass(value string) (signer.PriorityClass, error) {
+	switch value {
+	case "high":
+		return signer.PriorityClassHigh, nil
+	case "normal":
+		return signer.PriorityClassNormal, nil
+	case "low":
+		return signer.PriorityClassLow, nil
+	default:
+		return signer.PriorityClassNormal, fmt.Errorf("priority class %q is not \"high\", \"normal\" or \"low\"", value)
+	}
+}
+
+// maxCorrelationIDLength is the maximum length of a correlation ID, it leaves
+// room for the ID in the headers and log lines of CAs.
+const maxCorrelationIDLength = 128
+
+// ValidateCorrelationID validates the value of the RequestCorrelationID
+// annotation, which must consist of 1 to 128 alphanumeric characters, '-', '_',
+// '.' or ':'. The controllers replace invalid values with a generated ID.
+func ValidateCorrelationID(value string) error {
+	if value == "" || len(value) > maxCorrelationIDLength {
+		return fmt.Errorf("correlation ID must be between 1 and %d characters long", maxCorrelationIDLength)
+	}
+	for _, r := range value {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') && !strings.ContainsRune("-_.:", r) {
+			return fmt.Errorf("correlation ID %q contains the invalid character %q", value, r)
+		}
+	}
+	return nil
+}
+
+// IssuerReference is a reference to an issuer, as used in the value of the
+// InjectCAFromIssuer annotation.
+type IssuerReference struct {
+	// IssuerTypeIdentifier is the value returned by GetIssuerTypeIdentifier,
+	// eg. "simpleclusterissuers.issuer.cert-manager.io".
+	IssuerTypeIdentifier string
+	// Namespace is empty for cluster-scoped issuers.
+	Namespace string
+	Name      string
+}
+
+// ParseIssuerReference parses an issuer reference in the
+// "<issuer type identifier>/<namespace>/<name>" or "<issuer type identifier>/<name>"
+// format.
+func ParseIssuerReference(value string) (IssuerReference, error) {
+	segments := strings.Split(value, "/")
+	for _, segment := range segments {
+		if segment == "" {
+			return IssuerReference{}, fmt.Errorf("issuer reference %q contains an empty segment", value)
+		}
+	}
+
+	switch len(segments) {
+	case 2:
+		return IssuerReference{IssuerTypeIdentifier: segments[0], Name: segments[1]}, nil
+	case 3:
+		return IssuerReference{IssuerTypeIdentifier: segments[0], Namespace: segments[1], Name: segments[2]}, nil
+	default:
+		return IssuerReference{}, fmt.Errorf("issuer reference %q is not formatted as \"<issuer type identifier>/<namespace>/<name>\" or \"<issuer type identifier>/<name>\"", value)
+	}
+}
+
+// String returns the issuer reference in the format of the annotation.
+func (r IssuerReference) String() string {
+	if r.Namespace == "" {
+		return r.IssuerTypeIdentifier + "/" + r.Name
+	}
+	return r.IssuerTypeIdentifier + "/" + r.Namespace + "/" + r.Name
+}
+
+// ValidateObjectAnnotations validates the issuer-lib annotations of the object.
+// Unknown annotations with the issuer-lib prefix (eg. misspelled keys) are
+// rejected, other annotations are ignored. The annotations that are set by the
+// controllers accept any value.
+func ValidateObjectAnnotations(obj metav1.Object, fldPath *field.Path) field.ErrorList {
+	annotations := obj.GetAnnotations()
+	keys := make([]string, 0, len(annotations))
+	for key := range annotations {
+		if IsManaged(key) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	var errs field.ErrorList
+	for _, key := range keys {
+		value := annotations[key]
+
+		if name, ok := strings.CutPrefix(key, RequestOptionPrefix); ok {
+			if name == "" {
+				errs = append(errs, field.Invalid(fldPath.Key(key), value, "the annotation does not contain an option name"))
+			}
+			continue
+		}
+
+		validate, known := validators[key]
+		if !known {
+			errs = append(errs, field.NotSupported(fldPath.Key(key), key, knownKeys()))
+			continue
+		}
+
+		if validate == nil {
+			continue
+		}
+
+		if err := validate(value); err != nil {
+			errs = append(errs, field.Invalid(fldPath.Key(key), value, err.Error()))
+		}
+	}
+	return errs
+}
+
+// knownKeys returns the known annotation keys, including the option prefix.
+func knownKeys() []string {
+	keys := make([]string, 0, len(validators)+1)
+	for key := range validators {
+		keys = append(keys, key)
+	}
+	keys = append(keys, RequestOptionPrefix+"*")
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package annotations
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+func TestValidateObjectAnnotations(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name           string
+		annotations    map[string]string
+		expectedErrors []string
+	}
+
+	tests := []testCase{
+		{
+			name: "valid-annotations",
+			annotations: map[string]string{
+				IssuerPaused:                     "true",
+				IssuerMaintenanceWindow:          "2024-01-02T22:00:00Z/2024-01-03T02:00:00Z",
+				IssuerQuota:                      "100/1h",
+				RequestPriorityClass:             "high",
+				InjectCAFromIssuer:               "simpleclusterissuers.issuer.cert-manager.io/my-issuer",
+				RequestIssuanceClaim:             "replica-1/2024-01-02T22:05:00Z",
+				RequestIssuerOverride:            "simpleclusterissuers.issuer.cert-manager.io/backup",
+				RequestCorrelationID:             "workflow-42:step.1",
+				RequestOptionPrefix + "profile":  "server",
+				IssuerSecretHash:                 "any value",
+				"example.com/unrelated":          "any value",
+				"issuer.cert-manager.io/unknown": "any value",
+			},
+		},
+		{
+			name: "invalid-values",
+			annotations: map[string]string{
+				IssuerPaused:         "yes",
+				IssuerQuota:          "100",
+				RequestPriorityClass: "urgent",
+				InjectCAFromIssuer:   "simpleissuers.issuer.cert-manager.io//my-issuer",
+				RequestCorrelationID: "id with spaces",
+			},
+			expectedErrors: []string{
+				`metadata.annotations[issuer-lib.cert-manager.io/correlation-id]: Invalid value: "id with spaces": correlation ID "id with spaces" contains the invalid character ' '`,
+				`metadata.annotations[issuer-lib.cert-manager.io/inject-ca-from-issuer]: Invalid value: "simpleissuers.issuer.cert-manager.io//my-issuer": issuer reference "simpleissuers.issuer.cert-manager.io//my-issuer" contains an empty segment`,
+				`metadata.annotations[issuer-lib.cert-manager.io/paused]: Invalid value: "yes": paused value "yes" is not "true" or "false"`,
+				`metadata.annotations[issuer-lib.cert-manager.io/priority-class]: Invalid value: "urgent": priority class "urgent" is not "high", "normal" or "low"`,
+				`metadata.annotations[issuer-lib.cert-manager.io/quota]: Invalid value: "100": issuer quota "100" is not formatted as "<limit>/<period>"`,
+			},
+		},
+		{
+			name: "unknown-key",
+			annotations: map[string]string{
+				Prefix + "pause": "true",
+			},
+			expectedErrors: []string{
+				`metadata.annotations[issuer-lib.cert-manager.io/pause]: Unsupported value: "issuer-lib.cert-manager.io/pause"`,
+			},
+		},
+		{
+			name: "empty-option-name",
+			annotations: map[string]string{
+				RequestOptionPrefix: "value",
+			},
+			expectedErrors: []string{
+				`metadata.annotations[issuer-lib.cert-manager.io/option.]: Invalid value: "value": the annotation does not contain an option name`,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			obj := &metav1.ObjectMeta{Annotations: tc.annotations}
+			errs := ValidateObjectAnnotations(obj, field.NewPath("metadata", "annotations"))
+
+			require.Len(t, errs, len(tc.expectedErrors))
+			for i, expectedError := range tc.expectedErrors {
+				assert.Contains(t, errs[i].Error(), expectedError)
+			}
+		})
+	}
+}
+
+func TestParseIssuerReference(t *testing.T) {
+	t.Parallel()
+
+	for value, expected := range map[string]IssuerReference{
+		"simpleclusterissuers.issuer.cert-manager.io/my-issuer": {
+			IssuerTypeIdentifier: "simpleclusterissuers.issuer.cert-manager.io",
+			Name:                 "my-issuer",
+		},
+		"simpleissuers.issuer.cert-manager.io/ns1/my-issuer": {
+			IssuerTypeIdentifier: "simpleissuers.issuer.cert-manager.io",
+			Namespace:            "ns1",
+			Name:                 "my-issuer",
+		},
+	} {
+		reference, err := ParseIssuerReference(value)
+		require.NoError(t, err)
+		assert.Equal(t, expected, reference)
+		assert.Equal(t, value, reference.String())
+	}
+
+	for _, value := range []string{"", "my-issuer", "a/b/c/d", "a//b"} {
+		_, err := ParseIssuerReference(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestParsePriorityClass(t *testing.T) {
+	t.Parallel()
+
+	for value, expected := range map[string]signer.PriorityClass{
+		"high":   signer.PriorityClassHigh,
+		"normal": signer.PriorityClassNormal,
+		"low":    signer.PriorityClassLow,
+	} {
+		priorityClass, err := ParsePriorityClass(value)
+		require.NoError(t, err)
+		assert.Equal(t, expected, priorityClass)
+	}
+
+	priorityClass, err := ParsePriorityClass("urgent")
+	assert.Error(t, err)
+	assert.Equal(t, signer.PriorityClassNormal, priorityClass)
+}
+
+func TestValidateCorrelationID(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, ValidateCorrelationID("0f3c2a9e"))
+	assert.NoError(t, ValidateCorrelationID(strings.Repeat("a", 128)))
+	assert.Error(t, ValidateCorrelationID(""))
+	assert.Error(t, ValidateCorrelationID(strings.Repeat("a", 129)))
+	assert.Error(t, ValidateCorrelationID("id\nwith-newline"))
+}
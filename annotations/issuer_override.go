@@ -0,0 +1,109 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package annotations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// IssuerOverrideVerb is the verb that a user must be allowed to use on an issuer
+// to route requests to it using the RequestIssuerOverride annotation.
+const IssuerOverrideVerb = "override"
+
+// AuthorizeIssuerOverride checks that the user who creates or updates a request
+// is allowed to set its RequestIssuerOverride annotation. It is meant to be
+// called from an admission webhook, with the user info of the admission request,
+// the old request (nil on create) and the new request. If the annotation was
+// added or changed, a SubjectAccessReview checks that the user is allowed to use
+// the IssuerOverrideVerb on the issuer that the annotation references, eg.:
+//
+//	rules:
+//	- apiGroups: ["issuer.cert-manager.io"]
+//	  resources: ["simpleclusterissuers"]
+//	  verbs: ["override"]
+//
+// The group and resource are derived from the issuer type identifier, which is
+// formatted as "<issuer resource (plural)>.<issuer group>". Removing the
+// annotation is always allowed, since that routes the request back to the issuer
+// it references.
+func AuthorizeIssuerOverride(
+	ctx context.Context,
+	c client.Client,
+	userInfo authenticationv1.UserInfo,
+	oldObj metav1.Object,
+	newObj metav1.Object,
+) error {
+	value, ok := newObj.GetAnnotations()[RequestIssuerOverride]
+	if !ok {
+		return nil
+	}
+
+	if oldObj != nil {
+		if oldValue, ok := oldObj.GetAnnotations()[RequestIssuerOverride]; ok && oldValue == value {
+			return nil
+		}
+	}
+
+	issuerRef, err := ParseIssuerReference(value)
+	if err != nil {
+		return err
+	}
+
+	resource, group, _ := strings.Cut(issuerRef.IssuerTypeIdentifier, ".")
+
+	extra := make(map[string]authorizationv1.ExtraValue, len(userInfo.Extra))
+	for key, values := range userInfo.Extra {
+		extra[key] = authorizationv1.ExtraValue(values)
+	}
+
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   userInfo.Username,
+			Groups: userInfo.Groups,
+			UID:    userInfo.UID,
+			Extra:  extra,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: issuerRef.Namespace,
+				Verb:      IssuerOverrideVerb,
+				Group:     group,
+				Resource:  resource,
+				Name:      issuerRef.Name,
+			},
+		},
+	}
+	if err := c.Create(ctx, review); err != nil {
+		return fmt.Errorf("failed to review access to issuer %q: %w", issuerRef, err)
+	}
+
+	if !review.Status.Allowed {
+		message := fmt.Sprintf("user %q is not allowed to %s issuer %q", userInfo.Username, IssuerOverrideVerb, issuerRef)
+		if review.Status.Reason != "" {
+			message += ": " + review.Status.Reason
+		}
+		return errors.New(message)
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package annotations
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/cert-manager/issuer-lib/internal/tests/errormatch"
+)
+
+func TestAuthorizeIssuerOverride(t *testing.T) {
+	t.Parallel()
+
+	withOverride := func(value string) metav1.Object {
+		obj := &metav1.ObjectMeta{Namespace: "ns1", Name: "request"}
+		if value != "" {
+			obj.Annotations = map[string]string{RequestIssuerOverride: value}
+		}
+		return obj
+	}
+
+	type testCase struct {
+		name           string
+		oldObj         metav1.Object
+		newObj         metav1.Object
+		expectedReview *authorizationv1.ResourceAttributes
+		validateError  *errormatch.Matcher
+	}
+
+	tests := []testCase{
+		{
+			name:          "no-annotation",
+			newObj:        withOverride(""),
+			validateError: errormatch.NoError(),
+		},
+		{
+			name:          "annotation-removed",
+			oldObj:        withOverride("simpleclusterissuers.issuer.cert-manager.io/backup"),
+			newObj:        withOverride(""),
+			validateError: errormatch.NoError(),
+		},
+		{
+			name:          "annotation-unchanged",
+			oldObj:        withOverride("simpleclusterissuers.issuer.cert-manager.io/denied"),
+			newObj:        withOverride("simpleclusterissuers.issuer.cert-manager.io/denied"),
+			validateError: errormatch.NoError(),
+		},
+		{
+			name:   "annotation-added-allowed",
+			oldObj: withOverride(""),
+			newObj: withOverride("simpleclusterissuers.issuer.cert-manager.io/backup"),
+			expectedReview: &authorizationv1.ResourceAttributes{
+				Verb:     IssuerOverrideVerb,
+				Group:    "issuer.cert-manager.io",
+				Resource: "simpleclusterissuers",
+				Name:     "backup",
+			},
+			validateError: errormatch.NoError(),
+		},
+		{
+			name:   "created-with-namespaced-issuer",
+			newObj: withOverride("simpleissuers.issuer.cert-manager.io/ns1/backup"),
+			expectedReview: &authorizationv1.ResourceAttributes{
+				Namespace: "ns1",
+				Verb:      IssuerOverrideVerb,
+				Group:     "issuer.cert-manager.io",
+				Resource:  "simpleissuers",
+				Name:      "backup",
+			},
+			validateError: errormatch.NoError(),
+		},
+		{
+			name:   "annotation-changed-denied",
+			oldObj: withOverride("simpleclusterissuers.issuer.cert-manager.io/backup"),
+			newObj: withOverride("simpleclusterissuers.issuer.cert-manager.io/denied"),
+			expectedReview: &authorizationv1.ResourceAttributes{
+				Verb:     IssuerOverrideVerb,
+				Group:    "issuer.cert-manager.io",
+				Resource: "simpleclusterissuers",
+				Name:     "denied",
+			},
+			validateError: errormatch.ErrorContains("user \"automation\" is not allowed to override issuer \"simpleclusterissuers.issuer.cert-manager.io/denied\": no matching rule"),
+		},
+		{
+			name:          "invalid-reference",
+			newObj:        withOverride("backup"),
+			validateError: errormatch.ErrorContains("issuer reference \"backup\" is not formatted as"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var review *authorizationv1.SubjectAccessReview
+			fakeClient := interceptor.NewClient(fake.NewClientBuilder().Build(), interceptor.Funcs{
+				Create: func(_ context.Context, _ client.WithWatch, obj client.Object, _ ...client.CreateOption) error {
+					review = obj.(*authorizationv1.SubjectAccessReview)
+					if review.Spec.ResourceAttributes.Name == "denied" {
+						review.Status.Reason = "no matching rule"
+					} else {
+						review.Status.Allowed = true
+					}
+					return nil
+				},
+			})
+
+			userInfo := authenticationv1.UserInfo{
+				Username: "automation",
+				Groups:   []string{"system:authenticated"},
+				Extra:    map[string]authenticationv1.ExtraValue{"scopes": {"incident"}},
+			}
+
+			err := AuthorizeIssuerOverride(context.TODO(), fakeClient, userInfo, tc.oldObj, tc.newObj)
+			(*tc.validateError)(t, err)
+
+			if tc.expectedReview == nil {
+				return
+			}
+
+			require.NotNil(t, review)
+			assert.Equal(t, tc.expectedReview, review.Spec.ResourceAttributes)
+			assert.Equal(t, "automation", review.Spec.User)
+			assert.Equal(t, []string{"system:authenticated"}, review.Spec.Groups)
+			assert.Equal(t, authorizationv1.ExtraValue{"incident"}, review.Spec.Extra["scopes"])
+		})
+	}
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// IssuanceClaim is a claim of a controller replica on the right to call the Sign
+// function for a request. It is stored in the RequestIssuanceClaimAnnotationKey
+// annotation, formatted as "<holder>/<expires>" with the expiry in RFC 3339 format,
+// eg. "issuer-controller-7d9f8-x2x4z/2024-01-02T22:05:00Z".
+// +kubebuilder:object:generate=false
+type IssuanceClaim struct {
+	Holder  string
+	Expires time.Time
+}
+
+// ParseIssuanceClaim parses an issuance claim in the "<holder>/<expires>" format.
+func ParseIssuanceClaim(value string) (IssuanceClaim, error) {
+	separator := strings.LastIndex(value, "/")
+	if separator <= 0 {
+		return IssuanceClaim{}, fmt.Errorf("issuance claim %q is not formatted as \"<holder>/<expires>\"", value)
+	}
+
+	expires, err := time.Parse(time.RFC3339, value[separator+1:])
+	if err != nil {
+		return IssuanceClaim{}, fmt.Errorf("invalid issuance claim expiry: %w", err)
+	}
+
+	return IssuanceClaim{Holder: value[:separator], Expires: expires}, nil
+}
+
+// String returns the issuance claim in the format of the annotation.
+func (c IssuanceClaim) String() string {
+	return c.Holder + "/" + c.Expires.Format(time.RFC3339)
+}
+
+// Expired returns true if the issuance claim has expired at the given time.
+func (c IssuanceClaim) Expired(t time.Time) bool {
+	return !t.Before(c.Expires)
+}
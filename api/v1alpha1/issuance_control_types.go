@@ -0,0 +1,91 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// IssuanceControlName is the name of the IssuanceControl singleton that is
+// obeyed by the request controllers when their EnableIssuanceControl option is
+// set.
+const IssuanceControlName = "cluster"
+
+// IssuanceControlSpec is the desired state of the issuance in the cluster.
+type IssuanceControlSpec struct {
+	// Paused pauses the issuance of all requests.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+
+	// PausedIssuerKinds pauses the issuance of the requests for issuers of the
+	// listed kinds.
+	// +listType=atomic
+	// +optional
+	PausedIssuerKinds []IssuerKind `json:"pausedIssuerKinds,omitempty"`
+
+	// ShadowMode makes the controllers process the requests without calling
+	// the CA: requests are validated, but are left pending instead of signed.
+	// +optional
+	ShadowMode bool `json:"shadowMode,omitempty"`
+
+	// Reason is an optional explanation (eg. a link to the incident) that is
+	// added to the conditions and events of the paused requests.
+	// +kubebuilder:validation:MaxLength=256
+	// +optional
+	Reason string `json:"reason,omitempty"`
+}
+
+// IssuerKind identifies an issuer type by its API group and kind.
+type IssuerKind struct {
+	// Group is the API group of the issuer type, eg. "testing.cert-manager.io".
+	Group string `json:"group"`
+
+	// Kind is the kind of the issuer type, eg. "SimpleClusterIssuer".
+	Kind string `json:"kind"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:validation:XValidation:rule="self.metadata.name == 'cluster'",message="the IssuanceControl must be named 'cluster'"
+// +kubebuilder:printcolumn:name="Paused",type="boolean",JSONPath=".spec.paused"
+// +kubebuilder:printcolumn:name="ShadowMode",type="boolean",JSONPath=".spec.shadowMode"
+// +kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".spec.reason"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// IssuanceControl is a cluster-scoped singleton named "cluster" that gives
+// incident responders a single switch to pause the issuance of all requests or
+// of specific issuer kinds, or to enable shadow mode, instead of annotating
+// many issuers.
+type IssuanceControl struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec IssuanceControlSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// IssuanceControlList contains a list of IssuanceControl
+type IssuanceControlList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []IssuanceControl `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&IssuanceControl{}, &IssuanceControlList{})
+}
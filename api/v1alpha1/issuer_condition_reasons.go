@@ -0,0 +1,43 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+// wellKnownIssuerConditionReasons contains the reasons that the Check function
+// can set on the Ready condition of an issuer using a signer.IssuerReasonError,
+// and whether the reason marks the issuer as permanently failed.
+var wellKnownIssuerConditionReasons = map[string]bool{
+	IssuerConditionReasonCredentialsInvalid:  false,
+	IssuerConditionReasonEndpointUnreachable: false,
+	IssuerConditionReasonQuotaExceeded:       false,
+	IssuerConditionReasonConfigurationError:  true,
+}
+
+// IsWellKnownIssuerConditionReason returns true if the reason is one of the
+// well-known reasons that can be set on the Ready condition of an issuer by the
+// Check function.
+func IsWellKnownIssuerConditionReason(reason string) bool {
+	_, ok := wellKnownIssuerConditionReasons[reason]
+	return ok
+}
+
+// IsIssuerConditionReasonFailed returns true if the reason of the Ready
+// condition of an issuer indicates that the issuer failed permanently, ie. the
+// reason is IssuerConditionReasonFailed or a well-known reason that marks the
+// issuer as permanently failed (IssuerConditionReasonConfigurationError).
+func IsIssuerConditionReasonFailed(reason string) bool {
+	return reason == IssuerConditionReasonFailed || wellKnownIssuerConditionReasons[reason]
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+)
+
+// IssuerStatusHolder can be embedded in an issuer type to store its status and
+// implement the GetStatus method of the Issuer interface, so the issuer type
+// only has to implement GetIssuerTypeIdentifier:
+//
+//	type SimpleIssuer struct {
+//		metav1.TypeMeta   `json:",inline"`
+//		metav1.ObjectMeta `json:"metadata,omitempty"`
+//
+//		Spec SimpleIssuerSpec `json:"spec,omitempty"`
+//
+//		v1alpha1.IssuerStatusHolder `json:",inline"`
+//	}
+//
+// The embedded struct must be inlined, so the status is stored in the "status"
+// field of the resource.
+type IssuerStatusHolder struct {
+	// +optional
+	Status IssuerStatus `json:"status,omitempty"`
+}
+
+// GetStatus returns the status of the issuer.
+func (h *IssuerStatusHolder) GetStatus() *IssuerStatus {
+	return &h.Status
+}
+
+// IssuerStatusFromConditions returns an IssuerStatus for issuer types that
+// already define their own status type, so GetStatus can be implemented as:
+//
+//	func (i *SimpleIssuer) GetStatus() *v1alpha1.IssuerStatus {
+//		return v1alpha1.IssuerStatusFromConditions(i.Status.Conditions)
+//	}
+//
+// The returned status shares the conditions with the issuer, it is only read by
+// the controllers. The status of the issuer is applied using server-side apply,
+// so the own status type must store the conditions in its "conditions" field.
+func IssuerStatusFromConditions(conditions []cmapi.IssuerCondition) *IssuerStatus {
+	return &IssuerStatus{Conditions: conditions}
+}
+
+// GetCondition returns a copy of the condition with the given type, or nil if
+// the status has no such condition.
+func (s *IssuerStatus) GetCondition(conditionType cmapi.IssuerConditionType) *cmapi.IssuerCondition {
+	if s == nil {
+		return nil
+	}
+
+	for _, condition := range s.Conditions {
+		if condition.Type == conditionType {
+			return &condition
+		}
+	}
+	return nil
+}
+
+// ObservedGeneration returns the generation of the issuer that was observed
+// when the Ready condition was last set, or 0 if the status has no Ready
+// condition.
+func (s *IssuerStatus) ObservedGeneration() int64 {
+	readyCondition := s.GetCondition(cmapi.IssuerConditionReady)
+	if readyCondition == nil {
+		return 0
+	}
+	return readyCondition.ObservedGeneration
+}
+
+// IsUpToDate returns true if the Ready condition was set for the given
+// generation of the issuer (or a later one).
+func (s *IssuerStatus) IsUpToDate(generation int64) bool {
+	readyCondition := s.GetCondition(cmapi.IssuerConditionReady)
+	return readyCondition != nil && readyCondition.ObservedGeneration >= generation
+}
+
+// IsReady returns true if the status has an up-to-date Ready condition with
+// status True for the given generation of the issuer.
+func (s *IssuerStatus) IsReady(generation int64) bool {
+	readyCondition := s.GetCondition(cmapi.IssuerConditionReady)
+	return readyCondition != nil &&
+		readyCondition.Status == cmmeta.ConditionTrue &&
+		readyCondition.ObservedGeneration >= generation
+}
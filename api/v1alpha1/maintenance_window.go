@@ -0,0 +1,83 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaintenanceWindow is a period of planned downtime of the CA of an issuer. It is
+// declared using the IssuerMaintenanceWindowAnnotationKey annotation, formatted as
+// "<start>/<end>" with both timestamps in RFC 3339 format, eg.
+// "2024-01-02T22:00:00Z/2024-01-03T02:00:00Z".
+// +kubebuilder:object:generate=false
+type MaintenanceWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+// ParseMaintenanceWindow parses a maintenance window in the "<start>/<end>" format.
+func ParseMaintenanceWindow(value string) (MaintenanceWindow, error) {
+	startValue, endValue, found := strings.Cut(value, "/")
+	if !found {
+		return MaintenanceWindow{}, fmt.Errorf("maintenance window %q is not formatted as \"<start>/<end>\"", value)
+	}
+
+	start, err := time.Parse(time.RFC3339, startValue)
+	if err != nil {
+		return MaintenanceWindow{}, fmt.Errorf("invalid maintenance window start: %w", err)
+	}
+
+	end, err := time.Parse(time.RFC3339, endValue)
+	if err != nil {
+		return MaintenanceWindow{}, fmt.Errorf("invalid maintenance window end: %w", err)
+	}
+
+	if !end.After(start) {
+		return MaintenanceWindow{}, fmt.Errorf("maintenance window %q ends before it starts", value)
+	}
+
+	return MaintenanceWindow{Start: start, End: end}, nil
+}
+
+// GetMaintenanceWindow returns the maintenance window that is declared on the
+// issuer, or nil if the issuer has no maintenance window annotation.
+func GetMaintenanceWindow(issuer Issuer) (*MaintenanceWindow, error) {
+	value, ok := issuer.GetAnnotations()[IssuerMaintenanceWindowAnnotationKey]
+	if !ok {
+		return nil, nil
+	}
+
+	window, err := ParseMaintenanceWindow(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &window, nil
+}
+
+// String returns the maintenance window in the format of the annotation.
+func (w MaintenanceWindow) String() string {
+	return w.Start.Format(time.RFC3339) + "/" + w.End.Format(time.RFC3339)
+}
+
+// Contains returns true if the time is within the maintenance window.
+func (w MaintenanceWindow) Contains(t time.Time) bool {
+	return !t.Before(w.Start) && t.Before(w.End)
+}
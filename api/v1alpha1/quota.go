@@ -0,0 +1,79 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IssuerQuota is the maximum number of certificates that may be issued by an
+// issuer within a period. It is declared using the IssuerQuotaAnnotationKey
+// annotation, formatted as "<limit>/<period>" with the period as a Go duration,
+// eg. "1000/24h".
+// +kubebuilder:object:generate=false
+type IssuerQuota struct {
+	Limit  int
+	Period time.Duration
+}
+
+// ParseIssuerQuota parses an issuer quota in the "<limit>/<period>" format.
+func ParseIssuerQuota(value string) (IssuerQuota, error) {
+	limitValue, periodValue, found := strings.Cut(value, "/")
+	if !found {
+		return IssuerQuota{}, fmt.Errorf("issuer quota %q is not formatted as \"<limit>/<period>\"", value)
+	}
+
+	limit, err := strconv.Atoi(limitValue)
+	if err != nil || limit < 0 {
+		return IssuerQuota{}, fmt.Errorf("invalid issuer quota limit %q", limitValue)
+	}
+
+	period, err := time.ParseDuration(periodValue)
+	if err != nil {
+		return IssuerQuota{}, fmt.Errorf("invalid issuer quota period: %w", err)
+	}
+
+	if period <= 0 {
+		return IssuerQuota{}, fmt.Errorf("issuer quota %q has a period that is not positive", value)
+	}
+
+	return IssuerQuota{Limit: limit, Period: period}, nil
+}
+
+// GetIssuerQuota returns the quota that is declared on the issuer, or nil if
+// the issuer has no quota annotation.
+func GetIssuerQuota(issuer Issuer) (*IssuerQuota, error) {
+	value, ok := issuer.GetAnnotations()[IssuerQuotaAnnotationKey]
+	if !ok {
+		return nil, nil
+	}
+
+	quota, err := ParseIssuerQuota(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &quota, nil
+}
+
+// String returns the issuer quota in the format of the annotation.
+func (q IssuerQuota) String() string {
+	return strconv.Itoa(q.Limit) + "/" + q.Period.String()
+}
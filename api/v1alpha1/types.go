@@ -22,13 +22,116 @@ const (
 	// reconciles a CertificateRequest which does not already have a Ready
 	// condition.
 	CertificateRequestConditionReasonInitializing = "Initializing"
+
+	// CertificateRequestConditionReasonIssuerPaused is the value assigned to
+	// the Reason field of the Ready condition when the issuer referenced by
+	// the CertificateRequest is paused using the IssuerPausedAnnotationKey
+	// annotation.
+	CertificateRequestConditionReasonIssuerPaused = "IssuerPaused"
+
+	// CertificateRequestConditionReasonIssuancePaused is the value assigned to
+	// the Reason field of the Ready condition when the issuance of all requests,
+	// or of the requests for the kind of the referenced issuer, is paused using
+	// the IssuanceControl singleton.
+	CertificateRequestConditionReasonIssuancePaused = "IssuancePaused"
+
+	// CertificateRequestConditionReasonScheduledMaintenance is the value assigned
+	// to the Reason field of the Ready condition when the issuer referenced by
+	// the CertificateRequest is in a maintenance window that was declared using
+	// the IssuerMaintenanceWindowAnnotationKey annotation.
+	CertificateRequestConditionReasonScheduledMaintenance = "ScheduledMaintenance"
+
+	// CertificateRequestConditionReasonIssuerFailed is the value assigned to the
+	// Reason field of the Failed condition of a Kubernetes CertificateSigningRequest
+	// (and of the recorded event) when the issuer referenced by the request failed
+	// permanently. CertificateRequests keep using the "Failed" reason of the Ready
+	// condition, since cert-manager only treats that reason as terminal.
+	CertificateRequestConditionReasonIssuerFailed = "IssuerFailed"
+)
+
+// The reasons of the Ready condition of a CertificateRequest that is pending for
+// a transient reason that requires a different response of the operator than a
+// plain "Pending" request. cert-manager keeps waiting for requests with these
+// reasons, like it does for requests with the "Pending" reason. Requests that
+// wait for a declared maintenance window use the ScheduledMaintenance reason.
+const (
+	// CertificateRequestConditionReasonIssuerRateLimited is the value assigned to
+	// the Reason field of the Ready condition when a request is delayed because the
+	// issuer is rate limited, eg. by the quota of the QuotaPolicy.
+	CertificateRequestConditionReasonIssuerRateLimited = "IssuerRateLimited"
+
+	// CertificateRequestConditionReasonCircuitOpen is the value assigned to the
+	// Reason field of the Ready condition when a request is delayed because the
+	// circuit breaker of the connection to the CA is open after repeated failures.
+	// Sign functions that use a circuit breaker return a signer.PendingError with
+	// this reason.
+	CertificateRequestConditionReasonCircuitOpen = "CircuitOpen"
+
+	// CertificateRequestConditionReasonExternalApprovalPending is the value assigned
+	// to the Reason field of the Ready condition when a request is waiting for the
+	// decision of the external approval system of the ExternalApprovalPolicy.
+	CertificateRequestConditionReasonExternalApprovalPending = "ExternalApprovalPending"
+)
+
+const (
+	// ConditionTypeStatusPatchRejected is the type of the condition that is set
+	// on a request or issuer when the status patches of the controller were
+	// rejected repeatedly (eg. by a validating webhook). The condition is set
+	// using a separate, minimal patch and is removed once the status patches are
+	// accepted again. Its reason is the reason of the last rejection (eg. "Invalid").
+	ConditionTypeStatusPatchRejected = "StatusPatchRejected"
+)
+
+const (
+	// CertificateRequestConditionTypeIgnored is the type of the condition that
+	// is set on a request that was deliberately ignored by the IgnoreCertificateRequest
+	// function, if the IgnoredCertificateRequestReason function is configured.
+	CertificateRequestConditionTypeIgnored = "Ignored"
+)
+
+const (
+	// CertificateRequestConditionTypeChainExpiryRisk is the type of the condition
+	// that is set on an issued request when the chain returned by the Sign function
+	// contains an intermediate certificate that expires before the leaf certificate
+	// or within the window of the ChainExpiryPolicy.
+	CertificateRequestConditionTypeChainExpiryRisk = "ChainExpiryRisk"
+
+	// CertificateRequestConditionReasonIntermediateExpiresBeforeLeaf is the value
+	// assigned to the Reason field of the ChainExpiryRisk condition when an
+	// intermediate certificate expires before the leaf certificate.
+	CertificateRequestConditionReasonIntermediateExpiresBeforeLeaf = "IntermediateExpiresBeforeLeaf"
+
+	// CertificateRequestConditionReasonIntermediateExpiresSoon is the value
+	// assigned to the Reason field of the ChainExpiryRisk condition when an
+	// intermediate certificate expires within the window of the ChainExpiryPolicy.
+	CertificateRequestConditionReasonIntermediateExpiresSoon = "IntermediateExpiresSoon"
+)
+
+const (
+	// CertificateRequestConditionTypeExternalApproval is the type of the condition
+	// that records the decision of the external approval system on a request, if
+	// the ExternalApprovalPolicy is enabled. Its status is Unknown while the
+	// approval is pending, True once the request is approved and False when it
+	// is denied. The message contains the ticket ID of the external system.
+	CertificateRequestConditionTypeExternalApproval = "ExternalApproval"
+
+	// ExternalApprovalDecisionPending, ExternalApprovalDecisionApproved and
+	// ExternalApprovalDecisionDenied are the decisions that the external approval
+	// webhook can return. They are used as the Reason of the ExternalApproval
+	// condition.
+	ExternalApprovalDecisionPending  = "Pending"
+	ExternalApprovalDecisionApproved = "Approved"
+	ExternalApprovalDecisionDenied   = "Denied"
 )
 
 const (
-	// IssuerConditionReasonInitializing is the value assigned to
+	// IssuerConditionReasonInitializing is the value that was assigned to
 	// the Reason field of the Ready condition when issuer-lib first
-	// reconciles an Issuer which does not already have a Ready
+	// reconciled an Issuer which did not already have a Ready
 	// condition.
+	//
+	// Deprecated: the first reconcile of an Issuer sets the Ready condition
+	// to the result of the checks directly, this reason is no longer used.
 	IssuerConditionReasonInitializing = "Initializing"
 
 	IssuerConditionReasonPending = "Pending"
@@ -36,4 +139,129 @@ const (
 	IssuerConditionReasonChecked = "Checked"
 
 	IssuerConditionReasonFailed = "Failed"
+
+	// IssuerConditionReasonCredentialsInvalid is the value assigned to the
+	// Reason field of the Ready condition when the credentials used to connect
+	// to the CA were rejected (eg. an expired token or a revoked client
+	// certificate).
+	IssuerConditionReasonCredentialsInvalid = "CredentialsInvalid"
+
+	// IssuerConditionReasonEndpointUnreachable is the value assigned to the
+	// Reason field of the Ready condition when the CA could not be reached
+	// (eg. a DNS, connection or TLS handshake error).
+	IssuerConditionReasonEndpointUnreachable = "EndpointUnreachable"
+
+	// IssuerConditionReasonQuotaExceeded is the value assigned to the Reason
+	// field of the Ready condition when the CA refuses to issue certificates
+	// because a quota or rate limit was exceeded.
+	IssuerConditionReasonQuotaExceeded = "QuotaExceeded"
+
+	// IssuerConditionReasonConfigurationError is the value assigned to the
+	// Reason field of the Ready condition when the issuer resource contains an
+	// invalid configuration. Like IssuerConditionReasonFailed, it marks the
+	// issuer as permanently failed until the issuer resource is changed.
+	IssuerConditionReasonConfigurationError = "ConfigurationError"
+)
+
+const (
+	// IssuerPausedAnnotationKey is the annotation that can be set to "true" on
+	// an issuer to pause it, e.g. during a CA outage or maintenance. While the
+	// issuer is paused, the Check function is not called for the issuer and
+	// the Sign function is not called for requests that reference the issuer.
+	IssuerPausedAnnotationKey = "issuer-lib.cert-manager.io/paused"
+
+	// IssuerMaintenanceWindowAnnotationKey is the annotation that can be set on
+	// an issuer to declare a window of planned CA downtime (see MaintenanceWindow).
+	// During the window, the Check function is not called for the issuer and the
+	// Sign function is not called for requests that reference the issuer. Once the
+	// window ends, the issuer and its requests are reconciled again automatically.
+	IssuerMaintenanceWindowAnnotationKey = "issuer-lib.cert-manager.io/maintenance-window"
+
+	// IssuerQuotaAnnotationKey is the annotation that can be set on an issuer to
+	// limit the number of certificates that are issued within a period (see
+	// IssuerQuota), if the QuotaPolicy option is enabled.
+	IssuerQuotaAnnotationKey = "issuer-lib.cert-manager.io/quota"
+
+	// IssuerSecretHashAnnotationKey is the annotation that is set on an issuer
+	// to a hash of the contents of the Secrets that the issuer depends on. It is
+	// updated when one of these Secrets changes, which triggers the Check function.
+	IssuerSecretHashAnnotationKey = "issuer-lib.cert-manager.io/secret-hash"
+
+	// RequestPriorityClassAnnotationKey is the annotation that can be set to
+	// "high" or "low" on a request to change the order in which it is reconciled,
+	// if the controller uses the PriorityClassFromAnnotation function.
+	RequestPriorityClassAnnotationKey = "issuer-lib.cert-manager.io/priority-class"
+
+	// RequestTimeInStateAnnotationKey is the annotation that is set on a request
+	// when it transitions to Issued or Failed, if the SetTimeInStateAnnotation option
+	// is enabled. Its value is a JSON object containing the outcome and the number
+	// of seconds that the request spent in the Initializing and Pending states, eg.
+	// {"outcome":"Issued","initializingSeconds":1.5,"pendingSeconds":30}.
+	RequestTimeInStateAnnotationKey = "issuer-lib.cert-manager.io/time-in-state"
+
+	// RequestOCSPServerAnnotationKey and RequestCRLDistributionPointAnnotationKey
+	// are the annotations that are set on a request to the revocation endpoints of
+	// the signed certificate, if the Sign function recorded them using the
+	// signer.WithRevocationInfo option.
+	RequestOCSPServerAnnotationKey           = "issuer-lib.cert-manager.io/ocsp-server"
+	RequestCRLDistributionPointAnnotationKey = "issuer-lib.cert-manager.io/crl-distribution-point"
+
+	// IssuerRevocationInfoAnnotationKey is the annotation that is set on an issuer
+	// to a JSON object containing all revocation endpoints that were recorded for
+	// its requests, if the AggregateRevocationInfo option is enabled, eg.
+	// {"ocspServers":["http://ocsp.example.com"],"crlDistributionPoints":["http://crl.example.com/ca.crl"]}.
+	IssuerRevocationInfoAnnotationKey = "issuer-lib.cert-manager.io/revocation-info"
+
+	// RequestIssuanceClaimAnnotationKey is the annotation that a controller sets
+	// on a request before calling the Sign function for it, if the IssuanceClaimPolicy
+	// option is enabled (see IssuanceClaim). Other controller replicas do not call
+	// the Sign function for the request until the claim has expired.
+	RequestIssuanceClaimAnnotationKey = "issuer-lib.cert-manager.io/issuance-claim"
+
+	// RequestSignedByAnnotationKey and RequestSignedByVersionAnnotationKey are the
+	// annotations that are set on a request to the name and the version of the
+	// controller instance that called the Sign function for it, if the
+	// ProvenancePolicy option is set. RequestSignedByIssuerLibVersionAnnotationKey
+	// is set to the issuer-lib version of that controller, if the policy includes it.
+	RequestSignedByAnnotationKey                 = "issuer-lib.cert-manager.io/signed-by"
+	RequestSignedByVersionAnnotationKey          = "issuer-lib.cert-manager.io/signed-by-version"
+	RequestSignedByIssuerLibVersionAnnotationKey = "issuer-lib.cert-manager.io/signed-by-issuer-lib-version"
+
+	// RequestIssuerSnapshotAnnotationKey is the annotation that is set on a
+	// request when it fails permanently, if the IssuerSnapshotPolicy option is
+	// set. Its value is a JSON object containing the identity and generation of
+	// the issuer and the selected fields of the issuer at the time of the
+	// failure, eg. {"group":"example.com","kind":"MyIssuer","name":"my-issuer",
+	// "uid":"...","generation":3,"resourceVersion":"1234","fields":{"spec.url":"https://ca.example.com"}}.
+	RequestIssuerSnapshotAnnotationKey = "issuer-lib.cert-manager.io/issuer-snapshot"
+
+	// RequestCorrelationIDAnnotationKey is the annotation that contains the
+	// correlation ID of a request, if the EnableCorrelationIDs option is set. The
+	// controllers generate the ID when they first reconcile the request, unless it
+	// was already set (eg. to the ID of a workflow that created the request). The
+	// ID is added to the logs and events of the request and to the context that
+	// is passed to the Sign function.
+	RequestCorrelationIDAnnotationKey = "issuer-lib.cert-manager.io/correlation-id"
+
+	// RequestOptionAnnotationPrefix is the prefix of the annotations that can be
+	// set on a request to pass options to the Sign function, if the
+	// RequestOptionsPolicy option is set. Eg. the annotation
+	// "issuer-lib.cert-manager.io/option.profile" sets the option "profile".
+	RequestOptionAnnotationPrefix = "issuer-lib.cert-manager.io/option."
+
+	// InjectCAFromIssuerAnnotationKey is the annotation that can be set on a
+	// ValidatingWebhookConfiguration, MutatingWebhookConfiguration,
+	// CustomResourceDefinition or APIService to keep its caBundle fields updated
+	// with the CA bundle of an issuer, if the cabundle.Injector is running. The
+	// value is "<issuer type identifier>/<namespace>/<name>" for namespaced
+	// issuers and "<issuer type identifier>/<name>" for cluster-scoped issuers,
+	// eg. "simpleclusterissuers.issuer.cert-manager.io/my-issuer".
+	InjectCAFromIssuerAnnotationKey = "issuer-lib.cert-manager.io/inject-ca-from-issuer"
+
+	// RequestIssuerOverrideAnnotationKey is the annotation that can be set on a
+	// request to route it to a different issuer than the one it references, if
+	// the AllowIssuerOverride option is enabled. The value has the same format as
+	// the InjectCAFromIssuerAnnotationKey annotation; namespaced issuers must be in
+	// the namespace of the request.
+	RequestIssuerOverrideAnnotationKey = "issuer-lib.cert-manager.io/issuer-override"
 )
@@ -22,8 +22,102 @@ package v1alpha1
 
 import (
 	"github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IssuanceControl) DeepCopyInto(out *IssuanceControl) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IssuanceControl.
+func (in *IssuanceControl) DeepCopy() *IssuanceControl {
+	if in == nil {
+		return nil
+	}
+	out := new(IssuanceControl)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *IssuanceControl) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IssuanceControlList) DeepCopyInto(out *IssuanceControlList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]IssuanceControl, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IssuanceControlList.
+func (in *IssuanceControlList) DeepCopy() *IssuanceControlList {
+	if in == nil {
+		return nil
+	}
+	out := new(IssuanceControlList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *IssuanceControlList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IssuanceControlSpec) DeepCopyInto(out *IssuanceControlSpec) {
+	*out = *in
+	if in.PausedIssuerKinds != nil {
+		in, out := &in.PausedIssuerKinds, &out.PausedIssuerKinds
+		*out = make([]IssuerKind, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IssuanceControlSpec.
+func (in *IssuanceControlSpec) DeepCopy() *IssuanceControlSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(IssuanceControlSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IssuerKind) DeepCopyInto(out *IssuerKind) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IssuerKind.
+func (in *IssuerKind) DeepCopy() *IssuerKind {
+	if in == nil {
+		return nil
+	}
+	out := new(IssuerKind)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IssuerStatus) DeepCopyInto(out *IssuerStatus) {
 	*out = *in
@@ -45,3 +139,19 @@ func (in *IssuerStatus) DeepCopy() *IssuerStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IssuerStatusHolder) DeepCopyInto(out *IssuerStatusHolder) {
+	*out = *in
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IssuerStatusHolder.
+func (in *IssuerStatusHolder) DeepCopy() *IssuerStatusHolder {
+	if in == nil {
+		return nil
+	}
+	out := new(IssuerStatusHolder)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,308 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cabundle
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/internal/kubeutil"
+)
+
+// InjectionTarget is a kind of resource that contains caBundle fields that can
+// be kept updated by the Injector.
+type InjectionTarget struct {
+	GroupVersionKind schema.GroupVersionKind
+
+	// SetCABundle sets the caBundle fields of the resource to the base64
+	// encoded CA bundle. Resources without caBundle fields are left unchanged.
+	SetCABundle func(obj *unstructured.Unstructured, caBundle string) error
+}
+
+var (
+	// ValidatingWebhookConfigurationTarget injects the CA bundle into the
+	// clientConfig of all webhooks of a ValidatingWebhookConfiguration.
+	ValidatingWebhookConfigurationTarget = InjectionTarget{
+		GroupVersionKind: admissionregistrationv1.SchemeGroupVersion.WithKind("ValidatingWebhookConfiguration"),
+		SetCABundle:      setWebhooksCABundle,
+	}
+
+	// MutatingWebhookConfigurationTarget injects the CA bundle into the
+	// clientConfig of all webhooks of a MutatingWebhookConfiguration.
+	MutatingWebhookConfigurationTarget = InjectionTarget{
+		GroupVersionKind: admissionregistrationv1.SchemeGroupVersion.WithKind("MutatingWebhookConfiguration"),
+		SetCABundle:      setWebhooksCABundle,
+	}
+
+	// CustomResourceDefinitionTarget injects the CA bundle into the conversion
+	// webhook clientConfig of a CustomResourceDefinition that uses the Webhook
+	// conversion strategy.
+	CustomResourceDefinitionTarget = InjectionTarget{
+		GroupVersionKind: apiextensionsv1.SchemeGroupVersion.WithKind("CustomResourceDefinition"),
+		SetCABundle:      setConversionWebhookCABundle,
+	}
+
+	// APIServiceTarget injects the CA bundle into an APIService that does not
+	// set insecureSkipTLSVerify.
+	APIServiceTarget = InjectionTarget{
+		GroupVersionKind: schema.GroupVersionKind{Group: "apiregistration.k8s.io", Version: "v1", Kind: "APIService"},
+		SetCABundle:      setAPIServiceCABundle,
+	}
+)
+
+// DefaultInjectionTargets are the kinds of resources that are injected by the
+// Injector if its Targets are not set.
+var DefaultInjectionTargets = []InjectionTarget{
+	ValidatingWebhookConfigurationTarget,
+	MutatingWebhookConfigurationTarget,
+	CustomResourceDefinitionTarget,
+	APIServiceTarget,
+}
+
+func setWebhooksCABundle(obj *unstructured.Unstructured, caBundle string) error {
+	webhooks, found, err := unstructured.NestedSlice(obj.Object, "webhooks")
+	if err != nil || !found {
+		return err
+	}
+
+	for i, webhook := range webhooks {
+		webhookObject, ok := webhook.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("webhook %d is not an object", i)
+		}
+		if err := unstructured.SetNestedField(webhookObject, caBundle, "clientConfig", "caBundle"); err != nil {
+			return err
+		}
+	}
+
+	return unstructured.SetNestedSlice(obj.Object, webhooks, "webhooks")
+}
+
+func setConversionWebhookCABundle(obj *unstructured.Unstructured, caBundle string) error {
+	strategy, _, err := unstructured.NestedString(obj.Object, "spec", "conversion", "strategy")
+	if err != nil || strategy != string(apiextensionsv1.WebhookConverter) {
+		return err
+	}
+
+	return unstructured.SetNestedField(obj.Object, caBundle, "spec", "conversion", "webhook", "clientConfig", "caBundle")
+}
+
+func setAPIServiceCABundle(obj *unstructured.Unstructured, caBundle string) error {
+	// The caBundle field cannot be set together with insecureSkipTLSVerify.
+	insecureSkipTLSVerify, _, err := unstructured.NestedBool(obj.Object, "spec", "insecureSkipTLSVerify")
+	if err != nil || insecureSkipTLSVerify {
+		return err
+	}
+
+	return unstructured.SetNestedField(obj.Object, caBundle, "spec", "caBundle")
+}
+
+// Injector keeps the caBundle fields of the resources that have the
+// v1alpha1.InjectCAFromIssuerAnnotationKey annotation updated with the CA
+// bundle of the referenced issuer, similar to the cert-manager cainjector. The
+// CA bundle is only injected once the issuer is ready, and is updated when the
+// issuer resource changes.
+//
+// The manager needs RBAC permissions to watch and patch the resources of the
+// Targets.
+type Injector struct {
+	IssuerTypes        []v1alpha1.Issuer
+	ClusterIssuerTypes []v1alpha1.Issuer
+
+	// Targets are the kinds of resources that are injected, defaults to
+	// DefaultInjectionTargets.
+	Targets []InjectionTarget
+
+	// Client is used to read the issuer and target resources and to patch the
+	// target resources.
+	Client client.Client
+	// CABundle returns the CA bundle of an issuer.
+	CABundle CABundle
+}
+
+// SetupWithManager sets up a controller for each of the Targets with the Manager.
+func (i *Injector) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
+	targets := i.Targets
+	if len(targets) == 0 {
+		targets = DefaultInjectionTargets
+	}
+
+	for _, target := range targets {
+		if err := (&injectionTargetReconciler{
+			Injector: i,
+			target:   target,
+			reader:   mgr.GetCache(),
+		}).setupWithManager(ctx, mgr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// injectCAFromIssuerIndex is the name of the index of the target resources on
+// the value of their InjectCAFromIssuerAnnotationKey annotation.
+const injectCAFromIssuerIndex = ".metadata.annotations.inject-ca-from-issuer"
+
+type injectionTargetReconciler struct {
+	*Injector
+	target InjectionTarget
+
+	// reader is used to list the target resources using the injectCAFromIssuerIndex.
+	reader client.Reader
+}
+
+func (r *injectionTargetReconciler) newTarget() *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(r.target.GroupVersionKind)
+	return obj
+}
+
+func (r *injectionTargetReconciler) setupWithManager(ctx context.Context, mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(ctx, r.newTarget(), injectCAFromIssuerIndex, injectCAFromIssuerIndexValue); err != nil {
+		return err
+	}
+
+	build := ctrl.NewControllerManagedBy(mgr).
+		Named("cainjector-"+strings.ToLower(r.target.GroupVersionKind.Kind)).
+		For(
+			r.newTarget(),
+			builder.WithPredicates(predicate.NewPredicateFuncs(func(obj client.Object) bool {
+				return injectCAFromIssuerIndexValue(obj) != nil
+			})),
+		)
+
+	for _, issuerType := range append(append([]v1alpha1.Issuer{}, r.IssuerTypes...), r.ClusterIssuerTypes...) {
+		if err := kubeutil.SetGroupVersionKind(mgr.GetScheme(), issuerType); err != nil {
+			return err
+		}
+
+		build = build.Watches(
+			issuerType,
+			handler.EnqueueRequestsFromMapFunc(r.targetsForIssuer(issuerType.GetIssuerTypeIdentifier())),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
+		)
+	}
+
+	return build.Complete(r)
+}
+
+func injectCAFromIssuerIndexValue(obj client.Object) []string {
+	ref := strings.TrimSpace(obj.GetAnnotations()[v1alpha1.InjectCAFromIssuerAnnotationKey])
+	if ref == "" {
+		return nil
+	}
+	return []string{ref}
+}
+
+// targetsForIssuer returns a handler.MapFunc which returns the target resources
+// that reference the issuer.
+func (r *injectionTargetReconciler) targetsForIssuer(issuerTypeIdentifier string) handler.MapFunc {
+	return func(ctx context.Context, issuerObject client.Object) []reconcile.Request {
+		ref := issuerTypeIdentifier + "/" + issuerObject.GetName()
+		if issuerObject.GetNamespace() != "" {
+			ref = issuerTypeIdentifier + "/" + issuerObject.GetNamespace() + "/" + issuerObject.GetName()
+		}
+
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(r.target.GroupVersionKind.GroupVersion().WithKind(r.target.GroupVersionKind.Kind + "List"))
+		if err := r.reader.List(ctx, list, &client.ListOptions{
+			FieldSelector: fields.OneTermEqualSelector(injectCAFromIssuerIndex, ref),
+		}); err != nil {
+			log.FromContext(ctx).Error(err, "While listing the resources to inject the CA bundle of the issuer into", "issuer", ref)
+			return nil
+		}
+
+		requests := make([]reconcile.Request, 0, len(list.Items))
+		for _, item := range list.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&item)})
+		}
+		return requests
+	}
+}
+
+func (r *injectionTargetReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	logger := log.FromContext(ctx)
+
+	obj := r.newTarget()
+	if err := r.Client.Get(ctx, req.NamespacedName, obj); apierrors.IsNotFound(err) {
+		return reconcile.Result{}, nil // done
+	} else if err != nil {
+		return reconcile.Result{}, fmt.Errorf("unexpected get error: %v", err) // requeue with backoff
+	}
+
+	refs := injectCAFromIssuerIndexValue(obj)
+	if len(refs) == 0 {
+		return reconcile.Result{}, nil // done
+	}
+
+	issuerObject, issuerName, ok := lookupIssuer(r.IssuerTypes, r.ClusterIssuerTypes, strings.Split(refs[0], "/"))
+	if !ok {
+		logger.V(1).Info("Annotation does not reference a known issuer type. Ignoring.", "issuer", refs[0])
+		return reconcile.Result{}, nil // done
+	}
+
+	if err := r.Client.Get(ctx, issuerName, issuerObject); apierrors.IsNotFound(err) {
+		logger.V(1).Info("Issuer not found. Waiting for it to be created.", "issuer", refs[0])
+		return reconcile.Result{}, nil // done, the issuer watch triggers a new reconcile
+	} else if err != nil {
+		return reconcile.Result{}, fmt.Errorf("unexpected get error: %v", err) // requeue with backoff
+	}
+
+	if !isIssuerReady(issuerObject) {
+		logger.V(1).Info("Issuer is not Ready yet. Waiting for it to become ready.", "issuer", refs[0])
+		return reconcile.Result{}, nil // done, the issuer watch triggers a new reconcile
+	}
+
+	bundle, err := r.CABundle(ctx, issuerObject)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to get CA bundle: %w", err) // requeue with backoff
+	}
+
+	patched := obj.DeepCopy()
+	if err := r.target.SetCABundle(patched, base64.StdEncoding.EncodeToString(bundle)); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to set CA bundle: %w", err) // requeue with backoff
+	}
+	if equality.Semantic.DeepEqual(obj, patched) {
+		return reconcile.Result{}, nil // done
+	}
+
+	logger.V(1).Info("Injecting CA bundle.", "issuer", refs[0])
+	if err := r.Client.Patch(ctx, patched, client.MergeFromWithOptions(obj, client.MergeFromWithOptimisticLock{})); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to patch CA bundle: %w", err) // requeue with backoff
+	}
+
+	return reconcile.Result{}, nil // done
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cabundle
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+	"github.com/cert-manager/issuer-lib/internal/testapi/testutil"
+)
+
+func TestInjector(t *testing.T) {
+	t.Parallel()
+
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+
+	readyIssuer := testutil.TestIssuer(
+		"ready-issuer",
+		testutil.SetTestIssuerNamespace("ns1"),
+		testutil.SetTestIssuerStatusCondition(
+			fakeClock,
+			cmapi.IssuerConditionReady,
+			cmmeta.ConditionTrue,
+			v1alpha1.IssuerConditionReasonChecked,
+			"Succeeded checking the issuer",
+		),
+	)
+
+	notReadyIssuer := testutil.TestIssuer(
+		"not-ready-issuer",
+		testutil.SetTestIssuerNamespace("ns1"),
+		testutil.SetTestIssuerStatusCondition(
+			fakeClock,
+			cmapi.IssuerConditionReady,
+			cmmeta.ConditionFalse,
+			v1alpha1.IssuerConditionReasonPending,
+			"Not ready yet",
+		),
+	)
+
+	webhookConfiguration := func(name string, issuerRef string) *admissionregistrationv1.ValidatingWebhookConfiguration {
+		webhookConfiguration := &admissionregistrationv1.ValidatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Webhooks: []admissionregistrationv1.ValidatingWebhook{
+				{Name: "a.example.com", ClientConfig: admissionregistrationv1.WebhookClientConfig{CABundle: []byte("old")}},
+				{Name: "b.example.com"},
+			},
+		}
+		if issuerRef != "" {
+			webhookConfiguration.Annotations = map[string]string{v1alpha1.InjectCAFromIssuerAnnotationKey: issuerRef}
+		}
+		return webhookConfiguration
+	}
+
+	injected := webhookConfiguration("injected", "testissuers.testing.cert-manager.io/ns1/ready-issuer")
+	waiting := webhookConfiguration("waiting", "testissuers.testing.cert-manager.io/ns1/not-ready-issuer")
+	unknown := webhookConfiguration("unknown", "unknown.example.com/ns1/ready-issuer")
+	notAnnotated := webhookConfiguration("not-annotated", "")
+
+	crd := &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "foos.example.com",
+			Annotations: map[string]string{v1alpha1.InjectCAFromIssuerAnnotationKey: "testissuers.testing.cert-manager.io/ns1/ready-issuer"},
+		},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Conversion: &apiextensionsv1.CustomResourceConversion{
+				Strategy: apiextensionsv1.WebhookConverter,
+				Webhook: &apiextensionsv1.WebhookConversion{
+					ClientConfig:             &apiextensionsv1.WebhookClientConfig{},
+					ConversionReviewVersions: []string{"v1"},
+				},
+			},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, apiextensionsv1.AddToScheme(scheme))
+	require.NoError(t, api.AddToScheme(scheme))
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(readyIssuer, notReadyIssuer, injected, waiting, unknown, notAnnotated, crd).
+		WithIndex(injected, injectCAFromIssuerIndex, injectCAFromIssuerIndexValue).
+		Build()
+
+	injector := &Injector{
+		IssuerTypes:        []v1alpha1.Issuer{&api.TestIssuer{}},
+		ClusterIssuerTypes: []v1alpha1.Issuer{&api.TestClusterIssuer{}},
+		Client:             fakeClient,
+		CABundle: func(_ context.Context, issuerObject v1alpha1.Issuer) ([]byte, error) {
+			return []byte(fmt.Sprintf("ca-of-%s", issuerObject.GetName())), nil
+		},
+	}
+
+	webhookReconciler := &injectionTargetReconciler{Injector: injector, target: ValidatingWebhookConfigurationTarget, reader: fakeClient}
+	crdReconciler := &injectionTargetReconciler{Injector: injector, target: CustomResourceDefinitionTarget, reader: fakeClient}
+
+	reconcileTarget := func(r *injectionTargetReconciler, obj client.Object) {
+		t.Helper()
+
+		result, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(obj)})
+		require.NoError(t, err)
+		assert.Equal(t, reconcile.Result{}, result)
+	}
+
+	caBundles := func(obj *admissionregistrationv1.ValidatingWebhookConfiguration) []string {
+		t.Helper()
+
+		current := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+		require.NoError(t, fakeClient.Get(context.TODO(), client.ObjectKeyFromObject(obj), current))
+
+		var caBundles []string
+		for _, webhook := range current.Webhooks {
+			caBundles = append(caBundles, string(webhook.ClientConfig.CABundle))
+		}
+		return caBundles
+	}
+
+	for _, obj := range []client.Object{injected, waiting, unknown, notAnnotated} {
+		reconcileTarget(webhookReconciler, obj)
+	}
+	reconcileTarget(webhookReconciler, &admissionregistrationv1.ValidatingWebhookConfiguration{ObjectMeta: metav1.ObjectMeta{Name: "missing"}})
+	reconcileTarget(crdReconciler, crd)
+
+	assert.Equal(t, []string{"ca-of-ready-issuer", "ca-of-ready-issuer"}, caBundles(injected))
+	assert.Equal(t, []string{"old", ""}, caBundles(waiting))
+	assert.Equal(t, []string{"old", ""}, caBundles(unknown))
+	assert.Equal(t, []string{"old", ""}, caBundles(notAnnotated))
+
+	currentCRD := &apiextensionsv1.CustomResourceDefinition{}
+	require.NoError(t, fakeClient.Get(context.TODO(), client.ObjectKeyFromObject(crd), currentCRD))
+	assert.Equal(t, "ca-of-ready-issuer", string(currentCRD.Spec.Conversion.Webhook.ClientConfig.CABundle))
+
+	// Events for an issuer are mapped to the resources that reference it.
+	assert.Equal(t,
+		[]reconcile.Request{{NamespacedName: client.ObjectKeyFromObject(waiting)}},
+		webhookReconciler.targetsForIssuer("testissuers.testing.cert-manager.io")(context.TODO(), notReadyIssuer),
+	)
+	assert.Empty(t, webhookReconciler.targetsForIssuer("testclusterissuers.testing.cert-manager.io")(context.TODO(), &api.TestClusterIssuer{
+		ObjectMeta: metav1.ObjectMeta{Name: "ready-issuer"},
+	}))
+}
+
+func TestSetAPIServiceCABundle(t *testing.T) {
+	t.Parallel()
+
+	apiService := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"service": map[string]interface{}{"name": "api"}},
+	}}
+	require.NoError(t, setAPIServiceCABundle(apiService, "Y2E="))
+	caBundle, _, _ := unstructured.NestedString(apiService.Object, "spec", "caBundle")
+	assert.Equal(t, "Y2E=", caBundle)
+
+	insecureAPIService := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"insecureSkipTLSVerify": true},
+	}}
+	require.NoError(t, setAPIServiceCABundle(insecureAPIService, "Y2E="))
+	_, found, _ := unstructured.NestedString(insecureAPIService.Object, "spec", "caBundle")
+	assert.False(t, found)
+}
@@ -0,0 +1,272 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cabundle serves the CA bundles of ready issuers over HTTPS, for
+// bootstrapping nodes and external systems that cannot read Kubernetes Secrets
+// or ConfigMaps.
+//
+// The CA bundle of a namespaced issuer is served at
+// "/<issuer type identifier>/<namespace>/<name>/ca.pem" and the CA bundle of a
+// cluster-scoped issuer at "/<issuer type identifier>/<name>/ca.pem", where the
+// issuer type identifier is the value returned by GetIssuerTypeIdentifier
+// (eg. "simpleclusterissuers.issuer.cert-manager.io").
+//
+// The package also contains an Injector, which keeps the caBundle fields of
+// webhook configurations, CRDs and APIServices updated with the CA bundle of an
+// issuer, without depending on the cert-manager cainjector.
+package cabundle
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+)
+
+// CABundle returns the PEM encoded CA bundle of the issuer.
+type CABundle func(ctx context.Context, issuerObject v1alpha1.Issuer) ([]byte, error)
+
+// Handler is a http.Handler that serves the CA bundles of ready issuers. The
+// CA bundle of an issuer is cached until the issuer resource changes.
+type Handler struct {
+	IssuerTypes        []v1alpha1.Issuer
+	ClusterIssuerTypes []v1alpha1.Issuer
+
+	// Client is used to read the issuer resources.
+	Client client.Reader
+	// CABundle returns the CA bundle of an issuer.
+	CABundle CABundle
+
+	// MaxAge is the duration that clients are allowed to cache the CA
+	// bundle for, defaults to 5 minutes.
+	MaxAge time.Duration
+
+	mu    sync.Mutex
+	cache map[cacheKey]cacheEntry
+}
+
+var _ http.Handler = &Handler{}
+
+type cacheKey struct {
+	issuerTypeIdentifier string
+	namespacedName       types.NamespacedName
+}
+
+type cacheEntry struct {
+	uid             types.UID
+	resourceVersion string
+	bundle          []byte
+	etag            string
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	issuerObject, key, ok := h.parsePath(r.URL.Path)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	entry, status, err := h.getCABundle(r.Context(), issuerObject, key)
+	if err != nil {
+		log.FromContext(r.Context()).V(1).Info("Failed to serve CA bundle", "issuer", key.namespacedName, "error", err.Error())
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	maxAge := h.MaxAge
+	if maxAge == 0 {
+		maxAge = 5 * time.Minute
+	}
+
+	w.Header().Set("ETag", entry.etag)
+	w.Header().Set("Cache-Control", "max-age="+strconv.Itoa(int(maxAge.Seconds())))
+	if r.Header.Get("If-None-Match") == entry.etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-pem-file")
+	w.Header().Set("Content-Length", strconv.Itoa(len(entry.bundle)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodGet {
+		_, _ = w.Write(entry.bundle)
+	}
+}
+
+// parsePath returns an empty issuer object of the type matching the path and the
+// cache key of the issuer.
+func (h *Handler) parsePath(path string) (v1alpha1.Issuer, cacheKey, bool) {
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(segments) < 3 || segments[len(segments)-1] != "ca.pem" {
+		return nil, cacheKey{}, false
+	}
+
+	issuerObject, namespacedName, ok := lookupIssuer(h.IssuerTypes, h.ClusterIssuerTypes, segments[:len(segments)-1])
+	if !ok {
+		return nil, cacheKey{}, false
+	}
+
+	return issuerObject, cacheKey{
+		issuerTypeIdentifier: segments[0],
+		namespacedName:       namespacedName,
+	}, true
+}
+
+// lookupIssuer returns an empty issuer object of the type matching the issuer
+// reference and the namespaced name of the issuer. The reference consists of
+// the segments "<issuer type identifier>/<namespace>/<name>" for namespaced
+// issuers and "<issuer type identifier>/<name>" for cluster-scoped issuers.
+func lookupIssuer(issuerTypes, clusterIssuerTypes []v1alpha1.Issuer, segments []string) (v1alpha1.Issuer, types.NamespacedName, bool) {
+	if len(segments) == 0 {
+		return nil, types.NamespacedName{}, false
+	}
+
+	identifier := segments[0]
+	switch len(segments) {
+	case 3:
+		for _, issuerType := range issuerTypes {
+			if issuerType.GetIssuerTypeIdentifier() == identifier {
+				return issuerType.DeepCopyObject().(v1alpha1.Issuer), types.NamespacedName{Namespace: segments[1], Name: segments[2]}, true
+			}
+		}
+	case 2:
+		for _, issuerType := range clusterIssuerTypes {
+			if issuerType.GetIssuerTypeIdentifier() == identifier {
+				return issuerType.DeepCopyObject().(v1alpha1.Issuer), types.NamespacedName{Name: segments[1]}, true
+			}
+		}
+	}
+
+	return nil, types.NamespacedName{}, false
+}
+
+// isIssuerReady returns true if the issuer has an up-to-date Ready condition
+// with status True.
+func isIssuerReady(issuerObject v1alpha1.Issuer) bool {
+	return issuerObject.GetStatus().IsReady(issuerObject.GetGeneration())
+}
+
+// getCABundle returns the CA bundle of the issuer, or an error and the HTTP
+// status code that should be returned to the client.
+func (h *Handler) getCABundle(ctx context.Context, issuerObject v1alpha1.Issuer, key cacheKey) (cacheEntry, int, error) {
+	if err := h.Client.Get(ctx, key.namespacedName, issuerObject); apierrors.IsNotFound(err) {
+		return cacheEntry{}, http.StatusNotFound, err
+	} else if err != nil {
+		return cacheEntry{}, http.StatusInternalServerError, err
+	}
+
+	if !isIssuerReady(issuerObject) {
+		return cacheEntry{}, http.StatusServiceUnavailable, errors.New("issuer is not ready")
+	}
+
+	h.mu.Lock()
+	entry, ok := h.cache[key]
+	h.mu.Unlock()
+	if ok && entry.uid == issuerObject.GetUID() && entry.resourceVersion == issuerObject.GetResourceVersion() {
+		return entry, http.StatusOK, nil
+	}
+
+	bundle, err := h.CABundle(ctx, issuerObject)
+	if err != nil {
+		return cacheEntry{}, http.StatusServiceUnavailable, fmt.Errorf("failed to get CA bundle: %w", err)
+	}
+
+	hash := sha256.Sum256(bundle)
+	entry = cacheEntry{
+		uid:             issuerObject.GetUID(),
+		resourceVersion: issuerObject.GetResourceVersion(),
+		bundle:          bundle,
+		etag:            `"` + hex.EncodeToString(hash[:]) + `"`,
+	}
+
+	h.mu.Lock()
+	if h.cache == nil {
+		h.cache = map[cacheKey]cacheEntry{}
+	}
+	h.cache[key] = entry
+	h.mu.Unlock()
+
+	return entry, http.StatusOK, nil
+}
+
+// Server is a manager.Runnable that serves the Handler over HTTPS.
+type Server struct {
+	// BindAddress is the address the server listens on, eg. ":8443".
+	BindAddress string
+	// TLSConfig is the TLS configuration of the server, it must contain
+	// a certificate (eg. using the Certificates or GetCertificate field).
+	TLSConfig *tls.Config
+
+	Handler *Handler
+}
+
+var _ manager.Runnable = &Server{}
+var _ manager.LeaderElectionRunnable = &Server{}
+
+// Start serves the CA bundles until the context is cancelled.
+func (s *Server) Start(ctx context.Context) error {
+	listener, err := tls.Listen("tcp", s.BindAddress, s.TLSConfig)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{
+		Handler:           s.Handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext: func(net.Listener) context.Context {
+			return ctx
+		},
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// NeedLeaderElection returns false, all replicas serve the CA bundles.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cabundle
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+	"github.com/cert-manager/issuer-lib/internal/testapi/testutil"
+)
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+
+	readyIssuer := testutil.TestIssuer(
+		"ready-issuer",
+		testutil.SetTestIssuerNamespace("ns1"),
+		testutil.SetTestIssuerGeneration(2),
+		testutil.SetTestIssuerStatusCondition(
+			fakeClock,
+			cmapi.IssuerConditionReady,
+			cmmeta.ConditionTrue,
+			v1alpha1.IssuerConditionReasonChecked,
+			"Succeeded checking the issuer",
+		),
+	)
+
+	notReadyIssuer := testutil.TestIssuer(
+		"not-ready-issuer",
+		testutil.SetTestIssuerNamespace("ns1"),
+		testutil.SetTestIssuerStatusCondition(
+			fakeClock,
+			cmapi.IssuerConditionReady,
+			cmmeta.ConditionFalse,
+			v1alpha1.IssuerConditionReasonPending,
+			"Not ready yet",
+		),
+	)
+
+	readyClusterIssuer := testutil.TestClusterIssuer(
+		"ready-cluster-issuer",
+		testutil.SetTestClusterIssuerStatusCondition(
+			fakeClock,
+			cmapi.IssuerConditionReady,
+			cmmeta.ConditionTrue,
+			v1alpha1.IssuerConditionReasonChecked,
+			"Succeeded checking the issuer",
+		),
+	)
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, api.AddToScheme(scheme))
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(readyIssuer, notReadyIssuer, readyClusterIssuer).
+		Build()
+
+	calls := 0
+	handler := &Handler{
+		IssuerTypes:        []v1alpha1.Issuer{&api.TestIssuer{}},
+		ClusterIssuerTypes: []v1alpha1.Issuer{&api.TestClusterIssuer{}},
+		Client:             fakeClient,
+		CABundle: func(_ context.Context, issuerObject v1alpha1.Issuer) ([]byte, error) {
+			calls++
+			return []byte(fmt.Sprintf("ca-of-%s", issuerObject.GetName())), nil
+		},
+		MaxAge: time.Minute,
+	}
+
+	get := func(path string, etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("/testissuers.testing.cert-manager.io/ns1/ready-issuer/ca.pem", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "ca-of-ready-issuer", rec.Body.String())
+	assert.Equal(t, "max-age=60", rec.Header().Get("Cache-Control"))
+	etag := rec.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	// A request with a matching ETag is answered without a body, and the
+	// cached CA bundle is used as long as the issuer did not change.
+	rec = get("/testissuers.testing.cert-manager.io/ns1/ready-issuer/ca.pem", etag)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+	assert.Equal(t, 1, calls)
+
+	// The CA bundle is fetched again once the issuer changed.
+	issuer := &api.TestIssuer{}
+	require.NoError(t, fakeClient.Get(context.TODO(), client.ObjectKeyFromObject(readyIssuer), issuer))
+	issuer.Annotations = map[string]string{"changed": "true"}
+	require.NoError(t, fakeClient.Update(context.TODO(), issuer))
+	rec = get("/testissuers.testing.cert-manager.io/ns1/ready-issuer/ca.pem", etag)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Equal(t, 2, calls)
+
+	rec = get("/testclusterissuers.testing.cert-manager.io/ready-cluster-issuer/ca.pem", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "ca-of-ready-cluster-issuer", rec.Body.String())
+
+	rec = get("/testissuers.testing.cert-manager.io/ns1/not-ready-issuer/ca.pem", "")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	rec = get("/testissuers.testing.cert-manager.io/ns1/missing-issuer/ca.pem", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	// Namespaced issuer types are not served at cluster-scoped paths.
+	rec = get("/testissuers.testing.cert-manager.io/ready-issuer/ca.pem", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = get("/unknown.example.com/ns1/ready-issuer/ca.pem", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	req := httptest.NewRequest(http.MethodPost, "/testissuers.testing.cert-manager.io/ns1/ready-issuer/ca.pem", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
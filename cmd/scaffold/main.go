@@ -0,0 +1,217 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command scaffold generates the skeleton of a new issuer project built on
+// issuer-lib: the API types of a namespaced and a cluster-scoped issuer, the
+// controller wiring using the CombinedController, a main.go and a conformance
+// test that checks the certificates returned by the Sign function.
+//
+//	go run github.com/cert-manager/issuer-lib/cmd/scaffold
+//
+// The values that are not set using flags are asked for interactively, unless
+// the -yes flag is set.
+package main
+
+import (
+	"bufio"
+	"embed"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+//go:embed templates
+var templates embed.FS
+
+// project contains the values that are used to render the templates.
+type project struct {
+	Module     string
+	Group      string
+	IssuerKind string
+}
+
+// Name is the name of the project, the last element of the module path.
+func (p project) Name() string {
+	return path.Base(p.Module)
+}
+
+func (p project) prefix() string {
+	return strings.TrimSuffix(p.IssuerKind, "Issuer")
+}
+
+func (p project) ClusterIssuerKind() string {
+	return p.prefix() + "ClusterIssuer"
+}
+
+func (p project) SpecKind() string {
+	return p.prefix() + "IssuerSpec"
+}
+
+func (p project) IssuerResource() string {
+	return strings.ToLower(p.IssuerKind) + "s"
+}
+
+func (p project) ClusterIssuerResource() string {
+	return strings.ToLower(p.ClusterIssuerKind()) + "s"
+}
+
+func (p project) FieldOwner() string {
+	return strings.ToLower(p.IssuerKind) + "." + p.Group
+}
+
+func (p project) LeaderElectionID() string {
+	return p.Name() + "." + p.Group
+}
+
+var (
+	modulePattern = regexp.MustCompile(`^[a-zA-Z0-9._~-]+(/[a-zA-Z0-9._~-]+)*$`)
+	kindPattern   = regexp.MustCompile(`^[A-Z][a-zA-Z0-9]*Issuer$`)
+)
+
+func (p project) validate() error {
+	var errs []error
+	if !modulePattern.MatchString(p.Module) {
+		errs = append(errs, fmt.Errorf("invalid module path %q", p.Module))
+	}
+	if msgs := validation.IsDNS1123Subdomain(p.Group); len(msgs) > 0 || !strings.Contains(p.Group, ".") {
+		errs = append(errs, fmt.Errorf("invalid API group %q: must be a DNS subdomain containing a dot", p.Group))
+	}
+	if !kindPattern.MatchString(p.IssuerKind) || p.IssuerKind == "Issuer" {
+		errs = append(errs, fmt.Errorf("invalid issuer kind %q: must be a CamelCase name ending with \"Issuer\", eg. \"AcmeIssuer\"", p.IssuerKind))
+	}
+	return errors.Join(errs...)
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, in io.Reader, out io.Writer) error {
+	flags := flag.NewFlagSet("scaffold", flag.ContinueOnError)
+	flags.SetOutput(out)
+
+	var p project
+	var dir string
+	var yes bool
+	flags.StringVar(&p.Module, "module", "", "The Go module path of the new project (eg. \"example.com/acme-issuer\").")
+	flags.StringVar(&p.Group, "group", "", "The API group of the issuer resources (eg. \"acme.example.com\").")
+	flags.StringVar(&p.IssuerKind, "kind", "", "The kind of the namespaced issuer resource (eg. \"AcmeIssuer\"), the cluster-scoped kind is derived from it.")
+	flags.StringVar(&dir, "dir", "", "The directory to create the project in, defaults to the last element of the module path.")
+	flags.BoolVar(&yes, "yes", false, "Use the default values instead of asking for the values that are not set.")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(in)
+	ask := func(value *string, question string, defaultValue string) error {
+		if *value != "" {
+			return nil
+		}
+		if yes {
+			*value = defaultValue
+			return nil
+		}
+
+		fmt.Fprintf(out, "%s [%s]: ", question, defaultValue)
+		line, err := reader.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		if *value = strings.TrimSpace(line); *value == "" {
+			*value = defaultValue
+		}
+		return nil
+	}
+
+	if err := ask(&p.Module, "Go module path", "example.com/my-issuer"); err != nil {
+		return err
+	}
+	if err := ask(&p.Group, "API group", "issuer.example.com"); err != nil {
+		return err
+	}
+	if err := ask(&p.IssuerKind, "Issuer kind", "MyIssuer"); err != nil {
+		return err
+	}
+	if err := ask(&dir, "Output directory", p.Name()); err != nil {
+		return err
+	}
+
+	if err := p.validate(); err != nil {
+		return err
+	}
+
+	if err := generate(p, dir); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Created %s with the %s and %s resources.\n", dir, p.IssuerKind, p.ClusterIssuerKind())
+	fmt.Fprintf(out, "Run \"go mod tidy\" in %s to add the dependencies.\n", dir)
+	return nil
+}
+
+// generate renders the templates into the directory, which must not exist yet
+// or be empty. Go files are formatted after rendering.
+func generate(p project, dir string) error {
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		return fmt.Errorf("directory %q is not empty", dir)
+	} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return fs.WalkDir(templates, "templates", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		tmpl, err := template.ParseFS(templates, name)
+		if err != nil {
+			return err
+		}
+
+		var content strings.Builder
+		if err := tmpl.Execute(&content, p); err != nil {
+			return fmt.Errorf("failed to render %s: %w", name, err)
+		}
+
+		output := []byte(content.String())
+		target := strings.TrimSuffix(strings.TrimPrefix(name, "templates/"), ".tmpl")
+		if strings.HasSuffix(target, ".go") {
+			if output, err = format.Source(output); err != nil {
+				return fmt.Errorf("failed to format %s: %w", target, err)
+			}
+		}
+
+		target = filepath.Join(dir, filepath.FromSlash(target))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		return os.WriteFile(target, output, 0o644) // #nosec: G306 -- The generated source files are not secret.
+	})
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunInteractive(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "acme-issuer")
+
+	var out strings.Builder
+	in := strings.NewReader("example.com/acme-issuer\nacme.example.com\nAcmeIssuer\n" + dir + "\n")
+	require.NoError(t, run(nil, in, &out))
+	assert.Contains(t, out.String(), "Go module path [example.com/my-issuer]: ")
+	assert.Contains(t, out.String(), "Created "+dir+" with the AcmeIssuer and AcmeClusterIssuer resources.")
+
+	var files []string
+	require.NoError(t, filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			rel, _ := filepath.Rel(dir, path)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return err
+	}))
+	assert.ElementsMatch(t, []string{
+		"README.md",
+		"go.mod",
+		"main.go",
+		"api/doc.go",
+		"api/issuer_types.go",
+		"api/cluster_issuer_types.go",
+		"api/zz_generated.deepcopy.go",
+		"controller/signer.go",
+		"controller/signer_test.go",
+	}, files)
+
+	goMod, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	require.NoError(t, err)
+	assert.Contains(t, string(goMod), "module example.com/acme-issuer\n")
+
+	clusterIssuerTypes, err := os.ReadFile(filepath.Join(dir, "api", "cluster_issuer_types.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(clusterIssuerTypes), "type AcmeClusterIssuer struct {")
+	assert.Contains(t, string(clusterIssuerTypes), "Spec   AcmeIssuerSpec")
+	assert.Contains(t, string(clusterIssuerTypes), `return "acmeclusterissuers.acme.example.com"`)
+
+	signerSource, err := os.ReadFile(filepath.Join(dir, "controller", "signer.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(signerSource), `"example.com/acme-issuer/api"`)
+	assert.Contains(t, string(signerSource), `FieldOwner:               "acmeissuer.acme.example.com",`)
+
+	// The directory is not overwritten.
+	err = run([]string{"-yes", "-dir", dir}, strings.NewReader(""), &out)
+	assert.ErrorContains(t, err, "is not empty")
+}
+
+func TestRunDefaults(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "out")
+
+	var out strings.Builder
+	require.NoError(t, run([]string{"-yes", "-dir", dir}, strings.NewReader(""), &out))
+	assert.Equal(t, "Created "+dir+" with the MyIssuer and MyClusterIssuer resources.\n"+
+		"Run \"go mod tidy\" in "+dir+" to add the dependencies.\n", out.String())
+	assert.FileExists(t, filepath.Join(dir, "api", "issuer_types.go"))
+}
+
+func TestRunInvalid(t *testing.T) {
+	t.Parallel()
+
+	err := run([]string{
+		"-module", "example.com/acme issuer",
+		"-group", "acme",
+		"-kind", "acme",
+		"-dir", t.TempDir(),
+	}, strings.NewReader(""), &strings.Builder{})
+	assert.ErrorContains(t, err, `invalid module path "example.com/acme issuer"`)
+	assert.ErrorContains(t, err, `invalid API group "acme"`)
+	assert.ErrorContains(t, err, `invalid issuer kind "acme"`)
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package compat helps external issuers that were built using the older
+// "sample-external-issuer" pattern to adopt issuer-lib incrementally.
+//
+// Issuers built using that pattern implement a HealthChecker and a Signer, which
+// are created by builder functions from the issuer and the data of the Secret
+// that the issuer references. The Adapter wraps these builders into the Check,
+// Sign and IssuerSecretRefs functions of the issuer-lib controllers, so the
+// existing HealthChecker and Signer implementations can be re-used as they are:
+//
+//	adapter := &compat.Adapter{
+//		Client: mgr.GetClient(),
+//		SecretName: func(issuerObject v1alpha1.Issuer) string {
+//			return getSpec(issuerObject).AuthSecretName
+//		},
+//		HealthCheckerBuilder: func(issuerObject v1alpha1.Issuer, secretData map[string][]byte) (compat.HealthChecker, error) {
+//			return healthCheckerBuilder(getSpec(issuerObject), secretData)
+//		},
+//		SignerBuilder: func(issuerObject v1alpha1.Issuer, secretData map[string][]byte) (compat.Signer, error) {
+//			return signerBuilder(getSpec(issuerObject), secretData)
+//		},
+//	}
+//
+//	controllers.CombinedController{
+//		...
+//		Check:            adapter.Check,
+//		Sign:             adapter.Sign,
+//		IssuerSecretRefs: adapter.IssuerSecretRefs,
+//	}
+package compat
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+// HealthChecker checks the health of the CA of an issuer.
+type HealthChecker interface {
+	Check() error
+}
+
+// HealthCheckerBuilder creates a HealthChecker for the issuer, using the data of
+// the Secret that the issuer references.
+type HealthCheckerBuilder func(issuerObject v1alpha1.Issuer, secretData map[string][]byte) (HealthChecker, error)
+
+// Signer signs a PEM encoded CSR and returns the PEM encoded certificate chain.
+type Signer interface {
+	Sign(csrPEM []byte) ([]byte, error)
+}
+
+// SignerBuilder creates a Signer for the issuer, using the data of the Secret
+// that the issuer references.
+type SignerBuilder func(issuerObject v1alpha1.Issuer, secretData map[string][]byte) (Signer, error)
+
+// Adapter implements the Check, Sign and IssuerSecretRefs functions using a
+// HealthCheckerBuilder and a SignerBuilder.
+type Adapter struct {
+	// Client is used to read the Secrets referenced by the issuers.
+	Client client.Reader
+
+	// SecretName is an optional function that returns the name of the Secret
+	// that the issuer references (eg. the authSecretName field of its spec). The
+	// Secret is read from the namespace of the issuer or, for cluster-scoped
+	// issuers, from the ClusterResourceNamespace of the controllers. If not set,
+	// or if an empty name is returned, the builders receive no Secret data.
+	SecretName func(issuerObject v1alpha1.Issuer) string
+
+	HealthCheckerBuilder HealthCheckerBuilder
+	SignerBuilder        SignerBuilder
+}
+
+// Check creates a HealthChecker for the issuer and checks the health of its CA.
+func (a *Adapter) Check(ctx context.Context, issuerObject v1alpha1.Issuer) error {
+	secretData, err := a.secretData(ctx, issuerObject)
+	if err != nil {
+		return err
+	}
+
+	checker, err := a.HealthCheckerBuilder(issuerObject, secretData)
+	if err != nil {
+		return fmt.Errorf("failed to build the health checker: %w", err)
+	}
+
+	if err := checker.Check(); err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+
+	return nil
+}
+
+// Sign creates a Signer for the issuer and signs the CSR of the request. Errors
+// reading the Secret or creating the Signer are returned as a signer.IssuerError,
+// so the issuer is checked again.
+func (a *Adapter) Sign(ctx context.Context, cr signer.CertificateRequestObject, issuerObject v1alpha1.Issuer) (signer.PEMBundle, error) {
+	secretData, err := a.secretData(ctx, issuerObject)
+	if err != nil {
+		return signer.PEMBundle{}, signer.IssuerError{Err: err}
+	}
+
+	s, err := a.SignerBuilder(issuerObject, secretData)
+	if err != nil {
+		return signer.PEMBundle{}, signer.IssuerError{Err: fmt.Errorf("failed to build the signer: %w", err)}
+	}
+
+	_, _, csrPEM, err := cr.GetRequest()
+	if err != nil {
+		return signer.PEMBundle{}, signer.PermanentError{Err: fmt.Errorf("failed to parse the request: %w", err)}
+	}
+
+	chainPEM, err := s.Sign(csrPEM)
+	if err != nil {
+		return signer.PEMBundle{}, fmt.Errorf("failed to sign: %w", err)
+	}
+
+	return signer.PEMBundle{ChainPEM: chainPEM}, nil
+}
+
+// IssuerSecretRefs returns the Secret that the issuer references, so the issuer
+// is checked again when the contents of the Secret change.
+func (a *Adapter) IssuerSecretRefs(issuerObject v1alpha1.Issuer) []types.NamespacedName {
+	if a.SecretName == nil {
+		return nil
+	}
+
+	name := a.SecretName(issuerObject)
+	if name == "" {
+		return nil
+	}
+
+	// The namespace is resolved by the issuer secret controller.
+	return []types.NamespacedName{{Name: name}}
+}
+
+func (a *Adapter) secretData(ctx context.Context, issuerObject v1alpha1.Issuer) (map[string][]byte, error) {
+	if a.SecretName == nil {
+		return nil, nil
+	}
+
+	name := a.SecretName(issuerObject)
+	if name == "" {
+		return nil, nil
+	}
+
+	secretName := signer.ResourceName(ctx, issuerObject, name)
+	if secretName.Namespace == "" {
+		return nil, fmt.Errorf("the Secret %q of the cluster-scoped issuer cannot be read, the ClusterResourceNamespace is not set", name)
+	}
+
+	var secret corev1.Secret
+	if err := a.Client.Get(ctx, secretName, &secret); err != nil {
+		return nil, fmt.Errorf("failed to get the Secret %s: %w", secretName, err)
+	}
+
+	return secret.Data, nil
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compat
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+
+	cmgen "github.com/cert-manager/cert-manager/test/unit/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/testapi/testutil"
+)
+
+type healthCheckerFunc func() error
+
+func (f healthCheckerFunc) Check() error { return f() }
+
+type signerFunc func([]byte) ([]byte, error)
+
+func (f signerFunc) Sign(csrPEM []byte) ([]byte, error) { return f(csrPEM) }
+
+func testAdapter(t *testing.T) *Adapter {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "auth", Namespace: "ns1"},
+				Data:       map[string][]byte{"token": []byte("ns1-token")},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "auth", Namespace: "cert-manager"},
+				Data:       map[string][]byte{"token": []byte("cluster-token")},
+			},
+		).
+		Build()
+
+	return &Adapter{
+		Client: fakeClient,
+		SecretName: func(issuerObject v1alpha1.Issuer) string {
+			return issuerObject.GetAnnotations()["auth-secret-name"]
+		},
+		HealthCheckerBuilder: func(_ v1alpha1.Issuer, secretData map[string][]byte) (HealthChecker, error) {
+			return healthCheckerFunc(func() error {
+				if string(secretData["token"]) == "" {
+					return errors.New("missing token")
+				}
+				return nil
+			}), nil
+		},
+		SignerBuilder: func(_ v1alpha1.Issuer, secretData map[string][]byte) (Signer, error) {
+			if string(secretData["token"]) == "" {
+				return nil, errors.New("missing token")
+			}
+			return signerFunc(func(csrPEM []byte) ([]byte, error) {
+				return append([]byte(string(secretData["token"])+":"), csrPEM...), nil
+			}), nil
+		},
+	}
+}
+
+func withAuthSecret(name string) func(*metav1.ObjectMeta) {
+	return func(meta *metav1.ObjectMeta) {
+		meta.Annotations = map[string]string{"auth-secret-name": name}
+	}
+}
+
+func TestAdapterCheck(t *testing.T) {
+	t.Parallel()
+
+	adapter := testAdapter(t)
+	ctx := signer.WithClusterResourceNamespace(context.TODO(), "cert-manager")
+
+	issuer := testutil.TestIssuer("issuer-1", testutil.SetTestIssuerNamespace("ns1"))
+	withAuthSecret("auth")(&issuer.ObjectMeta)
+	require.NoError(t, adapter.Check(ctx, issuer))
+
+	clusterIssuer := testutil.TestClusterIssuer("cluster-issuer-1")
+	withAuthSecret("auth")(&clusterIssuer.ObjectMeta)
+	require.NoError(t, adapter.Check(ctx, clusterIssuer))
+
+	// The Secret of a cluster-scoped issuer cannot be read without the
+	// cluster resource namespace.
+	err := adapter.Check(context.TODO(), clusterIssuer)
+	assert.ErrorContains(t, err, "the ClusterResourceNamespace is not set")
+
+	missingSecretIssuer := testutil.TestIssuer("issuer-2", testutil.SetTestIssuerNamespace("ns1"))
+	withAuthSecret("missing")(&missingSecretIssuer.ObjectMeta)
+	err = adapter.Check(ctx, missingSecretIssuer)
+	assert.ErrorContains(t, err, "failed to get the Secret ns1/missing")
+
+	noSecretIssuer := testutil.TestIssuer("issuer-3", testutil.SetTestIssuerNamespace("ns1"))
+	err = adapter.Check(ctx, noSecretIssuer)
+	assert.EqualError(t, err, "health check failed: missing token")
+}
+
+func TestAdapterSign(t *testing.T) {
+	t.Parallel()
+
+	adapter := testAdapter(t)
+	ctx := signer.WithClusterResourceNamespace(context.TODO(), "cert-manager")
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		DNSNames: []string{"example.com"},
+	}, key)
+	require.NoError(t, err)
+	csrPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER})
+	cr := signer.CertificateRequestObjectFromCertificateRequest(
+		cmgen.CertificateRequest("cr-1", cmgen.SetCertificateRequestCSR(csrPEM)),
+	)
+
+	clusterIssuer := testutil.TestClusterIssuer("cluster-issuer-1")
+	withAuthSecret("auth")(&clusterIssuer.ObjectMeta)
+	bundle, err := adapter.Sign(ctx, cr, clusterIssuer)
+	require.NoError(t, err)
+	assert.Equal(t, signer.PEMBundle{ChainPEM: append([]byte("cluster-token:"), csrPEM...)}, bundle)
+
+	// Errors that are caused by the issuer trigger a new check of the issuer.
+	noSecretIssuer := testutil.TestIssuer("issuer-3", testutil.SetTestIssuerNamespace("ns1"))
+	_, err = adapter.Sign(ctx, cr, noSecretIssuer)
+	assert.ErrorAs(t, err, &signer.IssuerError{})
+	assert.EqualError(t, err, "failed to build the signer: missing token")
+}
+
+func TestAdapterIssuerSecretRefs(t *testing.T) {
+	t.Parallel()
+
+	adapter := testAdapter(t)
+
+	issuer := testutil.TestIssuer("issuer-1", testutil.SetTestIssuerNamespace("ns1"))
+	withAuthSecret("auth")(&issuer.ObjectMeta)
+	assert.Equal(t, []types.NamespacedName{{Name: "auth"}}, adapter.IssuerSecretRefs(issuer))
+
+	assert.Nil(t, adapter.IssuerSecretRefs(testutil.TestIssuer("issuer-2")))
+	assert.Nil(t, (&Adapter{}).IssuerSecretRefs(issuer))
+}
@@ -0,0 +1,24 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package conditions contains the helpers that the controllers use to set the
+// conditions of issuers, CertificateRequests and CertificateSigningRequests.
+//
+// # Stability
+//
+// This package is stable, its exported functions follow the deprecation policy
+// described in "API stability" in the README.
+package conditions
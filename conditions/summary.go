@@ -0,0 +1,164 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conditions
+
+import (
+	"strings"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+)
+
+// The states of a Summary.
+const (
+	SummaryStateReady    = "Ready"
+	SummaryStateNotReady = "NotReady"
+	SummaryStatePending  = "Pending"
+	SummaryStateFailed   = "Failed"
+	SummaryStateIssued   = "Issued"
+	SummaryStateDenied   = "Denied"
+)
+
+// summaryMessageMaxLength is the maximum length of the message in the string
+// representation of a Summary.
+const summaryMessageMaxLength = 200
+
+// Summary describes the state of an issuer or request in a way that can be
+// presented to humans, eg. in a printer column, an event or a CLI. Downstream
+// projects can create a Summary for their own resources, so all resources are
+// summarized consistently.
+type Summary struct {
+	// State is the overall state, one of the SummaryState constants.
+	State string
+	// Reason is the reason of the condition that determined the state, if any.
+	Reason string
+	// Message is the message of the condition that determined the state, if any.
+	Message string
+}
+
+// String returns the summary as a single line, formatted as
+// "<State> (<Reason>): <Message>". The reason is omitted if it is empty or
+// equal to the state, and the message is shortened to 200 characters.
+func (s Summary) String() string {
+	var sb strings.Builder
+	sb.WriteString(s.State)
+	if s.Reason != "" && s.Reason != s.State {
+		sb.WriteString(" (")
+		sb.WriteString(s.Reason)
+		sb.WriteString(")")
+	}
+
+	// Collapse newlines and repeated whitespace, so the summary is a single line.
+	message := strings.Join(strings.Fields(s.Message), " ")
+	if runes := []rune(message); len(runes) > summaryMessageMaxLength {
+		message = string(runes[:summaryMessageMaxLength-3]) + "..."
+	}
+	if message != "" {
+		sb.WriteString(": ")
+		sb.WriteString(message)
+	}
+	return sb.String()
+}
+
+// IssuerSummary summarizes the state of an issuer based on its Ready condition
+// and its generation.
+func IssuerSummary(generation int64, conditions []cmapi.IssuerCondition) Summary {
+	readyCondition := GetIssuerStatusCondition(conditions, cmapi.IssuerConditionReady)
+	switch {
+	case readyCondition == nil:
+		return Summary{State: SummaryStatePending, Message: "Waiting for the issuer to be checked."}
+	case readyCondition.ObservedGeneration < generation:
+		return Summary{State: SummaryStatePending, Message: "Waiting for the latest generation of the issuer to be checked."}
+	}
+
+	summary := Summary{Reason: readyCondition.Reason, Message: readyCondition.Message}
+	switch {
+	case readyCondition.Status == cmmeta.ConditionTrue:
+		summary.State = SummaryStateReady
+	case v1alpha1.IsIssuerConditionReasonFailed(readyCondition.Reason):
+		summary.State = SummaryStateFailed
+	case readyCondition.Status == cmmeta.ConditionFalse && v1alpha1.IsWellKnownIssuerConditionReason(readyCondition.Reason):
+		summary.State = SummaryStateNotReady
+	default:
+		summary.State = SummaryStatePending
+	}
+	return summary
+}
+
+// CertificateRequestSummary summarizes the state of a CertificateRequest based
+// on its conditions.
+func CertificateRequestSummary(conditions []cmapi.CertificateRequestCondition) Summary {
+	var readyCondition, approvedCondition *cmapi.CertificateRequestCondition
+	for _, condition := range conditions {
+		switch condition.Type {
+		case cmapi.CertificateRequestConditionReady:
+			readyCondition = &condition
+		case cmapi.CertificateRequestConditionApproved:
+			approvedCondition = &condition
+		case cmapi.CertificateRequestConditionDenied:
+			if condition.Status == cmmeta.ConditionTrue {
+				return Summary{State: SummaryStateDenied, Reason: condition.Reason, Message: condition.Message}
+			}
+		case cmapi.CertificateRequestConditionInvalidRequest:
+			if condition.Status == cmmeta.ConditionTrue {
+				return Summary{State: SummaryStateFailed, Reason: condition.Reason, Message: condition.Message}
+			}
+		}
+	}
+
+	if readyCondition == nil {
+		if approvedCondition == nil || approvedCondition.Status != cmmeta.ConditionTrue {
+			return Summary{State: SummaryStatePending, Message: "Waiting for approval."}
+		}
+		return Summary{State: SummaryStatePending, Message: "Waiting for the request to be signed."}
+	}
+
+	summary := Summary{Reason: readyCondition.Reason, Message: readyCondition.Message}
+	switch {
+	case readyCondition.Status == cmmeta.ConditionTrue:
+		summary.State = SummaryStateIssued
+	case readyCondition.Reason == cmapi.CertificateRequestReasonFailed:
+		summary.State = SummaryStateFailed
+	case readyCondition.Reason == cmapi.CertificateRequestReasonDenied:
+		summary.State = SummaryStateDenied
+	default:
+		summary.State = SummaryStatePending
+	}
+	return summary
+}
+
+// CertificateSigningRequestSummary summarizes the state of a Kubernetes
+// CertificateSigningRequest based on its conditions and issued certificate.
+func CertificateSigningRequestSummary(conditions []certificatesv1.CertificateSigningRequestCondition, certificate []byte) Summary {
+	if condition := GetCertificateSigningRequestStatusCondition(conditions, certificatesv1.CertificateDenied); condition != nil && condition.Status == v1.ConditionTrue {
+		return Summary{State: SummaryStateDenied, Reason: condition.Reason, Message: condition.Message}
+	}
+	if condition := GetCertificateSigningRequestStatusCondition(conditions, certificatesv1.CertificateFailed); condition != nil && condition.Status == v1.ConditionTrue {
+		return Summary{State: SummaryStateFailed, Reason: condition.Reason, Message: condition.Message}
+	}
+	if len(certificate) > 0 {
+		return Summary{State: SummaryStateIssued}
+	}
+	if condition := GetCertificateSigningRequestStatusCondition(conditions, certificatesv1.CertificateApproved); condition != nil && condition.Status == v1.ConditionTrue {
+		return Summary{State: SummaryStatePending, Message: "Waiting for the request to be signed."}
+	}
+	return Summary{State: SummaryStatePending, Message: "Waiting for approval."}
+}
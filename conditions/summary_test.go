@@ -0,0 +1,251 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conditions
+
+import (
+	"strings"
+	"testing"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/stretchr/testify/require"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestSummaryString(t *testing.T) {
+	testCases := []struct {
+		name     string
+		summary  Summary
+		expected string
+	}{
+		{
+			name:     "state only",
+			summary:  Summary{State: SummaryStateIssued},
+			expected: "Issued",
+		},
+		{
+			name:     "reason equal to the state is omitted",
+			summary:  Summary{State: SummaryStateFailed, Reason: "Failed", Message: "CA rejected the request"},
+			expected: "Failed: CA rejected the request",
+		},
+		{
+			name:     "multi-line message is collapsed",
+			summary:  Summary{State: SummaryStateNotReady, Reason: "EndpointUnreachable", Message: "dial tcp:\n  connection refused\n"},
+			expected: "NotReady (EndpointUnreachable): dial tcp: connection refused",
+		},
+		{
+			name:     "long message is shortened",
+			summary:  Summary{State: SummaryStatePending, Message: strings.Repeat("a", 250)},
+			expected: "Pending: " + strings.Repeat("a", 197) + "...",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.summary.String())
+		})
+	}
+}
+
+func TestIssuerSummary(t *testing.T) {
+	readyCondition := func(status cmmeta.ConditionStatus, reason, message string, observedGeneration int64) []cmapi.IssuerCondition {
+		return []cmapi.IssuerCondition{
+			{
+				Type:               cmapi.IssuerConditionReady,
+				Status:             status,
+				Reason:             reason,
+				Message:            message,
+				ObservedGeneration: observedGeneration,
+			},
+		}
+	}
+
+	testCases := []struct {
+		name       string
+		generation int64
+		conditions []cmapi.IssuerCondition
+		expected   string
+	}{
+		{
+			name:       "not checked",
+			generation: 1,
+			expected:   "Pending: Waiting for the issuer to be checked.",
+		},
+		{
+			name:       "outdated",
+			generation: 2,
+			conditions: readyCondition(cmmeta.ConditionTrue, "Checked", "Succeeded", 1),
+			expected:   "Pending: Waiting for the latest generation of the issuer to be checked.",
+		},
+		{
+			name:       "initializing",
+			generation: 1,
+			conditions: readyCondition(cmmeta.ConditionUnknown, "Initializing", "", 1),
+			expected:   "Pending (Initializing)",
+		},
+		{
+			name:       "ready",
+			generation: 1,
+			conditions: readyCondition(cmmeta.ConditionTrue, "Checked", "Succeeded", 1),
+			expected:   "Ready (Checked): Succeeded",
+		},
+		{
+			name:       "retryable error",
+			generation: 1,
+			conditions: readyCondition(cmmeta.ConditionFalse, "Pending", "Issuer is not ready yet: timeout", 1),
+			expected:   "Pending: Issuer is not ready yet: timeout",
+		},
+		{
+			name:       "well-known retryable reason",
+			generation: 1,
+			conditions: readyCondition(cmmeta.ConditionFalse, "CredentialsInvalid", "token expired", 1),
+			expected:   "NotReady (CredentialsInvalid): token expired",
+		},
+		{
+			name:       "failed",
+			generation: 1,
+			conditions: readyCondition(cmmeta.ConditionFalse, "Failed", "Issuer has failed permanently: invalid URL", 1),
+			expected:   "Failed: Issuer has failed permanently: invalid URL",
+		},
+		{
+			name:       "configuration error",
+			generation: 1,
+			conditions: readyCondition(cmmeta.ConditionFalse, "ConfigurationError", "missing secret", 1),
+			expected:   "Failed (ConfigurationError): missing secret",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, IssuerSummary(tc.generation, tc.conditions).String())
+		})
+	}
+}
+
+func TestCertificateRequestSummary(t *testing.T) {
+	approved := cmapi.CertificateRequestCondition{Type: cmapi.CertificateRequestConditionApproved, Status: cmmeta.ConditionTrue, Reason: "Approved"}
+
+	testCases := []struct {
+		name       string
+		conditions []cmapi.CertificateRequestCondition
+		expected   string
+	}{
+		{
+			name:     "not approved",
+			expected: "Pending: Waiting for approval.",
+		},
+		{
+			name:       "approved",
+			conditions: []cmapi.CertificateRequestCondition{approved},
+			expected:   "Pending: Waiting for the request to be signed.",
+		},
+		{
+			name: "denied",
+			conditions: []cmapi.CertificateRequestCondition{
+				{Type: cmapi.CertificateRequestConditionDenied, Status: cmmeta.ConditionTrue, Reason: "PolicyDenied", Message: "Denied by policy"},
+			},
+			expected: "Denied (PolicyDenied): Denied by policy",
+		},
+		{
+			name: "invalid request",
+			conditions: []cmapi.CertificateRequestCondition{
+				approved,
+				{Type: cmapi.CertificateRequestConditionInvalidRequest, Status: cmmeta.ConditionTrue, Reason: "InvalidCSR", Message: "CSR is malformed"},
+			},
+			expected: "Failed (InvalidCSR): CSR is malformed",
+		},
+		{
+			name: "pending",
+			conditions: []cmapi.CertificateRequestCondition{
+				approved,
+				{Type: cmapi.CertificateRequestConditionReady, Status: cmmeta.ConditionFalse, Reason: cmapi.CertificateRequestReasonPending, Message: "Signing"},
+			},
+			expected: "Pending: Signing",
+		},
+		{
+			name: "failed",
+			conditions: []cmapi.CertificateRequestCondition{
+				approved,
+				{Type: cmapi.CertificateRequestConditionReady, Status: cmmeta.ConditionFalse, Reason: cmapi.CertificateRequestReasonFailed, Message: "CertificateRequest has failed permanently: rejected"},
+			},
+			expected: "Failed: CertificateRequest has failed permanently: rejected",
+		},
+		{
+			name: "issued",
+			conditions: []cmapi.CertificateRequestCondition{
+				approved,
+				{Type: cmapi.CertificateRequestConditionReady, Status: cmmeta.ConditionTrue, Reason: cmapi.CertificateRequestReasonIssued, Message: "issued"},
+			},
+			expected: "Issued: issued",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, CertificateRequestSummary(tc.conditions).String())
+		})
+	}
+}
+
+func TestCertificateSigningRequestSummary(t *testing.T) {
+	approved := certificatesv1.CertificateSigningRequestCondition{Type: certificatesv1.CertificateApproved, Status: v1.ConditionTrue}
+
+	testCases := []struct {
+		name        string
+		conditions  []certificatesv1.CertificateSigningRequestCondition
+		certificate []byte
+		expected    string
+	}{
+		{
+			name:     "not approved",
+			expected: "Pending: Waiting for approval.",
+		},
+		{
+			name:       "approved",
+			conditions: []certificatesv1.CertificateSigningRequestCondition{approved},
+			expected:   "Pending: Waiting for the request to be signed.",
+		},
+		{
+			name:        "issued",
+			conditions:  []certificatesv1.CertificateSigningRequestCondition{approved},
+			certificate: []byte("certificate"),
+			expected:    "Issued",
+		},
+		{
+			name: "denied",
+			conditions: []certificatesv1.CertificateSigningRequestCondition{
+				{Type: certificatesv1.CertificateDenied, Status: v1.ConditionTrue, Reason: "PolicyDenied", Message: "Denied by policy"},
+			},
+			expected: "Denied (PolicyDenied): Denied by policy",
+		},
+		{
+			name: "failed",
+			conditions: []certificatesv1.CertificateSigningRequestCondition{
+				approved,
+				{Type: certificatesv1.CertificateFailed, Status: v1.ConditionTrue, Reason: "SignerFailure", Message: "CA rejected the request"},
+			},
+			expected: "Failed (SignerFailure): CA rejected the request",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, CertificateSigningRequestSummary(tc.conditions, tc.certificate).String())
+		})
+	}
+}
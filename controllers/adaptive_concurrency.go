@@ -0,0 +1,233 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// AdaptiveConcurrencyPolicy scales the number of requests that are reconciled
+// concurrently between MinConcurrency and MaxConcurrency, using an
+// additive-increase/multiplicative-decrease algorithm: every AdjustInterval, the
+// limit is halved if the average latency of Sign in the interval exceeded
+// TargetSignLatency, and otherwise increased by one if requests are waiting to be
+// reconciled. This keeps the issuance latency stable under bursty load, without
+// having to tune MaxConcurrentReconciles manually.
+//
+// The controller runs MaxConcurrency workers; the workers that exceed the current
+// limit wait before reconciling a request. The CertificateRequest and Kubernetes
+// CSR controllers each have their own limit, which is exported as the
+// issuer_lib_request_concurrency_limit metric.
+type AdaptiveConcurrencyPolicy struct {
+	// MinConcurrency is the lower bound and the initial value of the limit,
+	// defaults to 1.
+	MinConcurrency int
+
+	// MaxConcurrency is the upper bound of the limit, defaults to 16.
+	MaxConcurrency int
+
+	// TargetSignLatency is the average latency of Sign above which the limit is
+	// decreased, defaults to 5 seconds.
+	TargetSignLatency time.Duration
+
+	// AdjustInterval is the interval at which the limit is adjusted, defaults to
+	// 10 seconds.
+	AdjustInterval time.Duration
+}
+
+// concurrencyLimiter limits the number of concurrent reconciles of a controller
+// according to an AdaptiveConcurrencyPolicy. A nil concurrencyLimiter does not
+// limit the reconciles.
+type concurrencyLimiter struct {
+	name              string
+	minConcurrency    int
+	maxConcurrency    int
+	targetSignLatency time.Duration
+	adjustInterval    time.Duration
+	clock             clock.PassiveClock
+
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	// queue is the work queue of the controller, it is set when the controller
+	// creates its queue.
+	queue interface{ Len() int }
+
+	limit    int
+	inFlight int
+	waiting  int
+
+	lastAdjust       time.Time
+	signLatencySum   time.Duration
+	signLatencyCount int
+}
+
+// newConcurrencyLimiter returns a concurrencyLimiter for the controller of the
+// given kind, which starts at the minimum limit of the policy.
+func newConcurrencyLimiter(kind string, policy AdaptiveConcurrencyPolicy, clock clock.PassiveClock) (*concurrencyLimiter, error) {
+	l := &concurrencyLimiter{
+		name:              strings.ToLower(kind),
+		minConcurrency:    policy.MinConcurrency,
+		maxConcurrency:    policy.MaxConcurrency,
+		targetSignLatency: policy.TargetSignLatency,
+		adjustInterval:    policy.AdjustInterval,
+		clock:             clock,
+	}
+	if l.minConcurrency == 0 {
+		l.minConcurrency = 1
+	}
+	if l.maxConcurrency == 0 {
+		l.maxConcurrency = max(16, l.minConcurrency)
+	}
+	if l.targetSignLatency == 0 {
+		l.targetSignLatency = 5 * time.Second
+	}
+	if l.adjustInterval == 0 {
+		l.adjustInterval = 10 * time.Second
+	}
+
+	if l.minConcurrency < 1 || l.maxConcurrency < l.minConcurrency {
+		return nil, fmt.Errorf("invalid adaptive concurrency bounds: min %d, max %d", l.minConcurrency, l.maxConcurrency)
+	}
+
+	l.cond = sync.NewCond(&l.mu)
+	l.limit = l.minConcurrency
+	l.lastAdjust = clock.Now()
+	requestConcurrencyLimit.WithLabelValues(l.name).Set(float64(l.limit))
+	return l, nil
+}
+
+// wrapNewQueue wraps the function that creates the work queue of the controller,
+// so that the limiter can read the depth of the queue. A nil newQueue creates
+// the default queue of controller-runtime.
+func (l *concurrencyLimiter) wrapNewQueue(
+	newQueue func(string, workqueue.TypedRateLimiter[reconcile.Request]) workqueue.TypedRateLimitingInterface[reconcile.Request],
+) func(string, workqueue.TypedRateLimiter[reconcile.Request]) workqueue.TypedRateLimitingInterface[reconcile.Request] {
+	return func(controllerName string, rateLimiter workqueue.TypedRateLimiter[reconcile.Request]) workqueue.TypedRateLimitingInterface[reconcile.Request] {
+		var queue workqueue.TypedRateLimitingInterface[reconcile.Request]
+		if newQueue != nil {
+			queue = newQueue(controllerName, rateLimiter)
+		} else {
+			queue = workqueue.NewTypedRateLimitingQueueWithConfig(rateLimiter, workqueue.TypedRateLimitingQueueConfig[reconcile.Request]{
+				Name: controllerName,
+			})
+		}
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.queue = queue
+		return queue
+	}
+}
+
+// acquire waits until the number of in-flight reconciles is below the limit,
+// and returns a function that must be called when the reconcile is done.
+func (l *concurrencyLimiter) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	// Wake up the waiting workers when the context is cancelled, so that they
+	// don't block the shutdown of the controller.
+	stop := context.AfterFunc(ctx, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.cond.Broadcast()
+	})
+	defer stop()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.waiting++
+	defer func() { l.waiting-- }()
+
+	for {
+		l.adjust()
+		if l.inFlight < l.limit {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		l.cond.Wait()
+	}
+
+	l.inFlight++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.inFlight--
+			l.cond.Signal()
+		})
+	}, nil
+}
+
+// observeSignLatency records the latency of a call of Sign.
+func (l *concurrencyLimiter) observeSignLatency(latency time.Duration) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.signLatencySum += latency
+	l.signLatencyCount++
+}
+
+// adjust adjusts the limit if the adjust interval has passed since the last
+// adjustment, it must be called with the lock held.
+func (l *concurrencyLimiter) adjust() {
+	now := l.clock.Now()
+	if now.Sub(l.lastAdjust) < l.adjustInterval {
+		return
+	}
+
+	queueDepth := l.waiting
+	if l.queue != nil {
+		queueDepth += l.queue.Len()
+	}
+
+	limit := l.limit
+	switch {
+	case l.signLatencyCount > 0 && l.signLatencySum/time.Duration(l.signLatencyCount) > l.targetSignLatency:
+		limit = max(l.minConcurrency, limit/2)
+	case queueDepth > limit-l.inFlight:
+		limit = min(l.maxConcurrency, limit+1)
+	}
+
+	l.lastAdjust = now
+	l.signLatencySum = 0
+	l.signLatencyCount = 0
+
+	if limit == l.limit {
+		return
+	}
+	l.limit = limit
+	requestConcurrencyLimit.WithLabelValues(l.name).Set(float64(limit))
+	l.cond.Broadcast()
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	prometheustestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+type fakeQueueDepth int
+
+func (d fakeQueueDepth) Len() int {
+	return int(d)
+}
+
+func TestConcurrencyLimiter(t *testing.T) {
+	t.Parallel()
+
+	fakeClock := clocktesting.NewFakeClock(randomTime())
+	l, err := newConcurrencyLimiter("TestAIMD", AdaptiveConcurrencyPolicy{
+		MinConcurrency:    1,
+		MaxConcurrency:    3,
+		TargetSignLatency: time.Second,
+		AdjustInterval:    10 * time.Second,
+	}, fakeClock)
+	require.NoError(t, err)
+	l.queue = fakeQueueDepth(5)
+
+	limit := func() float64 {
+		return prometheustestutil.ToFloat64(requestConcurrencyLimit.WithLabelValues("testaimd"))
+	}
+	assert.Equal(t, float64(1), limit())
+
+	release1, err := l.acquire(context.TODO())
+	require.NoError(t, err)
+
+	// A worker that exceeds the limit waits until a reconcile is done.
+	acquired := make(chan func())
+	go func() {
+		release, err := l.acquire(context.TODO())
+		assert.NoError(t, err)
+		acquired <- release
+	}()
+	assert.Eventually(t, func() bool {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return l.waiting == 1
+	}, 5*time.Second, 5*time.Millisecond)
+	release1()
+	release2 := <-acquired
+
+	// The limit is increased by one per interval while requests are queued.
+	fakeClock.Step(10 * time.Second)
+	release3, err := l.acquire(context.TODO())
+	require.NoError(t, err)
+	assert.Equal(t, float64(2), limit())
+
+	fakeClock.Step(10 * time.Second)
+	release4, err := l.acquire(context.TODO())
+	require.NoError(t, err)
+	assert.Equal(t, float64(3), limit())
+
+	// The limit does not exceed the maximum.
+	fakeClock.Step(10 * time.Second)
+	ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+	defer cancel()
+	_, err = l.acquire(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, float64(3), limit())
+
+	// The limit is halved when the average Sign latency exceeds the target.
+	l.observeSignLatency(500 * time.Millisecond)
+	l.observeSignLatency(2 * time.Second)
+	release2()
+	release3()
+	release4()
+	fakeClock.Step(10 * time.Second)
+	release5, err := l.acquire(context.TODO())
+	require.NoError(t, err)
+	assert.Equal(t, float64(1), limit())
+	release5()
+}
+
+func TestConcurrencyLimiterInvalidBounds(t *testing.T) {
+	t.Parallel()
+
+	_, err := newConcurrencyLimiter("TestInvalidBounds", AdaptiveConcurrencyPolicy{
+		MinConcurrency: 4,
+		MaxConcurrency: 2,
+	}, clocktesting.NewFakeClock(randomTime()))
+	require.EqualError(t, err, "invalid adaptive concurrency bounds: min 4, max 2")
+}
+
+func TestConcurrencyLimiterNil(t *testing.T) {
+	t.Parallel()
+
+	var l *concurrencyLimiter
+	release, err := l.acquire(context.TODO())
+	require.NoError(t, err)
+	release()
+	l.observeSignLatency(time.Minute)
+}
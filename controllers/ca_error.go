@@ -0,0 +1,42 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+
+	"github.com/go-logr/logr"
+
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+// logCAErrorDetails logs the full details of the response of the CA at
+// verbosity level 3 if the error wraps a signer.CAError. The condition messages
+// only contain a truncated and sanitized summary of the response.
+func logCAErrorDetails(logger logr.Logger, err error) {
+	caError := signer.CAError{}
+	if !errors.As(err, &caError) {
+		return
+	}
+
+	logger.V(3).Info("CA returned an error.",
+		"error", caError.Err.Error(),
+		"statusCode", caError.StatusCode,
+		"requestID", caError.RequestID,
+		"body", string(caError.Body),
+	)
+}
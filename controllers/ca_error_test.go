@@ -0,0 +1,97 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+func TestCAErrorMessage(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name            string
+		err             error
+		expectedMessage string
+	}
+
+	tests := []testcase{
+		{
+			name:            "no-details",
+			err:             signer.WrapCAError(errors.New("[error]"), 0, nil, ""),
+			expectedMessage: "[error]",
+		},
+		{
+			name:            "all-details",
+			err:             signer.WrapCAError(errors.New("[error]"), 503, []byte("maintenance"), "req-1"),
+			expectedMessage: `[error] (CA status 503, request ID "req-1", response "maintenance")`,
+		},
+		{
+			name:            "sanitized-body",
+			err:             signer.WrapCAError(errors.New("[error]"), 400, []byte("\n  {\"error\":\t\"invalid\x00csr\"}\r\n"), ""),
+			expectedMessage: `[error] (CA status 400, response "{\"error\": \"invalid csr\"}")`,
+		},
+		{
+			name:            "truncated-body",
+			err:             signer.WrapCAError(errors.New("[error]"), 500, []byte(strings.Repeat("a", 300)), ""),
+			expectedMessage: "[error] (CA status 500, response \"" + strings.Repeat("a", 256) + "...\")",
+		},
+		{
+			name:            "permanent",
+			err:             signer.PermanentError{Err: signer.WrapCAError(errors.New("[error]"), 403, nil, "req-2")},
+			expectedMessage: `[error] (CA status 403, request ID "req-2")`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expectedMessage, tc.err.Error())
+		})
+	}
+
+	assert.NoError(t, signer.WrapCAError(nil, 500, nil, ""))
+}
+
+func TestLogCAErrorDetails(t *testing.T) {
+	t.Parallel()
+
+	var lines []string
+	logger := funcr.New(func(prefix, args string) {
+		lines = append(lines, args)
+	}, funcr.Options{Verbosity: 3})
+
+	logCAErrorDetails(logger, errors.New("[not a CA error]"))
+	require.Empty(t, lines)
+
+	body := strings.Repeat("a", 300)
+	logCAErrorDetails(logger, signer.PermanentError{Err: signer.WrapCAError(errors.New("[error]"), 500, []byte(body), "req-1")})
+	require.Len(t, lines, 1)
+	assert.Contains(t, lines[0], `"statusCode"=500`)
+	assert.Contains(t, lines[0], `"requestID"="req-1"`)
+	// The full body is logged.
+	assert.Contains(t, lines[0], `"body"="`+body+`"`)
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"encoding/json"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+)
+
+// cachedCertificatePlaceholder replaces the certificate of issued Kubernetes
+// CSRs in the cache. Kubernetes CSRs have no Ready condition, so the controllers
+// rely on the certificate being non-empty to tell that the CSR was issued.
+var cachedCertificatePlaceholder = []byte("removed-from-cache")
+
+// CacheTransformPolicy shrinks the CertificateRequests, Kubernetes CSRs and
+// issuers that are stored in the informer cache of the manager, which reduces
+// the memory usage of controllers that watch clusters with tens of thousands of
+// requests. Register it on the cache options before creating the manager:
+//
+//	options := ctrl.Options{...}
+//	(&controllers.CacheTransformPolicy{}).ApplyTo(&options.Cache)
+//	mgr, err := ctrl.NewManager(config, options)
+//
+// The transform only changes the cached copies of the objects, so all clients
+// that read from the cache of the manager see the shrunk objects. Use the Keep
+// fields to disable the parts of the transform that conflict with other code
+// that reads these objects from the cache.
+type CacheTransformPolicy struct {
+	// KeepManagedFields keeps the managed fields of the objects as-is. By
+	// default, only the status conditions that are owned by each field manager
+	// are kept, which is what the controllers use to detect conflicting field
+	// owners and the approver that denied a request.
+	KeepManagedFields bool
+
+	// KeepLastAppliedConfiguration keeps the
+	// kubectl.kubernetes.io/last-applied-configuration annotation, which
+	// contains a copy of the whole object if it was created by kubectl apply.
+	KeepLastAppliedConfiguration bool
+
+	// KeepFinishedRequestData keeps the CSR, certificate and CA of requests
+	// that were issued, failed or denied. By default, these are removed because
+	// the controllers do not reconcile finished requests anymore. The
+	// certificate of an issued Kubernetes CSR is replaced with a placeholder
+	// instead of being removed.
+	KeepFinishedRequestData bool
+}
+
+// ApplyTo sets the transform as the DefaultTransform of the cache options,
+// before the DefaultTransform that was already set. Object types with a
+// Transform in ByObject are not transformed by the DefaultTransform, so the
+// Transform function of the policy has to be added to those explicitly.
+func (p *CacheTransformPolicy) ApplyTo(opts *cache.Options) {
+	transform := p.Transform()
+	if next := opts.DefaultTransform; next != nil {
+		opts.DefaultTransform = func(obj interface{}) (interface{}, error) {
+			obj, err := transform(obj)
+			if err != nil {
+				return nil, err
+			}
+			return next(obj)
+		}
+		return
+	}
+	opts.DefaultTransform = transform
+}
+
+// Transform returns the transform function of the policy. Objects that are not
+// CertificateRequests, Kubernetes CSRs or issuers are returned unchanged. The
+// transform is idempotent, as required by the informers.
+func (p *CacheTransformPolicy) Transform() toolscache.TransformFunc {
+	return func(obj interface{}) (interface{}, error) {
+		if p == nil {
+			return obj, nil
+		}
+
+		switch obj := obj.(type) {
+		case *cmapi.CertificateRequest:
+			p.shrinkMetadata(obj)
+			if !p.KeepFinishedRequestData && certificateRequestFinished(obj) {
+				obj.Spec.Request = nil
+				obj.Status.Certificate = nil
+				obj.Status.CA = nil
+			}
+		case *certificatesv1.CertificateSigningRequest:
+			p.shrinkMetadata(obj)
+			if !p.KeepFinishedRequestData {
+				if _, finished := csrFinishedAt(obj); finished {
+					obj.Spec.Request = nil
+					if len(obj.Status.Certificate) > 0 {
+						obj.Status.Certificate = cachedCertificatePlaceholder
+					}
+				}
+			}
+		case v1alpha1.Issuer:
+			p.shrinkMetadata(obj)
+		}
+
+		return obj, nil
+	}
+}
+
+func (p *CacheTransformPolicy) shrinkMetadata(obj client.Object) {
+	if !p.KeepManagedFields {
+		obj.SetManagedFields(compactManagedFields(obj.GetManagedFields()))
+	}
+
+	if annotations := obj.GetAnnotations(); !p.KeepLastAppliedConfiguration && annotations != nil {
+		if _, ok := annotations[corev1.LastAppliedConfigAnnotation]; ok {
+			delete(annotations, corev1.LastAppliedConfigAnnotation)
+			obj.SetAnnotations(annotations)
+		}
+	}
+}
+
+// certificateRequestFinished returns true if the CertificateRequest was issued,
+// failed or denied.
+func certificateRequestFinished(cr *cmapi.CertificateRequest) bool {
+	for _, condition := range cr.Status.Conditions {
+		switch {
+		case condition.Type == cmapi.CertificateRequestConditionReady && condition.Status == cmmeta.ConditionTrue,
+			condition.Type == cmapi.CertificateRequestConditionReady && condition.Reason == cmapi.CertificateRequestReasonFailed,
+			condition.Type == cmapi.CertificateRequestConditionReady && condition.Reason == cmapi.CertificateRequestReasonDenied,
+			condition.Type == cmapi.CertificateRequestConditionDenied && condition.Status == cmmeta.ConditionTrue:
+			return true
+		}
+	}
+	return false
+}
+
+// compactedFields is the subset of the fields of a managed fields entry that is
+// kept in the cache, see managesCondition.
+type compactedFields struct {
+	Status struct {
+		Conditions map[string]struct{} `json:"f:conditions"`
+	} `json:"f:status"`
+}
+
+// compactManagedFields reduces the managed fields entries to the status
+// conditions that they own, and drops the entries that own no status
+// conditions. Applying it to compacted entries returns equal entries.
+func compactManagedFields(entries []metav1.ManagedFieldsEntry) []metav1.ManagedFieldsEntry {
+	var compacted []metav1.ManagedFieldsEntry
+	for _, entry := range entries {
+		if entry.FieldsV1 == nil {
+			continue
+		}
+
+		var fields compactedFields
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil || len(fields.Status.Conditions) == 0 {
+			continue
+		}
+		raw, err := json.Marshal(fields)
+		if err != nil {
+			continue
+		}
+
+		compacted = append(compacted, metav1.ManagedFieldsEntry{
+			Manager:     entry.Manager,
+			Operation:   entry.Operation,
+			Time:        entry.Time,
+			Subresource: entry.Subresource,
+			FieldsType:  entry.FieldsType,
+			FieldsV1:    &metav1.FieldsV1{Raw: raw},
+		})
+	}
+	return compacted
+}
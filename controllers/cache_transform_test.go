@@ -0,0 +1,283 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	cmgen "github.com/cert-manager/cert-manager/test/unit/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+)
+
+func TestCacheTransformPolicyCertificateRequests(t *testing.T) {
+	t.Parallel()
+
+	appliedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	specFields := metav1.ManagedFieldsEntry{
+		Manager:    "cert-manager-certificates-request-manager",
+		Operation:  metav1.ManagedFieldsOperationApply,
+		Time:       &metav1.Time{Time: appliedAt},
+		FieldsType: "FieldsV1",
+		FieldsV1:   &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:request":{},"f:issuerRef":{"f:name":{}}}}`)},
+	}
+	statusFields := metav1.ManagedFieldsEntry{
+		Manager:     "issuer-lib",
+		Operation:   metav1.ManagedFieldsOperationApply,
+		Subresource: "status",
+		Time:        &metav1.Time{Time: appliedAt},
+		FieldsType:  "FieldsV1",
+		FieldsV1: &metav1.FieldsV1{Raw: []byte(
+			`{"f:status":{"f:certificate":{},"f:conditions":{"k:{\"type\":\"Ready\"}":{".":{},"f:message":{},"f:reason":{},"f:status":{},"f:type":{}}}}}`,
+		)},
+	}
+
+	newCR := func(conditions ...cmapi.CertificateRequestCondition) *cmapi.CertificateRequest {
+		mods := []cmgen.CertificateRequestModifier{
+			cmgen.SetCertificateRequestNamespace("ns1"),
+			cmgen.SetCertificateRequestCSR([]byte("csr")),
+			cmgen.SetCertificateRequestCertificate([]byte("certificate")),
+			cmgen.SetCertificateRequestCA([]byte("ca")),
+			cmgen.SetCertificateRequestAnnotations(map[string]string{
+				corev1.LastAppliedConfigAnnotation: "{...}",
+				"example.com/keep":                 "true",
+			}),
+		}
+		for _, condition := range conditions {
+			mods = append(mods, cmgen.AddCertificateRequestStatusCondition(condition))
+		}
+		cr := cmgen.CertificateRequest("cr1", mods...)
+		cr.ManagedFields = []metav1.ManagedFieldsEntry{specFields, statusFields}
+		return cr
+	}
+
+	ready := cmapi.CertificateRequestCondition{
+		Type:   cmapi.CertificateRequestConditionReady,
+		Status: cmmeta.ConditionTrue,
+		Reason: cmapi.CertificateRequestReasonIssued,
+	}
+	pending := cmapi.CertificateRequestCondition{
+		Type:   cmapi.CertificateRequestConditionReady,
+		Status: cmmeta.ConditionFalse,
+		Reason: cmapi.CertificateRequestReasonPending,
+	}
+	failed := cmapi.CertificateRequestCondition{
+		Type:   cmapi.CertificateRequestConditionReady,
+		Status: cmmeta.ConditionFalse,
+		Reason: cmapi.CertificateRequestReasonFailed,
+	}
+	denied := cmapi.CertificateRequestCondition{
+		Type:   cmapi.CertificateRequestConditionDenied,
+		Status: cmmeta.ConditionTrue,
+		Reason: "Denied",
+	}
+
+	tests := []struct {
+		name              string
+		policy            *CacheTransformPolicy
+		cr                *cmapi.CertificateRequest
+		expectDataRemoved bool
+		expectFieldsKept  bool
+		expectLastApplied bool
+	}{
+		{
+			name:   "pending-request-keeps-data",
+			policy: &CacheTransformPolicy{},
+			cr:     newCR(pending),
+		},
+		{
+			name:              "issued-request",
+			policy:            &CacheTransformPolicy{},
+			cr:                newCR(ready),
+			expectDataRemoved: true,
+		},
+		{
+			name:              "failed-request",
+			policy:            &CacheTransformPolicy{},
+			cr:                newCR(failed),
+			expectDataRemoved: true,
+		},
+		{
+			name:              "denied-request",
+			policy:            &CacheTransformPolicy{},
+			cr:                newCR(denied),
+			expectDataRemoved: true,
+		},
+		{
+			name: "keep-everything",
+			policy: &CacheTransformPolicy{
+				KeepManagedFields:            true,
+				KeepLastAppliedConfiguration: true,
+				KeepFinishedRequestData:      true,
+			},
+			cr:                newCR(ready),
+			expectFieldsKept:  true,
+			expectLastApplied: true,
+		},
+		{
+			name:              "nil-policy",
+			cr:                newCR(ready),
+			expectFieldsKept:  true,
+			expectLastApplied: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			transformed, err := tc.policy.Transform()(tc.cr)
+			require.NoError(t, err)
+			cr := transformed.(*cmapi.CertificateRequest)
+
+			if tc.expectDataRemoved {
+				assert.Nil(t, cr.Spec.Request)
+				assert.Nil(t, cr.Status.Certificate)
+				assert.Nil(t, cr.Status.CA)
+			} else {
+				assert.Equal(t, []byte("csr"), cr.Spec.Request)
+				assert.Equal(t, []byte("certificate"), cr.Status.Certificate)
+				assert.Equal(t, []byte("ca"), cr.Status.CA)
+			}
+
+			assert.Equal(t, "true", cr.Annotations["example.com/keep"])
+			_, hasLastApplied := cr.Annotations[corev1.LastAppliedConfigAnnotation]
+			assert.Equal(t, tc.expectLastApplied, hasLastApplied)
+
+			if tc.expectFieldsKept {
+				assert.Equal(t, []metav1.ManagedFieldsEntry{specFields, statusFields}, cr.ManagedFields)
+				return
+			}
+
+			// Only the status conditions of the field managers are kept, which
+			// is enough to detect the owners of the conditions.
+			require.Len(t, cr.ManagedFields, 1)
+			assert.Equal(t, "issuer-lib", cr.ManagedFields[0].Manager)
+			assert.Equal(t, "status", cr.ManagedFields[0].Subresource)
+			assert.Equal(t, appliedAt, cr.ManagedFields[0].Time.Time)
+			assert.JSONEq(t, `{"f:status":{"f:conditions":{"k:{\"type\":\"Ready\"}":{}}}}`, string(cr.ManagedFields[0].FieldsV1.Raw))
+			assert.True(t, managesCondition(cr.ManagedFields[0], "Ready"))
+
+			// The transform is idempotent.
+			again, err := tc.policy.Transform()(cr.DeepCopy())
+			require.NoError(t, err)
+			assert.Equal(t, cr, again)
+		})
+	}
+}
+
+func TestCacheTransformPolicyCertificateSigningRequests(t *testing.T) {
+	t.Parallel()
+
+	issued := &certificatesv1.CertificateSigningRequest{
+		Spec:   certificatesv1.CertificateSigningRequestSpec{Request: []byte("csr")},
+		Status: certificatesv1.CertificateSigningRequestStatus{Certificate: []byte("certificate")},
+	}
+	pending := &certificatesv1.CertificateSigningRequest{
+		Spec: certificatesv1.CertificateSigningRequestSpec{Request: []byte("csr")},
+		Status: certificatesv1.CertificateSigningRequestStatus{
+			Conditions: []certificatesv1.CertificateSigningRequestCondition{{
+				Type:   certificatesv1.CertificateApproved,
+				Status: corev1.ConditionTrue,
+			}},
+		},
+	}
+
+	transform := (&CacheTransformPolicy{}).Transform()
+
+	transformed, err := transform(issued)
+	require.NoError(t, err)
+	csr := transformed.(*certificatesv1.CertificateSigningRequest)
+	assert.Nil(t, csr.Spec.Request)
+	// The certificate is replaced with a placeholder, so that the CSR is still
+	// recognised as issued.
+	assert.Equal(t, cachedCertificatePlaceholder, csr.Status.Certificate)
+	assert.True(t, (&certificatesigningRequestObjectHelper{readOnlyObj: csr}).IsReady())
+
+	transformed, err = transform(pending)
+	require.NoError(t, err)
+	csr = transformed.(*certificatesv1.CertificateSigningRequest)
+	assert.Equal(t, []byte("csr"), csr.Spec.Request)
+}
+
+func TestCacheTransformPolicyOtherObjects(t *testing.T) {
+	t.Parallel()
+
+	managedFields := []metav1.ManagedFieldsEntry{{
+		Manager:    "kubectl",
+		FieldsType: "FieldsV1",
+		FieldsV1:   &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:url":{}}}`)},
+	}}
+	transform := (&CacheTransformPolicy{}).Transform()
+
+	// Issuers are shrunk.
+	issuer := &api.TestIssuer{ObjectMeta: metav1.ObjectMeta{ManagedFields: managedFields}}
+	transformed, err := transform(issuer)
+	require.NoError(t, err)
+	assert.Empty(t, transformed.(*api.TestIssuer).ManagedFields)
+
+	// Other objects and the tombstones of deleted objects are returned as-is.
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{ManagedFields: managedFields}}
+	transformed, err = transform(secret)
+	require.NoError(t, err)
+	assert.Equal(t, managedFields, transformed.(*corev1.Secret).ManagedFields)
+
+	tombstone := toolscache.DeletedFinalStateUnknown{Key: "ns1/cr1"}
+	transformed, err = transform(tombstone)
+	require.NoError(t, err)
+	assert.Equal(t, tombstone, transformed)
+}
+
+func TestCacheTransformPolicyApplyTo(t *testing.T) {
+	t.Parallel()
+
+	opts := cache.Options{}
+	(&CacheTransformPolicy{}).ApplyTo(&opts)
+	require.NotNil(t, opts.DefaultTransform)
+
+	// An existing DefaultTransform is applied after the transform of the policy.
+	var calledWith interface{}
+	opts = cache.Options{
+		DefaultTransform: func(obj interface{}) (interface{}, error) {
+			calledWith = obj
+			return obj, nil
+		},
+	}
+	(&CacheTransformPolicy{}).ApplyTo(&opts)
+
+	cr := cmgen.CertificateRequest("cr1",
+		cmgen.SetCertificateRequestCSR([]byte("csr")),
+		cmgen.AddCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+			Type:   cmapi.CertificateRequestConditionReady,
+			Status: cmmeta.ConditionTrue,
+		}),
+	)
+	transformed, err := opts.DefaultTransform(cr)
+	require.NoError(t, err)
+	require.Same(t, cr, calledWith)
+	assert.Nil(t, transformed.(*cmapi.CertificateRequest).Spec.Request)
+}
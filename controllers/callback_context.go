@@ -0,0 +1,37 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/upstream"
+)
+
+// callbackContext returns the context that is passed to the Sign and Check
+// functions, it carries the upstream configuration and the cluster resource
+// namespace when these are set.
+func callbackContext(ctx context.Context, upstreamConfig *upstream.Config, clusterResourceNamespace string) context.Context {
+	if upstreamConfig != nil {
+		ctx = upstream.IntoContext(ctx, upstreamConfig)
+	}
+	if clusterResourceNamespace != "" {
+		ctx = signer.WithClusterResourceNamespace(ctx, clusterResourceNamespace)
+	}
+	return ctx
+}
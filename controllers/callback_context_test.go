@@ -0,0 +1,48 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/testapi/testutil"
+	"github.com/cert-manager/issuer-lib/upstream"
+)
+
+func TestCallbackContext(t *testing.T) {
+	t.Parallel()
+
+	issuer := testutil.TestIssuer("issuer-1", testutil.SetTestIssuerNamespace("ns1"))
+	clusterIssuer := testutil.TestClusterIssuer("cluster-issuer-1")
+
+	ctx := callbackContext(context.TODO(), nil, "")
+	assert.Equal(t, &upstream.Config{}, upstream.FromContext(ctx))
+	assert.Equal(t, "", signer.ClusterResourceNamespaceFromContext(ctx))
+	assert.Equal(t, "ns1", signer.ResourceNamespace(ctx, issuer))
+
+	upstreamConfig := &upstream.Config{CAFile: "/etc/ca/ca.pem"}
+	ctx = callbackContext(context.TODO(), upstreamConfig, "cert-manager")
+	assert.Same(t, upstreamConfig, upstream.FromContext(ctx))
+	assert.Equal(t, "cert-manager", signer.ClusterResourceNamespaceFromContext(ctx))
+	assert.Equal(t, "ns1", signer.ResourceNamespace(ctx, issuer))
+	assert.Equal(t, types.NamespacedName{Namespace: "cert-manager", Name: "credentials"}, signer.ResourceName(ctx, clusterIssuer, "credentials"))
+}
@@ -0,0 +1,235 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/cert-manager/cert-manager/pkg/util/pki"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+// CanarySigningPolicy supplements the Check function of the issuer controllers
+// with a canary signature: after the checks succeeded, Sign is called for a
+// throwaway CertificateRequest with a freshly generated CSR, and the issuer only
+// becomes Ready if a certificate for the CSR is returned. This ensures that the
+// Ready condition reflects the ability of the CA to sign, instead of only its
+// reachability. The canary CertificateRequest and its private key are never
+// persisted, but the CA does issue (and might log or publish) the certificate.
+//
+// A PendingError returned by Sign for the canary request is handled as a
+// retryable error, so canary signing should not be enabled for issuer types
+// whose CA signs asynchronously.
+type CanarySigningPolicy struct {
+	// IssuerTypes are the issuer types for which a canary signature is required,
+	// matched using GetIssuerTypeIdentifier. All issuer types require a canary
+	// signature if it is empty.
+	IssuerTypes []v1alpha1.Issuer
+
+	// Sign is the function that signs the canary request. It defaults to the
+	// Sign function of the CombinedController and must be set when the policy is
+	// used with an IssuerReconciler directly.
+	Sign signer.Sign
+
+	// Interval is the minimum interval between the canary signatures of an
+	// issuer, defaults to 1 hour. A successful canary signature is reused until
+	// the interval has passed or the generation of the issuer changed.
+	Interval time.Duration
+
+	// KeyAlgorithm is the algorithm of the key of the canary CSR, one of RSA,
+	// ECDSA or Ed25519. Defaults to ECDSA (P-256).
+	KeyAlgorithm x509.PublicKeyAlgorithm
+
+	// Duration is the requested duration of the canary certificate, defaults to
+	// 1 hour.
+	Duration time.Duration
+
+	mu        sync.Mutex
+	succeeded map[canaryKey]time.Time
+}
+
+// canaryKey identifies the generation of an issuer for which a canary signature
+// succeeded.
+type canaryKey struct {
+	issuerGvk  schema.GroupVersionKind
+	issuerName types.NamespacedName
+	uid        types.UID
+	generation int64
+}
+
+// enabled returns true if the issuer type requires a canary signature. A nil
+// policy never requires a canary signature.
+func (p *CanarySigningPolicy) enabled(issuer v1alpha1.Issuer) bool {
+	if p == nil {
+		return false
+	}
+	if len(p.IssuerTypes) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(p.IssuerTypes, func(issuerType v1alpha1.Issuer) bool {
+		return issuerType.GetIssuerTypeIdentifier() == issuer.GetIssuerTypeIdentifier()
+	})
+}
+
+// check signs a canary request for the issuer, unless a canary signature for the
+// current generation of the issuer succeeded within the interval. The
+// defaultSign function is used if the policy has no Sign function. The returned
+// error is not classified yet.
+func (p *CanarySigningPolicy) check(ctx context.Context, issuer v1alpha1.Issuer, issuerGvk schema.GroupVersionKind, defaultSign signer.Sign, now time.Time) error {
+	if !p.enabled(issuer) {
+		return nil
+	}
+	sign := p.Sign
+	if sign == nil {
+		sign = defaultSign
+	}
+	if sign == nil {
+		return signer.PermanentError{Err: errors.New("canary signing is enabled, but no Sign function is set")}
+	}
+
+	interval := p.Interval
+	if interval == 0 {
+		interval = time.Hour
+	}
+
+	key := canaryKey{
+		issuerGvk:  issuerGvk,
+		issuerName: types.NamespacedName{Namespace: issuer.GetNamespace(), Name: issuer.GetName()},
+		uid:        issuer.GetUID(),
+		generation: issuer.GetGeneration(),
+	}
+
+	p.mu.Lock()
+	signedAt, ok := p.succeeded[key]
+	p.mu.Unlock()
+	if ok && now.Sub(signedAt) < interval {
+		return nil
+	}
+
+	if err := p.sign(ctx, sign, issuer, issuerGvk); err != nil {
+		canarySignatures.WithLabelValues(issuerGvk.Kind, "failed").Inc()
+		return err
+	}
+	canarySignatures.WithLabelValues(issuerGvk.Kind, "succeeded").Inc()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.succeeded == nil {
+		p.succeeded = map[canaryKey]time.Time{}
+	}
+	// Forget the canary signatures of the previous generations of the issuer.
+	for existing := range p.succeeded {
+		if existing.issuerGvk == key.issuerGvk && existing.issuerName == key.issuerName {
+			delete(p.succeeded, existing)
+		}
+	}
+	p.succeeded[key] = now
+	return nil
+}
+
+// sign calls Sign for a canary request and verifies that the returned
+// certificate belongs to the key of the canary CSR.
+func (p *CanarySigningPolicy) sign(ctx context.Context, sign signer.Sign, issuer v1alpha1.Issuer, issuerGvk schema.GroupVersionKind) error {
+	privateKey, err := p.generatePrivateKey()
+	if err != nil {
+		return fmt.Errorf("failed to generate canary private key: %w", err)
+	}
+
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: "issuer-lib-canary"},
+	}, privateKey)
+	if err != nil {
+		return fmt.Errorf("failed to create canary CSR: %w", err)
+	}
+
+	duration := p.Duration
+	if duration == 0 {
+		duration = time.Hour
+	}
+
+	canaryRequest := &cmapi.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "issuer-lib-canary-" + issuer.GetName(),
+			Namespace: issuer.GetNamespace(),
+		},
+		Spec: cmapi.CertificateRequestSpec{
+			Request:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr}),
+			Duration: &metav1.Duration{Duration: duration},
+			IssuerRef: cmmeta.ObjectReference{
+				Group: issuerGvk.Group,
+				Kind:  issuerGvk.Kind,
+				Name:  issuer.GetName(),
+			},
+			Usages: []cmapi.KeyUsage{cmapi.UsageDigitalSignature},
+		},
+	}
+
+	bundle, err := sign(ctx, signer.CertificateRequestObjectFromCertificateRequest(canaryRequest), issuer)
+	if pendingError := new(signer.PendingError); errors.As(err, pendingError) {
+		return signer.RetryAfterError{Err: fmt.Errorf("canary signature is pending: %w", err), RetryAfter: pendingError.RetryAfter}
+	} else if err != nil {
+		return fmt.Errorf("canary signature failed: %w", err)
+	}
+
+	certs, err := pki.DecodeX509CertificateChainBytes(bundle.ChainPEM)
+	if err != nil {
+		return fmt.Errorf("canary signature returned an invalid certificate: %w", err)
+	}
+	if len(certs) == 0 {
+		return errors.New("canary signature returned no certificate")
+	}
+	publicKey, ok := certs[0].PublicKey.(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !publicKey.Equal(privateKey.Public()) {
+		return errors.New("canary signature returned a certificate that does not match the canary CSR")
+	}
+	return nil
+}
+
+func (p *CanarySigningPolicy) generatePrivateKey() (crypto.Signer, error) {
+	switch p.KeyAlgorithm {
+	case x509.UnknownPublicKeyAlgorithm, x509.ECDSA:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case x509.RSA:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case x509.Ed25519:
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		return privateKey, err
+	default:
+		return nil, signer.PermanentError{Err: fmt.Errorf("unsupported canary key algorithm %s", p.KeyAlgorithm)}
+	}
+}
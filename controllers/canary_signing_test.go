@@ -0,0 +1,182 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+	"github.com/cert-manager/issuer-lib/internal/testapi/testutil"
+)
+
+// testCanarySign returns a Sign function that signs the CSR of the request
+// with a throwaway CA, or with a different key than the key of the CSR if
+// mismatch is true.
+func testCanarySign(t *testing.T, calls *int, mismatch bool) signer.Sign {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "canary-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+
+	return func(_ context.Context, cr signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+		*calls++
+
+		template, _, _, err := cr.GetRequest()
+		if err != nil {
+			return signer.PEMBundle{}, err
+		}
+		if mismatch {
+			template.PublicKey = caKey.Public()
+		}
+		template.SerialNumber = big.NewInt(2)
+		template.NotBefore = time.Now().Add(-time.Hour)
+
+		der, err := x509.CreateCertificate(rand.Reader, template, caTemplate, template.PublicKey, caKey)
+		if err != nil {
+			return signer.PEMBundle{}, err
+		}
+		return signer.PEMBundle{ChainPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}, nil
+	}
+}
+
+func TestCanarySigningPolicyCheck(t *testing.T) {
+	t.Parallel()
+
+	issuerGvk := schema.GroupVersionKind{Group: "testing.cert-manager.io", Version: "api", Kind: "TestIssuer"}
+	issuer := testutil.TestIssuer("issuer-1", testutil.SetTestIssuerNamespace("ns1"), testutil.SetTestIssuerGeneration(1))
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("nil policy is disabled", func(t *testing.T) {
+		t.Parallel()
+
+		var policy *CanarySigningPolicy
+		require.NoError(t, policy.check(context.TODO(), issuer, issuerGvk, nil, now))
+	})
+
+	t.Run("other issuer types are not checked", func(t *testing.T) {
+		t.Parallel()
+
+		policy := &CanarySigningPolicy{IssuerTypes: []v1alpha1.Issuer{&api.TestClusterIssuer{}}}
+		require.NoError(t, policy.check(context.TODO(), issuer, issuerGvk, nil, now))
+	})
+
+	t.Run("missing sign function is a permanent error", func(t *testing.T) {
+		t.Parallel()
+
+		policy := &CanarySigningPolicy{IssuerTypes: []v1alpha1.Issuer{&api.TestIssuer{}}}
+		err := policy.check(context.TODO(), issuer, issuerGvk, nil, now)
+		require.ErrorAs(t, err, &signer.PermanentError{})
+	})
+
+	for _, keyAlgorithm := range []x509.PublicKeyAlgorithm{x509.UnknownPublicKeyAlgorithm, x509.RSA, x509.ECDSA, x509.Ed25519} {
+		t.Run("successful signature with "+keyAlgorithm.String(), func(t *testing.T) {
+			t.Parallel()
+
+			calls := 0
+			policy := &CanarySigningPolicy{KeyAlgorithm: keyAlgorithm}
+			require.NoError(t, policy.check(context.TODO(), issuer, issuerGvk, testCanarySign(t, &calls, false), now))
+			assert.Equal(t, 1, calls)
+		})
+	}
+
+	t.Run("unsupported key algorithm is a permanent error", func(t *testing.T) {
+		t.Parallel()
+
+		calls := 0
+		policy := &CanarySigningPolicy{KeyAlgorithm: x509.DSA}
+		err := policy.check(context.TODO(), issuer, issuerGvk, testCanarySign(t, &calls, false), now)
+		require.ErrorAs(t, err, &signer.PermanentError{})
+		assert.Equal(t, 0, calls)
+	})
+
+	t.Run("policy sign function takes precedence", func(t *testing.T) {
+		t.Parallel()
+
+		policyCalls, defaultCalls := 0, 0
+		policy := &CanarySigningPolicy{Sign: testCanarySign(t, &policyCalls, false)}
+		require.NoError(t, policy.check(context.TODO(), issuer, issuerGvk, testCanarySign(t, &defaultCalls, false), now))
+		assert.Equal(t, 1, policyCalls)
+		assert.Equal(t, 0, defaultCalls)
+	})
+
+	t.Run("certificate for another key fails", func(t *testing.T) {
+		t.Parallel()
+
+		calls := 0
+		policy := &CanarySigningPolicy{}
+		err := policy.check(context.TODO(), issuer, issuerGvk, testCanarySign(t, &calls, true), now)
+		require.EqualError(t, err, "canary signature returned a certificate that does not match the canary CSR")
+	})
+
+	t.Run("pending signature is retried", func(t *testing.T) {
+		t.Parallel()
+
+		policy := &CanarySigningPolicy{}
+		err := policy.check(context.TODO(), issuer, issuerGvk, func(context.Context, signer.CertificateRequestObject, v1alpha1.Issuer) (signer.PEMBundle, error) {
+			return signer.PEMBundle{}, signer.PendingError{Err: errors.New("waiting for approval"), RetryAfter: 5 * time.Minute}
+		}, now)
+
+		retryAfterError := signer.RetryAfterError{}
+		require.ErrorAs(t, err, &retryAfterError)
+		assert.Equal(t, 5*time.Minute, retryAfterError.RetryAfter)
+	})
+
+	t.Run("successful signature is reused within the interval", func(t *testing.T) {
+		t.Parallel()
+
+		calls := 0
+		sign := testCanarySign(t, &calls, false)
+		policy := &CanarySigningPolicy{Interval: 10 * time.Minute}
+
+		require.NoError(t, policy.check(context.TODO(), issuer, issuerGvk, sign, now))
+		require.NoError(t, policy.check(context.TODO(), issuer, issuerGvk, sign, now.Add(5*time.Minute)))
+		assert.Equal(t, 1, calls)
+
+		// A new generation of the issuer requires a new canary signature.
+		updatedIssuer := testutil.TestIssuerFrom(issuer, testutil.SetTestIssuerGeneration(2))
+		require.NoError(t, policy.check(context.TODO(), updatedIssuer, issuerGvk, sign, now.Add(5*time.Minute)))
+		assert.Equal(t, 2, calls)
+
+		require.NoError(t, policy.check(context.TODO(), updatedIssuer, issuerGvk, sign, now.Add(20*time.Minute)))
+		assert.Equal(t, 3, calls)
+		assert.Len(t, policy.succeeded, 1)
+	})
+}
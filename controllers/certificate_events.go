@@ -0,0 +1,103 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// issuerWaitEventReasons are the reasons of the events that are recorded on a
+// request while it is waiting for its issuer.
+var issuerWaitEventReasons = map[string]bool{
+	eventRequestWaitingForIssuerExist: true,
+	eventRequestWaitingForIssuerReady: true,
+	eventRequestIssuerPaused:          true,
+	eventRequestIssuerFailed:          true,
+}
+
+// certificateEventRecorder is an EventRecorder that records the events about the
+// issuer of a CertificateRequest on the Certificate that owns the CertificateRequest
+// as well, so application teams see why their certificate is not issued on the
+// resource they actually manage. The Certificate is resolved using the owner
+// references of the CertificateRequest, it is not read from the API server.
+type certificateEventRecorder struct {
+	record.EventRecorder
+}
+
+var _ record.EventRecorder = certificateEventRecorder{}
+
+func (r certificateEventRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	r.EventRecorder.Event(object, eventtype, reason, message)
+	r.recordOnCertificate(object, eventtype, reason, message)
+}
+
+func (r certificateEventRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.Event(object, eventtype, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+func (r certificateEventRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.EventRecorder.AnnotatedEventf(object, annotations, eventtype, reason, messageFmt, args...)
+	r.recordOnCertificate(object, eventtype, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+func (r certificateEventRecorder) recordOnCertificate(object runtime.Object, eventtype, reason, message string) {
+	if !issuerWaitEventReasons[reason] {
+		return
+	}
+
+	cr, ok := object.(*cmapi.CertificateRequest)
+	if !ok {
+		return
+	}
+
+	certificate := owningCertificate(cr)
+	if certificate == nil {
+		return
+	}
+
+	r.EventRecorder.Event(certificate, eventtype, reason, fmt.Sprintf("CertificateRequest %s: %s", cr.Name, message))
+}
+
+// owningCertificate returns a Certificate that only contains the identity of the
+// Certificate that owns the CertificateRequest, or nil if the CertificateRequest
+// is not owned by a Certificate.
+func owningCertificate(cr *cmapi.CertificateRequest) *cmapi.Certificate {
+	for _, ownerReference := range cr.OwnerReferences {
+		if ownerReference.Kind != cmapi.CertificateKind || ownerReference.APIVersion != cmapi.SchemeGroupVersion.String() {
+			continue
+		}
+
+		return &cmapi.Certificate{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: ownerReference.APIVersion,
+				Kind:       ownerReference.Kind,
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: cr.Namespace,
+				Name:      ownerReference.Name,
+				UID:       ownerReference.UID,
+			},
+		}
+	}
+
+	return nil
+}
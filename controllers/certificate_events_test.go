@@ -0,0 +1,81 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"testing"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmgen "github.com/cert-manager/cert-manager/test/unit/gen"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// objectEventRecorder records the events together with the object they are
+// recorded on.
+type objectEventRecorder struct {
+	record.EventRecorder
+	events []string
+}
+
+func (r *objectEventRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	obj := object.(client.Object)
+	r.events = append(r.events, fmt.Sprintf("%T %s/%s %s %s %s", obj, obj.GetNamespace(), obj.GetName(), eventtype, reason, message))
+}
+
+func TestCertificateEventRecorder(t *testing.T) {
+	t.Parallel()
+
+	ownedCR := cmgen.CertificateRequest(
+		"cr1",
+		cmgen.SetCertificateRequestNamespace("ns1"),
+	)
+	ownedCR.OwnerReferences = []metav1.OwnerReference{
+		*metav1.NewControllerRef(
+			&cmapi.Certificate{ObjectMeta: metav1.ObjectMeta{Name: "cert1", UID: "uid1"}},
+			cmapi.SchemeGroupVersion.WithKind(cmapi.CertificateKind),
+		),
+	}
+
+	unownedCR := cmgen.CertificateRequest(
+		"cr2",
+		cmgen.SetCertificateRequestNamespace("ns1"),
+	)
+
+	recorder := &objectEventRecorder{}
+	certificateRecorder := certificateEventRecorder{recorder}
+
+	certificateRecorder.Event(ownedCR, corev1.EventTypeNormal, eventRequestWaitingForIssuerReady, "Waiting for issuer to become ready.")
+	certificateRecorder.Event(ownedCR, corev1.EventTypeWarning, eventRequestRetryableError, "Failed to sign.")
+	certificateRecorder.Event(unownedCR, corev1.EventTypeNormal, eventRequestIssuerPaused, "Issuer is paused.")
+
+	assert.Equal(t, []string{
+		"*v1.CertificateRequest ns1/cr1 Normal WaitingForIssuerReady Waiting for issuer to become ready.",
+		"*v1.Certificate ns1/cert1 Normal WaitingForIssuerReady CertificateRequest cr1: Waiting for issuer to become ready.",
+		"*v1.CertificateRequest ns1/cr1 Warning RetryableError Failed to sign.",
+		"*v1.CertificateRequest ns1/cr2 Normal IssuerPaused Issuer is paused.",
+	}, recorder.events)
+
+	certificate := owningCertificate(ownedCR)
+	assert.Equal(t, "uid1", string(certificate.UID))
+	assert.Nil(t, owningCertificate(unownedCR))
+}
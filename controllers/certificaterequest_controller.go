@@ -25,6 +25,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	v1alpha1 "github.com/cert-manager/issuer-lib/api/v1alpha1"
 )
@@ -39,6 +40,16 @@ type CertificateRequestReconciler struct {
 	// ca.crt is discouraged. Instead, the CA certificate should be provided
 	// separately using a tool such as trust-manager.
 	SetCAOnCertificateRequest bool
+
+	// CRDCompatibility is optional. If set, the status patches are adjusted to
+	// the schema of an older CertificateRequest CRD.
+	CRDCompatibility *CRDCompatibility
+	// DetectCRDCompatibility enables deriving the CRDCompatibility from the schema
+	// of the installed CertificateRequest CRD when the controller is set up, if
+	// CRDCompatibility is not set. This requires get permissions on the
+	// CertificateRequest CRD, and the controller has to be restarted after the
+	// CRD was upgraded.
+	DetectCRDCompatibility bool
 }
 
 func (r *CertificateRequestReconciler) matchIssuerType(requestObject client.Object) (v1alpha1.Issuer, types.NamespacedName, error) {
@@ -82,6 +93,10 @@ func (r *CertificateRequestReconciler) Init() *CertificateRequestReconciler {
 			return &certificateRequestObjectHelper{
 				readOnlyObj:               o.(*cmapi.CertificateRequest),
 				setCAOnCertificateRequest: r.SetCAOnCertificateRequest,
+				crdCompatibility:          r.CRDCompatibility,
+				messages:                  r.Messages,
+				messageStabilization:      r.MessageStabilizationPolicy,
+				redaction:                 r.Redaction,
 			}
 		},
 	)
@@ -94,6 +109,29 @@ func (r *CertificateRequestReconciler) SetupWithManager(ctx context.Context, mgr
 		return err
 	}
 
+	if r.DetectCRDCompatibility && r.CRDCompatibility == nil {
+		// The CRD is read without the cache of the manager, using a reader with
+		// its own scheme.
+		crdReader, err := newCRDReader(mgr.GetConfig(), mgr.GetHTTPClient())
+		if err != nil {
+			return err
+		}
+
+		compatibility, err := DetectCRDCompatibility(ctx, crdReader)
+		if err != nil {
+			return err
+		}
+
+		if compatibility != nil {
+			log.FromContext(ctx).Info(
+				"Adjusting the CertificateRequest status patches to the installed CRD",
+				"atomicConditions", compatibility.AtomicConditions,
+				"unsupportedStatusFields", compatibility.UnsupportedStatusFields,
+			)
+		}
+		r.CRDCompatibility = compatibility
+	}
+
 	r.Init()
 
 	return r.RequestController.SetupWithManager(
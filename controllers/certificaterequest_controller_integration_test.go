@@ -370,6 +370,141 @@ func TestCertificateRequestControllerIntegrationSetCondition(t *testing.T) {
 	require.Equal(t, uint64(3), atomic.LoadUint64(&counter))
 }
 
+// TestCertificateRequestControllerIntegrationSingleStatusPatchPerReconcile runs
+// the CertificateRequestController against a real Kubernetes API server and
+// counts the status patches, to show that every reconcile results in a single
+// API write, even if it changes multiple conditions.
+func TestCertificateRequestControllerIntegrationSingleStatusPatchPerReconcile(t *testing.T) {
+	t.Parallel()
+
+	fieldOwner := "cr-single-status-patch"
+
+	ctx := testcontext.ForTest(t)
+	kubeClients := testresource.KubeClients(t, nil)
+
+	signCounter := uint64(0)
+	statusPatchCounter := uint64(0)
+	var managerClient client.Client
+	ctx = setupControllersAPIServerAndClient(t, ctx, kubeClients,
+		func(mgr ctrl.Manager) controllerInterface {
+			managerClient = mgr.GetClient()
+			return &CertificateRequestReconciler{
+				RequestController: RequestController{
+					IssuerTypes:        []v1alpha1.Issuer{&api.TestIssuer{}},
+					ClusterIssuerTypes: []v1alpha1.Issuer{&api.TestClusterIssuer{}},
+					FieldOwner:         fieldOwner,
+					MaxRetryDuration:   time.Minute,
+					EventSource:        kubeutil.NewEventStore(),
+					Client: statusPatchCountingClient{
+						Client:  mgr.GetClient(),
+						counter: &statusPatchCounter,
+					},
+					Sign: func(_ context.Context, cr signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+						// The first call sets a custom condition and keeps the
+						// request pending, the second call issues the certificate.
+						if atomic.AddUint64(&signCounter, 1) == 1 {
+							return signer.PEMBundle{}, signer.SetCertificateRequestConditionError{
+								Err:           signer.PendingError{Err: fmt.Errorf("[pending]")},
+								ConditionType: "[condition type]",
+								Status:        cmmeta.ConditionTrue,
+								Reason:        "[reason]",
+							}
+						}
+						return signer.PEMBundle{
+							ChainPEM: []byte("cert"),
+						}, nil
+					},
+					EventRecorder: record.NewFakeRecorder(100),
+					Clock:         clock.RealClock{},
+				},
+			}
+		},
+	)
+
+	crName := types.NamespacedName{
+		Name:      "cr1",
+		Namespace: "single-status-patch",
+	}
+
+	t.Logf("Creating a namespace: %s", crName.Namespace)
+	createNS(t, ctx, kubeClients.Client, crName.Namespace)
+
+	cr := cmgen.CertificateRequest(
+		crName.Name,
+		cmgen.SetCertificateRequestNamespace(crName.Namespace),
+		cmgen.SetCertificateRequestCSR([]byte("doo")),
+		cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+			Name:  "issuer-1",
+			Kind:  "TestIssuer",
+			Group: api.SchemeGroupVersion.Group,
+		}),
+	)
+
+	t.Log("Creating a Ready Issuer and waiting for the controller to observe it")
+	issuer := createIssuerForCR(t, ctx, kubeClients.Client, cr)
+	markIssuerReady(t, ctx, kubeClients.Client, clock.RealClock{}, fieldOwner, issuer)
+	require.Eventually(t, func() bool {
+		cachedIssuer := &api.TestIssuer{}
+		if err := managerClient.Get(ctx, client.ObjectKeyFromObject(issuer), cachedIssuer); err != nil {
+			return false
+		}
+		readyCondition := conditions.GetIssuerStatusCondition(cachedIssuer.Status.Conditions, cmapi.IssuerConditionReady)
+		return readyCondition != nil && readyCondition.Status == cmmeta.ConditionTrue
+	}, 10*time.Second, 10*time.Millisecond)
+
+	checkComplete := kubeClients.StartObjectWatch(t, ctx, cr)
+	t.Log("Creating & approving the CertificateRequest")
+	createApprovedCR(t, ctx, kubeClients.Client, cr)
+	t.Log("Waiting for the controller to marks the CertificateRequest as Ready")
+	err := checkComplete(func(obj runtime.Object) error {
+		readyCondition := cmutil.GetCertificateRequestCondition(obj.(*cmapi.CertificateRequest), cmapi.CertificateRequestConditionReady)
+
+		if (readyCondition == nil) ||
+			(readyCondition.Status != cmmeta.ConditionTrue) ||
+			(readyCondition.Reason != cmapi.CertificateRequestReasonIssued) {
+			return fmt.Errorf("incorrect ready condition: %v", readyCondition)
+		}
+
+		customCondition := cmutil.GetCertificateRequestCondition(obj.(*cmapi.CertificateRequest), "[condition type]")
+		if (customCondition == nil) ||
+			(customCondition.Status != cmmeta.ConditionTrue) {
+			return fmt.Errorf("incorrect custom condition: %v", customCondition)
+		}
+
+		return nil
+	}, watch.Added, watch.Modified)
+	require.NoError(t, err)
+
+	// One status patch sets the custom condition together with the initialized
+	// and Pending Ready condition, the other one marks the request as Issued.
+	require.Equal(t, uint64(2), atomic.LoadUint64(&signCounter))
+	require.Equal(t, uint64(2), atomic.LoadUint64(&statusPatchCounter))
+}
+
+// statusPatchCountingClient counts the status patches that are sent to the
+// API server.
+type statusPatchCountingClient struct {
+	client.Client
+	counter *uint64
+}
+
+func (c statusPatchCountingClient) Status() client.SubResourceWriter {
+	return statusPatchCountingWriter{
+		SubResourceWriter: c.Client.Status(),
+		counter:           c.counter,
+	}
+}
+
+type statusPatchCountingWriter struct {
+	client.SubResourceWriter
+	counter *uint64
+}
+
+func (w statusPatchCountingWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+	atomic.AddUint64(w.counter, 1)
+	return w.SubResourceWriter.Patch(ctx, obj, patch, opts...)
+}
+
 func createApprovedCR(t *testing.T, ctx context.Context, kc client.Client, cr *cmapi.CertificateRequest) {
 	t.Helper()
 
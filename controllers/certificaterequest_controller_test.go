@@ -18,11 +18,18 @@ package controllers
 
 import (
 	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	cmutil "github.com/cert-manager/cert-manager/pkg/api/util"
 	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
 	cmgen "github.com/cert-manager/cert-manager/test/unit/gen"
@@ -31,12 +38,14 @@ import (
 	"github.com/stretchr/testify/require"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	clocktesting "k8s.io/utils/clock/testing"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/cert-manager/issuer-lib/api/v1alpha1"
@@ -46,6 +55,7 @@ import (
 	"github.com/cert-manager/issuer-lib/internal/testapi/api"
 	"github.com/cert-manager/issuer-lib/internal/testapi/testutil"
 	"github.com/cert-manager/issuer-lib/internal/tests/errormatch"
+	"github.com/cert-manager/issuer-lib/testing/ssafake"
 )
 
 func TestCertificateRequestReconcilerReconcile(t *testing.T) {
@@ -56,6 +66,16 @@ func TestCertificateRequestReconcilerReconcile(t *testing.T) {
 	type testCase struct {
 		name                string
 		sign                signer.Sign
+		retryPolicy         *RetryPolicy
+		messages            *MessageCatalog
+		ignore              signer.IgnoreCertificateRequest
+		ignoredReason       signer.IgnoredCertificateRequestReason
+		readinessRegistry   kubeutil.ReadinessRegistry
+		errorClassifier     signer.ErrorClassifier
+		extensionPolicy     *CriticalExtensionPolicy
+		keyAlgorithms       signer.SupportedKeyAlgorithms
+		quotaPolicy         *QuotaPolicy
+		failOnIssuerFailed  *time.Duration
 		objects             []client.Object
 		validateError       *errormatch.Matcher
 		expectedResult      reconcile.Result
@@ -126,6 +146,15 @@ func TestCertificateRequestReconcilerReconcile(t *testing.T) {
 		},
 	)
 
+	readinessRegistry := func(issuer *api.TestIssuer, generation int64, ready bool) kubeutil.ReadinessRegistry {
+		registry := kubeutil.NewReadinessRegistry()
+		registry.SetReadiness(
+			api.SchemeGroupVersion.WithKind("TestIssuer"), client.ObjectKeyFromObject(issuer),
+			generation, ready,
+		)
+		return registry
+	}
+
 	successSigner := func(cert string) signer.Sign {
 		return func(_ context.Context, _ signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
 			return signer.PEMBundle{
@@ -217,6 +246,60 @@ func TestCertificateRequestReconcilerReconcile(t *testing.T) {
 		},
 
 		// Initialize the CertificateRequest Ready condition if it is missing.
+		// Ignore the request if IgnoreCertificateRequest returns true.
+		{
+			name: "ignore-certificate-request",
+			ignore: func(_ context.Context, _ signer.CertificateRequestObject, _ schema.GroupVersionKind, _ types.NamespacedName) (bool, error) {
+				return true, nil
+			},
+			objects: []client.Object{
+				cmgen.CertificateRequestFrom(cr1,
+					cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+						Name:  issuer1.Name,
+						Group: api.SchemeGroupVersion.Group,
+					}),
+				),
+				testutil.TestIssuerFrom(issuer1),
+			},
+		},
+
+		// Set the Ignored condition if IgnoreCertificateRequest returns true and
+		// IgnoredCertificateRequestReason is set.
+		{
+			name: "ignore-certificate-request-with-reason",
+			ignore: func(_ context.Context, _ signer.CertificateRequestObject, _ schema.GroupVersionKind, _ types.NamespacedName) (bool, error) {
+				return true, nil
+			},
+			ignoredReason: func(_ context.Context, _ signer.CertificateRequestObject, _ schema.GroupVersionKind, issuerName types.NamespacedName) (string, string) {
+				return "OtherRegion", fmt.Sprintf("Issuer %s is handled by another region", issuerName.Name)
+			},
+			objects: []client.Object{
+				cmgen.CertificateRequestFrom(cr1,
+					cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+						Name:  issuer1.Name,
+						Group: api.SchemeGroupVersion.Group,
+					}),
+				),
+				testutil.TestIssuerFrom(issuer1),
+			},
+			expectedStatusPatch: &cmapi.CertificateRequestStatus{
+				Conditions: []cmapi.CertificateRequestCondition{
+					{
+						Type:               v1alpha1.CertificateRequestConditionTypeIgnored,
+						Status:             cmmeta.ConditionTrue,
+						Reason:             "OtherRegion",
+						Message:            "Issuer issuer-1 is handled by another region",
+						LastTransitionTime: &fakeTimeObj2,
+					},
+				},
+			},
+			expectedEvents: []string{
+				"Normal Ignored Issuer issuer-1 is handled by another region",
+			},
+		},
+
+		// The Ready condition is initialized and updated to the next state in
+		// the same reconcile, so only a single status patch is applied.
 		{
 			name: "initialize-ready-condition",
 			objects: []client.Object{
@@ -228,13 +311,43 @@ func TestCertificateRequestReconcilerReconcile(t *testing.T) {
 				Conditions: []cmapi.CertificateRequestCondition{
 					{
 						Type:               cmapi.CertificateRequestConditionReady,
-						Status:             cmmeta.ConditionUnknown,
-						Reason:             v1alpha1.CertificateRequestConditionReasonInitializing,
-						Message:            fieldOwner + " has started reconciling this CertificateRequest",
+						Status:             cmmeta.ConditionFalse,
+						Reason:             cmapi.CertificateRequestReasonPending,
+						Message:            "testissuers.testing.cert-manager.io \"\" not found. Waiting for it to be created.",
+						LastTransitionTime: &fakeTimeObj2,
+					},
+				},
+			},
+			expectedEvents: []string{
+				"Normal WaitingForIssuerExist testissuers.testing.cert-manager.io \"\" not found. Waiting for it to be created.",
+			},
+		},
+
+		{
+			name: "initialize-ready-condition-and-issue",
+			sign: successSigner("a-signed-certificate"),
+			objects: []client.Object{
+				cmgen.CertificateRequestFrom(cr1, func(cr *cmapi.CertificateRequest) {
+					removeCertificateRequestCondition(cr, cmapi.CertificateRequestConditionReady)
+					cr.Spec.IssuerRef.Name = issuer1.Name
+				}),
+				testutil.TestIssuerFrom(issuer1),
+			},
+			expectedStatusPatch: &cmapi.CertificateRequestStatus{
+				Certificate: []byte("a-signed-certificate"),
+				Conditions: []cmapi.CertificateRequestCondition{
+					{
+						Type:               cmapi.CertificateRequestConditionReady,
+						Status:             cmmeta.ConditionTrue,
+						Reason:             cmapi.CertificateRequestReasonIssued,
+						Message:            "Succeeded signing the CertificateRequest",
 						LastTransitionTime: &fakeTimeObj2,
 					},
 				},
 			},
+			expectedEvents: []string{
+				"Normal Issued Succeeded signing the CertificateRequest",
+			},
 		},
 
 		// If denied, set Ready condition status to false and reason to denied.
@@ -264,6 +377,50 @@ func TestCertificateRequestReconcilerReconcile(t *testing.T) {
 			},
 		},
 
+		// If denied by an approver, include the approver and the reason for the denial.
+		{
+			name: "set-ready-denied-by-approver",
+			objects: []client.Object{
+				cmgen.CertificateRequestFrom(cr1,
+					cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+						Type:    cmapi.CertificateRequestConditionDenied,
+						Status:  cmmeta.ConditionTrue,
+						Reason:  "policy.cert-manager.io",
+						Message: "No policy approved this request.",
+					}),
+					func(cr *cmapi.CertificateRequest) {
+						cr.ManagedFields = []metav1.ManagedFieldsEntry{
+							{
+								Manager:     "cert-manager-approver-policy",
+								Operation:   metav1.ManagedFieldsOperationUpdate,
+								Subresource: "status",
+								Time:        &fakeTimeObj1,
+								FieldsType:  "FieldsV1",
+								FieldsV1: &metav1.FieldsV1{
+									Raw: []byte(`{"f:status":{"f:conditions":{".":{},"k:{\"type\":\"Denied\"}":{".":{},"f:reason":{},"f:message":{}}}}}`),
+								},
+							},
+						}
+					},
+				),
+			},
+			expectedStatusPatch: &cmapi.CertificateRequestStatus{
+				Conditions: []cmapi.CertificateRequestCondition{
+					{
+						Type:               cmapi.CertificateRequestConditionReady,
+						Status:             cmmeta.ConditionFalse,
+						Reason:             cmapi.CertificateRequestReasonDenied,
+						Message:            "Detected that the CertificateRequest is denied by cert-manager-approver-policy, so it will never be Ready. Denied condition is \"policy.cert-manager.io\": No policy approved this request.",
+						LastTransitionTime: &fakeTimeObj2,
+					},
+				},
+				FailureTime: &fakeTimeObj2,
+			},
+			expectedEvents: []string{
+				"Warning PermanentError Detected that the CertificateRequest is denied by cert-manager-approver-policy, so it will never be Ready. Denied condition is \"policy.cert-manager.io\": No policy approved this request.",
+			},
+		},
+
 		// If issuer is missing, set Ready condition status to false and reason to pending.
 		{
 			name: "set-ready-pending-missing-issuer",
@@ -322,6 +479,85 @@ func TestCertificateRequestReconcilerReconcile(t *testing.T) {
 			},
 		},
 
+		// If the issuer failed permanently for longer than the grace period, fail the request.
+		{
+			name:               "set-ready-failed-issuer-failed",
+			failOnIssuerFailed: ptr.To(time.Hour),
+			objects: []client.Object{
+				cmgen.CertificateRequestFrom(cr1,
+					cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+						Name:  issuer1.Name,
+						Group: api.SchemeGroupVersion.Group,
+					}),
+				),
+				testutil.TestIssuerFrom(issuer1,
+					testutil.SetTestIssuerStatusCondition(
+						fakeClock1,
+						cmapi.IssuerConditionReady,
+						cmmeta.ConditionFalse,
+						v1alpha1.IssuerConditionReasonFailed,
+						"Failed permanently: [MESSAGE]",
+					),
+				),
+			},
+			expectedStatusPatch: &cmapi.CertificateRequestStatus{
+				Conditions: []cmapi.CertificateRequestCondition{
+					{
+						Type:               cmapi.CertificateRequestConditionReady,
+						Status:             cmmeta.ConditionFalse,
+						Reason:             cmapi.CertificateRequestReasonFailed,
+						Message:            "The issuer failed permanently, so the CertificateRequest will never be Ready: Failed permanently: [MESSAGE]",
+						LastTransitionTime: &fakeTimeObj2,
+					},
+				},
+				FailureTime: &fakeTimeObj2,
+			},
+			validateError: errormatch.ErrorContains("terminal error: issuer failed permanently: Failed permanently: [MESSAGE]"),
+			expectedEvents: []string{
+				"Warning IssuerFailed The issuer failed permanently, so the CertificateRequest will never be Ready: Failed permanently: [MESSAGE]",
+			},
+		},
+
+		// If the issuer failed permanently within the grace period, wait for it to recover.
+		{
+			name:               "set-ready-pending-issuer-failed-within-grace-period",
+			failOnIssuerFailed: ptr.To(5 * time.Hour),
+			objects: []client.Object{
+				cmgen.CertificateRequestFrom(cr1,
+					cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+						Name:  issuer1.Name,
+						Group: api.SchemeGroupVersion.Group,
+					}),
+				),
+				testutil.TestIssuerFrom(issuer1,
+					testutil.SetTestIssuerStatusCondition(
+						fakeClock1,
+						cmapi.IssuerConditionReady,
+						cmmeta.ConditionFalse,
+						v1alpha1.IssuerConditionReasonFailed,
+						"Failed permanently: [MESSAGE]",
+					),
+				),
+			},
+			expectedStatusPatch: &cmapi.CertificateRequestStatus{
+				Conditions: []cmapi.CertificateRequestCondition{
+					{
+						Type:               cmapi.CertificateRequestConditionReady,
+						Status:             cmmeta.ConditionFalse,
+						Reason:             cmapi.CertificateRequestReasonPending,
+						Message:            "Waiting for issuer to become ready. Current issuer ready condition is \"Failed\": Failed permanently: [MESSAGE].",
+						LastTransitionTime: &fakeTimeObj2,
+					},
+				},
+			},
+			expectedResult: reconcile.Result{
+				RequeueAfter: time.Hour,
+			},
+			expectedEvents: []string{
+				"Normal WaitingForIssuerReady Waiting for issuer to become ready. Current issuer ready condition is \"Failed\": Failed permanently: [MESSAGE].",
+			},
+		},
+
 		// If issuer is not ready, set Ready condition status to false and reason to pending.
 		{
 			name: "set-ready-pending-issuer-is-not-ready",
@@ -358,10 +594,9 @@ func TestCertificateRequestReconcilerReconcile(t *testing.T) {
 			},
 		},
 
-		// If issuer's ready condition is outdated, set Ready condition status to false and reason
-		// to pending.
+		// If issuer is paused, set Ready condition status to false and reason to IssuerPaused.
 		{
-			name: "set-ready-pending-issuer-ready-outdated",
+			name: "set-ready-pending-issuer-is-paused",
 			objects: []client.Object{
 				cmgen.CertificateRequestFrom(cr1,
 					cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
@@ -370,7 +605,9 @@ func TestCertificateRequestReconcilerReconcile(t *testing.T) {
 					}),
 				),
 				testutil.TestIssuerFrom(issuer1,
-					testutil.SetTestIssuerGeneration(issuer1.Generation+1),
+					testutil.SetTestIssuerAnnotations(map[string]string{
+						v1alpha1.IssuerPausedAnnotationKey: "true",
+					}),
 				),
 			},
 			expectedStatusPatch: &cmapi.CertificateRequestStatus{
@@ -378,89 +615,584 @@ func TestCertificateRequestReconcilerReconcile(t *testing.T) {
 					{
 						Type:               cmapi.CertificateRequestConditionReady,
 						Status:             cmmeta.ConditionFalse,
-						Reason:             cmapi.CertificateRequestReasonPending,
-						Message:            "Waiting for issuer to become ready. Current issuer ready condition is outdated.",
+						Reason:             v1alpha1.CertificateRequestConditionReasonIssuerPaused,
+						Message:            "Waiting for issuer to be unpaused.",
 						LastTransitionTime: &fakeTimeObj2,
 					},
 				},
 			},
 			expectedEvents: []string{
-				"Normal WaitingForIssuerReady Waiting for issuer to become ready. Current issuer ready condition is outdated.",
+				"Normal IssuerPaused Waiting for issuer to be unpaused.",
 			},
 		},
 
-		// If the sign function returns an error & it's too late for a retry, set the Ready
-		// condition to Failed.
+		// If the issuer is in a scheduled maintenance window, set Ready condition status to
+		// false and reason to ScheduledMaintenance without recording events, and requeue
+		// once the window has ended.
 		{
-			name: "timeout-permanent-error",
-			sign: func(_ context.Context, cr signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
-				return signer.PEMBundle{}, fmt.Errorf("a specific error")
+			name: "set-ready-pending-issuer-scheduled-maintenance",
+			objects: []client.Object{
+				cmgen.CertificateRequestFrom(cr1,
+					cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+						Name:  issuer1.Name,
+						Group: api.SchemeGroupVersion.Group,
+					}),
+				),
+				testutil.TestIssuerFrom(issuer1,
+					testutil.SetTestIssuerAnnotations(map[string]string{
+						v1alpha1.IssuerMaintenanceWindowAnnotationKey: v1alpha1.MaintenanceWindow{
+							Start: fakeTime2.Add(-1 * time.Hour),
+							End:   fakeTime2.Add(30 * time.Minute),
+						}.String(),
+					}),
+				),
+			},
+			expectedResult: reconcile.Result{
+				RequeueAfter: 30 * time.Minute,
+			},
+			expectedStatusPatch: &cmapi.CertificateRequestStatus{
+				Conditions: []cmapi.CertificateRequestCondition{
+					{
+						Type:               cmapi.CertificateRequestConditionReady,
+						Status:             cmmeta.ConditionFalse,
+						Reason:             v1alpha1.CertificateRequestConditionReasonScheduledMaintenance,
+						Message:            fmt.Sprintf("Waiting for the scheduled maintenance window of the issuer to end at %s.", fakeTime2.Add(30*time.Minute).Format(time.RFC3339)),
+						LastTransitionTime: &fakeTimeObj2,
+					},
+				},
 			},
+		},
+
+		// If the scheduled maintenance window of the issuer has ended, sign the request.
+		{
+			name: "success-issuer-scheduled-maintenance-ended",
+			sign: successSigner("a-signed-certificate"),
 			objects: []client.Object{
 				cmgen.CertificateRequestFrom(cr1,
 					cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
 						Name:  issuer1.Name,
 						Group: api.SchemeGroupVersion.Group,
 					}),
-					func(cr *cmapi.CertificateRequest) {
-						cr.CreationTimestamp = metav1.NewTime(fakeTimeObj2.Add(-2 * time.Minute))
+				),
+				testutil.TestIssuerFrom(issuer1,
+					testutil.SetTestIssuerAnnotations(map[string]string{
+						v1alpha1.IssuerMaintenanceWindowAnnotationKey: v1alpha1.MaintenanceWindow{
+							Start: fakeTime2.Add(-2 * time.Hour),
+							End:   fakeTime2.Add(-1 * time.Hour),
+						}.String(),
+					}),
+				),
+			},
+			expectedStatusPatch: &cmapi.CertificateRequestStatus{
+				Certificate: []byte("a-signed-certificate"),
+				Conditions: []cmapi.CertificateRequestCondition{
+					{
+						Type:               cmapi.CertificateRequestConditionReady,
+						Status:             cmmeta.ConditionTrue,
+						Reason:             cmapi.CertificateRequestReasonIssued,
+						Message:            "Succeeded signing the CertificateRequest",
+						LastTransitionTime: &fakeTimeObj2,
 					},
+				},
+			},
+			expectedEvents: []string{
+				"Normal Issued Succeeded signing the CertificateRequest",
+			},
+		},
+
+		// If issuer's ready condition is outdated, set Ready condition status to false and reason
+		// to pending.
+		{
+			name: "set-ready-pending-issuer-ready-outdated",
+			objects: []client.Object{
+				cmgen.CertificateRequestFrom(cr1,
+					cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+						Name:  issuer1.Name,
+						Group: api.SchemeGroupVersion.Group,
+					}),
+				),
+				testutil.TestIssuerFrom(issuer1,
+					testutil.SetTestIssuerGeneration(issuer1.Generation+1),
 				),
-				testutil.TestIssuerFrom(issuer1),
 			},
 			expectedStatusPatch: &cmapi.CertificateRequestStatus{
 				Conditions: []cmapi.CertificateRequestCondition{
 					{
 						Type:               cmapi.CertificateRequestConditionReady,
 						Status:             cmmeta.ConditionFalse,
-						Reason:             cmapi.CertificateRequestReasonFailed,
-						Message:            "Failed permanently to sign CertificateRequest: a specific error",
+						Reason:             cmapi.CertificateRequestReasonPending,
+						Message:            "Waiting for issuer to become ready. Current issuer ready condition is outdated.",
 						LastTransitionTime: &fakeTimeObj2,
 					},
 				},
-				FailureTime: &fakeTimeObj2,
 			},
-			validateError: errormatch.ErrorContains("terminal error: a specific error"),
 			expectedEvents: []string{
-				"Warning PermanentError Failed permanently to sign CertificateRequest: a specific error",
+				"Normal WaitingForIssuerReady Waiting for issuer to become ready. Current issuer ready condition is outdated.",
 			},
 		},
 
-		// If the sign function returns a reason for being pending, set the Ready condition to Pending (even if
-		// the MaxRetryDuration has been exceeded).
+		// If the readiness registry reports the issuer as ready for its current generation,
+		// sign without waiting for the cached ready condition to be updated.
 		{
-			name: "retry-on-pending-error",
-			sign: func(_ context.Context, cr signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
-				return signer.PEMBundle{}, signer.PendingError{Err: fmt.Errorf("reason for being pending")}
-			},
+			name:              "success-issuer-ready-in-readiness-registry",
+			sign:              successSigner("a-signed-certificate"),
+			readinessRegistry: readinessRegistry(issuer1, issuer1.Generation+1, true),
+			objects: []client.Object{
+				cmgen.CertificateRequestFrom(cr1,
+					cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+						Name:  issuer1.Name,
+						Group: api.SchemeGroupVersion.Group,
+					}),
+				),
+				testutil.TestIssuerFrom(issuer1,
+					testutil.SetTestIssuerGeneration(issuer1.Generation+1),
+				),
+			},
+			expectedStatusPatch: &cmapi.CertificateRequestStatus{
+				Certificate: []byte("a-signed-certificate"),
+				Conditions: []cmapi.CertificateRequestCondition{
+					{
+						Type:               cmapi.CertificateRequestConditionReady,
+						Status:             cmmeta.ConditionTrue,
+						Reason:             cmapi.CertificateRequestReasonIssued,
+						Message:            "Succeeded signing the CertificateRequest",
+						LastTransitionTime: &fakeTimeObj2,
+					},
+				},
+			},
+			expectedEvents: []string{
+				"Normal Issued Succeeded signing the CertificateRequest",
+			},
+		},
+
+		// If the readiness registry reports the issuer as ready for an older generation,
+		// fall back to the issuer's ready condition.
+		{
+			name:              "set-ready-pending-issuer-readiness-registry-outdated",
+			readinessRegistry: readinessRegistry(issuer1, issuer1.Generation, true),
+			objects: []client.Object{
+				cmgen.CertificateRequestFrom(cr1,
+					cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+						Name:  issuer1.Name,
+						Group: api.SchemeGroupVersion.Group,
+					}),
+				),
+				testutil.TestIssuerFrom(issuer1,
+					testutil.SetTestIssuerGeneration(issuer1.Generation+1),
+				),
+			},
+			expectedStatusPatch: &cmapi.CertificateRequestStatus{
+				Conditions: []cmapi.CertificateRequestCondition{
+					{
+						Type:               cmapi.CertificateRequestConditionReady,
+						Status:             cmmeta.ConditionFalse,
+						Reason:             cmapi.CertificateRequestReasonPending,
+						Message:            "Waiting for issuer to become ready. Current issuer ready condition is outdated.",
+						LastTransitionTime: &fakeTimeObj2,
+					},
+				},
+			},
+			expectedEvents: []string{
+				"Normal WaitingForIssuerReady Waiting for issuer to become ready. Current issuer ready condition is outdated.",
+			},
+		},
+
+		// If the sign function returns an error & it's too late for a retry, set the Ready
+		// condition to Failed.
+		{
+			name: "timeout-permanent-error",
+			sign: func(_ context.Context, cr signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+				return signer.PEMBundle{}, fmt.Errorf("a specific error")
+			},
+			objects: []client.Object{
+				cmgen.CertificateRequestFrom(cr1,
+					cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+						Name:  issuer1.Name,
+						Group: api.SchemeGroupVersion.Group,
+					}),
+					func(cr *cmapi.CertificateRequest) {
+						cr.CreationTimestamp = metav1.NewTime(fakeTimeObj2.Add(-2 * time.Minute))
+					},
+				),
+				testutil.TestIssuerFrom(issuer1),
+			},
+			expectedStatusPatch: &cmapi.CertificateRequestStatus{
+				Conditions: []cmapi.CertificateRequestCondition{
+					{
+						Type:               cmapi.CertificateRequestConditionReady,
+						Status:             cmmeta.ConditionFalse,
+						Reason:             cmapi.CertificateRequestReasonFailed,
+						Message:            "Failed permanently to sign CertificateRequest: a specific error",
+						LastTransitionTime: &fakeTimeObj2,
+					},
+				},
+				FailureTime: &fakeTimeObj2,
+			},
+			validateError: errormatch.ErrorContains("terminal error: a specific error"),
+			expectedEvents: []string{
+				"Warning PermanentError Failed permanently to sign CertificateRequest: a specific error",
+			},
+		},
+
+		// If the sign function returns an error that matches a RetryPolicy rule which
+		// extends the retry window, keep retrying past the MaxRetryDuration.
+		{
+			name: "retry-policy-extends-max-retry-duration",
+			sign: func(_ context.Context, cr signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+				return signer.PEMBundle{}, fmt.Errorf("a network timeout")
+			},
+			retryPolicy: &RetryPolicy{
+				Rules: []RetryPolicyRule{
+					{
+						Name:       "timeout",
+						Matches:    func(err error) bool { return strings.Contains(err.Error(), "timeout") },
+						Adjustment: 5 * time.Minute,
+					},
+				},
+			},
+			objects: []client.Object{
+				cmgen.CertificateRequestFrom(cr1,
+					cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+						Name:  issuer1.Name,
+						Group: api.SchemeGroupVersion.Group,
+					}),
+					func(cr *cmapi.CertificateRequest) {
+						cr.CreationTimestamp = metav1.NewTime(fakeTimeObj2.Add(-2 * time.Minute))
+					},
+				),
+				testutil.TestIssuerFrom(issuer1),
+			},
+			expectedStatusPatch: &cmapi.CertificateRequestStatus{
+				Conditions: []cmapi.CertificateRequestCondition{
+					{
+						Type:               cmapi.CertificateRequestConditionReady,
+						Status:             cmmeta.ConditionFalse,
+						Reason:             cmapi.CertificateRequestReasonPending,
+						Message:            "Failed to sign CertificateRequest, will retry: a network timeout",
+						LastTransitionTime: &fakeTimeObj2,
+					},
+				},
+			},
+			validateError: errormatch.ErrorContains("a network timeout"),
+			expectedEvents: []string{
+				"Warning RetryableError Failed to sign CertificateRequest, will retry: a network timeout",
+			},
+		},
+
+		// If the sign function returns an error that matches a RetryPolicy rule which
+		// shortens the retry window, fail before the MaxRetryDuration has been reached.
+		{
+			name: "retry-policy-shortens-max-retry-duration",
+			sign: func(_ context.Context, cr signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+				return signer.PEMBundle{}, fmt.Errorf("400 bad request")
+			},
+			retryPolicy: &RetryPolicy{
+				Rules: []RetryPolicyRule{
+					{
+						Name:       "client-error",
+						Matches:    func(err error) bool { return strings.HasPrefix(err.Error(), "4") },
+						Adjustment: -time.Minute,
+					},
+				},
+			},
+			objects: []client.Object{
+				cmgen.CertificateRequestFrom(cr1,
+					cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+						Name:  issuer1.Name,
+						Group: api.SchemeGroupVersion.Group,
+					}),
+					func(cr *cmapi.CertificateRequest) {
+						cr.CreationTimestamp = metav1.NewTime(fakeTimeObj2.Add(-30 * time.Second))
+					},
+				),
+				testutil.TestIssuerFrom(issuer1),
+			},
+			expectedStatusPatch: &cmapi.CertificateRequestStatus{
+				Conditions: []cmapi.CertificateRequestCondition{
+					{
+						Type:               cmapi.CertificateRequestConditionReady,
+						Status:             cmmeta.ConditionFalse,
+						Reason:             cmapi.CertificateRequestReasonFailed,
+						Message:            "Failed permanently to sign CertificateRequest: 400 bad request",
+						LastTransitionTime: &fakeTimeObj2,
+					},
+				},
+				FailureTime: &fakeTimeObj2,
+			},
+			validateError: errormatch.ErrorContains("terminal error: 400 bad request"),
+			expectedEvents: []string{
+				"Warning PermanentError Failed permanently to sign CertificateRequest: 400 bad request",
+			},
+		},
+
+		// If the sign function returns a reason for being pending, set the Ready condition to Pending (even if
+		// the MaxRetryDuration has been exceeded).
+		{
+			name: "retry-on-pending-error",
+			sign: func(_ context.Context, cr signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+				return signer.PEMBundle{}, signer.PendingError{Err: fmt.Errorf("reason for being pending")}
+			},
+			objects: []client.Object{
+				cmgen.CertificateRequestFrom(cr1,
+					cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+						Name:  issuer1.Name,
+						Group: api.SchemeGroupVersion.Group,
+					}),
+					func(cr *cmapi.CertificateRequest) {
+						cr.CreationTimestamp = metav1.NewTime(fakeTimeObj2.Add(-2 * time.Minute))
+					},
+				),
+				testutil.TestIssuerFrom(issuer1),
+			},
+			expectedStatusPatch: &cmapi.CertificateRequestStatus{
+				Conditions: []cmapi.CertificateRequestCondition{
+					{
+						Type:               cmapi.CertificateRequestConditionReady,
+						Status:             cmmeta.ConditionFalse,
+						Reason:             cmapi.CertificateRequestReasonPending,
+						Message:            "Signing still in progress. Reason: Signing still in progress. Reason: reason for being pending",
+						LastTransitionTime: &fakeTimeObj2,
+					},
+				},
+			},
+			expectedResult: reconcile.Result{
+				Requeue: true,
+			},
+			expectedEvents: []string{
+				"Warning RetryableError Signing still in progress. Reason: Signing still in progress. Reason: reason for being pending",
+			},
+		},
+
+		// If the sign function returns a PendingError with a RetryAfter duration, requeue
+		// the request after that duration instead of using the default backoff.
+		{
+			name: "retry-on-pending-error-with-retry-after",
+			sign: func(_ context.Context, cr signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+				return signer.PEMBundle{}, signer.PendingError{
+					Err:        fmt.Errorf("reason for being pending"),
+					RetryAfter: 30 * time.Second,
+				}
+			},
+			objects: []client.Object{
+				cmgen.CertificateRequestFrom(cr1,
+					cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+						Name:  issuer1.Name,
+						Group: api.SchemeGroupVersion.Group,
+					}),
+				),
+				testutil.TestIssuerFrom(issuer1),
+			},
+			expectedStatusPatch: &cmapi.CertificateRequestStatus{
+				Conditions: []cmapi.CertificateRequestCondition{
+					{
+						Type:               cmapi.CertificateRequestConditionReady,
+						Status:             cmmeta.ConditionFalse,
+						Reason:             cmapi.CertificateRequestReasonPending,
+						Message:            "Signing still in progress. Reason: Signing still in progress. Reason: reason for being pending",
+						LastTransitionTime: &fakeTimeObj2,
+					},
+				},
+			},
+			expectedResult: reconcile.Result{
+				RequeueAfter: 30 * time.Second,
+			},
+			expectedEvents: []string{
+				"Warning RetryableError Signing still in progress. Reason: Signing still in progress. Reason: reason for being pending",
+			},
+		},
+
+		// If the ErrorClassifier maps an opaque error returned by the sign function to a
+		// PermanentError, set the Ready condition to Failed without retrying.
+		{
+			name: "error-classifier-permanent-error",
+			sign: func(_ context.Context, cr signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+				return signer.PEMBundle{}, fmt.Errorf("HTTP 400: bad request")
+			},
+			errorClassifier: func(err error) error {
+				return signer.PermanentError{Err: err}
+			},
+			objects: []client.Object{
+				cmgen.CertificateRequestFrom(cr1,
+					cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+						Name:  issuer1.Name,
+						Group: api.SchemeGroupVersion.Group,
+					}),
+				),
+				testutil.TestIssuerFrom(issuer1),
+			},
+			expectedStatusPatch: &cmapi.CertificateRequestStatus{
+				Conditions: []cmapi.CertificateRequestCondition{
+					{
+						Type:               cmapi.CertificateRequestConditionReady,
+						Status:             cmmeta.ConditionFalse,
+						Reason:             cmapi.CertificateRequestReasonFailed,
+						Message:            "Failed permanently to sign CertificateRequest: HTTP 400: bad request",
+						LastTransitionTime: &fakeTimeObj2,
+					},
+				},
+				FailureTime: &fakeTimeObj2,
+			},
+			validateError: errormatch.ErrorContains("terminal error: HTTP 400: bad request"),
+			expectedEvents: []string{
+				"Warning PermanentError Failed permanently to sign CertificateRequest: HTTP 400: bad request",
+			},
+		},
+
+		// If the CriticalExtensionPolicy is set and the CSR contains an unsupported critical
+		// extension, set the Ready condition to Failed without calling the sign function.
+		{
+			name: "critical-extension-policy-unsupported-extension",
+			sign: func(_ context.Context, cr signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+				return signer.PEMBundle{}, fmt.Errorf("sign should not be called")
+			},
+			extensionPolicy: &CriticalExtensionPolicy{},
+			objects: []client.Object{
+				cmgen.CertificateRequestFrom(cr1,
+					cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+						Name:  issuer1.Name,
+						Group: api.SchemeGroupVersion.Group,
+					}),
+					cmgen.SetCertificateRequestCSR(testCSRWithExtensions(t, pkix.Extension{
+						Id:       testCriticalExtensionID,
+						Critical: true,
+						Value:    []byte{0x05, 0x00},
+					})),
+				),
+				testutil.TestIssuerFrom(issuer1),
+			},
+			expectedStatusPatch: &cmapi.CertificateRequestStatus{
+				Conditions: []cmapi.CertificateRequestCondition{
+					{
+						Type:               cmapi.CertificateRequestConditionReady,
+						Status:             cmmeta.ConditionFalse,
+						Reason:             cmapi.CertificateRequestReasonFailed,
+						Message:            "Failed permanently to sign CertificateRequest: the CSR contains unsupported critical extensions: 1.2.3.4",
+						LastTransitionTime: &fakeTimeObj2,
+					},
+				},
+				FailureTime: &fakeTimeObj2,
+			},
+			validateError: errormatch.ErrorContains("terminal error: the CSR contains unsupported critical extensions: 1.2.3.4"),
+			expectedEvents: []string{
+				"Warning PermanentError Failed permanently to sign CertificateRequest: the CSR contains unsupported critical extensions: 1.2.3.4",
+			},
+		},
+
+		// If the CSR uses a public key algorithm that the issuer does not support, set the
+		// Ready condition to Failed without calling the sign function.
+		{
+			name: "supported-key-algorithms-unsupported-algorithm",
+			sign: func(_ context.Context, cr signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+				return signer.PEMBundle{}, fmt.Errorf("sign should not be called")
+			},
+			keyAlgorithms: func(_ v1alpha1.Issuer) []x509.PublicKeyAlgorithm {
+				return []x509.PublicKeyAlgorithm{x509.RSA, x509.Ed25519}
+			},
+			objects: []client.Object{
+				cmgen.CertificateRequestFrom(cr1,
+					cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+						Name:  issuer1.Name,
+						Group: api.SchemeGroupVersion.Group,
+					}),
+					cmgen.SetCertificateRequestCSR(testCSRWithExtensions(t)),
+				),
+				testutil.TestIssuerFrom(issuer1),
+			},
+			expectedStatusPatch: &cmapi.CertificateRequestStatus{
+				Conditions: []cmapi.CertificateRequestCondition{
+					{
+						Type:               cmapi.CertificateRequestConditionReady,
+						Status:             cmmeta.ConditionFalse,
+						Reason:             cmapi.CertificateRequestReasonFailed,
+						Message:            "Failed permanently to sign CertificateRequest: the CSR uses the ECDSA public key algorithm, but the issuer only supports: RSA, Ed25519",
+						LastTransitionTime: &fakeTimeObj2,
+					},
+				},
+				FailureTime: &fakeTimeObj2,
+			},
+			validateError: errormatch.ErrorContains("terminal error: the CSR uses the ECDSA public key algorithm, but the issuer only supports: RSA, Ed25519"),
+			expectedEvents: []string{
+				"Warning PermanentError Failed permanently to sign CertificateRequest: the CSR uses the ECDSA public key algorithm, but the issuer only supports: RSA, Ed25519",
+			},
+		},
+
+		// The ErrorClassifier is not applied to errors that already are one of the signer
+		// error types.
+		{
+			name: "error-classifier-skips-signer-errors",
+			sign: func(_ context.Context, cr signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+				return signer.PEMBundle{}, signer.PendingError{
+					Err:        fmt.Errorf("reason for being pending"),
+					RetryAfter: 30 * time.Second,
+				}
+			},
+			errorClassifier: func(err error) error {
+				return signer.PermanentError{Err: err}
+			},
+			objects: []client.Object{
+				cmgen.CertificateRequestFrom(cr1,
+					cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+						Name:  issuer1.Name,
+						Group: api.SchemeGroupVersion.Group,
+					}),
+				),
+				testutil.TestIssuerFrom(issuer1),
+			},
+			expectedStatusPatch: &cmapi.CertificateRequestStatus{
+				Conditions: []cmapi.CertificateRequestCondition{
+					{
+						Type:               cmapi.CertificateRequestConditionReady,
+						Status:             cmmeta.ConditionFalse,
+						Reason:             cmapi.CertificateRequestReasonPending,
+						Message:            "Signing still in progress. Reason: Signing still in progress. Reason: reason for being pending",
+						LastTransitionTime: &fakeTimeObj2,
+					},
+				},
+			},
+			expectedResult: reconcile.Result{
+				RequeueAfter: 30 * time.Second,
+			},
+			expectedEvents: []string{
+				"Warning RetryableError Signing still in progress. Reason: Signing still in progress. Reason: reason for being pending",
+			},
+		},
+
+		// If the issuer is over quota, delay the request without calling the sign function.
+		{
+			name: "quota-policy-over-quota",
+			sign: func(_ context.Context, cr signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+				return signer.PEMBundle{}, fmt.Errorf("sign should not be called")
+			},
+			quotaPolicy: &QuotaPolicy{},
 			objects: []client.Object{
 				cmgen.CertificateRequestFrom(cr1,
 					cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
 						Name:  issuer1.Name,
 						Group: api.SchemeGroupVersion.Group,
 					}),
-					func(cr *cmapi.CertificateRequest) {
-						cr.CreationTimestamp = metav1.NewTime(fakeTimeObj2.Add(-2 * time.Minute))
-					},
 				),
-				testutil.TestIssuerFrom(issuer1),
+				testutil.TestIssuerFrom(issuer1, func(issuer *api.TestIssuer) {
+					issuer.Annotations = map[string]string{
+						v1alpha1.IssuerQuotaAnnotationKey: "0/1h",
+					}
+				}),
 			},
 			expectedStatusPatch: &cmapi.CertificateRequestStatus{
 				Conditions: []cmapi.CertificateRequestCondition{
 					{
 						Type:               cmapi.CertificateRequestConditionReady,
 						Status:             cmmeta.ConditionFalse,
-						Reason:             cmapi.CertificateRequestReasonPending,
-						Message:            "Signing still in progress. Reason: Signing still in progress. Reason: reason for being pending",
+						Reason:             v1alpha1.CertificateRequestConditionReasonIssuerRateLimited,
+						Message:            "Signing still in progress. Reason: Signing still in progress. Reason: issuer quota of 0 certificates per 1h0m0s exceeded: 0 certificates were issued recently and 0 are in progress",
 						LastTransitionTime: &fakeTimeObj2,
 					},
 				},
 			},
 			expectedResult: reconcile.Result{
-				Requeue: true,
+				RequeueAfter: time.Hour,
 			},
 			expectedEvents: []string{
-				"Warning RetryableError Signing still in progress. Reason: Signing still in progress. Reason: reason for being pending",
+				"Warning IssuerRateLimited Signing still in progress. Reason: Signing still in progress. Reason: issuer quota of 0 certificates per 1h0m0s exceeded: 0 certificates were issued recently and 0 are in progress",
 			},
 		},
 
@@ -859,6 +1591,46 @@ func TestCertificateRequestReconcilerReconcile(t *testing.T) {
 			},
 		},
 
+		// Use the message from the MessageCatalog if it is provided.
+		{
+			name: "retry-on-error-with-custom-message",
+			sign: func(_ context.Context, cr signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+				return signer.PEMBundle{}, errors.New("waiting for approval")
+			},
+			messages: &MessageCatalog{
+				RequestRetryableError: func(request client.Object, err error) string {
+					return fmt.Sprintf("Could not sign %s, see https://example.com/docs: %s", request.GetName(), err)
+				},
+			},
+			objects: []client.Object{
+				cmgen.CertificateRequestFrom(cr1,
+					func(cr *cmapi.CertificateRequest) {
+						cr.CreationTimestamp = fakeTimeObj2
+					},
+					func(cr *cmapi.CertificateRequest) {
+						cr.Spec.IssuerRef.Name = issuer1.Name
+						cr.Spec.IssuerRef.Kind = issuer1.Kind
+					},
+				),
+				testutil.TestIssuerFrom(issuer1),
+			},
+			expectedStatusPatch: &cmapi.CertificateRequestStatus{
+				Conditions: []cmapi.CertificateRequestCondition{
+					{
+						Type:               cmapi.CertificateRequestConditionReady,
+						Status:             cmmeta.ConditionFalse,
+						Reason:             cmapi.CertificateRequestReasonPending,
+						Message:            "Could not sign cr1, see https://example.com/docs: waiting for approval",
+						LastTransitionTime: &fakeTimeObj2,
+					},
+				},
+			},
+			validateError: errormatch.ErrorContains("waiting for approval"),
+			expectedEvents: []string{
+				"Warning RetryableError Could not sign cr1, see https://example.com/docs: waiting for approval",
+			},
+		},
+
 		{
 			name: "success-issuer",
 			sign: successSigner("a-signed-certificate"),
@@ -946,11 +1718,23 @@ func TestCertificateRequestReconcilerReconcile(t *testing.T) {
 					ClusterIssuerTypes: []v1alpha1.Issuer{&api.TestClusterIssuer{}},
 					FieldOwner:         fieldOwner,
 					MaxRetryDuration:   time.Minute,
-					EventSource:        kubeutil.NewEventStore(),
-					Client:             fakeClient,
-					Sign:               tc.sign,
-					EventRecorder:      fakeRecorder,
-					Clock:              fakeClock2,
+					RetryPolicy:        tc.retryPolicy,
+					Messages:           tc.messages,
+
+					IgnoreCertificateRequest:        tc.ignore,
+					IgnoredCertificateRequestReason: tc.ignoredReason,
+					EventSource:                     kubeutil.NewEventStore(),
+					ReadinessRegistry:               tc.readinessRegistry,
+					ErrorClassifier:                 tc.errorClassifier,
+					CriticalExtensionPolicy:         tc.extensionPolicy,
+					SupportedKeyAlgorithms:          tc.keyAlgorithms,
+					QuotaPolicy:                     tc.quotaPolicy,
+					FailOnIssuerFailed:              tc.failOnIssuerFailed != nil,
+					IssuerFailedGracePeriod:         ptr.Deref(tc.failOnIssuerFailed, 0),
+					Client:                          fakeClient,
+					Sign:                            tc.sign,
+					EventRecorder:                   fakeRecorder,
+					Clock:                           fakeClock2,
 				},
 			}).Init()
 
@@ -977,9 +1761,474 @@ func TestCertificateRequestReconcilerReconcile(t *testing.T) {
 	}
 }
 
+func TestCertificateRequestReconcilerReadsReportedIssuerFromAPIReader(t *testing.T) {
+	t.Parallel()
+
+	fieldOwner := "test-certificate-request-reconciler-api-reader"
+
+	randTime := randomTime()
+
+	fakeTime1 := randTime.Truncate(time.Second)
+	fakeClock1 := clocktesting.NewFakeClock(fakeTime1)
+
+	fakeTime2 := randTime.Add(4 * time.Hour).Truncate(time.Second)
+	fakeTimeObj2 := metav1.NewTime(fakeTime2)
+	fakeClock2 := clocktesting.NewFakeClock(fakeTime2)
+
+	cachedIssuer := testutil.TestIssuer(
+		"issuer-1",
+		testutil.SetTestIssuerNamespace("ns1"),
+		testutil.SetTestIssuerStatusCondition(
+			fakeClock1,
+			cmapi.IssuerConditionReady,
+			cmmeta.ConditionTrue,
+			v1alpha1.IssuerConditionReasonChecked,
+			"Succeeded checking the issuer",
+		),
+	)
+
+	liveIssuer := testutil.TestIssuerFrom(cachedIssuer,
+		testutil.SetTestIssuerStatusCondition(
+			fakeClock1,
+			cmapi.IssuerConditionReady,
+			cmmeta.ConditionFalse,
+			v1alpha1.IssuerConditionReasonPending,
+			"[error message]",
+		),
+	)
+
+	cr1 := cmgen.CertificateRequest(
+		"cr1",
+		cmgen.SetCertificateRequestNamespace("ns1"),
+		cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+			Name:  cachedIssuer.Name,
+			Group: api.SchemeGroupVersion.Group,
+		}),
+		cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+			Type:   cmapi.CertificateRequestConditionReady,
+			Status: cmmeta.ConditionUnknown,
+			Reason: v1alpha1.CertificateRequestConditionReasonInitializing,
+		}),
+		cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+			Type:   cmapi.CertificateRequestConditionApproved,
+			Status: cmmeta.ConditionTrue,
+		}),
+	)
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, setupCertificateRequestReconcilerScheme(scheme))
+	require.NoError(t, api.AddToScheme(scheme))
+
+	cachedClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(cr1, cachedIssuer).
+		Build()
+	liveClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(liveIssuer).
+		Build()
+
+	logger := logrtesting.NewTestLoggerWithOptions(t, logrtesting.Options{LogTimestamp: true, Verbosity: 10})
+
+	controller := (&CertificateRequestReconciler{
+		RequestController: RequestController{
+			IssuerTypes:      []v1alpha1.Issuer{&api.TestIssuer{}},
+			FieldOwner:       fieldOwner,
+			MaxRetryDuration: time.Minute,
+			EventSource: fakeEventSource{
+				err: fmt.Errorf("[error message]"),
+			},
+			Client:    cachedClient,
+			APIReader: liveClient,
+			Sign: func(_ context.Context, _ signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+				return signer.PEMBundle{}, fmt.Errorf("sign should not be called")
+			},
+			EventRecorder: record.NewFakeRecorder(100),
+			Clock:         fakeClock2,
+		},
+	}).Init()
+
+	require.NoError(t, controller.setAllIssuerTypesWithGroupVersionKind(scheme))
+
+	_, statusPatch, reconcileErr := controller.reconcileStatusPatch(logger, context.TODO(), reconcile.Request{
+		NamespacedName: client.ObjectKeyFromObject(cr1),
+	})
+	require.NoError(t, reconcileErr)
+	require.NotNil(t, statusPatch)
+
+	assert.Equal(t, &cmapi.CertificateRequestStatus{
+		Conditions: []cmapi.CertificateRequestCondition{
+			{
+				Type:               cmapi.CertificateRequestConditionReady,
+				Status:             cmmeta.ConditionFalse,
+				Reason:             cmapi.CertificateRequestReasonPending,
+				Message:            "Waiting for issuer to become ready. Current issuer ready condition is \"Pending\": [error message].",
+				LastTransitionTime: &fakeTimeObj2,
+			},
+		},
+	}, statusPatch.(CertificateRequestPatch).CertificateRequestPatch())
+}
+
+func TestRequestControllerGetIssuer(t *testing.T) {
+	t.Parallel()
+
+	fakeClock := clocktesting.NewFakeClock(randomTime().Truncate(time.Second))
+
+	upToDateIssuer := testutil.TestIssuer(
+		"issuer-1",
+		testutil.SetTestIssuerNamespace("ns1"),
+		testutil.SetTestIssuerGeneration(2),
+		testutil.SetTestIssuerStatusCondition(
+			fakeClock,
+			cmapi.IssuerConditionReady,
+			cmmeta.ConditionTrue,
+			v1alpha1.IssuerConditionReasonChecked,
+			"Succeeded checking the issuer",
+		),
+	)
+
+	outdatedIssuer := testutil.TestIssuer(
+		"issuer-1",
+		testutil.SetTestIssuerNamespace("ns1"),
+		testutil.SetTestIssuerGeneration(1),
+		testutil.SetTestIssuerStatusCondition(
+			fakeClock,
+			cmapi.IssuerConditionReady,
+			cmmeta.ConditionTrue,
+			v1alpha1.IssuerConditionReasonChecked,
+			"Succeeded checking the issuer",
+		),
+		testutil.SetTestIssuerGeneration(2),
+	)
+
+	liveIssuer := testutil.TestIssuerFrom(upToDateIssuer,
+		testutil.SetTestIssuerStatusCondition(
+			fakeClock,
+			cmapi.IssuerConditionReady,
+			cmmeta.ConditionFalse,
+			v1alpha1.IssuerConditionReasonPending,
+			"[error message]",
+		),
+	)
+
+	type testCase struct {
+		name           string
+		cachedIssuer   *api.TestIssuer
+		reportedErr    error
+		noAPIReader    bool
+		expectedReason string
+	}
+
+	tests := []testCase{
+		{
+			name:           "up-to-date-cached-issuer",
+			cachedIssuer:   upToDateIssuer,
+			expectedReason: v1alpha1.IssuerConditionReasonChecked,
+		},
+		{
+			name:           "reported-error",
+			cachedIssuer:   upToDateIssuer,
+			reportedErr:    fmt.Errorf("[error message]"),
+			expectedReason: v1alpha1.IssuerConditionReasonPending,
+		},
+		{
+			name:           "outdated-cached-issuer",
+			cachedIssuer:   outdatedIssuer,
+			expectedReason: v1alpha1.IssuerConditionReasonPending,
+		},
+		{
+			name:           "outdated-cached-issuer-without-api-reader",
+			cachedIssuer:   outdatedIssuer,
+			noAPIReader:    true,
+			expectedReason: v1alpha1.IssuerConditionReasonChecked,
+		},
+		{
+			name:           "issuer-missing-from-cache",
+			reportedErr:    fmt.Errorf("[error message]"),
+			expectedReason: v1alpha1.IssuerConditionReasonPending,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			scheme := runtime.NewScheme()
+			require.NoError(t, api.AddToScheme(scheme))
+
+			cachedClientBuilder := fake.NewClientBuilder().WithScheme(scheme)
+			if tc.cachedIssuer != nil {
+				cachedClientBuilder = cachedClientBuilder.WithObjects(tc.cachedIssuer)
+			}
+
+			controller := &RequestController{
+				EventSource: fakeEventSource{err: tc.reportedErr},
+				Client:      cachedClientBuilder.Build(),
+			}
+			if !tc.noAPIReader {
+				controller.APIReader = fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(liveIssuer).
+					Build()
+			}
+
+			issuerGvk := api.SchemeGroupVersion.WithKind("TestIssuer")
+			issuer := &api.TestIssuer{}
+			require.NoError(t, controller.getIssuer(context.TODO(), issuerGvk, client.ObjectKeyFromObject(liveIssuer), issuer))
+
+			readyCondition := conditions.GetIssuerStatusCondition(issuer.Status.Conditions, cmapi.IssuerConditionReady)
+			require.NotNil(t, readyCondition)
+			assert.Equal(t, tc.expectedReason, readyCondition.Reason)
+		})
+	}
+}
+
+func TestCertificateRequestReconcilerInitialPatch(t *testing.T) {
+	t.Parallel()
+
+	fieldOwner := "test-certificate-request-reconciler-initial-patch"
+
+	fakeClock := clocktesting.NewFakeClock(randomTime().Truncate(time.Second))
+	fakeTimeObj := metav1.NewTime(fakeClock.Now())
+
+	issuer := testutil.TestIssuer(
+		"issuer-1",
+		testutil.SetTestIssuerNamespace("ns1"),
+		testutil.SetTestIssuerStatusCondition(
+			fakeClock,
+			cmapi.IssuerConditionReady,
+			cmmeta.ConditionTrue,
+			v1alpha1.IssuerConditionReasonChecked,
+			"Succeeded checking the issuer",
+		),
+	)
+
+	// The issuance claim on the request is held by another replica.
+	newRequest := cmgen.CertificateRequest(
+		"cr1",
+		cmgen.SetCertificateRequestNamespace("ns1"),
+		cmgen.SetCertificateRequestCSR(testCSRWithExtensions(t)),
+		cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+			Name:  issuer.Name,
+			Group: api.SchemeGroupVersion.Group,
+		}),
+		cmgen.SetCertificateRequestAnnotations(map[string]string{
+			v1alpha1.RequestIssuanceClaimAnnotationKey: v1alpha1.IssuanceClaim{
+				Holder:  "replica-b",
+				Expires: fakeClock.Now().Add(time.Minute),
+			}.String(),
+		}),
+		cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+			Type:   cmapi.CertificateRequestConditionApproved,
+			Status: cmmeta.ConditionTrue,
+		}),
+	)
+
+	type testCase struct {
+		name                string
+		request             *cmapi.CertificateRequest
+		expectedStatusPatch *cmapi.CertificateRequestStatus
+	}
+
+	tests := []testCase{
+		{
+			// The initial patch only contains the initial Ready condition, not
+			// the ExternalApproval condition that was set on the status patch
+			// before the claim was found to be held by another replica.
+			name:    "initializing",
+			request: newRequest,
+			expectedStatusPatch: &cmapi.CertificateRequestStatus{
+				Conditions: []cmapi.CertificateRequestCondition{
+					{
+						Type:               cmapi.CertificateRequestConditionReady,
+						Status:             cmmeta.ConditionUnknown,
+						Reason:             v1alpha1.CertificateRequestConditionReasonInitializing,
+						Message:            fieldOwner + " has started reconciling this CertificateRequest",
+						LastTransitionTime: &fakeTimeObj,
+					},
+				},
+			},
+		},
+		{
+			// Requests that already have a Ready condition have no initial patch.
+			name: "already-initialized",
+			request: cmgen.CertificateRequestFrom(newRequest,
+				cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+					Type:   cmapi.CertificateRequestConditionReady,
+					Status: cmmeta.ConditionFalse,
+					Reason: cmapi.CertificateRequestReasonPending,
+				}),
+			),
+			expectedStatusPatch: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				require.NoError(t, json.NewEncoder(w).Encode(ExternalApprovalResponse{
+					Decision: v1alpha1.ExternalApprovalDecisionApproved,
+				}))
+			}))
+			defer server.Close()
+
+			scheme := runtime.NewScheme()
+			require.NoError(t, setupCertificateRequestReconcilerScheme(scheme))
+			require.NoError(t, api.AddToScheme(scheme))
+
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(tc.request, issuer).
+				Build()
+
+			controller := (&CertificateRequestReconciler{
+				RequestController: RequestController{
+					IssuerTypes:      []v1alpha1.Issuer{&api.TestIssuer{}},
+					FieldOwner:       fieldOwner,
+					MaxRetryDuration: time.Minute,
+					EventSource:      kubeutil.NewEventStore(),
+					Client:           fakeClient,
+					ExternalApprovalPolicy: &ExternalApprovalPolicy{
+						URL: server.URL,
+					},
+					IssuanceClaimPolicy: &IssuanceClaimPolicy{
+						Identity: "replica-a",
+					},
+					Sign: func(_ context.Context, _ signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+						return signer.PEMBundle{}, fmt.Errorf("sign should not be called")
+					},
+					EventRecorder: record.NewFakeRecorder(100),
+					Clock:         fakeClock,
+				},
+			}).Init()
+			require.NoError(t, controller.setAllIssuerTypesWithGroupVersionKind(scheme))
+
+			logger := logrtesting.NewTestLoggerWithOptions(t, logrtesting.Options{LogTimestamp: true, Verbosity: 10})
+			result, statusPatch, reconcileErr := controller.reconcileStatusPatch(logger, context.TODO(), reconcile.Request{
+				NamespacedName: client.ObjectKeyFromObject(tc.request),
+			})
+			require.NoError(t, reconcileErr)
+			assert.Equal(t, time.Minute, result.RequeueAfter)
+
+			if tc.expectedStatusPatch == nil {
+				assert.Nil(t, statusPatch)
+				return
+			}
+			require.NotNil(t, statusPatch)
+			assert.Equal(t, tc.expectedStatusPatch, statusPatch.(CertificateRequestPatch).CertificateRequestPatch())
+		})
+	}
+}
+
+func TestCertificateRequestReconcilerSingleStatusPatchPerReconcile(t *testing.T) {
+	t.Parallel()
+
+	fakeClock := clocktesting.NewFakeClock(randomTime().Truncate(time.Second))
+
+	issuer := testutil.TestIssuer(
+		"issuer-1",
+		testutil.SetTestIssuerNamespace("ns1"),
+		testutil.SetTestIssuerStatusCondition(
+			fakeClock,
+			cmapi.IssuerConditionReady,
+			cmmeta.ConditionTrue,
+			v1alpha1.IssuerConditionReasonChecked,
+			"Succeeded checking the issuer",
+		),
+	)
+
+	cr1 := cmgen.CertificateRequest(
+		"cr1",
+		cmgen.SetCertificateRequestNamespace("ns1"),
+		cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+			Name:  issuer.Name,
+			Group: api.SchemeGroupVersion.Group,
+		}),
+		cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+			Type:   cmapi.CertificateRequestConditionApproved,
+			Status: cmmeta.ConditionTrue,
+		}),
+	)
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, setupCertificateRequestReconcilerScheme(scheme))
+	require.NoError(t, api.AddToScheme(scheme))
+
+	statusPatches := 0
+	applierFuncs := ssafake.NewApplier().InterceptorFuncs()
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(cr1, issuer).
+		WithStatusSubresource(cr1).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Patch: applierFuncs.Patch,
+			SubResourcePatch: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+				statusPatches++
+				return applierFuncs.SubResourcePatch(ctx, c, subResourceName, obj, patch, opts...)
+			},
+		}).
+		Build()
+
+	signResults := []error{
+		signer.SetCertificateRequestConditionError{
+			Err:           signer.PendingError{Err: fmt.Errorf("[pending]")},
+			ConditionType: "[condition type]",
+			Status:        cmmeta.ConditionTrue,
+			Reason:        "[reason]",
+		},
+		nil,
+	}
+
+	controller := (&CertificateRequestReconciler{
+		RequestController: RequestController{
+			IssuerTypes:      []v1alpha1.Issuer{&api.TestIssuer{}},
+			FieldOwner:       "test-single-status-patch",
+			MaxRetryDuration: time.Hour,
+			EventSource:      kubeutil.NewEventStore(),
+			Client:           fakeClient,
+			Sign: func(_ context.Context, _ signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+				err := signResults[0]
+				signResults = signResults[1:]
+				return signer.PEMBundle{ChainPEM: []byte("cert")}, err
+			},
+			EventRecorder: record.NewFakeRecorder(100),
+			Clock:         fakeClock,
+		},
+	}).Init()
+	require.NoError(t, controller.setAllIssuerTypesWithGroupVersionKind(scheme))
+
+	request := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cr1)}
+
+	// The first reconcile initializes the Ready condition and sets both the
+	// custom condition and the Pending Ready condition in a single patch.
+	_, err := controller.Reconcile(context.TODO(), request)
+	require.NoError(t, err)
+	assert.Equal(t, 1, statusPatches)
+
+	var cr cmapi.CertificateRequest
+	require.NoError(t, fakeClient.Get(context.TODO(), request.NamespacedName, &cr))
+	readyCondition := cmutil.GetCertificateRequestCondition(&cr, cmapi.CertificateRequestConditionReady)
+	require.NotNil(t, readyCondition)
+	assert.Equal(t, cmapi.CertificateRequestReasonPending, readyCondition.Reason)
+	customCondition := cmutil.GetCertificateRequestCondition(&cr, "[condition type]")
+	require.NotNil(t, customCondition)
+	assert.Equal(t, cmmeta.ConditionTrue, customCondition.Status)
+
+	// The second reconcile issues the certificate in a single patch.
+	_, err = controller.Reconcile(context.TODO(), request)
+	require.NoError(t, err)
+	assert.Equal(t, 2, statusPatches)
+
+	require.NoError(t, fakeClient.Get(context.TODO(), request.NamespacedName, &cr))
+	assert.Equal(t, []byte("cert"), cr.Status.Certificate)
+}
+
 func chanToSlice(ch <-chan string) []string {
-	out := make([]string, 0, len(ch))
-	for i := 0; i < len(ch); i++ {
+	n := len(ch)
+	out := make([]string, 0, n)
+	for i := 0; i < n; i++ {
 		out = append(out, <-ch)
 	}
 	return out
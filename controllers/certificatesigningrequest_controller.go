@@ -18,21 +18,60 @@ package controllers
 
 import (
 	"context"
+	"crypto/x509"
 	"fmt"
+	"slices"
 	"strings"
 
+	"github.com/cert-manager/cert-manager/pkg/util/pki"
 	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	v1alpha1 "github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+const eventRequestKeyUsageMismatch = "KeyUsageMismatch"
+
+// KeyUsageEnforcement determines what happens when the certificate returned by
+// the Sign function for a CertificateSigningRequest does not contain the key
+// usages requested in spec.usages. The Kubernetes API server does not enforce
+// this, so without enforcement workloads might get certificates that don't work.
+type KeyUsageEnforcement string
+
+const (
+	// KeyUsageEnforcementDisabled does not check the key usages of the certificate.
+	KeyUsageEnforcementDisabled KeyUsageEnforcement = ""
+	// KeyUsageEnforcementWarn issues the certificate, but records a warning event
+	// on the CertificateSigningRequest.
+	KeyUsageEnforcementWarn KeyUsageEnforcement = "Warn"
+	// KeyUsageEnforcementFail marks the CertificateSigningRequest as failed.
+	KeyUsageEnforcementFail KeyUsageEnforcement = "Fail"
 )
 
 // CertificateSigningRequestReconciler reconciles a CertificateSigningRequest object
 type CertificateSigningRequestReconciler struct {
 	RequestController
+
+	// KeyUsageEnforcement determines if the key usages of the signed certificate
+	// are checked against the usages requested in the CertificateSigningRequest.
+	// The check is disabled by default.
+	KeyUsageEnforcement KeyUsageEnforcement
+
+	// SignerNamePatterns is an optional list of signer names, or signer name
+	// prefixes ending with "*" (eg. "myissuer.example.com/*"). The CSRs with a
+	// signer name that matches one of the patterns are signed by the cluster
+	// issuer that ResolveSignerName returns, instead of by the issuer referenced
+	// using the "<issuer-type-id>/<issuer-id>" format.
+	SignerNamePatterns []string
+	// ResolveSignerName resolves the signer names that match one of the
+	// SignerNamePatterns to a cluster issuer. It is required if SignerNamePatterns
+	// is set.
+	ResolveSignerName signer.ResolveSignerName
 }
 
 // matchIssuerType returns the IssuerType and IssuerName that matches the
@@ -42,6 +81,8 @@ type CertificateSigningRequestReconciler struct {
 // "<issuer-type-id>/<issuer-id>". The issuer-type-id is obtained from the
 // GetIssuerTypeIdentifier function of the IssuerType.
 // The issuer-id is "<name>" for a ClusterIssuer resource.
+// Signer names that match one of the SignerNamePatterns are resolved using the
+// ResolveSignerName function instead.
 func (r *CertificateSigningRequestReconciler) matchIssuerType(requestObject client.Object) (v1alpha1.Issuer, types.NamespacedName, error) {
 	csr := requestObject.(*certificatesv1.CertificateSigningRequest)
 
@@ -49,6 +90,10 @@ func (r *CertificateSigningRequestReconciler) matchIssuerType(requestObject clie
 		return nil, types.NamespacedName{}, fmt.Errorf("invalid signer name, should have format <issuer-type-id>/<issuer-id>")
 	}
 
+	if r.ResolveSignerName != nil && matchesSignerNamePattern(r.SignerNamePatterns, csr.Spec.SignerName) {
+		return r.resolveSignerName(csr.Spec.SignerName)
+	}
+
 	split := strings.Split(csr.Spec.SignerName, "/")
 	if len(split) != 2 {
 		return nil, types.NamespacedName{}, fmt.Errorf("invalid signer name, should have format <issuer-type-id>/<issuer-id>: %q", csr.Spec.SignerName)
@@ -86,20 +131,100 @@ func (r *CertificateSigningRequestReconciler) Init() *CertificateSigningRequestR
 		r.matchIssuerType,
 		func(o client.Object) RequestObjectHelper {
 			return &certificatesigningRequestObjectHelper{
-				readOnlyObj: o.(*certificatesv1.CertificateSigningRequest),
+				readOnlyObj:          o.(*certificatesv1.CertificateSigningRequest),
+				messages:             r.Messages,
+				messageStabilization: r.MessageStabilizationPolicy,
+				redaction:            r.Redaction,
 			}
 		},
 	)
 
+	if r.KeyUsageEnforcement != KeyUsageEnforcementDisabled {
+		r.RequestController.verifySignedCertificate = r.verifyKeyUsages
+	}
+
 	return r
 }
 
+// verifyKeyUsages checks that the leaf certificate contains all the key usages
+// requested in the CertificateSigningRequest. Depending on the KeyUsageEnforcement,
+// a mismatch results in a warning event or in a PermanentError.
+func (r *CertificateSigningRequestReconciler) verifyKeyUsages(
+	_ context.Context,
+	requestObject client.Object,
+	signedCertificate signer.PEMBundle,
+) error {
+	csr := requestObject.(*certificatesv1.CertificateSigningRequest)
+
+	mismatch, err := keyUsageMismatch(csr.Spec.Usages, signedCertificate.ChainPEM)
+	if err != nil {
+		return signer.PermanentError{Err: fmt.Errorf("failed to check key usages of the signed certificate: %w", err)}
+	}
+
+	if mismatch == "" {
+		return nil
+	}
+
+	if r.KeyUsageEnforcement == KeyUsageEnforcementFail {
+		return signer.PermanentError{Err: fmt.Errorf("signed certificate does not match the requested usages: %s", mismatch)}
+	}
+
+	r.EventRecorder.Event(csr, corev1.EventTypeWarning, eventRequestKeyUsageMismatch, r.Messages.requestKeyUsageMismatch(csr, mismatch))
+	return nil
+}
+
+// keyUsageMismatch returns a description of the requested usages that are
+// missing in the leaf certificate, or an empty string if all usages are present.
+func keyUsageMismatch(usages []certificatesv1.KeyUsage, chainPEM []byte) (string, error) {
+	leaf, err := pki.DecodeX509CertificateBytes(chainPEM)
+	if err != nil {
+		return "", err
+	}
+
+	var missing []string
+	for _, usage := range usages {
+		keyUsage, extKeyUsages, err := pki.BuildKeyUsagesKube([]certificatesv1.KeyUsage{usage})
+		if err != nil {
+			return "", err
+		}
+
+		// A certificate without a key usage extension is valid for all key usages.
+		if leaf.KeyUsage != 0 && keyUsage&^leaf.KeyUsage != 0 {
+			missing = append(missing, string(usage))
+			continue
+		}
+
+		// A certificate without an extended key usage extension is valid for all
+		// extended key usages.
+		if len(leaf.ExtKeyUsage) == 0 || slices.Contains(leaf.ExtKeyUsage, x509.ExtKeyUsageAny) {
+			continue
+		}
+
+		for _, extKeyUsage := range extKeyUsages {
+			if !slices.Contains(leaf.ExtKeyUsage, extKeyUsage) {
+				missing = append(missing, string(usage))
+				break
+			}
+		}
+	}
+
+	if len(missing) == 0 {
+		return "", nil
+	}
+
+	return fmt.Sprintf("missing %q", missing), nil
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *CertificateSigningRequestReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
 	if err := setupCertificateSigningRequestReconcilerScheme(mgr.GetScheme()); err != nil {
 		return err
 	}
 
+	if len(r.SignerNamePatterns) > 0 && r.ResolveSignerName == nil {
+		return fmt.Errorf("SignerNamePatterns requires ResolveSignerName to be set")
+	}
+
 	r.Init()
 
 	return r.RequestController.SetupWithManager(
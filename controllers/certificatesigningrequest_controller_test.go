@@ -18,8 +18,14 @@ package controllers
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
 	"errors"
 	"fmt"
+	"math/big"
 	"testing"
 	"time"
 
@@ -58,6 +64,8 @@ func TestCertificateSigningRequestReconcilerReconcile(t *testing.T) {
 	type testCase struct {
 		name                string
 		sign                signer.Sign
+		keyUsageEnforcement KeyUsageEnforcement
+		failOnIssuerFailed  bool
 		objects             []client.Object
 		validateError       *errormatch.Matcher
 		expectedResult      reconcile.Result
@@ -124,6 +132,8 @@ func TestCertificateSigningRequestReconcilerReconcile(t *testing.T) {
 		}
 	}
 
+	serverAuthCertificate := createTestCertificatePEM(t, x509.KeyUsageDigitalSignature, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth})
+
 	tests := []testCase{
 		// NOTE: The IssuerError error cannot be tested in this unit test. It is tested in the
 		// integration test instead.
@@ -265,6 +275,64 @@ func TestCertificateSigningRequestReconcilerReconcile(t *testing.T) {
 			},
 		},
 
+		// If the issuer failed permanently, set the Failed condition with the
+		// IssuerFailed reason.
+		{
+			name:               "set-failed-issuer-failed",
+			failOnIssuerFailed: true,
+			objects: []client.Object{
+				cmgen.CertificateSigningRequestFrom(cr1, func(cr *certificatesv1.CertificateSigningRequest) {
+					cr.Spec.SignerName = fmt.Sprintf("%s/%s", clusterIssuer1.GetIssuerTypeIdentifier(), clusterIssuer1.Name)
+				}),
+				testutil.TestClusterIssuerFrom(clusterIssuer1,
+					testutil.SetTestClusterIssuerStatusCondition(
+						fakeClock1,
+						cmapi.IssuerConditionReady,
+						cmmeta.ConditionFalse,
+						v1alpha1.IssuerConditionReasonFailed,
+						"[MESSAGE]",
+					),
+				),
+			},
+			expectedStatusPatch: &certificatesv1.CertificateSigningRequestStatus{
+				Conditions: []certificatesv1.CertificateSigningRequestCondition{
+					{
+						Type:               certificatesv1.CertificateFailed,
+						Status:             v1.ConditionTrue,
+						Reason:             v1alpha1.CertificateRequestConditionReasonIssuerFailed,
+						Message:            "The issuer failed permanently, so the CertificateSigningRequest will never be Ready: [MESSAGE]",
+						LastTransitionTime: fakeTimeObj2,
+						LastUpdateTime:     fakeTimeObj2,
+					},
+				},
+			},
+			validateError: errormatch.ErrorContains("terminal error: issuer failed permanently: [MESSAGE]"),
+			expectedEvents: []string{
+				"Warning IssuerFailed The issuer failed permanently, so the CertificateSigningRequest will never be Ready: [MESSAGE]",
+			},
+		},
+
+		// If issuer is paused, don't sign and record an event.
+		{
+			name: "set-ready-pending-issuer-is-paused",
+			objects: []client.Object{
+				cmgen.CertificateSigningRequestFrom(cr1, func(cr *certificatesv1.CertificateSigningRequest) {
+					cr.Spec.SignerName = fmt.Sprintf("%s/%s", clusterIssuer1.GetIssuerTypeIdentifier(), clusterIssuer1.Name)
+				}),
+				testutil.TestClusterIssuerFrom(clusterIssuer1,
+					testutil.SetTestClusterIssuerAnnotations(map[string]string{
+						v1alpha1.IssuerPausedAnnotationKey: "true",
+					}),
+				),
+			},
+			expectedStatusPatch: &certificatesv1.CertificateSigningRequestStatus{
+				Conditions: nil,
+			},
+			expectedEvents: []string{
+				"Normal IssuerPaused Waiting for issuer to be unpaused.",
+			},
+		},
+
 		// If issuer's ready condition is outdated, set Ready condition status to false and reason
 		// to pending.
 		{
@@ -350,6 +418,35 @@ func TestCertificateSigningRequestReconcilerReconcile(t *testing.T) {
 			},
 		},
 
+		// If the sign function returns a Pending error with a RetryAfter duration, requeue
+		// the request after that duration instead of using the default backoff.
+		{
+			name: "retry-on-pending-error-with-retry-after",
+			sign: func(_ context.Context, cr signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+				return signer.PEMBundle{}, signer.PendingError{
+					Err:        fmt.Errorf("pending error"),
+					RetryAfter: 30 * time.Second,
+				}
+			},
+			objects: []client.Object{
+				cmgen.CertificateSigningRequestFrom(cr1,
+					func(cr *certificatesv1.CertificateSigningRequest) {
+						cr.Spec.SignerName = fmt.Sprintf("%s/%s", clusterIssuer1.GetIssuerTypeIdentifier(), clusterIssuer1.Name)
+					},
+				),
+				testutil.TestClusterIssuerFrom(clusterIssuer1),
+			},
+			expectedStatusPatch: &certificatesv1.CertificateSigningRequestStatus{
+				Conditions: nil,
+			},
+			expectedResult: reconcile.Result{
+				RequeueAfter: 30 * time.Second,
+			},
+			expectedEvents: []string{
+				"Warning Pending Signing still in progress. Reason: Signing still in progress. Reason: pending error",
+			},
+		},
+
 		// If the sign function returns an SetCertificateRequestConditionError error with a condition
 		// type that is *not present* in the status, the new condition is *added* to the
 		// CertificateSigningRequest.
@@ -746,6 +843,81 @@ func TestCertificateSigningRequestReconcilerReconcile(t *testing.T) {
 				"Normal Issued Succeeded signing the CertificateSigningRequest",
 			},
 		},
+
+		// Issue the certificate if it contains all requested usages.
+		{
+			name:                "key-usage-enforcement-match",
+			sign:                successSigner(string(serverAuthCertificate)),
+			keyUsageEnforcement: KeyUsageEnforcementFail,
+			objects: []client.Object{
+				cmgen.CertificateSigningRequestFrom(cr1, func(cr *certificatesv1.CertificateSigningRequest) {
+					cr.Spec.SignerName = fmt.Sprintf("%s/%s", clusterIssuer1.GetIssuerTypeIdentifier(), clusterIssuer1.Name)
+					cr.Spec.Usages = []certificatesv1.KeyUsage{certificatesv1.UsageDigitalSignature, certificatesv1.UsageServerAuth}
+				}),
+				testutil.TestClusterIssuerFrom(clusterIssuer1),
+			},
+			expectedStatusPatch: &certificatesv1.CertificateSigningRequestStatus{
+				Certificate: serverAuthCertificate,
+				Conditions:  nil,
+			},
+			expectedEvents: []string{
+				"Normal Issued Succeeded signing the CertificateSigningRequest",
+			},
+		},
+
+		// Issue the certificate, but record a warning event if it is missing requested
+		// usages and the key usage enforcement is set to Warn.
+		{
+			name:                "key-usage-enforcement-warn",
+			sign:                successSigner(string(serverAuthCertificate)),
+			keyUsageEnforcement: KeyUsageEnforcementWarn,
+			objects: []client.Object{
+				cmgen.CertificateSigningRequestFrom(cr1, func(cr *certificatesv1.CertificateSigningRequest) {
+					cr.Spec.SignerName = fmt.Sprintf("%s/%s", clusterIssuer1.GetIssuerTypeIdentifier(), clusterIssuer1.Name)
+					cr.Spec.Usages = []certificatesv1.KeyUsage{certificatesv1.UsageDigitalSignature, certificatesv1.UsageClientAuth}
+				}),
+				testutil.TestClusterIssuerFrom(clusterIssuer1),
+			},
+			expectedStatusPatch: &certificatesv1.CertificateSigningRequestStatus{
+				Certificate: serverAuthCertificate,
+				Conditions:  nil,
+			},
+			expectedEvents: []string{
+				"Warning KeyUsageMismatch Signed certificate does not match the requested usages: missing [\"client auth\"]",
+				"Normal Issued Succeeded signing the CertificateSigningRequest",
+			},
+		},
+
+		// Set the Failed condition if the certificate is missing requested usages and the
+		// key usage enforcement is set to Fail.
+		{
+			name:                "key-usage-enforcement-fail",
+			sign:                successSigner(string(serverAuthCertificate)),
+			keyUsageEnforcement: KeyUsageEnforcementFail,
+			objects: []client.Object{
+				cmgen.CertificateSigningRequestFrom(cr1, func(cr *certificatesv1.CertificateSigningRequest) {
+					cr.Spec.SignerName = fmt.Sprintf("%s/%s", clusterIssuer1.GetIssuerTypeIdentifier(), clusterIssuer1.Name)
+					cr.Spec.Usages = []certificatesv1.KeyUsage{certificatesv1.UsageKeyEncipherment, certificatesv1.UsageServerAuth}
+				}),
+				testutil.TestClusterIssuerFrom(clusterIssuer1),
+			},
+			expectedStatusPatch: &certificatesv1.CertificateSigningRequestStatus{
+				Conditions: []certificatesv1.CertificateSigningRequestCondition{
+					{
+						Type:               certificatesv1.CertificateFailed,
+						Status:             v1.ConditionTrue,
+						Reason:             cmapi.CertificateRequestReasonFailed,
+						Message:            "CertificateSigningRequest has failed permanently: signed certificate does not match the requested usages: missing [\"key encipherment\"]",
+						LastTransitionTime: fakeTimeObj2,
+						LastUpdateTime:     fakeTimeObj2,
+					},
+				},
+			},
+			validateError: errormatch.ErrorContains("terminal error: signed certificate does not match the requested usages"),
+			expectedEvents: []string{
+				"Warning PermanentError CertificateSigningRequest has failed permanently: signed certificate does not match the requested usages: missing [\"key encipherment\"]",
+			},
+		},
 	}
 
 	for _, tc := range tests {
@@ -785,7 +957,9 @@ func TestCertificateSigningRequestReconcilerReconcile(t *testing.T) {
 					Sign:               tc.sign,
 					EventRecorder:      fakeRecorder,
 					Clock:              fakeClock2,
+					FailOnIssuerFailed: tc.failOnIssuerFailed,
 				},
+				KeyUsageEnforcement: tc.keyUsageEnforcement,
 			}).Init()
 
 			err = controller.setAllIssuerTypesWithGroupVersionKind(scheme)
@@ -934,3 +1108,23 @@ func TestCertificateSigningRequestMatchIssuerType(t *testing.T) {
 		})
 	}
 }
+
+func createTestCertificatePEM(t *testing.T, keyUsage x509.KeyUsage, extKeyUsages []x509.ExtKeyUsage) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     keyUsage,
+		ExtKeyUsage:  extKeyUsages,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+// ChainExpiryPolicy enables checking the chain that is returned by Sign for
+// intermediate certificates that expire before the leaf certificate, or within the
+// Window. Such chains are usually caused by a misconfigured CA and result in
+// outages long after the certificate was issued. The request is still issued, but
+// a ChainExpiryRisk condition and a Warning event are added to the request.
+type ChainExpiryPolicy struct {
+	// Window is the duration in which intermediate certificates should not expire,
+	// measured from the time of issuance. If zero, only intermediate certificates
+	// that expire before the leaf certificate are reported.
+	Window time.Duration
+}
+
+// chainExpiryRisk describes the intermediate certificate of the chain that expires
+// first, if it is a risk.
+type chainExpiryRisk struct {
+	reason  string
+	message string
+}
+
+// check returns the expiry risk of the chain, or nil if there is none. Chains
+// that can't be parsed are not checked; use the PEMNormalizationPolicy to reject
+// them. A nil policy does not check anything.
+func (p *ChainExpiryPolicy) check(bundle signer.PEMBundle, now time.Time) *chainExpiryRisk {
+	if p == nil {
+		return nil
+	}
+
+	var certificates []*x509.Certificate
+	rest := bundle.ChainPEM
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil
+		}
+		certificates = append(certificates, certificate)
+	}
+	if len(certificates) < 2 {
+		return nil
+	}
+
+	leaf := certificates[0]
+	first := certificates[1]
+	for _, intermediate := range certificates[2:] {
+		if intermediate.NotAfter.Before(first.NotAfter) {
+			first = intermediate
+		}
+	}
+
+	switch {
+	case first.NotAfter.Before(leaf.NotAfter):
+		return &chainExpiryRisk{
+			reason: v1alpha1.CertificateRequestConditionReasonIntermediateExpiresBeforeLeaf,
+			message: fmt.Sprintf(
+				"The intermediate certificate %q expires at %s, before the issued certificate expires at %s",
+				first.Subject.String(), first.NotAfter.UTC().Format(time.RFC3339), leaf.NotAfter.UTC().Format(time.RFC3339),
+			),
+		}
+	case p.Window > 0 && first.NotAfter.Before(now.Add(p.Window)):
+		return &chainExpiryRisk{
+			reason: v1alpha1.CertificateRequestConditionReasonIntermediateExpiresSoon,
+			message: fmt.Sprintf(
+				"The intermediate certificate %q expires at %s, within %s",
+				first.Subject.String(), first.NotAfter.UTC().Format(time.RFC3339), p.Window,
+			),
+		}
+	default:
+		return nil
+	}
+}
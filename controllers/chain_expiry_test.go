@@ -0,0 +1,137 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+func testExpiringCertificatePEM(t *testing.T, commonName string, notAfter time.Time) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+}
+
+func TestChainExpiryPolicyCheck(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	leaf := testExpiringCertificatePEM(t, "leaf", now.Add(90*24*time.Hour))
+	expiresBeforeLeaf := testExpiringCertificatePEM(t, "expires-before-leaf", now.Add(30*24*time.Hour))
+	expiresSoon := testExpiringCertificatePEM(t, "expires-soon", now.Add(120*24*time.Hour))
+	expiresLate := testExpiringCertificatePEM(t, "expires-late", now.Add(3*365*24*time.Hour))
+
+	chain := func(certificates ...[]byte) signer.PEMBundle {
+		var chainPEM []byte
+		for _, certificate := range certificates {
+			chainPEM = append(chainPEM, certificate...)
+		}
+		return signer.PEMBundle{ChainPEM: chainPEM}
+	}
+
+	type testCase struct {
+		name            string
+		policy          *ChainExpiryPolicy
+		bundle          signer.PEMBundle
+		expectedReason  string
+		expectedMessage string
+	}
+
+	tests := []testCase{
+		{
+			name:   "nil-policy",
+			policy: nil,
+			bundle: chain(leaf, expiresBeforeLeaf),
+		},
+		{
+			name:   "leaf-only",
+			policy: &ChainExpiryPolicy{Window: 365 * 24 * time.Hour},
+			bundle: chain(leaf),
+		},
+		{
+			name:   "unparseable-chain",
+			policy: &ChainExpiryPolicy{},
+			bundle: signer.PEMBundle{ChainPEM: append(append([]byte{}, leaf...), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("invalid")})...)},
+		},
+		{
+			name:   "healthy-chain",
+			policy: &ChainExpiryPolicy{Window: 180 * 24 * time.Hour},
+			bundle: chain(leaf, expiresLate),
+		},
+		{
+			name:            "intermediate-expires-before-leaf",
+			policy:          &ChainExpiryPolicy{},
+			bundle:          chain(leaf, expiresLate, expiresBeforeLeaf),
+			expectedReason:  v1alpha1.CertificateRequestConditionReasonIntermediateExpiresBeforeLeaf,
+			expectedMessage: `The intermediate certificate "CN=expires-before-leaf" expires at 2024-01-31T00:00:00Z, before the issued certificate expires at 2024-03-31T00:00:00Z`,
+		},
+		{
+			name:   "intermediate-expires-after-leaf-without-window",
+			policy: &ChainExpiryPolicy{},
+			bundle: chain(leaf, expiresSoon),
+		},
+		{
+			name:            "intermediate-expires-within-window",
+			policy:          &ChainExpiryPolicy{Window: 180 * 24 * time.Hour},
+			bundle:          chain(leaf, expiresSoon, expiresLate),
+			expectedReason:  v1alpha1.CertificateRequestConditionReasonIntermediateExpiresSoon,
+			expectedMessage: `The intermediate certificate "CN=expires-soon" expires at 2024-04-30T00:00:00Z, within 4320h0m0s`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			risk := tc.policy.check(tc.bundle, now)
+			if tc.expectedReason == "" {
+				assert.Nil(t, risk)
+				return
+			}
+
+			require.NotNil(t, risk)
+			assert.Equal(t, tc.expectedReason, risk.reason)
+			assert.Equal(t, tc.expectedMessage, risk.message)
+		})
+	}
+}
@@ -19,8 +19,12 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"slices"
+	"sync"
 	"time"
 
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/clock"
@@ -30,7 +34,10 @@ import (
 
 	v1alpha1 "github.com/cert-manager/issuer-lib/api/v1alpha1"
 	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/eventbus"
 	"github.com/cert-manager/issuer-lib/internal/kubeutil"
+	"github.com/cert-manager/issuer-lib/inventory"
+	"github.com/cert-manager/issuer-lib/upstream"
 )
 
 type CombinedController struct {
@@ -39,19 +46,256 @@ type CombinedController struct {
 
 	FieldOwner string
 
+	// FieldOwnerForIssuer is optional. If set, it returns the field owner that is
+	// used to apply the status of an issuer and of its requests, instead of
+	// FieldOwner. During a staged cutover between two controllers that manage the
+	// same issuer types, it can be used to hand over individual issuers.
+	FieldOwnerForIssuer FieldOwnerFunc
+	// FieldOwnerConflictWindow is optional. If set, the status of an issuer or
+	// request is not applied while one of the conditions in the status was applied
+	// by another field manager within the window, so that two controllers that
+	// manage the same resources do not keep overwriting each other's status.
+	FieldOwnerConflictWindow time.Duration
+
 	MaxRetryDuration time.Duration
 
+	// RetryPolicy is an optional policy that extends or shortens the
+	// MaxRetryDuration of a request based on the errors returned by Sign.
+	RetryPolicy *RetryPolicy
+
+	// Messages is an optional catalog that overrides the messages used in the
+	// conditions and events set by the controllers.
+	Messages *MessageCatalog
+	// MessageStabilizationPolicy is optional. If set, condition messages that
+	// only differ in their volatile parts (eg. timestamps) are not updated.
+	MessageStabilizationPolicy *MessageStabilizationPolicy
+	// Redaction is optional. If set, sensitive data (eg. tokens or URLs with
+	// credentials) is redacted from condition messages, events and logs.
+	Redaction *RedactionPolicy
+
+	// CriticalExtensionPolicy is optional. If set, CertificateRequest and Kubernetes
+	// CSR resources with a CSR that contains unsupported critical extensions are
+	// failed permanently, without calling Sign.
+	CriticalExtensionPolicy *CriticalExtensionPolicy
+
+	// CSRCanonicalizationPolicy is optional. If set, the CSRs of CertificateRequest
+	// and Kubernetes CSR resources are re-encoded deterministically before they
+	// are passed to Sign.
+	CSRCanonicalizationPolicy *CSRCanonicalizationPolicy
+
+	// PEMNormalizationPolicy is optional. If set, the PEM bundle returned by Sign
+	// is validated and re-encoded before it is written to the status of the
+	// CertificateRequest and Kubernetes CSR resources.
+	PEMNormalizationPolicy *PEMNormalizationPolicy
+
+	// ChainExpiryPolicy is optional. If set, CertificateRequest and Kubernetes CSR
+	// resources that are issued with a chain containing an intermediate certificate
+	// that expires too soon get a ChainExpiryRisk condition and a Warning event.
+	ChainExpiryPolicy *ChainExpiryPolicy
+
+	// FailedRequestCache is optional. If set, CertificateRequest and Kubernetes CSR
+	// resources that are identical to a request that was rejected for a policy
+	// reason recently are failed immediately with the reason of the prior failure.
+	FailedRequestCache *FailedRequestCache
+
+	// ExternalApprovalPolicy is optional. If set, CertificateRequest and Kubernetes
+	// CSR resources are only signed once they were approved by the external
+	// approval webhook of the policy.
+	ExternalApprovalPolicy *ExternalApprovalPolicy
+
+	// IssuanceClaimPolicy is optional. If set, the CertificateRequest and Kubernetes
+	// CSR controllers acquire a claim on a resource before calling Sign, so that
+	// overlapping reconciles of multiple replicas do not sign the same resource twice.
+	IssuanceClaimPolicy *IssuanceClaimPolicy
+
+	// AdaptiveConcurrency is optional. If set, the CertificateRequest and Kubernetes
+	// CSR controllers each scale the number of requests that they reconcile
+	// concurrently between the bounds of the policy.
+	AdaptiveConcurrency *AdaptiveConcurrencyPolicy
+
+	// WarmUp is optional. If set, the CertificateRequest and Kubernetes CSR
+	// controllers hold back the requests that are added while they start, and
+	// release them at a limited rate (eg. after a cluster was restored from a
+	// backup).
+	WarmUp *WarmUpPolicy
+
+	// StatusPatchCoalescing is optional. If set, the CertificateRequest and
+	// Kubernetes CSR controllers delay the status patches that don't finish a
+	// request, and merge the patches of a request within the window of the policy
+	// into a single patch.
+	StatusPatchCoalescing *StatusPatchCoalescingPolicy
+
+	// ProvenancePolicy is optional. If set, the controller instance that signed a
+	// CertificateRequest or Kubernetes CSR is recorded in annotations on it.
+	ProvenancePolicy *ProvenancePolicy
+
+	// AggregateRevocationInfo enables adding the revocation endpoints that Sign
+	// recorded using signer.WithRevocationInfo to the IssuerRevocationInfoAnnotationKey
+	// annotation of the issuer. This requires patch permissions on the issuers.
+	AggregateRevocationInfo bool
+
+	// QuotaPolicy is an optional policy that limits the number of certificates
+	// that are issued per issuer within a period. The quota is shared by the
+	// CertificateRequest and Kubernetes CSR controllers.
+	QuotaPolicy *QuotaPolicy
+
+	// DeduplicationPolicy is optional. If set, a CertificateRequest is marked as
+	// Issued without calling Sign when the Secret of its Certificate already
+	// contains a matching certificate that is not yet due for renewal.
+	DeduplicationPolicy *DeduplicationPolicy
+
+	// PreviousCertificatePolicy is optional. If set, the certificate that was
+	// previously issued for the Certificate of a renewal request is passed to
+	// Sign, see signer.PreviousCertificateFromContext.
+	PreviousCertificatePolicy *PreviousCertificatePolicy
+
+	// InventoryExporter is optional. If set, the metadata of each signed
+	// certificate is exported to it, eg. to feed an inventory or CMDB system
+	// (see the inventory package).
+	InventoryExporter inventory.Exporter
+
+	// IssuerSnapshotPolicy is optional. If set, the configuration of the issuer
+	// (the fields selected by the policy) is recorded on requests that fail
+	// permanently, for post-mortems.
+	IssuerSnapshotPolicy *IssuerSnapshotPolicy
+
+	// UpstreamConfig is the optional configuration of the outbound connections to
+	// the CA. If set, it is passed to the Sign and Check functions through the context, where it
+	// can be obtained using upstream.FromContext.
+	UpstreamConfig *upstream.Config
+
+	// ClusterResourceNamespace is the namespace in which the resources (eg. Secrets)
+	// referenced by cluster-scoped issuers are found. If set, it is passed to the
+	// Sign and Check functions through the context, where it can be obtained using
+	// signer.ResourceNamespace, and the Secrets returned by IssuerSecretRefs without
+	// a namespace are looked up in this namespace for cluster-scoped issuers.
+	ClusterResourceNamespace string
+
+	// FailOnIssuerFailed enables failing requests permanently when their issuer
+	// failed permanently for longer than the IssuerFailedGracePeriod, instead of
+	// waiting for the issuer to become ready forever.
+	FailOnIssuerFailed bool
+	// IssuerFailedGracePeriod is the duration for which requests keep waiting
+	// for a permanently failed issuer to recover, if FailOnIssuerFailed is set.
+	IssuerFailedGracePeriod time.Duration
+
+	// DurationPolicy is an optional policy that limits the requested duration of
+	// the certificates, which is the spec.duration of a CertificateRequest or the
+	// spec.expirationSeconds of a Kubernetes CSR.
+	DurationPolicy *DurationPolicy
+
+	// RequestOptionsPolicy is optional. If set, the options that are set on
+	// CertificateRequest and Kubernetes CSR resources using RequestOptionAnnotationPrefix
+	// annotations are validated and passed to the Sign function through the context.
+	RequestOptionsPolicy *RequestOptionsPolicy
+
+	// ReportedErrorBackend is optional. If set, the errors that the request
+	// controllers report for an issuer are persisted, so that the issuer
+	// controller still processes them after a restart (see ConfigMapReportedErrorBackend).
+	ReportedErrorBackend ReportedErrorBackend
+
+	// EventSource is optional. It passes the errors that the request controllers
+	// report for an issuer to the issuer controllers. Defaults to
+	// NewEventSource(ReportedErrorBackend), set it to share an EventSource with
+	// controllers that are not set up by this CombinedController.
+	EventSource EventSource
+
+	// IssuerNotReadyCache is optional. If set, requests that are already waiting
+	// for a not-ready issuer are skipped cheaply until the Ready condition of the
+	// issuer transitions or the TTL of the cache expires.
+	IssuerNotReadyCache *IssuerNotReadyCache
+
+	// IssuerResyncPeriod is optional. If set, the requests of all ready issuers
+	// are reconciled again at this interval, in case an issuer event was missed.
+	IssuerResyncPeriod time.Duration
+
+	// SetTimeInStateAnnotation enables setting the RequestTimeInStateAnnotationKey
+	// annotation on CertificateRequest and Kubernetes CSR resources once they are
+	// Issued or Failed. This requires patch permissions on these resources and is
+	// disabled by default.
+	SetTimeInStateAnnotation bool
+
+	// EnableCorrelationIDs enables generating a correlation ID for each
+	// CertificateRequest and Kubernetes CSR, which is persisted in the
+	// RequestCorrelationIDAnnotationKey annotation and added to the logs and
+	// events of the request and to the context passed to Sign. This requires
+	// patch permissions on these resources and is disabled by default.
+	EnableCorrelationIDs bool
+
+	// EnableIssuanceControl makes the CertificateRequest and Kubernetes CSR
+	// controllers obey the IssuanceControl singleton, which can pause issuance
+	// or enable shadow mode cluster-wide (see v1alpha1.IssuanceControl). This
+	// requires the v1alpha1 types to be added to the scheme of the manager, the
+	// IssuanceControl CRD to be installed and read permissions on
+	// issuancecontrols.
+	EnableIssuanceControl bool
+
+	// NotifyOwningCertificate enables recording the events about the issuer of a
+	// CertificateRequest (eg. while it is waiting for the issuer to become ready)
+	// on the Certificate that owns the CertificateRequest as well, so application
+	// teams see the cause on the resource they manage.
+	NotifyOwningCertificate bool
+
+	// PropagatedLabels is an optional allowlist of request label keys whose
+	// values are added to the logs, events and metrics of the requests.
+	PropagatedLabels []string
+
+	// AllowIssuerOverride enables routing requests to the issuer referenced by
+	// their RequestIssuerOverrideAnnotationKey annotation. Only enable it if the
+	// annotation is guarded by annotations.AuthorizeIssuerOverride.
+	AllowIssuerOverride bool
+
 	// Check connects to a CA and checks if it is available
 	signer.Check
+	// NamedChecks is an optional list of checks that is run instead of Check. The
+	// result of each check is recorded as a separate condition on the issuer, and
+	// the results are aggregated into the Ready condition using CheckAggregation.
+	NamedChecks []signer.NamedCheck
+	// CheckAggregation determines how the results of the NamedChecks are aggregated,
+	// all checks have to succeed by default.
+	CheckAggregation signer.CheckAggregation
+	// CanarySigning optionally requires a successful canary signature before an
+	// issuer becomes Ready, see CanarySigningPolicy. Sign is used to sign the
+	// canary requests unless the policy has its own Sign function.
+	CanarySigning *CanarySigningPolicy
 	// Sign connects to a CA and returns a signed certificate for the supplied CertificateRequest.
 	signer.Sign
 
 	// IgnoreCertificateRequest is an optional function that can prevent the CertificateRequest
 	// and Kubernetes CSR controllers from reconciling a CertificateRequest resource.
 	signer.IgnoreCertificateRequest
+	// IgnoredCertificateRequestReason is an optional function that provides the
+	// reason and message of the "Ignored" condition that is set on a CertificateRequest
+	// or Kubernetes CSR resource that was ignored by IgnoreCertificateRequest.
+	signer.IgnoredCertificateRequestReason
+	// RequestPriority is an optional function that returns the priority class of a
+	// CertificateRequest or Kubernetes CSR resource. When set, resources with a higher
+	// priority class are reconciled first (see PriorityClassFromAnnotation).
+	signer.RequestPriority
+	// RequestTenant is an optional function that returns the tenant of a
+	// CertificateRequest or Kubernetes CSR resource. When set, the resources of
+	// the tenants are reconciled in a round-robin fashion, so a tenant that creates
+	// many resources cannot starve the other tenants (see TenantFromNamespace and
+	// TenantFromLabel).
+	signer.RequestTenant
+	// ErrorClassifier is an optional function that classifies the opaque errors
+	// returned by Sign and Check into one of the signer error types, eg. to mark
+	// errors caused by invalid requests as permanent.
+	signer.ErrorClassifier
+	// SupportedKeyAlgorithms is an optional function that returns the public key
+	// algorithms (RSA, ECDSA, Ed25519) that the CA of an issuer supports. Requests
+	// with a CSR for another algorithm are failed permanently without calling Sign.
+	signer.SupportedKeyAlgorithms
 	// IgnoreIssuer is an optional function that can prevent the issuer controllers from
 	// reconciling an issuer resource.
 	signer.IgnoreIssuer
+	// IssuerSecretRefs is an optional function that returns the Secrets that an
+	// issuer depends on. When set, the Check function is re-run for an issuer
+	// whenever the contents of one of its Secrets change.
+	signer.IssuerSecretRefs
+	// ResolveSignerName resolves the signer names that match one of the
+	// KubernetesCSRSignerNamePatterns to a cluster issuer.
+	signer.ResolveSignerName
 
 	// EventRecorder is used for creating Kubernetes events on resources.
 	EventRecorder record.EventRecorder
@@ -66,22 +310,76 @@ type CombinedController struct {
 	// separately using a tool such as trust-manager.
 	SetCAOnCertificateRequest bool
 
+	// CRDCompatibility is optional. If set, the status patches of CertificateRequests
+	// are adjusted to the schema of an older CertificateRequest CRD.
+	CRDCompatibility *CRDCompatibility
+	// DetectCRDCompatibility enables deriving the CRDCompatibility from the schema
+	// of the installed CertificateRequest CRD, so that the controllers can run on
+	// clusters with the CRDs of older (eg. long-term-support) cert-manager releases.
+	// This requires get permissions on the CertificateRequest CRD.
+	DetectCRDCompatibility bool
+
+	// EnableCertificateRequests explicitly enables (true) or disables (false) the
+	// CertificateRequest controller. If it is explicitly enabled, SetupWithManager
+	// checks that the cert-manager CRDs are installed and that the manager has the
+	// RBAC permissions needed to sign CertificateRequests, so a missing prerequisite
+	// fails at startup instead of leaving requests unsigned. If not set, the
+	// controller is enabled unless DisableCertificateRequestController is set.
+	EnableCertificateRequests *bool
+
+	// EnableKubernetesCSRs explicitly enables (true) or disables (false) the
+	// Kubernetes CSR controller. If it is explicitly enabled, SetupWithManager
+	// checks that the manager has the RBAC permissions needed to sign Kubernetes
+	// CSRs for all issuer types. If not set, the controller is enabled unless
+	// DisableKubernetesCSRController is set.
+	EnableKubernetesCSRs *bool
+
 	// DisableCertificateRequestController is used to disable the CertificateRequest
 	// controller. This controller is enabled by default.
 	// You should only disable this controller if you eg. don't want to rely on the cert-manager
 	// CRDs to be installed.
-	// Note: in the future, we might remove this option and always enable the CertificateRequest
-	// controller.
+	// Deprecated: set EnableCertificateRequests to false instead.
 	DisableCertificateRequestController bool
 
 	// DisableKubernetesCSRController is used to disable the Kubernetes CSR controller.
 	// This controller is enabled by default.
 	// You should only disable this controller if you really don't want to support signing
 	// Kubernetes CSRs.
-	// Note: in the future, we might remove this option and always enable the Kubernetes CSR
-	// controller.
+	// Deprecated: set EnableKubernetesCSRs to false instead.
 	DisableKubernetesCSRController bool
 
+	// KubernetesCSRKeyUsageEnforcement determines if the Kubernetes CSR controller
+	// checks that the signed certificate contains the usages requested in the
+	// CertificateSigningRequest. The check is disabled by default.
+	KubernetesCSRKeyUsageEnforcement KeyUsageEnforcement
+
+	// KubernetesCSRGarbageCollection is optional. If set and the Kubernetes CSR
+	// controller is enabled, the Kubernetes CSRs with the signer name of one of
+	// the ClusterIssuerTypes are deleted once they were issued, failed or denied
+	// for longer than the TTL. This requires delete permissions on the
+	// CertificateSigningRequests.
+	KubernetesCSRGarbageCollection *CSRGarbageCollection
+
+	// KubernetesCSRSignerNamePatterns is an optional list of signer names, or
+	// signer name prefixes ending with "*" (eg. "myissuer.example.com/*"), that
+	// the Kubernetes CSR controller serves in addition to the "<issuer-type-id>/<issuer-id>"
+	// signer names. The matching signer names are resolved to a cluster issuer
+	// using ResolveSignerName.
+	KubernetesCSRSignerNamePatterns []string
+
+	// RBACSelfCheck is optional. If set, SetupWithManager adds it to the manager
+	// to periodically check that the manager has all RBAC permissions that the
+	// configured controllers need, and registers it as the "rbac-self-check"
+	// health check of the manager.
+	RBACSelfCheck *RBACSelfCheck
+
+	// AllowedIssuerAPIGroups is an optional list of API groups that the issuer
+	// types must belong to. If set, SetupWithManager fails when one of the
+	// IssuerTypes or ClusterIssuerTypes belongs to another API group. This prevents
+	// accidentally registering foreign issuer types in operator binaries that
+	// embed multiple issuers.
+	AllowedIssuerAPIGroups []string
+
 	// PreSetupWithManager is an optional function that can be used to perform
 	// additional setup before the controller is built and registered with the
 	// manager.
@@ -91,89 +389,417 @@ type CombinedController struct {
 	// additional setup after the controller is built and registered with the
 	// manager.
 	PostSetupWithManager func(context.Context, schema.GroupVersionKind, ctrl.Manager, controller.Controller) error
+
+	lifecycleEventsOnce sync.Once
+	lifecycleEvents     *eventbus.Bus[LifecycleEvent]
+
+	sharedOnce        sync.Once
+	eventSource       EventSource
+	readinessRegistry kubeutil.ReadinessRegistry
+
+	// rbacSelfCheckAdded is set once the RBACSelfCheck was added to the manager,
+	// the request kinds are those of all Setup calls.
+	rbacSelfCheckAdded               bool
+	rbacSelfCheckCertificateRequests bool
+	rbacSelfCheckKubernetesCSRs      bool
+}
+
+// Subscribe calls the handler for every LifecycleEvent that is emitted by the
+// controllers, until the context is cancelled. This allows multiple consumers
+// (eg. metrics, audit logs or notifications) to observe the reconciles of the
+// requests and issuers. Subscribe can be called before or after SetupWithManager.
+//
+// The handler is called sequentially from a separate goroutine per subscriber,
+// so slow handlers do not block the reconciles. Events are dropped if more than
+// 1024 events are waiting for a subscriber, the dropped events are counted in
+// the issuer_lib_lifecycle_events_dropped_total metric.
+func (r *CombinedController) Subscribe(ctx context.Context, handler func(LifecycleEvent)) {
+	r.lifecycleEventBus().Subscribe(ctx, handler)
+}
+
+func (r *CombinedController) lifecycleEventBus() *eventbus.Bus[LifecycleEvent] {
+	r.lifecycleEventsOnce.Do(func() {
+		r.lifecycleEvents = newLifecycleEventBus()
+	})
+	return r.lifecycleEvents
 }
 
+// sharedState returns the EventSource and the ReadinessRegistry that are shared
+// by the issuer and request controllers.
+func (r *CombinedController) sharedState() (EventSource, kubeutil.ReadinessRegistry) {
+	r.sharedOnce.Do(func() {
+		r.eventSource = r.EventSource
+		if r.eventSource == nil {
+			r.eventSource = NewEventSource(r.ReportedErrorBackend)
+		}
+		r.readinessRegistry = kubeutil.NewReadinessRegistry()
+	})
+	return r.eventSource, r.readinessRegistry
+}
+
+// SetupWithManager sets up the issuer controllers and the request controllers.
 func (r *CombinedController) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
+	return r.setup(ctx, mgr, true, true)
+}
+
+// SetupIssuerControllersOnly only sets up the issuer controllers (and the
+// IssuerSecretRefs controllers), for projects that run the request controllers
+// in another manager, eg. in a separate binary. The options of the request
+// controllers are ignored.
+//
+// The issuer controllers only receive the errors that are reported by request
+// controllers which use the same EventSource. Request controllers that run in
+// another process can only pass reported errors through a shared
+// ReportedErrorBackend, which the issuer controllers read when they start.
+func (r *CombinedController) SetupIssuerControllersOnly(ctx context.Context, mgr ctrl.Manager) error {
+	return r.setup(ctx, mgr, true, false)
+}
+
+// SetupRequestControllersOnly only sets up the CertificateRequest and Kubernetes
+// CSR controllers, for projects that run the issuer controllers in another
+// manager, eg. in a separate binary. The request controllers read the Ready
+// condition of the issuers, so the issuer controllers must run somewhere for the
+// requests to be signed.
+//
+// Use the same FieldOwner (or FieldOwnerForIssuer) in both managers, and set
+// EventSource or ReportedErrorBackend to pass the errors that are reported by
+// the request controllers to the issuer controllers. Calling both
+// SetupIssuerControllersOnly and SetupRequestControllersOnly on the same
+// CombinedController and manager is equivalent to SetupWithManager.
+func (r *CombinedController) SetupRequestControllersOnly(ctx context.Context, mgr ctrl.Manager) error {
+	return r.setup(ctx, mgr, false, true)
+}
+
+func (r *CombinedController) setup(ctx context.Context, mgr ctrl.Manager, setupIssuerControllers bool, setupRequestControllers bool) error {
 	var err error
 	cl := mgr.GetClient()
-	eventSource := kubeutil.NewEventStore()
+	eventSource, readinessRegistry := r.sharedState()
+	lifecycleEvents := r.lifecycleEventBus()
+
+	if err := checkAllowedIssuerAPIGroups(mgr.GetScheme(), r.AllowedIssuerAPIGroups, append(r.IssuerTypes, r.ClusterIssuerTypes...)); err != nil {
+		return err
+	}
 
 	if r.Clock == nil {
 		r.Clock = clock.RealClock{}
 	}
 
-	for _, issuerType := range append(r.IssuerTypes, r.ClusterIssuerTypes...) {
-		if err = (&IssuerReconciler{
-			ForObject: issuerType,
-
-			FieldOwner:  r.FieldOwner,
-			EventSource: eventSource,
-
-			Client:        cl,
-			Check:         r.Check,
-			IgnoreIssuer:  r.IgnoreIssuer,
-			EventRecorder: r.EventRecorder,
-			Clock:         r.Clock,
+	enableCertificateRequests, enableKubernetesCSRs := false, false
+	if setupRequestControllers {
+		enableCertificateRequests, enableKubernetesCSRs, err = r.enabledRequestKinds(ctx, mgr)
+		if err != nil {
+			return err
+		}
+	}
 
-			PreSetupWithManager:  r.PreSetupWithManager,
-			PostSetupWithManager: r.PostSetupWithManager,
-		}).SetupWithManager(ctx, mgr); err != nil {
-			return fmt.Errorf("%T: %w", issuerType, err)
+	if r.RBACSelfCheck != nil {
+		if err := r.setupRBACSelfCheck(mgr, enableCertificateRequests, enableKubernetesCSRs); err != nil {
+			return fmt.Errorf("RBACSelfCheck: %w", err)
 		}
 	}
 
-	if r.DisableCertificateRequestController && r.DisableKubernetesCSRController {
-		return fmt.Errorf("both CertificateRequest and Kubernetes CSR controllers are disabled, must enable at least one")
+	if setupIssuerControllers {
+		for _, issuerType := range append(r.IssuerTypes, r.ClusterIssuerTypes...) {
+			if err = (&IssuerReconciler{
+				ForObject: issuerType,
+
+				FieldOwner:                 r.FieldOwner,
+				FieldOwnerForIssuer:        r.FieldOwnerForIssuer,
+				FieldOwnerConflictWindow:   r.FieldOwnerConflictWindow,
+				EventSource:                eventSource,
+				ReadinessRegistry:          readinessRegistry,
+				Messages:                   r.Messages,
+				MessageStabilizationPolicy: r.MessageStabilizationPolicy,
+				Redaction:                  r.Redaction,
+				UpstreamConfig:             r.UpstreamConfig,
+				ClusterResourceNamespace:   r.ClusterResourceNamespace,
+
+				Client:           cl,
+				Check:            r.Check,
+				NamedChecks:      r.NamedChecks,
+				CheckAggregation: r.CheckAggregation,
+				CanarySigning:    r.CanarySigning,
+				IgnoreIssuer:     r.IgnoreIssuer,
+				ErrorClassifier:  r.ErrorClassifier,
+				EventRecorder:    r.EventRecorder,
+				Clock:            r.Clock,
+
+				PreSetupWithManager:  r.PreSetupWithManager,
+				PostSetupWithManager: r.PostSetupWithManager,
+
+				lifecycleEvents: lifecycleEvents,
+				canarySign:      r.Sign,
+			}).SetupWithManager(ctx, mgr); err != nil {
+				return fmt.Errorf("%T: %w", issuerType, err)
+			}
+
+			if r.IssuerSecretRefs != nil {
+				if err = (&IssuerSecretReconciler{
+					ForObject: issuerType,
+
+					ClusterResourceNamespace: r.ClusterResourceNamespace,
+
+					Client:           cl,
+					IssuerSecretRefs: r.IssuerSecretRefs,
+				}).SetupWithManager(ctx, mgr); err != nil {
+					return fmt.Errorf("%T secrets: %w", issuerType, err)
+				}
+			}
+		}
 	}
 
-	if !r.DisableCertificateRequestController {
+	if enableCertificateRequests {
 		if err = (&CertificateRequestReconciler{
 			RequestController: RequestController{
 				IssuerTypes:        r.IssuerTypes,
 				ClusterIssuerTypes: r.ClusterIssuerTypes,
 
-				FieldOwner:       r.FieldOwner,
-				MaxRetryDuration: r.MaxRetryDuration,
-				EventSource:      eventSource,
-
-				Client:                   cl,
-				Sign:                     r.Sign,
-				IgnoreCertificateRequest: r.IgnoreCertificateRequest,
-				EventRecorder:            r.EventRecorder,
-				Clock:                    r.Clock,
+				FieldOwner:                 r.FieldOwner,
+				FieldOwnerForIssuer:        r.FieldOwnerForIssuer,
+				FieldOwnerConflictWindow:   r.FieldOwnerConflictWindow,
+				MaxRetryDuration:           r.MaxRetryDuration,
+				RetryPolicy:                r.RetryPolicy,
+				Messages:                   r.Messages,
+				MessageStabilizationPolicy: r.MessageStabilizationPolicy,
+				Redaction:                  r.Redaction,
+				EventSource:                eventSource,
+				ReadinessRegistry:          readinessRegistry,
+
+				CriticalExtensionPolicy:   r.CriticalExtensionPolicy,
+				CSRCanonicalizationPolicy: r.CSRCanonicalizationPolicy,
+				PEMNormalizationPolicy:    r.PEMNormalizationPolicy,
+				ChainExpiryPolicy:         r.ChainExpiryPolicy,
+				FailedRequestCache:        r.FailedRequestCache,
+				ExternalApprovalPolicy:    r.ExternalApprovalPolicy,
+				IssuanceClaimPolicy:       r.IssuanceClaimPolicy,
+				ProvenancePolicy:          r.ProvenancePolicy,
+				AdaptiveConcurrency:       r.AdaptiveConcurrency,
+				WarmUp:                    r.WarmUp,
+				StatusPatchCoalescing:     r.StatusPatchCoalescing,
+				AggregateRevocationInfo:   r.AggregateRevocationInfo,
+				QuotaPolicy:               r.QuotaPolicy,
+				DeduplicationPolicy:       r.DeduplicationPolicy,
+				PreviousCertificatePolicy: r.PreviousCertificatePolicy,
+				InventoryExporter:         r.InventoryExporter,
+				IssuerSnapshotPolicy:      r.IssuerSnapshotPolicy,
+				UpstreamConfig:            r.UpstreamConfig,
+				ClusterResourceNamespace:  r.ClusterResourceNamespace,
+				FailOnIssuerFailed:        r.FailOnIssuerFailed,
+				IssuerFailedGracePeriod:   r.IssuerFailedGracePeriod,
+				DurationPolicy:            r.DurationPolicy,
+				RequestOptionsPolicy:      r.RequestOptionsPolicy,
+				IssuerNotReadyCache:       r.IssuerNotReadyCache,
+				IssuerResyncPeriod:        r.IssuerResyncPeriod,
+				SetTimeInStateAnnotation:  r.SetTimeInStateAnnotation,
+				EnableCorrelationIDs:      r.EnableCorrelationIDs,
+				EnableIssuanceControl:     r.EnableIssuanceControl,
+				NotifyOwningCertificate:   r.NotifyOwningCertificate,
+				PropagatedLabels:          r.PropagatedLabels,
+				AllowIssuerOverride:       r.AllowIssuerOverride,
+
+				Client:                          cl,
+				APIReader:                       mgr.GetAPIReader(),
+				Sign:                            r.Sign,
+				IgnoreCertificateRequest:        r.IgnoreCertificateRequest,
+				IgnoredCertificateRequestReason: r.IgnoredCertificateRequestReason,
+				RequestPriority:                 r.RequestPriority,
+				RequestTenant:                   r.RequestTenant,
+				ErrorClassifier:                 r.ErrorClassifier,
+				SupportedKeyAlgorithms:          r.SupportedKeyAlgorithms,
+				EventRecorder:                   r.EventRecorder,
+				Clock:                           r.Clock,
 
 				PreSetupWithManager:  r.PreSetupWithManager,
 				PostSetupWithManager: r.PostSetupWithManager,
+
+				lifecycleEvents: lifecycleEvents,
 			},
 
 			SetCAOnCertificateRequest: r.SetCAOnCertificateRequest,
+			CRDCompatibility:          r.CRDCompatibility,
+			DetectCRDCompatibility:    r.DetectCRDCompatibility,
 		}).SetupWithManager(ctx, mgr); err != nil {
 			return fmt.Errorf("CertificateRequestReconciler: %w", err)
 		}
 	}
 
-	if !r.DisableKubernetesCSRController {
+	if enableKubernetesCSRs {
 		if err = (&CertificateSigningRequestReconciler{
 			RequestController: RequestController{
 				IssuerTypes:        r.IssuerTypes,
 				ClusterIssuerTypes: r.ClusterIssuerTypes,
 
-				FieldOwner:       r.FieldOwner,
-				MaxRetryDuration: r.MaxRetryDuration,
-				EventSource:      eventSource,
-
-				Client:                   cl,
-				Sign:                     r.Sign,
-				IgnoreCertificateRequest: r.IgnoreCertificateRequest,
-				EventRecorder:            r.EventRecorder,
-				Clock:                    r.Clock,
+				FieldOwner:                 r.FieldOwner,
+				FieldOwnerForIssuer:        r.FieldOwnerForIssuer,
+				FieldOwnerConflictWindow:   r.FieldOwnerConflictWindow,
+				MaxRetryDuration:           r.MaxRetryDuration,
+				RetryPolicy:                r.RetryPolicy,
+				Messages:                   r.Messages,
+				MessageStabilizationPolicy: r.MessageStabilizationPolicy,
+				Redaction:                  r.Redaction,
+				EventSource:                eventSource,
+				ReadinessRegistry:          readinessRegistry,
+
+				CriticalExtensionPolicy:   r.CriticalExtensionPolicy,
+				CSRCanonicalizationPolicy: r.CSRCanonicalizationPolicy,
+				PEMNormalizationPolicy:    r.PEMNormalizationPolicy,
+				ChainExpiryPolicy:         r.ChainExpiryPolicy,
+				FailedRequestCache:        r.FailedRequestCache,
+				ExternalApprovalPolicy:    r.ExternalApprovalPolicy,
+				IssuanceClaimPolicy:       r.IssuanceClaimPolicy,
+				ProvenancePolicy:          r.ProvenancePolicy,
+				AdaptiveConcurrency:       r.AdaptiveConcurrency,
+				WarmUp:                    r.WarmUp,
+				StatusPatchCoalescing:     r.StatusPatchCoalescing,
+				AggregateRevocationInfo:   r.AggregateRevocationInfo,
+				QuotaPolicy:               r.QuotaPolicy,
+				DeduplicationPolicy:       r.DeduplicationPolicy,
+				PreviousCertificatePolicy: r.PreviousCertificatePolicy,
+				InventoryExporter:         r.InventoryExporter,
+				IssuerSnapshotPolicy:      r.IssuerSnapshotPolicy,
+				UpstreamConfig:            r.UpstreamConfig,
+				ClusterResourceNamespace:  r.ClusterResourceNamespace,
+				FailOnIssuerFailed:        r.FailOnIssuerFailed,
+				IssuerFailedGracePeriod:   r.IssuerFailedGracePeriod,
+				DurationPolicy:            r.DurationPolicy,
+				RequestOptionsPolicy:      r.RequestOptionsPolicy,
+				IssuerNotReadyCache:       r.IssuerNotReadyCache,
+				IssuerResyncPeriod:        r.IssuerResyncPeriod,
+				SetTimeInStateAnnotation:  r.SetTimeInStateAnnotation,
+				EnableCorrelationIDs:      r.EnableCorrelationIDs,
+				EnableIssuanceControl:     r.EnableIssuanceControl,
+				PropagatedLabels:          r.PropagatedLabels,
+				AllowIssuerOverride:       r.AllowIssuerOverride,
+
+				Client:                          cl,
+				APIReader:                       mgr.GetAPIReader(),
+				Sign:                            r.Sign,
+				IgnoreCertificateRequest:        r.IgnoreCertificateRequest,
+				IgnoredCertificateRequestReason: r.IgnoredCertificateRequestReason,
+				RequestPriority:                 r.RequestPriority,
+				RequestTenant:                   r.RequestTenant,
+				ErrorClassifier:                 r.ErrorClassifier,
+				SupportedKeyAlgorithms:          r.SupportedKeyAlgorithms,
+				EventRecorder:                   r.EventRecorder,
+				Clock:                           r.Clock,
 
 				PreSetupWithManager:  r.PreSetupWithManager,
 				PostSetupWithManager: r.PostSetupWithManager,
+
+				lifecycleEvents: lifecycleEvents,
 			},
+
+			KeyUsageEnforcement: r.KubernetesCSRKeyUsageEnforcement,
+			SignerNamePatterns:  r.KubernetesCSRSignerNamePatterns,
+			ResolveSignerName:   r.ResolveSignerName,
 		}).SetupWithManager(ctx, mgr); err != nil {
 			return fmt.Errorf("CertificateRequestReconciler: %w", err)
 		}
+
+		if r.KubernetesCSRGarbageCollection != nil {
+			if err = (&CertificateSigningRequestGCReconciler{
+				ClusterIssuerTypes:   r.ClusterIssuerTypes,
+				SignerNamePatterns:   r.KubernetesCSRSignerNamePatterns,
+				CSRGarbageCollection: *r.KubernetesCSRGarbageCollection,
+
+				Client: cl,
+				Clock:  r.Clock,
+			}).SetupWithManager(ctx, mgr); err != nil {
+				return fmt.Errorf("CertificateSigningRequestGCReconciler: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// enabledRequestKinds returns which request controllers must be set up, and
+// checks the prerequisites of the request kinds that were explicitly enabled.
+func (r *CombinedController) enabledRequestKinds(ctx context.Context, mgr ctrl.Manager) (bool, bool, error) {
+	enableCertificateRequests, checkCertificateRequests, err := requestTypeEnabled(certificateRequestType, r.EnableCertificateRequests, r.DisableCertificateRequestController)
+	if err != nil {
+		return false, false, err
+	}
+	enableKubernetesCSRs, checkKubernetesCSRs, err := requestTypeEnabled(kubernetesCSRType, r.EnableKubernetesCSRs, r.DisableKubernetesCSRController)
+	if err != nil {
+		return false, false, err
+	}
+
+	if !enableCertificateRequests && !enableKubernetesCSRs {
+		return false, false, fmt.Errorf("both CertificateRequest and Kubernetes CSR controllers are disabled, must enable at least one")
+	}
+
+	if checkCertificateRequests || checkKubernetesCSRs {
+		reviewAccess, err := newSelfSubjectAccessReviewer(mgr.GetConfig())
+		if err != nil {
+			return false, false, err
+		}
+
+		issuerTypes := append(append([]v1alpha1.Issuer{}, r.IssuerTypes...), r.ClusterIssuerTypes...)
+		if checkCertificateRequests {
+			if err := checkRequestTypePrerequisites(ctx, mgr.GetRESTMapper(), reviewAccess, certificateRequestType, issuerTypes); err != nil {
+				return false, false, err
+			}
+		}
+		if checkKubernetesCSRs {
+			if err := checkRequestTypePrerequisites(ctx, mgr.GetRESTMapper(), reviewAccess, kubernetesCSRType, issuerTypes); err != nil {
+				return false, false, err
+			}
+		}
+	}
+
+	return enableCertificateRequests, enableKubernetesCSRs, nil
+}
+
+// setupRBACSelfCheck adds the RBACSelfCheck to the manager and registers it as a
+// health check. When the issuer and request controllers are set up by separate
+// Setup calls, the RBACSelfCheck is only added by the first call and checks the
+// permissions of the request kinds that are enabled by any of the calls.
+func (r *CombinedController) setupRBACSelfCheck(mgr ctrl.Manager, enableCertificateRequests bool, enableKubernetesCSRs bool) error {
+	r.rbacSelfCheckCertificateRequests = r.rbacSelfCheckCertificateRequests || enableCertificateRequests
+	r.rbacSelfCheckKubernetesCSRs = r.rbacSelfCheckKubernetesCSRs || enableKubernetesCSRs
+	if r.rbacSelfCheckAdded {
+		return nil
+	}
+
+	reviewAccess, err := newSelfSubjectAccessReviewer(mgr.GetConfig())
+	if err != nil {
+		return err
+	}
+
+	r.RBACSelfCheck.reviewAccess = reviewAccess
+	r.RBACSelfCheck.permissions = func() ([]authorizationv1.ResourceAttributes, error) {
+		return r.requiredPermissions(mgr.GetScheme(), mgr.GetRESTMapper(), r.rbacSelfCheckCertificateRequests, r.rbacSelfCheckKubernetesCSRs)
+	}
+
+	if err := mgr.Add(r.RBACSelfCheck); err != nil {
+		return err
+	}
+	if err := mgr.AddHealthzCheck("rbac-self-check", r.RBACSelfCheck.Checker); err != nil {
+		return err
+	}
+
+	r.rbacSelfCheckAdded = true
+	return nil
+}
+
+// checkAllowedIssuerAPIGroups returns an error if one of the issuer types does not
+// belong to one of the allowed API groups. All API groups are allowed if the list
+// of allowed API groups is empty.
+func checkAllowedIssuerAPIGroups(scheme *runtime.Scheme, allowedAPIGroups []string, issuerTypes []v1alpha1.Issuer) error {
+	if len(allowedAPIGroups) == 0 {
+		return nil
+	}
+
+	for _, issuerType := range issuerTypes {
+		if err := kubeutil.SetGroupVersionKind(scheme, issuerType); err != nil {
+			return fmt.Errorf("%T: %w", issuerType, err)
+		}
+
+		group := issuerType.GetObjectKind().GroupVersionKind().Group
+		if !slices.Contains(allowedAPIGroups, group) {
+			return fmt.Errorf("%T: API group %q is not one of the allowed issuer API groups %q", issuerType, group, allowedAPIGroups)
+		}
 	}
 
 	return nil
@@ -0,0 +1,135 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/config"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+	"github.com/cert-manager/issuer-lib/internal/tests/errormatch"
+)
+
+func TestCheckAllowedIssuerAPIGroups(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name             string
+		allowedAPIGroups []string
+		expectedError    *errormatch.Matcher
+	}
+
+	tests := []testcase{
+		{
+			name:             "no-allowed-groups",
+			allowedAPIGroups: nil,
+		},
+		{
+			name:             "allowed-group",
+			allowedAPIGroups: []string{"other.example.com", api.SchemeGroupVersion.Group},
+		},
+		{
+			name:             "unknown-group",
+			allowedAPIGroups: []string{"other.example.com"},
+			expectedError:    errormatch.ErrorContains(`API group "testing.cert-manager.io" is not one of the allowed issuer API groups ["other.example.com"]`),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			scheme := runtime.NewScheme()
+			require.NoError(t, api.AddToScheme(scheme))
+
+			err := checkAllowedIssuerAPIGroups(
+				scheme,
+				tc.allowedAPIGroups,
+				[]v1alpha1.Issuer{&api.TestIssuer{}, &api.TestClusterIssuer{}},
+			)
+			ptr.Deref(tc.expectedError, *errormatch.NoError())(t, err)
+		})
+	}
+}
+
+func TestSetupControllersSelectively(t *testing.T) {
+	t.Parallel()
+
+	newManager := func(t *testing.T) ctrl.Manager {
+		scheme := runtime.NewScheme()
+		require.NoError(t, api.AddToScheme(scheme))
+
+		// The manager is never started, so the API server is never contacted.
+		mgr, err := ctrl.NewManager(&rest.Config{Host: "https://127.0.0.1:1"}, ctrl.Options{
+			Scheme:                 scheme,
+			Metrics:                metricsserver.Options{BindAddress: "0"},
+			HealthProbeBindAddress: "0",
+			Controller: config.Controller{
+				SkipNameValidation: ptr.To(true),
+			},
+		})
+		require.NoError(t, err)
+		return mgr
+	}
+
+	newController := func() *CombinedController {
+		return &CombinedController{
+			IssuerTypes: []v1alpha1.Issuer{&api.TestIssuer{}},
+			FieldOwner:  "test",
+
+			EnableCertificateRequests: ptr.To(false),
+			EnableKubernetesCSRs:      ptr.To(false),
+		}
+	}
+
+	// The request kinds are only checked when the request controllers are set up.
+	require.NoError(t, newController().SetupIssuerControllersOnly(context.TODO(), newManager(t)))
+	require.ErrorContains(t, newController().SetupRequestControllersOnly(context.TODO(), newManager(t)), "must enable at least one")
+	require.ErrorContains(t, newController().SetupWithManager(context.TODO(), newManager(t)), "must enable at least one")
+
+	// An injected EventSource is shared by all controllers.
+	eventSource := NewEventSource(nil)
+	controller := newController()
+	controller.EventSource = eventSource
+	actualEventSource, readinessRegistry := controller.sharedState()
+	assert.Same(t, eventSource, actualEventSource)
+
+	// The state is shared between separate Setup calls.
+	actualEventSource, actualReadinessRegistry := controller.sharedState()
+	assert.Same(t, eventSource, actualEventSource)
+	assert.Same(t, readinessRegistry, actualReadinessRegistry)
+
+	// The RBACSelfCheck is registered once when the issuer and request controllers
+	// are set up by separate calls, and checks the request kinds of both calls.
+	mgr := newManager(t)
+	controller = newController()
+	controller.RBACSelfCheck = &RBACSelfCheck{}
+	require.NoError(t, controller.setupRBACSelfCheck(mgr, false, false))
+	require.NoError(t, controller.setupRBACSelfCheck(mgr, true, false))
+	assert.True(t, controller.rbacSelfCheckCertificateRequests)
+	assert.False(t, controller.rbacSelfCheckKubernetesCSRs)
+}
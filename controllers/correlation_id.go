@@ -0,0 +1,99 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"maps"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/cert-manager/issuer-lib/annotations"
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+)
+
+// newCorrelationID returns a random correlation ID of 32 hexadecimal characters.
+func newCorrelationID() (string, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id[:]), nil
+}
+
+// ensureCorrelationID returns the correlation ID of the request. If the request
+// has no valid RequestCorrelationIDAnnotationKey annotation, a new ID is
+// generated and persisted in the annotation. The patch is conditional on the
+// resourceVersion of the request, so that concurrent reconciles cannot persist
+// different IDs.
+func (r *RequestController) ensureCorrelationID(ctx context.Context, requestObject client.Object) (string, error) {
+	correlationID := requestObject.GetAnnotations()[v1alpha1.RequestCorrelationIDAnnotationKey]
+	if annotations.ValidateCorrelationID(correlationID) == nil {
+		return correlationID, nil
+	}
+
+	correlationID, err := newCorrelationID()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate correlation ID: %w", err)
+	}
+
+	if err := patchAnnotations(ctx, r.Client, requestObject, requestObject.GetResourceVersion(), map[string]string{
+		v1alpha1.RequestCorrelationIDAnnotationKey: correlationID,
+	}); err != nil {
+		return "", fmt.Errorf("failed to set correlation ID annotation on request: %w", err)
+	}
+	return correlationID, nil
+}
+
+// withCorrelationID returns an EventRecorder that adds the correlation ID as the
+// RequestCorrelationIDAnnotationKey annotation to the recorded events. The
+// recorder is returned unchanged if the correlation ID is empty.
+func withCorrelationID(recorder record.EventRecorder, correlationID string) record.EventRecorder {
+	if correlationID == "" {
+		return recorder
+	}
+	return correlationEventRecorder{EventRecorder: recorder, correlationID: correlationID}
+}
+
+// correlationEventRecorder is an EventRecorder that annotates the recorded events
+// with a correlation ID.
+type correlationEventRecorder struct {
+	record.EventRecorder
+	correlationID string
+}
+
+var _ record.EventRecorder = correlationEventRecorder{}
+
+func (r correlationEventRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	r.AnnotatedEventf(object, nil, eventtype, reason, "%s", message)
+}
+
+func (r correlationEventRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.AnnotatedEventf(object, nil, eventtype, reason, messageFmt, args...)
+}
+
+func (r correlationEventRecorder) AnnotatedEventf(object runtime.Object, eventAnnotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	merged := make(map[string]string, len(eventAnnotations)+1)
+	maps.Copy(merged, eventAnnotations)
+	merged[v1alpha1.RequestCorrelationIDAnnotationKey] = r.correlationID
+	r.EventRecorder.AnnotatedEventf(object, merged, eventtype, reason, messageFmt, args...)
+}
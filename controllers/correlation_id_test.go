@@ -0,0 +1,203 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	cmgen "github.com/cert-manager/cert-manager/test/unit/gen"
+	"github.com/go-logr/logr/funcr"
+	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/cert-manager/issuer-lib/annotations"
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/kubeutil"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+	"github.com/cert-manager/issuer-lib/internal/testapi/testutil"
+)
+
+func TestCertificateRequestReconcilerCorrelationID(t *testing.T) {
+	t.Parallel()
+
+	fieldOwner := "test-certificate-request-reconciler-correlation-id"
+
+	fakeClock := clocktesting.NewFakeClock(randomTime())
+
+	type testCase struct {
+		name                  string
+		enabled               bool
+		existingCorrelationID string
+		expectGenerated       bool
+		expectedCorrelationID string
+	}
+
+	tests := []testCase{
+		{
+			name:                  "disabled",
+			enabled:               false,
+			expectedCorrelationID: "",
+		},
+		{
+			name:            "generated",
+			enabled:         true,
+			expectGenerated: true,
+		},
+		{
+			name:                  "existing",
+			enabled:               true,
+			existingCorrelationID: "workflow-42",
+			expectedCorrelationID: "workflow-42",
+		},
+		{
+			name:                  "invalid-existing",
+			enabled:               true,
+			existingCorrelationID: "invalid id",
+			expectGenerated:       true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			issuer := testutil.TestIssuer(
+				"issuer-1",
+				testutil.SetTestIssuerNamespace("ns1"),
+				testutil.SetTestIssuerStatusCondition(
+					fakeClock,
+					cmapi.IssuerConditionReady,
+					cmmeta.ConditionTrue,
+					v1alpha1.IssuerConditionReasonChecked,
+					"Succeeded checking the issuer",
+				),
+			)
+
+			cr1 := cmgen.CertificateRequest(
+				"cr1",
+				cmgen.SetCertificateRequestNamespace("ns1"),
+				cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+					Name:  issuer.Name,
+					Group: api.SchemeGroupVersion.Group,
+				}),
+				cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+					Type:   cmapi.CertificateRequestConditionReady,
+					Status: cmmeta.ConditionUnknown,
+					Reason: v1alpha1.CertificateRequestConditionReasonInitializing,
+				}),
+				cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+					Type:   cmapi.CertificateRequestConditionApproved,
+					Status: cmmeta.ConditionTrue,
+				}),
+			)
+			if tc.existingCorrelationID != "" {
+				cr1.Annotations = map[string]string{
+					v1alpha1.RequestCorrelationIDAnnotationKey: tc.existingCorrelationID,
+				}
+			}
+
+			scheme := runtime.NewScheme()
+			require.NoError(t, setupCertificateRequestReconcilerScheme(scheme))
+			require.NoError(t, api.AddToScheme(scheme))
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(cr1, issuer).
+				Build()
+
+			logger := logrtesting.NewTestLoggerWithOptions(t, logrtesting.Options{LogTimestamp: true, Verbosity: 10})
+			fakeRecorder := record.NewFakeRecorder(100)
+
+			var signCorrelationID string
+			controller := (&CertificateRequestReconciler{
+				RequestController: RequestController{
+					IssuerTypes:          []v1alpha1.Issuer{&api.TestIssuer{}},
+					FieldOwner:           fieldOwner,
+					MaxRetryDuration:     time.Minute,
+					EventSource:          kubeutil.NewEventStore(),
+					EnableCorrelationIDs: tc.enabled,
+					Client:               fakeClient,
+					Sign: func(ctx context.Context, _ signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+						signCorrelationID = signer.CorrelationIDFromContext(ctx)
+						return signer.PEMBundle{ChainPEM: []byte("cert")}, nil
+					},
+					EventRecorder: fakeRecorder,
+					Clock:         fakeClock,
+				},
+			}).Init()
+			require.NoError(t, controller.setAllIssuerTypesWithGroupVersionKind(scheme))
+
+			_, _, err := controller.reconcileStatusPatch(logger, context.TODO(), reconcile.Request{
+				NamespacedName: client.ObjectKeyFromObject(cr1),
+			})
+			require.NoError(t, err)
+
+			var currentCr cmapi.CertificateRequest
+			require.NoError(t, fakeClient.Get(context.TODO(), client.ObjectKeyFromObject(cr1), &currentCr))
+			correlationID := currentCr.Annotations[v1alpha1.RequestCorrelationIDAnnotationKey]
+
+			if tc.expectGenerated {
+				require.NoError(t, annotations.ValidateCorrelationID(correlationID))
+				assert.Len(t, correlationID, 32)
+			} else {
+				assert.Equal(t, tc.expectedCorrelationID, correlationID)
+			}
+
+			// The correlation ID is passed to Sign and added to the events.
+			require.Len(t, fakeRecorder.Events, 1)
+			event := <-fakeRecorder.Events
+			if tc.enabled {
+				assert.Equal(t, correlationID, signCorrelationID)
+				assert.Contains(t, event, v1alpha1.RequestCorrelationIDAnnotationKey+":"+correlationID)
+			} else {
+				assert.Empty(t, signCorrelationID)
+				assert.NotContains(t, event, v1alpha1.RequestCorrelationIDAnnotationKey)
+			}
+		})
+	}
+}
+
+func TestCorrelatedLogger(t *testing.T) {
+	t.Parallel()
+
+	var lines []string
+	logger := funcr.New(func(prefix, args string) {
+		lines = append(lines, args)
+	}, funcr.Options{})
+
+	ctx := signer.WithCorrelationID(context.TODO(), "workflow-42")
+	assert.Equal(t, "workflow-42", signer.CorrelationIDFromContext(ctx))
+	signer.CorrelatedLogger(ctx, logger).Info("signing")
+
+	assert.Empty(t, signer.CorrelationIDFromContext(context.TODO()))
+	signer.CorrelatedLogger(context.TODO(), logger).Info("signing")
+
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"correlationID"="workflow-42"`)
+	assert.NotContains(t, lines[1], "correlationID")
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+)
+
+// certificateRequestCRDName is the name of the CertificateRequest CRD that is
+// installed by cert-manager.
+const certificateRequestCRDName = "certificaterequests.cert-manager.io"
+
+// certificateRequestStatusFields are the fields of the CertificateRequest status
+// that can be set by the controllers, except for the conditions.
+var certificateRequestStatusFields = []string{"ca", "certificate", "failureTime"}
+
+// CRDCompatibility describes how the status patches of CertificateRequests are
+// adjusted to the schema of an older CertificateRequest CRD (eg. the CRDs of a
+// long-term-support cert-manager release). The zero value does not adjust the
+// status patches.
+type CRDCompatibility struct {
+	// AtomicConditions is true if the conditions of the CRD are an atomic list
+	// instead of a list map keyed by the condition type. A server-side apply
+	// patch of an atomic list replaces the whole list, so the status patches
+	// then contain all current conditions of the CertificateRequest to not
+	// remove the conditions of other field managers (eg. the Approved condition).
+	AtomicConditions bool
+
+	// UnsupportedStatusFields are the JSON names of the status fields that are not
+	// part of the schema of the CRD. These fields are not included in the status
+	// patches, since the API server rejects server-side apply patches that contain
+	// fields that are not declared in the schema.
+	UnsupportedStatusFields []string
+}
+
+// DetectCRDCompatibility reads the schema of the "v1" version of the installed
+// CertificateRequest CRD and returns the adjustments that are required for it,
+// or nil if the CRD supports the status patches of the controllers as-is. This
+// requires get permissions on the CertificateRequest CRD, and the scheme of the
+// reader must contain the apiextensions.k8s.io/v1 types.
+func DetectCRDCompatibility(ctx context.Context, reader client.Reader) (*CRDCompatibility, error) {
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	if err := reader.Get(ctx, types.NamespacedName{Name: certificateRequestCRDName}, crd); err != nil {
+		return nil, fmt.Errorf("failed to get the %s CRD: %w", certificateRequestCRDName, err)
+	}
+
+	var schema *apiextensionsv1.JSONSchemaProps
+	for _, version := range crd.Spec.Versions {
+		if version.Name == cmapi.SchemeGroupVersion.Version && version.Schema != nil {
+			schema = version.Schema.OpenAPIV3Schema
+		}
+	}
+	if schema == nil {
+		return nil, fmt.Errorf("the %s CRD has no schema for version %s", certificateRequestCRDName, cmapi.SchemeGroupVersion.Version)
+	}
+
+	statusSchema, ok := schema.Properties["status"]
+	if !ok {
+		return nil, fmt.Errorf("the %s CRD has no status in its schema", certificateRequestCRDName)
+	}
+
+	compatibility := &CRDCompatibility{}
+	for _, field := range certificateRequestStatusFields {
+		if _, ok := statusSchema.Properties[field]; !ok {
+			compatibility.UnsupportedStatusFields = append(compatibility.UnsupportedStatusFields, field)
+		}
+	}
+
+	conditionsSchema := statusSchema.Properties["conditions"]
+	if conditionsSchema.XListType == nil || *conditionsSchema.XListType != "map" {
+		compatibility.AtomicConditions = true
+	}
+
+	if !compatibility.AtomicConditions && len(compatibility.UnsupportedStatusFields) == 0 {
+		return nil, nil
+	}
+	return compatibility, nil
+}
+
+// newCRDReader returns a reader for CustomResourceDefinitions that uses its own
+// scheme, so the apiextensions.k8s.io/v1 types do not have to be added to the
+// scheme of the manager.
+func newCRDReader(config *rest.Config, httpClient *http.Client) (client.Reader, error) {
+	scheme := runtime.NewScheme()
+	if err := apiextensionsv1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+
+	return client.New(config, client.Options{
+		Scheme:     scheme,
+		HTTPClient: httpClient,
+	})
+}
+
+// adjustStatusPatch returns a copy of the status patch of the CertificateRequest
+// that is adjusted to the schema of the CRD. A nil CRDCompatibility returns the
+// status patch unchanged.
+func (c *CRDCompatibility) adjustStatusPatch(
+	cr *cmapi.CertificateRequest,
+	status *cmapi.CertificateRequestStatus,
+) *cmapi.CertificateRequestStatus {
+	if c == nil {
+		return status
+	}
+
+	status = status.DeepCopy()
+
+	for _, field := range c.UnsupportedStatusFields {
+		switch field {
+		case "ca":
+			status.CA = nil
+		case "certificate":
+			status.Certificate = nil
+		case "failureTime":
+			status.FailureTime = nil
+		}
+	}
+
+	if c.AtomicConditions && len(status.Conditions) > 0 {
+		// Carry over the current conditions that are not set by the patch, in
+		// their current order. The StatusPatchRejected condition is not carried
+		// over, so that it is removed by the first accepted status patch, like
+		// it is when the conditions are a list map.
+		conditions := make([]cmapi.CertificateRequestCondition, 0, len(cr.Status.Conditions)+len(status.Conditions))
+		for _, current := range cr.Status.Conditions {
+			i := slices.IndexFunc(status.Conditions, func(condition cmapi.CertificateRequestCondition) bool {
+				return condition.Type == current.Type
+			})
+			switch {
+			case i >= 0:
+				conditions = append(conditions, status.Conditions[i])
+			case current.Type != v1alpha1.ConditionTypeStatusPatchRejected:
+				conditions = append(conditions, current)
+			}
+		}
+
+		for _, condition := range status.Conditions {
+			if !slices.ContainsFunc(cr.Status.Conditions, func(current cmapi.CertificateRequestCondition) bool {
+				return condition.Type == current.Type
+			}) {
+				conditions = append(conditions, condition)
+			}
+		}
+
+		status.Conditions = conditions
+	}
+
+	return status
+}
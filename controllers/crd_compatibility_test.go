@@ -0,0 +1,238 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	cmgen "github.com/cert-manager/cert-manager/test/unit/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+)
+
+func certificateRequestCRD(conditionsListType *string, statusFields ...string) *apiextensionsv1.CustomResourceDefinition {
+	statusProperties := map[string]apiextensionsv1.JSONSchemaProps{
+		"conditions": {Type: "array", XListType: conditionsListType},
+	}
+	for _, field := range statusFields {
+		statusProperties[field] = apiextensionsv1.JSONSchemaProps{Type: "string"}
+	}
+
+	return &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: certificateRequestCRDName},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+				{
+					Name: "v1",
+					Schema: &apiextensionsv1.CustomResourceValidation{
+						OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+							Type: "object",
+							Properties: map[string]apiextensionsv1.JSONSchemaProps{
+								"status": {Type: "object", Properties: statusProperties},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestDetectCRDCompatibility(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		crd           *apiextensionsv1.CustomResourceDefinition
+		expected      *CRDCompatibility
+		expectedError string
+	}{
+		{
+			name: "current-crd",
+			crd:  certificateRequestCRD(ptr.To("map"), "ca", "certificate", "failureTime"),
+		},
+		{
+			name: "atomic-conditions",
+			crd:  certificateRequestCRD(nil, "ca", "certificate", "failureTime"),
+			expected: &CRDCompatibility{
+				AtomicConditions: true,
+			},
+		},
+		{
+			name: "unsupported-status-fields",
+			crd:  certificateRequestCRD(ptr.To("map"), "certificate"),
+			expected: &CRDCompatibility{
+				UnsupportedStatusFields: []string{"ca", "failureTime"},
+			},
+		},
+		{
+			name:          "missing-crd",
+			expectedError: "failed to get the certificaterequests.cert-manager.io CRD",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			scheme := runtime.NewScheme()
+			require.NoError(t, apiextensionsv1.AddToScheme(scheme))
+
+			builder := fake.NewClientBuilder().WithScheme(scheme)
+			if tc.crd != nil {
+				builder = builder.WithObjects(tc.crd)
+			}
+
+			compatibility, err := DetectCRDCompatibility(context.TODO(), builder.Build())
+			if tc.expectedError != "" {
+				require.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, compatibility)
+		})
+	}
+}
+
+func TestNewCRDReader(t *testing.T) {
+	t.Parallel()
+
+	reader, err := newCRDReader(&rest.Config{Host: "https://127.0.0.1:6443"}, http.DefaultClient)
+	require.NoError(t, err)
+
+	// The reader has its own scheme that contains the CRD type.
+	cl, ok := reader.(client.Client)
+	require.True(t, ok)
+	_, _, err = cl.Scheme().ObjectKinds(&apiextensionsv1.CustomResourceDefinition{})
+	require.NoError(t, err)
+}
+
+func TestCRDCompatibilityAdjustStatusPatch(t *testing.T) {
+	t.Parallel()
+
+	approved := cmapi.CertificateRequestCondition{
+		Type:   cmapi.CertificateRequestConditionApproved,
+		Status: cmmeta.ConditionTrue,
+	}
+	rejected := cmapi.CertificateRequestCondition{
+		Type:   v1alpha1.ConditionTypeStatusPatchRejected,
+		Status: cmmeta.ConditionTrue,
+	}
+	pending := cmapi.CertificateRequestCondition{
+		Type:   cmapi.CertificateRequestConditionReady,
+		Status: cmmeta.ConditionFalse,
+		Reason: cmapi.CertificateRequestReasonPending,
+	}
+	issued := cmapi.CertificateRequestCondition{
+		Type:   cmapi.CertificateRequestConditionReady,
+		Status: cmmeta.ConditionTrue,
+		Reason: cmapi.CertificateRequestReasonIssued,
+	}
+	custom := cmapi.CertificateRequestCondition{
+		Type:   "Custom",
+		Status: cmmeta.ConditionTrue,
+	}
+
+	cr := cmgen.CertificateRequest(
+		"cr1",
+		cmgen.SetCertificateRequestStatusCondition(pending),
+		cmgen.SetCertificateRequestStatusCondition(approved),
+		cmgen.SetCertificateRequestStatusCondition(rejected),
+	)
+
+	status := &cmapi.CertificateRequestStatus{
+		Conditions:  []cmapi.CertificateRequestCondition{issued, custom},
+		Certificate: []byte("cert"),
+		CA:          []byte("ca"),
+		FailureTime: &metav1.Time{},
+	}
+
+	// A nil CRDCompatibility does not adjust the status patch.
+	var nilCompatibility *CRDCompatibility
+	assert.Same(t, status, nilCompatibility.adjustStatusPatch(cr, status))
+
+	compatibility := &CRDCompatibility{
+		AtomicConditions:        true,
+		UnsupportedStatusFields: []string{"ca", "failureTime"},
+	}
+	assert.Equal(t, &cmapi.CertificateRequestStatus{
+		Conditions:  []cmapi.CertificateRequestCondition{issued, approved, custom},
+		Certificate: []byte("cert"),
+	}, compatibility.adjustStatusPatch(cr, status))
+
+	// The status patch itself is not modified.
+	assert.Equal(t, []cmapi.CertificateRequestCondition{issued, custom}, status.Conditions)
+	assert.Equal(t, []byte("ca"), status.CA)
+
+	// Status patches without conditions (eg. the patches that only remove the
+	// fields of a field owner) are not extended with the current conditions.
+	assert.Equal(t, &cmapi.CertificateRequestStatus{}, compatibility.adjustStatusPatch(cr, &cmapi.CertificateRequestStatus{}))
+}
+
+func TestCertificateRequestPatchCRDCompatibility(t *testing.T) {
+	t.Parallel()
+
+	cr := cmgen.CertificateRequest(
+		"cr1",
+		cmgen.SetCertificateRequestNamespace("ns1"),
+		cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+			Type:   cmapi.CertificateRequestConditionApproved,
+			Status: cmmeta.ConditionTrue,
+		}),
+	)
+
+	helper := &certificateRequestObjectHelper{
+		readOnlyObj:      cr,
+		crdCompatibility: &CRDCompatibility{AtomicConditions: true},
+	}
+	patchHelper := helper.NewPatch(clocktesting.NewFakeClock(randomTime()), "test", record.NewFakeRecorder(10))
+	require.True(t, patchHelper.SetInitializing())
+
+	obj, patch, err := patchHelper.Patch()
+	require.NoError(t, err)
+
+	data, err := patch.Data(obj)
+	require.NoError(t, err)
+
+	var applied cmapi.CertificateRequest
+	require.NoError(t, json.Unmarshal(data, &applied))
+
+	var conditionTypes []cmapi.CertificateRequestConditionType
+	for _, condition := range applied.Status.Conditions {
+		conditionTypes = append(conditionTypes, condition.Type)
+	}
+	assert.Equal(t, []cmapi.CertificateRequestConditionType{
+		cmapi.CertificateRequestConditionApproved,
+		cmapi.CertificateRequestConditionReady,
+	}, conditionTypes)
+}
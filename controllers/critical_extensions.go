@@ -0,0 +1,106 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"strings"
+
+	"github.com/cert-manager/cert-manager/pkg/util/pki"
+
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+// DefaultSupportedCriticalExtensions are the extensions that are supported when
+// they are marked as critical in a CSR, if the CriticalExtensionPolicy does not
+// list the supported extensions.
+var DefaultSupportedCriticalExtensions = []asn1.ObjectIdentifier{
+	{2, 5, 29, 15}, // key usage
+	{2, 5, 29, 17}, // subject alternative name
+	{2, 5, 29, 19}, // basic constraints
+	{2, 5, 29, 37}, // extended key usage
+}
+
+// CriticalExtensionPolicy enables a strict mode in which requests are failed
+// permanently when their CSR requests a critical extension that is not supported
+// by the signer. Without this policy, it is up to the Sign function to handle such
+// extensions, which often results in them being dropped silently.
+type CriticalExtensionPolicy struct {
+	// SupportedExtensions are the extensions that the signer supports when they
+	// are marked as critical. Defaults to DefaultSupportedCriticalExtensions.
+	SupportedExtensions []asn1.ObjectIdentifier
+}
+
+// check returns a PermanentError if the CSR of the request contains a critical
+// extension that is not supported. A nil policy does not check anything.
+func (p *CriticalExtensionPolicy) check(cr signer.CertificateRequestObject) error {
+	if p == nil {
+		return nil
+	}
+
+	csr, err := parseRequestCSR(cr)
+	if err != nil {
+		return err
+	}
+
+	supportedExtensions := p.SupportedExtensions
+	if len(supportedExtensions) == 0 {
+		supportedExtensions = DefaultSupportedCriticalExtensions
+	}
+
+	var unsupported []string
+	for _, extension := range csr.Extensions {
+		if extension.Critical && !containsObjectIdentifier(supportedExtensions, extension.Id) {
+			unsupported = append(unsupported, extension.Id.String())
+		}
+	}
+
+	if len(unsupported) > 0 {
+		return signer.PermanentError{
+			Err: fmt.Errorf("the CSR contains unsupported critical extensions: %s", strings.Join(unsupported, ", ")),
+		}
+	}
+
+	return nil
+}
+
+// parseRequestCSR returns the parsed CSR of the request, or a PermanentError if
+// the request or its CSR is invalid.
+func parseRequestCSR(cr signer.CertificateRequestObject) (*x509.CertificateRequest, error) {
+	_, _, csrPEM, err := cr.GetRequest()
+	if err != nil {
+		return nil, signer.PermanentError{Err: fmt.Errorf("failed to parse the request: %w", err)}
+	}
+
+	csr, err := pki.DecodeX509CertificateRequestBytes(csrPEM)
+	if err != nil {
+		return nil, signer.PermanentError{Err: fmt.Errorf("failed to parse the CSR: %w", err)}
+	}
+
+	return csr, nil
+}
+
+func containsObjectIdentifier(oids []asn1.ObjectIdentifier, oid asn1.ObjectIdentifier) bool {
+	for _, candidate := range oids {
+		if candidate.Equal(oid) {
+			return true
+		}
+	}
+	return false
+}
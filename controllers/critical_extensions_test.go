@@ -0,0 +1,121 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"testing"
+
+	cmgen "github.com/cert-manager/cert-manager/test/unit/gen"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/tests/errormatch"
+)
+
+var testCriticalExtensionID = asn1.ObjectIdentifier{1, 2, 3, 4}
+
+func testCSRWithExtensions(t *testing.T, extensions ...pkix.Extension) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:         pkix.Name{CommonName: "example.com"},
+		DNSNames:        []string{"example.com"},
+		ExtraExtensions: extensions,
+	}, key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER})
+}
+
+func TestCriticalExtensionPolicyCheck(t *testing.T) {
+	t.Parallel()
+
+	criticalExtension := pkix.Extension{Id: testCriticalExtensionID, Critical: true, Value: []byte{0x05, 0x00}}
+	nonCriticalExtension := pkix.Extension{Id: testCriticalExtensionID, Critical: false, Value: []byte{0x05, 0x00}}
+
+	type testCase struct {
+		name          string
+		policy        *CriticalExtensionPolicy
+		csr           []byte
+		validateError *errormatch.Matcher
+	}
+
+	tests := []testCase{
+		{
+			name:          "nil-policy",
+			policy:        nil,
+			csr:           testCSRWithExtensions(t, criticalExtension),
+			validateError: errormatch.NoError(),
+		},
+		{
+			name:          "default-supported-extensions",
+			policy:        &CriticalExtensionPolicy{},
+			csr:           testCSRWithExtensions(t),
+			validateError: errormatch.NoError(),
+		},
+		{
+			name:          "unsupported-critical-extension",
+			policy:        &CriticalExtensionPolicy{},
+			csr:           testCSRWithExtensions(t, criticalExtension),
+			validateError: errormatch.ErrorContains("the CSR contains unsupported critical extensions: 1.2.3.4"),
+		},
+		{
+			name:          "unsupported-non-critical-extension",
+			policy:        &CriticalExtensionPolicy{},
+			csr:           testCSRWithExtensions(t, nonCriticalExtension),
+			validateError: errormatch.NoError(),
+		},
+		{
+			name: "allowlisted-critical-extension",
+			policy: &CriticalExtensionPolicy{
+				SupportedExtensions: []asn1.ObjectIdentifier{testCriticalExtensionID},
+			},
+			csr:           testCSRWithExtensions(t, criticalExtension),
+			validateError: errormatch.NoError(),
+		},
+		{
+			name:          "invalid-csr",
+			policy:        &CriticalExtensionPolicy{},
+			csr:           []byte("invalid"),
+			validateError: errormatch.ErrorContains("failed to parse the"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cr := cmgen.CertificateRequest("cr1", cmgen.SetCertificateRequestCSR(tc.csr))
+			err := tc.policy.check(signer.CertificateRequestObjectFromCertificateRequest(cr))
+			(*tc.validateError)(t, err)
+
+			if err != nil {
+				require.ErrorAs(t, err, &signer.PermanentError{})
+			}
+		})
+	}
+}
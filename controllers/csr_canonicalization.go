@@ -0,0 +1,174 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+const eventRequestNonCanonicalCSR = "NonCanonicalCSR"
+
+// oidExtensionRequest is the OID of the PKCS#9 extension request attribute.
+var oidExtensionRequest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 14}
+
+// CSRCanonicalizationPolicy re-encodes the CSR of a request deterministically
+// before it is passed to Sign, for CAs that reject CSRs with an unusual attribute
+// ordering. A canonical CSR contains at most a single attribute, the extension
+// request, in which the extensions are sorted by OID. Other attributes (eg. a
+// challenge password) are dropped. CSRs with duplicate extensions are always
+// failed permanently, since they are rejected by the Go CSR parser.
+//
+// Re-encoding a CSR invalidates its signature, so a CSR that is not canonical is
+// only re-encoded if PrivateKey returns its private key. Otherwise, the CSR is
+// passed to Sign unchanged and a NonCanonicalCSR Warning event is recorded on
+// the request, or the request is failed permanently if RequireCanonical is set.
+type CSRCanonicalizationPolicy struct {
+	// PrivateKey is optional. It returns the private key of the CSR of the
+	// request (eg. for requests whose key is managed by the controller), or nil
+	// if the key is not available.
+	PrivateKey func(ctx context.Context, cr signer.CertificateRequestObject) (crypto.Signer, error)
+
+	// RequireCanonical fails requests permanently if their CSR is not canonical
+	// and cannot be re-encoded.
+	RequireCanonical bool
+}
+
+// apply returns the request with its CSR replaced by the canonical CSR. If the
+// CSR is not canonical and cannot be re-encoded, the request is returned
+// unchanged together with a message that explains why the CSR is not canonical.
+// A nil policy returns the request unchanged.
+func (p *CSRCanonicalizationPolicy) apply(ctx context.Context, cr signer.CertificateRequestObject) (signer.CertificateRequestObject, string, error) {
+	if p == nil {
+		return cr, "", nil
+	}
+
+	csr, err := parseRequestCSR(cr)
+	if err != nil {
+		return cr, "", err
+	}
+
+	extensions, problem, err := canonicalExtensions(csr)
+	if err != nil {
+		return cr, "", signer.PermanentError{Err: fmt.Errorf("failed to parse the attributes of the CSR: %w", err)}
+	}
+	if problem == "" {
+		return cr, "", nil
+	}
+
+	var key crypto.Signer
+	if p.PrivateKey != nil {
+		if key, err = p.PrivateKey(ctx, cr); err != nil {
+			return cr, "", fmt.Errorf("failed to get the private key of the CSR: %w", err)
+		}
+	}
+	if key == nil {
+		if p.RequireCanonical {
+			return cr, "", signer.PermanentError{Err: fmt.Errorf("the CSR is not canonical and cannot be re-signed: %s", problem)}
+		}
+		return cr, problem, nil
+	}
+
+	if publicKey, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool }); !ok || !publicKey.Equal(csr.PublicKey) {
+		return cr, "", signer.PermanentError{Err: errors.New("the private key does not match the public key of the CSR")}
+	}
+
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		RawSubject:      csr.RawSubject,
+		ExtraExtensions: extensions,
+	}, key)
+	if err != nil {
+		return cr, "", fmt.Errorf("failed to re-sign the CSR: %w", err)
+	}
+
+	return &canonicalCSRRequest{
+		CertificateRequestObject: cr,
+		csrPEM:                   pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}),
+	}, "", nil
+}
+
+// canonicalExtensions returns the extensions of the canonical CSR and a message
+// that explains why the CSR is not canonical, or an empty message if it is.
+func canonicalExtensions(csr *x509.CertificateRequest) ([]pkix.Extension, string, error) {
+	var tbs struct {
+		Raw           asn1.RawContent
+		Version       int
+		Subject       asn1.RawValue
+		PublicKey     asn1.RawValue
+		RawAttributes []asn1.RawValue `asn1:"tag:0"`
+	}
+	if rest, err := asn1.Unmarshal(csr.RawTBSCertificateRequest, &tbs); err != nil {
+		return nil, "", err
+	} else if len(rest) > 0 {
+		return nil, "", errors.New("trailing data after the certificate request info")
+	}
+
+	var problems []string
+	for i, rawAttribute := range tbs.RawAttributes {
+		var attribute struct {
+			Type   asn1.ObjectIdentifier
+			Values asn1.RawValue
+		}
+		if _, err := asn1.Unmarshal(rawAttribute.FullBytes, &attribute); err != nil {
+			return nil, "", err
+		}
+		if !attribute.Type.Equal(oidExtensionRequest) {
+			problems = append(problems, fmt.Sprintf("contains the attribute %s", attribute.Type))
+		} else if i > 0 {
+			problems = append(problems, "the extension request is not the first attribute")
+		}
+	}
+
+	extensions := slices.Clone(csr.Extensions)
+	slices.SortStableFunc(extensions, func(a, b pkix.Extension) int {
+		return slices.Compare(a.Id, b.Id)
+	})
+	if !slices.IsSortedFunc(csr.Extensions, func(a, b pkix.Extension) int {
+		return slices.Compare(a.Id, b.Id)
+	}) {
+		problems = append(problems, "the extensions are not sorted by OID")
+	}
+
+	if len(problems) == 0 {
+		return extensions, "", nil
+	}
+	return extensions, strings.Join(problems, ", "), nil
+}
+
+type canonicalCSRRequest struct {
+	signer.CertificateRequestObject
+	csrPEM []byte
+}
+
+var _ signer.CertificateRequestObject = &canonicalCSRRequest{}
+
+func (r *canonicalCSRRequest) GetRequest() (*x509.Certificate, time.Duration, []byte, error) {
+	template, duration, _, err := r.CertificateRequestObject.GetRequest()
+	return template, duration, r.csrPEM, err
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"testing"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/cert-manager/cert-manager/pkg/util/pki"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/tests/errormatch"
+)
+
+func TestCSRCanonicalizationPolicy(t *testing.T) {
+	t.Parallel()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	basicConstraints := pkix.Extension{Id: asn1.ObjectIdentifier{2, 5, 29, 19}, Value: []byte{0x30, 0x00}}
+	keyUsage := pkix.Extension{Id: asn1.ObjectIdentifier{2, 5, 29, 15}, Critical: true, Value: []byte{0x03, 0x02, 0x05, 0xa0}}
+
+	createRequest := func(template *x509.CertificateRequest) signer.CertificateRequestObject {
+		der, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+		require.NoError(t, err)
+		return signer.CertificateRequestObjectFromCertificateRequest(&cmapi.CertificateRequest{
+			Spec: cmapi.CertificateRequestSpec{
+				Request: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}),
+			},
+		})
+	}
+
+	canonical := createRequest(&x509.CertificateRequest{
+		Subject:         pkix.Name{CommonName: "example.com"},
+		ExtraExtensions: []pkix.Extension{keyUsage, basicConstraints},
+	})
+	unsorted := createRequest(&x509.CertificateRequest{
+		Subject:         pkix.Name{CommonName: "example.com"},
+		ExtraExtensions: []pkix.Extension{basicConstraints, keyUsage},
+	})
+	duplicate := createRequest(&x509.CertificateRequest{
+		Subject:         pkix.Name{CommonName: "example.com"},
+		ExtraExtensions: []pkix.Extension{keyUsage, keyUsage, basicConstraints},
+	})
+	challengePassword := createRequest(&x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: "example.com"},
+		Attributes: []pkix.AttributeTypeAndValueSET{{
+			Type:  asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 7},
+			Value: [][]pkix.AttributeTypeAndValue{{{Type: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 7}, Value: "password"}}},
+		}},
+		ExtraExtensions: []pkix.Extension{keyUsage},
+	})
+
+	privateKey := func(key crypto.Signer) func(context.Context, signer.CertificateRequestObject) (crypto.Signer, error) {
+		return func(context.Context, signer.CertificateRequestObject) (crypto.Signer, error) {
+			return key, nil
+		}
+	}
+
+	type testcase struct {
+		name               string
+		policy             *CSRCanonicalizationPolicy
+		request            signer.CertificateRequestObject
+		expectedReencoded  bool
+		expectedExtensions []pkix.Extension
+		expectedProblem    string
+		expectedError      *errormatch.Matcher
+	}
+
+	tests := []testcase{
+		{
+			name:    "nil-policy",
+			policy:  nil,
+			request: unsorted,
+		},
+		{
+			name:    "canonical",
+			policy:  &CSRCanonicalizationPolicy{PrivateKey: privateKey(key)},
+			request: canonical,
+		},
+		{
+			name:               "unsorted-re-signed",
+			policy:             &CSRCanonicalizationPolicy{PrivateKey: privateKey(key)},
+			request:            unsorted,
+			expectedReencoded:  true,
+			expectedExtensions: []pkix.Extension{keyUsage, basicConstraints},
+		},
+		{
+			name:               "attribute-dropped",
+			policy:             &CSRCanonicalizationPolicy{PrivateKey: privateKey(key)},
+			request:            challengePassword,
+			expectedReencoded:  true,
+			expectedExtensions: []pkix.Extension{keyUsage},
+		},
+		{
+			name:            "no-key-flagged",
+			policy:          &CSRCanonicalizationPolicy{},
+			request:         unsorted,
+			expectedProblem: "the extensions are not sorted by OID",
+		},
+		{
+			name:          "duplicate-extensions",
+			policy:        &CSRCanonicalizationPolicy{PrivateKey: privateKey(key)},
+			request:       duplicate,
+			expectedError: errormatch.ErrorContains("certificate request contains duplicate requested extensions"),
+		},
+		{
+			name:            "nil-key-flagged",
+			policy:          &CSRCanonicalizationPolicy{PrivateKey: privateKey(nil)},
+			request:         challengePassword,
+			expectedProblem: "contains the attribute 1.2.840.113549.1.9.7, the extension request is not the first attribute",
+		},
+		{
+			name:          "no-key-required-canonical",
+			policy:        &CSRCanonicalizationPolicy{RequireCanonical: true},
+			request:       unsorted,
+			expectedError: errormatch.ErrorContains("the CSR is not canonical and cannot be re-signed: the extensions are not sorted by OID"),
+		},
+		{
+			name:          "key-mismatch",
+			policy:        &CSRCanonicalizationPolicy{PrivateKey: privateKey(otherKey)},
+			request:       unsorted,
+			expectedError: errormatch.ErrorContains("the private key does not match the public key of the CSR"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			result, problem, err := tc.policy.apply(context.TODO(), tc.request)
+			if tc.expectedError != nil {
+				(*tc.expectedError)(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedProblem, problem)
+
+			if !tc.expectedReencoded {
+				assert.Same(t, tc.request, result)
+				return
+			}
+
+			_, _, csrPEM, err := result.GetRequest()
+			require.NoError(t, err)
+			csr, err := pki.DecodeX509CertificateRequestBytes(csrPEM)
+			require.NoError(t, err)
+			require.NoError(t, csr.CheckSignature())
+			assert.True(t, key.PublicKey.Equal(csr.PublicKey))
+			assert.Equal(t, "example.com", csr.Subject.CommonName)
+			assert.Equal(t, tc.expectedExtensions, csr.Extensions)
+
+			// The canonical CSR is canonical.
+			_, problem, err = canonicalExtensions(csr)
+			require.NoError(t, err)
+			assert.Empty(t, problem)
+		})
+	}
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/clock"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	v1alpha1 "github.com/cert-manager/issuer-lib/api/v1alpha1"
+)
+
+const (
+	csrGarbageCollectionResultDeleted = "deleted"
+	csrGarbageCollectionResultDryRun  = "dry_run"
+)
+
+// CSRGarbageCollection configures the deletion of finished Kubernetes
+// CertificateSigningRequests, see CertificateSigningRequestGCReconciler.
+type CSRGarbageCollection struct {
+	// TTL is the duration for which a CertificateSigningRequest is kept after it
+	// was issued, failed or denied.
+	TTL time.Duration
+
+	// DryRun only logs and counts the CertificateSigningRequests that would be
+	// deleted, without deleting them.
+	DryRun bool
+}
+
+// CertificateSigningRequestGCReconciler deletes the Kubernetes CertificateSigningRequests
+// with the signer name of one of the cluster issuer types, once they were issued,
+// failed or denied for longer than the TTL. CertificateSigningRequests are
+// cluster-scoped and are not owned by another resource, so they pile up otherwise.
+// The time at which a CertificateSigningRequest finished is the last transition
+// or update of its conditions.
+//
+// The deleted CertificateSigningRequests are counted in the
+// issuer_lib_csr_garbage_collected_total metric. This requires delete permissions
+// on the CertificateSigningRequests.
+type CertificateSigningRequestGCReconciler struct {
+	ClusterIssuerTypes []v1alpha1.Issuer
+	// SignerNamePatterns are the signer name patterns of the Kubernetes CSR
+	// controller, the CSRs that match one of them are deleted as well.
+	SignerNamePatterns []string
+
+	CSRGarbageCollection
+
+	// Client is a controller-runtime client used to get and delete K8S API resources.
+	client.Client
+
+	// Clock is used to mock the current time in tests.
+	Clock clock.PassiveClock
+}
+
+func (r *CertificateSigningRequestGCReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithName("Reconcile")
+
+	logger.V(2).Info("Starting reconcile loop", "name", req.Name)
+
+	var csr certificatesv1.CertificateSigningRequest
+	if err := r.Client.Get(ctx, req.NamespacedName, &csr); err != nil && apierrors.IsNotFound(err) {
+		logger.V(1).Info("CertificateSigningRequest not found. Ignoring.")
+		return ctrl.Result{}, nil // done
+	} else if err != nil {
+		return ctrl.Result{}, fmt.Errorf("unexpected get error: %v", err) // requeue with backoff
+	}
+
+	if !r.matchesSigner(&csr) {
+		return ctrl.Result{}, nil // done
+	}
+
+	finishedAt, finished := csrFinishedAt(&csr)
+	if !finished {
+		logger.V(2).Info("CertificateSigningRequest is not finished yet. Ignoring.")
+		return ctrl.Result{}, nil // done, reconciled again when the status changes
+	}
+
+	if remaining := finishedAt.Add(r.TTL).Sub(r.Clock.Now()); remaining > 0 {
+		return ctrl.Result{RequeueAfter: remaining}, nil // requeue after the TTL
+	}
+
+	if r.DryRun {
+		logger.Info("Would delete finished CertificateSigningRequest (dry run).", "finished at", finishedAt)
+		csrGarbageCollected.WithLabelValues(csrGarbageCollectionResultDryRun).Inc()
+		return ctrl.Result{}, nil // done
+	}
+
+	logger.V(1).Info("Deleting finished CertificateSigningRequest.", "finished at", finishedAt)
+	if err := r.Client.Delete(ctx, &csr, client.Preconditions{UID: &csr.UID}); err != nil {
+		if apierrors.IsNotFound(err) || apierrors.IsConflict(err) {
+			return ctrl.Result{}, nil // done, already deleted or replaced
+		}
+		return ctrl.Result{}, fmt.Errorf("failed to delete CertificateSigningRequest: %v", err) // requeue with backoff
+	}
+	csrGarbageCollected.WithLabelValues(csrGarbageCollectionResultDeleted).Inc()
+
+	return ctrl.Result{}, nil // done
+}
+
+// matchesSigner returns true if the signer name of the CertificateSigningRequest
+// references one of the cluster issuer types or matches one of the signer name
+// patterns.
+func (r *CertificateSigningRequestGCReconciler) matchesSigner(csr *certificatesv1.CertificateSigningRequest) bool {
+	if matchesSignerNamePattern(r.SignerNamePatterns, csr.Spec.SignerName) {
+		return true
+	}
+
+	issuerTypeIdentifier, _, ok := strings.Cut(csr.Spec.SignerName, "/")
+	if !ok {
+		return false
+	}
+
+	for _, issuerType := range r.ClusterIssuerTypes {
+		if issuerType.GetIssuerTypeIdentifier() == issuerTypeIdentifier {
+			return true
+		}
+	}
+	return false
+}
+
+// csrFinishedAt returns the time at which the CertificateSigningRequest was
+// issued, failed or denied, and false if it did not finish yet.
+func csrFinishedAt(csr *certificatesv1.CertificateSigningRequest) (time.Time, bool) {
+	finished := len(csr.Status.Certificate) > 0
+	finishedAt := csr.CreationTimestamp.Time
+	for _, condition := range csr.Status.Conditions {
+		if (condition.Type == certificatesv1.CertificateFailed || condition.Type == certificatesv1.CertificateDenied) &&
+			condition.Status == corev1.ConditionTrue {
+			finished = true
+		}
+
+		if condition.LastTransitionTime.After(finishedAt) {
+			finishedAt = condition.LastTransitionTime.Time
+		}
+		if condition.LastUpdateTime.After(finishedAt) {
+			finishedAt = condition.LastUpdateTime.Time
+		}
+	}
+
+	return finishedAt, finished
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *CertificateSigningRequestGCReconciler) SetupWithManager(_ context.Context, mgr ctrl.Manager) error {
+	if r.Clock == nil {
+		r.Clock = clock.RealClock{}
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("certificatesigningrequest-gc").
+		For(
+			&certificatesv1.CertificateSigningRequest{},
+			builder.WithPredicates(
+				predicate.ResourceVersionChangedPredicate{},
+				predicate.NewPredicateFuncs(func(obj client.Object) bool {
+					csr, ok := obj.(*certificatesv1.CertificateSigningRequest)
+					return ok && r.matchesSigner(csr)
+				}),
+			),
+		).
+		Complete(r)
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	prometheustestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+)
+
+func TestCertificateSigningRequestGCReconcilerReconcile(t *testing.T) {
+	t.Parallel()
+
+	now := randomTime().Truncate(time.Second)
+	fakeClock := clocktesting.NewFakeClock(now)
+	signerName := "testclusterissuers.testing.cert-manager.io/issuer-1"
+
+	csr := func(name string, signerName string, certificate []byte, conditions ...certificatesv1.CertificateSigningRequestCondition) *certificatesv1.CertificateSigningRequest {
+		return &certificatesv1.CertificateSigningRequest{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				UID:               types.UID(name),
+				CreationTimestamp: metav1.NewTime(now.Add(-48 * time.Hour)),
+			},
+			Spec: certificatesv1.CertificateSigningRequestSpec{
+				SignerName: signerName,
+			},
+			Status: certificatesv1.CertificateSigningRequestStatus{
+				Certificate: certificate,
+				Conditions:  conditions,
+			},
+		}
+	}
+	condition := func(conditionType certificatesv1.RequestConditionType, at time.Time) certificatesv1.CertificateSigningRequestCondition {
+		return certificatesv1.CertificateSigningRequestCondition{
+			Type:               conditionType,
+			Status:             corev1.ConditionTrue,
+			LastTransitionTime: metav1.NewTime(at),
+			LastUpdateTime:     metav1.NewTime(at),
+		}
+	}
+
+	type testCase struct {
+		name           string
+		csr            *certificatesv1.CertificateSigningRequest
+		dryRun         bool
+		expectedResult reconcile.Result
+		expectDeleted  bool
+	}
+
+	tests := []testCase{
+		{
+			name:          "issued-past-ttl",
+			csr:           csr("issued-past-ttl", signerName, []byte("cert"), condition(certificatesv1.CertificateApproved, now.Add(-25*time.Hour))),
+			expectDeleted: true,
+		},
+		{
+			name:           "issued-within-ttl",
+			csr:            csr("issued-within-ttl", signerName, []byte("cert"), condition(certificatesv1.CertificateApproved, now.Add(-23*time.Hour))),
+			expectedResult: reconcile.Result{RequeueAfter: time.Hour},
+		},
+		{
+			name: "failed-past-ttl",
+			csr: csr("failed-past-ttl", signerName, nil,
+				condition(certificatesv1.CertificateApproved, now.Add(-30*time.Hour)),
+				condition(certificatesv1.CertificateFailed, now.Add(-25*time.Hour)),
+			),
+			expectDeleted: true,
+		},
+		{
+			name:          "denied-past-ttl",
+			csr:           csr("denied-past-ttl", signerName, nil, condition(certificatesv1.CertificateDenied, now.Add(-25*time.Hour))),
+			expectDeleted: true,
+		},
+		{
+			name:   "denied-past-ttl-dry-run",
+			csr:    csr("denied-past-ttl-dry-run", signerName, nil, condition(certificatesv1.CertificateDenied, now.Add(-25*time.Hour))),
+			dryRun: true,
+		},
+		{
+			name: "pending",
+			csr:  csr("pending", signerName, nil, condition(certificatesv1.CertificateApproved, now.Add(-25*time.Hour))),
+		},
+		{
+			name: "foreign-signer",
+			csr:  csr("foreign-signer", "example.com/issuer-1", []byte("cert"), condition(certificatesv1.CertificateApproved, now.Add(-25*time.Hour))),
+		},
+		{
+			name:          "signer-name-pattern",
+			csr:           csr("signer-name-pattern", "tenants.example.com/team-a", []byte("cert"), condition(certificatesv1.CertificateApproved, now.Add(-25*time.Hour))),
+			expectDeleted: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			scheme := runtime.NewScheme()
+			require.NoError(t, certificatesv1.AddToScheme(scheme))
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(tc.csr).
+				Build()
+
+			controller := &CertificateSigningRequestGCReconciler{
+				ClusterIssuerTypes: []v1alpha1.Issuer{&api.TestClusterIssuer{}},
+				SignerNamePatterns: []string{"tenants.example.com/*"},
+				CSRGarbageCollection: CSRGarbageCollection{
+					TTL:    24 * time.Hour,
+					DryRun: tc.dryRun,
+				},
+				Client: fakeClient,
+				Clock:  fakeClock,
+			}
+
+			dryRunsBefore := prometheustestutil.ToFloat64(csrGarbageCollected.WithLabelValues(csrGarbageCollectionResultDryRun))
+
+			res, err := controller.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Name: tc.csr.Name}})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedResult, res)
+
+			err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: tc.csr.Name}, &certificatesv1.CertificateSigningRequest{})
+			if tc.expectDeleted {
+				assert.True(t, apierrors.IsNotFound(err), "expected the CertificateSigningRequest to be deleted")
+			} else {
+				assert.NoError(t, err)
+			}
+
+			// Only the dry-run test case counts dry runs, so the counter is
+			// not changed concurrently.
+			if tc.dryRun {
+				assert.Equal(t, dryRunsBefore+1, prometheustestutil.ToFloat64(csrGarbageCollected.WithLabelValues(csrGarbageCollectionResultDryRun)))
+			}
+		})
+	}
+}
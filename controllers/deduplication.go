@@ -0,0 +1,260 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/cert-manager/cert-manager/pkg/util/pki"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+// DeduplicationPolicy skips calling Sign for a CertificateRequest if the Secret
+// of the Certificate that owns the request already contains a certificate that
+// was issued by the same issuer for the same public key, subject, subject
+// alternative names, key usages, isCA and duration as requested, and that is not
+// yet due for renewal. The certificate chain must verify against the CA bundle
+// of the issuer. The request is marked as Issued with the existing certificate
+// chain instead. This prevents unnecessary load on the CA, eg. when requests are
+// re-created while the controllers restart.
+//
+// Kubernetes CertificateSigningRequests are not owned by a Certificate and are
+// never deduplicated.
+type DeduplicationPolicy struct {
+	// Reader is used to read the Certificates and Secrets, it defaults to the
+	// Client of the controller. Reading Secrets using the manager's client starts
+	// an informer that caches all Secrets in the cluster, consider using the
+	// manager's APIReader instead.
+	Reader client.Reader
+
+	// CABundle returns the PEM encoded CA bundle of the issuer. An existing
+	// certificate is only re-used if its chain verifies against the CA bundle,
+	// since anyone who can write the Secret can set its issuer annotations.
+	// Certificates are never re-used if CABundle is not set.
+	CABundle func(ctx context.Context, issuerObject v1alpha1.Issuer) ([]byte, error)
+}
+
+// deduplicationLifetimeTolerance is the maximum difference between the lifetime
+// of an existing certificate and the requested duration, to allow for issuers
+// that backdate the certificates they sign.
+const deduplicationLifetimeTolerance = 5 * time.Minute
+
+// existingCertificate returns the certificate chain from the Secret of the
+// Certificate that owns the request, if that certificate can be re-used for the
+// request. A nil policy never returns a certificate.
+func (p *DeduplicationPolicy) existingCertificate(
+	ctx context.Context,
+	cl client.Reader,
+	cr signer.CertificateRequestObject,
+	requestObject client.Object,
+	issuerObject v1alpha1.Issuer,
+	now time.Time,
+) (signer.PEMBundle, bool, error) {
+	if p == nil || p.CABundle == nil {
+		return signer.PEMBundle{}, false, nil
+	}
+
+	certificateRequest, ok := requestObject.(*cmapi.CertificateRequest)
+	if !ok {
+		return signer.PEMBundle{}, false, nil
+	}
+
+	certificateName := owningCertificateName(certificateRequest)
+	if certificateName == "" {
+		return signer.PEMBundle{}, false, nil
+	}
+
+	if p.Reader != nil {
+		cl = p.Reader
+	}
+
+	certificate := &cmapi.Certificate{}
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: certificateRequest.Namespace, Name: certificateName}, certificate); apierrors.IsNotFound(err) {
+		return signer.PEMBundle{}, false, nil
+	} else if err != nil {
+		return signer.PEMBundle{}, false, fmt.Errorf("failed to get the Certificate: %w", err)
+	}
+
+	secret := &corev1.Secret{}
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: certificateRequest.Namespace, Name: certificate.Spec.SecretName}, secret); apierrors.IsNotFound(err) {
+		return signer.PEMBundle{}, false, nil
+	} else if err != nil {
+		return signer.PEMBundle{}, false, fmt.Errorf("failed to get the Secret of the Certificate: %w", err)
+	}
+
+	// Only re-use certificates that were issued by the issuer of the request.
+	issuerRef := certificateRequest.Spec.IssuerRef
+	if secret.Annotations[cmapi.IssuerNameAnnotationKey] != issuerRef.Name ||
+		secret.Annotations[cmapi.IssuerKindAnnotationKey] != issuerRef.Kind ||
+		secret.Annotations[cmapi.IssuerGroupAnnotationKey] != issuerRef.Group {
+		return signer.PEMBundle{}, false, nil
+	}
+
+	chainPEM := secret.Data[corev1.TLSCertKey]
+	if len(chainPEM) == 0 {
+		return signer.PEMBundle{}, false, nil
+	}
+
+	certs, err := pki.DecodeX509CertificateChainBytes(chainPEM)
+	if err != nil || len(certs) == 0 {
+		return signer.PEMBundle{}, false, nil // the certificate is re-issued
+	}
+	leaf := certs[0]
+
+	if now.Before(leaf.NotBefore) || !now.Before(renewalTime(certificate, leaf)) {
+		return signer.PEMBundle{}, false, nil
+	}
+
+	csr, err := parseRequestCSR(cr)
+	if err != nil {
+		return signer.PEMBundle{}, false, nil // the request fails when calling Sign
+	}
+
+	if !certificateMatchesRequest(leaf, csr, certificateRequest) {
+		return signer.PEMBundle{}, false, nil
+	}
+
+	caPEM, err := p.CABundle(ctx, issuerObject)
+	if err != nil {
+		return signer.PEMBundle{}, false, fmt.Errorf("failed to get the CA bundle of the issuer: %w", err)
+	}
+
+	if !chainVerifies(certs, caPEM, now) {
+		return signer.PEMBundle{}, false, nil
+	}
+
+	return signer.PEMBundle{
+		ChainPEM: chainPEM,
+		CAPEM:    secret.Data[cmmeta.TLSCAKey],
+	}, true, nil
+}
+
+// owningCertificateName returns the name of the Certificate that created the
+// request, or an empty string if the request was not created by a Certificate.
+func owningCertificateName(cr *cmapi.CertificateRequest) string {
+	if name := cr.Annotations[cmapi.CertificateNameKey]; name != "" {
+		return name
+	}
+
+	for _, ownerReference := range cr.OwnerReferences {
+		if ownerReference.Kind == cmapi.CertificateKind && ownerReference.APIVersion == cmapi.SchemeGroupVersion.String() {
+			return ownerReference.Name
+		}
+	}
+
+	return ""
+}
+
+// renewalTime returns the time at which the certificate is due for renewal. If
+// the Certificate does not report a renewal time, cert-manager's default of two
+// thirds of the certificate's lifetime is used.
+func renewalTime(certificate *cmapi.Certificate, leaf *x509.Certificate) time.Time {
+	if certificate.Status.RenewalTime != nil {
+		return certificate.Status.RenewalTime.Time
+	}
+
+	lifetime := leaf.NotAfter.Sub(leaf.NotBefore)
+	return leaf.NotBefore.Add(lifetime * 2 / 3)
+}
+
+// chainVerifies returns true if the certificate chain verifies against the CA
+// bundle at the given time. The certificates after the leaf are used as
+// intermediates.
+func chainVerifies(certs []*x509.Certificate, caPEM []byte, now time.Time) bool {
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caPEM) {
+		return false
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	return err == nil
+}
+
+// certificateMatchesRequest returns true if the certificate matches the CSR and
+// has the key usages, isCA and duration of the request spec.
+func certificateMatchesRequest(cert *x509.Certificate, csr *x509.CertificateRequest, cr *cmapi.CertificateRequest) bool {
+	if !certificateMatchesCSR(cert, csr) || cert.IsCA != cr.Spec.IsCA {
+		return false
+	}
+
+	keyUsage, extKeyUsages, err := pki.KeyUsagesForCertificateOrCertificateRequest(cr.Spec.Usages, cr.Spec.IsCA)
+	if err != nil || cert.KeyUsage != keyUsage || !sets.New(cert.ExtKeyUsage...).Equal(sets.New(extKeyUsages...)) {
+		return false
+	}
+
+	duration := cmapi.DefaultCertificateDuration
+	if cr.Spec.Duration != nil {
+		duration = cr.Spec.Duration.Duration
+	}
+	lifetime := cert.NotAfter.Sub(cert.NotBefore)
+	return lifetime >= duration-deduplicationLifetimeTolerance && lifetime <= duration+deduplicationLifetimeTolerance
+}
+
+// certificateMatchesCSR returns true if the certificate contains the public key,
+// subject and subject alternative names of the CSR.
+func certificateMatchesCSR(cert *x509.Certificate, csr *x509.CertificateRequest) bool {
+	publicKey, ok := cert.PublicKey.(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !publicKey.Equal(csr.PublicKey) {
+		return false
+	}
+
+	return cert.Subject.String() == csr.Subject.String() &&
+		sets.New(cert.DNSNames...).Equal(sets.New(csr.DNSNames...)) &&
+		sets.New(cert.EmailAddresses...).Equal(sets.New(csr.EmailAddresses...)) &&
+		sets.New(ipStrings(cert.IPAddresses)...).Equal(sets.New(ipStrings(csr.IPAddresses)...)) &&
+		sets.New(uriStrings(cert.URIs)...).Equal(sets.New(uriStrings(csr.URIs)...))
+}
+
+func ipStrings(ips []net.IP) []string {
+	values := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		values = append(values, ip.String())
+	}
+	return values
+}
+
+func uriStrings(uris []*url.URL) []string {
+	values := make([]string, 0, len(uris))
+	for _, uri := range uris {
+		values = append(values, uri.String())
+	}
+	return values
+}
@@ -0,0 +1,384 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	cmgen "github.com/cert-manager/cert-manager/test/unit/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+)
+
+func testCSRAndCertificate(t *testing.T, key crypto.Signer, dnsNames []string, notBefore time.Time, lifetime time.Duration) ([]byte, []byte) {
+	t.Helper()
+
+	template := &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: dnsNames[0]},
+		DNSNames: dnsNames,
+	}
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+	require.NoError(t, err)
+
+	certDER, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      template.Subject,
+		DNSNames:     dnsNames,
+		NotBefore:    notBefore,
+		NotAfter:     notBefore.Add(lifetime),
+	}, &x509.Certificate{}, key.Public(), key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+}
+
+// testCA returns the key and the PEM encoded certificate of a self-signed CA.
+func testCA(t *testing.T, now time.Time) (crypto.Signer, *x509.Certificate, []byte) {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	caDER, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		NotBefore:             now.Add(-24 * time.Hour),
+		NotAfter:              now.Add(365 * 24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, &x509.Certificate{Subject: pkix.Name{CommonName: "ca"}}, caKey.Public(), caKey)
+	require.NoError(t, err)
+
+	caCert, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	return caKey, caCert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})
+}
+
+// testSignedCertificate returns a PEM encoded certificate for the key that is
+// signed by the CA and matches the default CertificateRequest spec, the modify
+// function can change the template of the certificate.
+func testSignedCertificate(
+	t *testing.T,
+	caKey crypto.Signer,
+	caCert *x509.Certificate,
+	key crypto.Signer,
+	notBefore time.Time,
+	modify func(*x509.Certificate),
+) []byte {
+	t.Helper()
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		DNSNames:     []string{"example.com"},
+		NotBefore:    notBefore,
+		NotAfter:     notBefore.Add(90 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+	}
+	if modify != nil {
+		modify(template)
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, template, caCert, key.Public(), caKey)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+}
+
+func TestDeduplicationPolicyExistingCertificate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now().Truncate(time.Second)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	caKey, caCert, caPEM := testCA(t, now)
+	otherCAKey, otherCACert, _ := testCA(t, now)
+
+	csrPEM, _ := testCSRAndCertificate(t, key, []string{"example.com"}, now.Add(-time.Hour), 90*time.Hour)
+	certPEM := testSignedCertificate(t, caKey, caCert, key, now.Add(-time.Hour), nil)
+	otherKeyCertPEM := testSignedCertificate(t, caKey, caCert, otherKey, now.Add(-time.Hour), nil)
+	otherSANsCertPEM := testSignedCertificate(t, caKey, caCert, key, now.Add(-time.Hour), func(cert *x509.Certificate) {
+		cert.DNSNames = []string{"example.com", "www.example.com"}
+	})
+	otherOrganizationCertPEM := testSignedCertificate(t, caKey, caCert, key, now.Add(-time.Hour), func(cert *x509.Certificate) {
+		cert.Subject.Organization = []string{"other"}
+	})
+	otherUsagesCertPEM := testSignedCertificate(t, caKey, caCert, key, now.Add(-time.Hour), func(cert *x509.Certificate) {
+		cert.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	})
+	caCertPEM := testSignedCertificate(t, caKey, caCert, key, now.Add(-time.Hour), func(cert *x509.Certificate) {
+		cert.IsCA = true
+		cert.BasicConstraintsValid = true
+		cert.KeyUsage |= x509.KeyUsageCertSign
+	})
+	otherDurationCertPEM := testSignedCertificate(t, caKey, caCert, key, now.Add(-time.Hour), func(cert *x509.Certificate) {
+		cert.NotAfter = cert.NotBefore.Add(900 * time.Hour)
+	})
+	otherCACertPEM := testSignedCertificate(t, otherCAKey, otherCACert, key, now.Add(-time.Hour), nil)
+	dueCertPEM := testSignedCertificate(t, caKey, caCert, key, now.Add(-80*time.Hour), nil)
+
+	issuerRef := cmmeta.ObjectReference{Name: "issuer-1", Kind: "TestIssuer", Group: "testing.cert-manager.io"}
+
+	certificate := &cmapi.Certificate{
+		ObjectMeta: metav1.ObjectMeta{Name: "cert-1", Namespace: "ns1"},
+		Spec:       cmapi.CertificateSpec{SecretName: "secret-1", IssuerRef: issuerRef},
+	}
+
+	secret := func(certPEM []byte, issuerName string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "secret-1",
+				Namespace: "ns1",
+				Annotations: map[string]string{
+					cmapi.IssuerNameAnnotationKey:  issuerName,
+					cmapi.IssuerKindAnnotationKey:  issuerRef.Kind,
+					cmapi.IssuerGroupAnnotationKey: issuerRef.Group,
+				},
+			},
+			Data: map[string][]byte{
+				corev1.TLSCertKey: certPEM,
+				cmmeta.TLSCAKey:   []byte("ca"),
+			},
+		}
+	}
+
+	cr := cmgen.CertificateRequest("cr-1",
+		cmgen.SetCertificateRequestNamespace("ns1"),
+		cmgen.SetCertificateRequestCSR(csrPEM),
+		cmgen.SetCertificateRequestIssuer(issuerRef),
+		cmgen.SetCertificateRequestDuration(&metav1.Duration{Duration: 90 * time.Hour}),
+		cmgen.SetCertificateRequestAnnotations(map[string]string{
+			cmapi.CertificateNameKey: "cert-1",
+		}),
+	)
+
+	policy := &DeduplicationPolicy{
+		CABundle: func(_ context.Context, _ v1alpha1.Issuer) ([]byte, error) {
+			return caPEM, nil
+		},
+	}
+
+	type testCase struct {
+		name          string
+		policy        *DeduplicationPolicy
+		cr            *cmapi.CertificateRequest
+		objects       []client.Object
+		expectedFound bool
+		expectedErr   string
+	}
+
+	tests := []testCase{
+		{
+			name:          "nil-policy",
+			policy:        nil,
+			cr:            cr,
+			objects:       []client.Object{certificate, secret(certPEM, "issuer-1")},
+			expectedFound: false,
+		},
+		{
+			name:          "matching-certificate",
+			policy:        policy,
+			cr:            cr,
+			objects:       []client.Object{certificate, secret(certPEM, "issuer-1")},
+			expectedFound: true,
+		},
+		{
+			name:          "policy-without-ca-bundle",
+			policy:        &DeduplicationPolicy{},
+			cr:            cr,
+			objects:       []client.Object{certificate, secret(certPEM, "issuer-1")},
+			expectedFound: false,
+		},
+		{
+			name: "ca-bundle-error",
+			policy: &DeduplicationPolicy{
+				CABundle: func(_ context.Context, _ v1alpha1.Issuer) ([]byte, error) {
+					return nil, errors.New("ca unavailable")
+				},
+			},
+			cr:          cr,
+			objects:     []client.Object{certificate, secret(certPEM, "issuer-1")},
+			expectedErr: "failed to get the CA bundle of the issuer: ca unavailable",
+		},
+		{
+			name:          "not-signed-by-the-issuer-ca",
+			policy:        policy,
+			cr:            cr,
+			objects:       []client.Object{certificate, secret(otherCACertPEM, "issuer-1")},
+			expectedFound: false,
+		},
+		{
+			name:   "request-not-owned-by-certificate",
+			policy: policy,
+			cr: cmgen.CertificateRequest("cr-1",
+				cmgen.SetCertificateRequestNamespace("ns1"),
+				cmgen.SetCertificateRequestCSR(csrPEM),
+				cmgen.SetCertificateRequestIssuer(issuerRef),
+			),
+			objects:       []client.Object{certificate, secret(certPEM, "issuer-1")},
+			expectedFound: false,
+		},
+		{
+			name:          "missing-certificate",
+			policy:        policy,
+			cr:            cr,
+			objects:       []client.Object{secret(certPEM, "issuer-1")},
+			expectedFound: false,
+		},
+		{
+			name:          "missing-secret",
+			policy:        policy,
+			cr:            cr,
+			objects:       []client.Object{certificate},
+			expectedFound: false,
+		},
+		{
+			name:          "different-issuer",
+			policy:        policy,
+			cr:            cr,
+			objects:       []client.Object{certificate, secret(certPEM, "issuer-2")},
+			expectedFound: false,
+		},
+		{
+			name:          "different-public-key",
+			policy:        policy,
+			cr:            cr,
+			objects:       []client.Object{certificate, secret(otherKeyCertPEM, "issuer-1")},
+			expectedFound: false,
+		},
+		{
+			name:          "different-subject-alternative-names",
+			policy:        policy,
+			cr:            cr,
+			objects:       []client.Object{certificate, secret(otherSANsCertPEM, "issuer-1")},
+			expectedFound: false,
+		},
+		{
+			name:          "different-organization",
+			policy:        policy,
+			cr:            cr,
+			objects:       []client.Object{certificate, secret(otherOrganizationCertPEM, "issuer-1")},
+			expectedFound: false,
+		},
+		{
+			name:          "different-usages",
+			policy:        policy,
+			cr:            cr,
+			objects:       []client.Object{certificate, secret(otherUsagesCertPEM, "issuer-1")},
+			expectedFound: false,
+		},
+		{
+			name:          "different-is-ca",
+			policy:        policy,
+			cr:            cr,
+			objects:       []client.Object{certificate, secret(caCertPEM, "issuer-1")},
+			expectedFound: false,
+		},
+		{
+			name:          "different-duration",
+			policy:        policy,
+			cr:            cr,
+			objects:       []client.Object{certificate, secret(otherDurationCertPEM, "issuer-1")},
+			expectedFound: false,
+		},
+		{
+			name:          "due-for-renewal",
+			policy:        policy,
+			cr:            cr,
+			objects:       []client.Object{certificate, secret(dueCertPEM, "issuer-1")},
+			expectedFound: false,
+		},
+		{
+			name:   "renewal-time-of-certificate-passed",
+			policy: policy,
+			cr:     cr,
+			objects: []client.Object{
+				&cmapi.Certificate{
+					ObjectMeta: certificate.ObjectMeta,
+					Spec:       certificate.Spec,
+					Status: cmapi.CertificateStatus{
+						RenewalTime: &metav1.Time{Time: now.Add(-time.Minute)},
+					},
+				},
+				secret(certPEM, "issuer-1"),
+			},
+			expectedFound: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			scheme := runtime.NewScheme()
+			require.NoError(t, cmapi.AddToScheme(scheme))
+			require.NoError(t, corev1.AddToScheme(scheme))
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(tc.objects...).
+				Build()
+
+			bundle, found, err := tc.policy.existingCertificate(
+				context.TODO(),
+				fakeClient,
+				signer.CertificateRequestObjectFromCertificateRequest(tc.cr),
+				tc.cr,
+				&api.TestIssuer{},
+				now,
+			)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedFound, found)
+
+			if tc.expectedFound {
+				assert.Equal(t, signer.PEMBundle{ChainPEM: certPEM, CAPEM: []byte("ca")}, bundle)
+			}
+		})
+	}
+}
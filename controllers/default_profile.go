@@ -0,0 +1,105 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"crypto/x509"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	experimentalapi "github.com/cert-manager/cert-manager/pkg/apis/experimental/v1alpha1"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+// applyDefaultProfile returns the request with the default profile of the issuer
+// applied to the fields that the request leaves empty, for issuer types that
+// implement the signer.DefaultProfileProvider interface. The DurationPolicy is
+// applied after the default profile, so it also limits the default duration.
+func applyDefaultProfile(
+	requestObject client.Object,
+	cr signer.CertificateRequestObject,
+	issuerObject v1alpha1.Issuer,
+) signer.CertificateRequestObject {
+	provider, ok := issuerObject.(signer.DefaultProfileProvider)
+	if !ok {
+		return cr
+	}
+
+	durationSet, usagesSet := requestedFields(requestObject)
+
+	return &defaultProfileRequest{
+		CertificateRequestObject: cr,
+		profile:                  provider.DefaultProfile(),
+		durationSet:              durationSet,
+		usagesSet:                usagesSet,
+	}
+}
+
+// requestedFields returns whether the request explicitly requests a duration
+// and usages.
+func requestedFields(requestObject client.Object) (durationSet bool, usagesSet bool) {
+	switch request := requestObject.(type) {
+	case *cmapi.CertificateRequest:
+		return request.Spec.Duration != nil, len(request.Spec.Usages) > 0
+	case *certificatesv1.CertificateSigningRequest:
+		_, annotationSet := request.Annotations[experimentalapi.CertificateSigningRequestDurationAnnotationKey]
+		return annotationSet || request.Spec.ExpirationSeconds != nil, len(request.Spec.Usages) > 0
+	default:
+		// Unknown request types are never defaulted.
+		return true, true
+	}
+}
+
+type defaultProfileRequest struct {
+	signer.CertificateRequestObject
+	profile     signer.DefaultProfile
+	durationSet bool
+	usagesSet   bool
+}
+
+var _ signer.CertificateRequestObject = &defaultProfileRequest{}
+
+func (r *defaultProfileRequest) GetRequest() (*x509.Certificate, time.Duration, []byte, error) {
+	template, duration, csr, err := r.CertificateRequestObject.GetRequest()
+	if err != nil {
+		return template, duration, csr, err
+	}
+
+	if !r.durationSet && r.profile.Duration > 0 {
+		duration = r.profile.Duration
+		template.NotAfter = template.NotBefore.Add(duration)
+	}
+
+	if !r.usagesSet && (r.profile.KeyUsage != 0 || len(r.profile.ExtKeyUsage) > 0) {
+		// CA certificates keep the cert sign usage that was derived from the
+		// isCA field of the request.
+		template.KeyUsage = r.profile.KeyUsage | (template.KeyUsage & x509.KeyUsageCertSign)
+		template.ExtKeyUsage = append([]x509.ExtKeyUsage(nil), r.profile.ExtKeyUsage...)
+	}
+
+	hasSANs := len(template.DNSNames) > 0 || len(template.IPAddresses) > 0 ||
+		len(template.URIs) > 0 || len(template.EmailAddresses) > 0
+	if r.profile.CommonNameAsDNSName && !hasSANs && template.Subject.CommonName != "" {
+		template.DNSNames = []string{template.Subject.CommonName}
+	}
+
+	return template, duration, csr, nil
+}
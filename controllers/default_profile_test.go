@@ -0,0 +1,186 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmgen "github.com/cert-manager/cert-manager/test/unit/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+)
+
+// defaultProfileIssuer is a TestIssuer that declares a default profile.
+type defaultProfileIssuer struct {
+	*api.TestIssuer
+	profile signer.DefaultProfile
+}
+
+var _ signer.DefaultProfileProvider = defaultProfileIssuer{}
+
+func (i defaultProfileIssuer) DefaultProfile() signer.DefaultProfile {
+	return i.profile
+}
+
+func TestApplyDefaultProfile(t *testing.T) {
+	t.Parallel()
+
+	commonNameCSR := testCSR(t, nil, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: "example.com"},
+	})
+	sanCSR := testCSR(t, nil, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: "example.com"},
+		DNSNames: []string{"www.example.com"},
+	})
+
+	profile := signer.DefaultProfile{
+		Duration:            24 * time.Hour,
+		KeyUsage:            x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:         []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		CommonNameAsDNSName: true,
+	}
+	profileIssuer := defaultProfileIssuer{TestIssuer: &api.TestIssuer{}, profile: profile}
+
+	type testCase struct {
+		name           string
+		issuer         v1alpha1.Issuer
+		request        client.Object
+		durationPolicy *DurationPolicy
+
+		expectedDuration    time.Duration
+		expectedKeyUsage    x509.KeyUsage
+		expectedExtKeyUsage []x509.ExtKeyUsage
+		expectedDNSNames    []string
+	}
+
+	tests := []testCase{
+		{
+			name:                "issuer-without-default-profile",
+			issuer:              &api.TestIssuer{},
+			request:             cmgen.CertificateRequest("cr1", cmgen.SetCertificateRequestCSR(commonNameCSR)),
+			expectedDuration:    cmapi.DefaultCertificateDuration,
+			expectedKeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+			expectedExtKeyUsage: nil,
+		},
+		{
+			name:                "defaults-applied-to-empty-fields",
+			issuer:              profileIssuer,
+			request:             cmgen.CertificateRequest("cr1", cmgen.SetCertificateRequestCSR(commonNameCSR)),
+			expectedDuration:    24 * time.Hour,
+			expectedKeyUsage:    x509.KeyUsageDigitalSignature,
+			expectedExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			expectedDNSNames:    []string{"example.com"},
+		},
+		{
+			name:   "request-fields-take-precedence",
+			issuer: profileIssuer,
+			request: cmgen.CertificateRequest("cr1",
+				cmgen.SetCertificateRequestCSR(sanCSR),
+				cmgen.SetCertificateRequestDuration(&metav1.Duration{Duration: time.Hour}),
+				cmgen.SetCertificateRequestKeyUsages(cmapi.UsageKeyEncipherment, cmapi.UsageClientAuth),
+			),
+			expectedDuration:    time.Hour,
+			expectedKeyUsage:    x509.KeyUsageKeyEncipherment,
+			expectedExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+			expectedDNSNames:    []string{"www.example.com"},
+		},
+		{
+			name:   "ca-keeps-cert-sign-usage",
+			issuer: profileIssuer,
+			request: cmgen.CertificateRequest("cr1",
+				cmgen.SetCertificateRequestCSR(sanCSR),
+				cmgen.SetCertificateRequestIsCA(true),
+			),
+			expectedDuration:    24 * time.Hour,
+			expectedKeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+			expectedExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			expectedDNSNames:    []string{"www.example.com"},
+		},
+		{
+			name:                "duration-policy-limits-default-duration",
+			issuer:              profileIssuer,
+			request:             cmgen.CertificateRequest("cr1", cmgen.SetCertificateRequestCSR(commonNameCSR)),
+			durationPolicy:      &DurationPolicy{MaxDuration: time.Hour},
+			expectedDuration:    time.Hour,
+			expectedKeyUsage:    x509.KeyUsageDigitalSignature,
+			expectedExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			expectedDNSNames:    []string{"example.com"},
+		},
+		{
+			name:   "kubernetes-csr-without-expiration-seconds",
+			issuer: profileIssuer,
+			request: &certificatesv1.CertificateSigningRequest{
+				Spec: certificatesv1.CertificateSigningRequestSpec{
+					Request: commonNameCSR,
+					Usages:  []certificatesv1.KeyUsage{certificatesv1.UsageClientAuth},
+				},
+			},
+			expectedDuration:    24 * time.Hour,
+			expectedExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+			expectedDNSNames:    []string{"example.com"},
+		},
+		{
+			name:   "kubernetes-csr-with-expiration-seconds",
+			issuer: profileIssuer,
+			request: &certificatesv1.CertificateSigningRequest{
+				Spec: certificatesv1.CertificateSigningRequestSpec{
+					Request:           commonNameCSR,
+					Usages:            []certificatesv1.KeyUsage{certificatesv1.UsageClientAuth},
+					ExpirationSeconds: ptr.To(int32(3600)),
+				},
+			},
+			expectedDuration:    time.Hour,
+			expectedExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+			expectedDNSNames:    []string{"example.com"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var cr signer.CertificateRequestObject
+			switch request := tc.request.(type) {
+			case *cmapi.CertificateRequest:
+				cr = signer.CertificateRequestObjectFromCertificateRequest(request)
+			case *certificatesv1.CertificateSigningRequest:
+				cr = signer.CertificateRequestObjectFromCertificateSigningRequest(request)
+			}
+
+			template, duration, _, err := tc.durationPolicy.apply(applyDefaultProfile(tc.request, cr, tc.issuer)).GetRequest()
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedDuration, duration)
+			assert.Equal(t, tc.expectedDuration, template.NotAfter.Sub(template.NotBefore))
+			assert.Equal(t, tc.expectedKeyUsage, template.KeyUsage)
+			assert.Equal(t, tc.expectedExtKeyUsage, template.ExtKeyUsage)
+			assert.Equal(t, tc.expectedDNSNames, template.DNSNames)
+		})
+	}
+}
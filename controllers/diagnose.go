@@ -0,0 +1,325 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	cmutil "github.com/cert-manager/cert-manager/pkg/api/util"
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/go-logr/logr"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/conditions"
+)
+
+// DiagnosisSeverity is the severity of a DiagnosisFinding.
+type DiagnosisSeverity string
+
+const (
+	// DiagnosisOK means that the check did not find a problem.
+	DiagnosisOK DiagnosisSeverity = "OK"
+	// DiagnosisInfo means that the finding does not block the request, but
+	// might help to understand its state.
+	DiagnosisInfo DiagnosisSeverity = "Info"
+	// DiagnosisBlocking means that the request cannot progress until the
+	// problem is resolved.
+	DiagnosisBlocking DiagnosisSeverity = "Blocking"
+)
+
+// DiagnosisFinding is the result of one of the checks of Diagnose.
+type DiagnosisFinding struct {
+	// Check is the name of the check, eg. "Approval" or "Issuer".
+	Check    string
+	Severity DiagnosisSeverity
+	Message  string
+}
+
+// Diagnosis explains why a request is not (yet) issued.
+type Diagnosis struct {
+	// Request describes the request, eg. "CertificateRequest ns1/cr1".
+	Request string
+	// Findings are the results of the checks, in the order in which the
+	// controllers evaluate them.
+	Findings []DiagnosisFinding
+	// Events are the most recent events recorded for the request, oldest first.
+	Events []corev1.Event
+}
+
+// Stuck returns true if one of the findings blocks the request.
+func (d *Diagnosis) Stuck() bool {
+	for _, finding := range d.Findings {
+		if finding.Severity == DiagnosisBlocking {
+			return true
+		}
+	}
+	return false
+}
+
+// String formats the diagnosis as a human-readable table, similar to the output
+// of kubectl describe.
+func (d *Diagnosis) String() string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "%s:\n", d.Request)
+
+	w := tabwriter.NewWriter(&out, 0, 8, 2, ' ', 0)
+	for _, finding := range d.Findings {
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", finding.Check, finding.Severity, finding.Message)
+	}
+	_ = w.Flush()
+
+	if len(d.Events) > 0 {
+		fmt.Fprintf(&out, "Recent events:\n")
+		w := tabwriter.NewWriter(&out, 0, 8, 2, ' ', 0)
+		for _, event := range d.Events {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", eventTime(event).UTC().Format(time.RFC3339), event.Type, event.Reason, event.Message)
+		}
+		_ = w.Flush()
+	}
+
+	return out.String()
+}
+
+// DiagnoseOptions configures Diagnose. The options must match the options of
+// the controllers that handle the request.
+type DiagnoseOptions struct {
+	IssuerTypes        []v1alpha1.Issuer
+	ClusterIssuerTypes []v1alpha1.Issuer
+
+	// MaxRetryDuration is the MaxRetryDuration of the controllers, the retry
+	// window is not checked if it is zero.
+	MaxRetryDuration time.Duration
+
+	// MaxEvents is the maximum number of recent events that are included in the
+	// diagnosis, defaults to 5.
+	MaxEvents int
+
+	// Clock defaults to the real clock.
+	Clock clock.PassiveClock
+}
+
+// Diagnose inspects a CertificateRequest or Kubernetes CSR and explains why it
+// is not (yet) issued: it checks whether the request is handled by one of the
+// issuer types, whether it is approved, whether its issuer exists and is ready,
+// whether it is still within its retry window, and it collects the events that
+// were recorded for it. It is meant for support tooling (eg. a kubectl plugin)
+// and only reads resources using the client.
+func Diagnose(ctx context.Context, c client.Client, request client.Object, options DiagnoseOptions) (*Diagnosis, error) {
+	if options.Clock == nil {
+		options.Clock = clock.RealClock{}
+	}
+	if options.MaxEvents == 0 {
+		options.MaxEvents = 5
+	}
+
+	requestController := RequestController{
+		IssuerTypes:        options.IssuerTypes,
+		ClusterIssuerTypes: options.ClusterIssuerTypes,
+	}
+	if err := requestController.setAllIssuerTypesWithGroupVersionKind(c.Scheme()); err != nil {
+		return nil, err
+	}
+
+	var matchIssuerType MatchIssuerType
+	var helper RequestObjectHelper
+	diagnosis := &Diagnosis{}
+	eventsNamespace := request.GetNamespace()
+	switch request := request.(type) {
+	case *cmapi.CertificateRequest:
+		reconciler := &CertificateRequestReconciler{RequestController: requestController}
+		matchIssuerType = reconciler.matchIssuerType
+		helper = &certificateRequestObjectHelper{readOnlyObj: request}
+		diagnosis.Request = fmt.Sprintf("CertificateRequest %s/%s", request.Namespace, request.Name)
+	case *certificatesv1.CertificateSigningRequest:
+		reconciler := &CertificateSigningRequestReconciler{RequestController: requestController}
+		matchIssuerType = reconciler.matchIssuerType
+		helper = &certificatesigningRequestObjectHelper{readOnlyObj: request}
+		diagnosis.Request = fmt.Sprintf("CertificateSigningRequest %s", request.Name)
+		// Events of cluster-scoped resources are recorded in the default namespace.
+		eventsNamespace = metav1.NamespaceDefault
+	default:
+		return nil, fmt.Errorf("unsupported request type %T", request)
+	}
+
+	events, err := recentEvents(ctx, c, request, eventsNamespace, options.MaxEvents)
+	if err != nil {
+		return nil, err
+	}
+	diagnosis.Events = events
+
+	addFinding := func(check string, severity DiagnosisSeverity, format string, args ...interface{}) {
+		diagnosis.Findings = append(diagnosis.Findings, DiagnosisFinding{
+			Check:    check,
+			Severity: severity,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	issuerObject, issuerName, err := matchIssuerType(request)
+	if err != nil {
+		addFinding("Issuer type", DiagnosisBlocking, "The request is not handled by these issuer types: %v.", err)
+		return diagnosis, nil
+	}
+	issuerGvk := issuerObject.GetObjectKind().GroupVersionKind()
+	issuerDescription := fmt.Sprintf("%s %s", issuerGvk.Kind, issuerName)
+	if issuerName.Namespace == "" {
+		issuerDescription = fmt.Sprintf("%s %s", issuerGvk.Kind, issuerName.Name)
+	}
+	addFinding("Issuer type", DiagnosisOK, "The request references the %s.", issuerDescription)
+
+	switch {
+	case helper.IsReady():
+		addFinding("Status", DiagnosisOK, "The request is issued.")
+		return diagnosis, nil
+	case helper.IsFailed():
+		addFinding("Status", DiagnosisBlocking, "The request failed permanently%s, a new request has to be created.", requestMessage(request))
+		return diagnosis, nil
+	case helper.IsDenied() || isCertificateRequestDenied(request):
+		by := ""
+		if cr, ok := request.(*cmapi.CertificateRequest); ok {
+			if approver := deniedBy(cr); approver != "" {
+				by = " by " + approver
+			}
+		}
+		addFinding("Approval", DiagnosisBlocking, "The request was denied%s, it will never be issued.", by)
+		return diagnosis, nil
+	case !helper.IsApproved():
+		addFinding("Approval", DiagnosisBlocking, "The request has not been approved or denied yet, an approver (eg. cert-manager's internal approver or approver-policy) has to approve it.")
+		return diagnosis, nil
+	}
+	addFinding("Approval", DiagnosisOK, "The request is approved.")
+
+	if err := c.Get(ctx, issuerName, issuerObject); apierrors.IsNotFound(err) {
+		addFinding("Issuer", DiagnosisBlocking, "The %s does not exist.", issuerDescription)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", issuerDescription, err)
+	} else {
+		addFinding(diagnoseIssuer(issuerObject, issuerDescription, options.Clock.Now()))
+	}
+
+	if message := requestMessage(request); message != "" {
+		addFinding("Status", DiagnosisInfo, "The last status of the request is%s.", message)
+	} else if cr, ok := request.(*cmapi.CertificateRequest); ok && cmutil.GetCertificateRequestCondition(cr, cmapi.CertificateRequestConditionReady) == nil {
+		addFinding("Status", DiagnosisBlocking, "The request has no Ready condition yet, check that the controller is running.")
+	}
+
+	if options.MaxRetryDuration > 0 {
+		deadline := request.GetCreationTimestamp().Add(options.MaxRetryDuration)
+		if now := options.Clock.Now(); now.After(deadline) {
+			addFinding("Retry window", DiagnosisInfo, "The retry window of %s ended at %s, the next error returned by Sign (except a PendingError) fails the request.",
+				options.MaxRetryDuration, deadline.UTC().Format(time.RFC3339))
+		} else {
+			addFinding("Retry window", DiagnosisOK, "Errors are retried until %s (%s left).",
+				deadline.UTC().Format(time.RFC3339), deadline.Sub(now).Round(time.Second))
+		}
+	}
+
+	return diagnosis, nil
+}
+
+// diagnoseIssuer returns the finding for an existing issuer.
+func diagnoseIssuer(issuerObject v1alpha1.Issuer, issuerDescription string, now time.Time) (string, DiagnosisSeverity, string) {
+	if isIssuerPaused(issuerObject) {
+		return "Issuer", DiagnosisBlocking, fmt.Sprintf("The %s is paused using the %s annotation.", issuerDescription, v1alpha1.IssuerPausedAnnotationKey)
+	}
+
+	if window := activeMaintenanceWindow(logr.Discard(), issuerObject, now); window != nil {
+		return "Issuer", DiagnosisBlocking, fmt.Sprintf("The %s is in a scheduled maintenance window (%s).", issuerDescription, window)
+	}
+
+	readyCondition := conditions.GetIssuerStatusCondition(issuerObject.GetStatus().Conditions, cmapi.IssuerConditionReady)
+	switch {
+	case readyCondition == nil:
+		return "Issuer", DiagnosisBlocking, fmt.Sprintf("The %s has no Ready condition yet, check that the issuer controller is running.", issuerDescription)
+	case readyCondition.ObservedGeneration < issuerObject.GetGeneration():
+		return "Issuer", DiagnosisBlocking, fmt.Sprintf("The Ready condition of the %s is outdated (observed generation %d, current generation %d), check that the issuer controller is running.",
+			issuerDescription, readyCondition.ObservedGeneration, issuerObject.GetGeneration())
+	case readyCondition.Status != cmmeta.ConditionTrue:
+		return "Issuer", DiagnosisBlocking, fmt.Sprintf("The %s is not ready (%s): %s", issuerDescription, readyCondition.Reason, readyCondition.Message)
+	}
+
+	return "Issuer", DiagnosisOK, fmt.Sprintf("The %s is ready.", issuerDescription)
+}
+
+// isCertificateRequestDenied returns true if the request is a CertificateRequest
+// with a Denied condition, which is not yet reflected in its Ready condition.
+func isCertificateRequestDenied(request client.Object) bool {
+	cr, ok := request.(*cmapi.CertificateRequest)
+	return ok && cmutil.CertificateRequestIsDenied(cr)
+}
+
+// requestMessage returns the reason and message of the Ready condition of a
+// CertificateRequest, formatted as " (<reason>: <message>)", or an empty string.
+func requestMessage(request client.Object) string {
+	cr, ok := request.(*cmapi.CertificateRequest)
+	if !ok {
+		return ""
+	}
+
+	for _, condition := range cr.Status.Conditions {
+		if condition.Type == cmapi.CertificateRequestConditionReady && (condition.Reason != "" || condition.Message != "") {
+			return fmt.Sprintf(" (%s: %s)", condition.Reason, strings.TrimSuffix(condition.Message, "."))
+		}
+	}
+	return ""
+}
+
+// recentEvents returns the most recent events recorded for the object, oldest first.
+func recentEvents(ctx context.Context, c client.Reader, obj client.Object, namespace string, maxEvents int) ([]corev1.Event, error) {
+	eventList := &corev1.EventList{}
+	if err := c.List(ctx, eventList, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+
+	var events []corev1.Event
+	for _, event := range eventList.Items {
+		if event.InvolvedObject.UID == obj.GetUID() {
+			events = append(events, event)
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return eventTime(events[i]).Before(eventTime(events[j]))
+	})
+	if len(events) > maxEvents {
+		events = events[len(events)-maxEvents:]
+	}
+	return events, nil
+}
+
+func eventTime(event corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	default:
+		return event.CreationTimestamp.Time
+	}
+}
@@ -0,0 +1,295 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	cmgen "github.com/cert-manager/cert-manager/test/unit/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+	"github.com/cert-manager/issuer-lib/internal/testapi/testutil"
+)
+
+func TestDiagnose(t *testing.T) {
+	t.Parallel()
+
+	fakeClock := clocktesting.NewFakeClock(randomTime().Truncate(time.Second))
+
+	readyIssuer := testutil.TestIssuer(
+		"ready-issuer",
+		testutil.SetTestIssuerNamespace("ns1"),
+		testutil.SetTestIssuerStatusCondition(
+			fakeClock,
+			cmapi.IssuerConditionReady,
+			cmmeta.ConditionTrue,
+			v1alpha1.IssuerConditionReasonChecked,
+			"Succeeded checking the issuer",
+		),
+	)
+
+	notReadyIssuer := testutil.TestIssuer(
+		"not-ready-issuer",
+		testutil.SetTestIssuerNamespace("ns1"),
+		testutil.SetTestIssuerStatusCondition(
+			fakeClock,
+			cmapi.IssuerConditionReady,
+			cmmeta.ConditionFalse,
+			v1alpha1.IssuerConditionReasonPending,
+			"Not ready yet: [error]",
+		),
+	)
+
+	pausedIssuer := testutil.TestIssuerFrom(readyIssuer, func(issuer *api.TestIssuer) {
+		issuer.Name = "paused-issuer"
+		issuer.Annotations = map[string]string{v1alpha1.IssuerPausedAnnotationKey: "true"}
+	})
+
+	cr := func(issuerName string, mods ...cmgen.CertificateRequestModifier) *cmapi.CertificateRequest {
+		return cmgen.CertificateRequest("cr1", append([]cmgen.CertificateRequestModifier{
+			cmgen.SetCertificateRequestNamespace("ns1"),
+			cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+				Name:  issuerName,
+				Group: api.SchemeGroupVersion.Group,
+			}),
+			func(cr *cmapi.CertificateRequest) {
+				cr.UID = "cr1-uid"
+				cr.CreationTimestamp = metav1.NewTime(fakeClock.Now().Add(-time.Minute))
+			},
+		}, mods...)...)
+	}
+	approved := cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+		Type:   cmapi.CertificateRequestConditionApproved,
+		Status: cmmeta.ConditionTrue,
+	})
+	ready := func(status cmmeta.ConditionStatus, reason string, message string) cmgen.CertificateRequestModifier {
+		return cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+			Type:    cmapi.CertificateRequestConditionReady,
+			Status:  status,
+			Reason:  reason,
+			Message: message,
+		})
+	}
+
+	type testcase struct {
+		name             string
+		request          client.Object
+		maxRetryDuration time.Duration
+		expectedFindings []DiagnosisFinding
+	}
+
+	tests := []testcase{
+		{
+			name: "foreign-issuer",
+			request: cmgen.CertificateRequest("cr1",
+				cmgen.SetCertificateRequestNamespace("ns1"),
+				cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{Name: "issuer", Group: "other.example.com"}),
+			),
+			expectedFindings: []DiagnosisFinding{
+				{"Issuer type", DiagnosisBlocking, `The request is not handled by these issuer types: no issuer found for reference: [Group="other.example.com", Kind="", Name="issuer"].`},
+			},
+		},
+		{
+			name:    "not-approved",
+			request: cr("ready-issuer"),
+			expectedFindings: []DiagnosisFinding{
+				{"Issuer type", DiagnosisOK, "The request references the TestIssuer ns1/ready-issuer."},
+				{"Approval", DiagnosisBlocking, "The request has not been approved or denied yet, an approver (eg. cert-manager's internal approver or approver-policy) has to approve it."},
+			},
+		},
+		{
+			name: "denied",
+			request: cr("ready-issuer", cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+				Type:   cmapi.CertificateRequestConditionDenied,
+				Status: cmmeta.ConditionTrue,
+			})),
+			expectedFindings: []DiagnosisFinding{
+				{"Issuer type", DiagnosisOK, "The request references the TestIssuer ns1/ready-issuer."},
+				{"Approval", DiagnosisBlocking, "The request was denied, it will never be issued."},
+			},
+		},
+		{
+			name:    "issued",
+			request: cr("ready-issuer", approved, ready(cmmeta.ConditionTrue, cmapi.CertificateRequestReasonIssued, "Succeeded signing the CertificateRequest")),
+			expectedFindings: []DiagnosisFinding{
+				{"Issuer type", DiagnosisOK, "The request references the TestIssuer ns1/ready-issuer."},
+				{"Status", DiagnosisOK, "The request is issued."},
+			},
+		},
+		{
+			name:    "failed",
+			request: cr("ready-issuer", approved, ready(cmmeta.ConditionFalse, cmapi.CertificateRequestReasonFailed, "Failed permanently: [error].")),
+			expectedFindings: []DiagnosisFinding{
+				{"Issuer type", DiagnosisOK, "The request references the TestIssuer ns1/ready-issuer."},
+				{"Status", DiagnosisBlocking, "The request failed permanently (Failed: Failed permanently: [error]), a new request has to be created."},
+			},
+		},
+		{
+			name:    "issuer-not-found",
+			request: cr("missing-issuer", approved, ready(cmmeta.ConditionFalse, cmapi.CertificateRequestReasonPending, "Issuer not found")),
+			expectedFindings: []DiagnosisFinding{
+				{"Issuer type", DiagnosisOK, "The request references the TestIssuer ns1/missing-issuer."},
+				{"Approval", DiagnosisOK, "The request is approved."},
+				{"Issuer", DiagnosisBlocking, "The TestIssuer ns1/missing-issuer does not exist."},
+				{"Status", DiagnosisInfo, "The last status of the request is (Pending: Issuer not found)."},
+			},
+		},
+		{
+			name:    "issuer-not-ready",
+			request: cr("not-ready-issuer", approved),
+			expectedFindings: []DiagnosisFinding{
+				{"Issuer type", DiagnosisOK, "The request references the TestIssuer ns1/not-ready-issuer."},
+				{"Approval", DiagnosisOK, "The request is approved."},
+				{"Issuer", DiagnosisBlocking, "The TestIssuer ns1/not-ready-issuer is not ready (Pending): Not ready yet: [error]"},
+				{"Status", DiagnosisBlocking, "The request has no Ready condition yet, check that the controller is running."},
+			},
+		},
+		{
+			name:             "issuer-paused",
+			request:          cr("paused-issuer", approved, ready(cmmeta.ConditionFalse, cmapi.CertificateRequestReasonPending, "Issuer is paused")),
+			maxRetryDuration: time.Hour,
+			expectedFindings: []DiagnosisFinding{
+				{"Issuer type", DiagnosisOK, "The request references the TestIssuer ns1/paused-issuer."},
+				{"Approval", DiagnosisOK, "The request is approved."},
+				{"Issuer", DiagnosisBlocking, "The TestIssuer ns1/paused-issuer is paused using the issuer-lib.cert-manager.io/paused annotation."},
+				{"Status", DiagnosisInfo, "The last status of the request is (Pending: Issuer is paused)."},
+				{"Retry window", DiagnosisOK, "Errors are retried until " + fakeClock.Now().Add(59*time.Minute).UTC().Format(time.RFC3339) + " (59m0s left)."},
+			},
+		},
+		{
+			name:             "retry-window-exceeded",
+			request:          cr("ready-issuer", approved, ready(cmmeta.ConditionFalse, cmapi.CertificateRequestReasonPending, "Signing failed: [error]")),
+			maxRetryDuration: 30 * time.Second,
+			expectedFindings: []DiagnosisFinding{
+				{"Issuer type", DiagnosisOK, "The request references the TestIssuer ns1/ready-issuer."},
+				{"Approval", DiagnosisOK, "The request is approved."},
+				{"Issuer", DiagnosisOK, "The TestIssuer ns1/ready-issuer is ready."},
+				{"Status", DiagnosisInfo, "The last status of the request is (Pending: Signing failed: [error])."},
+				{"Retry window", DiagnosisInfo, "The retry window of 30s ended at " + fakeClock.Now().Add(-30*time.Second).UTC().Format(time.RFC3339) + ", the next error returned by Sign (except a PendingError) fails the request."},
+			},
+		},
+		{
+			name: "csr-namespaced-issuer",
+			request: &certificatesv1.CertificateSigningRequest{
+				ObjectMeta: metav1.ObjectMeta{Name: "csr1"},
+				Spec:       certificatesv1.CertificateSigningRequestSpec{SignerName: "testissuers.testing.cert-manager.io/ready-issuer"},
+			},
+			expectedFindings: []DiagnosisFinding{
+				{"Issuer type", DiagnosisBlocking, `The request is not handled by these issuer types: invalid SignerName, "testissuers.testing.cert-manager.io" is a namespaced issuer type, namespaced issuers are not supported for Kubernetes CSRs.`},
+			},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, setupCertificateRequestReconcilerScheme(scheme))
+	require.NoError(t, api.AddToScheme(scheme))
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(readyIssuer, notReadyIssuer, pausedIssuer).
+				Build()
+
+			diagnosis, err := Diagnose(context.TODO(), fakeClient, tc.request, DiagnoseOptions{
+				IssuerTypes:        []v1alpha1.Issuer{&api.TestIssuer{}},
+				ClusterIssuerTypes: []v1alpha1.Issuer{&api.TestClusterIssuer{}},
+				MaxRetryDuration:   tc.maxRetryDuration,
+				Clock:              fakeClock,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedFindings, diagnosis.Findings)
+		})
+	}
+}
+
+func TestDiagnosisString(t *testing.T) {
+	t.Parallel()
+
+	fakeClock := clocktesting.NewFakeClock(time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC))
+
+	cr := cmgen.CertificateRequest("cr1",
+		cmgen.SetCertificateRequestNamespace("ns1"),
+		cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{Name: "issuer-1", Group: api.SchemeGroupVersion.Group}),
+		cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+			Type:   cmapi.CertificateRequestConditionApproved,
+			Status: cmmeta.ConditionTrue,
+		}),
+	)
+	cr.UID = "cr1-uid"
+
+	event := func(name string, uid string, minutesAgo int, reason string) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Namespace: "ns1", Name: name},
+			InvolvedObject: corev1.ObjectReference{UID: types.UID(uid)},
+			LastTimestamp:  metav1.NewTime(fakeClock.Now().Add(-time.Duration(minutesAgo) * time.Minute)),
+			Type:           corev1.EventTypeWarning,
+			Reason:         reason,
+			Message:        reason + " message",
+		}
+	}
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, setupCertificateRequestReconcilerScheme(scheme))
+	require.NoError(t, api.AddToScheme(scheme))
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			event("e1", "cr1-uid", 3, "First"),
+			event("e2", "cr1-uid", 1, "Third"),
+			event("e3", "cr1-uid", 2, "Second"),
+			event("e4", "other-uid", 1, "Other"),
+		).
+		Build()
+
+	diagnosis, err := Diagnose(context.TODO(), fakeClient, cr, DiagnoseOptions{
+		IssuerTypes: []v1alpha1.Issuer{&api.TestIssuer{}},
+		MaxEvents:   2,
+		Clock:       fakeClock,
+	})
+	require.NoError(t, err)
+	assert.True(t, diagnosis.Stuck())
+	assert.Equal(t, `CertificateRequest ns1/cr1:
+  Issuer type  OK        The request references the TestIssuer ns1/issuer-1.
+  Approval     OK        The request is approved.
+  Issuer       Blocking  The TestIssuer ns1/issuer-1 does not exist.
+  Status       Blocking  The request has no Ready condition yet, check that the controller is running.
+Recent events:
+  2023-01-01T11:58:00Z  Warning  Second  Second message
+  2023-01-01T11:59:00Z  Warning  Third   Third message
+`, diagnosis.String())
+}
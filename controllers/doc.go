@@ -0,0 +1,25 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package controllers contains the CombinedController and the issuer and request
+// controllers that it sets up, and the options and policies that they use.
+//
+// # Stability
+//
+// This package is stable: see "API stability" in the README for the
+// deprecation policy. Deprecated options are marked with a "Deprecated:"
+// paragraph that names their replacement.
+package controllers
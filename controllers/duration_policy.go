@@ -0,0 +1,83 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"crypto/x509"
+	"time"
+
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+// DurationPolicy limits the requested duration of the certificates, which is the
+// spec.duration of a CertificateRequest or the spec.expirationSeconds of a
+// Kubernetes CSR. The limited duration is returned by the GetRequest method of
+// the request that is passed to the Sign function, so both request types behave
+// the same for the same signer.
+type DurationPolicy struct {
+	// MinDuration is the minimum duration, shorter requested durations are
+	// raised to the MinDuration. Zero means no minimum.
+	MinDuration time.Duration
+	// MaxDuration is the maximum duration, longer requested durations are
+	// lowered to the MaxDuration. Zero means no maximum.
+	MaxDuration time.Duration
+}
+
+// clamp returns the duration limited to the MinDuration and MaxDuration.
+func (p *DurationPolicy) clamp(duration time.Duration) time.Duration {
+	if p.MinDuration > 0 && duration < p.MinDuration {
+		duration = p.MinDuration
+	}
+	if p.MaxDuration > 0 && duration > p.MaxDuration {
+		duration = p.MaxDuration
+	}
+	return duration
+}
+
+// apply returns the request with its requested duration limited by the policy.
+// A nil policy returns the request unchanged.
+func (p *DurationPolicy) apply(cr signer.CertificateRequestObject) signer.CertificateRequestObject {
+	if p == nil {
+		return cr
+	}
+
+	return &durationLimitedRequest{
+		CertificateRequestObject: cr,
+		policy:                   p,
+	}
+}
+
+type durationLimitedRequest struct {
+	signer.CertificateRequestObject
+	policy *DurationPolicy
+}
+
+var _ signer.CertificateRequestObject = &durationLimitedRequest{}
+
+func (r *durationLimitedRequest) GetRequest() (*x509.Certificate, time.Duration, []byte, error) {
+	template, duration, csr, err := r.CertificateRequestObject.GetRequest()
+	if err != nil {
+		return template, duration, csr, err
+	}
+
+	if limited := r.policy.clamp(duration); limited != duration {
+		duration = limited
+		template.NotAfter = template.NotBefore.Add(duration)
+	}
+
+	return template, duration, csr, nil
+}
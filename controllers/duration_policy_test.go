@@ -0,0 +1,130 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	cmgen "github.com/cert-manager/cert-manager/test/unit/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+func TestDurationPolicyApply(t *testing.T) {
+	t.Parallel()
+
+	csrPEM := testCSRWithExtensions(t)
+
+	certificateRequest := func(duration time.Duration) signer.CertificateRequestObject {
+		return signer.CertificateRequestObjectFromCertificateRequest(cmgen.CertificateRequest(
+			"cr1",
+			cmgen.SetCertificateRequestCSR(csrPEM),
+			cmgen.SetCertificateRequestDuration(&metav1.Duration{Duration: duration}),
+		))
+	}
+
+	type testCase struct {
+		name             string
+		policy           *DurationPolicy
+		request          signer.CertificateRequestObject
+		expectedDuration time.Duration
+	}
+
+	tests := []testCase{
+		{
+			name:             "nil-policy",
+			policy:           nil,
+			request:          certificateRequest(time.Hour),
+			expectedDuration: time.Hour,
+		},
+		{
+			name:             "within-limits",
+			policy:           &DurationPolicy{MinDuration: time.Hour, MaxDuration: 24 * time.Hour},
+			request:          certificateRequest(2 * time.Hour),
+			expectedDuration: 2 * time.Hour,
+		},
+		{
+			name:             "raised-to-min-duration",
+			policy:           &DurationPolicy{MinDuration: time.Hour},
+			request:          certificateRequest(10 * time.Minute),
+			expectedDuration: time.Hour,
+		},
+		{
+			name:             "lowered-to-max-duration",
+			policy:           &DurationPolicy{MaxDuration: 24 * time.Hour},
+			request:          certificateRequest(90 * 24 * time.Hour),
+			expectedDuration: 24 * time.Hour,
+		},
+		{
+			name:   "csr-expiration-seconds",
+			policy: &DurationPolicy{MaxDuration: 24 * time.Hour},
+			request: signer.CertificateRequestObjectFromCertificateSigningRequest(cmgen.CertificateSigningRequest(
+				"csr1",
+				cmgen.SetCertificateSigningRequestRequest(csrPEM),
+				cmgen.SetCertificateSigningRequestExpirationSeconds(3600),
+			)),
+			expectedDuration: time.Hour,
+		},
+		{
+			name:   "csr-expiration-seconds-lowered-to-max-duration",
+			policy: &DurationPolicy{MaxDuration: 24 * time.Hour},
+			request: signer.CertificateRequestObjectFromCertificateSigningRequest(cmgen.CertificateSigningRequest(
+				"csr1",
+				cmgen.SetCertificateSigningRequestRequest(csrPEM),
+				cmgen.SetCertificateSigningRequestExpirationSeconds(7*24*3600),
+			)),
+			expectedDuration: 24 * time.Hour,
+		},
+		{
+			name:   "csr-duration-annotation-takes-precedence-over-expiration-seconds",
+			policy: nil,
+			request: signer.CertificateRequestObjectFromCertificateSigningRequest(cmgen.CertificateSigningRequest(
+				"csr1",
+				cmgen.SetCertificateSigningRequestRequest(csrPEM),
+				cmgen.SetCertificateSigningRequestDuration("48h"),
+				cmgen.SetCertificateSigningRequestExpirationSeconds(3600),
+			)),
+			expectedDuration: 48 * time.Hour,
+		},
+		{
+			name:   "csr-duration-annotation",
+			policy: nil,
+			request: signer.CertificateRequestObjectFromCertificateSigningRequest(cmgen.CertificateSigningRequest(
+				"csr1",
+				cmgen.SetCertificateSigningRequestRequest(csrPEM),
+				cmgen.SetCertificateSigningRequestDuration("48h"),
+			)),
+			expectedDuration: 48 * time.Hour,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			template, duration, _, err := tc.policy.apply(tc.request).GetRequest()
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedDuration, duration)
+			assert.Equal(t, tc.expectedDuration, template.NotAfter.Sub(template.NotBefore))
+		})
+	}
+}
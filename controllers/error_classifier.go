@@ -0,0 +1,49 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+// classifyError applies the ErrorClassifier to errors that are not already one
+// of the signer error types.
+func classifyError(classifier signer.ErrorClassifier, err error) error {
+	if classifier == nil || err == nil {
+		return err
+	}
+
+	if isSignerError(err) {
+		return err
+	}
+
+	if classifiedErr := classifier(err); classifiedErr != nil {
+		return classifiedErr
+	}
+
+	return err
+}
+
+// isSignerError returns true if the error is one of the signer error types.
+func isSignerError(err error) bool {
+	return errors.As(err, &signer.PermanentError{}) ||
+		errors.As(err, &signer.PendingError{}) ||
+		errors.As(err, &signer.IssuerError{}) ||
+		errors.As(err, &signer.SetCertificateRequestConditionError{})
+}
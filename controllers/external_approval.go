@@ -0,0 +1,251 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+// ExternalApprovalRequest is the JSON body that is POSTed to the external approval
+// webhook. The webhook is called for every reconcile of a request until it is
+// approved or denied, so it has to be idempotent; the UID identifies the request.
+type ExternalApprovalRequest struct {
+	Kind      string            `json:"kind"`
+	Namespace string            `json:"namespace,omitempty"`
+	Name      string            `json:"name"`
+	UID       string            `json:"uid"`
+	Labels    map[string]string `json:"labels,omitempty"`
+
+	IssuerRef ExternalApprovalIssuerRef `json:"issuerRef"`
+
+	CommonName     string   `json:"commonName,omitempty"`
+	DNSNames       []string `json:"dnsNames,omitempty"`
+	IPAddresses    []string `json:"ipAddresses,omitempty"`
+	URIs           []string `json:"uris,omitempty"`
+	EmailAddresses []string `json:"emailAddresses,omitempty"`
+	IsCA           bool     `json:"isCA"`
+	Duration       string   `json:"duration,omitempty"`
+}
+
+// ExternalApprovalIssuerRef identifies the issuer of an ExternalApprovalRequest.
+type ExternalApprovalIssuerRef struct {
+	Group     string `json:"group"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+// ExternalApprovalResponse is the JSON body that the external approval webhook
+// returns. Decision is one of ExternalApprovalDecisionPending,
+// ExternalApprovalDecisionApproved and ExternalApprovalDecisionDenied.
+type ExternalApprovalResponse struct {
+	Decision string `json:"decision"`
+	TicketID string `json:"ticketID,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+// ExternalApprovalPolicy enables an approval step in an external system (eg. an
+// ITSM ticket) before a request is signed. The metadata of the request is POSTed to
+// the webhook at URL, which returns whether the request is approved, denied or still
+// pending. The decision and ticket ID are recorded in the ExternalApproval condition
+// of the request. Pending requests are polled every PollInterval, without a limit,
+// denied requests are failed permanently and once a request is approved the webhook
+// is no longer called for that request. Errors calling the webhook are retried with
+// backoff, until the MaxRetryDuration has passed.
+type ExternalApprovalPolicy struct {
+	// URL is the URL of the webhook.
+	URL string
+
+	// Client is the HTTP client used to call the webhook, defaults to a client
+	// with a 30 second timeout. Use a custom client to configure TLS or
+	// authentication.
+	Client *http.Client
+
+	// PollInterval is the interval at which the webhook is called for pending
+	// requests, defaults to 1 minute.
+	PollInterval time.Duration
+}
+
+// externalApprovalDecision is the ExternalApproval condition that is set on the
+// request.
+type externalApprovalDecision struct {
+	status  metav1.ConditionStatus
+	reason  string
+	message string
+}
+
+// decide calls the webhook and returns the decision that should be recorded on the
+// request. The returned error is a PendingError while the request is pending, a
+// PermanentError if it was denied and nil once it was approved. A nil policy and
+// requests that were already approved return no decision and no error.
+func (p *ExternalApprovalPolicy) decide(
+	ctx context.Context,
+	cr signer.CertificateRequestObject,
+	kind string,
+	issuerGvk schema.GroupVersionKind,
+	issuerName types.NamespacedName,
+) (*externalApprovalDecision, error) {
+	if p == nil {
+		return nil, nil
+	}
+
+	for _, condition := range cr.GetConditions() {
+		if string(condition.Type) == v1alpha1.CertificateRequestConditionTypeExternalApproval &&
+			string(condition.Status) == string(metav1.ConditionTrue) {
+			return nil, nil
+		}
+	}
+
+	response, err := p.call(ctx, cr, kind, issuerGvk, issuerName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call the external approval webhook: %w", err)
+	}
+
+	message := response.Message
+	if message == "" {
+		message = fmt.Sprintf("The external approval system returned the decision %s", response.Decision)
+	}
+	if response.TicketID != "" {
+		message = fmt.Sprintf("Ticket %s: %s", response.TicketID, message)
+	}
+
+	switch response.Decision {
+	case v1alpha1.ExternalApprovalDecisionApproved:
+		return &externalApprovalDecision{
+			status:  metav1.ConditionTrue,
+			reason:  v1alpha1.ExternalApprovalDecisionApproved,
+			message: message,
+		}, nil
+	case v1alpha1.ExternalApprovalDecisionDenied:
+		decision := &externalApprovalDecision{
+			status:  metav1.ConditionFalse,
+			reason:  v1alpha1.ExternalApprovalDecisionDenied,
+			message: message,
+		}
+		return decision, signer.PermanentError{
+			Err: fmt.Errorf("the request was denied by the external approval system: %s", message),
+		}
+	case v1alpha1.ExternalApprovalDecisionPending:
+		pollInterval := p.PollInterval
+		if pollInterval == 0 {
+			pollInterval = time.Minute
+		}
+
+		decision := &externalApprovalDecision{
+			status:  metav1.ConditionUnknown,
+			reason:  v1alpha1.ExternalApprovalDecisionPending,
+			message: message,
+		}
+		return decision, signer.PendingError{
+			Err:        fmt.Errorf("the external approval is pending: %s", message),
+			RetryAfter: pollInterval,
+			Reason:     v1alpha1.CertificateRequestConditionReasonExternalApprovalPending,
+		}
+	default:
+		return nil, fmt.Errorf("the external approval webhook returned an unknown decision %q", response.Decision)
+	}
+}
+
+func (p *ExternalApprovalPolicy) call(
+	ctx context.Context,
+	cr signer.CertificateRequestObject,
+	kind string,
+	issuerGvk schema.GroupVersionKind,
+	issuerName types.NamespacedName,
+) (*ExternalApprovalResponse, error) {
+	template, duration, _, err := cr.GetRequest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse request: %w", err)
+	}
+
+	approvalRequest := ExternalApprovalRequest{
+		Kind:      kind,
+		Namespace: cr.GetNamespace(),
+		Name:      cr.GetName(),
+		UID:       string(cr.GetUID()),
+		Labels:    cr.GetLabels(),
+		IssuerRef: ExternalApprovalIssuerRef{
+			Group:     issuerGvk.Group,
+			Kind:      issuerGvk.Kind,
+			Namespace: issuerName.Namespace,
+			Name:      issuerName.Name,
+		},
+		CommonName:     template.Subject.CommonName,
+		DNSNames:       template.DNSNames,
+		EmailAddresses: template.EmailAddresses,
+		IsCA:           template.BasicConstraintsValid && template.IsCA,
+	}
+	for _, ip := range template.IPAddresses {
+		approvalRequest.IPAddresses = append(approvalRequest.IPAddresses, ip.String())
+	}
+	for _, uri := range template.URIs {
+		approvalRequest.URIs = append(approvalRequest.URIs, uri.String())
+	}
+	if duration > 0 {
+		approvalRequest.Duration = duration.String()
+	}
+
+	body, err := json.Marshal(approvalRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+
+	client := p.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	httpResponse, err := client.Do(httpRequest)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResponse.Body.Close()
+
+	if httpResponse.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", httpResponse.Status)
+	}
+
+	var response ExternalApprovalResponse
+	if err := json.NewDecoder(io.LimitReader(httpResponse.Body, 1<<20)).Decode(&response); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	if response.Decision == "" {
+		return nil, errors.New("invalid response: missing decision")
+	}
+
+	return &response, nil
+}
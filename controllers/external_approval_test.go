@@ -0,0 +1,204 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	cmgen "github.com/cert-manager/cert-manager/test/unit/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/tests/errormatch"
+)
+
+func TestExternalApprovalPolicyDecide(t *testing.T) {
+	t.Parallel()
+
+	issuerGvk := schema.GroupVersionKind{Group: "testing.cert-manager.io", Version: "api", Kind: "TestIssuer"}
+	issuerName := types.NamespacedName{Namespace: "ns1", Name: "issuer-1"}
+
+	cr := cmgen.CertificateRequest(
+		"cr1",
+		cmgen.SetCertificateRequestNamespace("ns1"),
+		cmgen.SetCertificateRequestCSR(testCSRWithExtensions(t)),
+		cmgen.SetCertificateRequestDuration(&metav1.Duration{Duration: time.Hour}),
+	)
+	cr.UID = "uid-1"
+
+	approvedCR := cmgen.CertificateRequestFrom(cr,
+		cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+			Type:   v1alpha1.CertificateRequestConditionTypeExternalApproval,
+			Status: cmmeta.ConditionTrue,
+			Reason: v1alpha1.ExternalApprovalDecisionApproved,
+		}),
+	)
+
+	type testCase struct {
+		name             string
+		policy           func(url string) *ExternalApprovalPolicy
+		cr               *cmapi.CertificateRequest
+		status           int
+		response         string
+		expectedCalls    int
+		expectedDecision *externalApprovalDecision
+		validateError    *errormatch.Matcher
+	}
+
+	withURL := func(url string) *ExternalApprovalPolicy {
+		return &ExternalApprovalPolicy{URL: url, PollInterval: 5 * time.Minute}
+	}
+
+	tests := []testCase{
+		{
+			name:          "nil-policy",
+			policy:        func(string) *ExternalApprovalPolicy { return nil },
+			cr:            cr,
+			validateError: errormatch.NoError(),
+		},
+		{
+			name:          "already-approved",
+			policy:        withURL,
+			cr:            approvedCR,
+			validateError: errormatch.NoError(),
+		},
+		{
+			name:          "approved",
+			policy:        withURL,
+			cr:            cr,
+			status:        http.StatusOK,
+			response:      `{"decision": "Approved", "ticketID": "CHG-1", "message": "approved by alice"}`,
+			expectedCalls: 1,
+			expectedDecision: &externalApprovalDecision{
+				status:  metav1.ConditionTrue,
+				reason:  v1alpha1.ExternalApprovalDecisionApproved,
+				message: "Ticket CHG-1: approved by alice",
+			},
+			validateError: errormatch.NoError(),
+		},
+		{
+			name:          "pending",
+			policy:        withURL,
+			cr:            cr,
+			status:        http.StatusOK,
+			response:      `{"decision": "Pending", "ticketID": "CHG-1"}`,
+			expectedCalls: 1,
+			expectedDecision: &externalApprovalDecision{
+				status:  metav1.ConditionUnknown,
+				reason:  v1alpha1.ExternalApprovalDecisionPending,
+				message: "Ticket CHG-1: The external approval system returned the decision Pending",
+			},
+			validateError: errormatch.ErrorContains("the external approval is pending: Ticket CHG-1"),
+		},
+		{
+			name:          "denied",
+			policy:        withURL,
+			cr:            cr,
+			status:        http.StatusOK,
+			response:      `{"decision": "Denied", "message": "not allowed"}`,
+			expectedCalls: 1,
+			expectedDecision: &externalApprovalDecision{
+				status:  metav1.ConditionFalse,
+				reason:  v1alpha1.ExternalApprovalDecisionDenied,
+				message: "not allowed",
+			},
+			validateError: errormatch.ErrorContains("the request was denied by the external approval system: not allowed"),
+		},
+		{
+			name:          "unknown-decision",
+			policy:        withURL,
+			cr:            cr,
+			status:        http.StatusOK,
+			response:      `{"decision": "Maybe"}`,
+			expectedCalls: 1,
+			validateError: errormatch.ErrorContains(`unknown decision "Maybe"`),
+		},
+		{
+			name:          "server-error",
+			policy:        withURL,
+			cr:            cr,
+			status:        http.StatusInternalServerError,
+			expectedCalls: 1,
+			validateError: errormatch.ErrorContains("failed to call the external approval webhook: unexpected status 500 Internal Server Error"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+
+				var request ExternalApprovalRequest
+				if assert.NoError(t, json.NewDecoder(r.Body).Decode(&request)) {
+					assert.Equal(t, ExternalApprovalRequest{
+						Kind:      "CertificateRequest",
+						Namespace: "ns1",
+						Name:      "cr1",
+						UID:       "uid-1",
+						IssuerRef: ExternalApprovalIssuerRef{
+							Group:     "testing.cert-manager.io",
+							Kind:      "TestIssuer",
+							Namespace: "ns1",
+							Name:      "issuer-1",
+						},
+						CommonName: "example.com",
+						DNSNames:   []string{"example.com"},
+						Duration:   "1h0m0s",
+					}, request)
+				}
+
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.response))
+			}))
+			defer server.Close()
+
+			decision, err := tc.policy(server.URL).decide(
+				context.TODO(),
+				signer.CertificateRequestObjectFromCertificateRequest(tc.cr),
+				"CertificateRequest",
+				issuerGvk,
+				issuerName,
+			)
+			(*tc.validateError)(t, err)
+			assert.Equal(t, tc.expectedDecision, decision)
+			assert.Equal(t, tc.expectedCalls, calls)
+
+			if tc.name == "pending" {
+				pendingError := signer.PendingError{}
+				require.True(t, errors.As(err, &pendingError))
+				assert.Equal(t, 5*time.Minute, pendingError.RetryAfter)
+				assert.Equal(t, v1alpha1.CertificateRequestConditionReasonExternalApprovalPending, pendingError.Reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"slices"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/go-logr/logr"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+// reservedConditionTypes are the condition types that the request controllers
+// manage themselves, extra conditions of these types are ignored.
+var reservedConditionTypes = []string{
+	string(cmapi.CertificateRequestConditionReady),
+	string(cmapi.CertificateRequestConditionApproved),
+	string(cmapi.CertificateRequestConditionDenied),
+	string(cmapi.CertificateRequestConditionInvalidRequest),
+	string(certificatesv1.CertificateFailed),
+}
+
+// setExtraConditions sets the extra conditions that Sign recorded in the sign
+// result on the request.
+func setExtraConditions(logger logr.Logger, statusPatch RequestPatchHelper, result *signer.SignResult) {
+	if result == nil {
+		return
+	}
+
+	for _, condition := range result.ExtraConditions {
+		if slices.Contains(reservedConditionTypes, string(condition.Type)) {
+			logger.Info("Ignoring an extra condition of a type that is managed by the controller.", "type", condition.Type)
+			continue
+		}
+
+		statusPatch.SetCustomCondition(
+			string(condition.Type),
+			metav1.ConditionStatus(condition.Status),
+			condition.Reason,
+			condition.Message,
+		)
+	}
+}
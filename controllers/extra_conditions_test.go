@@ -0,0 +1,198 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	cmgen "github.com/cert-manager/cert-manager/test/unit/gen"
+	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/kubeutil"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+	"github.com/cert-manager/issuer-lib/internal/testapi/testutil"
+)
+
+func TestCertificateRequestReconcilerExtraConditions(t *testing.T) {
+	t.Parallel()
+
+	fieldOwner := "test-certificate-request-reconciler-extra-conditions"
+
+	fakeClock := clocktesting.NewFakeClock(randomTime())
+	fakeTimeObj := metav1.NewTime(fakeClock.Now())
+
+	policyCondition := signer.ExtraCondition{
+		Type:    "example.com/Policy",
+		Status:  cmmeta.ConditionTrue,
+		Reason:  "PolicyApplied",
+		Message: "the CA applied the server policy",
+	}
+
+	type testCase struct {
+		name                    string
+		sign                    signer.Sign
+		expectedExtraConditions []cmapi.CertificateRequestCondition
+	}
+
+	tests := []testCase{
+		{
+			name: "no-extra-conditions",
+			sign: func(_ context.Context, _ signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+				return signer.PEMBundle{ChainPEM: []byte("cert")}, nil
+			},
+		},
+		{
+			name: "extra-conditions-on-request",
+			sign: func(ctx context.Context, _ signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+				signer.SetSignResult(ctx, signer.WithExtraConditions(policyCondition))
+				return signer.PEMBundle{ChainPEM: []byte("cert")}, nil
+			},
+			expectedExtraConditions: []cmapi.CertificateRequestCondition{{
+				Type:               "example.com/Policy",
+				Status:             cmmeta.ConditionTrue,
+				Reason:             "PolicyApplied",
+				Message:            "the CA applied the server policy",
+				LastTransitionTime: &fakeTimeObj,
+			}},
+		},
+		{
+			name: "later-extra-condition-replaces-earlier",
+			sign: func(ctx context.Context, _ signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+				signer.SetSignResult(ctx, signer.WithExtraConditions(signer.ExtraCondition{
+					Type:   "example.com/Policy",
+					Status: cmmeta.ConditionFalse,
+					Reason: "NoPolicy",
+				}))
+				signer.SetSignResult(ctx, signer.WithExtraConditions(policyCondition))
+				return signer.PEMBundle{ChainPEM: []byte("cert")}, nil
+			},
+			expectedExtraConditions: []cmapi.CertificateRequestCondition{{
+				Type:               "example.com/Policy",
+				Status:             cmmeta.ConditionTrue,
+				Reason:             "PolicyApplied",
+				Message:            "the CA applied the server policy",
+				LastTransitionTime: &fakeTimeObj,
+			}},
+		},
+		{
+			name: "reserved-extra-conditions-are-ignored",
+			sign: func(ctx context.Context, _ signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+				signer.SetSignResult(ctx, signer.WithExtraConditions(
+					signer.ExtraCondition{Type: cmapi.CertificateRequestConditionDenied, Status: cmmeta.ConditionTrue, Reason: "Denied"},
+					signer.ExtraCondition{Type: cmapi.CertificateRequestConditionReady, Status: cmmeta.ConditionFalse, Reason: "Failed"},
+				))
+				return signer.PEMBundle{ChainPEM: []byte("cert")}, nil
+			},
+		},
+		{
+			name: "extra-conditions-not-set-on-error",
+			sign: func(ctx context.Context, _ signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+				signer.SetSignResult(ctx, signer.WithExtraConditions(policyCondition))
+				return signer.PEMBundle{}, signer.PermanentError{Err: context.Canceled}
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			issuer := testutil.TestIssuer(
+				"issuer-1",
+				testutil.SetTestIssuerNamespace("ns1"),
+				testutil.SetTestIssuerStatusCondition(
+					fakeClock,
+					cmapi.IssuerConditionReady,
+					cmmeta.ConditionTrue,
+					v1alpha1.IssuerConditionReasonChecked,
+					"Succeeded checking the issuer",
+				),
+			)
+
+			cr1 := cmgen.CertificateRequest(
+				"cr1",
+				cmgen.SetCertificateRequestNamespace("ns1"),
+				cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+					Name:  issuer.Name,
+					Group: api.SchemeGroupVersion.Group,
+				}),
+				cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+					Type:   cmapi.CertificateRequestConditionReady,
+					Status: cmmeta.ConditionUnknown,
+					Reason: v1alpha1.CertificateRequestConditionReasonInitializing,
+				}),
+				cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+					Type:   cmapi.CertificateRequestConditionApproved,
+					Status: cmmeta.ConditionTrue,
+				}),
+			)
+
+			scheme := runtime.NewScheme()
+			require.NoError(t, setupCertificateRequestReconcilerScheme(scheme))
+			require.NoError(t, api.AddToScheme(scheme))
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(cr1, issuer).
+				Build()
+
+			logger := logrtesting.NewTestLoggerWithOptions(t, logrtesting.Options{LogTimestamp: true, Verbosity: 10})
+
+			controller := (&CertificateRequestReconciler{
+				RequestController: RequestController{
+					IssuerTypes:      []v1alpha1.Issuer{&api.TestIssuer{}},
+					FieldOwner:       fieldOwner,
+					MaxRetryDuration: time.Minute,
+					EventSource:      kubeutil.NewEventStore(),
+					Client:           fakeClient,
+					Sign:             tc.sign,
+					EventRecorder:    record.NewFakeRecorder(100),
+					Clock:            fakeClock,
+				},
+			}).Init()
+			require.NoError(t, controller.setAllIssuerTypesWithGroupVersionKind(scheme))
+
+			_, statusPatch, _ := controller.reconcileStatusPatch(logger, context.TODO(), reconcile.Request{
+				NamespacedName: client.ObjectKeyFromObject(cr1),
+			})
+
+			var extraConditions []cmapi.CertificateRequestCondition
+			for _, condition := range statusPatch.(CertificateRequestPatch).CertificateRequestPatch().Conditions {
+				switch condition.Type {
+				case cmapi.CertificateRequestConditionReady, cmapi.CertificateRequestConditionApproved:
+					continue
+				}
+				extraConditions = append(extraConditions, condition)
+			}
+			assert.Equal(t, tc.expectedExtraConditions, extraConditions)
+		})
+	}
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/kubeutil"
+)
+
+var failedRequestCacheEntries = kubeutil.CacheEntries.WithLabelValues("failed_requests")
+
+// FailedRequestCache remembers the requests that failed permanently because the
+// CA rejected them for a policy reason (ie. Sign returned a
+// signer.PolicyRejectionError). When cert-manager retries a failed
+// Certificate, it creates a new request with an identical CSR; such requests are
+// failed immediately with the reason of the prior failure, instead of calling the
+// CA again. Requests are identical if they reference the same issuer and have the
+// same CSR contents (public key, subject, extensions and attributes), duration,
+// isCA flag and usages. Requests that failed permanently for another reason, or
+// because they were retried for longer than the MaxRetryDuration, are not
+// remembered.
+type FailedRequestCache struct {
+	// TTL is the duration for which a failed request is remembered, defaults to
+	// 10 minutes. The TTL is not extended by identical requests.
+	TTL time.Duration
+
+	// MaxEntries is the maximum number of remembered requests, defaults to 1000.
+	// When the cache is full, the entry that expires first is evicted.
+	MaxEntries int
+
+	mu      sync.Mutex
+	entries map[failedRequestKey]failedRequest
+}
+
+// failedRequestKey identifies the issuer and the contents of a request.
+type failedRequestKey struct {
+	gvk        schema.GroupVersionKind
+	issuerName types.NamespacedName
+	digest     [sha256.Size]byte
+}
+
+type failedRequest struct {
+	failedAt time.Time
+	expires  time.Time
+	message  string
+}
+
+func (c *FailedRequestCache) ttl() time.Duration {
+	if c.TTL == 0 {
+		return 10 * time.Minute
+	}
+	return c.TTL
+}
+
+func (c *FailedRequestCache) maxEntries() int {
+	if c.MaxEntries == 0 {
+		return 1000
+	}
+	return c.MaxEntries
+}
+
+// key returns the key of the request, or nil if the request can't be parsed or
+// the cache is nil.
+func (c *FailedRequestCache) key(
+	cr signer.CertificateRequestObject,
+	gvk schema.GroupVersionKind,
+	issuerName types.NamespacedName,
+) *failedRequestKey {
+	if c == nil {
+		return nil
+	}
+
+	template, duration, _, err := cr.GetRequest()
+	if err != nil {
+		return nil
+	}
+
+	csr, err := parseRequestCSR(cr)
+	if err != nil {
+		return nil
+	}
+
+	// The signature of a CSR is randomized for some key types, so only the signed
+	// contents of the CSR are compared.
+	hash := sha256.New()
+	hash.Write(csr.RawTBSCertificateRequest)
+	_ = binary.Write(hash, binary.BigEndian, int64(duration))
+	_ = binary.Write(hash, binary.BigEndian, template.BasicConstraintsValid && template.IsCA)
+	_ = binary.Write(hash, binary.BigEndian, int64(template.KeyUsage))
+	for _, extKeyUsage := range template.ExtKeyUsage {
+		_ = binary.Write(hash, binary.BigEndian, int64(extKeyUsage))
+	}
+
+	key := &failedRequestKey{gvk: gvk, issuerName: issuerName}
+	hash.Sum(key.digest[:0])
+	return key
+}
+
+// check returns a PermanentError with the reason of the prior failure if an
+// identical request failed recently.
+func (c *FailedRequestCache) check(key *failedRequestKey, now time.Time) error {
+	if c == nil || key == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, found := c.entries[*key]
+	if !found || !now.Before(entry.expires) {
+		return nil
+	}
+
+	return signer.PermanentError{
+		Err: fmt.Errorf("an identical request failed permanently at %s: %s", entry.failedAt.UTC().Format(time.RFC3339), entry.message),
+	}
+}
+
+// record remembers that the request failed permanently, unless the error is not
+// a signer.PolicyRejectionError or an identical request is already remembered.
+func (c *FailedRequestCache) record(key *failedRequestKey, err error, now time.Time) {
+	if c == nil || key == nil || !errors.As(err, &signer.PolicyRejectionError{}) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = map[failedRequestKey]failedRequest{}
+	}
+
+	if entry, found := c.entries[*key]; found && now.Before(entry.expires) {
+		return
+	}
+
+	for existingKey, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, existingKey)
+			failedRequestCacheEntries.Dec()
+		}
+	}
+
+	for len(c.entries) >= c.maxEntries() {
+		var firstKey failedRequestKey
+		var first *failedRequest
+		for existingKey, entry := range c.entries {
+			if first == nil || entry.expires.Before(first.expires) {
+				firstKey, first = existingKey, &entry
+			}
+		}
+		delete(c.entries, firstKey)
+		failedRequestCacheEntries.Dec()
+	}
+
+	c.entries[*key] = failedRequest{
+		failedAt: now,
+		expires:  now.Add(c.ttl()),
+		message:  err.Error(),
+	}
+	failedRequestCacheEntries.Inc()
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmgen "github.com/cert-manager/cert-manager/test/unit/gen"
+	prometheustestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/kubeutil"
+)
+
+func testFailedRequest(t *testing.T, name string, key crypto.Signer, duration time.Duration) signer.CertificateRequestObject {
+	t.Helper()
+
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: "example.com"},
+		DNSNames: []string{"example.com"},
+	}, key)
+	require.NoError(t, err)
+
+	return signer.CertificateRequestObjectFromCertificateRequest(cmgen.CertificateRequest(
+		name,
+		cmgen.SetCertificateRequestNamespace("ns1"),
+		cmgen.SetCertificateRequestCSR(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER})),
+		cmgen.SetCertificateRequestDuration(&metav1.Duration{Duration: duration}),
+		cmgen.SetCertificateRequestKeyUsages(cmapi.UsageDigitalSignature),
+	))
+}
+
+func TestFailedRequestCache(t *testing.T) {
+	t.Parallel()
+
+	now := randomTime()
+	issuerGvk := schema.GroupVersionKind{Group: "testing.cert-manager.io", Version: "api", Kind: "TestIssuer"}
+	issuerName := types.NamespacedName{Namespace: "ns1", Name: "issuer-1"}
+	otherIssuerName := types.NamespacedName{Namespace: "ns1", Name: "issuer-2"}
+
+	key1, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	key2, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	failed := testFailedRequest(t, "cr1", key1, time.Hour)
+	retried := testFailedRequest(t, "cr2", key1, time.Hour)
+	otherKey := testFailedRequest(t, "cr3", key2, time.Hour)
+	otherDuration := testFailedRequest(t, "cr4", key1, 2*time.Hour)
+
+	cache := &FailedRequestCache{TTL: time.Minute}
+	failedKey := cache.key(failed, issuerGvk, issuerName)
+	require.NotNil(t, failedKey)
+
+	require.NoError(t, cache.check(failedKey, now))
+	cache.record(failedKey, signer.PermanentError{Err: signer.PolicyRejectionError{Err: errors.New("CN not allowed by policy")}}, now)
+
+	// A retry with an identical CSR for the same issuer fails with the prior
+	// reason, even though the signature of the CSR differs.
+	err = cache.check(cache.key(retried, issuerGvk, issuerName), now.Add(30*time.Second))
+	require.ErrorAs(t, err, &signer.PermanentError{})
+	assert.ErrorContains(t, err, "an identical request failed permanently at "+now.UTC().Format(time.RFC3339)+": CN not allowed by policy")
+
+	// Requests with another key, duration or issuer are not identical.
+	assert.NoError(t, cache.check(cache.key(otherKey, issuerGvk, issuerName), now))
+	assert.NoError(t, cache.check(cache.key(otherDuration, issuerGvk, issuerName), now))
+	assert.NoError(t, cache.check(cache.key(retried, issuerGvk, otherIssuerName), now))
+
+	// Recording an identical failure does not extend the TTL.
+	cache.record(failedKey, signer.PolicyRejectionError{Err: errors.New("other reason")}, now.Add(30*time.Second))
+	assert.ErrorContains(t, cache.check(failedKey, now.Add(59*time.Second)), "CN not allowed by policy")
+	assert.NoError(t, cache.check(failedKey, now.Add(time.Minute)))
+
+	// Requests with an invalid CSR and a nil cache are never remembered.
+	invalid := signer.CertificateRequestObjectFromCertificateRequest(cmgen.CertificateRequest("invalid", cmgen.SetCertificateRequestCSR([]byte("invalid"))))
+	assert.Nil(t, cache.key(invalid, issuerGvk, issuerName))
+	var disabled *FailedRequestCache
+	assert.Nil(t, disabled.key(failed, issuerGvk, issuerName))
+	assert.NoError(t, disabled.check(failedKey, now))
+}
+
+func TestFailedRequestCacheOnlyPolicyRejections(t *testing.T) {
+	t.Parallel()
+
+	now := randomTime()
+	issuerGvk := schema.GroupVersionKind{Group: "testing.cert-manager.io", Version: "api", Kind: "TestIssuer"}
+	issuerName := types.NamespacedName{Namespace: "ns1", Name: "issuer-1"}
+
+	type testCase struct {
+		name           string
+		err            error
+		expectedCached bool
+	}
+
+	tests := []testCase{
+		{
+			name:           "permanent-policy-rejection",
+			err:            signer.PermanentError{Err: signer.PolicyRejectionError{Err: errors.New("CN not allowed by policy")}},
+			expectedCached: true,
+		},
+		{
+			name:           "wrapped-policy-rejection",
+			err:            fmt.Errorf("wrapped: %w", signer.PermanentError{Err: signer.PolicyRejectionError{Err: errors.New("CN not allowed by policy")}}),
+			expectedCached: true,
+		},
+		{
+			name:           "permanent",
+			err:            signer.PermanentError{Err: errors.New("invalid CSR")},
+			expectedCached: false,
+		},
+		{
+			name:           "permanent-ca-error",
+			err:            signer.PermanentError{Err: signer.WrapCAError(errors.New("bad request"), 400, nil, "")},
+			expectedCached: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			require.NoError(t, err)
+
+			cache := &FailedRequestCache{TTL: time.Minute}
+			failedKey := cache.key(testFailedRequest(t, "cr1", key, time.Hour), issuerGvk, issuerName)
+			require.NotNil(t, failedKey)
+
+			cache.record(failedKey, tc.err, now)
+			if tc.expectedCached {
+				assert.ErrorContains(t, cache.check(failedKey, now), "CN not allowed by policy")
+			} else {
+				assert.NoError(t, cache.check(failedKey, now))
+			}
+		})
+	}
+}
+
+func TestFailedRequestCacheMaxEntries(t *testing.T) {
+	t.Parallel()
+
+	now := randomTime()
+	issuerGvk := schema.GroupVersionKind{Group: "testing.cert-manager.io", Version: "api", Kind: "TestIssuer"}
+
+	cache := &FailedRequestCache{TTL: time.Minute, MaxEntries: 2}
+	keys := make([]*failedRequestKey, 3)
+	for i := range keys {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		keys[i] = cache.key(testFailedRequest(t, "cr", key, time.Hour), issuerGvk, types.NamespacedName{Name: "issuer-1"})
+		cache.record(keys[i], signer.PolicyRejectionError{Err: errors.New("failed")}, now.Add(time.Duration(i)*time.Second))
+	}
+
+	// The entry that expires first is evicted.
+	assert.NoError(t, cache.check(keys[0], now.Add(3*time.Second)))
+	assert.Error(t, cache.check(keys[1], now.Add(3*time.Second)))
+	assert.Error(t, cache.check(keys[2], now.Add(3*time.Second)))
+}
+
+// TestCacheEntriesMetric is not run in parallel, because the caches of the other
+// tests update the same gauge.
+func TestCacheEntriesMetric(t *testing.T) {
+	cacheEntries := func(cache string) float64 {
+		return prometheustestutil.ToFloat64(kubeutil.CacheEntries.WithLabelValues(cache))
+	}
+
+	now := randomTime()
+	issuerGvk := schema.GroupVersionKind{Group: "testing.cert-manager.io", Version: "api", Kind: "TestIssuer"}
+	issuerName := types.NamespacedName{Name: "issuer-1"}
+
+	failedRequests := cacheEntries("failed_requests")
+	failedRequestCache := &FailedRequestCache{TTL: time.Minute}
+	for i := 0; i < 2; i++ {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		failedRequestCache.record(failedRequestCache.key(testFailedRequest(t, "cr", key, time.Hour), issuerGvk, issuerName), signer.PolicyRejectionError{Err: errors.New("failed")}, now)
+	}
+	assert.Equal(t, failedRequests+2, cacheEntries("failed_requests"))
+
+	issuerNotReady := cacheEntries("issuer_not_ready")
+	issuerNotReadyCache := &IssuerNotReadyCache{TTL: time.Minute}
+	issuerNotReadyCache.setWaiting(issuerGvk, issuerName, types.NamespacedName{Name: "cr1"}, now)
+	issuerNotReadyCache.setWaiting(issuerGvk, issuerName, types.NamespacedName{Name: "cr2"}, now)
+	assert.Equal(t, issuerNotReady+2, cacheEntries("issuer_not_ready"))
+	issuerNotReadyCache.invalidate(issuerGvk, issuerName)
+	assert.Equal(t, issuerNotReady, cacheEntries("issuer_not_ready"))
+
+	reportedErrors := cacheEntries("reported_errors")
+	eventSource := kubeutil.NewEventStore()
+	require.NoError(t, eventSource.AddConsumer(issuerGvk).Start(context.TODO(), workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())))
+	require.NoError(t, eventSource.ReportError(issuerGvk, issuerName, errors.New("failed")))
+	assert.Equal(t, reportedErrors+1, cacheEntries("reported_errors"))
+	require.Error(t, eventSource.HasReportedError(issuerGvk, issuerName))
+	assert.Equal(t, reportedErrors, cacheEntries("reported_errors"))
+}
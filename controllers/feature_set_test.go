@@ -0,0 +1,188 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"net"
+	"testing"
+	"time"
+
+	cmgen "github.com/cert-manager/cert-manager/test/unit/gen"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+	"github.com/cert-manager/issuer-lib/internal/tests/errormatch"
+)
+
+// featureSetIssuer is a TestIssuer that declares its supported features.
+type featureSetIssuer struct {
+	*api.TestIssuer
+	featureSet signer.FeatureSet
+}
+
+var _ signer.FeatureSetProvider = featureSetIssuer{}
+
+func (i featureSetIssuer) FeatureSet() signer.FeatureSet {
+	return i.featureSet
+}
+
+func testCSR(t *testing.T, key crypto.Signer, template *x509.CertificateRequest) []byte {
+	t.Helper()
+
+	if key == nil {
+		var err error
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+	}
+
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER})
+}
+
+func TestCheckFeatures(t *testing.T) {
+	t.Parallel()
+
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	basicCSR := testCSR(t, nil, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: "example.com", Organization: []string{"Example"}},
+		DNSNames: []string{"example.com"},
+	})
+	ed25519CSR := testCSR(t, ed25519Key, &x509.CertificateRequest{
+		DNSNames: []string{"example.com"},
+	})
+	ipCSR := testCSR(t, nil, &x509.CertificateRequest{
+		IPAddresses: []net.IP{net.ParseIP("10.0.0.1")},
+	})
+	literalSubjectCSR := testCSR(t, nil, &x509.CertificateRequest{
+		Subject: pkix.Name{
+			CommonName: "example.com",
+			ExtraNames: []pkix.AttributeTypeAndValue{
+				{Type: asn1.ObjectIdentifier{0, 9, 2342, 19200300, 100, 1, 1}, Value: "user1"},
+			},
+		},
+	})
+
+	allFeatures := signer.FeatureSet{
+		Features: []signer.Feature{
+			signer.FeatureEd25519,
+			signer.FeatureIPSANs,
+			signer.FeatureLiteralSubject,
+			signer.FeatureIsCA,
+		},
+	}
+
+	type testCase struct {
+		name          string
+		issuer        v1alpha1.Issuer
+		csr           []byte
+		modifiers     []cmgen.CertificateRequestModifier
+		validateError *errormatch.Matcher
+	}
+
+	tests := []testCase{
+		{
+			name:          "issuer-without-feature-set",
+			issuer:        &api.TestIssuer{},
+			csr:           ed25519CSR,
+			validateError: errormatch.NoError(),
+		},
+		{
+			name:          "no-optional-features",
+			issuer:        featureSetIssuer{TestIssuer: &api.TestIssuer{}},
+			csr:           basicCSR,
+			validateError: errormatch.NoError(),
+		},
+		{
+			name:          "all-features-supported",
+			issuer:        featureSetIssuer{TestIssuer: &api.TestIssuer{}, featureSet: allFeatures},
+			csr:           literalSubjectCSR,
+			modifiers:     []cmgen.CertificateRequestModifier{cmgen.SetCertificateRequestIsCA(true)},
+			validateError: errormatch.NoError(),
+		},
+		{
+			name:          "ed25519-unsupported",
+			issuer:        featureSetIssuer{TestIssuer: &api.TestIssuer{}},
+			csr:           ed25519CSR,
+			validateError: errormatch.ErrorContains("the request uses features that the issuer does not support: Ed25519 keys are not supported"),
+		},
+		{
+			name:          "ip-sans-unsupported",
+			issuer:        featureSetIssuer{TestIssuer: &api.TestIssuer{}},
+			csr:           ipCSR,
+			validateError: errormatch.ErrorContains("IP address SANs are not supported"),
+		},
+		{
+			name:          "literal-subject-unsupported",
+			issuer:        featureSetIssuer{TestIssuer: &api.TestIssuer{}},
+			csr:           literalSubjectCSR,
+			validateError: errormatch.ErrorContains("the subject attribute 0.9.2342.19200300.100.1.1 is not supported"),
+		},
+		{
+			name:          "is-ca-unsupported",
+			issuer:        featureSetIssuer{TestIssuer: &api.TestIssuer{}},
+			csr:           basicCSR,
+			modifiers:     []cmgen.CertificateRequestModifier{cmgen.SetCertificateRequestIsCA(true)},
+			validateError: errormatch.ErrorContains("CA certificates are not supported"),
+		},
+		{
+			name:   "max-duration-exceeded",
+			issuer: featureSetIssuer{TestIssuer: &api.TestIssuer{}, featureSet: signer.FeatureSet{MaxDuration: 24 * time.Hour}},
+			csr:    basicCSR,
+			modifiers: []cmgen.CertificateRequestModifier{
+				cmgen.SetCertificateRequestDuration(&metav1.Duration{Duration: 48 * time.Hour}),
+			},
+			validateError: errormatch.ErrorContains("the requested duration 48h0m0s exceeds the maximum duration 24h0m0s"),
+		},
+		{
+			name:          "multiple-unsupported-features",
+			issuer:        featureSetIssuer{TestIssuer: &api.TestIssuer{}},
+			csr:           ipCSR,
+			modifiers:     []cmgen.CertificateRequestModifier{cmgen.SetCertificateRequestIsCA(true)},
+			validateError: errormatch.ErrorContains("IP address SANs are not supported, remove the IP addresses from the request; CA certificates are not supported"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cr := cmgen.CertificateRequest("cr1", append([]cmgen.CertificateRequestModifier{cmgen.SetCertificateRequestCSR(tc.csr)}, tc.modifiers...)...)
+			err := checkFeatures(signer.CertificateRequestObjectFromCertificateRequest(cr), tc.issuer)
+			(*tc.validateError)(t, err)
+
+			if err != nil {
+				require.ErrorAs(t, err, &signer.PermanentError{})
+			}
+		})
+	}
+}
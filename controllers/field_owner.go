@@ -0,0 +1,203 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+)
+
+const eventFieldOwnerConflict = "FieldOwnerConflict"
+
+// FieldOwnerFunc returns the field owner that is used to apply the status of the
+// issuer with the given type and name, and the status of the requests that
+// reference that issuer. An empty string selects the FieldOwner of the controller.
+//
+// For cluster-scoped issuers, the namespace of the issuer name is empty.
+type FieldOwnerFunc func(issuerGvk schema.GroupVersionKind, issuerName types.NamespacedName) string
+
+// fieldOwnerFor returns the field owner for the issuer, falling back to the
+// default field owner if fieldOwnerForIssuer is nil or returns an empty string.
+func fieldOwnerFor(
+	fieldOwner string,
+	fieldOwnerForIssuer FieldOwnerFunc,
+	issuerGvk schema.GroupVersionKind,
+	issuerName types.NamespacedName,
+) string {
+	if fieldOwnerForIssuer == nil {
+		return fieldOwner
+	}
+
+	if owner := fieldOwnerForIssuer(issuerGvk, issuerName); owner != "" {
+		return owner
+	}
+	return fieldOwner
+}
+
+// fieldOwnerPatch is implemented by the RequestPatch implementations to expose
+// the field owner that the patch was created for.
+type fieldOwnerPatch interface {
+	patchFieldOwner() string
+}
+
+// fieldOwnerConflict describes a status that was recently applied by another
+// field manager.
+type fieldOwnerConflict struct {
+	manager       string
+	conditionType string
+	appliedAt     time.Time
+	// remaining is the time after which the conflict expires.
+	remaining time.Duration
+}
+
+func (c *fieldOwnerConflict) message(fieldOwner string) string {
+	return fmt.Sprintf(
+		"The %s condition was applied by field manager %q at %s, not applying the status as %q to avoid overwriting it. "+
+			"Make sure that each issuer is managed by a single controller.",
+		c.conditionType, c.manager, c.appliedAt.UTC().Format(time.RFC3339), fieldOwner,
+	)
+}
+
+// detectFieldOwnerConflict returns a conflict if one of the conditions was
+// applied by a field manager other than fieldOwner (or its diagnostics field
+// owner) within the window. This indicates that multiple controllers are
+// managing the same resource, which would otherwise keep overwriting each
+// other's status. Returns nil if window is not positive.
+func detectFieldOwnerConflict(
+	obj client.Object,
+	conditionTypes []string,
+	fieldOwner string,
+	now time.Time,
+	window time.Duration,
+) *fieldOwnerConflict {
+	if window <= 0 || obj == nil {
+		return nil
+	}
+
+	var conflict *fieldOwnerConflict
+	for _, entry := range obj.GetManagedFields() {
+		if entry.Manager == fieldOwner || entry.Manager == statusPatchDiagnosticsFieldOwner(fieldOwner) {
+			continue
+		}
+
+		appliedAt := ptr.Deref(entry.Time, metav1.Time{}).Time
+		remaining := appliedAt.Add(window).Sub(now)
+		if remaining <= 0 {
+			continue
+		}
+
+		for _, conditionType := range conditionTypes {
+			if !managesCondition(entry, conditionType) {
+				continue
+			}
+
+			// Report the most recent conflict, it expires last.
+			if conflict == nil || conflict.remaining < remaining {
+				conflict = &fieldOwnerConflict{
+					manager:       entry.Manager,
+					conditionType: conditionType,
+					appliedAt:     appliedAt,
+					remaining:     remaining,
+				}
+			}
+			break
+		}
+	}
+
+	return conflict
+}
+
+// requestConditionTypes returns the types of the status conditions of the
+// request, except for the approval conditions that are set by the approvers.
+func requestConditionTypes(request client.Object) []string {
+	var conditionTypes []string
+	switch request := request.(type) {
+	case *cmapi.CertificateRequest:
+		for _, condition := range request.Status.Conditions {
+			if condition.Type == cmapi.CertificateRequestConditionApproved ||
+				condition.Type == cmapi.CertificateRequestConditionDenied {
+				continue
+			}
+			conditionTypes = append(conditionTypes, string(condition.Type))
+		}
+	case *certificatesv1.CertificateSigningRequest:
+		for _, condition := range request.Status.Conditions {
+			if condition.Type == certificatesv1.CertificateApproved ||
+				condition.Type == certificatesv1.CertificateDenied {
+				continue
+			}
+			conditionTypes = append(conditionTypes, string(condition.Type))
+		}
+	}
+	return conditionTypes
+}
+
+// issuerConditionTypes returns the types of the status conditions of the issuer.
+func issuerConditionTypes(issuer v1alpha1.Issuer) []string {
+	conditionTypes := make([]string, 0, len(issuer.GetStatus().Conditions))
+	for _, condition := range issuer.GetStatus().Conditions {
+		conditionTypes = append(conditionTypes, string(condition.Type))
+	}
+	return conditionTypes
+}
+
+// recordFieldOwnerConflict records a FieldOwnerConflict event on the object and
+// counts the conflict.
+func recordFieldOwnerConflict(
+	eventRecorder record.EventRecorder,
+	obj client.Object,
+	kind string,
+	fieldOwner string,
+	conflict *fieldOwnerConflict,
+) {
+	fieldOwnerConflicts.WithLabelValues(kind, conflict.manager).Inc()
+	eventRecorder.Event(obj, corev1.EventTypeWarning, eventFieldOwnerConflict, conflict.message(fieldOwner))
+}
+
+// managesCondition returns true if the managed fields entry contains the status
+// condition with the given type. This relies on the conditions being a list map
+// keyed by type, which is the case for all resources managed by issuer-lib.
+func managesCondition(entry metav1.ManagedFieldsEntry, conditionType string) bool {
+	if entry.FieldsV1 == nil {
+		return false
+	}
+
+	var fields struct {
+		Status struct {
+			Conditions map[string]json.RawMessage `json:"f:conditions"`
+		} `json:"f:status"`
+	}
+	if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+		return false
+	}
+
+	_, ok := fields.Status.Conditions[fmt.Sprintf("k:{\"type\":%q}", conditionType)]
+	return ok
+}
@@ -0,0 +1,356 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	cmgen "github.com/cert-manager/cert-manager/test/unit/gen"
+	prometheustestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/conditions"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/kubeutil"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+	"github.com/cert-manager/issuer-lib/internal/testapi/testutil"
+	"github.com/cert-manager/issuer-lib/testing/ssafake"
+)
+
+// conditionManagedFields returns a managed fields entry of the field manager
+// that owns the status conditions with the given types.
+func conditionManagedFields(manager string, appliedAt time.Time, conditionTypes ...string) metav1.ManagedFieldsEntry {
+	conditionFields := ""
+	for i, conditionType := range conditionTypes {
+		if i > 0 {
+			conditionFields += ","
+		}
+		conditionFields += fmt.Sprintf("%q:{}", fmt.Sprintf("k:{\"type\":%q}", conditionType))
+	}
+
+	return metav1.ManagedFieldsEntry{
+		Manager:     manager,
+		Operation:   metav1.ManagedFieldsOperationApply,
+		Subresource: "status",
+		Time:        &metav1.Time{Time: appliedAt},
+		FieldsType:  "FieldsV1",
+		FieldsV1: &metav1.FieldsV1{
+			Raw: []byte(fmt.Sprintf("{\"f:status\":{\"f:conditions\":{%s}}}", conditionFields)),
+		},
+	}
+}
+
+func TestFieldOwnerFor(t *testing.T) {
+	t.Parallel()
+
+	gvk := api.SchemeGroupVersion.WithKind("TestIssuer")
+	issuerName := types.NamespacedName{Namespace: "ns1", Name: "issuer-1"}
+
+	assert.Equal(t, "default", fieldOwnerFor("default", nil, gvk, issuerName))
+	assert.Equal(t, "default", fieldOwnerFor("default", func(schema.GroupVersionKind, types.NamespacedName) string {
+		return ""
+	}, gvk, issuerName))
+	assert.Equal(t, "new-controller/issuer-1", fieldOwnerFor("default", func(issuerGvk schema.GroupVersionKind, issuerName types.NamespacedName) string {
+		assert.Equal(t, gvk, issuerGvk)
+		return "new-controller/" + issuerName.Name
+	}, gvk, issuerName))
+}
+
+func TestDetectFieldOwnerConflict(t *testing.T) {
+	t.Parallel()
+
+	now := randomTime().Truncate(time.Second)
+	const window = 5 * time.Minute
+
+	tests := []struct {
+		name           string
+		managedFields  []metav1.ManagedFieldsEntry
+		window         time.Duration
+		expectedResult *fieldOwnerConflict
+	}{
+		{
+			name: "disabled",
+			managedFields: []metav1.ManagedFieldsEntry{
+				conditionManagedFields("old-controller", now.Add(-time.Minute), "Ready"),
+			},
+		},
+		{
+			name:          "no-managed-fields",
+			window:        window,
+			managedFields: nil,
+		},
+		{
+			name:   "own-field-manager",
+			window: window,
+			managedFields: []metav1.ManagedFieldsEntry{
+				conditionManagedFields("new-controller", now.Add(-time.Minute), "Ready"),
+				conditionManagedFields("new-controller-diagnostics", now.Add(-time.Minute), "Ready"),
+			},
+		},
+		{
+			name:   "other-condition",
+			window: window,
+			managedFields: []metav1.ManagedFieldsEntry{
+				conditionManagedFields("cert-manager-approver", now.Add(-time.Minute), "Approved"),
+			},
+		},
+		{
+			name:   "outside-window",
+			window: window,
+			managedFields: []metav1.ManagedFieldsEntry{
+				conditionManagedFields("old-controller", now.Add(-window), "Ready"),
+			},
+		},
+		{
+			name:   "within-window",
+			window: window,
+			managedFields: []metav1.ManagedFieldsEntry{
+				conditionManagedFields("cert-manager-approver", now.Add(-time.Minute), "Approved"),
+				conditionManagedFields("old-controller", now.Add(-3*time.Minute), "Ready"),
+				conditionManagedFields("other-controller", now.Add(-2*time.Minute), "Approved", "Ready"),
+			},
+			expectedResult: &fieldOwnerConflict{
+				manager:       "other-controller",
+				conditionType: "Ready",
+				appliedAt:     now.Add(-2 * time.Minute),
+				remaining:     3 * time.Minute,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cr := cmgen.CertificateRequest("cr1")
+			cr.ManagedFields = tc.managedFields
+
+			conflict := detectFieldOwnerConflict(cr, []string{"Ready"}, "new-controller", now, tc.window)
+			if conflict != nil {
+				conflict.appliedAt = conflict.appliedAt.In(now.Location())
+			}
+			assert.Equal(t, tc.expectedResult, conflict)
+		})
+	}
+}
+
+func TestRequestControllerFieldOwnerConflict(t *testing.T) {
+	t.Parallel()
+
+	fakeClock := clocktesting.NewFakeClock(randomTime().Truncate(time.Second))
+
+	issuer := testutil.TestIssuer(
+		"issuer-1",
+		testutil.SetTestIssuerNamespace("ns1"),
+		testutil.SetTestIssuerStatusCondition(
+			fakeClock,
+			cmapi.IssuerConditionReady,
+			cmmeta.ConditionTrue,
+			v1alpha1.IssuerConditionReasonChecked,
+			"Succeeded checking the issuer",
+		),
+	)
+
+	cr1 := cmgen.CertificateRequest(
+		"cr-field-owner-conflict",
+		cmgen.SetCertificateRequestNamespace("ns1"),
+		cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+			Name:  issuer.Name,
+			Group: api.SchemeGroupVersion.Group,
+		}),
+		cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+			Type:   cmapi.CertificateRequestConditionReady,
+			Status: cmmeta.ConditionFalse,
+			Reason: cmapi.CertificateRequestReasonPending,
+		}),
+		cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+			Type:   cmapi.CertificateRequestConditionApproved,
+			Status: cmmeta.ConditionTrue,
+		}),
+	)
+	cr1.ManagedFields = []metav1.ManagedFieldsEntry{
+		conditionManagedFields("old-controller", fakeClock.Now().Add(-time.Minute), string(cmapi.CertificateRequestConditionReady)),
+	}
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, setupCertificateRequestReconcilerScheme(scheme))
+	require.NoError(t, api.AddToScheme(scheme))
+
+	var fieldManagers []string
+	applierFuncs := ssafake.NewApplier().InterceptorFuncs()
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(cr1, issuer).
+		WithStatusSubresource(cr1).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Patch: applierFuncs.Patch,
+			SubResourcePatch: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+				options := (&client.SubResourcePatchOptions{}).ApplyOptions(opts)
+				fieldManagers = append(fieldManagers, options.FieldManager)
+				return applierFuncs.SubResourcePatch(ctx, c, subResourceName, obj, patch, opts...)
+			},
+		}).
+		Build()
+
+	fakeRecorder := record.NewFakeRecorder(100)
+	controller := (&CertificateRequestReconciler{
+		RequestController: RequestController{
+			IssuerTypes: []v1alpha1.Issuer{&api.TestIssuer{}},
+			FieldOwner:  "new-controller",
+			FieldOwnerForIssuer: func(_ schema.GroupVersionKind, issuerName types.NamespacedName) string {
+				return "new-controller-" + issuerName.Name
+			},
+			FieldOwnerConflictWindow: 5 * time.Minute,
+			MaxRetryDuration:         time.Hour,
+			EventSource:              kubeutil.NewEventStore(),
+			Client:                   fakeClient,
+			Sign: func(_ context.Context, _ signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+				return signer.PEMBundle{ChainPEM: []byte("cert")}, nil
+			},
+			EventRecorder: fakeRecorder,
+			Clock:         fakeClock,
+		},
+	}).Init()
+	require.NoError(t, controller.setAllIssuerTypesWithGroupVersionKind(scheme))
+
+	request := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cr1)}
+
+	metric := fieldOwnerConflicts.WithLabelValues("CertificateRequest", "old-controller")
+	initialConflicts := prometheustestutil.ToFloat64(metric)
+
+	// The Ready condition was applied by the old controller a minute ago, so
+	// the status is not applied until the window has passed.
+	result, err := controller.Reconcile(context.TODO(), request)
+	require.NoError(t, err)
+	assert.Equal(t, reconcile.Result{RequeueAfter: 4 * time.Minute}, result)
+	assert.Empty(t, fieldManagers)
+	assert.Equal(t, initialConflicts+1, prometheustestutil.ToFloat64(metric))
+
+	events := chanToSlice(fakeRecorder.Events)
+	require.Len(t, events, 1)
+	assert.Contains(t, events[0], "Warning FieldOwnerConflict The Ready condition was applied by field manager \"old-controller\"")
+
+	// Once the window has passed, the status is applied using the field owner
+	// of the issuer.
+	fakeClock.Step(4 * time.Minute)
+	_, err = controller.Reconcile(context.TODO(), request)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"new-controller-issuer-1"}, fieldManagers)
+
+	var cr cmapi.CertificateRequest
+	require.NoError(t, fakeClient.Get(context.TODO(), request.NamespacedName, &cr))
+	assert.Equal(t, []byte("cert"), cr.Status.Certificate)
+}
+
+func TestIssuerReconcilerFieldOwnerConflict(t *testing.T) {
+	t.Parallel()
+
+	fakeClock := clocktesting.NewFakeClock(randomTime().Truncate(time.Second))
+
+	issuer := testutil.TestIssuer(
+		"issuer-1",
+		testutil.SetTestIssuerNamespace("ns1"),
+		testutil.SetTestIssuerStatusCondition(
+			fakeClock,
+			cmapi.IssuerConditionReady,
+			cmmeta.ConditionFalse,
+			v1alpha1.IssuerConditionReasonPending,
+			"Checked by the old controller",
+		),
+	)
+	issuer.ManagedFields = []metav1.ManagedFieldsEntry{
+		conditionManagedFields("old-controller", fakeClock.Now().Add(-time.Minute), string(cmapi.IssuerConditionReady)),
+	}
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, api.AddToScheme(scheme))
+
+	var fieldManagers []string
+	applierFuncs := ssafake.NewApplier().InterceptorFuncs()
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(issuer).
+		WithStatusSubresource(issuer).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Patch: applierFuncs.Patch,
+			SubResourcePatch: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+				options := (&client.SubResourcePatchOptions{}).ApplyOptions(opts)
+				fieldManagers = append(fieldManagers, options.FieldManager)
+				return applierFuncs.SubResourcePatch(ctx, c, subResourceName, obj, patch, opts...)
+			},
+		}).
+		Build()
+
+	forObject := &api.TestIssuer{}
+	require.NoError(t, kubeutil.SetGroupVersionKind(scheme, forObject))
+
+	fakeRecorder := record.NewFakeRecorder(100)
+	controller := IssuerReconciler{
+		ForObject:  forObject,
+		FieldOwner: "new-controller",
+		FieldOwnerForIssuer: func(issuerGvk schema.GroupVersionKind, issuerName types.NamespacedName) string {
+			return "new-controller-" + issuerGvk.Kind + "-" + issuerName.Name
+		},
+		FieldOwnerConflictWindow: 5 * time.Minute,
+		EventSource:              kubeutil.NewEventStore(),
+		Client:                   fakeClient,
+		Check: func(_ context.Context, _ v1alpha1.Issuer) error {
+			return nil
+		},
+		EventRecorder: fakeRecorder,
+		Clock:         fakeClock,
+	}
+
+	request := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(issuer)}
+
+	result, err := controller.Reconcile(context.TODO(), request)
+	require.NoError(t, err)
+	assert.Equal(t, reconcile.Result{RequeueAfter: 4 * time.Minute}, result)
+	assert.Empty(t, fieldManagers)
+
+	events := chanToSlice(fakeRecorder.Events)
+	require.Len(t, events, 1)
+	assert.Contains(t, events[0], "Warning FieldOwnerConflict")
+
+	fakeClock.Step(4 * time.Minute)
+	_, err = controller.Reconcile(context.TODO(), request)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"new-controller-TestIssuer-issuer-1"}, fieldManagers)
+
+	var current api.TestIssuer
+	require.NoError(t, fakeClient.Get(context.TODO(), request.NamespacedName, &current))
+	readyCondition := conditions.GetIssuerStatusCondition(current.Status.Conditions, cmapi.IssuerConditionReady)
+	require.NotNil(t, readyCondition)
+	assert.Equal(t, cmmeta.ConditionTrue, readyCondition.Status)
+}
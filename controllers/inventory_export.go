@@ -0,0 +1,76 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cert-manager/cert-manager/pkg/util/pki"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/inventory"
+)
+
+// exportCertificate passes the metadata of the signed certificate to the
+// InventoryExporter, if it is set.
+func (r *RequestController) exportCertificate(
+	ctx context.Context,
+	requestObject client.Object,
+	issuerGvk schema.GroupVersionKind,
+	issuerName types.NamespacedName,
+	bundle signer.PEMBundle,
+) error {
+	if r.InventoryExporter == nil {
+		return nil
+	}
+
+	kind := requestKind(requestObject)
+	err := func() error {
+		certs, err := pki.DecodeX509CertificateChainBytes(bundle.ChainPEM)
+		if err != nil {
+			return fmt.Errorf("failed to decode the signed certificate: %w", err)
+		}
+		if len(certs) == 0 {
+			return errors.New("the signed certificate chain is empty")
+		}
+
+		metadata := inventory.MetadataFromCertificate(certs[0])
+		metadata.RequestKind = kind
+		metadata.Namespace = requestObject.GetNamespace()
+		metadata.Name = requestObject.GetName()
+		metadata.UID = string(requestObject.GetUID())
+		metadata.IssuerRef = inventory.IssuerRef{
+			Group:     issuerGvk.Group,
+			Kind:      issuerGvk.Kind,
+			Namespace: issuerName.Namespace,
+			Name:      issuerName.Name,
+		}
+
+		return r.InventoryExporter.Export(ctx, metadata)
+	}()
+	if err != nil {
+		inventoryExports.WithLabelValues(kind, "failed").Inc()
+		return err
+	}
+	inventoryExports.WithLabelValues(kind, "succeeded").Inc()
+	return nil
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	cmgen "github.com/cert-manager/cert-manager/test/unit/gen"
+	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/kubeutil"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+	"github.com/cert-manager/issuer-lib/internal/testapi/testutil"
+	"github.com/cert-manager/issuer-lib/inventory"
+)
+
+func TestCertificateRequestReconcilerInventoryExport(t *testing.T) {
+	t.Parallel()
+
+	fieldOwner := "test-certificate-request-reconciler-inventory-export"
+
+	fakeClock := clocktesting.NewFakeClock(randomTime())
+
+	certPEM := createTestCertificatePEM(t, x509.KeyUsageDigitalSignature, nil)
+
+	signSucceeds := func(_ context.Context, _ signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+		return signer.PEMBundle{ChainPEM: certPEM}, nil
+	}
+
+	type testCase struct {
+		name           string
+		sign           signer.Sign
+		exportErr      error
+		expectedExport bool
+		expectedReason string
+	}
+
+	tests := []testCase{
+		{
+			name:           "exported",
+			sign:           signSucceeds,
+			expectedExport: true,
+			expectedReason: cmapi.CertificateRequestReasonIssued,
+		},
+		{
+			name:           "export-error-does-not-block-issuance",
+			sign:           signSucceeds,
+			exportErr:      errors.New("inventory unavailable"),
+			expectedExport: true,
+			expectedReason: cmapi.CertificateRequestReasonIssued,
+		},
+		{
+			name: "not-exported-on-sign-error",
+			sign: func(_ context.Context, _ signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+				return signer.PEMBundle{}, signer.PermanentError{Err: context.Canceled}
+			},
+			expectedExport: false,
+			expectedReason: cmapi.CertificateRequestReasonFailed,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			issuer := testutil.TestIssuer(
+				"issuer-1",
+				testutil.SetTestIssuerNamespace("ns1"),
+				testutil.SetTestIssuerStatusCondition(
+					fakeClock,
+					cmapi.IssuerConditionReady,
+					cmmeta.ConditionTrue,
+					v1alpha1.IssuerConditionReasonChecked,
+					"Succeeded checking the issuer",
+				),
+			)
+
+			cr1 := cmgen.CertificateRequest(
+				"cr1",
+				cmgen.SetCertificateRequestNamespace("ns1"),
+				cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+					Name:  issuer.Name,
+					Group: api.SchemeGroupVersion.Group,
+				}),
+				cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+					Type:   cmapi.CertificateRequestConditionReady,
+					Status: cmmeta.ConditionUnknown,
+					Reason: v1alpha1.CertificateRequestConditionReasonInitializing,
+				}),
+				cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+					Type:   cmapi.CertificateRequestConditionApproved,
+					Status: cmmeta.ConditionTrue,
+				}),
+			)
+
+			scheme := runtime.NewScheme()
+			require.NoError(t, setupCertificateRequestReconcilerScheme(scheme))
+			require.NoError(t, api.AddToScheme(scheme))
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(cr1, issuer).
+				Build()
+
+			var exported []inventory.CertificateMetadata
+			exporter := inventory.ExporterFunc(func(_ context.Context, metadata inventory.CertificateMetadata) error {
+				exported = append(exported, metadata)
+				return tc.exportErr
+			})
+
+			logger := logrtesting.NewTestLoggerWithOptions(t, logrtesting.Options{LogTimestamp: true, Verbosity: 10})
+
+			controller := (&CertificateRequestReconciler{
+				RequestController: RequestController{
+					IssuerTypes:       []v1alpha1.Issuer{&api.TestIssuer{}},
+					FieldOwner:        fieldOwner,
+					MaxRetryDuration:  time.Minute,
+					EventSource:       kubeutil.NewEventStore(),
+					InventoryExporter: exporter,
+					Client:            fakeClient,
+					Sign:              tc.sign,
+					EventRecorder:     record.NewFakeRecorder(100),
+					Clock:             fakeClock,
+				},
+			}).Init()
+			require.NoError(t, controller.setAllIssuerTypesWithGroupVersionKind(scheme))
+
+			_, statusPatch, _ := controller.reconcileStatusPatch(logger, context.TODO(), reconcile.Request{
+				NamespacedName: client.ObjectKeyFromObject(cr1),
+			})
+
+			crPatch, ok := statusPatch.(CertificateRequestPatch)
+			require.True(t, ok)
+			readyCondition := cmapi.CertificateRequestCondition{}
+			for _, condition := range crPatch.CertificateRequestPatch().Conditions {
+				if condition.Type == cmapi.CertificateRequestConditionReady {
+					readyCondition = condition
+				}
+			}
+			assert.Equal(t, tc.expectedReason, readyCondition.Reason)
+
+			if !tc.expectedExport {
+				assert.Empty(t, exported)
+				return
+			}
+			require.Len(t, exported, 1)
+			metadata := exported[0]
+			assert.Equal(t, "CertificateRequest", metadata.RequestKind)
+			assert.Equal(t, "ns1", metadata.Namespace)
+			assert.Equal(t, "cr1", metadata.Name)
+			assert.Equal(t, string(cr1.UID), metadata.UID)
+			assert.Equal(t, inventory.IssuerRef{
+				Group:     api.SchemeGroupVersion.Group,
+				Kind:      "TestIssuer",
+				Namespace: "ns1",
+				Name:      "issuer-1",
+			}, metadata.IssuerRef)
+			assert.Equal(t, "01", metadata.SerialNumber)
+			assert.Len(t, metadata.SHA256Fingerprint, 64)
+			assert.Equal(t, int64(time.Hour/time.Second), metadata.TTLSeconds)
+		})
+	}
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/coordination"
+)
+
+// IssuanceClaimPolicy makes sure that only a single controller replica calls Sign
+// for a request, even when reconciles of multiple replicas overlap (eg. during a
+// leader election transition). Before calling Sign, a replica acquires a claim on
+// the request by setting the RequestIssuanceClaimAnnotationKey annotation using a
+// server-side apply patch that is conditional on the resourceVersion of the request.
+// Other replicas do not call Sign for the request until the claim has expired.
+//
+// If a Backend is set, the claims are stored in that backend instead of in the
+// annotation.
+type IssuanceClaimPolicy struct {
+	// Identity uniquely identifies the controller replica, eg. the pod name.
+	Identity string
+
+	// Duration is the duration for which a claim is valid, defaults to 5 minutes.
+	// It must be longer than a single call of the Sign function.
+	Duration time.Duration
+
+	// Backend is optional. If set, the claims are stored in the backend (eg. in
+	// Leases or in an external key-value store) instead of in the annotation on
+	// the request, and no patch permissions on the requests are required.
+	Backend coordination.Backend
+}
+
+func (p *IssuanceClaimPolicy) duration() time.Duration {
+	if p.Duration == 0 {
+		return 5 * time.Minute
+	}
+	return p.Duration
+}
+
+// acquire acquires the issuance claim on the request, or renews the claim if it
+// is held by this replica and has less than half of its duration left. If the
+// claim is held by another replica, false is returned together with the duration
+// after which that claim expires. A nil policy always acquires the claim.
+func (p *IssuanceClaimPolicy) acquire(
+	ctx context.Context,
+	cl client.Client,
+	fieldOwner string,
+	obj client.Object,
+	now time.Time,
+) (bool, time.Duration, error) {
+	if p == nil {
+		return true, 0, nil
+	}
+
+	duration := p.duration()
+	if p.Backend != nil {
+		return p.Backend.TryAcquire(ctx, "issuance-claim/"+string(obj.GetUID()), p.Identity, duration, now)
+	}
+
+	if value, ok := obj.GetAnnotations()[v1alpha1.RequestIssuanceClaimAnnotationKey]; ok {
+		// An invalid claim is overwritten.
+		if claim, err := v1alpha1.ParseIssuanceClaim(value); err == nil {
+			if claim.Holder != p.Identity && !claim.Expired(now) {
+				return false, claim.Expires.Sub(now), nil
+			}
+
+			// Don't renew a claim that is still valid for long enough, every
+			// change of the annotation triggers a new reconcile.
+			if claim.Holder == p.Identity && claim.Expires.Sub(now) > duration/2 {
+				return true, 0, nil
+			}
+		}
+	}
+
+	if err := applyIssuanceClaim(ctx, cl, fieldOwner, obj, map[string]string{
+		v1alpha1.RequestIssuanceClaimAnnotationKey: v1alpha1.IssuanceClaim{
+			Holder:  p.Identity,
+			Expires: now.Add(duration),
+		}.String(),
+	}); err != nil {
+		return false, 0, err
+	}
+
+	return true, 0, nil
+}
+
+// release releases the issuance claim on the request if it is held by this
+// replica, so that other replicas do not have to wait for the claim to expire.
+// Claims stored in a Backend that does not implement coordination.Releaser are
+// held until they expire. A nil policy does nothing.
+func (p *IssuanceClaimPolicy) release(
+	ctx context.Context,
+	cl client.Client,
+	fieldOwner string,
+	obj client.Object,
+) error {
+	if p == nil {
+		return nil
+	}
+
+	if p.Backend != nil {
+		releaser, ok := p.Backend.(coordination.Releaser)
+		if !ok {
+			return nil
+		}
+		return releaser.Release(ctx, "issuance-claim/"+string(obj.GetUID()), p.Identity)
+	}
+
+	value, ok := obj.GetAnnotations()[v1alpha1.RequestIssuanceClaimAnnotationKey]
+	if !ok {
+		return nil
+	}
+	if claim, err := v1alpha1.ParseIssuanceClaim(value); err != nil || claim.Holder != p.Identity {
+		return nil
+	}
+
+	// Applying the patch without the annotation removes the annotation, since
+	// it is owned by the issuance claim field owner.
+	return applyIssuanceClaim(ctx, cl, fieldOwner, obj, nil)
+}
+
+// applyIssuanceClaim applies the annotations using the issuance claim field
+// owner. The patch is conditional on the resourceVersion of the request.
+func applyIssuanceClaim(
+	ctx context.Context,
+	cl client.Client,
+	fieldOwner string,
+	obj client.Object,
+	annotations map[string]string,
+) error {
+	gvk, err := apiutil.GVKForObject(obj, cl.Scheme())
+	if err != nil {
+		return err
+	}
+
+	metadata := map[string]interface{}{
+		"name": obj.GetName(),
+		// The resourceVersion makes the patch fail with a conflict if another
+		// replica acquired the claim since we read the request.
+		"resourceVersion": obj.GetResourceVersion(),
+	}
+	if obj.GetNamespace() != "" {
+		metadata["namespace"] = obj.GetNamespace()
+	}
+	if annotations != nil {
+		metadata["annotations"] = annotations
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"apiVersion": gvk.GroupVersion().String(),
+		"kind":       gvk.Kind,
+		"metadata":   metadata,
+	})
+	if err != nil {
+		return err
+	}
+
+	return cl.Patch(
+		ctx,
+		obj,
+		client.RawPatch(types.ApplyPatchType, patch),
+		client.FieldOwner(fieldOwner+"-issuance-claim"),
+		client.ForceOwnership,
+	)
+}
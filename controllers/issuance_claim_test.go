@@ -0,0 +1,389 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cmutil "github.com/cert-manager/cert-manager/pkg/api/util"
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	cmgen "github.com/cert-manager/cert-manager/test/unit/gen"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/coordination"
+	"github.com/cert-manager/issuer-lib/internal/kubeutil"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+	"github.com/cert-manager/issuer-lib/internal/testapi/testutil"
+	"github.com/cert-manager/issuer-lib/testing/ssafake"
+)
+
+type issuanceClaimTest struct {
+	clock   *clocktesting.FakeClock
+	scheme  *runtime.Scheme
+	client  client.WithWatch
+	request reconcile.Request
+}
+
+func newIssuanceClaimTest(t *testing.T) *issuanceClaimTest {
+	t.Helper()
+
+	fakeClock := clocktesting.NewFakeClock(randomTime().Truncate(time.Second))
+
+	issuer := testutil.TestIssuer(
+		"issuer-1",
+		testutil.SetTestIssuerNamespace("ns1"),
+		testutil.SetTestIssuerStatusCondition(
+			fakeClock,
+			cmapi.IssuerConditionReady,
+			cmmeta.ConditionTrue,
+			v1alpha1.IssuerConditionReasonChecked,
+			"Succeeded checking the issuer",
+		),
+	)
+
+	cr1 := cmgen.CertificateRequest(
+		"cr1",
+		cmgen.SetCertificateRequestNamespace("ns1"),
+		cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+			Name:  issuer.Name,
+			Group: api.SchemeGroupVersion.Group,
+		}),
+		cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+			Type:   cmapi.CertificateRequestConditionReady,
+			Status: cmmeta.ConditionUnknown,
+			Reason: v1alpha1.CertificateRequestConditionReasonInitializing,
+		}),
+		cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+			Type:   cmapi.CertificateRequestConditionApproved,
+			Status: cmmeta.ConditionTrue,
+		}),
+	)
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, setupCertificateRequestReconcilerScheme(scheme))
+	require.NoError(t, api.AddToScheme(scheme))
+
+	return &issuanceClaimTest{
+		clock:  fakeClock,
+		scheme: scheme,
+		client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(cr1, issuer).
+			WithStatusSubresource(cr1).
+			WithInterceptorFuncs(ssafake.NewApplier().InterceptorFuncs()).
+			Build(),
+		request: reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cr1)},
+	}
+}
+
+// controller returns the controller of a single replica, identified by the identity.
+func (c *issuanceClaimTest) controller(t *testing.T, identity string, cl client.Client, sign signer.Sign) *CertificateRequestReconciler {
+	t.Helper()
+
+	controller := (&CertificateRequestReconciler{
+		RequestController: RequestController{
+			IssuerTypes:      []v1alpha1.Issuer{&api.TestIssuer{}},
+			FieldOwner:       "test-issuance-claim",
+			MaxRetryDuration: time.Hour,
+			EventSource:      kubeutil.NewEventStore(),
+			IssuanceClaimPolicy: &IssuanceClaimPolicy{
+				Identity: identity,
+				Duration: time.Minute,
+			},
+			Client:        cl,
+			Sign:          sign,
+			EventRecorder: record.NewFakeRecorder(100),
+			Clock:         c.clock,
+		},
+	}).Init()
+	require.NoError(t, controller.setAllIssuerTypesWithGroupVersionKind(c.scheme))
+
+	return controller
+}
+
+func (c *issuanceClaimTest) getRequest(t *testing.T) *cmapi.CertificateRequest {
+	t.Helper()
+
+	var cr cmapi.CertificateRequest
+	require.NoError(t, c.client.Get(context.TODO(), c.request.NamespacedName, &cr))
+	return &cr
+}
+
+func (c *issuanceClaimTest) assertIssuedBy(t *testing.T, holder string) {
+	t.Helper()
+
+	cr := c.getRequest(t)
+	assert.True(t, cmutil.CertificateRequestHasCondition(cr, cmapi.CertificateRequestCondition{
+		Type:   cmapi.CertificateRequestConditionReady,
+		Status: cmmeta.ConditionTrue,
+	}))
+	assert.Equal(t, []byte("cert"), cr.Status.Certificate)
+
+	claim, err := v1alpha1.ParseIssuanceClaim(cr.Annotations[v1alpha1.RequestIssuanceClaimAnnotationKey])
+	require.NoError(t, err)
+	assert.Equal(t, holder, claim.Holder)
+}
+
+func countingSign(calls *int, result error) signer.Sign {
+	return func(_ context.Context, _ signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+		*calls++
+		if result != nil {
+			return signer.PEMBundle{}, result
+		}
+		return signer.PEMBundle{ChainPEM: []byte("cert")}, nil
+	}
+}
+
+func TestIssuanceClaimPolicyOverlappingReconciles(t *testing.T) {
+	t.Parallel()
+
+	c := newIssuanceClaimTest(t)
+
+	// The old leader is still signing when the new leader reconciles the request.
+	newLeaderCalls := 0
+	newLeader := c.controller(t, "replica-b", c.client, countingSign(&newLeaderCalls, nil))
+
+	oldLeaderCalls := 0
+	oldLeader := c.controller(t, "replica-a", c.client, func(ctx context.Context, cr signer.CertificateRequestObject, issuerObject v1alpha1.Issuer) (signer.PEMBundle, error) {
+		result, err := newLeader.Reconcile(ctx, c.request)
+		require.NoError(t, err)
+		assert.Equal(t, time.Minute, result.RequeueAfter)
+
+		return countingSign(&oldLeaderCalls, nil)(ctx, cr, issuerObject)
+	})
+
+	_, err := oldLeader.Reconcile(context.TODO(), c.request)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, oldLeaderCalls)
+	assert.Equal(t, 0, newLeaderCalls)
+	c.assertIssuedBy(t, "replica-a")
+}
+
+func TestIssuanceClaimPolicyFieldOwnerForIssuer(t *testing.T) {
+	t.Parallel()
+
+	c := newIssuanceClaimTest(t)
+
+	var fieldManagers []string
+	recordingClient := interceptor.NewClient(c.client, interceptor.Funcs{
+		Patch: func(ctx context.Context, cl client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			options := (&client.PatchOptions{}).ApplyOptions(opts)
+			fieldManagers = append(fieldManagers, options.FieldManager)
+			return cl.Patch(ctx, obj, patch, opts...)
+		},
+	})
+
+	// The claim is applied using the field owner of the issuer.
+	calls := 0
+	controller := c.controller(t, "replica-a", recordingClient, countingSign(&calls, nil))
+	controller.FieldOwnerForIssuer = func(_ schema.GroupVersionKind, issuerName types.NamespacedName) string {
+		return "test-issuance-claim-" + issuerName.Name
+	}
+
+	_, err := controller.Reconcile(context.TODO(), c.request)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, []string{"test-issuance-claim-issuer-1-issuance-claim"}, fieldManagers)
+	c.assertIssuedBy(t, "replica-a")
+}
+
+func singleCertificateQuota() *QuotaPolicy {
+	return &QuotaPolicy{
+		Quota: func(_ v1alpha1.Issuer) (*v1alpha1.IssuerQuota, error) {
+			return &v1alpha1.IssuerQuota{Limit: 1, Period: time.Hour}, nil
+		},
+	}
+}
+
+func TestIssuanceClaimPolicyReleasedOverQuota(t *testing.T) {
+	t.Parallel()
+
+	c := newIssuanceClaimTest(t)
+
+	calls := 0
+	controller := c.controller(t, "replica-a", c.client, countingSign(&calls, nil))
+	controller.QuotaPolicy = singleCertificateQuota()
+
+	// The claim was acquired by an earlier reconcile, and the quota was used up
+	// by another request since.
+	cr := c.getRequest(t)
+	acquired, _, err := controller.IssuanceClaimPolicy.acquire(context.TODO(), c.client, controller.FieldOwner, cr, c.clock.Now())
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	issuerGvk := api.SchemeGroupVersion.WithKind("TestIssuer")
+	issuerName := types.NamespacedName{Namespace: "ns1", Name: "issuer-1"}
+	issuer := testutil.TestIssuer("issuer-1", testutil.SetTestIssuerNamespace("ns1"))
+	require.NoError(t, controller.QuotaPolicy.reserve(logr.Discard(), issuer, issuerGvk, issuerName, "other-uid", c.clock.Now()))
+
+	// The request over quota releases its claim, so another replica can sign
+	// it without waiting for the claim to expire.
+	result, err := controller.Reconcile(context.TODO(), c.request)
+	require.NoError(t, err)
+	assert.Equal(t, quotaInFlightCheckInterval, result.RequeueAfter)
+	assert.Equal(t, 0, calls)
+	assert.NotContains(t, c.getRequest(t).Annotations, v1alpha1.RequestIssuanceClaimAnnotationKey)
+}
+
+func TestIssuanceClaimPolicyHeldCancelsQuota(t *testing.T) {
+	t.Parallel()
+
+	c := newIssuanceClaimTest(t)
+
+	// The claim is held by another replica.
+	otherCalls := 0
+	other := c.controller(t, "replica-b", c.client, countingSign(&otherCalls, nil))
+	acquired, _, err := other.IssuanceClaimPolicy.acquire(context.TODO(), c.client, other.FieldOwner, c.getRequest(t), c.clock.Now())
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	calls := 0
+	controller := c.controller(t, "replica-a", c.client, countingSign(&calls, nil))
+	controller.QuotaPolicy = singleCertificateQuota()
+
+	result, err := controller.Reconcile(context.TODO(), c.request)
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, result.RequeueAfter)
+	assert.Equal(t, 0, calls)
+
+	// The request that is signed by the other replica does not use up the quota
+	// of this replica.
+	issuerGvk := api.SchemeGroupVersion.WithKind("TestIssuer")
+	issuerName := types.NamespacedName{Namespace: "ns1", Name: "issuer-1"}
+	issuer := testutil.TestIssuer("issuer-1", testutil.SetTestIssuerNamespace("ns1"))
+	require.NoError(t, controller.QuotaPolicy.reserve(logr.Discard(), issuer, issuerGvk, issuerName, "other-uid", c.clock.Now()))
+}
+
+func TestIssuanceClaimPolicyStaleCache(t *testing.T) {
+	t.Parallel()
+
+	c := newIssuanceClaimTest(t)
+
+	// The cache of the new leader has not yet observed the claim of the old leader.
+	staleRequest := c.getRequest(t)
+	staleClient := interceptor.NewClient(c.client, interceptor.Funcs{
+		Get: func(ctx context.Context, cl client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if cr, ok := obj.(*cmapi.CertificateRequest); ok {
+				staleRequest.DeepCopyInto(cr)
+				return nil
+			}
+			return cl.Get(ctx, key, obj, opts...)
+		},
+	})
+
+	newLeaderCalls := 0
+	newLeader := c.controller(t, "replica-b", staleClient, countingSign(&newLeaderCalls, nil))
+
+	oldLeaderCalls := 0
+	oldLeader := c.controller(t, "replica-a", c.client, func(ctx context.Context, cr signer.CertificateRequestObject, issuerObject v1alpha1.Issuer) (signer.PEMBundle, error) {
+		_, err := newLeader.Reconcile(ctx, c.request)
+		require.Error(t, err)
+		assert.True(t, apierrors.IsConflict(err), "expected a conflict error, got %v", err)
+
+		return countingSign(&oldLeaderCalls, nil)(ctx, cr, issuerObject)
+	})
+
+	_, err := oldLeader.Reconcile(context.TODO(), c.request)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, oldLeaderCalls)
+	assert.Equal(t, 0, newLeaderCalls)
+	c.assertIssuedBy(t, "replica-a")
+}
+
+func TestIssuanceClaimPolicyFailover(t *testing.T) {
+	t.Parallel()
+
+	c := newIssuanceClaimTest(t)
+
+	// The old leader acquires the claim, but stops before the request is issued.
+	oldLeaderCalls := 0
+	oldLeader := c.controller(t, "replica-a", c.client, countingSign(&oldLeaderCalls, signer.PendingError{Err: context.Canceled}))
+	_, err := oldLeader.Reconcile(context.TODO(), c.request)
+	require.NoError(t, err)
+	assert.Equal(t, 1, oldLeaderCalls)
+
+	newLeaderCalls := 0
+	newLeader := c.controller(t, "replica-b", c.client, countingSign(&newLeaderCalls, nil))
+
+	// The new leader waits until the claim of the old leader has expired.
+	c.clock.Step(20 * time.Second)
+	result, err := newLeader.Reconcile(context.TODO(), c.request)
+	require.NoError(t, err)
+	assert.Equal(t, 40*time.Second, result.RequeueAfter)
+	assert.Equal(t, 0, newLeaderCalls)
+
+	c.clock.Step(40 * time.Second)
+	_, err = newLeader.Reconcile(context.TODO(), c.request)
+	require.NoError(t, err)
+	assert.Equal(t, 1, newLeaderCalls)
+	c.assertIssuedBy(t, "replica-b")
+
+	// The old leader does not sign the request again once it is issued.
+	_, err = oldLeader.Reconcile(context.TODO(), c.request)
+	require.NoError(t, err)
+	assert.Equal(t, 1, oldLeaderCalls)
+}
+
+func TestIssuanceClaimPolicyBackend(t *testing.T) {
+	t.Parallel()
+
+	c := newIssuanceClaimTest(t)
+	backend := &coordination.MemoryBackend{}
+
+	newLeaderCalls := 0
+	newLeader := c.controller(t, "replica-b", c.client, countingSign(&newLeaderCalls, nil))
+	newLeader.IssuanceClaimPolicy.Backend = backend
+
+	oldLeaderCalls := 0
+	oldLeader := c.controller(t, "replica-a", c.client, func(ctx context.Context, cr signer.CertificateRequestObject, issuerObject v1alpha1.Issuer) (signer.PEMBundle, error) {
+		result, err := newLeader.Reconcile(ctx, c.request)
+		require.NoError(t, err)
+		assert.Equal(t, time.Minute, result.RequeueAfter)
+
+		return countingSign(&oldLeaderCalls, nil)(ctx, cr, issuerObject)
+	})
+	oldLeader.IssuanceClaimPolicy.Backend = backend
+
+	_, err := oldLeader.Reconcile(context.TODO(), c.request)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, oldLeaderCalls)
+	assert.Equal(t, 0, newLeaderCalls)
+
+	// The claim is stored in the backend instead of in the annotation.
+	assert.NotContains(t, c.getRequest(t).Annotations, v1alpha1.RequestIssuanceClaimAnnotationKey)
+}
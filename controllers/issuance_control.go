@@ -0,0 +1,104 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+)
+
+// issuanceControl returns the IssuanceControl singleton, or nil if the
+// EnableIssuanceControl option is not set or the singleton does not exist.
+func (r *RequestController) issuanceControl(ctx context.Context) (*v1alpha1.IssuanceControl, error) {
+	if !r.EnableIssuanceControl {
+		return nil, nil
+	}
+
+	control := &v1alpha1.IssuanceControl{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: v1alpha1.IssuanceControlName}, control); apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get the IssuanceControl: %w", err)
+	}
+	return control, nil
+}
+
+// issuancePaused returns true if the IssuanceControl pauses the issuance of
+// the requests for the issuer type. A nil IssuanceControl never pauses issuance.
+func issuancePaused(control *v1alpha1.IssuanceControl, issuerGvk schema.GroupVersionKind) bool {
+	if control == nil {
+		return false
+	}
+	return control.Spec.Paused || slices.Contains(control.Spec.PausedIssuerKinds, v1alpha1.IssuerKind{
+		Group: issuerGvk.Group,
+		Kind:  issuerGvk.Kind,
+	})
+}
+
+// issuanceShadowMode returns true if the IssuanceControl enables shadow mode.
+func issuanceShadowMode(control *v1alpha1.IssuanceControl) bool {
+	return control != nil && control.Spec.ShadowMode
+}
+
+// requestsForIssuanceControl returns all requests of the type of the
+// controller, so that the requests are reconciled again when the IssuanceControl
+// changes (eg. when issuance is resumed). Requests that are already Ready or
+// Failed are skipped cheaply by Reconcile.
+func (r *RequestController) requestsForIssuanceControl(ctx context.Context, _ client.Object) []reconcile.Request {
+	logger := log.FromContext(ctx).WithName("IssuanceControl")
+
+	gvk := r.requestType.GetObjectKind().GroupVersionKind()
+	listObject, err := r.Client.Scheme().New(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err != nil {
+		logger.Error(err, "Failed to create the list of requests")
+		return nil
+	}
+	list, ok := listObject.(client.ObjectList)
+	if !ok {
+		logger.Error(fmt.Errorf("%T is not a list", listObject), "Failed to create the list of requests")
+		return nil
+	}
+
+	if err := r.Client.List(ctx, list); err != nil {
+		logger.Error(err, "Failed to list the requests")
+		return nil
+	}
+
+	objects, err := meta.ExtractList(list)
+	if err != nil {
+		logger.Error(err, "Failed to list the requests")
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(objects))
+	for _, object := range objects {
+		if obj, ok := object.(client.Object); ok {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(obj)})
+		}
+	}
+	return requests
+}
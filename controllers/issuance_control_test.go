@@ -0,0 +1,248 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	cmgen "github.com/cert-manager/cert-manager/test/unit/gen"
+	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/kubeutil"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+	"github.com/cert-manager/issuer-lib/internal/testapi/testutil"
+)
+
+func TestCertificateRequestReconcilerIssuanceControl(t *testing.T) {
+	t.Parallel()
+
+	fieldOwner := "test-certificate-request-reconciler-issuance-control"
+
+	fakeClock := clocktesting.NewFakeClock(randomTime())
+
+	issuanceControl := func(spec v1alpha1.IssuanceControlSpec) *v1alpha1.IssuanceControl {
+		return &v1alpha1.IssuanceControl{
+			ObjectMeta: metav1.ObjectMeta{Name: v1alpha1.IssuanceControlName},
+			Spec:       spec,
+		}
+	}
+
+	type testCase struct {
+		name            string
+		enabled         bool
+		issuanceControl *v1alpha1.IssuanceControl
+		expectSigned    bool
+		expectedReason  string
+		expectedMessage string
+	}
+
+	tests := []testCase{
+		{
+			name:           "disabled",
+			enabled:        false,
+			expectSigned:   true,
+			expectedReason: cmapi.CertificateRequestReasonIssued,
+		},
+		{
+			name:            "disabled-ignores-pause",
+			enabled:         false,
+			issuanceControl: issuanceControl(v1alpha1.IssuanceControlSpec{Paused: true}),
+			expectSigned:    true,
+			expectedReason:  cmapi.CertificateRequestReasonIssued,
+		},
+		{
+			name:           "missing-issuance-control",
+			enabled:        true,
+			expectSigned:   true,
+			expectedReason: cmapi.CertificateRequestReasonIssued,
+		},
+		{
+			name:            "paused",
+			enabled:         true,
+			issuanceControl: issuanceControl(v1alpha1.IssuanceControlSpec{Paused: true, Reason: "INC-42"}),
+			expectSigned:    false,
+			expectedReason:  v1alpha1.CertificateRequestConditionReasonIssuancePaused,
+			expectedMessage: "Waiting for issuance to be resumed using the IssuanceControl \"cluster\". Reason: INC-42",
+		},
+		{
+			name:    "paused-issuer-kind",
+			enabled: true,
+			issuanceControl: issuanceControl(v1alpha1.IssuanceControlSpec{PausedIssuerKinds: []v1alpha1.IssuerKind{
+				{Group: api.SchemeGroupVersion.Group, Kind: "TestIssuer"},
+			}}),
+			expectSigned:    false,
+			expectedReason:  v1alpha1.CertificateRequestConditionReasonIssuancePaused,
+			expectedMessage: "Waiting for issuance to be resumed using the IssuanceControl \"cluster\".",
+		},
+		{
+			name:    "paused-other-issuer-kind",
+			enabled: true,
+			issuanceControl: issuanceControl(v1alpha1.IssuanceControlSpec{PausedIssuerKinds: []v1alpha1.IssuerKind{
+				{Group: api.SchemeGroupVersion.Group, Kind: "TestClusterIssuer"},
+			}}),
+			expectSigned:   true,
+			expectedReason: cmapi.CertificateRequestReasonIssued,
+		},
+		{
+			name:            "shadow-mode",
+			enabled:         true,
+			issuanceControl: issuanceControl(v1alpha1.IssuanceControlSpec{ShadowMode: true}),
+			expectSigned:    false,
+			expectedReason:  v1alpha1.CertificateRequestConditionReasonInitializing,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			issuer := testutil.TestIssuer(
+				"issuer-1",
+				testutil.SetTestIssuerNamespace("ns1"),
+				testutil.SetTestIssuerStatusCondition(
+					fakeClock,
+					cmapi.IssuerConditionReady,
+					cmmeta.ConditionTrue,
+					v1alpha1.IssuerConditionReasonChecked,
+					"Succeeded checking the issuer",
+				),
+			)
+
+			cr1 := cmgen.CertificateRequest(
+				"cr1",
+				cmgen.SetCertificateRequestNamespace("ns1"),
+				cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+					Name:  issuer.Name,
+					Group: api.SchemeGroupVersion.Group,
+				}),
+				cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+					Type:   cmapi.CertificateRequestConditionReady,
+					Status: cmmeta.ConditionUnknown,
+					Reason: v1alpha1.CertificateRequestConditionReasonInitializing,
+				}),
+				cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+					Type:   cmapi.CertificateRequestConditionApproved,
+					Status: cmmeta.ConditionTrue,
+				}),
+			)
+
+			objects := []client.Object{cr1, issuer}
+			if tc.issuanceControl != nil {
+				objects = append(objects, tc.issuanceControl)
+			}
+
+			scheme := runtime.NewScheme()
+			require.NoError(t, setupCertificateRequestReconcilerScheme(scheme))
+			require.NoError(t, api.AddToScheme(scheme))
+			require.NoError(t, v1alpha1.AddToScheme(scheme))
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(objects...).
+				Build()
+
+			logger := logrtesting.NewTestLoggerWithOptions(t, logrtesting.Options{LogTimestamp: true, Verbosity: 10})
+
+			signed := false
+			controller := (&CertificateRequestReconciler{
+				RequestController: RequestController{
+					IssuerTypes:           []v1alpha1.Issuer{&api.TestIssuer{}},
+					FieldOwner:            fieldOwner,
+					MaxRetryDuration:      time.Minute,
+					EventSource:           kubeutil.NewEventStore(),
+					EnableIssuanceControl: tc.enabled,
+					Client:                fakeClient,
+					Sign: func(context.Context, signer.CertificateRequestObject, v1alpha1.Issuer) (signer.PEMBundle, error) {
+						signed = true
+						return signer.PEMBundle{ChainPEM: []byte("cert")}, nil
+					},
+					EventRecorder: record.NewFakeRecorder(100),
+					Clock:         fakeClock,
+				},
+			}).Init()
+			require.NoError(t, controller.setAllIssuerTypesWithGroupVersionKind(scheme))
+
+			result, statusPatch, err := controller.reconcileStatusPatch(logger, context.TODO(), reconcile.Request{
+				NamespacedName: client.ObjectKeyFromObject(cr1),
+			})
+			require.NoError(t, err)
+			assert.Equal(t, reconcile.Result{}, result)
+			assert.Equal(t, tc.expectSigned, signed)
+
+			readyCondition := cmapi.CertificateRequestCondition{
+				Type:   cmapi.CertificateRequestConditionReady,
+				Status: cmmeta.ConditionUnknown,
+				Reason: v1alpha1.CertificateRequestConditionReasonInitializing,
+			}
+			if statusPatch != nil {
+				crPatch, ok := statusPatch.(CertificateRequestPatch)
+				require.True(t, ok)
+				for _, condition := range crPatch.CertificateRequestPatch().Conditions {
+					if condition.Type == cmapi.CertificateRequestConditionReady {
+						readyCondition = condition
+					}
+				}
+			}
+			assert.Equal(t, tc.expectedReason, readyCondition.Reason)
+			if tc.expectedMessage != "" {
+				assert.Equal(t, tc.expectedMessage, readyCondition.Message)
+			}
+		})
+	}
+}
+
+func TestRequestsForIssuanceControl(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, setupCertificateRequestReconcilerScheme(scheme))
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			cmgen.CertificateRequest("cr1", cmgen.SetCertificateRequestNamespace("ns1")),
+			cmgen.CertificateRequest("cr2", cmgen.SetCertificateRequestNamespace("ns2")),
+		).
+		Build()
+
+	controller := (&CertificateRequestReconciler{
+		RequestController: RequestController{
+			Client: fakeClient,
+		},
+	}).Init()
+	require.NoError(t, kubeutil.SetGroupVersionKind(scheme, controller.requestType))
+
+	requests := controller.requestsForIssuanceControl(context.TODO(), &v1alpha1.IssuanceControl{})
+	assert.ElementsMatch(t, []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Namespace: "ns1", Name: "cr1"}},
+		{NamespacedName: types.NamespacedName{Namespace: "ns2", Name: "cr2"}},
+	}, requests)
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"crypto/x509"
+	"time"
+
+	"github.com/cert-manager/cert-manager/pkg/util/pki"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+// IssuedCertificatePatch gives access to the parsed certificate chain that was
+// set on a patch by SetIssued, so hooks that inspect the issued certificate do
+// not have to parse the PEM chain again. All RequestPatchHelper implementations
+// implement it. The returned certificates are shared and must not be modified.
+type IssuedCertificatePatch interface {
+	// LeafCertificate returns the first certificate of the chain, or nil if
+	// SetIssued was not called or the chain could not be parsed.
+	LeafCertificate() *x509.Certificate
+	// Intermediates returns the certificates of the chain that follow the leaf
+	// certificate, in the order of the chain.
+	Intermediates() []*x509.Certificate
+	// NotAfter returns the expiry of the leaf certificate, or the zero time if
+	// there is no leaf certificate.
+	NotAfter() time.Time
+}
+
+// issuedChain caches the parse of the chain that was set by SetIssued. It is
+// embedded in the patch helpers to implement IssuedCertificatePatch.
+type issuedChain struct {
+	certs []*x509.Certificate
+}
+
+// set parses the chain of the bundle. A chain that cannot be parsed leaves the
+// cache empty, the PEM chain is still set on the request as-is.
+func (c *issuedChain) set(bundle signer.PEMBundle) {
+	certs, err := pki.DecodeX509CertificateChainBytes(bundle.ChainPEM)
+	if err != nil {
+		c.certs = nil
+		return
+	}
+	c.certs = certs
+}
+
+func (c *issuedChain) LeafCertificate() *x509.Certificate {
+	if len(c.certs) == 0 {
+		return nil
+	}
+	return c.certs[0]
+}
+
+func (c *issuedChain) Intermediates() []*x509.Certificate {
+	if len(c.certs) < 2 {
+		return nil
+	}
+	return c.certs[1:]
+}
+
+func (c *issuedChain) NotAfter() time.Time {
+	leaf := c.LeafCertificate()
+	if leaf == nil {
+		return time.Time{}
+	}
+	return leaf.NotAfter
+}
+
+// recordIssuedCertificate observes the validity of the leaf certificate after
+// the status patch that issued it was applied.
+func recordIssuedCertificate(obj client.Object, statusPatch RequestPatch) {
+	patch, ok := statusPatch.(IssuedCertificatePatch)
+	if !ok {
+		return
+	}
+
+	leaf := patch.LeafCertificate()
+	if leaf == nil {
+		return
+	}
+
+	issuedCertificateValidity.WithLabelValues(requestKind(obj)).Observe(leaf.NotAfter.Sub(leaf.NotBefore).Seconds())
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+func TestIssuedCertificatePatch(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	leafPEM := testExpiringCertificatePEM(t, "leaf", now.Add(90*24*time.Hour))
+	intermediatePEM := testExpiringCertificatePEM(t, "intermediate", now.Add(365*24*time.Hour))
+	chainPEM := append(append([]byte{}, leafPEM...), intermediatePEM...)
+
+	helpers := map[string]RequestObjectHelper{
+		"CertificateRequest": &certificateRequestObjectHelper{
+			readOnlyObj: &cmapi.CertificateRequest{},
+		},
+		"CertificateSigningRequest": &certificatesigningRequestObjectHelper{
+			readOnlyObj: &certificatesv1.CertificateSigningRequest{},
+		},
+	}
+
+	for name, helper := range helpers {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			newPatch := func() RequestPatchHelper {
+				return helper.NewPatch(clocktesting.NewFakeClock(now), "test", record.NewFakeRecorder(10))
+			}
+
+			// Before SetIssued there is no issued certificate.
+			patch := newPatch()
+			assert.Nil(t, patch.LeafCertificate())
+			assert.Empty(t, patch.Intermediates())
+			assert.True(t, patch.NotAfter().IsZero())
+
+			patch.SetIssued(signer.PEMBundle{ChainPEM: chainPEM})
+			leaf := patch.LeafCertificate()
+			require.NotNil(t, leaf)
+			assert.Equal(t, "leaf", leaf.Subject.CommonName)
+			require.Len(t, patch.Intermediates(), 1)
+			assert.Equal(t, "intermediate", patch.Intermediates()[0].Subject.CommonName)
+			assert.Equal(t, now.Add(90*24*time.Hour), patch.NotAfter())
+
+			// The parse is cached, the accessors return the same certificates.
+			assert.Same(t, leaf, patch.LeafCertificate())
+
+			// A chain without intermediates.
+			patch = newPatch()
+			patch.SetIssued(signer.PEMBundle{ChainPEM: leafPEM})
+			assert.Equal(t, "leaf", patch.LeafCertificate().Subject.CommonName)
+			assert.Empty(t, patch.Intermediates())
+
+			// A chain that cannot be parsed is still set on the request, but
+			// has no parsed certificates.
+			patch = newPatch()
+			patch.SetIssued(signer.PEMBundle{ChainPEM: []byte("not a certificate")})
+			assert.Nil(t, patch.LeafCertificate())
+			assert.True(t, patch.NotAfter().IsZero())
+		})
+	}
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/conditions"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+// runChecks runs the Check function, or the NamedChecks if they are set. The
+// result of each named check is recorded as a separate condition in the status
+// patch, and the results are aggregated into a single error using the
+// CheckAggregation.
+func (r *IssuerReconciler) runChecks(
+	ctx context.Context,
+	issuer v1alpha1.Issuer,
+	issuerStatusPatch *v1alpha1.IssuerStatus,
+) error {
+	logger := log.FromContext(ctx)
+
+	if len(r.NamedChecks) == 0 {
+		err := classifyError(r.ErrorClassifier, r.Check(ctx, issuer))
+		logCAErrorDetails(logger, err)
+		return err
+	}
+
+	var failures []string
+	permanentFailures := 0
+	var retryAfter time.Duration
+	for _, check := range r.NamedChecks {
+		err := classifyError(r.ErrorClassifier, check.Check(ctx, issuer))
+		logCAErrorDetails(logger.WithValues("check", check.Name), err)
+
+		status, reason, message := cmmeta.ConditionTrue, v1alpha1.IssuerConditionReasonChecked, "Succeeded checking the issuer"
+		if err != nil {
+			status, reason, message = cmmeta.ConditionFalse, signer.IssuerConditionReason(err), err.Error()
+			if errors.As(err, &signer.PermanentError{}) {
+				permanentFailures++
+			}
+			if retryAfterError := new(signer.RetryAfterError); errors.As(err, retryAfterError) && retryAfterError.RetryAfter > 0 {
+				if retryAfter == 0 || retryAfterError.RetryAfter < retryAfter {
+					retryAfter = retryAfterError.RetryAfter
+				}
+			}
+			failures = append(failures, fmt.Sprintf("%s: %s", check.Name, err))
+		}
+
+		conditions.SetIssuerStatusCondition(
+			r.Clock,
+			issuer.GetStatus().Conditions,
+			&issuerStatusPatch.Conditions,
+			issuer.GetGeneration(),
+			cmapi.IssuerConditionType(check.Name),
+			status, reason, r.MessageStabilizationPolicy.stabilizeIssuerCondition(
+				issuer.GetStatus().Conditions,
+				cmapi.IssuerConditionType(check.Name),
+				status, reason, message,
+			),
+		)
+	}
+
+	var failed, permanent bool
+	switch r.CheckAggregation {
+	case signer.CheckAggregationOr:
+		failed = len(failures) == len(r.NamedChecks)
+		permanent = permanentFailures == len(r.NamedChecks)
+	default:
+		failed = len(failures) > 0
+		permanent = permanentFailures > 0
+	}
+
+	if !failed {
+		return nil
+	}
+
+	err := fmt.Errorf("failed checks: %s", strings.Join(failures, "; "))
+	if permanent {
+		return signer.PermanentError{Err: err}
+	}
+	if retryAfter > 0 {
+		// Check again once the first of the failed checks asked to be retried.
+		return signer.RetryAfterError{Err: err, RetryAfter: retryAfter}
+	}
+	return err
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
@@ -42,14 +43,19 @@ import (
 	v1alpha1 "github.com/cert-manager/issuer-lib/api/v1alpha1"
 	"github.com/cert-manager/issuer-lib/conditions"
 	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/eventbus"
 	"github.com/cert-manager/issuer-lib/internal/kubeutil"
 	"github.com/cert-manager/issuer-lib/internal/ssaclient"
+	"github.com/cert-manager/issuer-lib/upstream"
 )
 
 const (
-	eventIssuerChecked        = "Checked"
-	eventIssuerRetryableError = "RetryableError"
-	eventIssuerPermanentError = "PermanentError"
+	eventIssuerChecked         = "Checked"
+	eventIssuerRetryableError  = "RetryableError"
+	eventIssuerPermanentError  = "PermanentError"
+	eventIssuerReadyTransition = "ReadyTransition"
+
+	eventIssuerStatusPatchRejected = v1alpha1.ConditionTypeStatusPatchRejected
 )
 
 // IssuerReconciler reconciles a TestIssuer object
@@ -59,13 +65,67 @@ type IssuerReconciler struct {
 	FieldOwner  string
 	EventSource kubeutil.EventSource
 
+	// FieldOwnerForIssuer is optional. If set, it returns the field owner that is
+	// used to apply the status of an issuer, instead of FieldOwner. This allows a
+	// staged cutover between two controllers that manage the same issuer types.
+	FieldOwnerForIssuer FieldOwnerFunc
+	// FieldOwnerConflictWindow is optional. If set, the status of an issuer is not
+	// applied while one of the conditions in the status was applied by another
+	// field manager within the window. Instead, a FieldOwnerConflict event is
+	// recorded and the issuer is reconciled again once the window has passed.
+	FieldOwnerConflictWindow time.Duration
+
+	// ReadinessRegistry is optional. If set, the readiness of the issuer is recorded
+	// in it after each status update, so that it can be shared with the request
+	// controllers.
+	ReadinessRegistry kubeutil.ReadinessRegistry
+
+	// Messages is an optional catalog that overrides the messages used in the
+	// conditions and events set on the issuer.
+	Messages *MessageCatalog
+
+	// MessageStabilizationPolicy is optional. If set, the message of a condition
+	// is kept when the new message only differs from it in its volatile parts
+	// (eg. timestamps or attempt counters), so retries don't change the issuer.
+	MessageStabilizationPolicy *MessageStabilizationPolicy
+
+	// Redaction is optional. If set, sensitive data (eg. tokens or URLs with
+	// credentials) is redacted from the messages of conditions and events, and
+	// from the logs of the reconciles.
+	Redaction *RedactionPolicy
+
+	// UpstreamConfig is the optional configuration of the outbound connections to
+	// the CA. If set, it is passed to the Check functions through the context, where it
+	// can be obtained using upstream.FromContext.
+	UpstreamConfig *upstream.Config
+
+	// ClusterResourceNamespace is the namespace in which the resources (eg. Secrets)
+	// referenced by cluster-scoped issuers are found. If set, it is passed to the
+	// Check functions through the context, where it can be obtained using
+	// signer.ResourceNamespace.
+	ClusterResourceNamespace string
+
 	// Client is a controller-runtime client used to get and set K8S API resources
 	client.Client
 	// Check connects to a CA and checks if it is available
 	signer.Check
+	// NamedChecks is an optional list of checks that is run instead of Check. The
+	// result of each check is recorded as a separate condition on the issuer, and
+	// the results are aggregated into the Ready condition using CheckAggregation.
+	NamedChecks []signer.NamedCheck
+	// CheckAggregation determines how the results of the NamedChecks are aggregated,
+	// all checks have to succeed by default.
+	CheckAggregation signer.CheckAggregation
+	// CanarySigning is optional. If set, the issuer only becomes Ready once the
+	// checks succeeded and Sign returned a certificate for a throwaway canary
+	// request.
+	CanarySigning *CanarySigningPolicy
 	// IgnoreIssuer is an optional function that can prevent the issuer controllers from
 	// reconciling an issuer resource.
 	signer.IgnoreIssuer
+	// ErrorClassifier is an optional function that classifies the opaque errors
+	// returned by Check into one of the signer error types.
+	signer.ErrorClassifier
 
 	// EventRecorder is used for creating Kubernetes events on resources.
 	EventRecorder record.EventRecorder
@@ -82,16 +142,31 @@ type IssuerReconciler struct {
 	// additional setup after the controller is built and registered with the
 	// manager.
 	PostSetupWithManager func(context.Context, schema.GroupVersionKind, ctrl.Manager, controller.Controller) error
+
+	patchFailures *patchFailureTracker
+
+	// lifecycleEvents is set by the CombinedController to publish the lifecycle
+	// events of the issuers to its subscribers.
+	lifecycleEvents *eventbus.Bus[LifecycleEvent]
+
+	// canarySign is set by the CombinedController and used to sign the canary
+	// requests if CanarySigning has no Sign function.
+	canarySign signer.Sign
 }
 
 func (r *IssuerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, returnedError error) {
-	logger := log.FromContext(ctx).WithName("Reconcile")
+	logger := r.Redaction.logger(log.FromContext(ctx)).WithName("Reconcile")
+
+	// The error is logged by controller-runtime, so it is redacted as well.
+	defer func() {
+		returnedError = r.Redaction.redactError(returnedError)
+	}()
 
 	logger.V(2).Info("Starting reconcile loop", "name", req.Name, "namespace", req.Namespace)
 
 	// The error returned by `reconcileStatusPatch` is meant for controller-runtime,
 	// not for us. That's why we aren't checking `reconcileError != nil` .
-	result, issuerStatusPatch, reconcileError := r.reconcileStatusPatch(logger, ctx, req)
+	result, issuerStatusPatch, transition, reconcileError := r.reconcileStatusPatch(logger, ctx, req)
 
 	logger.V(2).Info("Got StatusPatch result", "result", result, "patch", issuerStatusPatch, "error", reconcileError)
 	if issuerStatusPatch != nil {
@@ -102,24 +177,70 @@ func (r *IssuerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res
 
 		if err := r.Client.Status().Patch(ctx, cr, patch, &client.SubResourcePatchOptions{
 			PatchOptions: client.PatchOptions{
-				FieldManager: r.FieldOwner,
+				FieldManager: r.fieldOwner(req),
 				Force:        ptr.To(true),
 			},
 		}); err != nil {
 			if !apierrors.IsNotFound(err) {
+				r.recordStatusPatchFailure(ctx, req, issuerStatusPatch, err)
 				return ctrl.Result{}, utilerrors.NewAggregate([]error{err, reconcileError})
 			}
 
 			logger.V(1).Info("Not found. Ignoring.")
+			r.forgetReadiness(req)
+
+			return result, reconcileError
 		}
+
+		r.recordStatusPatchSuccess(ctx, req)
+
+		r.recordReadiness(req, issuerStatusPatch)
+
+		// The Ready transition is only announced once it has been applied to the
+		// issuer, a failed patch is retried and will announce it again.
+		r.recordReadyTransition(transition)
 	}
 
 	return result, reconcileError
 }
 
+// fieldOwner returns the field owner that is used to apply the status of the
+// issuer.
+func (r *IssuerReconciler) fieldOwner(req ctrl.Request) string {
+	return fieldOwnerFor(r.FieldOwner, r.FieldOwnerForIssuer, r.ForObject.GetObjectKind().GroupVersionKind(), req.NamespacedName)
+}
+
+// recordReadiness records the Ready condition that was applied to the issuer
+// in the ReadinessRegistry.
+func (r *IssuerReconciler) recordReadiness(req ctrl.Request, issuerStatusPatch *v1alpha1.IssuerStatus) {
+	if r.ReadinessRegistry == nil {
+		return
+	}
+
+	readyCondition := conditions.GetIssuerStatusCondition(issuerStatusPatch.Conditions, cmapi.IssuerConditionReady)
+	if readyCondition == nil {
+		return
+	}
+
+	r.ReadinessRegistry.SetReadiness(
+		r.ForObject.GetObjectKind().GroupVersionKind(), req.NamespacedName,
+		readyCondition.ObservedGeneration, readyCondition.Status == cmmeta.ConditionTrue,
+	)
+}
+
+func (r *IssuerReconciler) forgetReadiness(req ctrl.Request) {
+	if r.ReadinessRegistry == nil {
+		return
+	}
+
+	r.ReadinessRegistry.Forget(r.ForObject.GetObjectKind().GroupVersionKind(), req.NamespacedName)
+}
+
 // reconcileStatusPatch is responsible for reconciling the issuer. It will return the
 // result and reconcileError to be returned by the Reconcile function. It also returns
-// an issuerStatusPatch that the Reconcile function will apply to the issuer's status.
+// an issuerStatusPatch that the Reconcile function will apply to the issuer's status,
+// and the transition of the Ready condition that the Reconcile function records
+// once that patch has been applied.
 // This function is split out from the Reconcile function to allow for easier testing.
 //
 // The error returned by `reconcileStatusPatch` is meant for controller-runtime,
@@ -129,18 +250,32 @@ func (r *IssuerReconciler) reconcileStatusPatch(
 	logger logr.Logger,
 	ctx context.Context,
 	req ctrl.Request,
-) (result ctrl.Result, issuerStatusPatch *v1alpha1.IssuerStatus, reconcileError error) { // nolint:unparam
+) (result ctrl.Result, issuerStatusPatch *v1alpha1.IssuerStatus, transition *readyTransition, reconcileError error) { // nolint:unparam
 	// Get the ClusterIssuer
 	issuer := r.ForObject.DeepCopyObject().(v1alpha1.Issuer)
 	forObjectGvk := r.ForObject.GetObjectKind().GroupVersionKind()
-	// calling IsInvalidated early to make sure the map is always cleared
-	reportedError := r.EventSource.HasReportedError(forObjectGvk, req.NamespacedName)
 
 	if err := r.Client.Get(ctx, req.NamespacedName, issuer); err != nil && apierrors.IsNotFound(err) {
 		logger.V(1).Info("Issuer not found. Ignoring.")
-		return result, nil, nil // done
+		// calling HasReportedError to make sure the map is cleared
+		_ = r.EventSource.HasReportedError(forObjectGvk, req.NamespacedName)
+		r.forgetReadiness(req)
+		return result, nil, nil, nil // done
 	} else if err != nil {
-		return result, nil, fmt.Errorf("unexpected get error: %v", err) // requeue with backoff
+		return result, nil, nil, fmt.Errorf("unexpected get error: %v", err) // requeue with backoff
+	}
+
+	fieldOwner := r.fieldOwner(req)
+	if conflict := detectFieldOwnerConflict(
+		issuer,
+		issuerConditionTypes(issuer),
+		fieldOwner,
+		r.Clock.Now(),
+		r.FieldOwnerConflictWindow,
+	); conflict != nil {
+		logger.V(1).Info("Issuer status was recently applied by another field manager. Ignoring.", "manager", conflict.manager)
+		recordFieldOwnerConflict(r.EventRecorder, issuer, forObjectGvk.Kind, fieldOwner, conflict)
+		return ctrl.Result{RequeueAfter: conflict.remaining}, nil, nil, nil // requeue after the conflict expired
 	}
 
 	readyCondition := conditions.GetIssuerStatusCondition(issuer.GetStatus().Conditions, cmapi.IssuerConditionReady)
@@ -148,21 +283,38 @@ func (r *IssuerReconciler) reconcileStatusPatch(
 	// Ignore Issuer if it is already permanently Failed
 	isFailed := (readyCondition != nil) &&
 		(readyCondition.Status == cmmeta.ConditionFalse) &&
-		(readyCondition.Reason == v1alpha1.IssuerConditionReasonFailed) &&
+		v1alpha1.IsIssuerConditionReasonFailed(readyCondition.Reason) &&
 		(readyCondition.ObservedGeneration >= issuer.GetGeneration())
 	if isFailed {
 		logger.V(1).Info("Issuer is Failed Permanently. Ignoring.")
-		return result, nil, nil // done
+		// calling HasReportedError to make sure the map is cleared
+		_ = r.EventSource.HasReportedError(forObjectGvk, req.NamespacedName)
+		return result, nil, nil, nil // done
+	}
+
+	if isIssuerPaused(issuer) {
+		logger.V(1).Info("Issuer is paused. Ignoring.")
+		return result, nil, nil, nil // done
 	}
 
+	if window := activeMaintenanceWindow(logger, issuer, r.Clock.Now()); window != nil {
+		logger.V(1).Info("Issuer is in a scheduled maintenance window. Ignoring.", "window", window.String())
+		result.RequeueAfter = window.End.Sub(r.Clock.Now())
+		return result, nil, nil, nil // requeue after the maintenance window
+	}
+
+	// The reported error is only consumed once the issuer is no longer ignored
+	// temporarily, so it is not lost when the issuer is requeued or unpaused.
+	reportedError := r.EventSource.HasReportedError(forObjectGvk, req.NamespacedName)
+
 	if r.IgnoreIssuer != nil {
 		ignore, err := r.IgnoreIssuer(ctx, issuer)
 		if err != nil {
-			return result, nil, fmt.Errorf("failed to check if issuer should be ignored: %v", err) // requeue with backoff
+			return result, nil, nil, fmt.Errorf("failed to check if issuer should be ignored: %v", err) // requeue with backoff
 		}
 		if ignore {
 			logger.V(1).Info("IgnoreIssuer() returned true. Ignoring.")
-			return result, nil, nil // done
+			return result, nil, nil, nil // done
 		}
 	}
 
@@ -174,50 +326,53 @@ func (r *IssuerReconciler) reconcileStatusPatch(
 		status cmmeta.ConditionStatus,
 		reason, message string,
 	) string {
+		message = r.Redaction.redact(message)
 		condition, _ := conditions.SetIssuerStatusCondition(
 			r.Clock,
 			issuer.GetStatus().Conditions,
 			&issuerStatusPatch.Conditions,
 			issuer.GetGeneration(),
 			cmapi.IssuerConditionReady,
-			status, reason, message,
-		)
-		return condition.Message
-	}
-
-	// Add a Ready condition if one does not already exist. Set initial Status
-	// to Unknown.
-	if readyCondition == nil {
-		logger.V(1).Info("Initializing Ready condition")
-		setReadyCondition(
-			cmmeta.ConditionUnknown,
-			v1alpha1.IssuerConditionReasonInitializing,
-			fmt.Sprintf("%s has started reconciling this Issuer", r.FieldOwner),
+			status, reason, r.MessageStabilizationPolicy.stabilizeIssuerCondition(
+				issuer.GetStatus().Conditions,
+				cmapi.IssuerConditionReady,
+				status, reason, message,
+			),
 		)
-		// To continue reconciling this Issuer, we must re-run the reconcile loop
-		// after adding the Unknown Ready condition. This update will trigger a
-		// new reconcile loop, so we don't need to requeue here.
-		return result, issuerStatusPatch, nil // apply patch, done
+		r.publishIssuerReadyChanged(issuer, readyCondition, condition)
+		transition = &readyTransition{
+			issuer:   issuer,
+			previous: readyCondition,
+			current:  condition,
+		}
+		// The events contain the new message, even if the message of the
+		// condition is stabilized.
+		return message
 	}
 
 	var err error
-	if (readyCondition.Status == cmmeta.ConditionTrue) && (reportedError != nil) {
+	if (readyCondition != nil) && (readyCondition.Status == cmmeta.ConditionTrue) && (reportedError != nil) {
 		// We received an error from a Certificaterequest while our current status is Ready,
 		// update the ready state of the issuer to reflect the error.
 		err = reportedError
 	} else {
-		err = r.Check(log.IntoContext(ctx, logger), issuer)
+		checkCtx := callbackContext(log.IntoContext(ctx, logger), r.UpstreamConfig, r.ClusterResourceNamespace)
+		err = r.runChecks(checkCtx, issuer, issuerStatusPatch)
+		if err == nil {
+			err = classifyError(r.ErrorClassifier, r.CanarySigning.check(checkCtx, issuer, forObjectGvk, r.canarySign, r.Clock.Now()))
+			logCAErrorDetails(logger, err)
+		}
 	}
 	if err == nil {
 		logger.V(1).Info("Successfully finished the reconciliation.")
 		message := setReadyCondition(
 			cmmeta.ConditionTrue,
 			v1alpha1.IssuerConditionReasonChecked,
-			"Succeeded checking the issuer",
+			r.Messages.issuerChecked(issuer),
 		)
 		r.EventRecorder.Event(issuer, corev1.EventTypeNormal, eventIssuerChecked, message)
 
-		return result, issuerStatusPatch, nil // apply patch, done
+		return result, issuerStatusPatch, transition, nil // apply patch, done
 	}
 
 	isPermanentError := errors.As(err, &signer.PermanentError{})
@@ -226,22 +381,81 @@ func (r *IssuerReconciler) reconcileStatusPatch(
 		logger.V(1).Error(err, "Permanent Issuer error. Marking as failed.")
 		message := setReadyCondition(
 			cmmeta.ConditionFalse,
-			v1alpha1.IssuerConditionReasonFailed,
-			fmt.Sprintf("Failed permanently: %s", err),
+			signer.IssuerConditionReason(err),
+			r.Messages.issuerPermanentError(issuer, err),
 		)
 		r.EventRecorder.Event(issuer, corev1.EventTypeWarning, eventIssuerPermanentError, message)
-		return result, issuerStatusPatch, reconcile.TerminalError(err) // apply patch, done
+		return result, issuerStatusPatch, transition, reconcile.TerminalError(err) // apply patch, done
 	} else {
 		// retry
 		logger.V(1).Error(err, "Retryable Issuer error.")
 		message := setReadyCondition(
 			cmmeta.ConditionFalse,
-			v1alpha1.IssuerConditionReasonPending,
-			fmt.Sprintf("Not ready yet: %s", err),
+			signer.IssuerConditionReason(err),
+			r.Messages.issuerRetryableError(issuer, err),
 		)
 		r.EventRecorder.Event(issuer, corev1.EventTypeWarning, eventIssuerRetryableError, message)
-		return result, issuerStatusPatch, err // apply patch, requeue with backoff
+
+		if retryAfterError := new(signer.RetryAfterError); errors.As(err, retryAfterError) && retryAfterError.RetryAfter > 0 {
+			result.RequeueAfter = retryAfterError.RetryAfter
+			return result, issuerStatusPatch, transition, nil // apply patch, requeue after RetryAfter
+		}
+
+		return result, issuerStatusPatch, transition, err // apply patch, requeue with backoff
+	}
+}
+
+// readyTransition is the change of the Ready condition of an issuer that is
+// applied by a status patch.
+type readyTransition struct {
+	issuer   v1alpha1.Issuer
+	previous *cmapi.IssuerCondition
+	current  *cmapi.IssuerCondition
+}
+
+// recordReadyTransition records a ReadyTransition event that contains both the
+// previous and the new status and reason of the Ready condition, so the history
+// of an issuer can be reconstructed from its events. No event is recorded if
+// neither the status nor the reason changed.
+func (r *IssuerReconciler) recordReadyTransition(transition *readyTransition) {
+	if transition == nil {
+		return
+	}
+
+	issuer, previous, current := transition.issuer, transition.previous, transition.current
+	if previous != nil && previous.Status == current.Status && previous.Reason == current.Reason {
+		return
+	}
+
+	eventType := corev1.EventTypeWarning
+	if current.Status == cmmeta.ConditionTrue {
+		eventType = corev1.EventTypeNormal
 	}
+
+	r.EventRecorder.Event(issuer, eventType, eventIssuerReadyTransition, r.Messages.issuerReadyTransition(issuer, previous, current))
+}
+
+// isIssuerPaused returns true if the issuer has the IssuerPausedAnnotationKey
+// annotation set to "true".
+func isIssuerPaused(issuer v1alpha1.Issuer) bool {
+	return issuer.GetAnnotations()[v1alpha1.IssuerPausedAnnotationKey] == "true"
+}
+
+// activeMaintenanceWindow returns the maintenance window that is declared on the
+// issuer using the IssuerMaintenanceWindowAnnotationKey annotation, if now is
+// within that window. Invalid maintenance windows are ignored.
+func activeMaintenanceWindow(logger logr.Logger, issuer v1alpha1.Issuer, now time.Time) *v1alpha1.MaintenanceWindow {
+	window, err := v1alpha1.GetMaintenanceWindow(issuer)
+	if err != nil {
+		logger.V(1).Info("Ignoring invalid maintenance window.", "error", err.Error())
+		return nil
+	}
+
+	if window == nil || !window.Contains(now) {
+		return nil
+	}
+
+	return window
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -251,6 +465,9 @@ func (r *IssuerReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manage
 	}
 	forObjectGvk := r.ForObject.GetObjectKind().GroupVersionKind()
 
+	r.patchFailures = newPatchFailureTracker()
+	r.EventRecorder = r.Redaction.eventRecorder(r.EventRecorder)
+
 	build := ctrl.NewControllerManagedBy(mgr).
 		For(
 			r.ForObject,
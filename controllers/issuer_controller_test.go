@@ -18,6 +18,7 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"testing"
@@ -33,18 +34,23 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	clocktesting "k8s.io/utils/clock/testing"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/conditions"
 	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/kubeutil"
 	"github.com/cert-manager/issuer-lib/internal/testapi/api"
 	"github.com/cert-manager/issuer-lib/internal/testapi/testutil"
 	"github.com/cert-manager/issuer-lib/internal/tests/errormatch"
+	"github.com/cert-manager/issuer-lib/testing/ssafake"
 )
 
 // We are using a random time generator to generate random times for the
@@ -69,6 +75,9 @@ func TestTestIssuerReconcilerReconcile(t *testing.T) {
 	type testCase struct {
 		name                string
 		check               signer.Check
+		namedChecks         []signer.NamedCheck
+		checkAggregation    signer.CheckAggregation
+		errorClassifier     signer.ErrorClassifier
 		objects             []client.Object
 		eventSourceError    error
 		validateError       *errormatch.Matcher
@@ -107,6 +116,40 @@ func TestTestIssuerReconcilerReconcile(t *testing.T) {
 			expectedStatusPatch: nil,
 		},
 
+		// Ignore if issuer is paused
+		{
+			name:  "ignore-issuer-paused",
+			check: staticChecker(fmt.Errorf("check should not be called")),
+			objects: []client.Object{
+				testutil.TestIssuerFrom(issuer1,
+					testutil.SetTestIssuerAnnotations(map[string]string{
+						v1alpha1.IssuerPausedAnnotationKey: "true",
+					}),
+				),
+			},
+			expectedStatusPatch: nil,
+		},
+
+		// Ignore if issuer is in a scheduled maintenance window, requeue once the window has ended
+		{
+			name:  "ignore-issuer-scheduled-maintenance",
+			check: staticChecker(fmt.Errorf("check should not be called")),
+			objects: []client.Object{
+				testutil.TestIssuerFrom(issuer1,
+					testutil.SetTestIssuerAnnotations(map[string]string{
+						v1alpha1.IssuerMaintenanceWindowAnnotationKey: v1alpha1.MaintenanceWindow{
+							Start: fakeTime2.Add(-1 * time.Hour),
+							End:   fakeTime2.Add(2 * time.Hour),
+						}.String(),
+					}),
+				),
+			},
+			expectedResult: reconcile.Result{
+				RequeueAfter: 2 * time.Hour,
+			},
+			expectedStatusPatch: nil,
+		},
+
 		// Update status, even if already at Ready for observed generation
 		{
 			name:  "trigger-when-ready",
@@ -211,6 +254,7 @@ func TestTestIssuerReconcilerReconcile(t *testing.T) {
 			validateError: errormatch.ErrorContains("[specific error]"),
 			expectedEvents: []string{
 				"Warning RetryableError Not ready yet: [specific error]",
+				"Warning ReadyTransition Ready condition changed from True (Checked) to False (Pending)",
 			},
 		},
 
@@ -248,9 +292,11 @@ func TestTestIssuerReconcilerReconcile(t *testing.T) {
 			},
 		},
 
-		// Initialize the Issuer Ready condition if it is missing
+		// Set the Issuer Ready condition in the first reconcile if it is missing,
+		// without applying an intermediate Initializing condition first
 		{
-			name: "initialize-ready-condition",
+			name:  "set-ready-condition-on-first-reconcile",
+			check: staticChecker(nil),
 			objects: []client.Object{
 				issuer1,
 			},
@@ -258,13 +304,17 @@ func TestTestIssuerReconcilerReconcile(t *testing.T) {
 				Conditions: []cmapi.IssuerCondition{
 					{
 						Type:               cmapi.IssuerConditionReady,
-						Status:             cmmeta.ConditionUnknown,
-						Reason:             v1alpha1.IssuerConditionReasonInitializing,
-						Message:            fieldOwner + " has started reconciling this Issuer",
+						Status:             cmmeta.ConditionTrue,
+						Reason:             v1alpha1.IssuerConditionReasonChecked,
+						Message:            "Succeeded checking the issuer",
 						LastTransitionTime: &fakeTimeObj2,
 					},
 				},
 			},
+			expectedEvents: []string{
+				"Normal Checked Succeeded checking the issuer",
+				"Normal ReadyTransition Ready condition set to True (Checked)",
+			},
 		},
 
 		// Retry if the check function returns an error
@@ -296,6 +346,7 @@ func TestTestIssuerReconcilerReconcile(t *testing.T) {
 			validateError: errormatch.ErrorContains("[specific error]"),
 			expectedEvents: []string{
 				"Warning RetryableError Not ready yet: [specific error]",
+				"Warning ReadyTransition Ready condition changed from Unknown (Initializing) to False (Pending)",
 			},
 		},
 
@@ -328,6 +379,369 @@ func TestTestIssuerReconcilerReconcile(t *testing.T) {
 			validateError: errormatch.ErrorContains("terminal error: [specific error]"),
 			expectedEvents: []string{
 				"Warning PermanentError Failed permanently: [specific error]",
+				"Warning ReadyTransition Ready condition changed from Unknown (Initializing) to False (Failed)",
+			},
+		},
+
+		// Use the well-known reason of a retryable IssuerReasonError
+		{
+			name:  "retry-on-error-with-reason",
+			check: staticChecker(signer.EndpointUnreachableError(fmt.Errorf("[connection refused]"))),
+			objects: []client.Object{
+				testutil.TestIssuerFrom(issuer1,
+					testutil.SetTestIssuerStatusCondition(
+						fakeClock1,
+						cmapi.IssuerConditionReady,
+						cmmeta.ConditionUnknown,
+						v1alpha1.IssuerConditionReasonInitializing,
+						fieldOwner+" has started reconciling this Issuer",
+					),
+				),
+			},
+			expectedStatusPatch: &v1alpha1.IssuerStatus{
+				Conditions: []cmapi.IssuerCondition{
+					{
+						Type:               cmapi.IssuerConditionReady,
+						Status:             cmmeta.ConditionFalse,
+						Reason:             v1alpha1.IssuerConditionReasonEndpointUnreachable,
+						Message:            "Not ready yet: [connection refused]",
+						LastTransitionTime: &fakeTimeObj2,
+					},
+				},
+			},
+			validateError: errormatch.ErrorContains("[connection refused]"),
+			expectedEvents: []string{
+				"Warning RetryableError Not ready yet: [connection refused]",
+				"Warning ReadyTransition Ready condition changed from Unknown (Initializing) to False (EndpointUnreachable)",
+			},
+		},
+
+		// Use the well-known reason of a permanent IssuerReasonError
+		{
+			name:  "dont-retry-on-configuration-error",
+			check: staticChecker(signer.ConfigurationError(fmt.Errorf("[invalid url]"))),
+			objects: []client.Object{
+				testutil.TestIssuerFrom(issuer1,
+					testutil.SetTestIssuerStatusCondition(
+						fakeClock1,
+						cmapi.IssuerConditionReady,
+						cmmeta.ConditionUnknown,
+						v1alpha1.IssuerConditionReasonInitializing,
+						fieldOwner+" has started reconciling this Issuer",
+					),
+				),
+			},
+			expectedStatusPatch: &v1alpha1.IssuerStatus{
+				Conditions: []cmapi.IssuerCondition{
+					{
+						Type:               cmapi.IssuerConditionReady,
+						Status:             cmmeta.ConditionFalse,
+						Reason:             v1alpha1.IssuerConditionReasonConfigurationError,
+						Message:            "Failed permanently: [invalid url]",
+						LastTransitionTime: &fakeTimeObj2,
+					},
+				},
+			},
+			validateError: errormatch.ErrorContains("terminal error: [invalid url]"),
+			expectedEvents: []string{
+				"Warning PermanentError Failed permanently: [invalid url]",
+				"Warning ReadyTransition Ready condition changed from Unknown (Initializing) to False (ConfigurationError)",
+			},
+		},
+
+		// Ignore if already at ConfigurationError for observed generation
+		{
+			name:  "ignore-configuration-error",
+			check: staticChecker(nil),
+			objects: []client.Object{
+				testutil.TestIssuerFrom(issuer1,
+					testutil.SetTestIssuerGeneration(80),
+					testutil.SetTestIssuerStatusCondition(
+						fakeClock1,
+						cmapi.IssuerConditionReady,
+						cmmeta.ConditionFalse,
+						v1alpha1.IssuerConditionReasonConfigurationError,
+						"[error message]",
+					),
+				),
+			},
+			expectedStatusPatch: nil,
+		},
+
+		// Requeue after the RetryAfter duration if the check function returns a
+		// RetryAfterError
+		{
+			name:  "retry-after-on-retry-after-error",
+			check: staticChecker(signer.RetryAfterError{Err: fmt.Errorf("[maintenance until 14:00]"), RetryAfter: 90 * time.Minute}),
+			objects: []client.Object{
+				testutil.TestIssuerFrom(issuer1,
+					testutil.SetTestIssuerStatusCondition(
+						fakeClock1,
+						cmapi.IssuerConditionReady,
+						cmmeta.ConditionUnknown,
+						v1alpha1.IssuerConditionReasonInitializing,
+						fieldOwner+" has started reconciling this Issuer",
+					),
+				),
+			},
+			expectedStatusPatch: &v1alpha1.IssuerStatus{
+				Conditions: []cmapi.IssuerCondition{
+					{
+						Type:               cmapi.IssuerConditionReady,
+						Status:             cmmeta.ConditionFalse,
+						Reason:             v1alpha1.IssuerConditionReasonPending,
+						Message:            "Not ready yet: [maintenance until 14:00]",
+						LastTransitionTime: &fakeTimeObj2,
+					},
+				},
+			},
+			expectedResult: reconcile.Result{
+				RequeueAfter: 90 * time.Minute,
+			},
+			expectedEvents: []string{
+				"Warning RetryableError Not ready yet: [maintenance until 14:00]",
+				"Warning ReadyTransition Ready condition changed from Unknown (Initializing) to False (Pending)",
+			},
+		},
+
+		// Record the result of each named check as a separate condition, and retry if
+		// one of the checks failed with a retryable error
+		{
+			name:  "named-checks-and-retry-on-error",
+			check: staticChecker(fmt.Errorf("check should not be called")),
+			namedChecks: []signer.NamedCheck{
+				{Name: "CredentialsValid", Check: staticChecker(nil)},
+				{Name: "EndpointReachable", Check: staticChecker(fmt.Errorf("[endpoint error]"))},
+			},
+			objects: []client.Object{
+				testutil.TestIssuerFrom(issuer1,
+					testutil.SetTestIssuerStatusCondition(
+						fakeClock1,
+						cmapi.IssuerConditionReady,
+						cmmeta.ConditionUnknown,
+						v1alpha1.IssuerConditionReasonInitializing,
+						fieldOwner+" has started reconciling this Issuer",
+					),
+				),
+			},
+			expectedStatusPatch: &v1alpha1.IssuerStatus{
+				Conditions: []cmapi.IssuerCondition{
+					{
+						Type:               "CredentialsValid",
+						Status:             cmmeta.ConditionTrue,
+						Reason:             v1alpha1.IssuerConditionReasonChecked,
+						Message:            "Succeeded checking the issuer",
+						LastTransitionTime: &fakeTimeObj2,
+					},
+					{
+						Type:               "EndpointReachable",
+						Status:             cmmeta.ConditionFalse,
+						Reason:             v1alpha1.IssuerConditionReasonPending,
+						Message:            "[endpoint error]",
+						LastTransitionTime: &fakeTimeObj2,
+					},
+					{
+						Type:               cmapi.IssuerConditionReady,
+						Status:             cmmeta.ConditionFalse,
+						Reason:             v1alpha1.IssuerConditionReasonPending,
+						Message:            "Not ready yet: failed checks: EndpointReachable: [endpoint error]",
+						LastTransitionTime: &fakeTimeObj2,
+					},
+				},
+			},
+			validateError: errormatch.ErrorContains("failed checks: EndpointReachable: [endpoint error]"),
+			expectedEvents: []string{
+				"Warning RetryableError Not ready yet: failed checks: EndpointReachable: [endpoint error]",
+				"Warning ReadyTransition Ready condition changed from Unknown (Initializing) to False (Pending)",
+			},
+		},
+
+		// Requeue after the shortest RetryAfter duration of the failed named checks
+		{
+			name: "named-checks-retry-after-shortest",
+			namedChecks: []signer.NamedCheck{
+				{Name: "PrimaryEndpointReachable", Check: staticChecker(signer.RetryAfterError{Err: fmt.Errorf("[primary error]"), RetryAfter: time.Hour})},
+				{Name: "SecondaryEndpointReachable", Check: staticChecker(signer.RetryAfterError{Err: fmt.Errorf("[secondary error]"), RetryAfter: 10 * time.Minute})},
+			},
+			objects: []client.Object{
+				testutil.TestIssuerFrom(issuer1,
+					testutil.SetTestIssuerStatusCondition(
+						fakeClock1,
+						cmapi.IssuerConditionReady,
+						cmmeta.ConditionUnknown,
+						v1alpha1.IssuerConditionReasonInitializing,
+						fieldOwner+" has started reconciling this Issuer",
+					),
+				),
+			},
+			expectedStatusPatch: &v1alpha1.IssuerStatus{
+				Conditions: []cmapi.IssuerCondition{
+					{
+						Type:               "PrimaryEndpointReachable",
+						Status:             cmmeta.ConditionFalse,
+						Reason:             v1alpha1.IssuerConditionReasonPending,
+						Message:            "[primary error]",
+						LastTransitionTime: &fakeTimeObj2,
+					},
+					{
+						Type:               "SecondaryEndpointReachable",
+						Status:             cmmeta.ConditionFalse,
+						Reason:             v1alpha1.IssuerConditionReasonPending,
+						Message:            "[secondary error]",
+						LastTransitionTime: &fakeTimeObj2,
+					},
+					{
+						Type:               cmapi.IssuerConditionReady,
+						Status:             cmmeta.ConditionFalse,
+						Reason:             v1alpha1.IssuerConditionReasonPending,
+						Message:            "Not ready yet: failed checks: PrimaryEndpointReachable: [primary error]; SecondaryEndpointReachable: [secondary error]",
+						LastTransitionTime: &fakeTimeObj2,
+					},
+				},
+			},
+			expectedResult: reconcile.Result{
+				RequeueAfter: 10 * time.Minute,
+			},
+			expectedEvents: []string{
+				"Warning RetryableError Not ready yet: failed checks: PrimaryEndpointReachable: [primary error]; SecondaryEndpointReachable: [secondary error]",
+				"Warning ReadyTransition Ready condition changed from Unknown (Initializing) to False (Pending)",
+			},
+		},
+
+		// Don't retry if one of the named checks failed permanently
+		{
+			name: "named-checks-and-dont-retry-on-permanent-error",
+			namedChecks: []signer.NamedCheck{
+				{Name: "CredentialsValid", Check: staticChecker(signer.PermanentError{Err: fmt.Errorf("[credentials error]")})},
+				{Name: "EndpointReachable", Check: staticChecker(fmt.Errorf("[endpoint error]"))},
+			},
+			objects: []client.Object{
+				testutil.TestIssuerFrom(issuer1,
+					testutil.SetTestIssuerStatusCondition(
+						fakeClock1,
+						cmapi.IssuerConditionReady,
+						cmmeta.ConditionUnknown,
+						v1alpha1.IssuerConditionReasonInitializing,
+						fieldOwner+" has started reconciling this Issuer",
+					),
+				),
+			},
+			expectedStatusPatch: &v1alpha1.IssuerStatus{
+				Conditions: []cmapi.IssuerCondition{
+					{
+						Type:               "CredentialsValid",
+						Status:             cmmeta.ConditionFalse,
+						Reason:             v1alpha1.IssuerConditionReasonFailed,
+						Message:            "[credentials error]",
+						LastTransitionTime: &fakeTimeObj2,
+					},
+					{
+						Type:               "EndpointReachable",
+						Status:             cmmeta.ConditionFalse,
+						Reason:             v1alpha1.IssuerConditionReasonPending,
+						Message:            "[endpoint error]",
+						LastTransitionTime: &fakeTimeObj2,
+					},
+					{
+						Type:               cmapi.IssuerConditionReady,
+						Status:             cmmeta.ConditionFalse,
+						Reason:             v1alpha1.IssuerConditionReasonFailed,
+						Message:            "Failed permanently: failed checks: CredentialsValid: [credentials error]; EndpointReachable: [endpoint error]",
+						LastTransitionTime: &fakeTimeObj2,
+					},
+				},
+			},
+			validateError: errormatch.ErrorContains("terminal error: failed checks: CredentialsValid: [credentials error]; EndpointReachable: [endpoint error]"),
+			expectedEvents: []string{
+				"Warning PermanentError Failed permanently: failed checks: CredentialsValid: [credentials error]; EndpointReachable: [endpoint error]",
+				"Warning ReadyTransition Ready condition changed from Unknown (Initializing) to False (Failed)",
+			},
+		},
+
+		// With the Or aggregation, the issuer is ready if one of the named checks succeeded
+		{
+			name:             "named-checks-or-one-succeeded",
+			checkAggregation: signer.CheckAggregationOr,
+			namedChecks: []signer.NamedCheck{
+				{Name: "PrimaryEndpointReachable", Check: staticChecker(signer.PermanentError{Err: fmt.Errorf("[primary error]")})},
+				{Name: "SecondaryEndpointReachable", Check: staticChecker(nil)},
+			},
+			objects: []client.Object{
+				testutil.TestIssuerFrom(issuer1,
+					testutil.SetTestIssuerStatusCondition(
+						fakeClock1,
+						cmapi.IssuerConditionReady,
+						cmmeta.ConditionUnknown,
+						v1alpha1.IssuerConditionReasonInitializing,
+						fieldOwner+" has started reconciling this Issuer",
+					),
+				),
+			},
+			expectedStatusPatch: &v1alpha1.IssuerStatus{
+				Conditions: []cmapi.IssuerCondition{
+					{
+						Type:               "PrimaryEndpointReachable",
+						Status:             cmmeta.ConditionFalse,
+						Reason:             v1alpha1.IssuerConditionReasonFailed,
+						Message:            "[primary error]",
+						LastTransitionTime: &fakeTimeObj2,
+					},
+					{
+						Type:               "SecondaryEndpointReachable",
+						Status:             cmmeta.ConditionTrue,
+						Reason:             v1alpha1.IssuerConditionReasonChecked,
+						Message:            "Succeeded checking the issuer",
+						LastTransitionTime: &fakeTimeObj2,
+					},
+					{
+						Type:               cmapi.IssuerConditionReady,
+						Status:             cmmeta.ConditionTrue,
+						Reason:             v1alpha1.IssuerConditionReasonChecked,
+						Message:            "Succeeded checking the issuer",
+						LastTransitionTime: &fakeTimeObj2,
+					},
+				},
+			},
+			expectedEvents: []string{
+				"Normal Checked Succeeded checking the issuer",
+				"Normal ReadyTransition Ready condition changed from Unknown (Initializing) to True (Checked)",
+			},
+		},
+
+		// Don't retry if the ErrorClassifier maps the error returned by the check function
+		// to a permanent error
+		{
+			name:  "dont-retry-on-classified-permanent-error",
+			check: staticChecker(fmt.Errorf("[specific error]")),
+			errorClassifier: func(err error) error {
+				return signer.PermanentError{Err: err}
+			},
+			objects: []client.Object{
+				testutil.TestIssuerFrom(issuer1,
+					testutil.SetTestIssuerStatusCondition(
+						fakeClock1,
+						cmapi.IssuerConditionReady,
+						cmmeta.ConditionUnknown,
+						v1alpha1.IssuerConditionReasonInitializing,
+						fieldOwner+" has started reconciling this Issuer",
+					),
+				),
+			},
+			expectedStatusPatch: &v1alpha1.IssuerStatus{
+				Conditions: []cmapi.IssuerCondition{
+					{
+						Type:               cmapi.IssuerConditionReady,
+						Status:             cmmeta.ConditionFalse,
+						Reason:             v1alpha1.IssuerConditionReasonFailed,
+						Message:            "Failed permanently: [specific error]",
+						LastTransitionTime: &fakeTimeObj2,
+					},
+				},
+			},
+			validateError: errormatch.ErrorContains("terminal error: [specific error]"),
+			expectedEvents: []string{
+				"Warning PermanentError Failed permanently: [specific error]",
+				"Warning ReadyTransition Ready condition changed from Unknown (Initializing) to False (Failed)",
 			},
 		},
 
@@ -362,6 +776,7 @@ func TestTestIssuerReconcilerReconcile(t *testing.T) {
 			},
 			expectedEvents: []string{
 				"Normal Checked Succeeded checking the issuer",
+				"Normal ReadyTransition Ready condition changed from Unknown (Initializing) to True (Checked)",
 			},
 		},
 
@@ -396,6 +811,7 @@ func TestTestIssuerReconcilerReconcile(t *testing.T) {
 			},
 			expectedEvents: []string{
 				"Normal Checked Succeeded checking the issuer",
+				"Normal ReadyTransition Ready condition changed from False (Initializing) to True (Checked)",
 			},
 		},
 	}
@@ -431,13 +847,18 @@ func TestTestIssuerReconcilerReconcile(t *testing.T) {
 				EventSource: fakeEventSource{
 					err: tc.eventSourceError,
 				},
-				Client:        fakeClient,
-				Check:         tc.check,
-				EventRecorder: fakeRecorder,
-				Clock:         fakeClock2,
+				Client:           fakeClient,
+				Check:            tc.check,
+				NamedChecks:      tc.namedChecks,
+				CheckAggregation: tc.checkAggregation,
+				ErrorClassifier:  tc.errorClassifier,
+				EventRecorder:    fakeRecorder,
+				Clock:            fakeClock2,
 			}
 
-			res, issuerStatusPatch, reconcileErr := controller.reconcileStatusPatch(logger, context.TODO(), req)
+			res, issuerStatusPatch, transition, reconcileErr := controller.reconcileStatusPatch(logger, context.TODO(), req)
+			// The Reconcile function records the transition once the patch is applied.
+			controller.recordReadyTransition(transition)
 
 			assert.Equal(t, tc.expectedResult, res)
 			assert.Equal(t, tc.expectedStatusPatch, issuerStatusPatch)
@@ -453,6 +874,343 @@ func TestTestIssuerReconcilerReconcile(t *testing.T) {
 	}
 }
 
+func TestIssuerReconcilerReadinessRegistry(t *testing.T) {
+	t.Parallel()
+
+	fieldOwner := "test-issuer-reconciler-readiness-registry"
+
+	type testCase struct {
+		name          string
+		check         signer.Check
+		expectedReady bool
+	}
+
+	tests := []testCase{
+		{
+			name: "ready-after-successful-check",
+			check: func(_ context.Context, _ v1alpha1.Issuer) error {
+				return nil
+			},
+			expectedReady: true,
+		},
+		{
+			name: "not-ready-after-failed-check",
+			check: func(_ context.Context, _ v1alpha1.Issuer) error {
+				return fmt.Errorf("a specific error")
+			},
+			expectedReady: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			issuer := testutil.TestIssuer(
+				"issuer-1",
+				testutil.SetTestIssuerNamespace("ns1"),
+				testutil.SetTestIssuerGeneration(5),
+			)
+
+			scheme := runtime.NewScheme()
+			require.NoError(t, api.AddToScheme(scheme))
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(issuer).
+				WithStatusSubresource(issuer).
+				WithInterceptorFuncs(ssafake.NewApplier().InterceptorFuncs()).
+				Build()
+
+			forObject := &api.TestIssuer{}
+			require.NoError(t, kubeutil.SetGroupVersionKind(scheme, forObject))
+			gvk := forObject.GetObjectKind().GroupVersionKind()
+
+			registry := kubeutil.NewReadinessRegistry()
+			controller := IssuerReconciler{
+				ForObject:         forObject,
+				FieldOwner:        fieldOwner,
+				EventSource:       kubeutil.NewEventStore(),
+				ReadinessRegistry: registry,
+				Client:            fakeClient,
+				Check:             tc.check,
+				EventRecorder:     record.NewFakeRecorder(100),
+				Clock:             clocktesting.NewFakeClock(randomTime()),
+			}
+
+			req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(issuer)}
+
+			// The registry must match the Ready condition of the issuer after every reconcile:
+			// the first reconcile sets the Ready condition, the second calls Check again.
+			for i := 0; i < 2; i++ {
+				_, _ = controller.Reconcile(context.TODO(), req)
+
+				var current api.TestIssuer
+				require.NoError(t, fakeClient.Get(context.TODO(), req.NamespacedName, &current))
+
+				readyCondition := conditions.GetIssuerStatusCondition(current.Status.Conditions, cmapi.IssuerConditionReady)
+				require.NotNil(t, readyCondition)
+				objectReady := readyCondition.Status == cmmeta.ConditionTrue && readyCondition.ObservedGeneration == current.Generation
+				assert.Equal(t, objectReady, registry.IsReady(gvk, req.NamespacedName, current.Generation))
+			}
+
+			assert.Equal(t, tc.expectedReady, registry.IsReady(gvk, req.NamespacedName, issuer.Generation))
+			assert.False(t, registry.IsReady(gvk, req.NamespacedName, issuer.Generation+1))
+
+			// Once the issuer is deleted, its readiness is forgotten.
+			require.NoError(t, fakeClient.Delete(context.TODO(), issuer))
+			_, err := controller.Reconcile(context.TODO(), req)
+			require.NoError(t, err)
+			assert.False(t, registry.IsReady(gvk, req.NamespacedName, issuer.Generation))
+		})
+	}
+}
+
+func TestIssuerReconcilerSingleStatusPatchPerReconcile(t *testing.T) {
+	t.Parallel()
+
+	issuer := testutil.TestIssuer(
+		"issuer-1",
+		testutil.SetTestIssuerNamespace("ns1"),
+	)
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, api.AddToScheme(scheme))
+
+	statusPatches := 0
+	applierFuncs := ssafake.NewApplier().InterceptorFuncs()
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(issuer).
+		WithStatusSubresource(issuer).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Patch: applierFuncs.Patch,
+			SubResourcePatch: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+				statusPatches++
+				return applierFuncs.SubResourcePatch(ctx, c, subResourceName, obj, patch, opts...)
+			},
+		}).
+		Build()
+
+	forObject := &api.TestIssuer{}
+	require.NoError(t, kubeutil.SetGroupVersionKind(scheme, forObject))
+
+	controller := IssuerReconciler{
+		ForObject:   forObject,
+		FieldOwner:  "test-single-status-patch",
+		EventSource: kubeutil.NewEventStore(),
+		Client:      fakeClient,
+		Check: func(_ context.Context, _ v1alpha1.Issuer) error {
+			return nil
+		},
+		EventRecorder: record.NewFakeRecorder(100),
+		Clock:         clocktesting.NewFakeClock(randomTime()),
+	}
+
+	// The first reconcile of an issuer without a Ready condition calls Check
+	// and applies the result without an intermediate Initializing patch.
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(issuer)}
+	_, err := controller.Reconcile(context.TODO(), req)
+	require.NoError(t, err)
+	assert.Equal(t, 1, statusPatches)
+
+	var current api.TestIssuer
+	require.NoError(t, fakeClient.Get(context.TODO(), req.NamespacedName, &current))
+	readyCondition := conditions.GetIssuerStatusCondition(current.Status.Conditions, cmapi.IssuerConditionReady)
+	require.NotNil(t, readyCondition)
+	assert.Equal(t, cmmeta.ConditionTrue, readyCondition.Status)
+	assert.Equal(t, v1alpha1.IssuerConditionReasonChecked, readyCondition.Reason)
+}
+
+func TestIssuerReconcilerReadyTransitionAfterStatusPatch(t *testing.T) {
+	t.Parallel()
+
+	issuer := testutil.TestIssuer(
+		"issuer-1",
+		testutil.SetTestIssuerNamespace("ns1"),
+	)
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, api.AddToScheme(scheme))
+
+	patchError := errors.New("[patch error]")
+	applierFuncs := ssafake.NewApplier().InterceptorFuncs()
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(issuer).
+		WithStatusSubresource(issuer).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Patch: applierFuncs.Patch,
+			SubResourcePatch: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+				if patchError != nil {
+					return patchError
+				}
+				return applierFuncs.SubResourcePatch(ctx, c, subResourceName, obj, patch, opts...)
+			},
+		}).
+		Build()
+
+	forObject := &api.TestIssuer{}
+	require.NoError(t, kubeutil.SetGroupVersionKind(scheme, forObject))
+
+	fakeRecorder := record.NewFakeRecorder(100)
+	controller := IssuerReconciler{
+		ForObject:   forObject,
+		FieldOwner:  "test-ready-transition-after-status-patch",
+		EventSource: kubeutil.NewEventStore(),
+		Client:      fakeClient,
+		Check: func(_ context.Context, _ v1alpha1.Issuer) error {
+			return nil
+		},
+		EventRecorder: fakeRecorder,
+		Clock:         clocktesting.NewFakeClock(randomTime()),
+	}
+
+	// The Ready transition is not recorded if the status patch fails.
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(issuer)}
+	_, err := controller.Reconcile(context.TODO(), req)
+	require.ErrorIs(t, err, patchError)
+	assert.Equal(t, []string{
+		"Normal Checked Succeeded checking the issuer",
+	}, chanToSlice(fakeRecorder.Events))
+
+	// The Ready transition is recorded once the status patch succeeds.
+	patchError = nil
+	_, err = controller.Reconcile(context.TODO(), req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"Normal Checked Succeeded checking the issuer",
+		"Normal ReadyTransition Ready condition set to True (Checked)",
+	}, chanToSlice(fakeRecorder.Events))
+}
+
+func TestIssuerReconcilerKeepsReportedErrorWhileIgnored(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name string
+		// ignore changes the issuer such that it is ignored temporarily
+		ignore func(issuer *api.TestIssuer, now time.Time)
+		// stopIgnoring changes the issuer or the time such that the issuer is
+		// no longer ignored
+		stopIgnoring func(t *testing.T, cl client.Client, issuer *api.TestIssuer, fakeClock *clocktesting.FakeClock)
+	}
+
+	tests := []testCase{
+		{
+			name: "field-owner-conflict",
+			ignore: func(issuer *api.TestIssuer, now time.Time) {
+				issuer.ManagedFields = []metav1.ManagedFieldsEntry{
+					conditionManagedFields("old-controller", now.Add(-time.Minute), string(cmapi.IssuerConditionReady)),
+				}
+			},
+			stopIgnoring: func(_ *testing.T, _ client.Client, _ *api.TestIssuer, fakeClock *clocktesting.FakeClock) {
+				fakeClock.Step(5 * time.Minute)
+			},
+		},
+		{
+			name: "paused",
+			ignore: func(issuer *api.TestIssuer, _ time.Time) {
+				issuer.Annotations = map[string]string{v1alpha1.IssuerPausedAnnotationKey: "true"}
+			},
+			stopIgnoring: func(t *testing.T, cl client.Client, issuer *api.TestIssuer, _ *clocktesting.FakeClock) {
+				var current api.TestIssuer
+				require.NoError(t, cl.Get(context.TODO(), client.ObjectKeyFromObject(issuer), &current))
+				current.Annotations = nil
+				require.NoError(t, cl.Update(context.TODO(), &current))
+			},
+		},
+		{
+			name: "scheduled-maintenance",
+			ignore: func(issuer *api.TestIssuer, now time.Time) {
+				issuer.Annotations = map[string]string{
+					v1alpha1.IssuerMaintenanceWindowAnnotationKey: v1alpha1.MaintenanceWindow{
+						Start: now.Add(-time.Hour),
+						End:   now.Add(time.Hour),
+					}.String(),
+				}
+			},
+			stopIgnoring: func(_ *testing.T, _ client.Client, _ *api.TestIssuer, fakeClock *clocktesting.FakeClock) {
+				fakeClock.Step(time.Hour)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fakeClock := clocktesting.NewFakeClock(randomTime().Truncate(time.Second))
+
+			issuer := testutil.TestIssuer(
+				"issuer-1",
+				testutil.SetTestIssuerNamespace("ns1"),
+				testutil.SetTestIssuerStatusCondition(
+					fakeClock,
+					cmapi.IssuerConditionReady,
+					cmmeta.ConditionTrue,
+					v1alpha1.IssuerConditionReasonChecked,
+					"Succeeded checking the issuer",
+				),
+			)
+			tc.ignore(issuer, fakeClock.Now())
+
+			scheme := runtime.NewScheme()
+			require.NoError(t, api.AddToScheme(scheme))
+
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(issuer).
+				WithStatusSubresource(issuer).
+				WithInterceptorFuncs(ssafake.NewApplier().InterceptorFuncs()).
+				Build()
+
+			forObject := &api.TestIssuer{}
+			require.NoError(t, kubeutil.SetGroupVersionKind(scheme, forObject))
+			issuerGvk := forObject.GetObjectKind().GroupVersionKind()
+
+			queue := workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+			defer queue.ShutDown()
+
+			eventSource := kubeutil.NewEventStore()
+			require.NoError(t, eventSource.AddConsumer(issuerGvk).Start(context.TODO(), queue))
+
+			controller := IssuerReconciler{
+				ForObject:                forObject,
+				FieldOwner:               "new-controller",
+				FieldOwnerConflictWindow: 5 * time.Minute,
+				EventSource:              eventSource,
+				Client:                   fakeClient,
+				Check: func(_ context.Context, _ v1alpha1.Issuer) error {
+					return nil
+				},
+				EventRecorder: record.NewFakeRecorder(100),
+				Clock:         fakeClock,
+			}
+
+			req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(issuer)}
+			require.NoError(t, eventSource.ReportError(issuerGvk, req.NamespacedName, errors.New("[reported error]")))
+
+			// The reported error is kept while the issuer is ignored.
+			_, err := controller.Reconcile(context.TODO(), req)
+			require.NoError(t, err)
+			require.EqualError(t, eventSource.(kubeutil.ReportedErrorPeeker).PeekReportedError(issuerGvk, req.NamespacedName), "[reported error]")
+
+			// The reported error is set on the Ready condition once the issuer
+			// is no longer ignored.
+			tc.stopIgnoring(t, fakeClient, issuer, fakeClock)
+			_, _ = controller.Reconcile(context.TODO(), req)
+			require.NoError(t, eventSource.(kubeutil.ReportedErrorPeeker).PeekReportedError(issuerGvk, req.NamespacedName))
+
+			var current api.TestIssuer
+			require.NoError(t, fakeClient.Get(context.TODO(), req.NamespacedName, &current))
+			readyCondition := conditions.GetIssuerStatusCondition(current.Status.Conditions, cmapi.IssuerConditionReady)
+			require.NotNil(t, readyCondition)
+			assert.Equal(t, cmmeta.ConditionFalse, readyCondition.Status)
+			assert.Contains(t, readyCondition.Message, "[reported error]")
+		})
+	}
+}
+
 type fakeEventSource struct {
 	err error
 }
@@ -467,3 +1225,7 @@ func (fakeEventSource) ReportError(gvk schema.GroupVersionKind, namespacedName t
 func (fes fakeEventSource) HasReportedError(gvk schema.GroupVersionKind, namespacedName types.NamespacedName) error {
 	return fes.err
 }
+
+func (fes fakeEventSource) PeekReportedError(gvk schema.GroupVersionKind, namespacedName types.NamespacedName) error {
+	return fes.err
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/cert-manager/issuer-lib/internal/kubeutil"
+)
+
+var issuerNotReadyCacheEntries = kubeutil.CacheEntries.WithLabelValues("issuer_not_ready")
+
+// IssuerNotReadyCache remembers for a short time which issuers are not ready and
+// which requests are already waiting for them. A request that is reconciled again
+// while it is waiting for such an issuer is skipped without reading the issuer,
+// patching the request or recording an event. The issuer is forgotten when its
+// Ready condition transitions (ie. when the requests of the issuer are reconciled
+// because of an issuer event) or when the TTL expires.
+type IssuerNotReadyCache struct {
+	// TTL is the duration for which an issuer is remembered as not ready,
+	// defaults to 30 seconds.
+	TTL time.Duration
+
+	// MaxEntries is the maximum number of requests that are remembered as waiting,
+	// defaults to 10000. When the cache is full, the issuer that expires first is
+	// evicted with all its requests.
+	MaxEntries int
+
+	mu      sync.Mutex
+	issuers map[notReadyIssuerKey]*notReadyIssuer
+	size    int
+}
+
+type notReadyIssuerKey struct {
+	gvk            schema.GroupVersionKind
+	namespacedName types.NamespacedName
+}
+
+type notReadyIssuer struct {
+	expires  time.Time
+	requests map[types.NamespacedName]struct{}
+}
+
+func (c *IssuerNotReadyCache) ttl() time.Duration {
+	if c.TTL == 0 {
+		return 30 * time.Second
+	}
+	return c.TTL
+}
+
+func (c *IssuerNotReadyCache) maxEntries() int {
+	if c.MaxEntries == 0 {
+		return 10000
+	}
+	return c.MaxEntries
+}
+
+// isWaiting returns true if the request is known to be waiting for the issuer
+// to become ready.
+func (c *IssuerNotReadyCache) isWaiting(
+	gvk schema.GroupVersionKind,
+	issuerName types.NamespacedName,
+	request types.NamespacedName,
+	now time.Time,
+) bool {
+	if c == nil {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	issuer, ok := c.issuers[notReadyIssuerKey{gvk: gvk, namespacedName: issuerName}]
+	if !ok || !now.Before(issuer.expires) {
+		return false
+	}
+
+	_, ok = issuer.requests[request]
+	return ok
+}
+
+// setWaiting records that the issuer is not ready and that the request is waiting
+// for it. The TTL starts when the issuer is first recorded as not ready.
+func (c *IssuerNotReadyCache) setWaiting(
+	gvk schema.GroupVersionKind,
+	issuerName types.NamespacedName,
+	request types.NamespacedName,
+	now time.Time,
+) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.issuers == nil {
+		c.issuers = map[notReadyIssuerKey]*notReadyIssuer{}
+	}
+
+	key := notReadyIssuerKey{gvk: gvk, namespacedName: issuerName}
+	issuer, ok := c.issuers[key]
+	if ok && now.Before(issuer.expires) {
+		if _, ok := issuer.requests[request]; ok {
+			return
+		}
+	}
+
+	for existingKey, existing := range c.issuers {
+		if !now.Before(existing.expires) {
+			c.remove(existingKey)
+		}
+	}
+
+	for c.size >= c.maxEntries() {
+		var firstKey notReadyIssuerKey
+		var first *notReadyIssuer
+		for existingKey, existing := range c.issuers {
+			if first == nil || existing.expires.Before(first.expires) {
+				firstKey, first = existingKey, existing
+			}
+		}
+		c.remove(firstKey)
+	}
+
+	issuer, ok = c.issuers[key]
+	if !ok {
+		issuer = &notReadyIssuer{
+			expires:  now.Add(c.ttl()),
+			requests: map[types.NamespacedName]struct{}{},
+		}
+		c.issuers[key] = issuer
+	}
+
+	issuer.requests[request] = struct{}{}
+	c.resize(1)
+}
+
+// remove must be called with the lock held.
+func (c *IssuerNotReadyCache) remove(key notReadyIssuerKey) {
+	if issuer, ok := c.issuers[key]; ok {
+		delete(c.issuers, key)
+		c.resize(-len(issuer.requests))
+	}
+}
+
+// resize must be called with the lock held.
+func (c *IssuerNotReadyCache) resize(delta int) {
+	c.size += delta
+	issuerNotReadyCacheEntries.Add(float64(delta))
+}
+
+// forgetRequest forgets that the request is waiting for an issuer, eg. because
+// the status patch that reflects this was not applied.
+func (c *IssuerNotReadyCache) forgetRequest(request types.NamespacedName) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, issuer := range c.issuers {
+		if _, ok := issuer.requests[request]; ok {
+			delete(issuer.requests, request)
+			c.resize(-1)
+		}
+	}
+}
+
+// invalidate forgets that the issuer is not ready.
+func (c *IssuerNotReadyCache) invalidate(gvk schema.GroupVersionKind, issuerName types.NamespacedName) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.remove(notReadyIssuerKey{gvk: gvk, namespacedName: issuerName})
+}
+
+// invalidatePredicate returns a predicate that accepts all events and invalidates
+// the issuers of the events. It must be evaluated after the LinkedIssuerPredicate,
+// so the cache is only invalidated by the events that trigger the reconciliation
+// of the requests of the issuer.
+func (c *IssuerNotReadyCache) invalidatePredicate(gvk schema.GroupVersionKind) predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		c.invalidate(gvk, client.ObjectKeyFromObject(obj))
+		return true
+	})
+}
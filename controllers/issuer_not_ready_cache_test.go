@@ -0,0 +1,241 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cmutil "github.com/cert-manager/cert-manager/pkg/api/util"
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	cmgen "github.com/cert-manager/cert-manager/test/unit/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/kubeutil"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+	"github.com/cert-manager/issuer-lib/internal/testapi/testutil"
+	"github.com/cert-manager/issuer-lib/testing/ssafake"
+	"github.com/cert-manager/issuer-lib/testing/timetravel"
+)
+
+func TestIssuerNotReadyCache(t *testing.T) {
+	t.Parallel()
+
+	gvk := api.SchemeGroupVersion.WithKind("TestIssuer")
+	issuer1 := types.NamespacedName{Namespace: "ns1", Name: "issuer-1"}
+	issuer2 := types.NamespacedName{Namespace: "ns1", Name: "issuer-2"}
+	cr1 := types.NamespacedName{Namespace: "ns1", Name: "cr1"}
+	cr2 := types.NamespacedName{Namespace: "ns1", Name: "cr2"}
+	now := randomTime()
+
+	cache := &IssuerNotReadyCache{TTL: time.Minute}
+	assert.False(t, cache.isWaiting(gvk, issuer1, cr1, now))
+
+	cache.setWaiting(gvk, issuer1, cr1, now)
+	assert.True(t, cache.isWaiting(gvk, issuer1, cr1, now))
+	assert.False(t, cache.isWaiting(gvk, issuer1, cr2, now), "other requests are not yet waiting")
+	assert.False(t, cache.isWaiting(gvk, issuer2, cr1, now), "other issuers are not known to be not ready")
+
+	// The TTL starts when the issuer is first recorded as not ready.
+	cache.setWaiting(gvk, issuer1, cr2, now.Add(30*time.Second))
+	assert.True(t, cache.isWaiting(gvk, issuer1, cr2, now.Add(59*time.Second)))
+	assert.False(t, cache.isWaiting(gvk, issuer1, cr1, now.Add(time.Minute)))
+	assert.False(t, cache.isWaiting(gvk, issuer1, cr2, now.Add(time.Minute)))
+
+	cache.setWaiting(gvk, issuer1, cr1, now)
+	cache.setWaiting(gvk, issuer1, cr2, now)
+	cache.forgetRequest(cr1)
+	assert.False(t, cache.isWaiting(gvk, issuer1, cr1, now))
+	assert.True(t, cache.isWaiting(gvk, issuer1, cr2, now))
+
+	cache.invalidate(gvk, issuer1)
+	assert.False(t, cache.isWaiting(gvk, issuer1, cr2, now))
+
+	// A nil cache does not remember anything.
+	var nilCache *IssuerNotReadyCache
+	nilCache.setWaiting(gvk, issuer1, cr1, now)
+	assert.False(t, nilCache.isWaiting(gvk, issuer1, cr1, now))
+	assert.True(t, nilCache.invalidatePredicate(gvk).Create(event.CreateEvent{Object: &api.TestIssuer{}}))
+}
+
+func TestIssuerNotReadyCacheMaxEntries(t *testing.T) {
+	t.Parallel()
+
+	gvk := api.SchemeGroupVersion.WithKind("TestIssuer")
+	issuer1 := types.NamespacedName{Namespace: "ns1", Name: "issuer-1"}
+	issuer2 := types.NamespacedName{Namespace: "ns1", Name: "issuer-2"}
+	cr1 := types.NamespacedName{Namespace: "ns1", Name: "cr1"}
+	cr2 := types.NamespacedName{Namespace: "ns1", Name: "cr2"}
+	cr3 := types.NamespacedName{Namespace: "ns1", Name: "cr3"}
+	now := randomTime()
+
+	cache := &IssuerNotReadyCache{TTL: time.Minute, MaxEntries: 2}
+	cache.setWaiting(gvk, issuer1, cr1, now)
+	cache.setWaiting(gvk, issuer1, cr2, now)
+	cache.setWaiting(gvk, issuer1, cr2, now)
+	assert.Equal(t, 2, cache.size)
+
+	// The issuer that expires first is evicted with all its requests.
+	cache.setWaiting(gvk, issuer2, cr3, now.Add(time.Second))
+	assert.False(t, cache.isWaiting(gvk, issuer1, cr1, now.Add(time.Second)))
+	assert.False(t, cache.isWaiting(gvk, issuer1, cr2, now.Add(time.Second)))
+	assert.True(t, cache.isWaiting(gvk, issuer2, cr3, now.Add(time.Second)))
+	assert.Equal(t, 1, cache.size)
+
+	// Expired issuers are removed when a request is recorded.
+	cache.setWaiting(gvk, issuer1, cr1, now.Add(2*time.Minute))
+	assert.Len(t, cache.issuers, 1)
+	assert.Equal(t, 1, cache.size)
+
+	cache.forgetRequest(cr1)
+	assert.Equal(t, 0, cache.size)
+}
+
+func TestIssuerNotReadyCacheReconcile(t *testing.T) {
+	t.Parallel()
+
+	fakeClock := timetravel.NewRandomClock()
+
+	issuer := testutil.TestIssuer(
+		"issuer-1",
+		testutil.SetTestIssuerNamespace("ns1"),
+		testutil.SetTestIssuerStatusCondition(
+			fakeClock,
+			cmapi.IssuerConditionReady,
+			cmmeta.ConditionFalse,
+			v1alpha1.IssuerConditionReasonPending,
+			"Not ready yet",
+		),
+	)
+
+	cr1 := cmgen.CertificateRequest(
+		"cr1",
+		cmgen.SetCertificateRequestNamespace("ns1"),
+		cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+			Name:  issuer.Name,
+			Group: api.SchemeGroupVersion.Group,
+		}),
+		cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+			Type:   cmapi.CertificateRequestConditionReady,
+			Status: cmmeta.ConditionUnknown,
+			Reason: v1alpha1.CertificateRequestConditionReasonInitializing,
+		}),
+		cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+			Type:   cmapi.CertificateRequestConditionApproved,
+			Status: cmmeta.ConditionTrue,
+		}),
+	)
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, setupCertificateRequestReconcilerScheme(scheme))
+	require.NoError(t, api.AddToScheme(scheme))
+
+	issuerReads := 0
+	applierFuncs := ssafake.NewApplier().InterceptorFuncs()
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(cr1, issuer).
+		WithStatusSubresource(cr1).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				if _, ok := obj.(*api.TestIssuer); ok {
+					issuerReads++
+				}
+				return c.Get(ctx, key, obj, opts...)
+			},
+			Patch:            applierFuncs.Patch,
+			SubResourcePatch: applierFuncs.SubResourcePatch,
+		}).
+		Build()
+
+	cache := &IssuerNotReadyCache{TTL: time.Minute}
+	eventRecorder := record.NewFakeRecorder(100)
+	controller := (&CertificateRequestReconciler{
+		RequestController: RequestController{
+			IssuerTypes:         []v1alpha1.Issuer{&api.TestIssuer{}},
+			FieldOwner:          "test-issuer-not-ready-cache",
+			MaxRetryDuration:    time.Hour,
+			EventSource:         kubeutil.NewEventStore(),
+			IssuerNotReadyCache: cache,
+			Client:              fakeClient,
+			Sign: func(_ context.Context, _ signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+				return signer.PEMBundle{ChainPEM: []byte("cert")}, nil
+			},
+			EventRecorder: eventRecorder,
+			Clock:         fakeClock,
+		},
+	}).Init()
+	require.NoError(t, controller.setAllIssuerTypesWithGroupVersionKind(scheme))
+
+	request := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cr1)}
+	reconcileRequest := func() {
+		t.Helper()
+
+		result, err := controller.Reconcile(context.TODO(), request)
+		require.NoError(t, err)
+		assert.Equal(t, reconcile.Result{}, result)
+	}
+
+	// The first reconcile reads the issuer and marks the request as waiting.
+	reconcileRequest()
+	assert.Equal(t, 1, issuerReads)
+	assert.Len(t, eventRecorder.Events, 1)
+	<-eventRecorder.Events
+
+	var cr cmapi.CertificateRequest
+	require.NoError(t, fakeClient.Get(context.TODO(), request.NamespacedName, &cr))
+	assert.True(t, cmutil.CertificateRequestHasCondition(&cr, cmapi.CertificateRequestCondition{
+		Type:   cmapi.CertificateRequestConditionReady,
+		Status: cmmeta.ConditionFalse,
+		Reason: cmapi.CertificateRequestReasonPending,
+	}))
+	timetravel.AssertCertificateRequestConditionTransitionedAt(t, &cr, cmapi.CertificateRequestConditionReady, fakeClock.Now())
+	timetravel.AssertCertificateRequestNotFailed(t, &cr)
+
+	// Later reconciles are skipped until the issuer transitions.
+	reconcileRequest()
+	reconcileRequest()
+	assert.Equal(t, 1, issuerReads)
+	assert.Empty(t, eventRecorder.Events)
+
+	predicate := cache.invalidatePredicate(issuer.GroupVersionKind())
+	assert.True(t, predicate.Update(event.UpdateEvent{ObjectOld: issuer, ObjectNew: issuer}))
+
+	reconcileRequest()
+	assert.Equal(t, 2, issuerReads)
+
+	// The request is reconciled again once the TTL expired.
+	reconcileRequest()
+	assert.Equal(t, 2, issuerReads)
+
+	fakeClock.Advance(time.Minute)
+	reconcileRequest()
+	assert.Equal(t, 3, issuerReads)
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/cert-manager/issuer-lib/annotations"
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+)
+
+const eventRequestInvalidIssuerOverride = "InvalidIssuerOverride"
+
+// overrideIssuer returns the issuer that is referenced by the RequestIssuerOverride
+// annotation of the request, if AllowIssuerOverride is enabled. Otherwise, the
+// issuer that the request references is returned. An error is returned if the
+// annotation does not reference one of the issuer types of the controller, or
+// references a namespaced issuer outside of the namespace of the request.
+func (r *RequestController) overrideIssuer(
+	requestObject client.Object,
+	issuerObject v1alpha1.Issuer,
+	issuerName types.NamespacedName,
+) (v1alpha1.Issuer, types.NamespacedName, bool, error) {
+	value, ok := requestObject.GetAnnotations()[annotations.RequestIssuerOverride]
+	if !r.AllowIssuerOverride || !ok {
+		return issuerObject, issuerName, false, nil
+	}
+
+	issuerRef, err := annotations.ParseIssuerReference(value)
+	if err != nil {
+		return nil, types.NamespacedName{}, false, err
+	}
+
+	for _, issuerType := range r.AllIssuerTypes() {
+		if issuerType.Type.GetIssuerTypeIdentifier() != issuerRef.IssuerTypeIdentifier {
+			continue
+		}
+
+		switch {
+		case issuerType.IsNamespaced && issuerRef.Namespace == "":
+			return nil, types.NamespacedName{}, false, fmt.Errorf("issuer override %q references a namespaced issuer type without a namespace", value)
+		case issuerType.IsNamespaced && issuerRef.Namespace != requestObject.GetNamespace():
+			return nil, types.NamespacedName{}, false, fmt.Errorf("issuer override %q references a namespaced issuer outside of the namespace of the request", value)
+		case !issuerType.IsNamespaced && issuerRef.Namespace != "":
+			return nil, types.NamespacedName{}, false, fmt.Errorf("issuer override %q references a cluster-scoped issuer type with a namespace", value)
+		}
+
+		return issuerType.Type.DeepCopyObject().(v1alpha1.Issuer), types.NamespacedName{
+			Namespace: issuerRef.Namespace,
+			Name:      issuerRef.Name,
+		}, true, nil
+	}
+
+	return nil, types.NamespacedName{}, false, fmt.Errorf("issuer override %q references an unknown issuer type", value)
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/internal/kubeutil"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+	"github.com/cert-manager/issuer-lib/internal/tests/errormatch"
+)
+
+func TestOverrideIssuer(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name string
+
+		allowIssuerOverride bool
+		override            string
+
+		expectedIssuerType v1alpha1.Issuer
+		expectedIssuerName types.NamespacedName
+		expectedOverridden bool
+		validateError      *errormatch.Matcher
+	}
+
+	originalIssuerName := types.NamespacedName{Namespace: "ns1", Name: "original"}
+
+	testcases := []testcase{
+		{
+			name:                "no-annotation",
+			allowIssuerOverride: true,
+
+			expectedIssuerType: &api.TestIssuer{},
+			expectedIssuerName: originalIssuerName,
+			validateError:      errormatch.NoError(),
+		},
+		{
+			name:     "override-not-allowed",
+			override: "testclusterissuers.testing.cert-manager.io/backup",
+
+			expectedIssuerType: &api.TestIssuer{},
+			expectedIssuerName: originalIssuerName,
+			validateError:      errormatch.NoError(),
+		},
+		{
+			name:                "override-cluster-issuer",
+			allowIssuerOverride: true,
+			override:            "testclusterissuers.testing.cert-manager.io/backup",
+
+			expectedIssuerType: &api.TestClusterIssuer{},
+			expectedIssuerName: types.NamespacedName{Name: "backup"},
+			expectedOverridden: true,
+			validateError:      errormatch.NoError(),
+		},
+		{
+			name:                "override-namespaced-issuer",
+			allowIssuerOverride: true,
+			override:            "testissuers.testing.cert-manager.io/ns1/backup",
+
+			expectedIssuerType: &api.TestIssuer{},
+			expectedIssuerName: types.NamespacedName{Namespace: "ns1", Name: "backup"},
+			expectedOverridden: true,
+			validateError:      errormatch.NoError(),
+		},
+		{
+			name:                "namespaced-issuer-in-other-namespace",
+			allowIssuerOverride: true,
+			override:            "testissuers.testing.cert-manager.io/ns2/backup",
+
+			validateError: errormatch.ErrorContains("references a namespaced issuer outside of the namespace of the request"),
+		},
+		{
+			name:                "namespaced-issuer-without-namespace",
+			allowIssuerOverride: true,
+			override:            "testissuers.testing.cert-manager.io/backup",
+
+			validateError: errormatch.ErrorContains("references a namespaced issuer type without a namespace"),
+		},
+		{
+			name:                "cluster-issuer-with-namespace",
+			allowIssuerOverride: true,
+			override:            "testclusterissuers.testing.cert-manager.io/ns1/backup",
+
+			validateError: errormatch.ErrorContains("references a cluster-scoped issuer type with a namespace"),
+		},
+		{
+			name:                "unknown-issuer-type",
+			allowIssuerOverride: true,
+			override:            "otherissuers.example.com/backup",
+
+			validateError: errormatch.ErrorContains("issuer override \"otherissuers.example.com/backup\" references an unknown issuer type"),
+		},
+		{
+			name:                "invalid-reference",
+			allowIssuerOverride: true,
+			override:            "backup",
+
+			validateError: errormatch.ErrorContains("issuer reference \"backup\" is not formatted as"),
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, api.AddToScheme(scheme))
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := &RequestController{
+				IssuerTypes:         []v1alpha1.Issuer{&api.TestIssuer{}},
+				ClusterIssuerTypes:  []v1alpha1.Issuer{&api.TestClusterIssuer{}},
+				AllowIssuerOverride: tc.allowIssuerOverride,
+			}
+			require.NoError(t, r.setAllIssuerTypesWithGroupVersionKind(scheme))
+
+			cr := &cmapi.CertificateRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "ns1",
+					Name:      "request",
+				},
+			}
+			if tc.override != "" {
+				cr.Annotations = map[string]string{v1alpha1.RequestIssuerOverrideAnnotationKey: tc.override}
+			}
+
+			originalIssuer := &api.TestIssuer{}
+			require.NoError(t, kubeutil.SetGroupVersionKind(scheme, originalIssuer))
+
+			issuerType, issuerName, overridden, err := r.overrideIssuer(cr, originalIssuer, originalIssuerName)
+			(*tc.validateError)(t, err)
+
+			if tc.expectedIssuerType != nil {
+				require.NoError(t, kubeutil.SetGroupVersionKind(scheme, tc.expectedIssuerType))
+				assert.Equal(t, tc.expectedIssuerType, issuerType)
+			} else {
+				assert.Nil(t, issuerType)
+			}
+			assert.Equal(t, tc.expectedIssuerName, issuerName)
+			assert.Equal(t, tc.expectedOverridden, overridden)
+		})
+	}
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	v1alpha1 "github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/kubeutil"
+)
+
+// IssuerSecretReconciler watches the Secrets that issuers depend on and sets
+// the IssuerSecretHashAnnotationKey annotation on an issuer to a hash of the
+// contents of its Secrets. A change of the annotation triggers the issuer
+// controller, which re-runs the Check function. This way, issuers react to
+// rotated credentials without any custom code.
+//
+// NOTE: this controller caches all Secrets in the cluster (or in the namespaces
+// that the manager's cache is restricted to).
+type IssuerSecretReconciler struct {
+	ForObject v1alpha1.Issuer
+
+	// ClusterResourceNamespace is the namespace in which the Secrets without a
+	// namespace are looked up for cluster-scoped issuers. For namespaced issuers,
+	// these Secrets are looked up in the namespace of the issuer.
+	ClusterResourceNamespace string
+
+	// Client is a controller-runtime client used to get and set K8S API resources
+	client.Client
+	// IssuerSecretRefs returns the Secrets that an issuer depends on.
+	signer.IssuerSecretRefs
+}
+
+func (r *IssuerSecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithName("Reconcile")
+
+	logger.V(2).Info("Starting reconcile loop", "name", req.Name, "namespace", req.Namespace)
+
+	issuer := r.ForObject.DeepCopyObject().(v1alpha1.Issuer)
+	if err := r.Client.Get(ctx, req.NamespacedName, issuer); err != nil && apierrors.IsNotFound(err) {
+		logger.V(1).Info("Issuer not found. Ignoring.")
+		return ctrl.Result{}, nil // done
+	} else if err != nil {
+		return ctrl.Result{}, fmt.Errorf("unexpected get error: %v", err) // requeue with backoff
+	}
+
+	hash, err := r.secretsHash(ctx, issuer)
+	if err != nil {
+		return ctrl.Result{}, err // requeue with backoff
+	}
+
+	if issuer.GetAnnotations()[v1alpha1.IssuerSecretHashAnnotationKey] == hash {
+		return ctrl.Result{}, nil // done
+	}
+
+	logger.V(1).Info("Secrets of the issuer changed. Updating the secret hash annotation.", "hash", hash)
+
+	patch := client.MergeFrom(issuer.DeepCopyObject().(v1alpha1.Issuer))
+	annotations := issuer.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[v1alpha1.IssuerSecretHashAnnotationKey] = hash
+	issuer.SetAnnotations(annotations)
+
+	if err := r.Client.Patch(ctx, issuer, patch); err != nil && !apierrors.IsNotFound(err) {
+		return ctrl.Result{}, fmt.Errorf("failed to update secret hash annotation: %v", err) // requeue with backoff
+	}
+
+	return ctrl.Result{}, nil // done
+}
+
+// secretsHash returns a hash of the names and contents of the Secrets that the
+// issuer depends on. Secrets that don't exist are included in the hash as well,
+// so the creation of a missing Secret also changes the hash.
+func (r *IssuerSecretReconciler) secretsHash(ctx context.Context, issuer v1alpha1.Issuer) (string, error) {
+	refs := r.secretRefs(issuer)
+	slices.SortFunc(refs, func(a, b types.NamespacedName) int {
+		return strings.Compare(a.String(), b.String())
+	})
+
+	hash := sha256.New()
+	for _, ref := range refs {
+		fmt.Fprintf(hash, "secret:%s\n", ref)
+
+		var secret corev1.Secret
+		if err := r.Client.Get(ctx, ref, &secret); err != nil && apierrors.IsNotFound(err) {
+			fmt.Fprintf(hash, "missing\n")
+			continue
+		} else if err != nil {
+			return "", fmt.Errorf("unexpected get error: %v", err)
+		}
+
+		keys := make([]string, 0, len(secret.Data))
+		for key := range secret.Data {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+
+		for _, key := range keys {
+			fmt.Fprintf(hash, "%s:%d:", key, len(secret.Data[key]))
+			hash.Write(secret.Data[key])
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// secretRefs returns the Secrets that the issuer depends on, Secrets without a
+// namespace are resolved to the namespace of the issuer or, for cluster-scoped
+// issuers, to the ClusterResourceNamespace.
+func (r *IssuerSecretReconciler) secretRefs(issuer v1alpha1.Issuer) []types.NamespacedName {
+	refs := slices.Clone(r.IssuerSecretRefs(issuer))
+	for i := range refs {
+		if refs[i].Namespace != "" {
+			continue
+		}
+
+		if refs[i].Namespace = issuer.GetNamespace(); refs[i].Namespace == "" {
+			refs[i].Namespace = r.ClusterResourceNamespace
+		}
+	}
+	return refs
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *IssuerSecretReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
+	if err := kubeutil.SetGroupVersionKind(mgr.GetScheme(), r.ForObject); err != nil {
+		return err
+	}
+	forObjectGvk := r.ForObject.GetObjectKind().GroupVersionKind()
+
+	// This context is passed through to the client-go informer factory and the
+	// timeout dictates how long to wait for the informer to sync with the K8S
+	// API server (see RequestController.SetupWithManager).
+	timeout := mgr.GetControllerOptions().CacheSyncTimeout
+	if timeout == 0 {
+		timeout = 2 * time.Minute
+	}
+	cacheSyncCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	secretHandler, err := kubeutil.NewLinkedResourceHandler(
+		cacheSyncCtx,
+		mgr.GetLogger(),
+		mgr.GetScheme(),
+		mgr.GetCache(),
+		r.ForObject,
+		func(rawObj client.Object) []string {
+			issuer, ok := rawObj.(v1alpha1.Issuer)
+			if !ok {
+				return nil
+			}
+
+			refs := r.secretRefs(issuer)
+			ids := make([]string, 0, len(refs))
+			for _, ref := range refs {
+				ids = append(ids, fmt.Sprintf("%s/%s", ref.Namespace, ref.Name))
+			}
+			return ids
+		},
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(strings.ToLower(forObjectGvk.Kind)+"-secrets").
+		For(
+			r.ForObject,
+			builder.WithPredicates(
+				predicate.GenerationChangedPredicate{},
+			),
+		).
+		Watches(
+			&corev1.Secret{},
+			secretHandler,
+			builder.WithPredicates(
+				predicate.ResourceVersionChangedPredicate{},
+			),
+		).
+		Complete(r)
+}
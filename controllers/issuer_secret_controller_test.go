@@ -0,0 +1,132 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+	"github.com/cert-manager/issuer-lib/internal/testapi/testutil"
+)
+
+func TestIssuerSecretReconcilerReconcile(t *testing.T) {
+	t.Parallel()
+
+	issuer1 := testutil.TestIssuer(
+		"issuer-1",
+		testutil.SetTestIssuerNamespace("ns1"),
+	)
+
+	secret1 := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "secret-1", Namespace: "ns1"},
+		Data:       map[string][]byte{"token": []byte("token-1")},
+	}
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, api.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(issuer1, secret1).
+		Build()
+
+	controller := &IssuerSecretReconciler{
+		ForObject: &api.TestIssuer{},
+		Client:    fakeClient,
+		IssuerSecretRefs: func(issuerObject v1alpha1.Issuer) []types.NamespacedName {
+			return []types.NamespacedName{
+				{Namespace: issuerObject.GetNamespace(), Name: "secret-1"},
+				{Namespace: issuerObject.GetNamespace(), Name: "secret-2"},
+			}
+		},
+	}
+
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(issuer1)}
+
+	reconcileAndGetHash := func() string {
+		res, err := controller.Reconcile(context.TODO(), req)
+		require.NoError(t, err)
+		require.Equal(t, reconcile.Result{}, res)
+
+		var issuer api.TestIssuer
+		require.NoError(t, fakeClient.Get(context.TODO(), req.NamespacedName, &issuer))
+		return issuer.GetAnnotations()[v1alpha1.IssuerSecretHashAnnotationKey]
+	}
+
+	// The hash is set on the first reconcile.
+	hash1 := reconcileAndGetHash()
+	require.NotEmpty(t, hash1)
+
+	// The hash is stable as long as the Secrets don't change.
+	require.Equal(t, hash1, reconcileAndGetHash())
+
+	// The hash changes when a referenced Secret changes.
+	secret1.Data["token"] = []byte("token-2")
+	require.NoError(t, fakeClient.Update(context.TODO(), secret1))
+	hash2 := reconcileAndGetHash()
+	require.NotEqual(t, hash1, hash2)
+
+	// The hash changes when a missing Secret is created.
+	require.NoError(t, fakeClient.Create(context.TODO(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "secret-2", Namespace: "ns1"},
+	}))
+	require.NotEqual(t, hash2, reconcileAndGetHash())
+
+	// A missing issuer is ignored.
+	res, err := controller.Reconcile(context.TODO(), reconcile.Request{
+		NamespacedName: types.NamespacedName{Namespace: "ns1", Name: "missing"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, reconcile.Result{}, res)
+}
+
+func TestIssuerSecretReconcilerSecretRefs(t *testing.T) {
+	t.Parallel()
+
+	controller := &IssuerSecretReconciler{
+		ClusterResourceNamespace: "cert-manager",
+		IssuerSecretRefs: func(issuerObject v1alpha1.Issuer) []types.NamespacedName {
+			return []types.NamespacedName{
+				{Name: "secret-1"},
+				{Namespace: "other", Name: "secret-2"},
+			}
+		},
+	}
+
+	// Secrets without a namespace are looked up in the namespace of a namespaced
+	// issuer, and in the cluster resource namespace for a cluster-scoped issuer.
+	require.Equal(t, []types.NamespacedName{
+		{Namespace: "ns1", Name: "secret-1"},
+		{Namespace: "other", Name: "secret-2"},
+	}, controller.secretRefs(testutil.TestIssuer("issuer-1", testutil.SetTestIssuerNamespace("ns1"))))
+
+	require.Equal(t, []types.NamespacedName{
+		{Namespace: "cert-manager", Name: "secret-1"},
+		{Namespace: "other", Name: "secret-2"},
+	}, controller.secretRefs(testutil.TestClusterIssuer("cluster-issuer-1")))
+}
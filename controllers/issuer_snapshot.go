@@ -0,0 +1,141 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+)
+
+// issuerSnapshotMaxSize is the maximum size of the value of the
+// RequestIssuerSnapshotAnnotationKey annotation. The fields of larger snapshots
+// are omitted.
+const issuerSnapshotMaxSize = 8 * 1024
+
+// IssuerSnapshotPolicy records a snapshot of the issuer of a request in the
+// RequestIssuerSnapshotAnnotationKey annotation when the request fails
+// permanently, so that post-mortems can see the configuration of the issuer at
+// the time of the failure, even if the issuer was edited since. It requires
+// patch permissions on the requests.
+type IssuerSnapshotPolicy struct {
+	// Fields are the paths of the fields of the issuer that are included in the
+	// snapshot, as dot-separated field names (eg. "spec.url"). Fields that are
+	// not set are omitted. Only the identity and generation of the issuer are
+	// recorded if Fields is empty.
+	//
+	// The snapshot can be read by everyone who can read the request, so never
+	// include fields that contain credentials.
+	Fields []string
+}
+
+// IssuerSnapshot is the value of the RequestIssuerSnapshotAnnotationKey
+// annotation.
+type IssuerSnapshot struct {
+	Group           string `json:"group"`
+	Kind            string `json:"kind"`
+	Namespace       string `json:"namespace,omitempty"`
+	Name            string `json:"name"`
+	UID             string `json:"uid"`
+	Generation      int64  `json:"generation"`
+	ResourceVersion string `json:"resourceVersion"`
+
+	// Fields contains the values of the selected fields, by path.
+	Fields map[string]interface{} `json:"fields,omitempty"`
+	// FieldsOmitted is true if the fields were omitted because the snapshot
+	// exceeded the maximum size of the annotation.
+	FieldsOmitted bool `json:"fieldsOmitted,omitempty"`
+}
+
+// snapshot returns the snapshot of the issuer.
+func (p *IssuerSnapshotPolicy) snapshot(issuerObject v1alpha1.Issuer, issuerGvk schema.GroupVersionKind) (IssuerSnapshot, error) {
+	snapshot := IssuerSnapshot{
+		Group:           issuerGvk.Group,
+		Kind:            issuerGvk.Kind,
+		Namespace:       issuerObject.GetNamespace(),
+		Name:            issuerObject.GetName(),
+		UID:             string(issuerObject.GetUID()),
+		Generation:      issuerObject.GetGeneration(),
+		ResourceVersion: issuerObject.GetResourceVersion(),
+	}
+	if len(p.Fields) == 0 {
+		return snapshot, nil
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(issuerObject)
+	if err != nil {
+		return IssuerSnapshot{}, fmt.Errorf("failed to convert the issuer: %w", err)
+	}
+
+	for _, path := range p.Fields {
+		value, found, err := unstructured.NestedFieldNoCopy(content, strings.Split(path, ".")...)
+		if err != nil || !found {
+			continue
+		}
+		if snapshot.Fields == nil {
+			snapshot.Fields = map[string]interface{}{}
+		}
+		snapshot.Fields[path] = value
+	}
+	return snapshot, nil
+}
+
+// record sets the snapshot of the issuer on the request. A nil policy does not
+// record anything.
+func (p *IssuerSnapshotPolicy) record(
+	ctx context.Context,
+	cl client.Client,
+	requestObject client.Object,
+	issuerObject v1alpha1.Issuer,
+	issuerGvk schema.GroupVersionKind,
+) error {
+	if p == nil {
+		return nil
+	}
+
+	snapshot, err := p.snapshot(issuerObject, issuerGvk)
+	if err != nil {
+		return err
+	}
+
+	value, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	if len(value) > issuerSnapshotMaxSize {
+		snapshot.Fields = nil
+		snapshot.FieldsOmitted = true
+		if value, err = json.Marshal(snapshot); err != nil {
+			return err
+		}
+	}
+
+	if err := patchAnnotations(ctx, cl, requestObject, "", map[string]string{
+		v1alpha1.RequestIssuerSnapshotAnnotationKey: string(value),
+	}); err != nil {
+		return fmt.Errorf("failed to set issuer snapshot annotation on request: %w", err)
+	}
+	return nil
+}
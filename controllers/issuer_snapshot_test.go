@@ -0,0 +1,210 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	cmgen "github.com/cert-manager/cert-manager/test/unit/gen"
+	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/kubeutil"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+	"github.com/cert-manager/issuer-lib/internal/testapi/testutil"
+)
+
+func TestCertificateRequestReconcilerIssuerSnapshot(t *testing.T) {
+	t.Parallel()
+
+	fieldOwner := "test-certificate-request-reconciler-issuer-snapshot"
+
+	fakeClock := clocktesting.NewFakeClock(randomTime())
+
+	signFails := func(_ context.Context, _ signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+		return signer.PEMBundle{}, signer.PermanentError{Err: errors.New("rejected by the CA")}
+	}
+
+	type testCase struct {
+		name              string
+		sign              signer.Sign
+		policy            *IssuerSnapshotPolicy
+		issuerAnnotations map[string]string
+		expectedSnapshot  *IssuerSnapshot
+	}
+
+	identity := IssuerSnapshot{
+		Group:      api.SchemeGroupVersion.Group,
+		Kind:       "TestIssuer",
+		Namespace:  "ns1",
+		Name:       "issuer-1",
+		UID:        "issuer-uid",
+		Generation: 3,
+	}
+
+	tests := []testCase{
+		{
+			name:             "no-policy",
+			sign:             signFails,
+			policy:           nil,
+			expectedSnapshot: nil,
+		},
+		{
+			name:             "identity-only",
+			sign:             signFails,
+			policy:           &IssuerSnapshotPolicy{},
+			expectedSnapshot: &identity,
+		},
+		{
+			name:              "selected-fields",
+			sign:              signFails,
+			policy:            &IssuerSnapshotPolicy{Fields: []string{"metadata.annotations", "spec.url"}},
+			issuerAnnotations: map[string]string{"ca": "https://ca.example.com"},
+			expectedSnapshot: func() *IssuerSnapshot {
+				snapshot := identity
+				snapshot.Fields = map[string]interface{}{
+					"metadata.annotations": map[string]interface{}{"ca": "https://ca.example.com"},
+				}
+				return &snapshot
+			}(),
+		},
+		{
+			name:              "oversized-fields-are-omitted",
+			sign:              signFails,
+			policy:            &IssuerSnapshotPolicy{Fields: []string{"metadata.annotations"}},
+			issuerAnnotations: map[string]string{"large": strings.Repeat("x", issuerSnapshotMaxSize)},
+			expectedSnapshot: func() *IssuerSnapshot {
+				snapshot := identity
+				snapshot.FieldsOmitted = true
+				return &snapshot
+			}(),
+		},
+		{
+			name: "not-recorded-on-success",
+			sign: func(_ context.Context, _ signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+				return signer.PEMBundle{ChainPEM: []byte("cert")}, nil
+			},
+			policy:           &IssuerSnapshotPolicy{},
+			expectedSnapshot: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			issuer := testutil.TestIssuer(
+				"issuer-1",
+				testutil.SetTestIssuerNamespace("ns1"),
+				testutil.SetTestIssuerGeneration(3),
+				testutil.SetTestIssuerAnnotations(tc.issuerAnnotations),
+				testutil.SetTestIssuerStatusCondition(
+					fakeClock,
+					cmapi.IssuerConditionReady,
+					cmmeta.ConditionTrue,
+					v1alpha1.IssuerConditionReasonChecked,
+					"Succeeded checking the issuer",
+				),
+			)
+			issuer.UID = "issuer-uid"
+
+			cr1 := cmgen.CertificateRequest(
+				"cr1",
+				cmgen.SetCertificateRequestNamespace("ns1"),
+				cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+					Name:  issuer.Name,
+					Group: api.SchemeGroupVersion.Group,
+				}),
+				cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+					Type:   cmapi.CertificateRequestConditionReady,
+					Status: cmmeta.ConditionUnknown,
+					Reason: v1alpha1.CertificateRequestConditionReasonInitializing,
+				}),
+				cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+					Type:   cmapi.CertificateRequestConditionApproved,
+					Status: cmmeta.ConditionTrue,
+				}),
+			)
+
+			scheme := runtime.NewScheme()
+			require.NoError(t, setupCertificateRequestReconcilerScheme(scheme))
+			require.NoError(t, api.AddToScheme(scheme))
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(cr1, issuer).
+				Build()
+
+			var currentIssuer api.TestIssuer
+			require.NoError(t, fakeClient.Get(context.TODO(), client.ObjectKeyFromObject(issuer), &currentIssuer))
+
+			logger := logrtesting.NewTestLoggerWithOptions(t, logrtesting.Options{LogTimestamp: true, Verbosity: 10})
+
+			controller := (&CertificateRequestReconciler{
+				RequestController: RequestController{
+					IssuerTypes:          []v1alpha1.Issuer{&api.TestIssuer{}},
+					FieldOwner:           fieldOwner,
+					MaxRetryDuration:     time.Minute,
+					EventSource:          kubeutil.NewEventStore(),
+					IssuerSnapshotPolicy: tc.policy,
+					Client:               fakeClient,
+					Sign:                 tc.sign,
+					EventRecorder:        record.NewFakeRecorder(100),
+					Clock:                fakeClock,
+				},
+			}).Init()
+			require.NoError(t, controller.setAllIssuerTypesWithGroupVersionKind(scheme))
+
+			_, _, _ = controller.reconcileStatusPatch(logger, context.TODO(), reconcile.Request{
+				NamespacedName: client.ObjectKeyFromObject(cr1),
+			})
+
+			var currentCr cmapi.CertificateRequest
+			require.NoError(t, fakeClient.Get(context.TODO(), client.ObjectKeyFromObject(cr1), &currentCr))
+
+			value, ok := currentCr.Annotations[v1alpha1.RequestIssuerSnapshotAnnotationKey]
+			if tc.expectedSnapshot == nil {
+				assert.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			assert.LessOrEqual(t, len(value), issuerSnapshotMaxSize)
+
+			var snapshot IssuerSnapshot
+			require.NoError(t, json.Unmarshal([]byte(value), &snapshot))
+
+			expected := *tc.expectedSnapshot
+			expected.ResourceVersion = currentIssuer.ResourceVersion
+			assert.Equal(t, expected, snapshot)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+// checkKeyAlgorithm returns a PermanentError if the CSR of the request uses a
+// public key algorithm that is not supported by the issuer.
+func (r *RequestController) checkKeyAlgorithm(cr signer.CertificateRequestObject, issuerObject v1alpha1.Issuer) error {
+	if r.SupportedKeyAlgorithms == nil {
+		return nil
+	}
+
+	supported := r.SupportedKeyAlgorithms(issuerObject)
+	if len(supported) == 0 {
+		return nil
+	}
+
+	csr, err := parseRequestCSR(cr)
+	if err != nil {
+		return err
+	}
+
+	return signer.CheckKeyAlgorithm(csr, supported)
+}
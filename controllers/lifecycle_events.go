@@ -0,0 +1,146 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/internal/eventbus"
+)
+
+// lifecycleEventBufferSize is the number of lifecycle events that are buffered
+// per subscriber, further events are dropped until the subscriber caught up.
+const lifecycleEventBufferSize = 1024
+
+// LifecycleEvent is an event emitted by the controllers during the reconcile
+// of a request or issuer. It is one of *RequestObserved, *SignStarted,
+// *SignFinished or *IssuerReadyChanged.
+//
+// The objects in the events are copies that must not be modified.
+type LifecycleEvent interface {
+	lifecycleEvent()
+}
+
+// RequestObserved is emitted when a request (a *cmapi.CertificateRequest or a
+// *certificatesv1.CertificateSigningRequest) that references one of the issuer
+// types is reconciled.
+type RequestObserved struct {
+	Request    client.Object
+	IssuerGVK  schema.GroupVersionKind
+	IssuerName types.NamespacedName
+}
+
+// SignStarted is emitted before the Sign function is called for a request.
+type SignStarted struct {
+	Request client.Object
+	Issuer  v1alpha1.Issuer
+}
+
+// SignFinished is emitted after the Sign function returned for a request. Err
+// is the (classified) error returned by Sign, or nil if a certificate was signed.
+type SignFinished struct {
+	Request  client.Object
+	Issuer   v1alpha1.Issuer
+	Duration time.Duration
+	Err      error
+}
+
+// IssuerReadyChanged is emitted when the issuer controller changes the status
+// of the Ready condition of an issuer, or sets the Ready condition for the first
+// time (in which case PreviousStatus is empty). The event is emitted before the
+// status is applied, so it is emitted again if applying the status fails.
+type IssuerReadyChanged struct {
+	Issuer         v1alpha1.Issuer
+	PreviousStatus cmmeta.ConditionStatus
+	Status         cmmeta.ConditionStatus
+	Reason         string
+	Message        string
+}
+
+func (*RequestObserved) lifecycleEvent()    {}
+func (*SignStarted) lifecycleEvent()        {}
+func (*SignFinished) lifecycleEvent()       {}
+func (*IssuerReadyChanged) lifecycleEvent() {}
+
+func newLifecycleEventBus() *eventbus.Bus[LifecycleEvent] {
+	return eventbus.New[LifecycleEvent](lifecycleEventBufferSize, lifecycleEventsDropped.Inc)
+}
+
+func (r *RequestController) publishRequestObserved(request client.Object, issuerGvk schema.GroupVersionKind, issuerName types.NamespacedName) {
+	if !r.lifecycleEvents.HasSubscribers() {
+		return
+	}
+
+	r.lifecycleEvents.Publish(&RequestObserved{
+		Request:    request.DeepCopyObject().(client.Object),
+		IssuerGVK:  issuerGvk,
+		IssuerName: issuerName,
+	})
+}
+
+func (r *RequestController) publishSignStarted(request client.Object, issuerObject v1alpha1.Issuer) {
+	if !r.lifecycleEvents.HasSubscribers() {
+		return
+	}
+
+	r.lifecycleEvents.Publish(&SignStarted{
+		Request: request.DeepCopyObject().(client.Object),
+		Issuer:  issuerObject.DeepCopyObject().(v1alpha1.Issuer),
+	})
+}
+
+func (r *RequestController) publishSignFinished(request client.Object, issuerObject v1alpha1.Issuer, duration time.Duration, err error) {
+	if !r.lifecycleEvents.HasSubscribers() {
+		return
+	}
+
+	r.lifecycleEvents.Publish(&SignFinished{
+		Request:  request.DeepCopyObject().(client.Object),
+		Issuer:   issuerObject.DeepCopyObject().(v1alpha1.Issuer),
+		Duration: duration,
+		Err:      err,
+	})
+}
+
+func (r *IssuerReconciler) publishIssuerReadyChanged(issuer v1alpha1.Issuer, previous *cmapi.IssuerCondition, current *cmapi.IssuerCondition) {
+	if !r.lifecycleEvents.HasSubscribers() {
+		return
+	}
+
+	previousStatus := cmmeta.ConditionStatus("")
+	if previous != nil {
+		previousStatus = previous.Status
+	}
+	if previousStatus == current.Status {
+		return
+	}
+
+	r.lifecycleEvents.Publish(&IssuerReadyChanged{
+		Issuer:         issuer.DeepCopyObject().(v1alpha1.Issuer),
+		PreviousStatus: previousStatus,
+		Status:         current.Status,
+		Reason:         current.Reason,
+		Message:        current.Message,
+	})
+}
@@ -0,0 +1,236 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	cmgen "github.com/cert-manager/cert-manager/test/unit/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/kubeutil"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+	"github.com/cert-manager/issuer-lib/internal/testapi/testutil"
+	"github.com/cert-manager/issuer-lib/testing/ssafake"
+)
+
+// collectLifecycleEvents subscribes to the lifecycle events of the controller
+// and returns a function that waits for the given number of events, and fails
+// the test if more events are received shortly after.
+func collectLifecycleEvents(t *testing.T, controller *CombinedController) func(n int) []LifecycleEvent {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	events := make(chan LifecycleEvent, 100)
+	controller.Subscribe(ctx, func(event LifecycleEvent) {
+		events <- event
+	})
+
+	return func(n int) []LifecycleEvent {
+		t.Helper()
+
+		received := make([]LifecycleEvent, 0, n)
+		for len(received) < n {
+			select {
+			case event := <-events:
+				received = append(received, event)
+			case <-time.After(5 * time.Second):
+				t.Fatalf("expected %d lifecycle events, got %d", n, len(received))
+			}
+		}
+
+		select {
+		case event := <-events:
+			t.Fatalf("expected %d lifecycle events, got an additional %T event", n, event)
+		case <-time.After(100 * time.Millisecond):
+		}
+		return received
+	}
+}
+
+func TestRequestControllerLifecycleEvents(t *testing.T) {
+	t.Parallel()
+
+	fakeClock := clocktesting.NewFakeClock(randomTime().Truncate(time.Second))
+
+	issuer := testutil.TestIssuer(
+		"issuer-1",
+		testutil.SetTestIssuerNamespace("ns1"),
+		testutil.SetTestIssuerStatusCondition(
+			fakeClock,
+			cmapi.IssuerConditionReady,
+			cmmeta.ConditionTrue,
+			v1alpha1.IssuerConditionReasonChecked,
+			"Succeeded checking the issuer",
+		),
+	)
+
+	cr1 := cmgen.CertificateRequest(
+		"cr-lifecycle-events",
+		cmgen.SetCertificateRequestNamespace("ns1"),
+		cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+			Name:  issuer.Name,
+			Group: api.SchemeGroupVersion.Group,
+		}),
+		cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+			Type:   cmapi.CertificateRequestConditionReady,
+			Status: cmmeta.ConditionFalse,
+			Reason: cmapi.CertificateRequestReasonPending,
+		}),
+		cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+			Type:   cmapi.CertificateRequestConditionApproved,
+			Status: cmmeta.ConditionTrue,
+		}),
+	)
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, setupCertificateRequestReconcilerScheme(scheme))
+	require.NoError(t, api.AddToScheme(scheme))
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(cr1, issuer).
+		WithStatusSubresource(cr1).
+		WithInterceptorFuncs(ssafake.NewApplier().InterceptorFuncs()).
+		Build()
+
+	combined := &CombinedController{}
+	waitForEvents := collectLifecycleEvents(t, combined)
+
+	signErr := errors.New("ca unavailable")
+	controller := (&CertificateRequestReconciler{
+		RequestController: RequestController{
+			IssuerTypes:      []v1alpha1.Issuer{&api.TestIssuer{}},
+			FieldOwner:       "test-lifecycle-events",
+			MaxRetryDuration: time.Hour,
+			EventSource:      kubeutil.NewEventStore(),
+			Client:           fakeClient,
+			Sign: func(_ context.Context, _ signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+				fakeClock.Step(2 * time.Second)
+				return signer.PEMBundle{}, signErr
+			},
+			EventRecorder: record.NewFakeRecorder(100),
+			Clock:         fakeClock,
+
+			lifecycleEvents: combined.lifecycleEventBus(),
+		},
+	}).Init()
+	require.NoError(t, controller.setAllIssuerTypesWithGroupVersionKind(scheme))
+
+	_, _ = controller.Reconcile(context.TODO(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cr1)})
+
+	events := waitForEvents(3)
+
+	observed, ok := events[0].(*RequestObserved)
+	require.True(t, ok, "expected RequestObserved, got %T", events[0])
+	assert.Equal(t, cr1.Name, observed.Request.GetName())
+	assert.Equal(t, "TestIssuer", observed.IssuerGVK.Kind)
+	assert.Equal(t, types.NamespacedName{Namespace: "ns1", Name: "issuer-1"}, observed.IssuerName)
+
+	started, ok := events[1].(*SignStarted)
+	require.True(t, ok, "expected SignStarted, got %T", events[1])
+	assert.Equal(t, cr1.Name, started.Request.GetName())
+	assert.Equal(t, issuer.Name, started.Issuer.GetName())
+
+	finished, ok := events[2].(*SignFinished)
+	require.True(t, ok, "expected SignFinished, got %T", events[2])
+	assert.Equal(t, 2*time.Second, finished.Duration)
+	assert.ErrorIs(t, finished.Err, signErr)
+}
+
+func TestIssuerReconcilerLifecycleEvents(t *testing.T) {
+	t.Parallel()
+
+	issuer := testutil.TestIssuer(
+		"issuer-1",
+		testutil.SetTestIssuerNamespace("ns1"),
+	)
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, api.AddToScheme(scheme))
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(issuer).
+		WithStatusSubresource(issuer).
+		WithInterceptorFuncs(ssafake.NewApplier().InterceptorFuncs()).
+		Build()
+
+	forObject := &api.TestIssuer{}
+	require.NoError(t, kubeutil.SetGroupVersionKind(scheme, forObject))
+
+	combined := &CombinedController{}
+	waitForEvents := collectLifecycleEvents(t, combined)
+
+	controller := IssuerReconciler{
+		ForObject:   forObject,
+		FieldOwner:  "test-lifecycle-events",
+		EventSource: kubeutil.NewEventStore(),
+		Client:      fakeClient,
+		Check: func(_ context.Context, _ v1alpha1.Issuer) error {
+			return nil
+		},
+		EventRecorder: record.NewFakeRecorder(100),
+		Clock:         clocktesting.NewFakeClock(randomTime()),
+
+		lifecycleEvents: combined.lifecycleEventBus(),
+	}
+
+	// The first reconcile calls Check and sets the Ready condition, the second
+	// does not change the status of the Ready condition.
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(issuer)}
+	for i := 0; i < 2; i++ {
+		_, err := controller.Reconcile(context.TODO(), req)
+		require.NoError(t, err)
+	}
+
+	events := waitForEvents(1)
+
+	ready, ok := events[0].(*IssuerReadyChanged)
+	require.True(t, ok, "expected IssuerReadyChanged, got %T", events[0])
+	assert.Equal(t, cmmeta.ConditionStatus(""), ready.PreviousStatus)
+	assert.Equal(t, cmmeta.ConditionTrue, ready.Status)
+	assert.Equal(t, v1alpha1.IssuerConditionReasonChecked, ready.Reason)
+	assert.Equal(t, issuer.Name, ready.Issuer.GetName())
+
+	// Events are not published to subscribers whose context was cancelled.
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	bus := newLifecycleEventBus()
+	bus.Subscribe(ctx, func(LifecycleEvent) { calls++ })
+	assert.True(t, bus.HasSubscribers())
+	cancel()
+	assert.Eventually(t, func() bool { return !bus.HasSubscribers() }, 5*time.Second, 5*time.Millisecond)
+	bus.Publish(&RequestObserved{})
+	assert.Equal(t, 0, calls)
+}
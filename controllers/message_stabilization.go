@@ -0,0 +1,124 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"regexp"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/cert-manager/issuer-lib/conditions"
+)
+
+// DefaultVolatileMessagePatterns match the parts of condition messages that
+// typically change on every retry: timestamps (eg. "2024-01-02T15:04:05Z"),
+// durations (eg. "1m30s") and attempt counters (eg. "attempt 3" or "retry #2").
+var DefaultVolatileMessagePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`),
+	regexp.MustCompile(`\b(\d+(\.\d+)?(ns|us|µs|ms|s|m|h))+\b`),
+	regexp.MustCompile(`(?i)\b(attempt|retry|try)\s*#?\d+`),
+}
+
+// MessageStabilizationPolicy keeps the message of a condition unchanged when the
+// new message only differs from the current message in its volatile parts (eg.
+// a timestamp or an attempt counter in the error returned by Sign or Check).
+// Without this policy, every retry with a slightly different message changes the
+// resourceVersion of the resource, which causes watch events for all clients.
+//
+// The message is only kept if the status and reason of the condition don't
+// change. The events recorded for the condition still contain the new message.
+type MessageStabilizationPolicy struct {
+	// VolatilePatterns match the volatile parts of the messages, which are
+	// ignored when the current and the new message are compared. Defaults to
+	// DefaultVolatileMessagePatterns.
+	VolatilePatterns []*regexp.Regexp
+}
+
+// normalize replaces the volatile parts of the message.
+func (p *MessageStabilizationPolicy) normalize(message string) string {
+	patterns := p.VolatilePatterns
+	if len(patterns) == 0 {
+		patterns = DefaultVolatileMessagePatterns
+	}
+
+	for _, pattern := range patterns {
+		message = pattern.ReplaceAllLiteralString(message, "*")
+	}
+	return message
+}
+
+// stabilize returns the current message if the new message only differs from
+// it in its volatile parts, otherwise the new message is returned. A nil policy
+// always returns the new message.
+func (p *MessageStabilizationPolicy) stabilize(currentMessage, message string) string {
+	if p == nil || currentMessage == message {
+		return message
+	}
+
+	if p.normalize(currentMessage) == p.normalize(message) {
+		return currentMessage
+	}
+	return message
+}
+
+func (p *MessageStabilizationPolicy) stabilizeIssuerCondition(
+	existingConditions []cmapi.IssuerCondition,
+	conditionType cmapi.IssuerConditionType,
+	status cmmeta.ConditionStatus,
+	reason, message string,
+) string {
+	condition := conditions.GetIssuerStatusCondition(existingConditions, conditionType)
+	if condition == nil || condition.Status != status || condition.Reason != reason {
+		return message
+	}
+	return p.stabilize(condition.Message, message)
+}
+
+func (p *MessageStabilizationPolicy) stabilizeCertificateRequestCondition(
+	existingConditions []cmapi.CertificateRequestCondition,
+	conditionType cmapi.CertificateRequestConditionType,
+	status cmmeta.ConditionStatus,
+	reason, message string,
+) string {
+	for _, condition := range existingConditions {
+		if condition.Type != conditionType {
+			continue
+		}
+
+		if condition.Status != status || condition.Reason != reason {
+			return message
+		}
+		return p.stabilize(condition.Message, message)
+	}
+	return message
+}
+
+func (p *MessageStabilizationPolicy) stabilizeCertificateSigningRequestCondition(
+	existingConditions []certificatesv1.CertificateSigningRequestCondition,
+	conditionType certificatesv1.RequestConditionType,
+	status corev1.ConditionStatus,
+	reason, message string,
+) string {
+	condition := conditions.GetCertificateSigningRequestStatusCondition(existingConditions, conditionType)
+	if condition == nil || condition.Status != status || condition.Reason != reason {
+		return message
+	}
+	return p.stabilize(condition.Message, message)
+}
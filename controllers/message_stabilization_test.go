@@ -0,0 +1,140 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	cmgen "github.com/cert-manager/cert-manager/test/unit/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestMessageStabilizationPolicyStabilize(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name            string
+		policy          *MessageStabilizationPolicy
+		currentMessage  string
+		message         string
+		expectedMessage string
+	}
+
+	tests := []testCase{
+		{
+			name:            "nil-policy",
+			policy:          nil,
+			currentMessage:  "CA unavailable at 2024-01-02T15:04:05Z",
+			message:         "CA unavailable at 2024-01-02T15:05:05Z",
+			expectedMessage: "CA unavailable at 2024-01-02T15:05:05Z",
+		},
+		{
+			name:            "timestamp-changed",
+			policy:          &MessageStabilizationPolicy{},
+			currentMessage:  "CA unavailable at 2024-01-02T15:04:05Z",
+			message:         "CA unavailable at 2024-01-02T15:05:05.123+02:00",
+			expectedMessage: "CA unavailable at 2024-01-02T15:04:05Z",
+		},
+		{
+			name:            "duration-and-attempt-changed",
+			policy:          &MessageStabilizationPolicy{},
+			currentMessage:  "attempt 3: rate limited, retry in 1m30s",
+			message:         "Attempt 4: rate limited, retry in 45s",
+			expectedMessage: "attempt 3: rate limited, retry in 1m30s",
+		},
+		{
+			name:            "material-change",
+			policy:          &MessageStabilizationPolicy{},
+			currentMessage:  "CA unavailable at 2024-01-02T15:04:05Z",
+			message:         "CA rejected the request at 2024-01-02T15:05:05Z",
+			expectedMessage: "CA rejected the request at 2024-01-02T15:05:05Z",
+		},
+		{
+			name: "custom-pattern",
+			policy: &MessageStabilizationPolicy{
+				VolatilePatterns: []*regexp.Regexp{regexp.MustCompile(`request id [0-9a-f]+`)},
+			},
+			currentMessage:  "CA error (request id 3f2a)",
+			message:         "CA error (request id 9b1c)",
+			expectedMessage: "CA error (request id 3f2a)",
+		},
+		{
+			name: "custom-pattern-replaces-defaults",
+			policy: &MessageStabilizationPolicy{
+				VolatilePatterns: []*regexp.Regexp{regexp.MustCompile(`request id [0-9a-f]+`)},
+			},
+			currentMessage:  "CA unavailable at 2024-01-02T15:04:05Z",
+			message:         "CA unavailable at 2024-01-02T15:05:05Z",
+			expectedMessage: "CA unavailable at 2024-01-02T15:05:05Z",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expectedMessage, tc.policy.stabilize(tc.currentMessage, tc.message))
+		})
+	}
+}
+
+func TestCertificateRequestPatchMessageStabilization(t *testing.T) {
+	t.Parallel()
+
+	currentMessage := "Failed to sign CertificateRequest, will retry: CA unavailable at 2024-01-02T15:04:05Z"
+	cr := cmgen.CertificateRequest(
+		"cr1",
+		cmgen.SetCertificateRequestNamespace("ns1"),
+		cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+			Type:    cmapi.CertificateRequestConditionReady,
+			Status:  cmmeta.ConditionFalse,
+			Reason:  cmapi.CertificateRequestReasonPending,
+			Message: currentMessage,
+		}),
+	)
+
+	helper := &certificateRequestObjectHelper{
+		readOnlyObj:          cr,
+		messageStabilization: &MessageStabilizationPolicy{},
+	}
+
+	// A message that only differs in its volatile parts keeps the condition
+	// message, the event contains the new message.
+	recorder := record.NewFakeRecorder(10)
+	patchHelper := helper.NewPatch(clocktesting.NewFakeClock(randomTime()), "test", recorder)
+	patchHelper.SetRetryableError(errors.New("CA unavailable at 2024-01-02T15:05:05Z"))
+
+	patch := patchHelper.(*certificateRequestPatchHelper).patch
+	require.Len(t, patch.Conditions, 1)
+	assert.Equal(t, currentMessage, patch.Conditions[0].Message)
+	assert.Equal(t, "Warning RetryableError Failed to sign CertificateRequest, will retry: CA unavailable at 2024-01-02T15:05:05Z", <-recorder.Events)
+
+	// A different reason updates the message.
+	patchHelper = helper.NewPatch(clocktesting.NewFakeClock(randomTime()), "test", recorder)
+	patchHelper.SetPermanentError(errors.New("CA unavailable at 2024-01-02T15:05:05Z"))
+
+	patch = patchHelper.(*certificateRequestPatchHelper).patch
+	require.Len(t, patch.Conditions, 1)
+	assert.Equal(t, "Failed permanently to sign CertificateRequest: CA unavailable at 2024-01-02T15:05:05Z", patch.Conditions[0].Message)
+}
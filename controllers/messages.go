@@ -0,0 +1,238 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	cmutil "github.com/cert-manager/cert-manager/pkg/api/util"
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/cert-manager/issuer-lib/api/v1alpha1"
+)
+
+// MessageCatalog contains the functions used to render the user-visible
+// messages of the conditions and events set by the controllers. It can be
+// used to override individual messages, eg. to link to company-specific
+// documentation or to use different terminology. Functions that are nil
+// render the default message.
+//
+// The request argument is either a *cmapi.CertificateRequest or a
+// *certificatesv1.CertificateSigningRequest.
+type MessageCatalog struct {
+	RequestInitializing                     func(request client.Object, fieldOwner string) string
+	RequestDenied                           func(request client.Object) string
+	RequestWaitingForIssuerExist            func(request client.Object, err error) string
+	RequestWaitingForIssuerReadyNoCondition func(request client.Object) string
+	RequestWaitingForIssuerReadyOutdated    func(request client.Object) string
+	RequestWaitingForIssuerReadyNotReady    func(request client.Object, issuerCondition *cmapi.IssuerCondition) string
+	RequestIssuerPaused                     func(request client.Object) string
+	RequestIssuancePaused                   func(request client.Object, control *v1alpha1.IssuanceControl) string
+	RequestIssuerFailed                     func(request client.Object, issuerCondition *cmapi.IssuerCondition) string
+	RequestScheduledMaintenance             func(request client.Object, window v1alpha1.MaintenanceWindow) string
+	RequestPending                          func(request client.Object, reason string) string
+	RequestUnexpectedError                  func(request client.Object, err error) string
+	RequestRetryableError                   func(request client.Object, err error) string
+	RequestPermanentError                   func(request client.Object, err error) string
+	RequestIssued                           func(request client.Object) string
+	RequestKeyUsageMismatch                 func(request client.Object, mismatch string) string
+
+	// Deprecated: the first reconcile of an Issuer sets the Ready condition to
+	// the result of the checks directly, this message is no longer used.
+	IssuerInitializing   func(issuer v1alpha1.Issuer, fieldOwner string) string
+	IssuerChecked        func(issuer v1alpha1.Issuer) string
+	IssuerPermanentError func(issuer v1alpha1.Issuer, err error) string
+	IssuerRetryableError func(issuer v1alpha1.Issuer, err error) string
+	// IssuerReadyTransition renders the message of the ReadyTransition event,
+	// previous is nil if the issuer did not have a Ready condition yet.
+	IssuerReadyTransition func(issuer v1alpha1.Issuer, previous, current *cmapi.IssuerCondition) string
+}
+
+func requestKind(request client.Object) string {
+	if _, ok := request.(*certificatesv1.CertificateSigningRequest); ok {
+		return "CertificateSigningRequest"
+	}
+	return "CertificateRequest"
+}
+
+func (m *MessageCatalog) requestInitializing(request client.Object, fieldOwner string) string {
+	if m != nil && m.RequestInitializing != nil {
+		return m.RequestInitializing(request, fieldOwner)
+	}
+	return fmt.Sprintf("%s has started reconciling this %s", fieldOwner, requestKind(request))
+}
+
+func (m *MessageCatalog) requestDenied(request client.Object) string {
+	if m != nil && m.RequestDenied != nil {
+		return m.RequestDenied(request)
+	}
+
+	// Include the approver and the reason for the denial when they are known,
+	// so the owner of the request can find out why it was denied.
+	by, details := "", ""
+	if cr, ok := request.(*cmapi.CertificateRequest); ok {
+		if approver := deniedBy(cr); approver != "" {
+			by = " by " + approver
+		}
+		if condition := cmutil.GetCertificateRequestCondition(cr, cmapi.CertificateRequestConditionDenied); condition != nil &&
+			(condition.Reason != "" || condition.Message != "") {
+			details = fmt.Sprintf(" Denied condition is \"%s\": %s.", condition.Reason, strings.TrimSuffix(condition.Message, "."))
+		}
+	}
+
+	return fmt.Sprintf("Detected that the %s is denied%s, so it will never be Ready.%s", requestKind(request), by, details)
+}
+
+func (m *MessageCatalog) requestWaitingForIssuerExist(request client.Object, err error) string {
+	if m != nil && m.RequestWaitingForIssuerExist != nil {
+		return m.RequestWaitingForIssuerExist(request, err)
+	}
+	return fmt.Sprintf("%s. Waiting for it to be created.", err)
+}
+
+func (m *MessageCatalog) requestWaitingForIssuerReadyNoCondition(request client.Object) string {
+	if m != nil && m.RequestWaitingForIssuerReadyNoCondition != nil {
+		return m.RequestWaitingForIssuerReadyNoCondition(request)
+	}
+	return "Waiting for issuer to become ready. Current issuer ready condition: <none>."
+}
+
+func (m *MessageCatalog) requestWaitingForIssuerReadyOutdated(request client.Object) string {
+	if m != nil && m.RequestWaitingForIssuerReadyOutdated != nil {
+		return m.RequestWaitingForIssuerReadyOutdated(request)
+	}
+	return "Waiting for issuer to become ready. Current issuer ready condition is outdated."
+}
+
+func (m *MessageCatalog) requestWaitingForIssuerReadyNotReady(request client.Object, issuerCondition *cmapi.IssuerCondition) string {
+	if m != nil && m.RequestWaitingForIssuerReadyNotReady != nil {
+		return m.RequestWaitingForIssuerReadyNotReady(request, issuerCondition)
+	}
+	return fmt.Sprintf("Waiting for issuer to become ready. Current issuer ready condition is \"%s\": %s.", issuerCondition.Reason, issuerCondition.Message)
+}
+
+func (m *MessageCatalog) requestIssuerPaused(request client.Object) string {
+	if m != nil && m.RequestIssuerPaused != nil {
+		return m.RequestIssuerPaused(request)
+	}
+	return "Waiting for issuer to be unpaused."
+}
+
+func (m *MessageCatalog) requestIssuancePaused(request client.Object, control *v1alpha1.IssuanceControl) string {
+	if m != nil && m.RequestIssuancePaused != nil {
+		return m.RequestIssuancePaused(request, control)
+	}
+	message := fmt.Sprintf("Waiting for issuance to be resumed using the IssuanceControl %q.", control.Name)
+	if control.Spec.Reason != "" {
+		message += " Reason: " + control.Spec.Reason
+	}
+	return message
+}
+
+func (m *MessageCatalog) requestIssuerFailed(request client.Object, issuerCondition *cmapi.IssuerCondition) string {
+	if m != nil && m.RequestIssuerFailed != nil {
+		return m.RequestIssuerFailed(request, issuerCondition)
+	}
+	return fmt.Sprintf("The issuer failed permanently, so the %s will never be Ready: %s", requestKind(request), issuerCondition.Message)
+}
+
+func (m *MessageCatalog) requestScheduledMaintenance(request client.Object, window v1alpha1.MaintenanceWindow) string {
+	if m != nil && m.RequestScheduledMaintenance != nil {
+		return m.RequestScheduledMaintenance(request, window)
+	}
+	return fmt.Sprintf("Waiting for the scheduled maintenance window of the issuer to end at %s.", window.End.Format(time.RFC3339))
+}
+
+func (m *MessageCatalog) requestPending(request client.Object, reason string) string {
+	if m != nil && m.RequestPending != nil {
+		return m.RequestPending(request, reason)
+	}
+	return fmt.Sprintf("Signing still in progress. Reason: %s", reason)
+}
+
+func (m *MessageCatalog) requestUnexpectedError(request client.Object, err error) string {
+	if m != nil && m.RequestUnexpectedError != nil {
+		return m.RequestUnexpectedError(request, err)
+	}
+	return fmt.Sprintf("Got an unexpected error while processing the %s", requestKind(request))
+}
+
+func (m *MessageCatalog) requestRetryableError(request client.Object, err error) string {
+	if m != nil && m.RequestRetryableError != nil {
+		return m.RequestRetryableError(request, err)
+	}
+	return fmt.Sprintf("Failed to sign %s, will retry: %s", requestKind(request), err)
+}
+
+func (m *MessageCatalog) requestPermanentError(request client.Object, err error) string {
+	if m != nil && m.RequestPermanentError != nil {
+		return m.RequestPermanentError(request, err)
+	}
+	if _, ok := request.(*certificatesv1.CertificateSigningRequest); ok {
+		return fmt.Sprintf("CertificateSigningRequest has failed permanently: %s", err)
+	}
+	return fmt.Sprintf("Failed permanently to sign CertificateRequest: %s", err)
+}
+
+func (m *MessageCatalog) requestIssued(request client.Object) string {
+	if m != nil && m.RequestIssued != nil {
+		return m.RequestIssued(request)
+	}
+	return fmt.Sprintf("Succeeded signing the %s", requestKind(request))
+}
+
+func (m *MessageCatalog) requestKeyUsageMismatch(request client.Object, mismatch string) string {
+	if m != nil && m.RequestKeyUsageMismatch != nil {
+		return m.RequestKeyUsageMismatch(request, mismatch)
+	}
+	return fmt.Sprintf("Signed certificate does not match the requested usages: %s", mismatch)
+}
+
+func (m *MessageCatalog) issuerChecked(issuer v1alpha1.Issuer) string {
+	if m != nil && m.IssuerChecked != nil {
+		return m.IssuerChecked(issuer)
+	}
+	return "Succeeded checking the issuer"
+}
+
+func (m *MessageCatalog) issuerPermanentError(issuer v1alpha1.Issuer, err error) string {
+	if m != nil && m.IssuerPermanentError != nil {
+		return m.IssuerPermanentError(issuer, err)
+	}
+	return fmt.Sprintf("Failed permanently: %s", err)
+}
+
+func (m *MessageCatalog) issuerRetryableError(issuer v1alpha1.Issuer, err error) string {
+	if m != nil && m.IssuerRetryableError != nil {
+		return m.IssuerRetryableError(issuer, err)
+	}
+	return fmt.Sprintf("Not ready yet: %s", err)
+}
+
+func (m *MessageCatalog) issuerReadyTransition(issuer v1alpha1.Issuer, previous, current *cmapi.IssuerCondition) string {
+	if m != nil && m.IssuerReadyTransition != nil {
+		return m.IssuerReadyTransition(issuer, previous, current)
+	}
+	if previous == nil {
+		return fmt.Sprintf("Ready condition set to %s (%s)", current.Status, current.Reason)
+	}
+	return fmt.Sprintf("Ready condition changed from %s (%s) to %s (%s)", previous.Status, previous.Reason, current.Status, current.Reason)
+}
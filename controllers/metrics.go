@@ -0,0 +1,133 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	requestQueueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "issuer_lib_request_queue_depth",
+		Help: "Current number of requests waiting in the work queue, per priority class.",
+	}, []string{"controller", "priority_class"})
+
+	requestTenantQueueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "issuer_lib_request_tenant_queue_depth",
+		Help: "Current number of requests waiting in the work queue, per tenant.",
+	}, []string{"controller", "tenant"})
+
+	requestConcurrencyLimit = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "issuer_lib_request_concurrency_limit",
+		Help: "Current number of requests that are reconciled concurrently, as set by the adaptive concurrency policy.",
+	}, []string{"controller"})
+
+	requestStateDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "issuer_lib_request_state_duration_seconds",
+		Help:    "Time that requests spent in the Initializing and Pending states, observed when a request is Issued or Failed.",
+		Buckets: prometheus.ExponentialBuckets(0.1, 4, 10),
+	}, []string{"kind", "state", "outcome"})
+
+	issuedCertificateValidity = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "issuer_lib_issued_certificate_validity_seconds",
+		Help:    "Validity period (NotAfter - NotBefore) of the leaf certificates of issued requests.",
+		Buckets: prometheus.ExponentialBuckets(3600, 2, 14),
+	}, []string{"kind"})
+
+	issuerLiveReads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "issuer_lib_issuer_live_reads_total",
+		Help: "Number of issuers that the request controllers read again using the APIReader because the cached issuer was likely stale, per result of the comparison with the cached issuer.",
+	}, []string{"kind", "result"})
+
+	statusPatchFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "issuer_lib_status_patch_failures_total",
+		Help: "Number of status patches of requests and issuers that were rejected by the API server.",
+	}, []string{"kind", "reason"})
+
+	statusPatchesCoalesced = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "issuer_lib_status_patches_coalesced_total",
+		Help: "Number of status patches of requests that were merged into a delayed status patch, instead of being applied separately.",
+	}, []string{"controller"})
+
+	canarySignatures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "issuer_lib_canary_signatures_total",
+		Help: "Number of canary signatures that the issuer controllers requested to verify that an issuer can sign, per result.",
+	}, []string{"kind", "result"})
+
+	inventoryExports = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "issuer_lib_inventory_exports_total",
+		Help: "Number of issued certificates whose metadata was passed to the InventoryExporter, per result.",
+	}, []string{"kind", "result"})
+
+	warmUpHeldRequests = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "issuer_lib_warm_up_held_requests",
+		Help: "Number of requests that are held back by the WarmUpPolicy.",
+	}, []string{"kind"})
+
+	warmUpReleasedRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "issuer_lib_warm_up_released_requests_total",
+		Help: "Number of held back requests that were released to the work queue by the WarmUpPolicy.",
+	}, []string{"kind"})
+
+	issuanceControlSkipped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "issuer_lib_issuance_control_skipped_total",
+		Help: "Number of reconciles of requests that did not call Sign because the IssuanceControl paused issuance or enabled shadow mode.",
+	}, []string{"kind", "mode"})
+
+	fieldOwnerConflicts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "issuer_lib_field_owner_conflicts_total",
+		Help: "Number of status patches of requests and issuers that were skipped because the status was recently applied by another field manager.",
+	}, []string{"kind", "manager"})
+
+	csrGarbageCollected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "issuer_lib_csr_garbage_collected_total",
+		Help: "Number of finished Kubernetes CertificateSigningRequests that were deleted, or that would have been deleted in dry-run mode.",
+	}, []string{"result"})
+
+	lifecycleEventsDropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "issuer_lib_lifecycle_events_dropped_total",
+		Help: "Number of lifecycle events that were dropped because the buffer of a subscriber was full.",
+	})
+
+	missingPermissions = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "issuer_lib_missing_permissions",
+		Help: "Number of RBAC permissions needed by the controllers that the manager was missing during the last RBAC self-check.",
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		requestQueueDepth,
+		requestTenantQueueDepth,
+		requestConcurrencyLimit,
+		requestStateDuration,
+		issuedCertificateValidity,
+		issuerLiveReads,
+		statusPatchFailures,
+		statusPatchesCoalesced,
+		canarySignatures,
+		issuanceControlSkipped,
+		inventoryExports,
+		warmUpHeldRequests,
+		warmUpReleasedRequests,
+		fieldOwnerConflicts,
+		csrGarbageCollected,
+		lifecycleEventsDropped,
+		missingPermissions,
+	)
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1alpha1 "github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/conditions"
+	"github.com/cert-manager/issuer-lib/internal/ssaclient"
+)
+
+// statusPatchFailureThreshold is the number of consecutive rejected status
+// patches of an object after which the StatusPatchRejected condition is set.
+const statusPatchFailureThreshold = 3
+
+// patchFailureTracker counts the consecutive rejected status patches per object,
+// so that silent patch-rejection loops (eg. caused by a validating webhook) can
+// be detected.
+type patchFailureTracker struct {
+	mu       sync.Mutex
+	failures map[types.NamespacedName]int
+}
+
+func newPatchFailureTracker() *patchFailureTracker {
+	return &patchFailureTracker{
+		failures: map[types.NamespacedName]int{},
+	}
+}
+
+// failed records a rejected status patch and returns the number of consecutive
+// rejected status patches of the object. A nil tracker always returns 0.
+func (t *patchFailureTracker) failed(key types.NamespacedName) int {
+	if t == nil {
+		return 0
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.failures[key]++
+	return t.failures[key]
+}
+
+// succeeded forgets the rejected status patches of the object, and returns true
+// if the StatusPatchRejected condition was set on the object.
+func (t *patchFailureTracker) succeeded(key types.NamespacedName) bool {
+	if t == nil {
+		return false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	failures, ok := t.failures[key]
+	if !ok {
+		return false
+	}
+
+	delete(t.failures, key)
+	return failures >= statusPatchFailureThreshold
+}
+
+// statusPatchDiagnosticsFieldOwner returns the field owner used to apply the
+// StatusPatchRejected condition. A separate field owner is used so that the
+// condition is not removed by the (rejected) patches of the controller, and can
+// be removed by applying an empty patch once the patches are accepted again.
+func statusPatchDiagnosticsFieldOwner(fieldOwner string) string {
+	return fieldOwner + "-diagnostics"
+}
+
+func statusPatchRejectedReason(err error) string {
+	if reason := apierrors.ReasonForError(err); reason != "" {
+		return string(reason)
+	}
+	return "Unknown"
+}
+
+func statusPatchRejectedMessage(kind string, failures int, err error) string {
+	return fmt.Sprintf("The status of the %s was rejected %d times in a row: %s", kind, failures, err)
+}
+
+// applyDiagnosticsPatch applies a status patch using the diagnostics field owner,
+// errors are only logged since the patch is best-effort.
+func applyDiagnosticsPatch(ctx context.Context, cl client.Client, fieldOwner string, obj client.Object, patch client.Patch) {
+	if err := cl.Status().Patch(ctx, obj, patch, &client.SubResourcePatchOptions{
+		PatchOptions: client.PatchOptions{
+			FieldManager: statusPatchDiagnosticsFieldOwner(fieldOwner),
+			Force:        ptr.To(true),
+		},
+	}); err != nil && !apierrors.IsNotFound(err) {
+		log.FromContext(ctx).V(1).Info("Failed to apply the status patch diagnostics", "error", err.Error())
+	}
+}
+
+// recordStatusPatchFailure counts the rejected status patch of the request. Once
+// the status patches of the request were rejected repeatedly, the StatusPatchRejected
+// condition is set using a minimal patch.
+func (r *RequestController) recordStatusPatchFailure(ctx context.Context, obj client.Object, err error) {
+	statusPatchFailures.WithLabelValues(requestKind(obj), statusPatchRejectedReason(err)).Inc()
+
+	failures := r.patchFailures.failed(client.ObjectKeyFromObject(obj))
+	if failures == 0 || failures%statusPatchFailureThreshold != 0 {
+		return
+	}
+
+	log.FromContext(ctx).Error(err, "Status patch of the request was rejected repeatedly", "failures", failures)
+
+	diagnosticsPatch := r.requestObjectHelperCreator(obj).NewPatch(r.Clock, r.FieldOwner, r.EventRecorder)
+	diagnosticsPatch.SetStatusPatchRejected(failures, err)
+	r.applyDiagnosticsPatch(ctx, diagnosticsPatch)
+}
+
+// recordStatusPatchSuccess removes the StatusPatchRejected condition from the
+// request, if it was set.
+func (r *RequestController) recordStatusPatchSuccess(ctx context.Context, obj client.Object) {
+	if !r.patchFailures.succeeded(client.ObjectKeyFromObject(obj)) {
+		return
+	}
+
+	// An empty patch removes the fields owned by the diagnostics field owner.
+	r.applyDiagnosticsPatch(ctx, r.requestObjectHelperCreator(obj).NewPatch(r.Clock, r.FieldOwner, r.EventRecorder))
+}
+
+func (r *RequestController) applyDiagnosticsPatch(ctx context.Context, diagnosticsPatch RequestPatch) {
+	obj, patch, err := diagnosticsPatch.Patch()
+	if err != nil {
+		log.FromContext(ctx).V(1).Info("Failed to generate the status patch diagnostics", "error", err.Error())
+		return
+	}
+
+	applyDiagnosticsPatch(ctx, r.Client, r.FieldOwner, obj, patch)
+}
+
+// recordStatusPatchFailure counts the rejected status patch of the issuer. Once
+// the status patches of the issuer were rejected repeatedly, the StatusPatchRejected
+// condition is set using a minimal patch.
+func (r *IssuerReconciler) recordStatusPatchFailure(
+	ctx context.Context,
+	req ctrl.Request,
+	issuerStatusPatch *v1alpha1.IssuerStatus,
+	err error,
+) {
+	kind := r.ForObject.GetObjectKind().GroupVersionKind().Kind
+	statusPatchFailures.WithLabelValues(kind, statusPatchRejectedReason(err)).Inc()
+
+	failures := r.patchFailures.failed(req.NamespacedName)
+	if failures == 0 || failures%statusPatchFailureThreshold != 0 {
+		return
+	}
+
+	log.FromContext(ctx).Error(err, "Status patch of the issuer was rejected repeatedly", "failures", failures)
+
+	// The rejected patch contains the generation that was observed.
+	observedGeneration := int64(0)
+	for _, condition := range issuerStatusPatch.Conditions {
+		observedGeneration = max(observedGeneration, condition.ObservedGeneration)
+	}
+
+	diagnosticsStatus := &v1alpha1.IssuerStatus{}
+	condition, _ := conditions.SetIssuerStatusCondition(
+		r.Clock,
+		nil,
+		&diagnosticsStatus.Conditions,
+		observedGeneration,
+		v1alpha1.ConditionTypeStatusPatchRejected,
+		cmmeta.ConditionTrue,
+		statusPatchRejectedReason(err),
+		statusPatchRejectedMessage(kind, failures, err),
+	)
+
+	obj, patch, patchErr := ssaclient.GenerateIssuerStatusPatch(r.ForObject, req.Name, req.Namespace, diagnosticsStatus)
+	if patchErr != nil {
+		log.FromContext(ctx).V(1).Info("Failed to generate the status patch diagnostics", "error", patchErr.Error())
+		return
+	}
+
+	r.EventRecorder.Event(obj, corev1.EventTypeWarning, eventIssuerStatusPatchRejected, condition.Message)
+	applyDiagnosticsPatch(ctx, r.Client, r.FieldOwner, obj, patch)
+}
+
+// recordStatusPatchSuccess removes the StatusPatchRejected condition from the
+// issuer, if it was set.
+func (r *IssuerReconciler) recordStatusPatchSuccess(ctx context.Context, req ctrl.Request) {
+	if !r.patchFailures.succeeded(req.NamespacedName) {
+		return
+	}
+
+	// An empty patch removes the fields owned by the diagnostics field owner.
+	obj, patch, err := ssaclient.GenerateIssuerStatusPatch(r.ForObject, req.Name, req.Namespace, &v1alpha1.IssuerStatus{})
+	if err != nil {
+		log.FromContext(ctx).V(1).Info("Failed to generate the status patch diagnostics", "error", err.Error())
+		return
+	}
+
+	applyDiagnosticsPatch(ctx, r.Client, r.FieldOwner, obj, patch)
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cmutil "github.com/cert-manager/cert-manager/pkg/api/util"
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	cmgen "github.com/cert-manager/cert-manager/test/unit/gen"
+	prometheustestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/kubeutil"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+	"github.com/cert-manager/issuer-lib/internal/testapi/testutil"
+	"github.com/cert-manager/issuer-lib/testing/ssafake"
+)
+
+func TestPatchFailureTracker(t *testing.T) {
+	t.Parallel()
+
+	key := types.NamespacedName{Namespace: "ns1", Name: "cr1"}
+	tracker := newPatchFailureTracker()
+
+	// Objects without rejected patches were never marked.
+	assert.False(t, tracker.succeeded(key))
+
+	assert.Equal(t, 1, tracker.failed(key))
+	assert.Equal(t, 2, tracker.failed(key))
+	assert.False(t, tracker.succeeded(key))
+
+	for i := 0; i < statusPatchFailureThreshold; i++ {
+		tracker.failed(key)
+	}
+	assert.True(t, tracker.succeeded(key))
+	assert.Equal(t, 1, tracker.failed(key))
+
+	// A nil tracker does not track anything.
+	var nilTracker *patchFailureTracker
+	assert.Equal(t, 0, nilTracker.failed(key))
+	assert.False(t, nilTracker.succeeded(key))
+}
+
+func TestRequestControllerStatusPatchRejected(t *testing.T) {
+	t.Parallel()
+
+	const fieldOwner = "test-patch-failures"
+
+	fakeClock := clocktesting.NewFakeClock(randomTime().Truncate(time.Second))
+
+	issuer := testutil.TestIssuer(
+		"issuer-1",
+		testutil.SetTestIssuerNamespace("ns1"),
+		testutil.SetTestIssuerStatusCondition(
+			fakeClock,
+			cmapi.IssuerConditionReady,
+			cmmeta.ConditionTrue,
+			v1alpha1.IssuerConditionReasonChecked,
+			"Succeeded checking the issuer",
+		),
+	)
+
+	cr1 := cmgen.CertificateRequest(
+		"cr-patch-rejected",
+		cmgen.SetCertificateRequestNamespace("ns1"),
+		cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+			Name:  issuer.Name,
+			Group: api.SchemeGroupVersion.Group,
+		}),
+		cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+			Type:   cmapi.CertificateRequestConditionReady,
+			Status: cmmeta.ConditionUnknown,
+			Reason: v1alpha1.CertificateRequestConditionReasonInitializing,
+		}),
+		cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+			Type:   cmapi.CertificateRequestConditionApproved,
+			Status: cmmeta.ConditionTrue,
+		}),
+	)
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, setupCertificateRequestReconcilerScheme(scheme))
+	require.NoError(t, api.AddToScheme(scheme))
+
+	// The status patches of the controller are rejected (eg. by a validating
+	// webhook) while reject is true, the diagnostics patches are accepted.
+	reject := true
+	applierFuncs := ssafake.NewApplier().InterceptorFuncs()
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(cr1, issuer).
+		WithStatusSubresource(cr1).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Patch: applierFuncs.Patch,
+			SubResourcePatch: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+				options := (&client.SubResourcePatchOptions{}).ApplyOptions(opts)
+				if reject && options.FieldManager == fieldOwner {
+					return apierrors.NewForbidden(cmapi.Resource("certificaterequests"), obj.GetName(), errors.New("denied by webhook"))
+				}
+				return applierFuncs.SubResourcePatch(ctx, c, subResourceName, obj, patch, opts...)
+			},
+		}).
+		Build()
+
+	controller := (&CertificateRequestReconciler{
+		RequestController: RequestController{
+			IssuerTypes:      []v1alpha1.Issuer{&api.TestIssuer{}},
+			FieldOwner:       fieldOwner,
+			MaxRetryDuration: time.Hour,
+			EventSource:      kubeutil.NewEventStore(),
+			Client:           fakeClient,
+			Sign: func(_ context.Context, _ signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+				return signer.PEMBundle{ChainPEM: []byte("cert")}, nil
+			},
+			EventRecorder: record.NewFakeRecorder(100),
+			Clock:         fakeClock,
+		},
+	}).Init()
+	require.NoError(t, controller.setAllIssuerTypesWithGroupVersionKind(scheme))
+
+	request := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cr1)}
+	rejectedCondition := func() *cmapi.CertificateRequestCondition {
+		var cr cmapi.CertificateRequest
+		require.NoError(t, fakeClient.Get(context.TODO(), request.NamespacedName, &cr))
+		for _, condition := range cr.Status.Conditions {
+			if condition.Type == v1alpha1.ConditionTypeStatusPatchRejected {
+				return &condition
+			}
+		}
+		return nil
+	}
+
+	metric := statusPatchFailures.WithLabelValues("CertificateRequest", "Forbidden")
+	initialFailures := prometheustestutil.ToFloat64(metric)
+
+	for i := 1; i <= statusPatchFailureThreshold; i++ {
+		if i == statusPatchFailureThreshold {
+			assert.Nil(t, rejectedCondition(), "the condition is only set once the threshold is reached")
+		}
+
+		_, err := controller.Reconcile(context.TODO(), request)
+		require.Error(t, err)
+	}
+
+	assert.Equal(t, initialFailures+statusPatchFailureThreshold, prometheustestutil.ToFloat64(metric))
+
+	condition := rejectedCondition()
+	require.NotNil(t, condition)
+	assert.Equal(t, cmmeta.ConditionTrue, condition.Status)
+	assert.Equal(t, "Forbidden", condition.Reason)
+	assert.Contains(t, condition.Message, "was rejected 3 times in a row")
+
+	// Once the status patches are accepted again, the condition is removed.
+	reject = false
+	_, err := controller.Reconcile(context.TODO(), request)
+	require.NoError(t, err)
+
+	assert.Nil(t, rejectedCondition())
+
+	var cr cmapi.CertificateRequest
+	require.NoError(t, fakeClient.Get(context.TODO(), request.NamespacedName, &cr))
+	assert.True(t, cmutil.CertificateRequestHasCondition(&cr, cmapi.CertificateRequestCondition{
+		Type:   cmapi.CertificateRequestConditionReady,
+		Status: cmmeta.ConditionTrue,
+	}))
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+// PEMNormalizationPolicy enables validating and re-encoding the PEM bundle that
+// is returned by Sign before it is written to the status of the request. Without
+// this policy, the bundle is written as-is, so subtle mistakes of the Sign function
+// (eg. CRLF line endings, trailing garbage or an accidentally included private key)
+// end up in the status of the request.
+//
+// With this policy, every certificate is re-encoded with consistent line endings
+// and without PEM headers, data that is not part of a PEM block is dropped and
+// private keys are stripped. Bundles that contain another type of PEM block, a
+// certificate that can't be parsed or a chain without any certificate are failed
+// permanently.
+type PEMNormalizationPolicy struct {
+	// RejectPrivateKeys fails requests permanently when the bundle contains a
+	// private key, instead of stripping the private key.
+	RejectPrivateKeys bool
+}
+
+// normalize returns the normalized PEM bundle, or a PermanentError if the bundle
+// is invalid. A nil policy returns the bundle as-is.
+func (p *PEMNormalizationPolicy) normalize(logger logr.Logger, bundle signer.PEMBundle) (signer.PEMBundle, error) {
+	if p == nil {
+		return bundle, nil
+	}
+
+	chainPEM, err := p.normalizeCertificates(logger, "certificate chain", bundle.ChainPEM)
+	if err != nil {
+		return signer.PEMBundle{}, err
+	}
+	if len(chainPEM) == 0 {
+		return signer.PEMBundle{}, signer.PermanentError{
+			Err: fmt.Errorf("the signed certificate chain does not contain a certificate"),
+		}
+	}
+
+	caPEM, err := p.normalizeCertificates(logger, "CA bundle", bundle.CAPEM)
+	if err != nil {
+		return signer.PEMBundle{}, err
+	}
+
+	return signer.PEMBundle{
+		ChainPEM: chainPEM,
+		CAPEM:    caPEM,
+	}, nil
+}
+
+// normalizeCertificates re-encodes the certificates in the PEM data.
+func (p *PEMNormalizationPolicy) normalizeCertificates(logger logr.Logger, name string, data []byte) ([]byte, error) {
+	var normalized bytes.Buffer
+	for rest := data; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			if len(bytes.TrimSpace(rest)) > 0 {
+				logger.V(1).Info("Dropping data that is not part of a PEM block from the signed certificate.", "bundle", name)
+			}
+			break
+		}
+
+		switch {
+		case block.Type == "CERTIFICATE":
+			if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+				return nil, signer.PermanentError{
+					Err: fmt.Errorf("the signed %s contains an invalid certificate: %w", name, err),
+				}
+			}
+
+			if err := pem.Encode(&normalized, &pem.Block{Type: block.Type, Bytes: block.Bytes}); err != nil {
+				return nil, err
+			}
+		case strings.HasSuffix(block.Type, "PRIVATE KEY"):
+			if p.RejectPrivateKeys {
+				return nil, signer.PermanentError{
+					Err: fmt.Errorf("the signed %s contains a private key", name),
+				}
+			}
+
+			logger.Info("Stripping a private key from the signed certificate.", "bundle", name)
+		default:
+			return nil, signer.PermanentError{
+				Err: fmt.Errorf("the signed %s contains a PEM block of type %q, only certificates are allowed", name, block.Type),
+			}
+		}
+	}
+
+	return normalized.Bytes(), nil
+}
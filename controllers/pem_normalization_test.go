@@ -0,0 +1,147 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/tests/errormatch"
+)
+
+func testCertificatePEM(t *testing.T, commonName string) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+}
+
+func TestPEMNormalizationPolicyNormalize(t *testing.T) {
+	t.Parallel()
+
+	leafPEM := testCertificatePEM(t, "leaf")
+	caPEM := testCertificatePEM(t, "ca")
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: []byte("key")})
+	crlfLeafPEM := []byte(strings.ReplaceAll(string(leafPEM), "\n", "\r\n"))
+	chainPEM := append(append([]byte{}, leafPEM...), caPEM...)
+
+	type testCase struct {
+		name           string
+		policy         *PEMNormalizationPolicy
+		bundle         signer.PEMBundle
+		expectedBundle signer.PEMBundle
+		validateError  *errormatch.Matcher
+	}
+
+	tests := []testCase{
+		{
+			name:           "nil-policy",
+			policy:         nil,
+			bundle:         signer.PEMBundle{ChainPEM: []byte("cert")},
+			expectedBundle: signer.PEMBundle{ChainPEM: []byte("cert")},
+			validateError:  errormatch.NoError(),
+		},
+		{
+			name:           "valid-bundle",
+			policy:         &PEMNormalizationPolicy{},
+			bundle:         signer.PEMBundle{ChainPEM: chainPEM, CAPEM: caPEM},
+			expectedBundle: signer.PEMBundle{ChainPEM: chainPEM, CAPEM: caPEM},
+			validateError:  errormatch.NoError(),
+		},
+		{
+			name:           "crlf-line-endings-and-trailing-garbage",
+			policy:         &PEMNormalizationPolicy{},
+			bundle:         signer.PEMBundle{ChainPEM: append(crlfLeafPEM, []byte("\r\ngarbage\x00")...)},
+			expectedBundle: signer.PEMBundle{ChainPEM: leafPEM},
+			validateError:  errormatch.NoError(),
+		},
+		{
+			name:           "strip-private-key",
+			policy:         &PEMNormalizationPolicy{},
+			bundle:         signer.PEMBundle{ChainPEM: append(append([]byte{}, leafPEM...), keyPEM...)},
+			expectedBundle: signer.PEMBundle{ChainPEM: leafPEM},
+			validateError:  errormatch.NoError(),
+		},
+		{
+			name:          "reject-private-key",
+			policy:        &PEMNormalizationPolicy{RejectPrivateKeys: true},
+			bundle:        signer.PEMBundle{ChainPEM: leafPEM, CAPEM: keyPEM},
+			validateError: errormatch.ErrorContains("the signed CA bundle contains a private key"),
+		},
+		{
+			name:   "reject-non-certificate-block",
+			policy: &PEMNormalizationPolicy{},
+			bundle: signer.PEMBundle{ChainPEM: append(
+				append([]byte{}, leafPEM...),
+				pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: []byte("csr")})...,
+			)},
+			validateError: errormatch.ErrorContains("the signed certificate chain contains a PEM block of type \"CERTIFICATE REQUEST\", only certificates are allowed"),
+		},
+		{
+			name:          "reject-invalid-certificate",
+			policy:        &PEMNormalizationPolicy{},
+			bundle:        signer.PEMBundle{ChainPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("cert")})},
+			validateError: errormatch.ErrorContains("the signed certificate chain contains an invalid certificate"),
+		},
+		{
+			name:          "reject-empty-chain",
+			policy:        &PEMNormalizationPolicy{},
+			bundle:        signer.PEMBundle{ChainPEM: []byte("cert"), CAPEM: caPEM},
+			validateError: errormatch.ErrorContains("the signed certificate chain does not contain a certificate"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			bundle, err := tc.policy.normalize(logr.Discard(), tc.bundle)
+			(*tc.validateError)(t, err)
+
+			if err != nil {
+				require.ErrorAs(t, err, &signer.PermanentError{})
+				return
+			}
+
+			assert.Equal(t, tc.expectedBundle, bundle)
+		})
+	}
+}
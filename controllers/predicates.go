@@ -122,6 +122,7 @@ func (CertificateSigningRequestPredicate) Update(e event.UpdateEvent) bool {
 // - the Ready condition was added/ removed
 // - the Ready condition's Status property changed
 // - the Ready condition's observed generation changed
+// - the issuer was paused or unpaused
 type LinkedIssuerPredicate struct {
 	predicate.Funcs
 }
@@ -141,6 +142,18 @@ func (LinkedIssuerPredicate) Update(e event.UpdateEvent) bool {
 		return true
 	}
 
+	if issuerOld.GetAnnotations()[v1alpha1.IssuerPausedAnnotationKey] !=
+		issuerNew.GetAnnotations()[v1alpha1.IssuerPausedAnnotationKey] {
+		// the issuer was paused or unpaused
+		return true
+	}
+
+	if issuerOld.GetAnnotations()[v1alpha1.IssuerMaintenanceWindowAnnotationKey] !=
+		issuerNew.GetAnnotations()[v1alpha1.IssuerMaintenanceWindowAnnotationKey] {
+		// the maintenance window of the issuer was changed
+		return true
+	}
+
 	readyOld := conditions.GetIssuerStatusCondition(
 		issuerOld.GetStatus().Conditions,
 		cmapi.IssuerConditionReady,
@@ -529,6 +529,42 @@ func TestLinkedIssuerPredicate(t *testing.T) {
 				),
 			},
 		},
+		{
+			name:            "issuer-paused",
+			shouldReconcile: true,
+			event: event.UpdateEvent{
+				ObjectOld: testutil.TestIssuerFrom(issuer1),
+				ObjectNew: testutil.TestIssuerFrom(issuer1,
+					testutil.SetTestIssuerAnnotations(map[string]string{
+						v1alpha1.IssuerPausedAnnotationKey: "true",
+					}),
+				),
+			},
+		},
+		{
+			name:            "issuer-maintenance-window-changed",
+			shouldReconcile: true,
+			event: event.UpdateEvent{
+				ObjectOld: testutil.TestIssuerFrom(issuer1),
+				ObjectNew: testutil.TestIssuerFrom(issuer1,
+					testutil.SetTestIssuerAnnotations(map[string]string{
+						v1alpha1.IssuerMaintenanceWindowAnnotationKey: "2024-01-02T22:00:00Z/2024-01-03T02:00:00Z",
+					}),
+				),
+			},
+		},
+		{
+			name:            "other-annotation-changed",
+			shouldReconcile: false,
+			event: event.UpdateEvent{
+				ObjectOld: testutil.TestIssuerFrom(issuer1),
+				ObjectNew: testutil.TestIssuerFrom(issuer1,
+					testutil.SetTestIssuerAnnotations(map[string]string{
+						"test-annotation": "test",
+					}),
+				),
+			},
+		},
 		{
 			name:            "ready-condition-changed",
 			shouldReconcile: true,
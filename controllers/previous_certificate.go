@@ -0,0 +1,111 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/cert-manager/cert-manager/pkg/util/pki"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+// PreviousCertificatePolicy passes the certificate that was previously issued
+// for the Certificate of a renewal request to the Sign function, where it can
+// be obtained using signer.PreviousCertificateFromContext. This allows Sign
+// functions to use the "renew" endpoint of a CA (reusing the original order)
+// instead of treating every request as a fresh enrollment.
+//
+// A request is a renewal if cert-manager created it for a Certificate that was
+// issued before (ie. the certificate-revision annotation of the request is
+// larger than 1). Kubernetes CertificateSigningRequests are never renewals.
+type PreviousCertificatePolicy struct {
+	// Reader is used to read the Certificates and Secrets, it defaults to the
+	// Client of the controller. Consider using the manager's APIReader, so that
+	// not all Secrets in the cluster are cached.
+	Reader client.Reader
+
+	// Resolve is an optional function that replaces the default lookup of the
+	// previous certificate, which reads the leaf certificate from the Secret of
+	// the Certificate if that certificate was issued by the current issuer of
+	// the Certificate. It is only called for renewal requests and should return nil if
+	// there is no previous certificate.
+	Resolve func(ctx context.Context, cl client.Reader, cr signer.CertificateRequestObject, revision signer.CertificateRevision) (*x509.Certificate, error)
+}
+
+// previousCertificate returns the certificate that was previously issued for
+// the Certificate of the request, or nil if the request is not a renewal. A nil
+// policy never returns a certificate.
+func (p *PreviousCertificatePolicy) previousCertificate(ctx context.Context, cl client.Reader, cr signer.CertificateRequestObject) (*x509.Certificate, error) {
+	if p == nil {
+		return nil, nil
+	}
+
+	revision, ok := cr.GetCertificateRevision()
+	if !ok || revision.Revision <= 1 {
+		return nil, nil
+	}
+
+	if p.Reader != nil {
+		cl = p.Reader
+	}
+	if p.Resolve != nil {
+		return p.Resolve(ctx, cl, cr, revision)
+	}
+
+	certificate := &cmapi.Certificate{}
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: revision.Namespace, Name: revision.Name}, certificate); apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get the Certificate: %w", err)
+	}
+	if revision.UID != "" && revision.UID != certificate.UID {
+		return nil, nil // the Certificate was re-created
+	}
+
+	secret := &corev1.Secret{}
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: revision.Namespace, Name: certificate.Spec.SecretName}, secret); apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get the Secret of the Certificate: %w", err)
+	}
+
+	// The certificate of a previous issuer of the Certificate cannot be renewed.
+	issuerRef := certificate.Spec.IssuerRef
+	if secret.Annotations[cmapi.IssuerNameAnnotationKey] != issuerRef.Name ||
+		secret.Annotations[cmapi.IssuerKindAnnotationKey] != issuerRef.Kind ||
+		secret.Annotations[cmapi.IssuerGroupAnnotationKey] != issuerRef.Group {
+		return nil, nil
+	}
+
+	chainPEM := secret.Data[corev1.TLSCertKey]
+	if len(chainPEM) == 0 {
+		return nil, nil
+	}
+
+	certs, err := pki.DecodeX509CertificateChainBytes(chainPEM)
+	if err != nil || len(certs) == 0 {
+		return nil, nil // an invalid certificate cannot be renewed
+	}
+	return certs[0], nil
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	cmgen "github.com/cert-manager/cert-manager/test/unit/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+func TestPreviousCertificatePolicyPreviousCertificate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now().Truncate(time.Second)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	csrPEM, certPEM := testCSRAndCertificate(t, key, []string{"example.com"}, now.Add(-time.Hour), 90*time.Hour)
+
+	issuerRef := cmmeta.ObjectReference{Name: "issuer-1", Kind: "TestIssuer", Group: "testing.cert-manager.io"}
+
+	certificate := &cmapi.Certificate{
+		ObjectMeta: metav1.ObjectMeta{Name: "cert-1", Namespace: "ns1", UID: "cert-uid"},
+		Spec:       cmapi.CertificateSpec{SecretName: "secret-1", IssuerRef: issuerRef},
+	}
+
+	secret := func(certPEM []byte, issuerName string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "secret-1",
+				Namespace: "ns1",
+				Annotations: map[string]string{
+					cmapi.IssuerNameAnnotationKey:  issuerName,
+					cmapi.IssuerKindAnnotationKey:  issuerRef.Kind,
+					cmapi.IssuerGroupAnnotationKey: issuerRef.Group,
+				},
+			},
+			Data: map[string][]byte{
+				corev1.TLSCertKey: certPEM,
+			},
+		}
+	}
+
+	request := func(revision string, ownerUID types.UID) *cmapi.CertificateRequest {
+		return cmgen.CertificateRequest("cr-1",
+			cmgen.SetCertificateRequestNamespace("ns1"),
+			cmgen.SetCertificateRequestCSR(csrPEM),
+			cmgen.SetCertificateRequestIssuer(issuerRef),
+			cmgen.SetCertificateRequestAnnotations(map[string]string{
+				cmapi.CertificateNameKey:                      "cert-1",
+				cmapi.CertificateRequestRevisionAnnotationKey: revision,
+			}),
+			func(cr *cmapi.CertificateRequest) {
+				cr.OwnerReferences = []metav1.OwnerReference{{
+					APIVersion: cmapi.SchemeGroupVersion.String(),
+					Kind:       cmapi.CertificateKind,
+					Name:       "cert-1",
+					UID:        ownerUID,
+				}}
+			},
+		)
+	}
+
+	type testCase struct {
+		name          string
+		policy        *PreviousCertificatePolicy
+		cr            *cmapi.CertificateRequest
+		objects       []client.Object
+		expectedFound bool
+	}
+
+	tests := []testCase{
+		{
+			name:          "nil-policy",
+			policy:        nil,
+			cr:            request("2", "cert-uid"),
+			objects:       []client.Object{certificate, secret(certPEM, "issuer-1")},
+			expectedFound: false,
+		},
+		{
+			name:          "renewal",
+			policy:        &PreviousCertificatePolicy{},
+			cr:            request("2", "cert-uid"),
+			objects:       []client.Object{certificate, secret(certPEM, "issuer-1")},
+			expectedFound: true,
+		},
+		{
+			name:          "first-issuance",
+			policy:        &PreviousCertificatePolicy{},
+			cr:            request("1", "cert-uid"),
+			objects:       []client.Object{certificate, secret(certPEM, "issuer-1")},
+			expectedFound: false,
+		},
+		{
+			name:   "request-not-owned-by-certificate",
+			policy: &PreviousCertificatePolicy{},
+			cr: cmgen.CertificateRequest("cr-1",
+				cmgen.SetCertificateRequestNamespace("ns1"),
+				cmgen.SetCertificateRequestCSR(csrPEM),
+				cmgen.SetCertificateRequestIssuer(issuerRef),
+			),
+			objects:       []client.Object{certificate, secret(certPEM, "issuer-1")},
+			expectedFound: false,
+		},
+		{
+			name:          "recreated-certificate",
+			policy:        &PreviousCertificatePolicy{},
+			cr:            request("2", "other-uid"),
+			objects:       []client.Object{certificate, secret(certPEM, "issuer-1")},
+			expectedFound: false,
+		},
+		{
+			name:          "missing-secret",
+			policy:        &PreviousCertificatePolicy{},
+			cr:            request("2", "cert-uid"),
+			objects:       []client.Object{certificate},
+			expectedFound: false,
+		},
+		{
+			name:          "issued-by-previous-issuer",
+			policy:        &PreviousCertificatePolicy{},
+			cr:            request("2", "cert-uid"),
+			objects:       []client.Object{certificate, secret(certPEM, "issuer-2")},
+			expectedFound: false,
+		},
+		{
+			name:          "invalid-certificate",
+			policy:        &PreviousCertificatePolicy{},
+			cr:            request("2", "cert-uid"),
+			objects:       []client.Object{certificate, secret([]byte("invalid"), "issuer-1")},
+			expectedFound: false,
+		},
+		{
+			name: "custom-resolver",
+			policy: &PreviousCertificatePolicy{
+				Resolve: func(_ context.Context, _ client.Reader, _ signer.CertificateRequestObject, revision signer.CertificateRevision) (*x509.Certificate, error) {
+					assert.Equal(t, signer.CertificateRevision{Namespace: "ns1", Name: "cert-1", UID: "cert-uid", Revision: 3}, revision)
+					return &x509.Certificate{Raw: certPEM}, nil
+				},
+			},
+			cr:            request("3", "cert-uid"),
+			expectedFound: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			scheme := runtime.NewScheme()
+			require.NoError(t, cmapi.AddToScheme(scheme))
+			require.NoError(t, corev1.AddToScheme(scheme))
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(tc.objects...).
+				Build()
+
+			previousCertificate, err := tc.policy.previousCertificate(
+				context.TODO(),
+				fakeClient,
+				signer.CertificateRequestObjectFromCertificateRequest(tc.cr),
+			)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedFound, previousCertificate != nil)
+
+			if previousCertificate != nil && tc.policy.Resolve == nil {
+				assert.Equal(t, "example.com", previousCertificate.Subject.CommonName)
+			}
+		})
+	}
+}
@@ -0,0 +1,380 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/cert-manager/issuer-lib/annotations"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+// PriorityClassFromAnnotation is a signer.RequestPriority function that reads the
+// priority class of a request from the RequestPriorityClassAnnotationKey annotation.
+// Invalid values result in PriorityClassNormal.
+func PriorityClassFromAnnotation(cr signer.CertificateRequestObject) signer.PriorityClass {
+	priorityClass, _ := annotations.ParsePriorityClass(cr.GetAnnotations()[annotations.RequestPriorityClass])
+	return priorityClass
+}
+
+// TenantFromNamespace is a signer.RequestTenant function that uses the namespace
+// of a request as its tenant. Kubernetes CSRs are cluster-scoped, so they all
+// belong to the same tenant.
+func TenantFromNamespace(cr signer.CertificateRequestObject) string {
+	return cr.GetNamespace()
+}
+
+// TenantFromLabel returns a signer.RequestTenant function that uses the value of
+// the label with the given key as the tenant of a request. Requests without the
+// label belong to the same tenant.
+func TenantFromLabel(key string) signer.RequestTenant {
+	return func(cr signer.CertificateRequestObject) string {
+		return cr.GetLabels()[key]
+	}
+}
+
+// queueKey is the priority class and the tenant of a queued item.
+type queueKey struct {
+	priorityClass signer.PriorityClass
+	tenant        string
+}
+
+// requestClassifier remembers the priority class and the tenant of the requests
+// that are observed in the watch events of the controller, so the queue does not
+// have to read the requests when they are added. Requests that were not observed
+// yet have the normal priority class and belong to the tenant of their namespace.
+type requestClassifier struct {
+	priority      signer.RequestPriority
+	tenant        signer.RequestTenant
+	requestObject func(client.Object) signer.CertificateRequestObject
+
+	mu   sync.RWMutex
+	keys map[reconcile.Request]queueKey
+}
+
+func newRequestClassifier(
+	priority signer.RequestPriority,
+	tenant signer.RequestTenant,
+	requestObject func(client.Object) signer.CertificateRequestObject,
+) *requestClassifier {
+	return &requestClassifier{
+		priority:      priority,
+		tenant:        tenant,
+		requestObject: requestObject,
+		keys:          map[reconcile.Request]queueKey{},
+	}
+}
+
+// predicate returns a predicate that records the priority class and the tenant
+// of the requests in the watch events, it never filters out any event.
+func (c *requestClassifier) predicate() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			c.observe(e.Object)
+			return true
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			c.observe(e.ObjectNew)
+			return true
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+
+			delete(c.keys, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(e.Object)})
+			return true
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			c.observe(e.Object)
+			return true
+		},
+	}
+}
+
+func (c *requestClassifier) observe(obj client.Object) {
+	requestObject := c.requestObject(obj)
+
+	var key queueKey
+	if c.priority != nil {
+		key.priorityClass = c.priority(requestObject)
+	}
+	if c.tenant != nil {
+		key.tenant = c.tenant(requestObject)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.keys[reconcile.Request{NamespacedName: client.ObjectKeyFromObject(obj)}] = key
+}
+
+func (c *requestClassifier) priorityClass(req reconcile.Request) signer.PriorityClass {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.keys[req].priorityClass
+}
+
+func (c *requestClassifier) tenantOf(req reconcile.Request) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if key, ok := c.keys[req]; ok {
+		return key.tenant
+	}
+	return req.Namespace
+}
+
+// tenantQueues contains the queued items of a priority class, per tenant. The
+// tenants that have queued items are handed out in a round-robin fashion.
+type tenantQueues struct {
+	tenants []string
+	items   map[string][]reconcile.Request
+}
+
+// priorityQueue is the storage of a work queue that hands out the items with the
+// highest priority class first. Within a priority class, the tenants of the items
+// take turns, and the items of a tenant are handed out in the order in which they
+// were added. Without a tenant function, all items belong to the same tenant.
+// The client-go work queue that uses the storage makes sure that an item is never
+// processed concurrently, and calls the storage with its lock held.
+type priorityQueue struct {
+	name     string
+	classify func(reconcile.Request) queueKey
+	fair     bool
+
+	queues       map[signer.PriorityClass]*tenantQueues
+	tenantDepths map[string]int
+	keys         map[reconcile.Request]queueKey
+	length       int
+}
+
+var _ workqueue.Queue[reconcile.Request] = &priorityQueue{}
+
+// newPriorityQueue returns a new priorityQueue. The priority and tenant functions
+// are optional; all items have the normal priority class and belong to the same
+// tenant if they are nil.
+func newPriorityQueue(
+	name string,
+	priority func(reconcile.Request) signer.PriorityClass,
+	tenant func(reconcile.Request) string,
+) *priorityQueue {
+	return &priorityQueue{
+		name: name,
+		classify: func(req reconcile.Request) queueKey {
+			var key queueKey
+			if priority != nil {
+				key.priorityClass = priority(req)
+			}
+			if tenant != nil {
+				key.tenant = tenant(req)
+			}
+			return key
+		},
+		fair: tenant != nil,
+
+		queues:       map[signer.PriorityClass]*tenantQueues{},
+		tenantDepths: map[string]int{},
+		keys:         map[reconcile.Request]queueKey{},
+	}
+}
+
+// newPriorityRateLimitingQueue returns the default rate limited work queue of
+// controller-runtime, including its work queue metrics, with the given storage.
+func newPriorityRateLimitingQueue(
+	name string,
+	rateLimiter workqueue.TypedRateLimiter[reconcile.Request],
+	queue workqueue.Queue[reconcile.Request],
+) workqueue.TypedRateLimitingInterface[reconcile.Request] {
+	return workqueue.NewTypedRateLimitingQueueWithConfig(rateLimiter, workqueue.TypedRateLimitingQueueConfig[reconcile.Request]{
+		Name: name,
+		DelayingQueue: workqueue.NewTypedDelayingQueueWithConfig(workqueue.TypedDelayingQueueConfig[reconcile.Request]{
+			Name: name,
+			Queue: workqueue.NewTypedWithConfig(workqueue.TypedQueueConfig[reconcile.Request]{
+				Name:  name,
+				Queue: queue,
+			}),
+		}),
+	})
+}
+
+// Touch is called when an item that is waiting to be processed is added again,
+// the item is only moved if its priority class was raised.
+func (q *priorityQueue) Touch(item reconcile.Request) {
+	currentKey, ok := q.keys[item]
+	if !ok {
+		return
+	}
+
+	key := q.classify(item)
+	if key.priorityClass <= currentKey.priorityClass {
+		return
+	}
+
+	q.remove(currentKey, item)
+	q.push(key, item)
+}
+
+func (q *priorityQueue) Push(item reconcile.Request) {
+	q.push(q.classify(item), item)
+}
+
+func (q *priorityQueue) Len() int {
+	return q.length
+}
+
+// Pop must only be called if the queue has items.
+func (q *priorityQueue) Pop() reconcile.Request {
+	priorityClass, _ := q.highestPriorityClass()
+	return q.pop(priorityClass)
+}
+
+// highestPriorityClass returns the highest priority class that has queued
+// items.
+func (q *priorityQueue) highestPriorityClass() (signer.PriorityClass, bool) {
+	priorityClasses := make([]signer.PriorityClass, 0, len(q.queues))
+	for priorityClass, queue := range q.queues {
+		if len(queue.tenants) > 0 {
+			priorityClasses = append(priorityClasses, priorityClass)
+		}
+	}
+	if len(priorityClasses) == 0 {
+		return 0, false
+	}
+
+	sort.Slice(priorityClasses, func(i, j int) bool {
+		return priorityClasses[i] > priorityClasses[j]
+	})
+	return priorityClasses[0], true
+}
+
+func (q *priorityQueue) push(key queueKey, item reconcile.Request) {
+	queue, ok := q.queues[key.priorityClass]
+	if !ok {
+		queue = &tenantQueues{items: map[string][]reconcile.Request{}}
+		q.queues[key.priorityClass] = queue
+	}
+
+	if len(queue.items[key.tenant]) == 0 {
+		queue.tenants = append(queue.tenants, key.tenant)
+	}
+	queue.items[key.tenant] = append(queue.items[key.tenant], item)
+	q.keys[item] = key
+	q.length++
+
+	q.updateDepth(key, 1)
+}
+
+// pop removes and returns the first item of the tenant whose turn it is, the
+// tenant is moved to the end of the round-robin order. It must be called for a
+// priority class that has queued items.
+func (q *priorityQueue) pop(priorityClass signer.PriorityClass) reconcile.Request {
+	queue := q.queues[priorityClass]
+	tenant := queue.tenants[0]
+	item := queue.items[tenant][0]
+
+	queue.tenants = queue.tenants[1:]
+	if items := queue.items[tenant][1:]; len(items) > 0 {
+		queue.items[tenant] = items
+		queue.tenants = append(queue.tenants, tenant)
+	} else {
+		delete(queue.items, tenant)
+	}
+
+	delete(q.keys, item)
+	q.length--
+
+	q.updateDepth(queueKey{priorityClass: priorityClass, tenant: tenant}, -1)
+	return item
+}
+
+func (q *priorityQueue) remove(key queueKey, item reconcile.Request) {
+	queue, ok := q.queues[key.priorityClass]
+	if !ok {
+		return
+	}
+
+	items := queue.items[key.tenant]
+	for i := range items {
+		if items[i] != item {
+			continue
+		}
+
+		if items = append(items[:i:i], items[i+1:]...); len(items) > 0 {
+			queue.items[key.tenant] = items
+		} else {
+			delete(queue.items, key.tenant)
+			queue.tenants = slices.DeleteFunc(queue.tenants, func(tenant string) bool {
+				return tenant == key.tenant
+			})
+		}
+
+		delete(q.keys, item)
+		q.length--
+
+		q.updateDepth(key, -1)
+		return
+	}
+}
+
+func (q *priorityQueue) updateDepth(key queueKey, delta int) {
+	depth := 0
+	for _, items := range q.queues[key.priorityClass].items {
+		depth += len(items)
+	}
+	requestQueueDepth.
+		WithLabelValues(q.name, priorityClassLabel(key.priorityClass)).
+		Set(float64(depth))
+
+	if !q.fair {
+		return
+	}
+
+	// The series of tenants without queued requests are deleted, to limit the
+	// cardinality of the metric.
+	q.tenantDepths[key.tenant] += delta
+	if q.tenantDepths[key.tenant] <= 0 {
+		delete(q.tenantDepths, key.tenant)
+		requestTenantQueueDepth.DeleteLabelValues(q.name, key.tenant)
+		return
+	}
+	requestTenantQueueDepth.
+		WithLabelValues(q.name, key.tenant).
+		Set(float64(q.tenantDepths[key.tenant]))
+}
+
+func priorityClassLabel(priorityClass signer.PriorityClass) string {
+	switch priorityClass {
+	case signer.PriorityClassHigh:
+		return "high"
+	case signer.PriorityClassNormal:
+		return "normal"
+	case signer.PriorityClassLow:
+		return "low"
+	default:
+		return strconv.Itoa(int(priorityClass))
+	}
+}
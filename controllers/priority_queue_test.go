@@ -0,0 +1,325 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	prometheustestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+func testPriorityQueue(name string, priorities map[string]signer.PriorityClass) workqueue.TypedRateLimitingInterface[reconcile.Request] {
+	return newPriorityRateLimitingQueue(
+		name,
+		workqueue.DefaultTypedControllerRateLimiter[reconcile.Request](),
+		newPriorityQueue(
+			name,
+			func(req reconcile.Request) signer.PriorityClass {
+				return priorities[req.Name]
+			},
+			nil,
+		),
+	)
+}
+
+func testTenantRequest(namespace string, name string) reconcile.Request {
+	return reconcile.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}}
+}
+
+func testRequest(name string) reconcile.Request {
+	return reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "ns1", Name: name}}
+}
+
+func getAll(t *testing.T, q workqueue.TypedInterface[reconcile.Request]) []string {
+	t.Helper()
+
+	var names []string
+	for q.Len() > 0 {
+		item, shutdown := q.Get()
+		require.False(t, shutdown)
+		names = append(names, item.Name)
+		q.Done(item)
+	}
+	return names
+}
+
+func TestPriorityQueueOrder(t *testing.T) {
+	t.Parallel()
+
+	q := testPriorityQueue("test-order", map[string]signer.PriorityClass{
+		"high-1": signer.PriorityClassHigh,
+		"high-2": signer.PriorityClassHigh,
+		"low-1":  signer.PriorityClassLow,
+	})
+
+	for _, name := range []string{"low-1", "normal-1", "high-1", "normal-2", "high-2", "normal-1"} {
+		q.Add(testRequest(name))
+	}
+
+	assert.Equal(t, 5, q.Len())
+	assert.Equal(t, float64(2), prometheustestutil.ToFloat64(requestQueueDepth.WithLabelValues("test-order", "high")))
+	assert.Equal(t, float64(2), prometheustestutil.ToFloat64(requestQueueDepth.WithLabelValues("test-order", "normal")))
+	assert.Equal(t, float64(1), prometheustestutil.ToFloat64(requestQueueDepth.WithLabelValues("test-order", "low")))
+
+	assert.Equal(t, []string{"high-1", "high-2", "normal-1", "normal-2", "low-1"}, getAll(t, q))
+	assert.Equal(t, float64(0), prometheustestutil.ToFloat64(requestQueueDepth.WithLabelValues("test-order", "high")))
+}
+
+func TestPriorityQueueRaisePriority(t *testing.T) {
+	t.Parallel()
+
+	priorities := map[string]signer.PriorityClass{}
+	q := testPriorityQueue("test-raise", priorities)
+
+	q.Add(testRequest("a"))
+	q.Add(testRequest("b"))
+
+	// Adding an item again with a higher priority class moves it forward,
+	// adding it with a lower priority class does not move it back.
+	priorities["b"] = signer.PriorityClassHigh
+	q.Add(testRequest("b"))
+	priorities["b"] = signer.PriorityClassLow
+	q.Add(testRequest("b"))
+
+	assert.Equal(t, []string{"b", "a"}, getAll(t, q))
+}
+
+func TestPriorityQueueAddWhileProcessing(t *testing.T) {
+	t.Parallel()
+
+	q := testPriorityQueue("test-processing", nil)
+
+	q.Add(testRequest("a"))
+	item, shutdown := q.Get()
+	require.False(t, shutdown)
+
+	// The item is not handed out again until it is marked as done.
+	q.Add(testRequest("a"))
+	assert.Equal(t, 0, q.Len())
+
+	q.Done(item)
+	assert.Equal(t, []string{"a"}, getAll(t, q))
+}
+
+func TestPriorityQueueAddAfter(t *testing.T) {
+	t.Parallel()
+
+	q := testPriorityQueue("test-add-after", nil)
+
+	q.AddAfter(testRequest("a"), 10*time.Millisecond)
+	assert.Equal(t, 0, q.Len())
+
+	assert.Eventually(t, func() bool { return q.Len() == 1 }, 5*time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"a"}, getAll(t, q))
+}
+
+func TestPriorityQueueShutDown(t *testing.T) {
+	t.Parallel()
+
+	q := testPriorityQueue("test-shutdown", nil)
+
+	q.Add(testRequest("a"))
+	item, shutdown := q.Get()
+	require.False(t, shutdown)
+
+	drained := make(chan struct{})
+	go func() {
+		q.ShutDownWithDrain()
+		close(drained)
+	}()
+
+	assert.Eventually(t, q.ShuttingDown, 5*time.Second, 5*time.Millisecond)
+	select {
+	case <-drained:
+		t.Fatal("expected ShutDownWithDrain to wait for the item to be done")
+	default:
+	}
+
+	// Items that are added after the queue is shut down are dropped.
+	q.Add(testRequest("b"))
+	q.Done(item)
+	<-drained
+
+	_, shutdown = q.Get()
+	assert.True(t, shutdown)
+}
+
+func TestPriorityQueueTenantRoundRobin(t *testing.T) {
+	t.Parallel()
+
+	q := newPriorityRateLimitingQueue(
+		"test-tenants",
+		workqueue.DefaultTypedControllerRateLimiter[reconcile.Request](),
+		newPriorityQueue(
+			"test-tenants",
+			func(req reconcile.Request) signer.PriorityClass {
+				if req.Name == "urgent" {
+					return signer.PriorityClassHigh
+				}
+				return signer.PriorityClassNormal
+			},
+			func(req reconcile.Request) string {
+				return req.Namespace
+			},
+		),
+	)
+
+	// The noisy tenant adds many requests before the other tenants.
+	for _, name := range []string{"a1", "a2", "a3", "a4"} {
+		q.Add(testTenantRequest("noisy", name))
+	}
+	q.Add(testTenantRequest("quiet1", "b1"))
+	q.Add(testTenantRequest("quiet2", "c1"))
+	q.Add(testTenantRequest("quiet1", "b2"))
+	q.Add(testTenantRequest("quiet2", "urgent"))
+
+	assert.Equal(t, 8, q.Len())
+	assert.Equal(t, float64(4), prometheustestutil.ToFloat64(requestTenantQueueDepth.WithLabelValues("test-tenants", "noisy")))
+	assert.Equal(t, float64(2), prometheustestutil.ToFloat64(requestTenantQueueDepth.WithLabelValues("test-tenants", "quiet1")))
+
+	// Requests with a higher priority class are still handed out first, within
+	// a priority class the tenants take turns.
+	assert.Equal(t, []string{"urgent", "a1", "b1", "c1", "a2", "b2", "a3", "a4"}, getAll(t, q))
+
+	// The series of tenants without queued requests are deleted.
+	assert.Equal(t, 0, prometheustestutil.CollectAndCount(requestTenantQueueDepth, "issuer_lib_request_tenant_queue_depth"))
+}
+
+func TestPriorityQueueWorkQueueMetrics(t *testing.T) {
+	t.Parallel()
+
+	q := testPriorityQueue("test-workqueue-metrics", nil)
+	q.Add(testRequest("a"))
+	q.Add(testRequest("b"))
+
+	// The standard work queue metrics of controller-runtime are still recorded.
+	families, err := metrics.Registry.Gather()
+	require.NoError(t, err)
+
+	var adds float64
+	for _, family := range families {
+		if family.GetName() != "workqueue_adds_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "name" && label.GetValue() == "test-workqueue-metrics" {
+					adds = metric.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	assert.Equal(t, float64(2), adds)
+}
+
+func TestRequestClassifier(t *testing.T) {
+	t.Parallel()
+
+	classifier := newRequestClassifier(
+		PriorityClassFromAnnotation,
+		TenantFromLabel("example.com/team"),
+		func(obj client.Object) signer.CertificateRequestObject {
+			return signer.CertificateRequestObjectFromCertificateRequest(obj.(*cmapi.CertificateRequest))
+		},
+	)
+
+	cr := &cmapi.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns1",
+			Name:      "cr1",
+			Annotations: map[string]string{
+				v1alpha1.RequestPriorityClassAnnotationKey: "high",
+			},
+			Labels: map[string]string{
+				"example.com/team": "team-a",
+			},
+		},
+	}
+	req := testRequest("cr1")
+
+	// Requests that were not observed yet have the normal priority class and
+	// belong to the tenant of their namespace.
+	assert.Equal(t, signer.PriorityClassNormal, classifier.priorityClass(req))
+	assert.Equal(t, "ns1", classifier.tenantOf(req))
+
+	// The events are never filtered out, and the request objects of the events
+	// are used to classify the requests.
+	assert.True(t, classifier.predicate().Create(event.CreateEvent{Object: cr}))
+	assert.Equal(t, signer.PriorityClassHigh, classifier.priorityClass(req))
+	assert.Equal(t, "team-a", classifier.tenantOf(req))
+
+	updated := cr.DeepCopy()
+	updated.Annotations[v1alpha1.RequestPriorityClassAnnotationKey] = "low"
+	assert.True(t, classifier.predicate().Update(event.UpdateEvent{ObjectOld: cr, ObjectNew: updated}))
+	assert.Equal(t, signer.PriorityClassLow, classifier.priorityClass(req))
+
+	assert.True(t, classifier.predicate().Delete(event.DeleteEvent{Object: updated}))
+	assert.Equal(t, signer.PriorityClassNormal, classifier.priorityClass(req))
+	assert.Empty(t, classifier.keys)
+}
+
+func TestTenantFunctions(t *testing.T) {
+	t.Parallel()
+
+	cr := signer.CertificateRequestObjectFromCertificateRequest(&cmapi.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns1",
+			Labels: map[string]string{
+				"example.com/team": "team-a",
+			},
+		},
+	})
+
+	assert.Equal(t, "ns1", TenantFromNamespace(cr))
+	assert.Equal(t, "team-a", TenantFromLabel("example.com/team")(cr))
+	assert.Equal(t, "", TenantFromLabel("example.com/missing")(cr))
+}
+
+func TestPriorityClassFromAnnotation(t *testing.T) {
+	t.Parallel()
+
+	for annotation, expected := range map[string]signer.PriorityClass{
+		"":        signer.PriorityClassNormal,
+		"high":    signer.PriorityClassHigh,
+		"low":     signer.PriorityClassLow,
+		"unknown": signer.PriorityClassNormal,
+	} {
+		cr := &cmapi.CertificateRequest{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					v1alpha1.RequestPriorityClassAnnotationKey: annotation,
+				},
+			},
+		}
+
+		assert.Equal(t, expected, PriorityClassFromAnnotation(signer.CertificateRequestObjectFromCertificateRequest(cr)), annotation)
+	}
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const requestOutcomesMetricName = "issuer_lib_request_outcomes_total"
+
+// requestLabelPropagation attaches the values of the allowlisted labels of a
+// request to its structured logs, to the events recorded on it and to the
+// requestOutcomes metric. A nil requestLabelPropagation does not propagate any
+// labels.
+type requestLabelPropagation struct {
+	keys []string
+
+	// requestOutcomes has the labels "kind", "outcome" and a label per key.
+	requestOutcomes *prometheus.CounterVec
+}
+
+// newRequestLabelPropagation validates the label keys and registers the
+// requestOutcomes metric. The metric is shared by all controllers, so they have to
+// be configured with the same label keys.
+func newRequestLabelPropagation(keys []string) (*requestLabelPropagation, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	metricLabels := []string{"kind", "outcome"}
+	seen := map[string]string{}
+	for _, key := range keys {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid propagated label %q: %s", key, strings.Join(errs, "; "))
+		}
+
+		metricLabel := metricLabelName(key)
+		if other, ok := seen[metricLabel]; ok {
+			return nil, fmt.Errorf("propagated labels %q and %q map to the same metric label %q", other, key, metricLabel)
+		}
+		seen[metricLabel] = key
+		metricLabels = append(metricLabels, metricLabel)
+	}
+
+	requestOutcomes := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: requestOutcomesMetricName,
+		Help: "Number of requests that were Issued or Failed, per propagated request label.",
+	}, metricLabels)
+	if err := metrics.Registry.Register(requestOutcomes); err != nil {
+		var alreadyRegistered prometheus.AlreadyRegisteredError
+		if !errors.As(err, &alreadyRegistered) {
+			return nil, fmt.Errorf("failed to register the %s metric, all controllers must use the same propagated labels: %w", requestOutcomesMetricName, err)
+		}
+		requestOutcomes = alreadyRegistered.ExistingCollector.(*prometheus.CounterVec)
+	}
+
+	return &requestLabelPropagation{
+		keys:            keys,
+		requestOutcomes: requestOutcomes,
+	}, nil
+}
+
+// metricLabelName converts a label key into a Prometheus label name, the same way
+// kube-state-metrics does: "example.com/team" becomes "label_example_com_team".
+func metricLabelName(key string) string {
+	return "label_" + strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}, key)
+}
+
+// logValues returns the key-value pairs of the allowlisted labels that are set
+// on the request.
+func (p *requestLabelPropagation) logValues(obj client.Object) []interface{} {
+	if p == nil {
+		return nil
+	}
+
+	labels := obj.GetLabels()
+	var values []interface{}
+	for _, key := range p.keys {
+		if value, ok := labels[key]; ok {
+			values = append(values, key, value)
+		}
+	}
+	return values
+}
+
+// eventAnnotations returns the allowlisted labels that are set on the object.
+func (p *requestLabelPropagation) eventAnnotations(object runtime.Object) map[string]string {
+	obj, ok := object.(client.Object)
+	if p == nil || !ok {
+		return nil
+	}
+
+	labels := obj.GetLabels()
+	var annotations map[string]string
+	for _, key := range p.keys {
+		if value, ok := labels[key]; ok {
+			if annotations == nil {
+				annotations = map[string]string{}
+			}
+			annotations[key] = value
+		}
+	}
+	return annotations
+}
+
+// eventRecorder wraps the EventRecorder, so that the allowlisted labels of the
+// object are added as annotations to the recorded events.
+func (p *requestLabelPropagation) eventRecorder(recorder record.EventRecorder) record.EventRecorder {
+	if p == nil {
+		return recorder
+	}
+	return labelEventRecorder{EventRecorder: recorder, propagation: p}
+}
+
+// recordOutcome increments the requestOutcomes metric. Requests without one of
+// the allowlisted labels are counted with an empty value for that label.
+func (p *requestLabelPropagation) recordOutcome(obj client.Object, outcome string) {
+	if p == nil {
+		return
+	}
+
+	labels := obj.GetLabels()
+	values := []string{requestKind(obj), outcome}
+	for _, key := range p.keys {
+		values = append(values, labels[key])
+	}
+	p.requestOutcomes.WithLabelValues(values...).Inc()
+}
+
+// labelEventRecorder is an EventRecorder that adds the allowlisted labels of the
+// object as annotations to the recorded events.
+type labelEventRecorder struct {
+	record.EventRecorder
+	propagation *requestLabelPropagation
+}
+
+var _ record.EventRecorder = labelEventRecorder{}
+
+func (r labelEventRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	r.AnnotatedEventf(object, nil, eventtype, reason, "%s", message)
+}
+
+func (r labelEventRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.AnnotatedEventf(object, nil, eventtype, reason, messageFmt, args...)
+}
+
+func (r labelEventRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	labelAnnotations := r.propagation.eventAnnotations(object)
+	if len(labelAnnotations) == 0 && len(annotations) == 0 {
+		r.EventRecorder.Eventf(object, eventtype, reason, messageFmt, args...)
+		return
+	}
+
+	merged := make(map[string]string, len(labelAnnotations)+len(annotations))
+	maps.Copy(merged, labelAnnotations)
+	maps.Copy(merged, annotations)
+	r.EventRecorder.AnnotatedEventf(object, merged, eventtype, reason, messageFmt, args...)
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	prometheustestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestRequestLabelPropagation(t *testing.T) {
+	t.Parallel()
+
+	_, err := newRequestLabelPropagation([]string{"example.com/team", "invalid label"})
+	require.ErrorContains(t, err, `invalid propagated label "invalid label"`)
+
+	_, err = newRequestLabelPropagation([]string{"team-a", "team_a"})
+	require.ErrorContains(t, err, `map to the same metric label "label_team_a"`)
+
+	propagation, err := newRequestLabelPropagation(nil)
+	require.NoError(t, err)
+	assert.Nil(t, propagation)
+
+	propagation, err = newRequestLabelPropagation([]string{"example.com/team", "app"})
+	require.NoError(t, err)
+
+	// The metric is shared by all controllers, which must use the same labels.
+	_, err = newRequestLabelPropagation([]string{"example.com/team", "app"})
+	require.NoError(t, err)
+	_, err = newRequestLabelPropagation([]string{"example.com/team"})
+	require.ErrorContains(t, err, "all controllers must use the same propagated labels")
+
+	cr := &cmapi.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns1",
+			Name:      "cr1",
+			Labels: map[string]string{
+				"example.com/team": "team-a",
+				"other":            "not-propagated",
+			},
+		},
+	}
+
+	assert.Equal(t, []interface{}{"example.com/team", "team-a"}, propagation.logValues(cr))
+
+	propagation.recordOutcome(cr, timeInStateOutcomeIssued)
+	propagation.recordOutcome(cr, timeInStateOutcomeIssued)
+	assert.Equal(t, float64(2), prometheustestutil.ToFloat64(
+		propagation.requestOutcomes.WithLabelValues("CertificateRequest", timeInStateOutcomeIssued, "team-a", ""),
+	))
+
+	fakeRecorder := record.NewFakeRecorder(3)
+	recorder := propagation.eventRecorder(fakeRecorder)
+	recorder.Event(cr, "Normal", "Issued", "issued")
+	recorder.AnnotatedEventf(cr, map[string]string{"extra": "value"}, "Warning", "Pending", "pending %d", 1)
+	recorder.Event(&cmapi.CertificateRequest{}, "Normal", "Issued", "issued")
+	assert.Equal(t, "Normal Issued issued map[example.com/team:team-a]", <-fakeRecorder.Events)
+	assert.Equal(t, "Warning Pending pending 1 map[example.com/team:team-a extra:value]", <-fakeRecorder.Events)
+	assert.Equal(t, "Normal Issued issued", <-fakeRecorder.Events)
+
+	// A nil propagation does not change the logs, events and metrics.
+	var disabled *requestLabelPropagation
+	assert.Nil(t, disabled.logValues(cr))
+	assert.Same(t, fakeRecorder, disabled.eventRecorder(fakeRecorder))
+	disabled.recordOutcome(cr, timeInStateOutcomeIssued)
+}
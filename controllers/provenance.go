@@ -0,0 +1,124 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime/debug"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+)
+
+// issuerLibModulePath is the module path of issuer-lib, used to find its
+// version in the build info.
+const issuerLibModulePath = "github.com/cert-manager/issuer-lib"
+
+// ProvenancePolicy records which controller instance called Sign for a request,
+// by setting the RequestSignedByAnnotationKey and RequestSignedByVersionAnnotationKey
+// annotations on the request after Sign succeeded. This makes it possible to find
+// the requests that were signed by a specific pod or version when diagnosing
+// issuance anomalies (eg. after an upgrade). It requires patch permissions on
+// the requests.
+type ProvenancePolicy struct {
+	// Instance identifies the controller instance, defaults to the value of the
+	// POD_NAME environment variable or the hostname (which is the pod name when
+	// running in Kubernetes).
+	Instance string
+
+	// Version is the version of the controller, defaults to the version of the
+	// main module in the build info of the binary.
+	Version string
+
+	// IncludeIssuerLibVersion enables setting the
+	// RequestSignedByIssuerLibVersionAnnotationKey annotation to the version of
+	// issuer-lib that the controller was built with.
+	IncludeIssuerLibVersion bool
+}
+
+func (p *ProvenancePolicy) instance() string {
+	if p.Instance != "" {
+		return p.Instance
+	}
+	if podName := os.Getenv("POD_NAME"); podName != "" {
+		return podName
+	}
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		return hostname
+	}
+	return "unknown"
+}
+
+func (p *ProvenancePolicy) version() string {
+	if p.Version != "" {
+		return p.Version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "unknown"
+}
+
+// annotations returns the provenance annotations of this controller instance.
+func (p *ProvenancePolicy) annotations() map[string]string {
+	annotations := map[string]string{
+		v1alpha1.RequestSignedByAnnotationKey:        p.instance(),
+		v1alpha1.RequestSignedByVersionAnnotationKey: p.version(),
+	}
+	if p.IncludeIssuerLibVersion {
+		annotations[v1alpha1.RequestSignedByIssuerLibVersionAnnotationKey] = issuerLibVersion()
+	}
+	return annotations
+}
+
+// record sets the provenance annotations on the request. A nil policy does not
+// record anything.
+func (p *ProvenancePolicy) record(ctx context.Context, cl client.Client, requestObject client.Object) error {
+	if p == nil {
+		return nil
+	}
+
+	if err := patchAnnotations(ctx, cl, requestObject, "", p.annotations()); err != nil {
+		return fmt.Errorf("failed to set provenance annotations on request: %w", err)
+	}
+	return nil
+}
+
+// issuerLibVersion returns the version of issuer-lib in the build info of the
+// binary, taking replace directives into account.
+func issuerLibVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	if info.Main.Path == issuerLibModulePath && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path != issuerLibModulePath {
+			continue
+		}
+		if dep.Replace != nil && dep.Replace.Version != "" {
+			return dep.Replace.Version
+		}
+		return dep.Version
+	}
+	return "unknown"
+}
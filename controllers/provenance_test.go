@@ -0,0 +1,185 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	cmgen "github.com/cert-manager/cert-manager/test/unit/gen"
+	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/kubeutil"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+	"github.com/cert-manager/issuer-lib/internal/testapi/testutil"
+)
+
+func TestCertificateRequestReconcilerProvenance(t *testing.T) {
+	t.Parallel()
+
+	fieldOwner := "test-certificate-request-reconciler-provenance"
+
+	fakeClock := clocktesting.NewFakeClock(randomTime())
+
+	signSucceeds := func(_ context.Context, _ signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+		return signer.PEMBundle{ChainPEM: []byte("cert")}, nil
+	}
+
+	type testCase struct {
+		name                string
+		sign                signer.Sign
+		policy              *ProvenancePolicy
+		expectedAnnotations map[string]string
+	}
+
+	tests := []testCase{
+		{
+			name:                "no-policy",
+			sign:                signSucceeds,
+			policy:              nil,
+			expectedAnnotations: nil,
+		},
+		{
+			name: "instance-and-version",
+			sign: signSucceeds,
+			policy: &ProvenancePolicy{
+				Instance: "controller-7d9f8-abcde",
+				Version:  "v1.2.3",
+			},
+			expectedAnnotations: map[string]string{
+				v1alpha1.RequestSignedByAnnotationKey:        "controller-7d9f8-abcde",
+				v1alpha1.RequestSignedByVersionAnnotationKey: "v1.2.3",
+			},
+		},
+		{
+			name: "issuer-lib-version",
+			sign: signSucceeds,
+			policy: &ProvenancePolicy{
+				Instance:                "controller-7d9f8-abcde",
+				Version:                 "v1.2.3",
+				IncludeIssuerLibVersion: true,
+			},
+			expectedAnnotations: map[string]string{
+				v1alpha1.RequestSignedByAnnotationKey:                 "controller-7d9f8-abcde",
+				v1alpha1.RequestSignedByVersionAnnotationKey:          "v1.2.3",
+				v1alpha1.RequestSignedByIssuerLibVersionAnnotationKey: issuerLibVersion(),
+			},
+		},
+		{
+			name: "not-recorded-on-error",
+			sign: func(_ context.Context, _ signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+				return signer.PEMBundle{}, signer.PermanentError{Err: context.Canceled}
+			},
+			policy: &ProvenancePolicy{
+				Instance: "controller-7d9f8-abcde",
+				Version:  "v1.2.3",
+			},
+			expectedAnnotations: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			issuer := testutil.TestIssuer(
+				"issuer-1",
+				testutil.SetTestIssuerNamespace("ns1"),
+				testutil.SetTestIssuerStatusCondition(
+					fakeClock,
+					cmapi.IssuerConditionReady,
+					cmmeta.ConditionTrue,
+					v1alpha1.IssuerConditionReasonChecked,
+					"Succeeded checking the issuer",
+				),
+			)
+
+			cr1 := cmgen.CertificateRequest(
+				"cr1",
+				cmgen.SetCertificateRequestNamespace("ns1"),
+				cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+					Name:  issuer.Name,
+					Group: api.SchemeGroupVersion.Group,
+				}),
+				cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+					Type:   cmapi.CertificateRequestConditionReady,
+					Status: cmmeta.ConditionUnknown,
+					Reason: v1alpha1.CertificateRequestConditionReasonInitializing,
+				}),
+				cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+					Type:   cmapi.CertificateRequestConditionApproved,
+					Status: cmmeta.ConditionTrue,
+				}),
+			)
+
+			scheme := runtime.NewScheme()
+			require.NoError(t, setupCertificateRequestReconcilerScheme(scheme))
+			require.NoError(t, api.AddToScheme(scheme))
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(cr1, issuer).
+				Build()
+
+			logger := logrtesting.NewTestLoggerWithOptions(t, logrtesting.Options{LogTimestamp: true, Verbosity: 10})
+
+			controller := (&CertificateRequestReconciler{
+				RequestController: RequestController{
+					IssuerTypes:      []v1alpha1.Issuer{&api.TestIssuer{}},
+					FieldOwner:       fieldOwner,
+					MaxRetryDuration: time.Minute,
+					EventSource:      kubeutil.NewEventStore(),
+					ProvenancePolicy: tc.policy,
+					Client:           fakeClient,
+					Sign:             tc.sign,
+					EventRecorder:    record.NewFakeRecorder(100),
+					Clock:            fakeClock,
+				},
+			}).Init()
+			require.NoError(t, controller.setAllIssuerTypesWithGroupVersionKind(scheme))
+
+			_, _, _ = controller.reconcileStatusPatch(logger, context.TODO(), reconcile.Request{
+				NamespacedName: client.ObjectKeyFromObject(cr1),
+			})
+
+			var currentCr cmapi.CertificateRequest
+			require.NoError(t, fakeClient.Get(context.TODO(), client.ObjectKeyFromObject(cr1), &currentCr))
+			assert.Equal(t, tc.expectedAnnotations, currentCr.Annotations)
+		})
+	}
+}
+
+func TestProvenancePolicyDefaults(t *testing.T) {
+	t.Setenv("POD_NAME", "controller-from-env")
+
+	annotations := (&ProvenancePolicy{}).annotations()
+	assert.Equal(t, "controller-from-env", annotations[v1alpha1.RequestSignedByAnnotationKey])
+	assert.NotEmpty(t, annotations[v1alpha1.RequestSignedByVersionAnnotationKey])
+	assert.NotContains(t, annotations, v1alpha1.RequestSignedByIssuerLibVersionAnnotationKey)
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+// quotaInFlightCheckInterval is the maximum duration that a request over quota
+// is delayed for, if requests that are in progress might free up the quota.
+const quotaInFlightCheckInterval = time.Minute
+
+// QuotaPolicy limits the number of certificates that are issued per issuer within
+// a period, eg. to protect CAs with contractual issuance limits. Requests count
+// against the quota from the moment Sign is called for them until they are issued
+// (in progress) and for the period after they were issued (recently issued).
+// Requests over quota are delayed until the quota allows them to be signed, or
+// are failed permanently if FailOverQuota is set.
+// The counts are kept in memory, so they are lost when the controller restarts.
+type QuotaPolicy struct {
+	// Quota returns the quota of the issuer, or nil if the issuer has no quota.
+	// Defaults to v1alpha1.GetIssuerQuota, which reads the IssuerQuotaAnnotationKey
+	// annotation of the issuer.
+	Quota func(issuerObject v1alpha1.Issuer) (*v1alpha1.IssuerQuota, error)
+
+	// FailOverQuota fails requests over quota permanently, instead of delaying them.
+	FailOverQuota bool
+
+	mu    sync.Mutex
+	usage map[quotaKey]*quotaUsage
+}
+
+type quotaKey struct {
+	issuerGvk  schema.GroupVersionKind
+	issuerName types.NamespacedName
+}
+
+type quotaUsage struct {
+	inFlight map[types.UID]time.Time
+	issued   []time.Time
+}
+
+// prune removes the requests that fall outside the quota period. Requests that
+// have been in progress for longer than the period are assumed to be abandoned.
+func (u *quotaUsage) prune(now time.Time, period time.Duration) {
+	cutoff := now.Add(-period)
+
+	for uid, started := range u.inFlight {
+		if !started.After(cutoff) {
+			delete(u.inFlight, uid)
+		}
+	}
+
+	i := 0
+	for i < len(u.issued) && !u.issued[i].After(cutoff) {
+		i++
+	}
+	u.issued = u.issued[i:]
+}
+
+// reserve counts the request as in progress against the quota of the issuer. If
+// the issuer is over quota, a PendingError that delays the request (or a
+// PermanentError if FailOverQuota is set) is returned. A nil policy allows all
+// requests.
+func (p *QuotaPolicy) reserve(
+	logger logr.Logger,
+	issuerObject v1alpha1.Issuer,
+	issuerGvk schema.GroupVersionKind,
+	issuerName types.NamespacedName,
+	uid types.UID,
+	now time.Time,
+) error {
+	if p == nil {
+		return nil
+	}
+
+	quotaFn := p.Quota
+	if quotaFn == nil {
+		quotaFn = v1alpha1.GetIssuerQuota
+	}
+
+	quota, err := quotaFn(issuerObject)
+	if err != nil {
+		logger.V(1).Info("Ignoring invalid issuer quota.", "error", err.Error())
+		return nil
+	}
+	if quota == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.usage == nil {
+		p.usage = map[quotaKey]*quotaUsage{}
+	}
+
+	key := quotaKey{issuerGvk: issuerGvk, issuerName: issuerName}
+	usage, ok := p.usage[key]
+	if !ok {
+		usage = &quotaUsage{inFlight: map[types.UID]time.Time{}}
+		p.usage[key] = usage
+	}
+
+	usage.prune(now, quota.Period)
+
+	if _, ok := usage.inFlight[uid]; ok {
+		return nil
+	}
+
+	if len(usage.inFlight)+len(usage.issued) < quota.Limit {
+		usage.inFlight[uid] = now
+		return nil
+	}
+
+	quotaErr := fmt.Errorf(
+		"issuer quota of %d certificates per %s exceeded: %d certificates were issued recently and %d are in progress",
+		quota.Limit, quota.Period, len(usage.issued), len(usage.inFlight),
+	)
+
+	if p.FailOverQuota {
+		return signer.PermanentError{Err: quotaErr}
+	}
+
+	retryAfter := quota.Period
+	if len(usage.issued) > 0 {
+		retryAfter = usage.issued[0].Add(quota.Period).Sub(now)
+	}
+	if len(usage.inFlight) > 0 {
+		retryAfter = min(retryAfter, quotaInFlightCheckInterval)
+	}
+
+	return signer.PendingError{
+		Err:        quotaErr,
+		RetryAfter: retryAfter,
+		Reason:     v1alpha1.CertificateRequestConditionReasonIssuerRateLimited,
+	}
+}
+
+// complete records the outcome of the Sign call for a request that was counted
+// as in progress. An issued request counts as recently issued, a request for
+// which Sign returned a PendingError stays in progress and any other request no
+// longer counts against the quota.
+func (p *QuotaPolicy) complete(
+	issuerGvk schema.GroupVersionKind,
+	issuerName types.NamespacedName,
+	uid types.UID,
+	err error,
+	now time.Time,
+) {
+	if p == nil || errors.As(err, &signer.PendingError{}) {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	usage, ok := p.usage[quotaKey{issuerGvk: issuerGvk, issuerName: issuerName}]
+	if !ok {
+		return
+	}
+
+	if _, ok := usage.inFlight[uid]; !ok {
+		return
+	}
+
+	delete(usage.inFlight, uid)
+	if err == nil {
+		usage.issued = append(usage.issued, now)
+	}
+}
+
+// cancel removes the reservation of a request for which Sign is not called,
+// eg. because another replica holds the issuance claim on the request.
+func (p *QuotaPolicy) cancel(
+	issuerGvk schema.GroupVersionKind,
+	issuerName types.NamespacedName,
+	uid types.UID,
+) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if usage, ok := p.usage[quotaKey{issuerGvk: issuerGvk, issuerName: issuerName}]; ok {
+		delete(usage.inFlight, uid)
+	}
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/testapi/testutil"
+)
+
+func TestQuotaPolicy(t *testing.T) {
+	t.Parallel()
+
+	issuer := testutil.TestIssuer("issuer-1", testutil.SetTestIssuerNamespace("ns1"))
+	issuer.Annotations = map[string]string{
+		v1alpha1.IssuerQuotaAnnotationKey: "2/1h",
+	}
+	gvk := schema.GroupVersionKind{Group: "testing.cert-manager.io", Version: "api", Kind: "TestIssuer"}
+	name := types.NamespacedName{Namespace: "ns1", Name: "issuer-1"}
+	now := randomTime()
+
+	policy := &QuotaPolicy{}
+	reserve := func(uid types.UID, now time.Time) error {
+		return policy.reserve(logr.Discard(), issuer, gvk, name, uid, now)
+	}
+
+	// Requests count against the quota while they are in progress.
+	require.NoError(t, reserve("uid1", now))
+	require.NoError(t, reserve("uid2", now))
+	require.NoError(t, reserve("uid1", now))
+
+	err := reserve("uid3", now)
+	pendingErr := signer.PendingError{}
+	require.ErrorAs(t, err, &pendingErr)
+	assert.EqualError(t, err, "issuer quota of 2 certificates per 1h0m0s exceeded: 0 certificates were issued recently and 2 are in progress")
+	assert.Equal(t, quotaInFlightCheckInterval, pendingErr.RetryAfter)
+
+	// A request for which Sign returned a retryable error no longer counts,
+	// a request for which Sign returned a PendingError does.
+	policy.complete(gvk, name, "uid1", errors.New("retryable error"), now)
+	policy.complete(gvk, name, "uid2", signer.PendingError{Err: errors.New("pending")}, now)
+	require.NoError(t, reserve("uid3", now))
+
+	// Issued requests count against the quota until the period has passed.
+	policy.complete(gvk, name, "uid2", nil, now)
+	policy.complete(gvk, name, "uid3", nil, now.Add(10*time.Minute))
+
+	err = reserve("uid4", now.Add(20*time.Minute))
+	require.ErrorAs(t, err, &pendingErr)
+	assert.Equal(t, 40*time.Minute, pendingErr.RetryAfter)
+
+	require.NoError(t, reserve("uid4", now.Add(time.Hour)))
+
+	// Issuers without a quota are not limited.
+	otherIssuer := testutil.TestIssuer("issuer-2", testutil.SetTestIssuerNamespace("ns1"))
+	for _, uid := range []types.UID{"uid1", "uid2", "uid3"} {
+		require.NoError(t, policy.reserve(logr.Discard(), otherIssuer, gvk, types.NamespacedName{Namespace: "ns1", Name: "issuer-2"}, uid, now))
+	}
+
+	// A nil policy allows all requests.
+	require.NoError(t, (*QuotaPolicy)(nil).reserve(logr.Discard(), issuer, gvk, name, "uid5", now))
+}
+
+func TestQuotaPolicyFailOverQuota(t *testing.T) {
+	t.Parallel()
+
+	issuer := testutil.TestIssuer("issuer-1", testutil.SetTestIssuerNamespace("ns1"))
+	gvk := schema.GroupVersionKind{Group: "testing.cert-manager.io", Version: "api", Kind: "TestIssuer"}
+	name := types.NamespacedName{Namespace: "ns1", Name: "issuer-1"}
+	now := randomTime()
+
+	policy := &QuotaPolicy{
+		Quota: func(_ v1alpha1.Issuer) (*v1alpha1.IssuerQuota, error) {
+			return &v1alpha1.IssuerQuota{Limit: 1, Period: time.Hour}, nil
+		},
+		FailOverQuota: true,
+	}
+
+	require.NoError(t, policy.reserve(logr.Discard(), issuer, gvk, name, "uid1", now))
+	policy.complete(gvk, name, "uid1", nil, now)
+
+	err := policy.reserve(logr.Discard(), issuer, gvk, name, "uid2", now)
+	require.ErrorAs(t, err, &signer.PermanentError{})
+	assert.EqualError(t, err, "issuer quota of 1 certificates per 1h0m0s exceeded: 1 certificates were issued recently and 0 are in progress")
+}
+
+func TestParseIssuerQuota(t *testing.T) {
+	t.Parallel()
+
+	quota, err := v1alpha1.ParseIssuerQuota("1000/24h")
+	require.NoError(t, err)
+	assert.Equal(t, v1alpha1.IssuerQuota{Limit: 1000, Period: 24 * time.Hour}, quota)
+	assert.Equal(t, "1000/24h0m0s", quota.String())
+
+	for _, value := range []string{"1000", "x/24h", "-1/24h", "1000/x", "1000/0s"} {
+		_, err := v1alpha1.ParseIssuerQuota(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestQuotaPolicyCancel(t *testing.T) {
+	t.Parallel()
+
+	issuer := testutil.TestIssuer("issuer-1", testutil.SetTestIssuerNamespace("ns1"))
+	gvk := schema.GroupVersionKind{Group: "testing.cert-manager.io", Version: "api", Kind: "TestIssuer"}
+	name := types.NamespacedName{Namespace: "ns1", Name: "issuer-1"}
+	now := randomTime()
+
+	policy := &QuotaPolicy{
+		Quota: func(_ v1alpha1.Issuer) (*v1alpha1.IssuerQuota, error) {
+			return &v1alpha1.IssuerQuota{Limit: 1, Period: time.Hour}, nil
+		},
+	}
+
+	// A cancelled reservation no longer counts against the quota, and a later
+	// completion of the cancelled request is not counted as issued.
+	require.NoError(t, policy.reserve(logr.Discard(), issuer, gvk, name, "uid1", now))
+	policy.cancel(gvk, name, "uid1")
+	policy.complete(gvk, name, "uid1", nil, now)
+	require.NoError(t, policy.reserve(logr.Discard(), issuer, gvk, name, "uid2", now))
+
+	// A nil policy can be cancelled.
+	(*QuotaPolicy)(nil).cancel(gvk, name, "uid1")
+}
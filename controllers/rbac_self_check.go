@@ -0,0 +1,218 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+)
+
+// RBACSelfCheck periodically checks, using SelfSubjectAccessReviews, that the
+// manager has all RBAC permissions that the configured controllers need. Missing
+// permissions are logged, counted in the issuer_lib_missing_permissions metric
+// and reported by the "rbac-self-check" health check of the manager, instead of
+// only surfacing as cache errors and stalled reconciles.
+type RBACSelfCheck struct {
+	// Interval is the interval at which the permissions are checked, defaults
+	// to 10 minutes. The permissions are also checked when the manager starts.
+	Interval time.Duration
+
+	reviewAccess accessReviewer
+	permissions  func() ([]authorizationv1.ResourceAttributes, error)
+
+	mu  sync.Mutex
+	err error
+}
+
+var _ manager.Runnable = &RBACSelfCheck{}
+var _ manager.LeaderElectionRunnable = &RBACSelfCheck{}
+
+func (c *RBACSelfCheck) interval() time.Duration {
+	if c.Interval == 0 {
+		return 10 * time.Minute
+	}
+	return c.Interval
+}
+
+// Start checks the permissions at the configured interval until the context is
+// cancelled.
+func (c *RBACSelfCheck) Start(ctx context.Context) error {
+	ticker := time.NewTicker(c.interval())
+	defer ticker.Stop()
+
+	for {
+		c.check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection returns false, the permissions of all replicas are checked.
+func (c *RBACSelfCheck) NeedLeaderElection() bool {
+	return false
+}
+
+// Checker is a healthz.Checker that fails while permissions are missing. It
+// succeeds until the permissions have been checked for the first time.
+func (c *RBACSelfCheck) Checker(_ *http.Request) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.err
+}
+
+var _ healthz.Checker = (&RBACSelfCheck{}).Checker
+
+// check checks all permissions and records the result.
+func (c *RBACSelfCheck) check(ctx context.Context) {
+	logger := log.FromContext(ctx).WithName("rbac-self-check")
+
+	err := c.missingPermissions(ctx)
+	if err != nil {
+		logger.Error(err, "The manager is missing RBAC permissions that the controllers need.")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = err
+}
+
+func (c *RBACSelfCheck) missingPermissions(ctx context.Context) error {
+	permissions, err := c.permissions()
+	if err != nil {
+		return fmt.Errorf("failed to determine the required permissions: %w", err)
+	}
+
+	var missing []string
+	for _, attributes := range permissions {
+		allowed, err := c.reviewAccess(ctx, attributes)
+		if err != nil {
+			return fmt.Errorf("failed to check the permissions: %w", err)
+		}
+		if !allowed {
+			missing = append(missing, formatResourceAttributes(attributes))
+		}
+	}
+
+	missingPermissions.Set(float64(len(missing)))
+	if len(missing) > 0 {
+		return errors.New("missing RBAC permissions: " + strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// requiredPermissions returns the permissions that the controllers configured
+// on the CombinedController need.
+func (r *CombinedController) requiredPermissions(
+	scheme *runtime.Scheme,
+	restMapper apimeta.RESTMapper,
+	enableCertificateRequests bool,
+	enableKubernetesCSRs bool,
+) ([]authorizationv1.ResourceAttributes, error) {
+	issuerTypes := append(append([]v1alpha1.Issuer{}, r.IssuerTypes...), r.ClusterIssuerTypes...)
+
+	var permissions []authorizationv1.ResourceAttributes
+	for _, issuerType := range issuerTypes {
+		gvk, err := apiutil.GVKForObject(issuerType, scheme)
+		if err != nil {
+			return nil, fmt.Errorf("%T: %w", issuerType, err)
+		}
+		mapping, err := restMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return nil, fmt.Errorf("the %s API is not available (are the CRDs installed?): %w", gvk, err)
+		}
+
+		permissions = append(permissions, requestPermissions(gvk.Group, mapping.Resource.Resource)...)
+		if r.AggregateRevocationInfo {
+			permissions = append(permissions, authorizationv1.ResourceAttributes{Group: gvk.Group, Resource: mapping.Resource.Resource, Verb: "patch"})
+		}
+	}
+
+	// Patching the requests is needed for the issuance claims in annotations, for
+	// the time in state annotation, for the provenance annotations and for the
+	// correlation ID annotation.
+	patchRequests := (r.IssuanceClaimPolicy != nil && r.IssuanceClaimPolicy.Backend == nil) ||
+		r.SetTimeInStateAnnotation ||
+		r.ProvenancePolicy != nil ||
+		r.EnableCorrelationIDs
+
+	if enableCertificateRequests {
+		permissions = append(permissions, certificateRequestType.permissions(issuerTypes)...)
+		if patchRequests {
+			permissions = append(permissions, authorizationv1.ResourceAttributes{Group: certificateRequestType.groupVersionKind.Group, Resource: "certificaterequests", Verb: "patch"})
+		}
+	}
+	if enableKubernetesCSRs {
+		permissions = append(permissions, kubernetesCSRType.permissions(issuerTypes)...)
+		if patchRequests {
+			permissions = append(permissions, authorizationv1.ResourceAttributes{Group: certificatesv1.SchemeGroupVersion.Group, Resource: "certificatesigningrequests", Verb: "patch"})
+		}
+		for _, pattern := range r.KubernetesCSRSignerNamePatterns {
+			// RBAC only supports the "<domain>/*" wildcard for signer names.
+			name := pattern
+			if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+				domain, _, found := strings.Cut(prefix, "/")
+				if !found {
+					continue
+				}
+				name = domain + "/*"
+			}
+			permissions = append(permissions, authorizationv1.ResourceAttributes{Group: certificatesv1.SchemeGroupVersion.Group, Resource: "signers", Verb: "sign", Name: name})
+		}
+		if r.KubernetesCSRGarbageCollection != nil {
+			permissions = append(permissions, authorizationv1.ResourceAttributes{Group: certificatesv1.SchemeGroupVersion.Group, Resource: "certificatesigningrequests", Verb: "delete"})
+		}
+	}
+
+	if r.EnableIssuanceControl && (enableCertificateRequests || enableKubernetesCSRs) {
+		for _, verb := range []string{"get", "list", "watch"} {
+			permissions = append(permissions, authorizationv1.ResourceAttributes{Group: v1alpha1.SchemeGroupVersion.Group, Resource: "issuancecontrols", Verb: verb})
+		}
+	}
+
+	if r.IssuerSecretRefs != nil {
+		for _, verb := range []string{"get", "list", "watch"} {
+			permissions = append(permissions, authorizationv1.ResourceAttributes{Resource: "secrets", Verb: verb})
+		}
+	}
+
+	for _, verb := range []string{"create", "patch"} {
+		permissions = append(permissions, authorizationv1.ResourceAttributes{Resource: "events", Verb: verb})
+	}
+
+	return permissions, nil
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	prometheustestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+)
+
+func TestRequiredPermissions(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, api.AddToScheme(scheme))
+
+	restMapper := apimeta.NewDefaultRESTMapper(nil)
+	restMapper.Add(api.SchemeGroupVersion.WithKind("TestIssuer"), apimeta.RESTScopeNamespace)
+
+	controller := &CombinedController{
+		IssuerTypes:                    []v1alpha1.Issuer{&api.TestIssuer{}},
+		IssuanceClaimPolicy:            &IssuanceClaimPolicy{Identity: "replica-a"},
+		KubernetesCSRGarbageCollection: &CSRGarbageCollection{TTL: time.Hour},
+		EnableIssuanceControl:          true,
+	}
+
+	permissions, err := controller.requiredPermissions(scheme, restMapper, true, false)
+	require.NoError(t, err)
+
+	formatted := make([]string, 0, len(permissions))
+	for _, attributes := range permissions {
+		formatted = append(formatted, formatResourceAttributes(attributes))
+	}
+	assert.Equal(t, []string{
+		"get testissuers.testing.cert-manager.io",
+		"list testissuers.testing.cert-manager.io",
+		"watch testissuers.testing.cert-manager.io",
+		"patch testissuers/status.testing.cert-manager.io",
+		"get certificaterequests.cert-manager.io",
+		"list certificaterequests.cert-manager.io",
+		"watch certificaterequests.cert-manager.io",
+		"patch certificaterequests/status.cert-manager.io",
+		"patch certificaterequests.cert-manager.io",
+		"get issuancecontrols.issuer.cert-manager.io",
+		"list issuancecontrols.issuer.cert-manager.io",
+		"watch issuancecontrols.issuer.cert-manager.io",
+		"create events",
+		"patch events",
+	}, formatted)
+
+	// The issuer types must be served by the API server.
+	controller.ClusterIssuerTypes = []v1alpha1.Issuer{&api.TestClusterIssuer{}}
+	_, err = controller.requiredPermissions(scheme, restMapper, true, false)
+	assert.ErrorContains(t, err, "the testing.cert-manager.io/api, Kind=TestClusterIssuer API is not available (are the CRDs installed?)")
+}
+
+// TestRBACSelfCheck is not run in parallel, because it checks the value of the
+// global issuer_lib_missing_permissions metric.
+func TestRBACSelfCheck(t *testing.T) {
+	permissions := []authorizationv1.ResourceAttributes{
+		{Group: cmapi.SchemeGroupVersion.Group, Resource: "certificaterequests", Verb: "get"},
+		{Group: cmapi.SchemeGroupVersion.Group, Resource: "certificaterequests", Subresource: "status", Verb: "patch"},
+		{Resource: "events", Verb: "create"},
+	}
+
+	var reviewErr error
+	denied := map[string]bool{}
+	check := &RBACSelfCheck{
+		reviewAccess: func(_ context.Context, attributes authorizationv1.ResourceAttributes) (bool, error) {
+			return !denied[attributes.Resource+"/"+attributes.Subresource], reviewErr
+		},
+		permissions: func() ([]authorizationv1.ResourceAttributes, error) {
+			return permissions, nil
+		},
+	}
+
+	// The health check succeeds until the permissions have been checked.
+	require.NoError(t, check.Checker(nil))
+
+	check.check(context.TODO())
+	require.NoError(t, check.Checker(nil))
+	assert.Equal(t, float64(0), prometheustestutil.ToFloat64(missingPermissions))
+
+	denied["certificaterequests/status"] = true
+	denied["events/"] = true
+	check.check(context.TODO())
+	assert.EqualError(t, check.Checker(nil), "missing RBAC permissions: patch certificaterequests/status.cert-manager.io, create events")
+	assert.Equal(t, float64(2), prometheustestutil.ToFloat64(missingPermissions))
+
+	reviewErr = errors.New("[review error]")
+	check.check(context.TODO())
+	assert.EqualError(t, check.Checker(nil), "failed to check the permissions: [review error]")
+
+	// The health check recovers once the permissions are granted.
+	reviewErr = nil
+	clear(denied)
+	check.check(context.TODO())
+	require.NoError(t, check.Checker(nil))
+	assert.Equal(t, float64(0), prometheustestutil.ToFloat64(missingPermissions))
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/cert-manager/issuer-lib/internal/kubeutil"
+)
+
+// ReportedError is an error that the request controllers reported for an issuer
+// (ie. a signer.IssuerError returned by Sign), which the issuer controller has not
+// yet processed.
+type ReportedError = kubeutil.ReportedError
+
+// ReportedErrorBackend persists the reported errors, so that they survive restarts
+// of the controller and the issuer controller reacts to them immediately after
+// coming back up. Without a backend, the reported errors are only kept in memory.
+type ReportedErrorBackend = kubeutil.ReportedErrorBackend
+
+// maxReportedErrorMessageLength is the maximum length of a message stored by the
+// ConfigMapReportedErrorBackend, longer messages are truncated.
+const maxReportedErrorMessageLength = 1024
+
+// ConfigMapReportedErrorBackend is a ReportedErrorBackend that stores the reported
+// errors in a single ConfigMap, with one key per issuer. The ConfigMap is created
+// when the first error is reported. This requires get, create and patch permissions
+// on the ConfigMap.
+type ConfigMapReportedErrorBackend struct {
+	// Namespace and Name of the ConfigMap, the namespace is usually the namespace
+	// of the controller.
+	Namespace string
+	Name      string
+
+	// Client is used to create and patch the ConfigMap.
+	Client client.Client
+	// Reader is an optional reader used to read the ConfigMap, which happens once
+	// after the controller started. Set it to the manager's API reader to avoid
+	// caching all ConfigMaps in the cluster. Defaults to the Client.
+	Reader client.Reader
+}
+
+var _ ReportedErrorBackend = &ConfigMapReportedErrorBackend{}
+
+func (b *ConfigMapReportedErrorBackend) Load(ctx context.Context) ([]ReportedError, error) {
+	reader := b.Reader
+	if reader == nil {
+		reader = b.Client
+	}
+
+	var configMap corev1.ConfigMap
+	if err := reader.Get(ctx, types.NamespacedName{Namespace: b.Namespace, Name: b.Name}, &configMap); apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	reportedErrors := make([]ReportedError, 0, len(configMap.Data))
+	for key, message := range configMap.Data {
+		gvk, namespacedName, ok := parseReportedErrorKey(key)
+		if !ok {
+			continue
+		}
+
+		reportedErrors = append(reportedErrors, ReportedError{
+			GVK:            gvk,
+			NamespacedName: namespacedName,
+			Message:        message,
+		})
+	}
+
+	return reportedErrors, nil
+}
+
+func (b *ConfigMapReportedErrorBackend) Store(ctx context.Context, reportedError ReportedError) error {
+	message := reportedError.Message
+	if len(message) > maxReportedErrorMessageLength {
+		message = message[:maxReportedErrorMessageLength]
+	}
+
+	key := reportedErrorKey(reportedError.GVK, reportedError.NamespacedName)
+	err := b.patch(ctx, key, &message)
+	if !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	err = b.Client.Create(ctx, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: b.Namespace,
+			Name:      b.Name,
+		},
+		Data: map[string]string{key: message},
+	})
+	if apierrors.IsAlreadyExists(err) {
+		// The ConfigMap was created concurrently.
+		return b.patch(ctx, key, &message)
+	}
+	return err
+}
+
+func (b *ConfigMapReportedErrorBackend) Delete(ctx context.Context, gvk schema.GroupVersionKind, namespacedName types.NamespacedName) error {
+	err := b.patch(ctx, reportedErrorKey(gvk, namespacedName), nil)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// patch sets the key of the ConfigMap to the message, or removes the key if the
+// message is nil, using a merge patch.
+func (b *ConfigMapReportedErrorBackend) patch(ctx context.Context, key string, message *string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"data": map[string]*string{key: message},
+	})
+	if err != nil {
+		return err
+	}
+
+	return b.Client.Patch(ctx, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: b.Namespace,
+			Name:      b.Name,
+		},
+	}, client.RawPatch(types.MergePatchType, patch))
+}
+
+// reportedErrorKey returns the ConfigMap key for the issuer, the parts of the key
+// are separated by underscores, which cannot occur in any of the parts.
+func reportedErrorKey(gvk schema.GroupVersionKind, namespacedName types.NamespacedName) string {
+	return strings.Join([]string{gvk.Group, gvk.Version, gvk.Kind, namespacedName.Namespace, namespacedName.Name}, "_")
+}
+
+func parseReportedErrorKey(key string) (schema.GroupVersionKind, types.NamespacedName, bool) {
+	parts := strings.Split(key, "_")
+	if len(parts) != 5 {
+		return schema.GroupVersionKind{}, types.NamespacedName{}, false
+	}
+
+	return schema.GroupVersionKind{Group: parts[0], Version: parts[1], Kind: parts[2]},
+		types.NamespacedName{Namespace: parts[3], Name: parts[4]},
+		true
+}
+
+// EventSource passes the errors that the request controllers report for an
+// issuer (ie. a signer.IssuerError returned by Sign) to the issuer controller,
+// which then checks the issuer again.
+type EventSource = kubeutil.EventSource
+
+// NewEventSource returns an EventSource that can be shared by controllers that
+// are set up separately, which persists the reported errors if a backend is set.
+func NewEventSource(backend ReportedErrorBackend) EventSource {
+	if backend == nil {
+		return kubeutil.NewEventStore()
+	}
+	return kubeutil.NewPersistentEventStore(backend)
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/cert-manager/issuer-lib/internal/kubeutil"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+)
+
+func TestConfigMapReportedErrorBackend(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	backend := &ConfigMapReportedErrorBackend{
+		Namespace: "issuer-system",
+		Name:      "reported-errors",
+		Client:    fakeClient,
+	}
+
+	issuerGvk := api.SchemeGroupVersion.WithKind("TestIssuer")
+	clusterIssuerGvk := api.SchemeGroupVersion.WithKind("TestClusterIssuer")
+	issuer1 := types.NamespacedName{Namespace: "ns1", Name: "issuer-1"}
+	clusterIssuer1 := types.NamespacedName{Name: "cluster-issuer-1"}
+
+	// Nothing is persisted before the ConfigMap exists.
+	reportedErrors, err := backend.Load(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, reportedErrors)
+	require.NoError(t, backend.Delete(ctx, issuerGvk, issuer1))
+
+	require.NoError(t, backend.Store(ctx, ReportedError{GVK: issuerGvk, NamespacedName: issuer1, Message: "credentials expired"}))
+	require.NoError(t, backend.Store(ctx, ReportedError{GVK: clusterIssuerGvk, NamespacedName: clusterIssuer1, Message: strings.Repeat("x", 2000)}))
+
+	reportedErrors, err = backend.Load(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []ReportedError{
+		{GVK: issuerGvk, NamespacedName: issuer1, Message: "credentials expired"},
+		{GVK: clusterIssuerGvk, NamespacedName: clusterIssuer1, Message: strings.Repeat("x", maxReportedErrorMessageLength)},
+	}, reportedErrors)
+
+	require.NoError(t, backend.Delete(ctx, issuerGvk, issuer1))
+
+	var configMap corev1.ConfigMap
+	require.NoError(t, fakeClient.Get(ctx, types.NamespacedName{Namespace: "issuer-system", Name: "reported-errors"}, &configMap))
+	assert.Len(t, configMap.Data, 1)
+	assert.Contains(t, configMap.Data, "testing.cert-manager.io_api_TestClusterIssuer__cluster-issuer-1")
+}
+
+func TestPersistentEventStore(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	backend := &ConfigMapReportedErrorBackend{
+		Namespace: "issuer-system",
+		Name:      "reported-errors",
+		Client:    fake.NewClientBuilder().WithScheme(scheme).Build(),
+	}
+
+	issuerGvk := api.SchemeGroupVersion.WithKind("TestIssuer")
+	issuer1 := types.NamespacedName{Namespace: "ns1", Name: "issuer-1"}
+
+	queue := workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+	defer queue.ShutDown()
+
+	eventSource := kubeutil.NewPersistentEventStore(backend)
+	require.NoError(t, eventSource.AddConsumer(issuerGvk).Start(context.TODO(), queue))
+	require.NoError(t, eventSource.ReportError(issuerGvk, issuer1, errors.New("credentials expired")))
+
+	// The reported error is restored after a restart, and is removed once it was
+	// processed.
+	restartedEventSource := kubeutil.NewPersistentEventStore(backend)
+	require.EqualError(t, restartedEventSource.(kubeutil.ReportedErrorPeeker).PeekReportedError(issuerGvk, issuer1), "credentials expired")
+	require.EqualError(t, restartedEventSource.HasReportedError(issuerGvk, issuer1), "credentials expired")
+	require.NoError(t, restartedEventSource.HasReportedError(issuerGvk, issuer1))
+
+	reportedErrors, err := backend.Load(context.TODO())
+	require.NoError(t, err)
+	assert.Empty(t, reportedErrors)
+}
+
+func TestEventStoreLimits(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	backend := &ConfigMapReportedErrorBackend{
+		Namespace: "issuer-system",
+		Name:      "reported-errors",
+		Client:    fake.NewClientBuilder().WithScheme(scheme).Build(),
+	}
+
+	issuerGvk := api.SchemeGroupVersion.WithKind("TestIssuer")
+	issuer1 := types.NamespacedName{Namespace: "ns1", Name: "issuer-1"}
+	issuer2 := types.NamespacedName{Namespace: "ns1", Name: "issuer-2"}
+	issuer3 := types.NamespacedName{Namespace: "ns1", Name: "issuer-3"}
+
+	queue := workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+	defer queue.ShutDown()
+
+	fakeClock := clocktesting.NewFakeClock(randomTime())
+	eventSource := kubeutil.NewEventStoreWithLimits(backend, kubeutil.EventStoreLimits{
+		MaxEntries: 2,
+		TTL:        time.Minute,
+		Clock:      fakeClock,
+	})
+	require.NoError(t, eventSource.AddConsumer(issuerGvk).Start(context.TODO(), queue))
+
+	require.NoError(t, eventSource.ReportError(issuerGvk, issuer1, errors.New("error 1")))
+	fakeClock.Step(time.Second)
+	require.NoError(t, eventSource.ReportError(issuerGvk, issuer2, errors.New("error 2")))
+	fakeClock.Step(time.Second)
+
+	// The oldest reported error is evicted when the limit is reached, also from
+	// the backend.
+	require.NoError(t, eventSource.ReportError(issuerGvk, issuer3, errors.New("error 3")))
+	require.NoError(t, eventSource.(kubeutil.ReportedErrorPeeker).PeekReportedError(issuerGvk, issuer1))
+	require.EqualError(t, eventSource.(kubeutil.ReportedErrorPeeker).PeekReportedError(issuerGvk, issuer2), "error 2")
+	require.EqualError(t, eventSource.(kubeutil.ReportedErrorPeeker).PeekReportedError(issuerGvk, issuer3), "error 3")
+
+	reportedErrors, err := backend.Load(context.TODO())
+	require.NoError(t, err)
+	assert.Len(t, reportedErrors, 2)
+
+	// Reported errors that were not consumed within the TTL are evicted.
+	fakeClock.Step(time.Minute - time.Second)
+	require.NoError(t, eventSource.HasReportedError(issuerGvk, issuer2))
+	require.EqualError(t, eventSource.HasReportedError(issuerGvk, issuer3), "error 3")
+
+	reportedErrors, err = backend.Load(context.TODO())
+	require.NoError(t, err)
+	assert.Empty(t, reportedErrors)
+}
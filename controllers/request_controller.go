@@ -25,18 +25,21 @@ import (
 	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	"k8s.io/utils/clock"
 	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -44,7 +47,10 @@ import (
 	v1alpha1 "github.com/cert-manager/issuer-lib/api/v1alpha1"
 	"github.com/cert-manager/issuer-lib/conditions"
 	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/eventbus"
 	"github.com/cert-manager/issuer-lib/internal/kubeutil"
+	"github.com/cert-manager/issuer-lib/inventory"
+	"github.com/cert-manager/issuer-lib/upstream"
 )
 
 // RequestController reconciles a "request" object.
@@ -60,13 +66,235 @@ type RequestController struct {
 	MaxRetryDuration time.Duration
 	EventSource      kubeutil.EventSource
 
-	// Client is a controller-runtime client used to get and set K8S API resources
+	// FieldOwnerForIssuer is optional. If set, it returns the field owner that is
+	// used to apply the status of the requests of an issuer, instead of FieldOwner.
+	// This allows a staged cutover between two controllers that manage the same
+	// issuer types.
+	FieldOwnerForIssuer FieldOwnerFunc
+	// FieldOwnerConflictWindow is optional. If set, the status of a request is not
+	// applied while one of the conditions in the status was applied by another
+	// field manager within the window. Instead, a FieldOwnerConflict event is
+	// recorded and the request is reconciled again once the window has passed.
+	FieldOwnerConflictWindow time.Duration
+
+	// ReadinessRegistry is optional. If set, the readiness of the issuer recorded
+	// by the issuer controller is used instead of parsing the issuer's conditions.
+	ReadinessRegistry kubeutil.ReadinessRegistry
+
+	// RetryPolicy is an optional policy that extends or shortens the
+	// MaxRetryDuration of a request based on the errors returned by Sign.
+	RetryPolicy *RetryPolicy
+
+	// Messages is an optional catalog that overrides the messages used in the
+	// conditions and events set on the request.
+	Messages *MessageCatalog
+
+	// MessageStabilizationPolicy is optional. If set, the message of a condition
+	// is kept when the new message only differs from it in its volatile parts
+	// (eg. timestamps or attempt counters), so retries don't change the request.
+	MessageStabilizationPolicy *MessageStabilizationPolicy
+
+	// Redaction is optional. If set, sensitive data (eg. tokens or URLs with
+	// credentials) is redacted from the messages of conditions and events, and
+	// from the logs of the reconciles.
+	Redaction *RedactionPolicy
+
+	// CriticalExtensionPolicy is optional. If set, requests with a CSR that
+	// contains unsupported critical extensions are failed permanently, without
+	// calling Sign.
+	CriticalExtensionPolicy *CriticalExtensionPolicy
+
+	// CSRCanonicalizationPolicy is optional. If set, the CSR of a request is
+	// re-encoded deterministically before it is passed to Sign.
+	CSRCanonicalizationPolicy *CSRCanonicalizationPolicy
+
+	// PEMNormalizationPolicy is optional. If set, the PEM bundle returned by Sign
+	// is validated and re-encoded before it is written to the status of the
+	// request.
+	PEMNormalizationPolicy *PEMNormalizationPolicy
+
+	// ChainExpiryPolicy is optional. If set, a ChainExpiryRisk condition and a
+	// Warning event are added to a request when the chain returned by Sign contains
+	// an intermediate certificate that expires before the leaf certificate or
+	// within the window of the policy.
+	ChainExpiryPolicy *ChainExpiryPolicy
+
+	// FailedRequestCache is optional. If set, requests that are identical to a
+	// request that was rejected for a policy reason recently are failed
+	// immediately with the reason of the prior failure, without calling Sign.
+	FailedRequestCache *FailedRequestCache
+
+	// ExternalApprovalPolicy is optional. If set, a request is only signed once
+	// it was approved by the external approval webhook of the policy.
+	ExternalApprovalPolicy *ExternalApprovalPolicy
+
+	// IssuanceClaimPolicy is optional. If set, a claim on the request has to be
+	// acquired before calling Sign, so that overlapping reconciles of multiple
+	// replicas do not sign the same request twice.
+	IssuanceClaimPolicy *IssuanceClaimPolicy
+
+	// AdaptiveConcurrency is optional. If set, the number of requests that are
+	// reconciled concurrently is scaled between the bounds of the policy, based on
+	// the depth of the work queue and the latency of Sign.
+	AdaptiveConcurrency *AdaptiveConcurrencyPolicy
+
+	// WarmUp is optional. If set, the requests that are added to the work queue
+	// while the controller starts are released at a limited rate, the requests
+	// of the Certificates that expire first are released first.
+	WarmUp *WarmUpPolicy
+
+	// EnableCorrelationIDs enables generating a correlation ID for each request,
+	// which is persisted in the RequestCorrelationIDAnnotationKey annotation and
+	// added to the logs and events of the request and to the context passed to
+	// Sign (see signer.CorrelationIDFromContext). This requires patch permissions
+	// on the requests.
+	EnableCorrelationIDs bool
+
+	// EnableIssuanceControl makes the controller obey the IssuanceControl
+	// singleton (see v1alpha1.IssuanceControl), which can pause the issuance of
+	// all requests or of the requests for specific issuer kinds, or enable
+	// shadow mode. This requires the v1alpha1 types to be added to the scheme of
+	// the manager, the IssuanceControl CRD to be installed and read permissions
+	// on issuancecontrols.
+	EnableIssuanceControl bool
+
+	// StatusPatchCoalescing is optional. If set, the status patches that don't
+	// finish a request are delayed for the window of the policy, and the patches
+	// of a request within the window are merged into a single patch.
+	StatusPatchCoalescing *StatusPatchCoalescingPolicy
+
+	// ProvenancePolicy is optional. If set, the controller instance that called
+	// Sign for a request is recorded in annotations on the request.
+	ProvenancePolicy *ProvenancePolicy
+
+	// AggregateRevocationInfo enables adding the revocation endpoints that Sign
+	// recorded using signer.WithRevocationInfo to the IssuerRevocationInfoAnnotationKey
+	// annotation of the issuer. This requires patch permissions on the issuers.
+	AggregateRevocationInfo bool
+
+	// QuotaPolicy is an optional policy that limits the number of certificates
+	// that are issued per issuer within a period.
+	QuotaPolicy *QuotaPolicy
+
+	// DeduplicationPolicy is optional. If set, a CertificateRequest is marked as
+	// Issued without calling Sign when the Secret of its Certificate already
+	// contains a matching certificate that is not yet due for renewal.
+	DeduplicationPolicy *DeduplicationPolicy
+
+	// PreviousCertificatePolicy is optional. If set, the certificate that was
+	// previously issued for the Certificate of a renewal request is passed to
+	// the Sign function through the context, where it can be obtained using
+	// signer.PreviousCertificateFromContext.
+	PreviousCertificatePolicy *PreviousCertificatePolicy
+
+	// InventoryExporter is optional. If set, the metadata of each signed
+	// certificate is exported to it before the request is marked as Issued.
+	// Certificates that are re-used by the DeduplicationPolicy are not exported
+	// again.
+	InventoryExporter inventory.Exporter
+
+	// IssuerSnapshotPolicy is optional. If set, a snapshot of the issuer is
+	// recorded in the RequestIssuerSnapshotAnnotationKey annotation of requests
+	// that fail permanently.
+	IssuerSnapshotPolicy *IssuerSnapshotPolicy
+
+	// UpstreamConfig is the optional configuration of the outbound connections to
+	// the CA. If set, it is passed to the Sign function through the context, where it
+	// can be obtained using upstream.FromContext.
+	UpstreamConfig *upstream.Config
+
+	// ClusterResourceNamespace is the namespace in which the resources (eg. Secrets)
+	// referenced by cluster-scoped issuers are found. If set, it is passed to the
+	// Sign function through the context, where it can be obtained using
+	// signer.ResourceNamespace.
+	ClusterResourceNamespace string
+
+	// FailOnIssuerFailed enables failing requests permanently when their issuer
+	// failed permanently (its Ready condition has the Failed reason) for longer
+	// than the IssuerFailedGracePeriod, instead of waiting for the issuer to
+	// become ready forever.
+	FailOnIssuerFailed bool
+	// IssuerFailedGracePeriod is the duration for which requests keep waiting
+	// for a permanently failed issuer to recover, if FailOnIssuerFailed is set.
+	IssuerFailedGracePeriod time.Duration
+
+	// DurationPolicy is an optional policy that limits the requested duration of
+	// the certificates passed to the Sign function.
+	DurationPolicy *DurationPolicy
+
+	// RequestOptionsPolicy is optional. If set, the options that are set on a
+	// request using RequestOptionAnnotationPrefix annotations are validated and
+	// passed to the Sign function through the context, where they can be obtained
+	// using signer.OptionsFromContext.
+	RequestOptionsPolicy *RequestOptionsPolicy
+
+	// IssuerNotReadyCache is optional. If set, requests that are already waiting
+	// for a not-ready issuer are skipped cheaply until the Ready condition of the
+	// issuer transitions or the TTL of the cache expires.
+	IssuerNotReadyCache *IssuerNotReadyCache
+
+	// IssuerResyncPeriod is optional. If set, the requests of all ready issuers
+	// are reconciled again at this interval, so requests do not keep waiting
+	// for an issuer whose transition to ready was missed (eg. during a watch
+	// restart). The requests that link to an issuer that does not exist are
+	// counted in the issuer_lib_linked_resource_stale_index_entries metric.
+	IssuerResyncPeriod time.Duration
+
+	// SetTimeInStateAnnotation enables setting the RequestTimeInStateAnnotationKey
+	// annotation on a request once it is Issued or Failed. This requires patch
+	// permissions on the request resources and is disabled by default.
+	SetTimeInStateAnnotation bool
+
+	// NotifyOwningCertificate enables recording the events about the issuer of a
+	// CertificateRequest (eg. while it is waiting for the issuer to become ready)
+	// on the Certificate that owns the CertificateRequest as well.
+	NotifyOwningCertificate bool
+
+	// PropagatedLabels is an optional allowlist of request label keys (eg.
+	// "example.com/team"). The values of these labels are added to the structured
+	// logs of a request, as annotations to the events recorded on the request and
+	// as labels to the issuer_lib_request_outcomes_total metric, which is only
+	// registered if the allowlist is not empty.
+	PropagatedLabels []string
+
+	// AllowIssuerOverride enables routing a request to the issuer referenced by
+	// its RequestIssuerOverrideAnnotationKey annotation instead of the issuer it
+	// references, eg. to re-point requests during a CA incident. Only enable it
+	// if the annotation is guarded by an admission webhook that calls
+	// annotations.AuthorizeIssuerOverride, since any user who can annotate a
+	// request could otherwise route it to any issuer of the controller.
+	AllowIssuerOverride bool
+
+	// Client is a controller-runtime client used to get and set K8S API resources.
+	// When using the manager's client, reads are served from the informer cache.
 	client.Client
+	// APIReader is an optional reader that bypasses the cache. If set, it is used
+	// to read the issuer when an error was reported for that issuer which has not
+	// yet been processed by the issuer controller, or when the Ready condition of
+	// the cached issuer was not updated for its current generation, since the
+	// cached issuer is likely stale in these cases.
+	APIReader client.Reader
 	// Sign connects to a CA and returns a signed certificate for the supplied Request.
 	signer.Sign
 	// IgnoreCertificateRequest is an optional function that can prevent the Request
 	// and Kubernetes CSR controllers from reconciling a Request resource.
 	signer.IgnoreCertificateRequest
+	// IgnoredCertificateRequestReason is an optional function that provides the
+	// reason and message that are recorded on a Request that was ignored.
+	signer.IgnoredCertificateRequestReason
+	// RequestPriority is an optional function that returns the priority class of a
+	// Request. When set, Requests with a higher priority class are reconciled first.
+	signer.RequestPriority
+	// RequestTenant is an optional function that returns the tenant of a Request.
+	// When set, the Requests of the tenants are reconciled in a round-robin fashion.
+	signer.RequestTenant
+	// ErrorClassifier is an optional function that classifies the opaque errors
+	// returned by Sign into one of the signer error types.
+	signer.ErrorClassifier
+	// SupportedKeyAlgorithms is an optional function that returns the public key
+	// algorithms that the issuer supports. Requests for other algorithms fail
+	// permanently without calling Sign.
+	signer.SupportedKeyAlgorithms
 
 	// EventRecorder is used for creating Kubernetes events on resources.
 	EventRecorder record.EventRecorder
@@ -86,11 +314,27 @@ type RequestController struct {
 
 	allIssuerTypes []IssuerType
 
+	patchFailures *patchFailureTracker
+
+	labelPropagation *requestLabelPropagation
+
+	concurrencyLimiter *concurrencyLimiter
+
+	statusPatchCoalescer *statusPatchCoalescer
+
+	// lifecycleEvents is set by the CombinedController to publish the lifecycle
+	// events of the requests to its subscribers.
+	lifecycleEvents *eventbus.Bus[LifecycleEvent]
+
 	initialised                bool
 	requestType                client.Object
 	requestPredicate           predicate.Predicate
 	matchIssuerType            MatchIssuerType
 	requestObjectHelperCreator RequestObjectHelperCreator
+
+	// verifySignedCertificate is an optional function that verifies the
+	// certificate returned by Sign, before it is set on the request.
+	verifySignedCertificate func(context.Context, client.Object, signer.PEMBundle) error
 }
 
 type MatchIssuerType func(client.Object) (v1alpha1.Issuer, client.ObjectKey, error)
@@ -101,11 +345,22 @@ type IssuerType struct {
 	IsNamespaced bool
 }
 
-func (r *RequestController) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	logger := log.FromContext(ctx).WithName("Reconcile")
+func (r *RequestController) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, returnedError error) {
+	logger := r.Redaction.logger(log.FromContext(ctx)).WithName("Reconcile")
+
+	// The error is logged by controller-runtime, so it is redacted as well.
+	defer func() {
+		returnedError = r.Redaction.redactError(returnedError)
+	}()
 
 	logger.V(2).Info("Starting reconcile loop", "name", req.Name, "namespace", req.Namespace)
 
+	release, err := r.concurrencyLimiter.acquire(ctx)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	defer release()
+
 	// The error returned by `reconcileStatusPatch` is meant for controller-runtime,
 	// not for us. That's why we aren't checking `reconcileError != nil` .
 	result, statusPatch, reconcileError := r.reconcileStatusPatch(logger, ctx, req)
@@ -118,17 +373,30 @@ func (r *RequestController) Reconcile(ctx context.Context, req ctrl.Request) (ct
 
 		logger.V(2).Info("Got StatusPatch result", "result", result, "error", reconcileError, "patch", patch)
 
-		if err := r.Client.Status().Patch(ctx, obj, patch, &client.SubResourcePatchOptions{
-			PatchOptions: client.PatchOptions{
-				FieldManager: r.FieldOwner,
-				Force:        ptr.To(true),
-			},
-		}); err != nil {
-			if !apierrors.IsNotFound(err) {
-				return ctrl.Result{}, utilerrors.NewAggregate([]error{err, reconcileError}) // requeue with backoff
-			}
+		fieldOwner := r.FieldOwner
+		if ownedPatch, ok := statusPatch.(fieldOwnerPatch); ok {
+			fieldOwner = ownedPatch.patchFieldOwner()
+		}
 
-			logger.V(1).Info("Request not found. Ignoring.")
+		// A patch is not delayed if the request is reconciled again before the
+		// delayed patch would be applied.
+		immediate := isFinalStatusPatch(statusPatch) || r.statusPatchCoalescer.requeuesBeforeFlush(result, reconcileError)
+		patch, deferred, err := r.statusPatchCoalescer.coalesce(obj, patch, fieldOwner, immediate)
+		if err != nil {
+			return ctrl.Result{}, utilerrors.NewAggregate([]error{err, reconcileError}) // requeue with backoff
+		}
+		if deferred {
+			logger.V(2).Info("Delayed StatusPatch")
+			return result, reconcileError
+		}
+
+		if applied, err := r.applyStatusPatch(ctx, obj, patch, fieldOwner); err != nil {
+			return ctrl.Result{}, utilerrors.NewAggregate([]error{err, reconcileError}) // requeue with backoff
+		} else if applied {
+			if err := r.recordTimeInState(ctx, obj, statusPatch); err != nil {
+				logger.Error(err, "Failed to record the time in state of the request")
+			}
+			recordIssuedCertificate(obj, statusPatch)
 		}
 	} else {
 		logger.V(2).Info("Got nil StatusPatch result", "result", result, "error", reconcileError)
@@ -137,6 +405,29 @@ func (r *RequestController) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	return result, reconcileError
 }
 
+// applyStatusPatch applies the status patch of a request and records whether it
+// was rejected. It returns false if the request no longer exists.
+func (r *RequestController) applyStatusPatch(ctx context.Context, obj client.Object, patch client.Patch, fieldOwner string) (bool, error) {
+	if err := r.Client.Status().Patch(ctx, obj, patch, &client.SubResourcePatchOptions{
+		PatchOptions: client.PatchOptions{
+			FieldManager: fieldOwner,
+			Force:        ptr.To(true),
+		},
+	}); err != nil {
+		if !apierrors.IsNotFound(err) {
+			r.recordStatusPatchFailure(ctx, obj, err)
+			r.IssuerNotReadyCache.forgetRequest(client.ObjectKeyFromObject(obj))
+			return false, err
+		}
+
+		log.FromContext(ctx).V(1).Info("Request not found. Ignoring.")
+		return false, nil
+	}
+
+	r.recordStatusPatchSuccess(ctx, obj)
+	return true, nil
+}
+
 // reconcileStatusPatch is responsible for reconciling the request resource (cert-manager
 // CertificateRequest or Kubernetes CertificateSigningRequest). It will return the
 // result and reconcileError to be returned by the Reconcile function. It also returns
@@ -155,6 +446,8 @@ func (r *RequestController) reconcileStatusPatch(
 
 	if err := r.Client.Get(ctx, req.NamespacedName, requestObject); err != nil && apierrors.IsNotFound(err) {
 		logger.V(1).Info("Request not found. Ignoring.")
+		r.RetryPolicy.forget(req.NamespacedName)
+		r.IssuerNotReadyCache.forgetRequest(req.NamespacedName)
 		return result, nil, nil // done
 	} else if err != nil {
 		return result, nil, fmt.Errorf("unexpected get error: %v", err) // requeue with backoff
@@ -167,7 +460,21 @@ func (r *RequestController) reconcileStatusPatch(
 		logger.V(1).Info("Request has a foreign issuer. Ignoring.", "error", err)
 		return result, nil, nil // done
 	}
+	var overridden bool
+	issuerObject, issuerName, overridden, err = r.overrideIssuer(requestObject, issuerObject, issuerName)
+	if err != nil {
+		logger.V(1).Info("Request has an invalid issuer override. Ignoring.", "error", err)
+		r.requestEventRecorder().Event(requestObject, corev1.EventTypeWarning, eventRequestInvalidIssuerOverride, err.Error())
+		return result, nil, nil // done, reconciled again once the annotation changes
+	}
+	if overridden {
+		logger = logger.WithValues("issuerOverride", issuerName)
+	}
 	issuerGvk := issuerObject.GetObjectKind().GroupVersionKind()
+	logger = logger.WithValues(r.labelPropagation.logValues(requestObject)...)
+	fieldOwner := fieldOwnerFor(r.FieldOwner, r.FieldOwnerForIssuer, issuerGvk, issuerName)
+
+	r.publishRequestObserved(requestObject, issuerGvk, issuerName)
 
 	// Create a helper for the requestObject
 	requestObjectHelper := r.requestObjectHelperCreator(requestObject)
@@ -197,6 +504,30 @@ func (r *RequestController) reconcileStatusPatch(
 		return result, nil, nil // done
 	}
 
+	var correlationID string
+	if r.EnableCorrelationIDs {
+		correlationID, err = r.ensureCorrelationID(ctx, requestObject)
+		if err != nil {
+			return result, nil, err // requeue with backoff
+		}
+
+		logger = logger.WithValues("correlationID", correlationID)
+		ctx = signer.WithCorrelationID(ctx, correlationID)
+	}
+	eventRecorder := withCorrelationID(r.requestEventRecorder(), correlationID)
+
+	if conflict := detectFieldOwnerConflict(
+		requestObject,
+		requestConditionTypes(requestObject),
+		fieldOwner,
+		r.Clock.Now(),
+		r.FieldOwnerConflictWindow,
+	); conflict != nil {
+		logger.V(1).Info("Request status was recently applied by another field manager. Ignoring.", "manager", conflict.manager)
+		recordFieldOwnerConflict(withCorrelationID(r.EventRecorder, correlationID), requestObject, requestKind(requestObject), fieldOwner, conflict)
+		return ctrl.Result{RequeueAfter: conflict.remaining}, nil, nil // requeue after the conflict expired
+	}
+
 	if r.IgnoreCertificateRequest != nil {
 		ignore, err := r.IgnoreCertificateRequest(
 			ctx,
@@ -211,7 +542,26 @@ func (r *RequestController) reconcileStatusPatch(
 
 		if ignore {
 			logger.V(1).Info("Ignoring Request")
-			return result, nil, nil // done
+
+			if r.IgnoredCertificateRequestReason == nil {
+				return result, nil, nil // done
+			}
+
+			reason, message := r.IgnoredCertificateRequestReason(
+				ctx,
+				requestObjectHelper.RequestObject(),
+				issuerGvk,
+				issuerName,
+			)
+
+			statusPatch := requestObjectHelper.NewPatch(
+				r.Clock,
+				fieldOwner,
+				withCorrelationID(r.labelPropagation.eventRecorder(r.EventRecorder), correlationID),
+			)
+			statusPatch.SetIgnored(reason, message)
+
+			return result, statusPatch, nil // apply patch, done
 		}
 	}
 
@@ -219,22 +569,39 @@ func (r *RequestController) reconcileStatusPatch(
 	// for updating its Status.
 	statusPatch := requestObjectHelper.NewPatch(
 		r.Clock,
-		r.FieldOwner,
-		r.EventRecorder,
+		fieldOwner,
+		eventRecorder,
 	)
 
 	// Add a Ready condition if one does not already exist. Set initial Status
-	// to Unknown.
+	// to Unknown. We continue reconciling in the same loop, so all condition
+	// updates of this reconcile are applied in a single status patch. The paths
+	// below that don't update the status must still apply initialPatch, which is
+	// a separate patch that only contains the initial Ready condition.
+	var initialPatch RequestPatch
 	if statusPatch.SetInitializing() {
+		if denied, ok := statusPatch.(deniedPatch); ok && denied.patchDenied() {
+			logger.V(1).Info("Request is Denied. Marking as failed.")
+			return result, statusPatch, nil // apply patch, done
+		}
+
 		logger.V(1).Info("Initialised Ready condition")
+		initialStatusPatch := requestObjectHelper.NewPatch(
+			r.Clock,
+			fieldOwner,
+			eventRecorder,
+		)
+		initialStatusPatch.SetInitializing()
+		initialPatch = initialStatusPatch
+	}
 
-		// To continue reconciling this Request, we must re-run the reconcile loop
-		// after adding the Unknown Ready condition. This update will trigger a
-		// new reconcile loop, so we don't need to requeue here.
-		return result, statusPatch, nil // apply patch, done
+	if r.IssuerNotReadyCache.isWaiting(issuerGvk, issuerName, req.NamespacedName, r.Clock.Now()) {
+		logger.V(1).Info("Issuer is known to be not Ready. Waiting for it to become ready.")
+
+		return result, initialPatch, nil // apply initial patch, done
 	}
 
-	if err := r.Client.Get(ctx, issuerName, issuerObject); err != nil && apierrors.IsNotFound(err) {
+	if err := r.getIssuer(ctx, issuerGvk, issuerName, issuerObject); err != nil && apierrors.IsNotFound(err) {
 		logger.V(1).Info("Issuer not found. Waiting for it to be created")
 		statusPatch.SetWaitingForIssuerExist(err)
 
@@ -243,36 +610,223 @@ func (r *RequestController) reconcileStatusPatch(
 		logger.V(1).Error(err, "Unexpected error while getting Issuer")
 		statusPatch.SetUnexpectedError(err)
 
-		return result, nil, fmt.Errorf("unexpected get error: %v", err) // requeue with backoff
+		return result, initialPatch, fmt.Errorf("unexpected get error: %v", err) // apply initial patch, requeue with backoff
 	}
 
-	readyCondition := conditions.GetIssuerStatusCondition(
-		issuerObject.GetStatus().Conditions,
-		cmapi.IssuerConditionReady,
-	)
-	if readyCondition == nil {
-		logger.V(1).Info("Issuer is not Ready yet (no ready condition). Waiting for it to become ready.")
-		statusPatch.SetWaitingForIssuerReadyNoCondition()
+	if isIssuerPaused(issuerObject) {
+		logger.V(1).Info("Issuer is paused. Waiting for it to be unpaused.")
+		statusPatch.SetIssuerPaused()
 
 		return result, statusPatch, nil // apply patch, done
 	}
-	if readyCondition.ObservedGeneration < issuerObject.GetGeneration() {
-		logger.V(1).Info("Issuer is not Ready yet (ready condition out-of-date). Waiting for it to become ready.", "issuer ready condition", readyCondition)
-		statusPatch.SetWaitingForIssuerReadyOutdated()
 
-		return result, statusPatch, nil // apply patch, done
+	control, err := r.issuanceControl(ctx)
+	if err != nil {
+		logger.V(1).Error(err, "Unexpected error while getting the IssuanceControl")
+		statusPatch.SetUnexpectedError(err)
+
+		return result, initialPatch, err // apply initial patch, requeue with backoff
 	}
-	if readyCondition.Status != cmmeta.ConditionTrue {
-		logger.V(1).Info("Issuer is not Ready yet (status == false). Waiting for it to become ready.", "issuer ready condition", readyCondition)
-		statusPatch.SetWaitingForIssuerReadyNotReady(readyCondition)
+	if issuancePaused(control, issuerGvk) {
+		logger.V(1).Info("Issuance is paused using the IssuanceControl. Waiting for it to be resumed.")
+		statusPatch.SetIssuancePaused(control)
+		issuanceControlSkipped.WithLabelValues(requestKind(requestObject), "paused").Inc()
 
 		return result, statusPatch, nil // apply patch, done
 	}
 
-	signedCertificate, err := r.Sign(log.IntoContext(ctx, logger), requestObjectHelper.RequestObject(), issuerObject)
+	if window := activeMaintenanceWindow(logger, issuerObject, r.Clock.Now()); window != nil {
+		logger.V(1).Info("Issuer is in a scheduled maintenance window. Waiting for it to end.", "window", window.String())
+		statusPatch.SetScheduledMaintenance(*window)
+		result.RequeueAfter = window.End.Sub(r.Clock.Now())
+
+		return result, statusPatch, nil // apply patch, requeue after the maintenance window
+	}
+
+	// The issuer controller records the readiness of the issuer for its current
+	// generation, in that case we don't have to parse the (possibly stale) conditions.
+	if r.ReadinessRegistry == nil || !r.ReadinessRegistry.IsReady(issuerGvk, issuerName, issuerObject.GetGeneration()) {
+		readyCondition := conditions.GetIssuerStatusCondition(
+			issuerObject.GetStatus().Conditions,
+			cmapi.IssuerConditionReady,
+		)
+		if readyCondition == nil {
+			logger.V(1).Info("Issuer is not Ready yet (no ready condition). Waiting for it to become ready.")
+			statusPatch.SetWaitingForIssuerReadyNoCondition()
+
+			return result, statusPatch, nil // apply patch, done
+		}
+		if readyCondition.ObservedGeneration < issuerObject.GetGeneration() {
+			logger.V(1).Info("Issuer is not Ready yet (ready condition out-of-date). Waiting for it to become ready.", "issuer ready condition", readyCondition)
+			statusPatch.SetWaitingForIssuerReadyOutdated()
+
+			return result, statusPatch, nil // apply patch, done
+		}
+		if readyCondition.Status != cmmeta.ConditionTrue && r.FailOnIssuerFailed && v1alpha1.IsIssuerConditionReasonFailed(readyCondition.Reason) {
+			failedFor := time.Duration(0)
+			if readyCondition.LastTransitionTime != nil {
+				failedFor = r.Clock.Since(readyCondition.LastTransitionTime.Time)
+			}
+			if failedFor >= r.IssuerFailedGracePeriod {
+				logger.V(1).Info("Issuer failed permanently. Marking as failed.", "issuer ready condition", readyCondition)
+				statusPatch.SetIssuerFailed(readyCondition)
+				r.RetryPolicy.forget(req.NamespacedName)
+
+				return result, statusPatch, reconcile.TerminalError(fmt.Errorf("issuer failed permanently: %s", readyCondition.Message)) // apply patch, done
+			}
+
+			logger.V(1).Info("Issuer failed permanently. Waiting for it to recover within the grace period.", "issuer ready condition", readyCondition)
+			statusPatch.SetWaitingForIssuerReadyNotReady(readyCondition)
+			result.RequeueAfter = r.IssuerFailedGracePeriod - failedFor
+
+			return result, statusPatch, nil // apply patch, requeue after the grace period
+		}
+		if readyCondition.Status != cmmeta.ConditionTrue {
+			logger.V(1).Info("Issuer is not Ready yet (status == false). Waiting for it to become ready.", "issuer ready condition", readyCondition)
+			statusPatch.SetWaitingForIssuerReadyNotReady(readyCondition)
+			r.IssuerNotReadyCache.setWaiting(issuerGvk, issuerName, req.NamespacedName, r.Clock.Now())
+
+			return result, statusPatch, nil // apply patch, done
+		}
+	}
+
+	// The request that is passed to Sign has the default profile of the issuer
+	// and the DurationPolicy applied.
+	effectiveRequest := r.DurationPolicy.apply(applyDefaultProfile(requestObject, requestObjectHelper.RequestObject(), issuerObject))
+
+	var signedCertificate signer.PEMBundle
+	var signResult *signer.SignResult
+	var options signer.Options
+	deduplicated := false
+	err = r.CriticalExtensionPolicy.check(requestObjectHelper.RequestObject())
+	if err == nil {
+		err = r.checkKeyAlgorithm(requestObjectHelper.RequestObject(), issuerObject)
+	}
+	if err == nil {
+		err = checkFeatures(effectiveRequest, issuerObject)
+	}
+	if err == nil {
+		var problem string
+		effectiveRequest, problem, err = r.CSRCanonicalizationPolicy.apply(ctx, effectiveRequest)
+		if problem != "" {
+			logger.V(1).Info("CSR is not canonical and cannot be re-signed, passing it to Sign unchanged.", "problem", problem)
+			eventRecorder.Event(requestObject, corev1.EventTypeWarning, eventRequestNonCanonicalCSR, "The CSR is not canonical and cannot be re-signed: "+problem)
+		}
+	}
+	if err == nil {
+		options, err = r.RequestOptionsPolicy.options(requestObjectHelper.RequestObject())
+	}
+	if err == nil {
+		var dedupErr error
+		signedCertificate, deduplicated, dedupErr = r.DeduplicationPolicy.existingCertificate(ctx, r.Client, requestObjectHelper.RequestObject(), requestObject, issuerObject, r.Clock.Now())
+		if dedupErr != nil {
+			logger.V(1).Error(dedupErr, "Failed to look up an existing certificate for the request, calling Sign.")
+		} else if deduplicated {
+			logger.V(1).Info("Found an existing certificate that matches the request, skipping Sign.")
+		}
+	}
+	failedKey := r.FailedRequestCache.key(requestObjectHelper.RequestObject(), issuerGvk, issuerName)
+	if err == nil && !deduplicated {
+		err = r.FailedRequestCache.check(failedKey, r.Clock.Now())
+	}
+	if err == nil && !deduplicated && issuanceShadowMode(control) {
+		logger.Info("Shadow mode is enabled using the IssuanceControl. The request would be signed, but Sign is not called.")
+		issuanceControlSkipped.WithLabelValues(requestKind(requestObject), "shadow").Inc()
+
+		return result, initialPatch, nil // apply initial patch, done
+	}
+	if err == nil && !deduplicated {
+		var decision *externalApprovalDecision
+		decision, err = r.ExternalApprovalPolicy.decide(ctx, requestObjectHelper.RequestObject(), requestKind(requestObject), issuerGvk, issuerName)
+		if decision != nil {
+			logger.V(1).Info("Got external approval decision.", "decision", decision.reason, "message", decision.message)
+			statusPatch.SetCustomCondition(
+				v1alpha1.CertificateRequestConditionTypeExternalApproval,
+				decision.status,
+				decision.reason,
+				decision.message,
+			)
+		}
+	}
+	// The quota is reserved before the issuance claim is acquired, so a request
+	// over quota does not hold a claim that blocks the other replicas.
+	quotaReserved := false
+	if err == nil && !deduplicated {
+		err = r.QuotaPolicy.reserve(logger, issuerObject, issuerGvk, issuerName, requestObject.GetUID(), r.Clock.Now())
+		quotaReserved = err == nil
+		if err != nil {
+			if releaseErr := r.IssuanceClaimPolicy.release(ctx, r.Client, fieldOwner, requestObject); releaseErr != nil {
+				logger.V(1).Error(releaseErr, "Failed to release the issuance claim of the request over quota.")
+			}
+		}
+	}
+	if err == nil && !deduplicated {
+		acquired, claimExpiresIn, claimErr := r.IssuanceClaimPolicy.acquire(ctx, r.Client, fieldOwner, requestObject, r.Clock.Now())
+		if claimErr != nil {
+			r.QuotaPolicy.cancel(issuerGvk, issuerName, requestObject.GetUID())
+			return result, initialPatch, fmt.Errorf("failed to acquire issuance claim: %w", claimErr) // apply initial patch, requeue with backoff
+		}
+		if !acquired {
+			r.QuotaPolicy.cancel(issuerGvk, issuerName, requestObject.GetUID())
+			logger.V(1).Info("Issuance claim is held by another controller. Waiting for it to expire.", "expires in", claimExpiresIn)
+			result.RequeueAfter = claimExpiresIn
+
+			return result, initialPatch, nil // apply initial patch, requeue after the claim expires
+		}
+	}
+	if err == nil && !deduplicated {
+		var signCtx context.Context
+		signCtx, signResult = signer.NewSignResultContext(callbackContext(log.IntoContext(ctx, logger), r.UpstreamConfig, r.ClusterResourceNamespace))
+		if options != nil {
+			signCtx = signer.WithOptions(signCtx, options)
+		}
+		if previousCertificate, previousErr := r.PreviousCertificatePolicy.previousCertificate(ctx, r.Client, requestObjectHelper.RequestObject()); previousErr != nil {
+			logger.V(1).Error(previousErr, "Failed to look up the previous certificate for the request, calling Sign without it.")
+		} else if previousCertificate != nil {
+			signCtx = signer.WithPreviousCertificate(signCtx, previousCertificate)
+		}
+		r.publishSignStarted(requestObject, issuerObject)
+		signStart := r.Clock.Now()
+		signedCertificate, err = r.Sign(signCtx, effectiveRequest, issuerObject)
+		signDuration := r.Clock.Since(signStart)
+		err = classifyError(r.ErrorClassifier, err)
+		logCAErrorDetails(logger, err)
+		r.concurrencyLimiter.observeSignLatency(signDuration)
+		r.publishSignFinished(requestObject, issuerObject, signDuration, err)
+	}
+	if err == nil {
+		signedCertificate, err = r.PEMNormalizationPolicy.normalize(logger, signedCertificate)
+	}
+	if err == nil && r.verifySignedCertificate != nil {
+		err = r.verifySignedCertificate(ctx, requestObject, signedCertificate)
+	}
+	// Deduplicated requests were not counted against the quota, a signed
+	// certificate only counts as issued once it was normalized and verified.
+	if quotaReserved {
+		r.QuotaPolicy.complete(issuerGvk, issuerName, requestObject.GetUID(), err, r.Clock.Now())
+	}
+	if err == nil && signResult != nil {
+		if err := r.recordRevocationInfo(ctx, requestObject, issuerObject, signResult); err != nil {
+			logger.Error(err, "Failed to record the revocation info of the signed certificate")
+		}
+	}
+	if err == nil && !deduplicated {
+		if err := r.ProvenancePolicy.record(ctx, r.Client, requestObject); err != nil {
+			logger.Error(err, "Failed to record the provenance of the signed certificate")
+		}
+		if err := r.exportCertificate(ctx, requestObject, issuerGvk, issuerName, signedCertificate); err != nil {
+			logger.Error(err, "Failed to export the signed certificate to the inventory")
+		}
+	}
 	if err == nil {
 		logger.V(1).Info("Successfully finished the reconciliation.")
+		if risk := r.ChainExpiryPolicy.check(signedCertificate, r.Clock.Now()); risk != nil {
+			logger.Info("Signed certificate chain has an expiry risk.", "reason", risk.reason, "message", risk.message)
+			statusPatch.SetChainExpiryRisk(risk.reason, risk.message)
+		}
+		setExtraConditions(logger, statusPatch, signResult)
 		statusPatch.SetIssued(signedCertificate)
+		r.RetryPolicy.forget(req.NamespacedName)
 
 		return result, statusPatch, nil // apply patch, done
 	}
@@ -280,11 +834,15 @@ func (r *RequestController) reconcileStatusPatch(
 	// An error in the issuer part of the operator should trigger a reconcile
 	// of the issuer's state.
 	if issuerError := new(signer.IssuerError); errors.As(err, issuerError) {
+		if r.ReadinessRegistry != nil {
+			r.ReadinessRegistry.Forget(issuerGvk, issuerName)
+		}
+
 		if reportError := r.EventSource.ReportError(
 			issuerGvk, client.ObjectKeyFromObject(issuerObject),
 			issuerError.Err,
 		); reportError != nil {
-			return result, nil, fmt.Errorf("unexpected ReportError error: %v", reportError) // requeue with backoff
+			return result, initialPatch, fmt.Errorf("unexpected ReportError error: %v", reportError) // apply initial patch, requeue with backoff
 		}
 
 		logger.V(1).Info("Issuer is not Ready yet (ready condition out-of-date). Waiting for it to become ready.", "issuer-error", issuerError)
@@ -305,9 +863,14 @@ func (r *RequestController) reconcileStatusPatch(
 	}
 
 	// Check if we have still time to requeue & retry
-	isPending := errors.As(err, &signer.PendingError{})
+	pendingError := new(signer.PendingError)
+	isPending := errors.As(err, pendingError)
 	isPermanentError := errors.As(err, &signer.PermanentError{})
-	pastMaxRetryDuration := r.Clock.Now().After(requestObject.GetCreationTimestamp().Add(r.MaxRetryDuration))
+	maxRetryDuration := r.MaxRetryDuration
+	if r.RetryPolicy != nil {
+		maxRetryDuration = r.RetryPolicy.maxRetryDuration(req.NamespacedName, requestObject.GetUID(), r.MaxRetryDuration, err)
+	}
+	pastMaxRetryDuration := r.Clock.Now().After(requestObject.GetCreationTimestamp().Add(maxRetryDuration))
 	switch {
 	case isPending:
 		// Signing is pending, wait more.
@@ -317,23 +880,35 @@ func (r *RequestController) reconcileStatusPatch(
 		// Its message gives the reason why the signing process is still in
 		// progress. Thus, we don't log any error.
 		logger.V(1).WithValues("reason", err.Error()).Info("Signing in progress.")
-		statusPatch.SetPending(fmt.Sprintf("Signing still in progress. Reason: %s", err))
+		statusPatch.SetPending(pendingError.Reason, fmt.Sprintf("Signing still in progress. Reason: %s", err))
 
 		// Let's not trigger an unnecessary reconciliation when we know that the
 		// user-defined condition was changed and will trigger a reconciliation.
 		if didCustomConditionTransition {
 			return result, statusPatch, nil // apply patch, done
+		} else if pendingError.RetryAfter > 0 {
+			result.RequeueAfter = pendingError.RetryAfter
+			return result, statusPatch, nil // apply patch, requeue after RetryAfter
 		} else {
 			result.Requeue = true
 			return result, statusPatch, nil // apply patch, requeue with backoff
 		}
 	case isPermanentError:
 		logger.V(1).Error(err, "Permanent Request error. Marking as failed.")
+		r.FailedRequestCache.record(failedKey, err, r.Clock.Now())
+		if err := r.IssuerSnapshotPolicy.record(ctx, r.Client, requestObject, issuerObject, issuerGvk); err != nil {
+			logger.Error(err, "Failed to record the issuer snapshot")
+		}
 		statusPatch.SetPermanentError(err)
+		r.RetryPolicy.forget(req.NamespacedName)
 		return result, statusPatch, reconcile.TerminalError(err) // apply patch, done
 	case pastMaxRetryDuration:
 		logger.V(1).Error(err, "Request has been retried for too long. Marking as failed.")
+		if err := r.IssuerSnapshotPolicy.record(ctx, r.Client, requestObject, issuerObject, issuerGvk); err != nil {
+			logger.Error(err, "Failed to record the issuer snapshot")
+		}
 		statusPatch.SetPermanentError(err)
+		r.RetryPolicy.forget(req.NamespacedName)
 		return result, statusPatch, reconcile.TerminalError(err) // apply patch, done
 	default:
 		// We consider all the other errors as being retryable.
@@ -350,6 +925,69 @@ func (r *RequestController) reconcileStatusPatch(
 	}
 }
 
+// getIssuer reads the issuer using the Client, which is normally backed by the
+// manager's informer cache. If an error was reported for the issuer that the issuer
+// controller did not process yet, or if the Ready condition of the cached issuer
+// is outdated, the issuer is read again using the APIReader (when configured), so
+// we act on the freshest state available. The generation and observedGeneration
+// of the cached and the live issuer are compared to count the stale cache reads.
+func (r *RequestController) getIssuer(
+	ctx context.Context,
+	issuerGvk schema.GroupVersionKind,
+	issuerName client.ObjectKey,
+	issuerObject v1alpha1.Issuer,
+) error {
+	err := r.Client.Get(ctx, issuerName, issuerObject)
+	if r.APIReader == nil || (err != nil && !apierrors.IsNotFound(err)) {
+		return err
+	}
+
+	var cached v1alpha1.Issuer
+	if err == nil {
+		cached = issuerObject.DeepCopyObject().(v1alpha1.Issuer)
+	}
+	if !r.hasPendingReportedError(issuerGvk, issuerName) && (cached == nil || !issuerStatusOutdated(cached)) {
+		return err
+	}
+
+	if err := r.APIReader.Get(ctx, issuerName, issuerObject); err != nil {
+		return err
+	}
+
+	result := "current"
+	if cached == nil || issuerGenerations(cached) != issuerGenerations(issuerObject) {
+		result = "stale"
+	}
+	issuerLiveReads.WithLabelValues(issuerGvk.Kind, result).Inc()
+	return nil
+}
+
+// hasPendingReportedError returns true if an error was reported for the issuer
+// that the issuer controller did not process yet. EventSources that do not
+// implement kubeutil.ReportedErrorPeeker never have pending reported errors.
+func (r *RequestController) hasPendingReportedError(issuerGvk schema.GroupVersionKind, issuerName client.ObjectKey) bool {
+	peeker, ok := r.EventSource.(kubeutil.ReportedErrorPeeker)
+	return ok && peeker.PeekReportedError(issuerGvk, issuerName) != nil
+}
+
+// issuerStatusOutdated returns true if the issuer has a Ready condition that was
+// set for an older generation of the issuer. Issuers without a Ready condition
+// were not checked yet, reading them again would not return a newer status.
+func issuerStatusOutdated(issuer v1alpha1.Issuer) bool {
+	readyCondition := conditions.GetIssuerStatusCondition(issuer.GetStatus().Conditions, cmapi.IssuerConditionReady)
+	return readyCondition != nil && readyCondition.ObservedGeneration < issuer.GetGeneration()
+}
+
+// issuerGenerations returns the generation of the issuer and the
+// observedGeneration of its Ready condition.
+func issuerGenerations(issuer v1alpha1.Issuer) [2]int64 {
+	generations := [2]int64{issuer.GetGeneration(), 0}
+	if readyCondition := conditions.GetIssuerStatusCondition(issuer.GetStatus().Conditions, cmapi.IssuerConditionReady); readyCondition != nil {
+		generations[1] = readyCondition.ObservedGeneration
+	}
+	return generations
+}
+
 func (r *RequestController) setAllIssuerTypesWithGroupVersionKind(scheme *runtime.Scheme) error {
 	issuers := make([]IssuerType, 0, len(r.IssuerTypes)+len(r.ClusterIssuerTypes))
 	for _, issuer := range r.IssuerTypes {
@@ -391,12 +1029,34 @@ func (r *RequestController) Init(
 	r.requestPredicate = requestPredicate
 	r.matchIssuerType = matchIssuerType
 	r.requestObjectHelperCreator = requestObjectHelperCreator
+	r.patchFailures = newPatchFailureTracker()
 
 	r.initialised = true
 
 	return r
 }
 
+// requestEventRecorder returns the EventRecorder that is used for the events
+// recorded by the status patch of a Request.
+func (r *RequestController) requestEventRecorder() record.EventRecorder {
+	recorder := r.EventRecorder
+	if r.NotifyOwningCertificate {
+		recorder = certificateEventRecorder{recorder}
+	}
+	return r.labelPropagation.eventRecorder(recorder)
+}
+
+// warmUpExpiry returns the expiry of the Certificate that owns the Request, see
+// WarmUpPolicy. Requests that cannot be read are released last.
+func (r *RequestController) warmUpExpiry(ctx context.Context, req reconcile.Request) time.Time {
+	requestObject := r.requestType.DeepCopyObject().(client.Object)
+	if err := r.Client.Get(ctx, req.NamespacedName, requestObject); err != nil {
+		return warmUpUnowned
+	}
+
+	return r.WarmUp.expiry(ctx, r.Client, requestObject)
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *RequestController) SetupWithManager(
 	ctx context.Context,
@@ -406,6 +1066,8 @@ func (r *RequestController) SetupWithManager(
 		return fmt.Errorf("must call Init(...) before calling SetupWithManager(...)")
 	}
 
+	r.EventRecorder = r.Redaction.eventRecorder(r.EventRecorder)
+
 	if err := kubeutil.SetGroupVersionKind(mgr.GetScheme(), r.requestType); err != nil {
 		return err
 	}
@@ -414,6 +1076,30 @@ func (r *RequestController) SetupWithManager(
 		return err
 	}
 
+	labelPropagation, err := newRequestLabelPropagation(r.PropagatedLabels)
+	if err != nil {
+		return err
+	}
+	r.labelPropagation = labelPropagation
+
+	// The priority class and the tenant of the requests are determined from the
+	// watch events, before any of the predicates can filter out an event.
+	requestPredicates := []predicate.Predicate{
+		predicate.ResourceVersionChangedPredicate{},
+		r.requestPredicate,
+	}
+	var classifier *requestClassifier
+	if r.RequestPriority != nil || r.RequestTenant != nil {
+		classifier = newRequestClassifier(
+			r.RequestPriority,
+			r.RequestTenant,
+			func(obj client.Object) signer.CertificateRequestObject {
+				return r.requestObjectHelperCreator(obj).RequestObject()
+			},
+		)
+		requestPredicates = append([]predicate.Predicate{classifier.predicate()}, requestPredicates...)
+	}
+
 	build := ctrl.
 		NewControllerManagedBy(mgr).
 		For(
@@ -422,10 +1108,7 @@ func (r *RequestController) SetupWithManager(
 			// certificaterequest, this also prevents us to get in fast reconcile loop
 			// when setting the status to Pending causing the resource to update, while
 			// we only want to re-reconcile with backoff/ when a resource becomes available.
-			builder.WithPredicates(
-				predicate.ResourceVersionChangedPredicate{},
-				r.requestPredicate,
-			),
+			builder.WithPredicates(requestPredicates...),
 		)
 
 	// We watch all the issuer types. When an issuer receives a watch event, we
@@ -458,6 +1141,10 @@ func (r *RequestController) SetupWithManager(
 			r.requestType,
 			func(rawObj client.Object) []string {
 				issuerObject, issuerName, err := r.matchIssuerType(rawObj)
+				if err != nil {
+					return nil
+				}
+				issuerObject, issuerName, _, err = r.overrideIssuer(rawObj, issuerObject, issuerName)
 				if err != nil || issuerObject.GetObjectKind().GroupVersionKind() != gvk {
 					return nil
 				}
@@ -476,9 +1163,122 @@ func (r *RequestController) SetupWithManager(
 			builder.WithPredicates(
 				predicate.ResourceVersionChangedPredicate{},
 				LinkedIssuerPredicate{},
+				r.IssuerNotReadyCache.invalidatePredicate(gvk),
 			),
 		)
+
+		if r.IssuerResyncPeriod > 0 {
+			build = build.WatchesRawSource(resourceHandler.ResyncSource(
+				issuerType.Type,
+				r.IssuerResyncPeriod,
+				func(obj client.Object) bool {
+					issuerObject, ok := obj.(v1alpha1.Issuer)
+					if !ok || !issuerObject.GetStatus().IsReady(issuerObject.GetGeneration()) {
+						return false
+					}
+
+					r.IssuerNotReadyCache.invalidate(gvk, client.ObjectKeyFromObject(obj))
+					return true
+				},
+			))
+		}
+	}
+
+	// When the IssuanceControl changes, all requests are reconciled again, so
+	// that paused requests are resumed immediately.
+	if r.EnableIssuanceControl {
+		if _, _, err := mgr.GetScheme().ObjectKinds(&v1alpha1.IssuanceControl{}); err != nil {
+			return fmt.Errorf("EnableIssuanceControl requires the v1alpha1 types to be added to the scheme: %w", err)
+		}
+
+		build = build.Watches(
+			&v1alpha1.IssuanceControl{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForIssuanceControl),
+			builder.WithPredicates(
+				predicate.GenerationChangedPredicate{},
+				predicate.NewPredicateFuncs(func(obj client.Object) bool {
+					return obj.GetName() == v1alpha1.IssuanceControlName
+				}),
+			),
+		)
+	}
+
+	var newQueue func(string, workqueue.TypedRateLimiter[reconcile.Request]) workqueue.TypedRateLimitingInterface[reconcile.Request]
+	if classifier != nil {
+		var priority func(reconcile.Request) signer.PriorityClass
+		if r.RequestPriority != nil {
+			priority = classifier.priorityClass
+		}
+
+		var tenant func(reconcile.Request) string
+		if r.RequestTenant != nil {
+			tenant = classifier.tenantOf
+		}
+
+		newQueue = func(controllerName string, rateLimiter workqueue.TypedRateLimiter[reconcile.Request]) workqueue.TypedRateLimitingInterface[reconcile.Request] {
+			return newPriorityRateLimitingQueue(controllerName, rateLimiter, newPriorityQueue(controllerName, priority, tenant))
+		}
+	}
+
+	if r.WarmUp != nil {
+		delayedClock, ok := r.Clock.(clock.WithDelayedExecution)
+		if !ok {
+			delayedClock = clock.RealClock{}
+		}
+
+		kind := r.requestType.GetObjectKind().GroupVersionKind().Kind
+		newQueue = wrapNewQueueWithWarmUp(
+			kind,
+			*r.WarmUp,
+			mgr.GetLogger().WithValues("kind", kind),
+			delayedClock,
+			func(req reconcile.Request) time.Time {
+				return r.warmUpExpiry(ctx, req)
+			},
+			newQueue,
+		)
+	}
+
+	var options controller.Options
+	if r.AdaptiveConcurrency != nil {
+		limiter, err := newConcurrencyLimiter(
+			r.requestType.GetObjectKind().GroupVersionKind().Kind,
+			*r.AdaptiveConcurrency,
+			r.Clock,
+		)
+		if err != nil {
+			return err
+		}
+		r.concurrencyLimiter = limiter
+
+		// The controller runs a worker per slot of the maximum limit, the
+		// workers that exceed the current limit wait in Reconcile.
+		options.MaxConcurrentReconciles = limiter.maxConcurrency
+		newQueue = limiter.wrapNewQueue(newQueue)
+	}
+	if r.StatusPatchCoalescing != nil {
+		delayedClock, ok := r.Clock.(clock.WithDelayedExecution)
+		if !ok {
+			delayedClock = clock.RealClock{}
+		}
+
+		coalescer := newStatusPatchCoalescer(
+			r.requestType.GetObjectKind().GroupVersionKind().Kind,
+			*r.StatusPatchCoalescing,
+			delayedClock,
+			func(ctx context.Context, obj client.Object, patch client.Patch, fieldOwner string) error {
+				_, err := r.applyStatusPatch(ctx, obj, patch, fieldOwner)
+				return err
+			},
+		)
+		if err := mgr.Add(coalescer); err != nil {
+			return err
+		}
+		r.statusPatchCoalescer = coalescer
+		newQueue = coalescer.wrapNewQueue(newQueue)
 	}
+	options.NewQueue = newQueue
+	build = build.WithOptions(options)
 
 	if r.PreSetupWithManager != nil {
 		err := r.PreSetupWithManager(ctx, r.requestType.GetObjectKind().GroupVersionKind(), mgr, build)
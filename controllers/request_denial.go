@@ -0,0 +1,52 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+// deniedPatch is implemented by request patches that can tell whether they
+// marked the request as Denied, in which case the reconcile must not continue.
+type deniedPatch interface {
+	patchDenied() bool
+}
+
+// deniedBy returns the name of the field manager that set the Denied condition
+// of the CertificateRequest, which is the name of the approver that denied it,
+// or an empty string if it cannot be determined from the managed fields.
+func deniedBy(cr *cmapi.CertificateRequest) string {
+	approver := ""
+	var deniedAt metav1.Time
+	for _, entry := range cr.GetManagedFields() {
+		if !managesCondition(entry, string(cmapi.CertificateRequestConditionDenied)) {
+			continue
+		}
+
+		// Use the most recent field manager if multiple field managers set
+		// the Denied condition.
+		entryTime := ptr.Deref(entry.Time, metav1.Time{})
+		if approver == "" || deniedAt.Before(&entryTime) {
+			approver = entry.Manager
+			deniedAt = entryTime
+		}
+	}
+
+	return approver
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmgen "github.com/cert-manager/cert-manager/test/unit/gen"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDeniedBy(t *testing.T) {
+	t.Parallel()
+
+	now := metav1.NewTime(randomTime())
+	later := metav1.NewTime(now.Add(time.Minute))
+
+	managedFields := func(manager string, at *metav1.Time, fields string) metav1.ManagedFieldsEntry {
+		return metav1.ManagedFieldsEntry{
+			Manager:     manager,
+			Operation:   metav1.ManagedFieldsOperationUpdate,
+			Subresource: "status",
+			Time:        at,
+			FieldsType:  "FieldsV1",
+			FieldsV1:    &metav1.FieldsV1{Raw: []byte(fields)},
+		}
+	}
+
+	const deniedFields = `{"f:status":{"f:conditions":{"k:{\"type\":\"Denied\"}":{"f:status":{}}}}}`
+	const readyFields = `{"f:status":{"f:conditions":{"k:{\"type\":\"Ready\"}":{"f:status":{}}}}}`
+
+	tests := []struct {
+		name          string
+		managedFields []metav1.ManagedFieldsEntry
+		expected      string
+	}{
+		{
+			name:     "no-managed-fields",
+			expected: "",
+		},
+		{
+			name: "denied-by-approver",
+			managedFields: []metav1.ManagedFieldsEntry{
+				managedFields("issuer-controller", &later, readyFields),
+				managedFields("approver", &now, deniedFields),
+			},
+			expected: "approver",
+		},
+		{
+			name: "most-recent-approver",
+			managedFields: []metav1.ManagedFieldsEntry{
+				managedFields("approver-1", &later, deniedFields),
+				managedFields("approver-2", &now, deniedFields),
+			},
+			expected: "approver-1",
+		},
+		{
+			name: "invalid-fields",
+			managedFields: []metav1.ManagedFieldsEntry{
+				managedFields("approver", &now, "invalid"),
+			},
+			expected: "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cr := cmgen.CertificateRequest("cr1", func(cr *cmapi.CertificateRequest) {
+				cr.ManagedFields = tc.managedFields
+			})
+			assert.Equal(t, tc.expected, deniedBy(cr))
+		})
+	}
+}
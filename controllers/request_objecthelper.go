@@ -24,6 +24,7 @@ import (
 	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
 	"github.com/cert-manager/issuer-lib/controllers/signer"
 )
 
@@ -37,6 +38,12 @@ const (
 
 	eventRequestWaitingForIssuerExist = "WaitingForIssuerExist"
 	eventRequestWaitingForIssuerReady = "WaitingForIssuerReady"
+	eventRequestIssuerPaused          = "IssuerPaused"
+	eventRequestIssuancePaused        = v1alpha1.CertificateRequestConditionReasonIssuancePaused
+	eventRequestIssuerFailed          = v1alpha1.CertificateRequestConditionReasonIssuerFailed
+	eventRequestIgnored               = "Ignored"
+	eventRequestStatusPatchRejected   = v1alpha1.ConditionTypeStatusPatchRejected
+	eventRequestChainExpiryRisk       = v1alpha1.CertificateRequestConditionTypeChainExpiryRisk
 )
 
 type RequestObjectHelper interface {
@@ -56,22 +63,30 @@ type RequestObjectHelper interface {
 
 type RequestPatchHelper interface { //nolint:interfacebloat
 	RequestPatch
+	IssuedCertificatePatch
 
 	SetInitializing() (didInitialise bool)
 	SetWaitingForIssuerExist(error)
 	SetWaitingForIssuerReadyNoCondition()
 	SetWaitingForIssuerReadyOutdated()
 	SetWaitingForIssuerReadyNotReady(*cmapi.IssuerCondition)
+	SetIssuerPaused()
+	SetIssuancePaused(*v1alpha1.IssuanceControl)
+	SetIssuerFailed(*cmapi.IssuerCondition)
+	SetScheduledMaintenance(v1alpha1.MaintenanceWindow)
+	SetIgnored(reason string, message string)
 	SetCustomCondition(
 		conditionType string,
 		conditionStatus metav1.ConditionStatus,
 		conditionReason string, conditionMessage string,
 	) (didCustomConditionTransition bool)
-	SetPending(reason string)
+	SetPending(reason string, message string)
 	SetRetryableError(error)
 	SetPermanentError(error)
 	SetUnexpectedError(error)
 	SetIssued(signer.PEMBundle)
+	SetChainExpiryRisk(reason string, message string)
+	SetStatusPatchRejected(failures int, err error)
 }
 
 type RequestPatch interface {
@@ -17,8 +17,6 @@ limitations under the License.
 package controllers
 
 import (
-	"fmt"
-
 	cmutil "github.com/cert-manager/cert-manager/pkg/api/util"
 	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
@@ -37,6 +35,10 @@ import (
 type certificateRequestObjectHelper struct {
 	readOnlyObj               *cmapi.CertificateRequest
 	setCAOnCertificateRequest bool
+	crdCompatibility          *CRDCompatibility
+	messages                  *MessageCatalog
+	messageStabilization      *MessageStabilizationPolicy
+	redaction                 *RedactionPolicy
 }
 
 var _ RequestObjectHelper = &certificateRequestObjectHelper{}
@@ -82,6 +84,10 @@ func (c *certificateRequestObjectHelper) NewPatch(
 		readOnlyObj:               c.readOnlyObj,
 		fieldOwner:                fieldOwner,
 		setCAOnCertificateRequest: c.setCAOnCertificateRequest,
+		crdCompatibility:          c.crdCompatibility,
+		messages:                  c.messages,
+		messageStabilization:      c.messageStabilization,
+		redaction:                 c.redaction,
 		patch:                     &cmapi.CertificateRequestStatus{},
 		eventRecorder:             eventRecorder,
 	}
@@ -92,28 +98,50 @@ type certificateRequestPatchHelper struct {
 	readOnlyObj               *cmapi.CertificateRequest
 	fieldOwner                string
 	setCAOnCertificateRequest bool
+	crdCompatibility          *CRDCompatibility
+	messages                  *MessageCatalog
+	messageStabilization      *MessageStabilizationPolicy
+	redaction                 *RedactionPolicy
 
 	patch         *cmapi.CertificateRequestStatus
 	eventRecorder record.EventRecorder
+
+	tis    *timeInState
+	denied bool
+
+	issuedChain
 }
 
 var _ RequestPatchHelper = &certificateRequestPatchHelper{}
 var _ RequestPatch = &certificateRequestPatchHelper{}
 var _ CertificateRequestPatch = &certificateRequestPatchHelper{}
+var _ timeInStatePatch = &certificateRequestPatchHelper{}
+var _ fieldOwnerPatch = &certificateRequestPatchHelper{}
+var _ deniedPatch = &certificateRequestPatchHelper{}
+var _ IssuedCertificatePatch = &certificateRequestPatchHelper{}
 
 func (c *certificateRequestPatchHelper) setCondition(
 	conditionType cmapi.CertificateRequestConditionType,
 	status cmmeta.ConditionStatus,
 	reason, message string,
 ) (string, *metav1.Time) {
-	condition, updatedAt := conditions.SetCertificateRequestStatusCondition(
+	message = c.redaction.redact(message)
+	conditionMessage := c.messageStabilization.stabilizeCertificateRequestCondition(
+		c.readOnlyObj.Status.Conditions,
+		conditionType, status,
+		reason, message,
+	)
+	_, updatedAt := conditions.SetCertificateRequestStatusCondition(
 		c.clock,
 		c.readOnlyObj.Status.Conditions,
 		&c.patch.Conditions,
 		conditionType, status,
-		reason, message,
+		reason, conditionMessage,
 	)
-	return condition.Message, updatedAt
+
+	// The events contain the new message, even if the message of the condition
+	// is stabilized.
+	return message, updatedAt
 }
 
 func (c *certificateRequestPatchHelper) SetInitializing() bool {
@@ -124,9 +152,10 @@ func (c *certificateRequestPatchHelper) SetInitializing() bool {
 			cmapi.CertificateRequestConditionReady,
 			cmmeta.ConditionFalse,
 			cmapi.CertificateRequestReasonDenied,
-			"Detected that the CertificateRequest is denied, so it will never be Ready.",
+			c.messages.requestDenied(c.readOnlyObj),
 		)
 		c.patch.FailureTime = failedAt.DeepCopy()
+		c.denied = true
 		c.eventRecorder.Event(c.readOnlyObj, corev1.EventTypeWarning, eventRequestPermanentError, message)
 		return true
 	}
@@ -142,7 +171,7 @@ func (c *certificateRequestPatchHelper) SetInitializing() bool {
 		cmapi.CertificateRequestConditionReady,
 		cmmeta.ConditionUnknown,
 		v1alpha1.CertificateRequestConditionReasonInitializing,
-		fmt.Sprintf("%s has started reconciling this CertificateRequest", c.fieldOwner),
+		c.messages.requestInitializing(c.readOnlyObj, c.fieldOwner),
 	)
 	return true
 }
@@ -152,7 +181,7 @@ func (c *certificateRequestPatchHelper) SetWaitingForIssuerExist(err error) {
 		cmapi.CertificateRequestConditionReady,
 		cmmeta.ConditionFalse,
 		cmapi.CertificateRequestReasonPending,
-		fmt.Sprintf("%s. Waiting for it to be created.", err),
+		c.messages.requestWaitingForIssuerExist(c.readOnlyObj, err),
 	)
 	c.eventRecorder.Event(c.readOnlyObj, corev1.EventTypeNormal, eventRequestWaitingForIssuerExist, message)
 }
@@ -162,7 +191,7 @@ func (c *certificateRequestPatchHelper) SetWaitingForIssuerReadyNoCondition() {
 		cmapi.CertificateRequestConditionReady,
 		cmmeta.ConditionFalse,
 		cmapi.CertificateRequestReasonPending,
-		"Waiting for issuer to become ready. Current issuer ready condition: <none>.",
+		c.messages.requestWaitingForIssuerReadyNoCondition(c.readOnlyObj),
 	)
 	c.eventRecorder.Event(c.readOnlyObj, corev1.EventTypeNormal, eventRequestWaitingForIssuerReady, message)
 }
@@ -172,7 +201,7 @@ func (c *certificateRequestPatchHelper) SetWaitingForIssuerReadyOutdated() {
 		cmapi.CertificateRequestConditionReady,
 		cmmeta.ConditionFalse,
 		cmapi.CertificateRequestReasonPending,
-		"Waiting for issuer to become ready. Current issuer ready condition is outdated.",
+		c.messages.requestWaitingForIssuerReadyOutdated(c.readOnlyObj),
 	)
 	c.eventRecorder.Event(c.readOnlyObj, corev1.EventTypeNormal, eventRequestWaitingForIssuerReady, message)
 }
@@ -182,11 +211,64 @@ func (c *certificateRequestPatchHelper) SetWaitingForIssuerReadyNotReady(cond *c
 		cmapi.CertificateRequestConditionReady,
 		cmmeta.ConditionFalse,
 		cmapi.CertificateRequestReasonPending,
-		fmt.Sprintf("Waiting for issuer to become ready. Current issuer ready condition is \"%s\": %s.", cond.Reason, cond.Message),
+		c.messages.requestWaitingForIssuerReadyNotReady(c.readOnlyObj, cond),
 	)
 	c.eventRecorder.Event(c.readOnlyObj, corev1.EventTypeNormal, eventRequestWaitingForIssuerReady, message)
 }
 
+func (c *certificateRequestPatchHelper) SetIssuerPaused() {
+	message, _ := c.setCondition(
+		cmapi.CertificateRequestConditionReady,
+		cmmeta.ConditionFalse,
+		v1alpha1.CertificateRequestConditionReasonIssuerPaused,
+		c.messages.requestIssuerPaused(c.readOnlyObj),
+	)
+	c.eventRecorder.Event(c.readOnlyObj, corev1.EventTypeNormal, eventRequestIssuerPaused, message)
+}
+
+func (c *certificateRequestPatchHelper) SetIssuancePaused(control *v1alpha1.IssuanceControl) {
+	message, _ := c.setCondition(
+		cmapi.CertificateRequestConditionReady,
+		cmmeta.ConditionFalse,
+		v1alpha1.CertificateRequestConditionReasonIssuancePaused,
+		c.messages.requestIssuancePaused(c.readOnlyObj, control),
+	)
+	c.eventRecorder.Event(c.readOnlyObj, corev1.EventTypeNormal, eventRequestIssuancePaused, message)
+}
+
+func (c *certificateRequestPatchHelper) SetIssuerFailed(cond *cmapi.IssuerCondition) {
+	message, failedAt := c.setCondition(
+		cmapi.CertificateRequestConditionReady,
+		cmmeta.ConditionFalse,
+		cmapi.CertificateRequestReasonFailed,
+		c.messages.requestIssuerFailed(c.readOnlyObj, cond),
+	)
+	c.patch.FailureTime = failedAt.DeepCopy()
+	c.tis = certificateRequestTimeInState(c.readOnlyObj, c.clock.Now(), timeInStateOutcomeFailed)
+	c.eventRecorder.Event(c.readOnlyObj, corev1.EventTypeWarning, eventRequestIssuerFailed, message)
+}
+
+// SetScheduledMaintenance does not record an event, to avoid flooding the
+// request with events while the CA is down on purpose.
+func (c *certificateRequestPatchHelper) SetScheduledMaintenance(window v1alpha1.MaintenanceWindow) {
+	_, _ = c.setCondition(
+		cmapi.CertificateRequestConditionReady,
+		cmmeta.ConditionFalse,
+		v1alpha1.CertificateRequestConditionReasonScheduledMaintenance,
+		c.messages.requestScheduledMaintenance(c.readOnlyObj, window),
+	)
+}
+
+func (c *certificateRequestPatchHelper) SetIgnored(reason string, message string) {
+	message, _ = c.setCondition(
+		v1alpha1.CertificateRequestConditionTypeIgnored,
+		cmmeta.ConditionTrue,
+		reason,
+		message,
+	)
+	c.eventRecorder.Event(c.readOnlyObj, corev1.EventTypeNormal, eventRequestIgnored, message)
+}
+
 func (c *certificateRequestPatchHelper) SetCustomCondition(
 	conditionType string,
 	conditionStatus metav1.ConditionStatus,
@@ -206,18 +288,23 @@ func (c *certificateRequestPatchHelper) SetCustomCondition(
 }
 
 func (c *certificateRequestPatchHelper) SetUnexpectedError(err error) {
-	message := "Got an unexpected error while processing the CertificateRequest"
+	message := c.messages.requestUnexpectedError(c.readOnlyObj, err)
 	c.eventRecorder.Event(c.readOnlyObj, corev1.EventTypeWarning, eventRequestUnexpectedError, message)
 }
 
-func (c *certificateRequestPatchHelper) SetPending(reason string) {
-	message, _ := c.setCondition(
+func (c *certificateRequestPatchHelper) SetPending(reason string, message string) {
+	conditionReason, eventReason := cmapi.CertificateRequestReasonPending, eventRequestRetryableError
+	if reason != "" {
+		conditionReason, eventReason = reason, reason
+	}
+
+	message, _ = c.setCondition(
 		cmapi.CertificateRequestConditionReady,
 		cmmeta.ConditionFalse,
-		cmapi.CertificateRequestReasonPending,
-		fmt.Sprintf("Signing still in progress. Reason: %s", reason),
+		conditionReason,
+		c.messages.requestPending(c.readOnlyObj, message),
 	)
-	c.eventRecorder.Event(c.readOnlyObj, corev1.EventTypeWarning, eventRequestRetryableError, message)
+	c.eventRecorder.Event(c.readOnlyObj, corev1.EventTypeWarning, eventReason, message)
 }
 
 func (c *certificateRequestPatchHelper) SetRetryableError(err error) {
@@ -225,7 +312,7 @@ func (c *certificateRequestPatchHelper) SetRetryableError(err error) {
 		cmapi.CertificateRequestConditionReady,
 		cmmeta.ConditionFalse,
 		cmapi.CertificateRequestReasonPending,
-		fmt.Sprintf("Failed to sign CertificateRequest, will retry: %s", err),
+		c.messages.requestRetryableError(c.readOnlyObj, err),
 	)
 	c.eventRecorder.Event(c.readOnlyObj, corev1.EventTypeWarning, eventRequestRetryableError, message)
 }
@@ -235,14 +322,16 @@ func (c *certificateRequestPatchHelper) SetPermanentError(err error) {
 		cmapi.CertificateRequestConditionReady,
 		cmmeta.ConditionFalse,
 		cmapi.CertificateRequestReasonFailed,
-		fmt.Sprintf("Failed permanently to sign CertificateRequest: %s", err),
+		c.messages.requestPermanentError(c.readOnlyObj, err),
 	)
 	c.patch.FailureTime = failedAt.DeepCopy()
+	c.tis = certificateRequestTimeInState(c.readOnlyObj, c.clock.Now(), timeInStateOutcomeFailed)
 	c.eventRecorder.Event(c.readOnlyObj, corev1.EventTypeWarning, eventRequestPermanentError, message)
 }
 
 func (c *certificateRequestPatchHelper) SetIssued(bundle signer.PEMBundle) {
 	c.patch.Certificate = bundle.ChainPEM
+	c.issuedChain.set(bundle)
 	if c.setCAOnCertificateRequest {
 		c.patch.CA = bundle.CAPEM
 	}
@@ -250,20 +339,53 @@ func (c *certificateRequestPatchHelper) SetIssued(bundle signer.PEMBundle) {
 		cmapi.CertificateRequestConditionReady,
 		cmmeta.ConditionTrue,
 		cmapi.CertificateRequestReasonIssued,
-		"Succeeded signing the CertificateRequest",
+		c.messages.requestIssued(c.readOnlyObj),
 	)
+	c.tis = certificateRequestTimeInState(c.readOnlyObj, c.clock.Now(), timeInStateOutcomeIssued)
 	c.eventRecorder.Event(c.readOnlyObj, corev1.EventTypeNormal, eventRequestIssued, message)
 }
 
+func (c *certificateRequestPatchHelper) SetChainExpiryRisk(reason string, message string) {
+	message, _ = c.setCondition(
+		v1alpha1.CertificateRequestConditionTypeChainExpiryRisk,
+		cmmeta.ConditionTrue,
+		reason,
+		message,
+	)
+	c.eventRecorder.Event(c.readOnlyObj, corev1.EventTypeWarning, eventRequestChainExpiryRisk, message)
+}
+
+func (c *certificateRequestPatchHelper) SetStatusPatchRejected(failures int, err error) {
+	message, _ := c.setCondition(
+		v1alpha1.ConditionTypeStatusPatchRejected,
+		cmmeta.ConditionTrue,
+		statusPatchRejectedReason(err),
+		statusPatchRejectedMessage(requestKind(c.readOnlyObj), failures, err),
+	)
+	c.eventRecorder.Event(c.readOnlyObj, corev1.EventTypeWarning, eventRequestStatusPatchRejected, message)
+}
+
 func (c *certificateRequestPatchHelper) Patch() (client.Object, client.Patch, error) {
 	cr, patch, err := ssaclient.GenerateCertificateRequestStatusPatch(
 		c.readOnlyObj.Name,
 		c.readOnlyObj.Namespace,
-		c.patch,
+		c.crdCompatibility.adjustStatusPatch(c.readOnlyObj, c.patch),
 	)
 	return &cr, patch, err
 }
 
+func (c *certificateRequestPatchHelper) timeInState() *timeInState {
+	return c.tis
+}
+
+func (c *certificateRequestPatchHelper) patchFieldOwner() string {
+	return c.fieldOwner
+}
+
+func (c *certificateRequestPatchHelper) patchDenied() bool {
+	return c.denied
+}
+
 func (c *certificateRequestPatchHelper) CertificateRequestPatch() *cmapi.CertificateRequestStatus {
 	return c.patch
 }
@@ -17,8 +17,6 @@ limitations under the License.
 package controllers
 
 import (
-	"fmt"
-
 	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	"github.com/cert-manager/cert-manager/pkg/controller/certificatesigningrequests/util"
 	certificatesv1 "k8s.io/api/certificates/v1"
@@ -28,13 +26,17 @@ import (
 	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	v1alpha1 "github.com/cert-manager/issuer-lib/api/v1alpha1"
 	"github.com/cert-manager/issuer-lib/conditions"
 	"github.com/cert-manager/issuer-lib/controllers/signer"
 	"github.com/cert-manager/issuer-lib/internal/ssaclient"
 )
 
 type certificatesigningRequestObjectHelper struct {
-	readOnlyObj *certificatesv1.CertificateSigningRequest
+	readOnlyObj          *certificatesv1.CertificateSigningRequest
+	messages             *MessageCatalog
+	messageStabilization *MessageStabilizationPolicy
+	redaction            *RedactionPolicy
 }
 
 var _ RequestObjectHelper = &certificatesigningRequestObjectHelper{}
@@ -65,40 +67,74 @@ func (c *certificatesigningRequestObjectHelper) NewPatch(
 	eventRecorder record.EventRecorder,
 ) RequestPatchHelper {
 	return &certificatesigningRequestPatchHelper{
-		clock:         clock,
-		readOnlyObj:   c.readOnlyObj,
-		fieldOwner:    fieldOwner,
-		patch:         &certificatesv1.CertificateSigningRequestStatus{},
-		eventRecorder: eventRecorder,
+		clock:                clock,
+		readOnlyObj:          c.readOnlyObj,
+		fieldOwner:           fieldOwner,
+		messages:             c.messages,
+		messageStabilization: c.messageStabilization,
+		redaction:            c.redaction,
+		patch:                &certificatesv1.CertificateSigningRequestStatus{},
+		eventRecorder:        eventRecorder,
 	}
 }
 
 type certificatesigningRequestPatchHelper struct {
-	clock       clock.PassiveClock
-	readOnlyObj *certificatesv1.CertificateSigningRequest
-	fieldOwner  string
+	clock                clock.PassiveClock
+	readOnlyObj          *certificatesv1.CertificateSigningRequest
+	fieldOwner           string
+	messages             *MessageCatalog
+	messageStabilization *MessageStabilizationPolicy
+	redaction            *RedactionPolicy
 
 	patch         *certificatesv1.CertificateSigningRequestStatus
 	eventRecorder record.EventRecorder
+
+	tis *timeInState
+
+	issuedChain
 }
 
 var _ RequestPatchHelper = &certificatesigningRequestPatchHelper{}
 var _ RequestPatch = &certificatesigningRequestPatchHelper{}
 var _ CertificateSigningRequestPatch = &certificatesigningRequestPatchHelper{}
+var _ timeInStatePatch = &certificatesigningRequestPatchHelper{}
+var _ fieldOwnerPatch = &certificatesigningRequestPatchHelper{}
+var _ IssuedCertificatePatch = &certificatesigningRequestPatchHelper{}
 
 func (c *certificatesigningRequestPatchHelper) setCondition(
 	conditionType certificatesv1.RequestConditionType,
 	status corev1.ConditionStatus,
 	reason, message string,
 ) string {
-	condition, _ := conditions.SetCertificateSigningRequestStatusCondition(
+	message = c.redaction.redact(message)
+	conditionMessage := c.messageStabilization.stabilizeCertificateSigningRequestCondition(
+		c.readOnlyObj.Status.Conditions,
+		conditionType, status,
+		reason, message,
+	)
+	conditions.SetCertificateSigningRequestStatusCondition(
 		c.clock,
 		c.readOnlyObj.Status.Conditions,
 		&c.patch.Conditions,
 		conditionType, status,
-		reason, message,
+		reason, conditionMessage,
 	)
-	return condition.Message
+
+	// If the condition does not change, its LastUpdateTime is kept as well when
+	// the messages are stabilized.
+	existing := conditions.GetCertificateSigningRequestStatusCondition(c.readOnlyObj.Status.Conditions, conditionType)
+	if c.messageStabilization != nil && existing != nil &&
+		existing.Status == status && existing.Reason == reason && existing.Message == conditionMessage {
+		for i := range c.patch.Conditions {
+			if c.patch.Conditions[i].Type == conditionType {
+				c.patch.Conditions[i].LastUpdateTime = existing.LastUpdateTime
+			}
+		}
+	}
+
+	// The events contain the new message, even if the message of the condition
+	// is stabilized.
+	return message
 }
 
 func (c *certificatesigningRequestPatchHelper) SetInitializing() bool {
@@ -106,25 +142,60 @@ func (c *certificatesigningRequestPatchHelper) SetInitializing() bool {
 }
 
 func (c *certificatesigningRequestPatchHelper) SetWaitingForIssuerExist(err error) {
-	message := fmt.Sprintf("%s. Waiting for it to be created.", err)
+	message := c.messages.requestWaitingForIssuerExist(c.readOnlyObj, err)
 	c.eventRecorder.Event(c.readOnlyObj, corev1.EventTypeNormal, eventRequestWaitingForIssuerExist, message)
 }
 
 func (c *certificatesigningRequestPatchHelper) SetWaitingForIssuerReadyNoCondition() {
-	message := "Waiting for issuer to become ready. Current issuer ready condition: <none>."
+	message := c.messages.requestWaitingForIssuerReadyNoCondition(c.readOnlyObj)
 	c.eventRecorder.Event(c.readOnlyObj, corev1.EventTypeNormal, eventRequestWaitingForIssuerReady, message)
 }
 
 func (c *certificatesigningRequestPatchHelper) SetWaitingForIssuerReadyOutdated() {
-	message := "Waiting for issuer to become ready. Current issuer ready condition is outdated."
+	message := c.messages.requestWaitingForIssuerReadyOutdated(c.readOnlyObj)
 	c.eventRecorder.Event(c.readOnlyObj, corev1.EventTypeNormal, eventRequestWaitingForIssuerReady, message)
 }
 
 func (c *certificatesigningRequestPatchHelper) SetWaitingForIssuerReadyNotReady(cond *cmapi.IssuerCondition) {
-	message := fmt.Sprintf("Waiting for issuer to become ready. Current issuer ready condition is \"%s\": %s.", cond.Reason, cond.Message)
+	message := c.messages.requestWaitingForIssuerReadyNotReady(c.readOnlyObj, cond)
 	c.eventRecorder.Event(c.readOnlyObj, corev1.EventTypeNormal, eventRequestWaitingForIssuerReady, message)
 }
 
+func (c *certificatesigningRequestPatchHelper) SetIssuerPaused() {
+	message := c.messages.requestIssuerPaused(c.readOnlyObj)
+	c.eventRecorder.Event(c.readOnlyObj, corev1.EventTypeNormal, eventRequestIssuerPaused, message)
+}
+
+func (c *certificatesigningRequestPatchHelper) SetIssuancePaused(control *v1alpha1.IssuanceControl) {
+	message := c.messages.requestIssuancePaused(c.readOnlyObj, control)
+	c.eventRecorder.Event(c.readOnlyObj, corev1.EventTypeNormal, eventRequestIssuancePaused, message)
+}
+
+func (c *certificatesigningRequestPatchHelper) SetIssuerFailed(cond *cmapi.IssuerCondition) {
+	message := c.setCondition(
+		certificatesv1.CertificateFailed,
+		corev1.ConditionTrue,
+		v1alpha1.CertificateRequestConditionReasonIssuerFailed,
+		c.messages.requestIssuerFailed(c.readOnlyObj, cond),
+	)
+	c.tis = certificateSigningRequestTimeInState(c.readOnlyObj, c.clock.Now(), timeInStateOutcomeFailed)
+	c.eventRecorder.Event(c.readOnlyObj, corev1.EventTypeWarning, eventRequestIssuerFailed, message)
+}
+
+// SetScheduledMaintenance is a no-op, Kubernetes CSRs have no condition to
+// report the maintenance on and events are not recorded during maintenance.
+func (c *certificatesigningRequestPatchHelper) SetScheduledMaintenance(v1alpha1.MaintenanceWindow) {}
+
+func (c *certificatesigningRequestPatchHelper) SetIgnored(reason string, message string) {
+	message = c.setCondition(
+		v1alpha1.CertificateRequestConditionTypeIgnored,
+		corev1.ConditionTrue,
+		reason,
+		message,
+	)
+	c.eventRecorder.Event(c.readOnlyObj, corev1.EventTypeNormal, eventRequestIgnored, message)
+}
+
 func (c *certificatesigningRequestPatchHelper) SetCustomCondition(
 	conditionType string,
 	conditionStatus metav1.ConditionStatus,
@@ -143,18 +214,23 @@ func (c *certificatesigningRequestPatchHelper) SetCustomCondition(
 	return didCustomConditionTransition
 }
 
-func (c *certificatesigningRequestPatchHelper) SetPending(reason string) {
-	message := fmt.Sprintf("Signing still in progress. Reason: %s", reason)
-	c.eventRecorder.Event(c.readOnlyObj, corev1.EventTypeWarning, eventRequestRetryable, message)
+func (c *certificatesigningRequestPatchHelper) SetPending(reason string, message string) {
+	eventReason := eventRequestRetryable
+	if reason != "" {
+		eventReason = reason
+	}
+
+	message = c.messages.requestPending(c.readOnlyObj, message)
+	c.eventRecorder.Event(c.readOnlyObj, corev1.EventTypeWarning, eventReason, message)
 }
 
 func (c *certificatesigningRequestPatchHelper) SetUnexpectedError(err error) {
-	message := "Got an unexpected error while processing the CertificateSigningRequest"
+	message := c.messages.requestUnexpectedError(c.readOnlyObj, err)
 	c.eventRecorder.Event(c.readOnlyObj, corev1.EventTypeWarning, eventRequestUnexpectedError, message)
 }
 
 func (c *certificatesigningRequestPatchHelper) SetRetryableError(err error) {
-	message := fmt.Sprintf("Failed to sign CertificateSigningRequest, will retry: %s", err)
+	message := c.messages.requestRetryableError(c.readOnlyObj, err)
 	c.eventRecorder.Event(c.readOnlyObj, corev1.EventTypeWarning, eventRequestRetryableError, message)
 }
 
@@ -163,17 +239,40 @@ func (c *certificatesigningRequestPatchHelper) SetPermanentError(err error) {
 		certificatesv1.CertificateFailed,
 		corev1.ConditionTrue,
 		cmapi.CertificateRequestReasonFailed,
-		fmt.Sprintf("CertificateSigningRequest has failed permanently: %s", err),
+		c.messages.requestPermanentError(c.readOnlyObj, err),
 	)
+	c.tis = certificateSigningRequestTimeInState(c.readOnlyObj, c.clock.Now(), timeInStateOutcomeFailed)
 	c.eventRecorder.Event(c.readOnlyObj, corev1.EventTypeWarning, eventRequestPermanentError, message)
 }
 
 func (c *certificatesigningRequestPatchHelper) SetIssued(bundle signer.PEMBundle) {
 	c.patch.Certificate = bundle.ChainPEM
-	message := "Succeeded signing the CertificateSigningRequest"
+	c.issuedChain.set(bundle)
+	message := c.messages.requestIssued(c.readOnlyObj)
+	c.tis = certificateSigningRequestTimeInState(c.readOnlyObj, c.clock.Now(), timeInStateOutcomeIssued)
 	c.eventRecorder.Event(c.readOnlyObj, corev1.EventTypeNormal, eventRequestIssued, message)
 }
 
+func (c *certificatesigningRequestPatchHelper) SetChainExpiryRisk(reason string, message string) {
+	message = c.setCondition(
+		v1alpha1.CertificateRequestConditionTypeChainExpiryRisk,
+		corev1.ConditionTrue,
+		reason,
+		message,
+	)
+	c.eventRecorder.Event(c.readOnlyObj, corev1.EventTypeWarning, eventRequestChainExpiryRisk, message)
+}
+
+func (c *certificatesigningRequestPatchHelper) SetStatusPatchRejected(failures int, err error) {
+	message := c.setCondition(
+		v1alpha1.ConditionTypeStatusPatchRejected,
+		corev1.ConditionTrue,
+		statusPatchRejectedReason(err),
+		statusPatchRejectedMessage(requestKind(c.readOnlyObj), failures, err),
+	)
+	c.eventRecorder.Event(c.readOnlyObj, corev1.EventTypeWarning, eventRequestStatusPatchRejected, message)
+}
+
 func (c *certificatesigningRequestPatchHelper) Patch() (client.Object, client.Patch, error) {
 	csr, patch, err := ssaclient.GenerateCertificateSigningRequestStatusPatch(
 		c.readOnlyObj.Name,
@@ -183,6 +282,14 @@ func (c *certificatesigningRequestPatchHelper) Patch() (client.Object, client.Pa
 	return &csr, patch, err
 }
 
+func (c *certificatesigningRequestPatchHelper) timeInState() *timeInState {
+	return c.tis
+}
+
+func (c *certificatesigningRequestPatchHelper) patchFieldOwner() string {
+	return c.fieldOwner
+}
+
 func (c *certificatesigningRequestPatchHelper) CertificateSigningRequestPatch() *certificatesv1.CertificateSigningRequestStatus {
 	return c.patch
 }
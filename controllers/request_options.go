@@ -0,0 +1,130 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+const (
+	defaultMaxRequestOptions           = 16
+	defaultMaxRequestOptionValueLength = 1024
+)
+
+// RequestOptionsPolicy enables per-request options: the annotations with the
+// v1alpha1.RequestOptionAnnotationPrefix prefix on a request are collected into
+// signer.Options, which are passed to the Sign function through the context (see
+// signer.OptionsFromContext). Requests with invalid options are failed
+// permanently, without calling Sign. Without this policy, the annotations are
+// ignored.
+type RequestOptionsPolicy struct {
+	// MaxOptions is the maximum number of options on a request, defaults to 16.
+	MaxOptions int
+	// MaxValueLength is the maximum length in bytes of the value of an option,
+	// defaults to 1024.
+	MaxValueLength int
+
+	// AllowedOptions are the names of the supported options. If set, requests
+	// with other options are failed permanently.
+	AllowedOptions []string
+	// Validate is an optional function that validates the options of a request,
+	// eg. to check that the value of an option is one of the supported values.
+	// An error fails the request permanently, unless it is a signer error of
+	// another type (eg. a PendingError).
+	Validate func(cr signer.CertificateRequestObject, options signer.Options) error
+}
+
+// options returns the options of the request, or an error if the options of the
+// request are invalid. A nil policy returns no options.
+func (p *RequestOptionsPolicy) options(cr signer.CertificateRequestObject) (signer.Options, error) {
+	if p == nil {
+		return nil, nil
+	}
+
+	options := signer.Options{}
+	for key, value := range cr.GetAnnotations() {
+		name, ok := strings.CutPrefix(key, v1alpha1.RequestOptionAnnotationPrefix)
+		if !ok {
+			continue
+		}
+
+		if name == "" {
+			return nil, signer.PermanentError{Err: fmt.Errorf("the annotation %q does not contain an option name", key)}
+		}
+
+		options[name] = value
+	}
+
+	if err := p.check(options); err != nil {
+		return nil, signer.PermanentError{Err: err}
+	}
+
+	if p.Validate != nil {
+		if err := p.Validate(cr, options); err != nil {
+			if isSignerError(err) {
+				return nil, err
+			}
+			return nil, signer.PermanentError{Err: fmt.Errorf("invalid request options: %w", err)}
+		}
+	}
+
+	return options, nil
+}
+
+func (p *RequestOptionsPolicy) check(options signer.Options) error {
+	maxOptions := p.MaxOptions
+	if maxOptions == 0 {
+		maxOptions = defaultMaxRequestOptions
+	}
+	if len(options) > maxOptions {
+		return fmt.Errorf("the request has %d options, at most %d options are allowed", len(options), maxOptions)
+	}
+
+	maxValueLength := p.MaxValueLength
+	if maxValueLength == 0 {
+		maxValueLength = defaultMaxRequestOptionValueLength
+	}
+
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var unsupported []string
+	for _, name := range names {
+		if len(options[name]) > maxValueLength {
+			return fmt.Errorf("the value of option %q is %d bytes long, at most %d bytes are allowed", name, len(options[name]), maxValueLength)
+		}
+
+		if len(p.AllowedOptions) > 0 && !slices.Contains(p.AllowedOptions, name) {
+			unsupported = append(unsupported, name)
+		}
+	}
+
+	if len(unsupported) > 0 {
+		return fmt.Errorf("the request has unsupported options: %s", strings.Join(unsupported, ", "))
+	}
+
+	return nil
+}
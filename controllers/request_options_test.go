@@ -0,0 +1,213 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	cmgen "github.com/cert-manager/cert-manager/test/unit/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/tests/errormatch"
+)
+
+func TestRequestOptionsPolicyOptions(t *testing.T) {
+	t.Parallel()
+
+	option := func(name string) string {
+		return v1alpha1.RequestOptionAnnotationPrefix + name
+	}
+
+	type testCase struct {
+		name            string
+		policy          *RequestOptionsPolicy
+		annotations     map[string]string
+		expectedOptions signer.Options
+		validateError   *errormatch.Matcher
+		permanent       bool
+	}
+
+	tests := []testCase{
+		{
+			name:   "nil-policy",
+			policy: nil,
+			annotations: map[string]string{
+				option("profile"): "tls-server",
+			},
+			expectedOptions: nil,
+			validateError:   errormatch.NoError(),
+		},
+		{
+			name:   "collect-options",
+			policy: &RequestOptionsPolicy{},
+			annotations: map[string]string{
+				option("profile"):                     "tls-server",
+				option("validity"):                    "24h",
+				"issuer-lib.cert-manager.io/other":    "ignored",
+				"example.com/option.unrelated-option": "ignored",
+			},
+			expectedOptions: signer.Options{
+				"profile":  "tls-server",
+				"validity": "24h",
+			},
+			validateError: errormatch.NoError(),
+		},
+		{
+			name:            "no-options",
+			policy:          &RequestOptionsPolicy{},
+			expectedOptions: signer.Options{},
+			validateError:   errormatch.NoError(),
+		},
+		{
+			name:   "empty-option-name",
+			policy: &RequestOptionsPolicy{},
+			annotations: map[string]string{
+				option(""): "value",
+			},
+			validateError: errormatch.ErrorContains("does not contain an option name"),
+			permanent:     true,
+		},
+		{
+			name:   "too-many-options",
+			policy: &RequestOptionsPolicy{MaxOptions: 1},
+			annotations: map[string]string{
+				option("a"): "1",
+				option("b"): "2",
+			},
+			validateError: errormatch.ErrorContains("the request has 2 options, at most 1 options are allowed"),
+			permanent:     true,
+		},
+		{
+			name:   "value-too-long",
+			policy: &RequestOptionsPolicy{},
+			annotations: map[string]string{
+				option("a"): strings.Repeat("x", 1025),
+			},
+			validateError: errormatch.ErrorContains("the value of option \"a\" is 1025 bytes long, at most 1024 bytes are allowed"),
+			permanent:     true,
+		},
+		{
+			name: "unsupported-options",
+			policy: &RequestOptionsPolicy{
+				AllowedOptions: []string{"profile"},
+			},
+			annotations: map[string]string{
+				option("profile"): "tls-server",
+				option("b"):       "2",
+				option("a"):       "1",
+			},
+			validateError: errormatch.ErrorContains("the request has unsupported options: a, b"),
+			permanent:     true,
+		},
+		{
+			name: "validate-error",
+			policy: &RequestOptionsPolicy{
+				Validate: func(_ signer.CertificateRequestObject, options signer.Options) error {
+					if options.String("profile", "") != "tls-server" {
+						return errors.New("unknown profile")
+					}
+					return nil
+				},
+			},
+			annotations: map[string]string{
+				option("profile"): "tls-client",
+			},
+			validateError: errormatch.ErrorContains("invalid request options: unknown profile"),
+			permanent:     true,
+		},
+		{
+			name: "validate-signer-error",
+			policy: &RequestOptionsPolicy{
+				Validate: func(_ signer.CertificateRequestObject, _ signer.Options) error {
+					return signer.PendingError{Err: errors.New("profiles not loaded yet")}
+				},
+			},
+			annotations: map[string]string{
+				option("profile"): "tls-server",
+			},
+			validateError: errormatch.ErrorContains("profiles not loaded yet"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cr := cmgen.CertificateRequest("cr1", cmgen.SetCertificateRequestAnnotations(tc.annotations))
+			options, err := tc.policy.options(signer.CertificateRequestObjectFromCertificateRequest(cr))
+			(*tc.validateError)(t, err)
+
+			if err == nil {
+				assert.Equal(t, tc.expectedOptions, options)
+			}
+			assert.Equal(t, tc.permanent, errors.As(err, &signer.PermanentError{}))
+		})
+	}
+}
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+
+	options := signer.Options{
+		"enabled":  "true",
+		"count":    "3",
+		"validity": "90m",
+		"invalid":  "not-a-value",
+	}
+
+	ctx := signer.WithOptions(context.TODO(), options)
+	assert.Equal(t, options, signer.OptionsFromContext(ctx))
+	assert.Nil(t, signer.OptionsFromContext(context.TODO()))
+
+	value, ok := options.Get("enabled")
+	assert.True(t, ok)
+	assert.Equal(t, "true", value)
+	assert.Equal(t, "default", options.String("missing", "default"))
+
+	enabled, err := options.Bool("enabled", false)
+	require.NoError(t, err)
+	assert.True(t, enabled)
+
+	count, err := options.Int("count", 1)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	validity, err := options.Duration("validity", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Minute, validity)
+
+	validity, err = options.Duration("missing", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, validity)
+
+	_, err = options.Bool("invalid", false)
+	require.ErrorAs(t, err, &signer.PermanentError{})
+	assert.ErrorContains(t, err, "invalid value \"not-a-value\" for option \"invalid\"")
+
+	// Reading a nil Options map is safe.
+	var nilOptions signer.Options
+	count, err = nilOptions.Int("count", 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
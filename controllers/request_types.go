@@ -0,0 +1,164 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+)
+
+// requestType is a type of request that the CombinedController can sign.
+type requestType struct {
+	name             string
+	groupVersionKind schema.GroupVersionKind
+
+	// permissions returns the permissions that the controller for this request
+	// kind needs to sign requests for the issuer types.
+	permissions func(issuerTypes []v1alpha1.Issuer) []authorizationv1.ResourceAttributes
+}
+
+var (
+	certificateRequestType = requestType{
+		name:             "CertificateRequest",
+		groupVersionKind: cmapi.SchemeGroupVersion.WithKind(cmapi.CertificateRequestKind),
+		permissions: func(_ []v1alpha1.Issuer) []authorizationv1.ResourceAttributes {
+			return requestPermissions(cmapi.SchemeGroupVersion.Group, "certificaterequests")
+		},
+	}
+
+	kubernetesCSRType = requestType{
+		name:             "Kubernetes CSR",
+		groupVersionKind: certificatesv1.SchemeGroupVersion.WithKind("CertificateSigningRequest"),
+		permissions: func(issuerTypes []v1alpha1.Issuer) []authorizationv1.ResourceAttributes {
+			permissions := requestPermissions(certificatesv1.SchemeGroupVersion.Group, "certificatesigningrequests")
+			for _, issuerType := range issuerTypes {
+				permissions = append(permissions, authorizationv1.ResourceAttributes{
+					Group:    certificatesv1.SchemeGroupVersion.Group,
+					Resource: "signers",
+					Verb:     "sign",
+					Name:     issuerType.GetIssuerTypeIdentifier() + "/*",
+				})
+			}
+			return permissions
+		},
+	}
+)
+
+func requestPermissions(group string, resource string) []authorizationv1.ResourceAttributes {
+	permissions := make([]authorizationv1.ResourceAttributes, 0, 4)
+	for _, verb := range []string{"get", "list", "watch"} {
+		permissions = append(permissions, authorizationv1.ResourceAttributes{Group: group, Resource: resource, Verb: verb})
+	}
+	return append(permissions, authorizationv1.ResourceAttributes{Group: group, Resource: resource, Subresource: "status", Verb: "patch"})
+}
+
+// requestTypeEnabled returns whether the controller for a request kind must be
+// set up, and whether it was enabled explicitly using the Enable option (in which
+// case its prerequisites are checked).
+func requestTypeEnabled(kind requestType, enable *bool, disable bool) (enabled bool, explicit bool, err error) {
+	if enable == nil {
+		return !disable, false, nil
+	}
+	if *enable && disable {
+		return false, false, fmt.Errorf("the %s controller is both enabled and disabled", kind.name)
+	}
+	return *enable, *enable, nil
+}
+
+// accessReviewer returns whether the manager is allowed to perform an action.
+type accessReviewer func(ctx context.Context, attributes authorizationv1.ResourceAttributes) (bool, error)
+
+// newSelfSubjectAccessReviewer returns an accessReviewer that uses
+// SelfSubjectAccessReviews to check the permissions of the manager.
+func newSelfSubjectAccessReviewer(config *rest.Config) (accessReviewer, error) {
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, attributes authorizationv1.ResourceAttributes) (bool, error) {
+		review, err := clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &attributes,
+			},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return false, err
+		}
+		return review.Status.Allowed, nil
+	}, nil
+}
+
+// checkRequestTypePrerequisites returns an error if the API of the request kind
+// is not served (eg. because the cert-manager CRDs are not installed) or if the
+// manager is missing one of the permissions that are needed to sign requests of
+// this kind.
+func checkRequestTypePrerequisites(
+	ctx context.Context,
+	restMapper apimeta.RESTMapper,
+	reviewAccess accessReviewer,
+	kind requestType,
+	issuerTypes []v1alpha1.Issuer,
+) error {
+	if _, err := restMapper.RESTMapping(kind.groupVersionKind.GroupKind(), kind.groupVersionKind.Version); err != nil {
+		return fmt.Errorf("the %s API %s is not available (are the CRDs installed?): %w", kind.name, kind.groupVersionKind.GroupVersion(), err)
+	}
+
+	var missing []string
+	for _, attributes := range kind.permissions(issuerTypes) {
+		allowed, err := reviewAccess(ctx, attributes)
+		if err != nil {
+			return fmt.Errorf("failed to check the %s permissions: %w", kind.name, err)
+		}
+		if !allowed {
+			missing = append(missing, formatResourceAttributes(attributes))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing RBAC permissions for the %s controller: %s", kind.name, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// formatResourceAttributes formats the attributes as "<verb> <resource>[/<subresource>][.<group>][ <name>]".
+func formatResourceAttributes(attributes authorizationv1.ResourceAttributes) string {
+	resource := attributes.Resource
+	if attributes.Subresource != "" {
+		resource += "/" + attributes.Subresource
+	}
+	if attributes.Group != "" {
+		resource += "." + attributes.Group
+	}
+	formatted := fmt.Sprintf("%s %s", attributes.Verb, resource)
+	if attributes.Name != "" {
+		formatted += " " + attributes.Name
+	}
+	return formatted
+}
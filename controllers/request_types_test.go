@@ -0,0 +1,106 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/utils/ptr"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+)
+
+func TestRequestTypeEnabled(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name             string
+		enable           *bool
+		disable          bool
+		expectedEnabled  bool
+		expectedExplicit bool
+		expectedErr      bool
+	}
+
+	tests := []testcase{
+		{name: "default", expectedEnabled: true},
+		{name: "disabled", disable: true},
+		{name: "explicitly-enabled", enable: ptr.To(true), expectedEnabled: true, expectedExplicit: true},
+		{name: "explicitly-disabled", enable: ptr.To(false)},
+		{name: "explicitly-disabled-and-disabled", enable: ptr.To(false), disable: true},
+		{name: "explicitly-enabled-and-disabled", enable: ptr.To(true), disable: true, expectedErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			enabled, explicit, err := requestTypeEnabled(certificateRequestType, tc.enable, tc.disable)
+			if tc.expectedErr {
+				require.EqualError(t, err, "the CertificateRequest controller is both enabled and disabled")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedEnabled, enabled)
+			assert.Equal(t, tc.expectedExplicit, explicit)
+		})
+	}
+}
+
+func TestCheckRequestTypePrerequisites(t *testing.T) {
+	t.Parallel()
+
+	restMapper := apimeta.NewDefaultRESTMapper(nil)
+	restMapper.Add(certificatesv1.SchemeGroupVersion.WithKind("CertificateSigningRequest"), apimeta.RESTScopeRoot)
+
+	issuerTypes := []v1alpha1.Issuer{&api.TestIssuer{}, &api.TestClusterIssuer{}}
+	allowAll := func(context.Context, authorizationv1.ResourceAttributes) (bool, error) {
+		return true, nil
+	}
+
+	require.NoError(t, checkRequestTypePrerequisites(context.TODO(), restMapper, allowAll, kubernetesCSRType, issuerTypes))
+
+	err := checkRequestTypePrerequisites(context.TODO(), restMapper, allowAll, certificateRequestType, issuerTypes)
+	assert.ErrorContains(t, err, "the CertificateRequest API cert-manager.io/v1 is not available (are the CRDs installed?)")
+	assert.True(t, apimeta.IsNoMatchError(err))
+
+	restMapper.Add(cmapi.SchemeGroupVersion.WithKind(cmapi.CertificateRequestKind), apimeta.RESTScopeNamespace)
+	require.NoError(t, checkRequestTypePrerequisites(context.TODO(), restMapper, allowAll, certificateRequestType, issuerTypes))
+
+	denyStatusAndClusterSigner := func(_ context.Context, attributes authorizationv1.ResourceAttributes) (bool, error) {
+		return attributes.Subresource != "status" && attributes.Name != "testclusterissuers.testing.cert-manager.io/*", nil
+	}
+	err = checkRequestTypePrerequisites(context.TODO(), restMapper, denyStatusAndClusterSigner, kubernetesCSRType, issuerTypes)
+	assert.EqualError(t, err, "missing RBAC permissions for the Kubernetes CSR controller: "+
+		"patch certificatesigningrequests/status.certificates.k8s.io, "+
+		"sign signers.certificates.k8s.io testclusterissuers.testing.cert-manager.io/*")
+
+	reviewFailed := func(context.Context, authorizationv1.ResourceAttributes) (bool, error) {
+		return false, errors.New("[review error]")
+	}
+	err = checkRequestTypePrerequisites(context.TODO(), restMapper, reviewFailed, certificateRequestType, issuerTypes)
+	assert.EqualError(t, err, "failed to check the CertificateRequest permissions: [review error]")
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// RetryPolicyRule describes a class of Sign errors and how observing an error
+// of that class changes the retry window of a request.
+type RetryPolicyRule struct {
+	// Name identifies the error class.
+	Name string
+
+	// Matches returns true if the error belongs to this error class.
+	Matches func(err error) bool
+
+	// Adjustment is added to the MaxRetryDuration of a request once an error
+	// of this class was returned by Sign for that request. A positive value
+	// extends the retry window (eg. for network timeouts), a negative value
+	// shortens it (eg. for client errors returned by the CA).
+	Adjustment time.Duration
+}
+
+// RetryPolicy adapts the MaxRetryDuration of each request based on the classes
+// of the errors that were returned by Sign for that request. Each error class
+// is counted once per request, independent of how often it was observed.
+// The error history is kept in memory, so it is lost when the controller restarts.
+type RetryPolicy struct {
+	// Rules are evaluated in order, the first matching rule determines the
+	// class of an error. Errors that don't match any rule are not recorded.
+	Rules []RetryPolicyRule
+
+	mu      sync.Mutex
+	history map[types.NamespacedName]*retryHistory
+}
+
+type retryHistory struct {
+	uid     types.UID
+	classes map[int]struct{}
+}
+
+// maxRetryDuration records the class of the error for the request and returns
+// the retry window of the request, based on all error classes observed so far.
+func (p *RetryPolicy) maxRetryDuration(
+	name types.NamespacedName,
+	uid types.UID,
+	base time.Duration,
+	err error,
+) time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.history == nil {
+		p.history = map[types.NamespacedName]*retryHistory{}
+	}
+
+	history, ok := p.history[name]
+	if !ok || history.uid != uid {
+		history = &retryHistory{uid: uid, classes: map[int]struct{}{}}
+		p.history[name] = history
+	}
+
+	for i, rule := range p.Rules {
+		if rule.Matches != nil && rule.Matches(err) {
+			history.classes[i] = struct{}{}
+			break
+		}
+	}
+
+	maxRetryDuration := base
+	for i := range history.classes {
+		maxRetryDuration += p.Rules[i].Adjustment
+	}
+
+	if maxRetryDuration < 0 {
+		return 0
+	}
+
+	return maxRetryDuration
+}
+
+// forget removes the error history of the request, it must be called once a
+// request no longer has to be retried.
+func (p *RetryPolicy) forget(name types.NamespacedName) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.history, name)
+}
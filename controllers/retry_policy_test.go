@@ -0,0 +1,76 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestRetryPolicyMaxRetryDuration(t *testing.T) {
+	t.Parallel()
+
+	errTimeout := errors.New("timeout")
+	errClient := errors.New("client error")
+	errOther := errors.New("other error")
+
+	policy := &RetryPolicy{
+		Rules: []RetryPolicyRule{
+			{
+				Name:       "timeout",
+				Matches:    func(err error) bool { return errors.Is(err, errTimeout) },
+				Adjustment: 10 * time.Minute,
+			},
+			{
+				Name:       "client-error",
+				Matches:    func(err error) bool { return errors.Is(err, errClient) },
+				Adjustment: -4 * time.Minute,
+			},
+		},
+	}
+
+	name := types.NamespacedName{Namespace: "ns1", Name: "cr1"}
+	base := 5 * time.Minute
+
+	// Errors that don't match a rule don't change the retry window.
+	assert.Equal(t, base, policy.maxRetryDuration(name, "uid1", base, errOther))
+
+	// Each error class is only counted once.
+	assert.Equal(t, 15*time.Minute, policy.maxRetryDuration(name, "uid1", base, errTimeout))
+	assert.Equal(t, 15*time.Minute, policy.maxRetryDuration(name, "uid1", base, errTimeout))
+
+	// Previously observed error classes are remembered.
+	assert.Equal(t, 11*time.Minute, policy.maxRetryDuration(name, "uid1", base, errClient))
+	assert.Equal(t, 11*time.Minute, policy.maxRetryDuration(name, "uid1", base, errOther))
+
+	// A recreated request starts with an empty history.
+	assert.Equal(t, time.Minute, policy.maxRetryDuration(name, "uid2", base, errClient))
+
+	// The retry window is never negative.
+	assert.Equal(t, time.Duration(0), policy.maxRetryDuration(name, "uid2", time.Minute, errClient))
+
+	// Forgetting a request clears its history.
+	policy.forget(name)
+	assert.Equal(t, base, policy.maxRetryDuration(name, "uid2", base, errOther))
+
+	// Forgetting a request on a nil policy is a no-op.
+	(*RetryPolicy)(nil).forget(name)
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+// IssuerRevocationInfo is the value of the IssuerRevocationInfoAnnotationKey
+// annotation, it contains all revocation endpoints that were returned by Sign
+// for the requests of an issuer.
+type IssuerRevocationInfo struct {
+	OCSPServers           []string `json:"ocspServers,omitempty"`
+	CRLDistributionPoints []string `json:"crlDistributionPoints,omitempty"`
+}
+
+// add adds the revocation endpoints of the sign result and returns true if
+// one of the endpoints was not yet known.
+func (i *IssuerRevocationInfo) add(result *signer.SignResult) bool {
+	added := false
+	insert := func(urls []string, url string) []string {
+		if url == "" || slices.Contains(urls, url) {
+			return urls
+		}
+		added = true
+		urls = append(urls, url)
+		slices.Sort(urls)
+		return urls
+	}
+
+	i.OCSPServers = insert(i.OCSPServers, result.OCSPServer)
+	i.CRLDistributionPoints = insert(i.CRLDistributionPoints, result.CRLDistributionPoint)
+	return added
+}
+
+// recordRevocationInfo sets the revocation endpoints that Sign recorded in the
+// sign result as annotations on the request and, if AggregateRevocationInfo is
+// enabled, adds them to the IssuerRevocationInfoAnnotationKey annotation of the issuer.
+func (r *RequestController) recordRevocationInfo(
+	ctx context.Context,
+	requestObject client.Object,
+	issuerObject v1alpha1.Issuer,
+	result *signer.SignResult,
+) error {
+	if result.OCSPServer == "" && result.CRLDistributionPoint == "" {
+		return nil
+	}
+
+	annotations := map[string]string{}
+	if result.OCSPServer != "" {
+		annotations[v1alpha1.RequestOCSPServerAnnotationKey] = result.OCSPServer
+	}
+	if result.CRLDistributionPoint != "" {
+		annotations[v1alpha1.RequestCRLDistributionPointAnnotationKey] = result.CRLDistributionPoint
+	}
+
+	if err := patchAnnotations(ctx, r.Client, requestObject, "", annotations); err != nil {
+		return fmt.Errorf("failed to set revocation info on request: %w", err)
+	}
+
+	if !r.AggregateRevocationInfo {
+		return nil
+	}
+
+	var info IssuerRevocationInfo
+	if value, ok := issuerObject.GetAnnotations()[v1alpha1.IssuerRevocationInfoAnnotationKey]; ok {
+		// An invalid value is overwritten.
+		_ = json.Unmarshal([]byte(value), &info)
+	}
+
+	if !info.add(result) {
+		return nil
+	}
+
+	value, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+
+	// The resourceVersion makes sure that we don't overwrite endpoints that
+	// were added concurrently for other requests.
+	if err := patchAnnotations(ctx, r.Client, issuerObject, issuerObject.GetResourceVersion(), map[string]string{
+		v1alpha1.IssuerRevocationInfoAnnotationKey: string(value),
+	}); err != nil {
+		return fmt.Errorf("failed to add revocation info to issuer: %w", err)
+	}
+
+	return nil
+}
+
+// patchAnnotations sets the annotations on the object using a merge patch. If
+// the resourceVersion is not empty, the patch fails with a conflict if the
+// object was changed in the meantime.
+func patchAnnotations(ctx context.Context, cl client.Client, obj client.Object, resourceVersion string, annotations map[string]string) error {
+	metadata := map[string]interface{}{
+		"annotations": annotations,
+	}
+	if resourceVersion != "" {
+		metadata["resourceVersion"] = resourceVersion
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": metadata,
+	})
+	if err != nil {
+		return err
+	}
+
+	return cl.Patch(ctx, obj, client.RawPatch(types.MergePatchType, patch))
+}
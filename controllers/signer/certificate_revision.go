@@ -0,0 +1,92 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// CertificateRevision identifies the revision of a cert-manager Certificate that
+// a CertificateRequest was created for. cert-manager creates a new CertificateRequest
+// for the same revision when a previous request failed, and increments the
+// revision after each successful issuance.
+type CertificateRevision struct {
+	// Namespace and Name of the Certificate.
+	Namespace string
+	Name      string
+	// UID of the Certificate, read from the owner reference of the request. It
+	// is empty if the request is not owned by the Certificate.
+	UID types.UID
+	// Revision is the value of the cert-manager.io/certificate-revision
+	// annotation of the request.
+	Revision int
+}
+
+// IdempotencyKey returns a key for CA APIs that support idempotent issuance. The
+// key is the same for all requests of this revision of the Certificate (including
+// the requests that cert-manager creates again after a failure), and differs for
+// other revisions and for a recreated Certificate with the same name (if the UID
+// is known). The key is a hex encoded SHA-256 hash, so it fits the length and
+// character restrictions of most APIs.
+func (r CertificateRevision) IdempotencyKey() string {
+	hash := sha256.New()
+	for _, part := range []string{r.Namespace, r.Name, string(r.UID), strconv.Itoa(r.Revision)} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// certificateRevisionFromObject returns the Certificate revision of a request
+// from its cert-manager.io/certificate-revision and cert-manager.io/certificate-name
+// annotations and its owner references. False is returned if the request was
+// not created by cert-manager for a Certificate.
+func certificateRevisionFromObject(obj metav1.Object) (CertificateRevision, bool) {
+	revision, err := strconv.Atoi(obj.GetAnnotations()[cmapi.CertificateRequestRevisionAnnotationKey])
+	if err != nil || revision < 1 {
+		return CertificateRevision{}, false
+	}
+
+	certificate := CertificateRevision{
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetAnnotations()[cmapi.CertificateNameKey],
+		Revision:  revision,
+	}
+	for _, ownerReference := range obj.GetOwnerReferences() {
+		if ownerReference.Kind != cmapi.CertificateKind || ownerReference.APIVersion != cmapi.SchemeGroupVersion.String() {
+			continue
+		}
+		if certificate.Name == "" {
+			certificate.Name = ownerReference.Name
+		}
+		if ownerReference.Name == certificate.Name {
+			certificate.UID = ownerReference.UID
+		}
+		break
+	}
+
+	if certificate.Name == "" {
+		return CertificateRevision{}, false
+	}
+	return certificate, true
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signer
+
+import (
+	"testing"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/stretchr/testify/assert"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetCertificateRevision(t *testing.T) {
+	t.Parallel()
+
+	ownerReference := metav1.OwnerReference{
+		APIVersion: cmapi.SchemeGroupVersion.String(),
+		Kind:       cmapi.CertificateKind,
+		Name:       "cert-1",
+		UID:        "uid-1",
+	}
+
+	type testcase struct {
+		name             string
+		objectMeta       metav1.ObjectMeta
+		expectedRevision CertificateRevision
+		expectedOk       bool
+	}
+
+	tests := []testcase{
+		{
+			name: "annotations-and-owner",
+			objectMeta: metav1.ObjectMeta{
+				Namespace: "ns1",
+				Annotations: map[string]string{
+					cmapi.CertificateRequestRevisionAnnotationKey: "3",
+					cmapi.CertificateNameKey:                      "cert-1",
+				},
+				OwnerReferences: []metav1.OwnerReference{ownerReference},
+			},
+			expectedRevision: CertificateRevision{Namespace: "ns1", Name: "cert-1", UID: "uid-1", Revision: 3},
+			expectedOk:       true,
+		},
+		{
+			name: "name-from-owner",
+			objectMeta: metav1.ObjectMeta{
+				Namespace: "ns1",
+				Annotations: map[string]string{
+					cmapi.CertificateRequestRevisionAnnotationKey: "1",
+				},
+				OwnerReferences: []metav1.OwnerReference{ownerReference},
+			},
+			expectedRevision: CertificateRevision{Namespace: "ns1", Name: "cert-1", UID: "uid-1", Revision: 1},
+			expectedOk:       true,
+		},
+		{
+			name: "no-owner",
+			objectMeta: metav1.ObjectMeta{
+				Namespace: "ns1",
+				Annotations: map[string]string{
+					cmapi.CertificateRequestRevisionAnnotationKey: "2",
+					cmapi.CertificateNameKey:                      "cert-1",
+				},
+			},
+			expectedRevision: CertificateRevision{Namespace: "ns1", Name: "cert-1", Revision: 2},
+			expectedOk:       true,
+		},
+		{
+			name: "no-revision",
+			objectMeta: metav1.ObjectMeta{
+				Namespace: "ns1",
+				Annotations: map[string]string{
+					cmapi.CertificateNameKey: "cert-1",
+				},
+			},
+			expectedOk: false,
+		},
+		{
+			name: "invalid-revision",
+			objectMeta: metav1.ObjectMeta{
+				Namespace: "ns1",
+				Annotations: map[string]string{
+					cmapi.CertificateRequestRevisionAnnotationKey: "first",
+					cmapi.CertificateNameKey:                      "cert-1",
+				},
+			},
+			expectedOk: false,
+		},
+		{
+			name: "no-certificate-name",
+			objectMeta: metav1.ObjectMeta{
+				Namespace: "ns1",
+				Annotations: map[string]string{
+					cmapi.CertificateRequestRevisionAnnotationKey: "1",
+				},
+			},
+			expectedOk: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cr := CertificateRequestObjectFromCertificateRequest(&cmapi.CertificateRequest{ObjectMeta: tc.objectMeta})
+			revision, ok := cr.GetCertificateRevision()
+			assert.Equal(t, tc.expectedOk, ok)
+			assert.Equal(t, tc.expectedRevision, revision)
+		})
+	}
+
+	// Kubernetes CSRs are not created for Certificates.
+	csr := CertificateRequestObjectFromCertificateSigningRequest(&certificatesv1.CertificateSigningRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				cmapi.CertificateRequestRevisionAnnotationKey: "1",
+				cmapi.CertificateNameKey:                      "cert-1",
+			},
+		},
+	})
+	_, ok := csr.GetCertificateRevision()
+	assert.False(t, ok)
+}
+
+func TestCertificateRevisionIdempotencyKey(t *testing.T) {
+	t.Parallel()
+
+	revision := CertificateRevision{Namespace: "ns1", Name: "cert-1", UID: "uid-1", Revision: 3}
+
+	key := revision.IdempotencyKey()
+	assert.Len(t, key, 64)
+	assert.Equal(t, key, revision.IdempotencyKey())
+
+	for _, other := range []CertificateRevision{
+		{Namespace: "ns1", Name: "cert-1", UID: "uid-1", Revision: 4},
+		{Namespace: "ns1", Name: "cert-1", UID: "uid-2", Revision: 3},
+		{Namespace: "ns2", Name: "cert-1", UID: "uid-1", Revision: 3},
+		{Namespace: "ns1", Name: "cert-2", UID: "uid-1", Revision: 3},
+		// The parts are separated, so they can't be shifted.
+		{Namespace: "ns1c", Name: "ert-1", UID: "uid-1", Revision: 3},
+	} {
+		assert.NotEqual(t, key, other.IdempotencyKey(), other)
+	}
+}
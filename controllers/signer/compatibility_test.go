@@ -0,0 +1,102 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signer
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+)
+
+// TestErrorClassificationCompatibility locks the way errors returned by the
+// Check function are classified. Downstream issuers depend on this behavior, so
+// changing an expected value in this test is a breaking change.
+func TestErrorClassificationCompatibility(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name           string
+		err            error
+		expectedReason string
+	}
+
+	tests := []testcase{
+		{
+			name:           "plain",
+			err:            errors.New("[error]"),
+			expectedReason: v1alpha1.IssuerConditionReasonPending,
+		},
+		{
+			name:           "permanent",
+			err:            PermanentError{Err: errors.New("[error]")},
+			expectedReason: v1alpha1.IssuerConditionReasonFailed,
+		},
+		{
+			name:           "wrapped-permanent",
+			err:            fmt.Errorf("wrapped: %w", PermanentError{Err: errors.New("[error]")}),
+			expectedReason: v1alpha1.IssuerConditionReasonFailed,
+		},
+		{
+			name:           "pending",
+			err:            PendingError{Err: errors.New("[error]"), RetryAfter: time.Minute},
+			expectedReason: v1alpha1.IssuerConditionReasonPending,
+		},
+		{
+			name:           "credentials-invalid",
+			err:            CredentialsInvalidError(errors.New("[error]")),
+			expectedReason: v1alpha1.IssuerConditionReasonCredentialsInvalid,
+		},
+		{
+			name:           "endpoint-unreachable",
+			err:            EndpointUnreachableError(errors.New("[error]")),
+			expectedReason: v1alpha1.IssuerConditionReasonEndpointUnreachable,
+		},
+		{
+			name:           "quota-exceeded",
+			err:            QuotaExceededError(errors.New("[error]")),
+			expectedReason: v1alpha1.IssuerConditionReasonQuotaExceeded,
+		},
+		{
+			name:           "configuration-error",
+			err:            ConfigurationError(errors.New("[error]")),
+			expectedReason: v1alpha1.IssuerConditionReasonConfigurationError,
+		},
+		{
+			name:           "retryable-reason-on-permanent-error",
+			err:            PermanentError{Err: CredentialsInvalidError(errors.New("[error]"))},
+			expectedReason: v1alpha1.IssuerConditionReasonFailed,
+		},
+		{
+			name:           "unknown-reason",
+			err:            IssuerReasonError{Err: errors.New("[error]"), Reason: "Unknown"},
+			expectedReason: v1alpha1.IssuerConditionReasonPending,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expectedReason, IssuerConditionReason(tc.err))
+		})
+	}
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signer
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+)
+
+type correlationIDKey struct{}
+
+// WithCorrelationID returns a context that carries the correlation ID of a
+// request. The controllers set this value on the context that is passed to the
+// Sign function when their EnableCorrelationIDs option is set.
+func WithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, correlationID)
+}
+
+// CorrelationIDFromContext returns the correlation ID of the request that is
+// being signed, or an empty string if the context does not carry one. Sign
+// functions can pass the ID to the CA (eg. in a request header), so that the
+// logs of the CA can be correlated with the events of the request.
+func CorrelationIDFromContext(ctx context.Context) string {
+	correlationID, _ := ctx.Value(correlationIDKey{}).(string)
+	return correlationID
+}
+
+// CorrelatedLogger returns the logger with the correlation ID carried by the
+// context added as the "correlationID" value. The logger that the controllers
+// pass to Sign (see log.FromContext) already contains the ID; use this function
+// for loggers that are created separately, eg. the logger of a CA client.
+func CorrelatedLogger(ctx context.Context, logger logr.Logger) logr.Logger {
+	if correlationID := CorrelationIDFromContext(ctx); correlationID != "" {
+		return logger.WithValues("correlationID", correlationID)
+	}
+	return logger
+}
@@ -0,0 +1,27 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package signer contains the Sign and Check function types that issuers
+// implement, and the errors and helpers that they use to report the result to
+// the controllers.
+//
+// # Stability
+//
+// This package is stable: see "API stability" in the README for the
+// deprecation policy. Besides the exported identifiers, the way in which the
+// controllers handle the errors of this package (whether an error is retried
+// and which condition reason it sets) is covered by the policy.
+package signer
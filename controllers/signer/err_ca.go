@@ -0,0 +1,112 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signer
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// caErrorSummaryMaxLength is the maximum length of the part of the CA response
+// body that is included in the message of a CAError.
+const caErrorSummaryMaxLength = 256
+
+// CAError wraps an error that was returned by the CA, together with the details
+// of the response of the CA. The message of the error only contains a truncated
+// and sanitized summary of the response body, so that it can be used in the
+// condition messages and events of issuers and requests without leaking large or
+// sensitive responses into status fields. The controllers log the full details
+// of the response at verbosity level 3.
+//
+// CAError is transparent to the other error types: wrap it in a PermanentError
+// or a PendingError, or wrap one of those in a CAError, to control how the error
+// is handled.
+type CAError struct {
+	Err error
+
+	// StatusCode is the (eg. HTTP) status code of the response of the CA, or 0
+	// if unknown.
+	StatusCode int
+	// Body is the full body of the response of the CA.
+	Body []byte
+	// RequestID is the ID of the request as reported by the CA, which helps the
+	// operators of the CA to find the request in their logs.
+	RequestID string
+}
+
+var _ error = CAError{}
+
+// WrapCAError wraps an error returned by the CA together with the details of
+// the response of the CA. It returns nil if err is nil.
+func WrapCAError(err error, statusCode int, body []byte, requestID string) error {
+	if err == nil {
+		return nil
+	}
+	return CAError{
+		Err:        err,
+		StatusCode: statusCode,
+		Body:       body,
+		RequestID:  requestID,
+	}
+}
+
+func (ve CAError) Unwrap() error {
+	return ve.Err
+}
+
+func (ve CAError) Error() string {
+	var details []string
+	if ve.StatusCode != 0 {
+		details = append(details, fmt.Sprintf("status %d", ve.StatusCode))
+	}
+	if ve.RequestID != "" {
+		details = append(details, fmt.Sprintf("request ID %q", sanitizeCAResponse(ve.RequestID, caErrorSummaryMaxLength)))
+	}
+	if summary := sanitizeCAResponse(string(ve.Body), caErrorSummaryMaxLength); summary != "" {
+		details = append(details, fmt.Sprintf("response %q", summary))
+	}
+
+	if len(details) == 0 {
+		return ve.Err.Error()
+	}
+	return fmt.Sprintf("%s (CA %s)", ve.Err, strings.Join(details, ", "))
+}
+
+// sanitizeCAResponse replaces the control characters and runs of whitespace in
+// the response with a single space, and truncates it to maxLength bytes.
+func sanitizeCAResponse(response string, maxLength int) string {
+	var sb strings.Builder
+	space := false
+	for _, r := range strings.ToValidUTF8(response, string(utf8.RuneError)) {
+		if unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			space = sb.Len() > 0
+			continue
+		}
+
+		if space {
+			sb.WriteByte(' ')
+			space = false
+		}
+		if sb.Len()+utf8.RuneLen(r) > maxLength {
+			return sb.String() + "..."
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
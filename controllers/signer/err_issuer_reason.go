@@ -0,0 +1,95 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signer
+
+import (
+	"errors"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+)
+
+// IssuerReasonError can be returned by the Check function to set one of the
+// well-known reasons (eg. v1alpha1.IssuerConditionReasonCredentialsInvalid) on
+// the Ready condition of the issuer, instead of the generic Pending or Failed
+// reason. This makes the failure modes of issuers machine-readable.
+//
+// The reason is only used if it is well-known (see
+// v1alpha1.IsWellKnownIssuerConditionReason) and if it matches how the error
+// is handled: the ConfigurationError reason is only used for permanent errors
+// and the other reasons only for retryable errors. Use the constructors below
+// to get a matching combination.
+//
+// > This error should be returned only by the Check function.
+type IssuerReasonError struct {
+	Err    error
+	Reason string
+}
+
+var _ error = IssuerReasonError{}
+
+func (ve IssuerReasonError) Unwrap() error {
+	return ve.Err
+}
+
+func (ve IssuerReasonError) Error() string {
+	return ve.Err.Error()
+}
+
+// CredentialsInvalidError returns a retryable error that sets the
+// CredentialsInvalid reason on the Ready condition of the issuer.
+func CredentialsInvalidError(err error) error {
+	return IssuerReasonError{Err: err, Reason: v1alpha1.IssuerConditionReasonCredentialsInvalid}
+}
+
+// EndpointUnreachableError returns a retryable error that sets the
+// EndpointUnreachable reason on the Ready condition of the issuer.
+func EndpointUnreachableError(err error) error {
+	return IssuerReasonError{Err: err, Reason: v1alpha1.IssuerConditionReasonEndpointUnreachable}
+}
+
+// QuotaExceededError returns a retryable error that sets the QuotaExceeded
+// reason on the Ready condition of the issuer.
+func QuotaExceededError(err error) error {
+	return IssuerReasonError{Err: err, Reason: v1alpha1.IssuerConditionReasonQuotaExceeded}
+}
+
+// ConfigurationError returns a permanent error that sets the ConfigurationError
+// reason on the Ready condition of the issuer.
+func ConfigurationError(err error) error {
+	return IssuerReasonError{Err: PermanentError{Err: err}, Reason: v1alpha1.IssuerConditionReasonConfigurationError}
+}
+
+// IssuerConditionReason returns the reason that should be set on the Ready
+// condition of an issuer for the error returned by the Check function: the
+// well-known reason of an IssuerReasonError if it matches whether the error is
+// permanent, otherwise v1alpha1.IssuerConditionReasonFailed for permanent
+// errors and v1alpha1.IssuerConditionReasonPending for other errors.
+func IssuerConditionReason(err error) string {
+	permanent := errors.As(err, &PermanentError{})
+
+	reasonError := IssuerReasonError{}
+	if errors.As(err, &reasonError) &&
+		v1alpha1.IsWellKnownIssuerConditionReason(reasonError.Reason) &&
+		v1alpha1.IsIssuerConditionReasonFailed(reasonError.Reason) == permanent {
+		return reasonError.Reason
+	}
+
+	if permanent {
+		return v1alpha1.IssuerConditionReasonFailed
+	}
+	return v1alpha1.IssuerConditionReasonPending
+}
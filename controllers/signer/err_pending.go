@@ -16,6 +16,8 @@ limitations under the License.
 
 package signer
 
+import "time"
+
 // PendingError should be returned if we are certain that we will converge to a
 // successful result or another type of error in a finite amount of time by
 // just retrying the same operation.
@@ -25,9 +27,21 @@ package signer
 // answer from an external service that is indicating that the request is still
 // being processed.
 //
+// If RetryAfter is set to a positive duration, the request will be requeued
+// after that duration instead of using the default rate-limited backoff. This
+// is useful when the signer knows the polling interval of the CA.
+//
+// If Reason is set, it is used as the Reason of the Ready condition of a
+// CertificateRequest (and of the event recorded on the request) instead of
+// "Pending", so that tooling can tell why the request is pending. Use one of the
+// well-known reasons (eg. v1alpha1.CertificateRequestConditionReasonCircuitOpen)
+// where possible.
+//
 // > This error should be returned only by the Sign function.
 type PendingError struct {
-	Err error
+	Err        error
+	RetryAfter time.Duration
+	Reason     string
 }
 
 var _ error = PendingError{}
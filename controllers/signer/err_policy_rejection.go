@@ -0,0 +1,41 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signer
+
+// PolicyRejectionError is returned if the CA rejected the request for a policy
+// reason (eg. the subject or a SAN is not allowed), so that an identical request
+// will be rejected as well. It is transparent to the other error types: wrap it
+// in a PermanentError to fail the request.
+//
+// Only requests that failed with a PolicyRejectionError are remembered by the
+// FailedRequestCache of the request controllers; requests that failed
+// permanently for another reason (eg. an invalid CSR) are not.
+//
+// > This error should be returned by the Sign function.
+type PolicyRejectionError struct {
+	Err error
+}
+
+var _ error = PolicyRejectionError{}
+
+func (ve PolicyRejectionError) Unwrap() error {
+	return ve.Err
+}
+
+func (ve PolicyRejectionError) Error() string {
+	return ve.Err.Error()
+}
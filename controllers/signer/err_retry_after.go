@@ -0,0 +1,43 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signer
+
+import "time"
+
+// RetryAfterError can be returned by the Check function to indicate that the
+// issuer should be checked again after a specific duration, instead of using
+// the default rate-limited backoff. This is useful when the CA reports when it
+// will be available again (eg. "maintenance until 14:00"), and checking the
+// issuer earlier is pointless.
+//
+// The issuer is marked as not ready (Pending) until it is checked again.
+//
+// > This error should be returned only by the Check function.
+type RetryAfterError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+var _ error = RetryAfterError{}
+
+func (ve RetryAfterError) Unwrap() error {
+	return ve.Err
+}
+
+func (ve RetryAfterError) Error() string {
+	return ve.Err.Error()
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signer
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cert-manager/cert-manager/pkg/util/pki"
+)
+
+// Feature is an optional feature of certificate requests, which not all CAs
+// support.
+type Feature string
+
+const (
+	// FeatureEd25519 is the signing of CSRs with an Ed25519 public key.
+	FeatureEd25519 Feature = "Ed25519"
+	// FeatureIPSANs is the signing of certificates with IP address SANs.
+	FeatureIPSANs Feature = "IPSANs"
+	// FeatureLiteralSubject is the signing of certificates with a subject that
+	// contains attributes other than the common name, serial number, country,
+	// locality, province, street address, organization, organizational unit and
+	// postal code (eg. a subject set using the literalSubject field of a
+	// cert-manager Certificate).
+	FeatureLiteralSubject Feature = "LiteralSubject"
+	// FeatureIsCA is the signing of CA certificates.
+	FeatureIsCA Feature = "IsCA"
+	// FeatureSignResult is the recording of metadata about the signed
+	// certificate using SetSignResult, eg. revocation info and extra
+	// conditions. Issuers whose Sign function only returns the PEM bundle do
+	// not support it; the request controllers do not check it.
+	FeatureSignResult Feature = "SignResult"
+)
+
+// FeatureSet declares the optional features that an issuer supports.
+type FeatureSet struct {
+	// Features are the optional features that are supported.
+	Features []Feature
+	// MaxDuration is the maximum duration of the certificates that can be
+	// issued, zero means that the duration is not limited.
+	MaxDuration time.Duration
+}
+
+// Supports returns true if the feature is part of the feature set.
+func (f FeatureSet) Supports(feature Feature) bool {
+	return slices.Contains(f.Features, feature)
+}
+
+// FeatureSetProvider can be implemented by issuer types (the v1alpha1.Issuer
+// implementations) to declare the features that an issuer supports. The request
+// controllers fail requests that use unsupported features permanently, without
+// calling Sign, and conformance suites can use it to skip the tests of the
+// features that an issuer does not support.
+type FeatureSetProvider interface {
+	FeatureSet() FeatureSet
+}
+
+// standardSubjectAttributes are the subject attributes that are represented by
+// the fields of pkix.Name.
+var standardSubjectAttributes = []asn1.ObjectIdentifier{
+	{2, 5, 4, 3},  // commonName
+	{2, 5, 4, 5},  // serialNumber
+	{2, 5, 4, 6},  // countryName
+	{2, 5, 4, 7},  // localityName
+	{2, 5, 4, 8},  // stateOrProvinceName
+	{2, 5, 4, 9},  // streetAddress
+	{2, 5, 4, 10}, // organizationName
+	{2, 5, 4, 11}, // organizationalUnitName
+	{2, 5, 4, 17}, // postalCode
+}
+
+// CheckFeatures returns a PermanentError that lists the features used by the
+// request that are not part of the feature set, or nil if the request only
+// uses supported features. It can be used by Sign implementations of issuer
+// types that do not implement FeatureSetProvider.
+func CheckFeatures(cr CertificateRequestObject, features FeatureSet) error {
+	template, duration, csrPEM, err := cr.GetRequest()
+	if err != nil {
+		return PermanentError{Err: fmt.Errorf("failed to parse the request: %w", err)}
+	}
+
+	csr, err := pki.DecodeX509CertificateRequestBytes(csrPEM)
+	if err != nil {
+		return PermanentError{Err: fmt.Errorf("failed to parse the CSR: %w", err)}
+	}
+
+	var unsupported []string
+	if csr.PublicKeyAlgorithm == x509.Ed25519 && !features.Supports(FeatureEd25519) {
+		unsupported = append(unsupported, "Ed25519 keys are not supported, use an RSA or ECDSA private key")
+	}
+
+	if len(csr.IPAddresses) > 0 && !features.Supports(FeatureIPSANs) {
+		unsupported = append(unsupported, "IP address SANs are not supported, remove the IP addresses from the request")
+	}
+
+	if !features.Supports(FeatureLiteralSubject) {
+		for _, name := range csr.Subject.Names {
+			if !slices.ContainsFunc(standardSubjectAttributes, name.Type.Equal) {
+				unsupported = append(unsupported, fmt.Sprintf("the subject attribute %s is not supported, use the standard subject fields instead of a literal subject", name.Type))
+				break
+			}
+		}
+	}
+
+	if template.IsCA && !features.Supports(FeatureIsCA) {
+		unsupported = append(unsupported, "CA certificates are not supported, remove isCA from the request")
+	}
+
+	if features.MaxDuration > 0 && duration > features.MaxDuration {
+		unsupported = append(unsupported, fmt.Sprintf("the requested duration %s exceeds the maximum duration %s", duration, features.MaxDuration))
+	}
+
+	if len(unsupported) == 0 {
+		return nil
+	}
+
+	return PermanentError{
+		Err: fmt.Errorf("the request uses features that the issuer does not support: %s", strings.Join(unsupported, "; ")),
+	}
+}
@@ -43,6 +43,28 @@ type PEMBundle pki.PEMBundle
 type Sign func(ctx context.Context, cr CertificateRequestObject, issuerObject v1alpha1.Issuer) (PEMBundle, error)
 type Check func(ctx context.Context, issuerObject v1alpha1.Issuer) error
 
+// NamedCheck is a Check function with a name. An issuer can be checked using
+// multiple named checks (eg. "CredentialsValid", "EndpointReachable" and
+// "IntermediateUnexpired"), the result of each check is recorded on the issuer
+// as a separate condition with the name as its type.
+type NamedCheck struct {
+	Name  string
+	Check Check
+}
+
+// CheckAggregation determines how the results of multiple named checks are
+// aggregated into the Ready condition of the issuer.
+type CheckAggregation string
+
+const (
+	// CheckAggregationAnd requires all checks to succeed. The issuer fails
+	// permanently if one of the failed checks failed permanently.
+	CheckAggregationAnd CheckAggregation = ""
+	// CheckAggregationOr requires at least one check to succeed. The issuer
+	// fails permanently if all checks failed permanently.
+	CheckAggregationOr CheckAggregation = "Or"
+)
+
 // CertificateRequestObject is an interface that represents either a
 // cert-manager CertificateRequest or a Kubernetes CertificateSigningRequest
 // resource. This interface hides the spec fields of the underlying resource
@@ -61,6 +83,11 @@ type CertificateRequestObject interface {
 	GetRequest() (template *x509.Certificate, duration time.Duration, csr []byte, err error)
 
 	GetConditions() []cmapi.CertificateRequestCondition
+
+	// GetCertificateRevision returns the revision of the cert-manager Certificate
+	// that the request was created for, or false if the request was not created
+	// for a Certificate (eg. for Kubernetes CSRs).
+	GetCertificateRevision() (CertificateRevision, bool)
 }
 
 // IgnoreIssuer is an optional function that can prevent the issuer controllers from
@@ -74,6 +101,82 @@ type IgnoreIssuer func(
 	issuerObject v1alpha1.Issuer,
 ) (bool, error)
 
+// IgnoredCertificateRequestReason is an optional function that is called when
+// IgnoreCertificateRequest returned true for a request. The returned reason and
+// message are recorded on the request as an "Ignored" condition and as an event,
+// so users can tell the difference between a request that was not picked up and
+// a request that was deliberately ignored.
+type IgnoredCertificateRequestReason func(
+	ctx context.Context,
+	cr CertificateRequestObject,
+	issuerGvk schema.GroupVersionKind,
+	issuerName types.NamespacedName,
+) (reason string, message string)
+
+// IssuerSecretRefs is an optional function that returns the Secrets that an issuer
+// resource depends on (eg. the Secret containing the credentials for the CA).
+// When set, the issuer controllers re-run the Check function for the issuer
+// whenever the contents of one of these Secrets change. Secrets without a
+// namespace are looked up in the namespace of the issuer or, for cluster-scoped
+// issuers, in the ClusterResourceNamespace of the controllers.
+type IssuerSecretRefs func(
+	issuerObject v1alpha1.Issuer,
+) []types.NamespacedName
+
+// SupportedKeyAlgorithms is an optional function that returns the public key
+// algorithms (eg. x509.RSA, x509.ECDSA and x509.Ed25519) of the CSRs that the CA
+// of the issuer can sign. When set, requests with a CSR that uses another public
+// key algorithm are failed permanently with a clear message, without calling
+// the Sign function. Returning an empty list allows all algorithms.
+type SupportedKeyAlgorithms func(
+	issuerObject v1alpha1.Issuer,
+) []x509.PublicKeyAlgorithm
+
+// ErrorClassifier is an optional function that classifies the errors returned
+// by the Sign and Check functions that are not already a PermanentError,
+// PendingError, IssuerError or SetCertificateRequestConditionError. It can
+// return the error wrapped in one of these error types (eg. to map a HTTP 400
+// response of the CA to a PermanentError and a HTTP 503 response to a
+// PendingError), or return the error as-is to use the default retry handling.
+type ErrorClassifier func(err error) error
+
+// ResolveSignerName is an optional function that resolves the signer name of a
+// Kubernetes CSR that matched one of the signer name patterns of the Kubernetes
+// CSR controller to the cluster issuer that signs the CSR. It returns the issuer
+// type identifier (see GetIssuerTypeIdentifier) and the name of the cluster
+// issuer. This allows a single controller to serve a family of signer names
+// (eg. one signer name per tenant) without one issuer resource per signer name.
+type ResolveSignerName func(
+	signerName string,
+) (issuerTypeIdentifier string, issuerName string, err error)
+
+// PriorityClass is the priority class of a request. Requests with a higher
+// priority class are reconciled before requests with a lower priority class.
+type PriorityClass int
+
+const (
+	PriorityClassLow    PriorityClass = -1
+	PriorityClassNormal PriorityClass = 0
+	PriorityClassHigh   PriorityClass = 1
+)
+
+// RequestPriority is an optional function that returns the priority class of a
+// request (eg. based on an annotation, or on the expiry of the certificate that
+// is being renewed). When set, the CertificateRequest and Kubernetes CSR controllers
+// use a work queue that is drained in order of priority class.
+type RequestPriority func(
+	cr CertificateRequestObject,
+) PriorityClass
+
+// RequestTenant is an optional function that returns the tenant of a request
+// (eg. its namespace, or the value of a label). When set, the CertificateRequest
+// and Kubernetes CSR controllers use a work queue that hands out the requests of
+// the tenants in a round-robin fashion, so a tenant that creates many requests
+// cannot starve the requests of other tenants.
+type RequestTenant func(
+	cr CertificateRequestObject,
+) string
+
 // IgnoreCertificateRequest is an optional function that can prevent the CertificateRequest
 // and Kubernetes CSR controllers from reconciling a CertificateRequest resource. By default,
 // the controllers will reconcile all CertificateRequest resources that match the issuerRef type.
@@ -52,6 +52,10 @@ func (c *certificateRequestImpl) GetConditions() []cmapi.CertificateRequestCondi
 	return c.Status.Conditions
 }
 
+func (c *certificateRequestImpl) GetCertificateRevision() (CertificateRevision, bool) {
+	return certificateRevisionFromObject(c.CertificateRequest)
+}
+
 type certificateSigningRequestImpl struct {
 	*certificatesv1.CertificateSigningRequest
 }
@@ -72,7 +76,6 @@ func (c *certificateSigningRequestImpl) GetRequest() (*x509.Certificate, time.Du
 	if err != nil {
 		return nil, 0, nil, err
 	}
-
 	return template, duration, c.Spec.Request, nil
 }
 
@@ -90,3 +93,7 @@ func (c *certificateSigningRequestImpl) GetConditions() []cmapi.CertificateReque
 	}
 	return conditions
 }
+
+func (c *certificateSigningRequestImpl) GetCertificateRevision() (CertificateRevision, bool) {
+	return CertificateRevision{}, false
+}
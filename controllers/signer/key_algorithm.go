@@ -0,0 +1,46 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signer
+
+import (
+	"crypto/x509"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// CheckKeyAlgorithm returns a PermanentError if the public key algorithm of the
+// CSR is not one of the supported algorithms. An empty list of supported
+// algorithms allows all algorithms. It can be used by Sign implementations that
+// don't set the SupportedKeyAlgorithms option.
+func CheckKeyAlgorithm(csr *x509.CertificateRequest, supported []x509.PublicKeyAlgorithm) error {
+	if len(supported) == 0 || slices.Contains(supported, csr.PublicKeyAlgorithm) {
+		return nil
+	}
+
+	supportedNames := make([]string, 0, len(supported))
+	for _, algorithm := range supported {
+		supportedNames = append(supportedNames, algorithm.String())
+	}
+
+	return PermanentError{
+		Err: fmt.Errorf(
+			"the CSR uses the %s public key algorithm, but the issuer only supports: %s",
+			csr.PublicKeyAlgorithm, strings.Join(supportedNames, ", "),
+		),
+	}
+}
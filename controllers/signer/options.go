@@ -0,0 +1,113 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signer
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Options are the per-request options that were set on a request using
+// annotations with the v1alpha1.RequestOptionAnnotationPrefix prefix, keyed by
+// the option name without the prefix. Eg. the annotation
+// "issuer-lib.cert-manager.io/option.profile: tls-server" results in the option
+// "profile" with value "tls-server".
+type Options map[string]string
+
+// Get returns the value of the option and whether the option was set.
+func (o Options) Get(name string) (string, bool) {
+	value, ok := o[name]
+	return value, ok
+}
+
+// String returns the value of the option, or the default value if the option
+// was not set.
+func (o Options) String(name string, defaultValue string) string {
+	if value, ok := o[name]; ok {
+		return value
+	}
+	return defaultValue
+}
+
+// Bool returns the value of the option parsed as a bool, or the default value if
+// the option was not set. An invalid value results in a PermanentError, since the
+// request has to be changed to fix it.
+func (o Options) Bool(name string, defaultValue bool) (bool, error) {
+	value, ok := o[name]
+	if !ok {
+		return defaultValue, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return defaultValue, invalidOptionError(name, value, err)
+	}
+	return parsed, nil
+}
+
+// Int returns the value of the option parsed as an int, or the default value if
+// the option was not set. An invalid value results in a PermanentError.
+func (o Options) Int(name string, defaultValue int) (int, error) {
+	value, ok := o[name]
+	if !ok {
+		return defaultValue, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return defaultValue, invalidOptionError(name, value, err)
+	}
+	return parsed, nil
+}
+
+// Duration returns the value of the option parsed as a duration (eg. "90m"), or
+// the default value if the option was not set. An invalid value results in a
+// PermanentError.
+func (o Options) Duration(name string, defaultValue time.Duration) (time.Duration, error) {
+	value, ok := o[name]
+	if !ok {
+		return defaultValue, nil
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return defaultValue, invalidOptionError(name, value, err)
+	}
+	return parsed, nil
+}
+
+func invalidOptionError(name string, value string, err error) error {
+	return PermanentError{Err: fmt.Errorf("invalid value %q for option %q: %w", value, name, err)}
+}
+
+type optionsKey struct{}
+
+// WithOptions returns a context that carries the options of a request. The
+// request controllers set this value on the context that is passed to the Sign
+// function when their RequestOptionsPolicy option is set.
+func WithOptions(ctx context.Context, options Options) context.Context {
+	return context.WithValue(ctx, optionsKey{}, options)
+}
+
+// OptionsFromContext returns the options of the request, or nil if the context
+// does not carry options. Reading a nil Options map is safe.
+func OptionsFromContext(ctx context.Context) Options {
+	options, _ := ctx.Value(optionsKey{}).(Options)
+	return options
+}
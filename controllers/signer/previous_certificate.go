@@ -0,0 +1,42 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signer
+
+import (
+	"context"
+	"crypto/x509"
+)
+
+type previousCertificateKey struct{}
+
+// WithPreviousCertificate returns a context that carries the certificate that
+// was previously issued for the Certificate of a renewal request. The request
+// controllers set this value on the context that is passed to the Sign function
+// when their PreviousCertificatePolicy option is set.
+func WithPreviousCertificate(ctx context.Context, certificate *x509.Certificate) context.Context {
+	return context.WithValue(ctx, previousCertificateKey{}, certificate)
+}
+
+// PreviousCertificateFromContext returns the leaf certificate that was
+// previously issued for the Certificate of the request that is being signed,
+// or nil if the request is not a renewal or the certificate could not be
+// found. Sign functions of CAs that have a "renew" endpoint can use it to look
+// up the original order (eg. by its serial number) instead of enrolling again.
+func PreviousCertificateFromContext(ctx context.Context) *x509.Certificate {
+	certificate, _ := ctx.Value(previousCertificateKey{}).(*x509.Certificate)
+	return certificate
+}
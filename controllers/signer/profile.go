@@ -0,0 +1,51 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signer
+
+import (
+	"crypto/x509"
+	"time"
+)
+
+// DefaultProfile contains the defaults that an issuer applies to the certificates
+// of requests that leave the corresponding fields empty. The values that are set
+// on a request always take precedence over the profile, and the profile takes
+// precedence over the defaults of the request type (eg. the 90 day default
+// duration of cert-manager).
+type DefaultProfile struct {
+	// Duration is the duration of the certificates of requests that don't
+	// request a duration. Zero means that the default duration of the request
+	// type is used.
+	Duration time.Duration
+	// KeyUsage and ExtKeyUsage are the usages of the certificates of requests
+	// that don't request any usages. If both are empty, the default usages of
+	// the request type are used.
+	KeyUsage    x509.KeyUsage
+	ExtKeyUsage []x509.ExtKeyUsage
+	// CommonNameAsDNSName adds the common name of requests without any SANs
+	// as a DNS name SAN.
+	CommonNameAsDNSName bool
+}
+
+// DefaultProfileProvider can be implemented by issuer types (the v1alpha1.Issuer
+// implementations) to declare the default profile of an issuer. The request
+// controllers apply the defaults to the certificate template that is returned
+// by the GetRequest method of the request that is passed to the Sign function,
+// so the Sign function does not have to implement the defaulting itself.
+type DefaultProfileProvider interface {
+	DefaultProfile() DefaultProfile
+}
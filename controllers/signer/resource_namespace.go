@@ -0,0 +1,62 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signer
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+)
+
+type clusterResourceNamespaceKey struct{}
+
+// WithClusterResourceNamespace returns a context that carries the namespace in
+// which the resources (eg. Secrets) referenced by cluster-scoped issuers are
+// found. The controllers set this value on the context that is passed to the
+// Sign and Check functions when their ClusterResourceNamespace option is set.
+func WithClusterResourceNamespace(ctx context.Context, namespace string) context.Context {
+	return context.WithValue(ctx, clusterResourceNamespaceKey{}, namespace)
+}
+
+// ClusterResourceNamespaceFromContext returns the namespace in which the resources
+// referenced by cluster-scoped issuers are found, or an empty string if the
+// context does not carry this namespace.
+func ClusterResourceNamespaceFromContext(ctx context.Context) string {
+	namespace, _ := ctx.Value(clusterResourceNamespaceKey{}).(string)
+	return namespace
+}
+
+// ResourceNamespace returns the namespace in which the resources referenced by
+// the issuer are found: the namespace of the issuer for namespaced issuers, and
+// the cluster resource namespace carried by the context for cluster-scoped issuers.
+func ResourceNamespace(ctx context.Context, issuerObject v1alpha1.Issuer) string {
+	if namespace := issuerObject.GetNamespace(); namespace != "" {
+		return namespace
+	}
+	return ClusterResourceNamespaceFromContext(ctx)
+}
+
+// ResourceName returns the namespaced name of a resource referenced by the issuer,
+// eg. the Secret containing the credentials for the CA (see ResourceNamespace).
+func ResourceName(ctx context.Context, issuerObject v1alpha1.Issuer, name string) types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: ResourceNamespace(ctx, issuerObject),
+		Name:      name,
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signer
+
+import (
+	"context"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+)
+
+// SignResult contains metadata about the certificate returned by the Sign
+// function, which the controller records on the request resource.
+type SignResult struct {
+	// OCSPServer is the URL of the OCSP responder for the certificate.
+	OCSPServer string
+	// CRLDistributionPoint is the URL of the CRL that covers the certificate.
+	CRLDistributionPoint string
+	// ExtraConditions are conditions that the controller sets on the request
+	// resource in addition to the Ready condition.
+	ExtraConditions []ExtraCondition
+}
+
+// ExtraCondition is a condition that the controller sets on the request
+// resource when the certificate is issued, eg. to surface the policy that the
+// CA applied. The controller ignores conditions of the types that it manages
+// itself (Ready, Approved, Denied, InvalidRequest and Failed).
+type ExtraCondition struct {
+	Type    cmapi.CertificateRequestConditionType
+	Status  cmmeta.ConditionStatus
+	Reason  string
+	Message string
+}
+
+// SignResultOption sets metadata on the SignResult.
+type SignResultOption func(*SignResult)
+
+// WithRevocationInfo sets the OCSP responder URL and CRL distribution point URL
+// of the signed certificate. Empty URLs are ignored.
+func WithRevocationInfo(ocspURL string, crlURL string) SignResultOption {
+	return func(result *SignResult) {
+		if ocspURL != "" {
+			result.OCSPServer = ocspURL
+		}
+		if crlURL != "" {
+			result.CRLDistributionPoint = crlURL
+		}
+	}
+}
+
+// WithExtraConditions adds conditions that the controller sets on the request
+// resource. A condition replaces an earlier condition of the same type.
+func WithExtraConditions(conditions ...ExtraCondition) SignResultOption {
+	return func(result *SignResult) {
+		for _, condition := range conditions {
+			replaced := false
+			for i := range result.ExtraConditions {
+				if result.ExtraConditions[i].Type == condition.Type {
+					result.ExtraConditions[i] = condition
+					replaced = true
+				}
+			}
+			if !replaced {
+				result.ExtraConditions = append(result.ExtraConditions, condition)
+			}
+		}
+	}
+}
+
+type signResultContextKey struct{}
+
+// NewSignResultContext returns a context for a call of the Sign function and
+// the SignResult that SetSignResult updates when it is called with that context.
+func NewSignResultContext(ctx context.Context) (context.Context, *SignResult) {
+	result := &SignResult{}
+	return context.WithValue(ctx, signResultContextKey{}, result), result
+}
+
+// SetSignResult can be called by the Sign function to record metadata about the
+// signed certificate, eg. SetSignResult(ctx, WithRevocationInfo(ocspURL, crlURL)).
+// The metadata is only recorded if Sign returns successfully. SetSignResult is a
+// no-op if the context was not created using NewSignResultContext.
+func SetSignResult(ctx context.Context, opts ...SignResultOption) {
+	result, ok := ctx.Value(signResultContextKey{}).(*SignResult)
+	if !ok {
+		return
+	}
+
+	for _, opt := range opts {
+		opt(result)
+	}
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	v1alpha1 "github.com/cert-manager/issuer-lib/api/v1alpha1"
+)
+
+// matchesSignerNamePattern returns true if the signer name is equal to one of the
+// patterns, or starts with the prefix of one of the patterns that end with "*".
+func matchesSignerNamePattern(patterns []string, signerName string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(signerName, prefix) {
+				return true
+			}
+			continue
+		}
+
+		if pattern == signerName {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveSignerName returns the issuer that the ResolveSignerName function
+// resolved the signer name to.
+func (r *CertificateSigningRequestReconciler) resolveSignerName(signerName string) (v1alpha1.Issuer, types.NamespacedName, error) {
+	issuerTypeIdentifier, issuerName, err := r.ResolveSignerName(signerName)
+	if err != nil {
+		return nil, types.NamespacedName{}, fmt.Errorf("failed to resolve signer name %q: %w", signerName, err)
+	}
+
+	for _, issuerType := range r.AllIssuerTypes() {
+		if issuerTypeIdentifier != issuerType.Type.GetIssuerTypeIdentifier() {
+			continue
+		}
+
+		if issuerType.IsNamespaced {
+			return nil, types.NamespacedName{}, fmt.Errorf("signer name %q resolved to %q, which is a namespaced issuer type, namespaced issuers are not supported for Kubernetes CSRs", signerName, issuerTypeIdentifier)
+		}
+
+		return issuerType.Type.DeepCopyObject().(v1alpha1.Issuer), types.NamespacedName{Name: issuerName}, nil
+	}
+
+	return nil, types.NamespacedName{}, fmt.Errorf("signer name %q resolved to unknown issuer type %q", signerName, issuerTypeIdentifier)
+}
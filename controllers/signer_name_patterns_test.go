@@ -0,0 +1,144 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/internal/kubeutil"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+	"github.com/cert-manager/issuer-lib/internal/tests/errormatch"
+)
+
+func TestMatchesSignerNamePattern(t *testing.T) {
+	t.Parallel()
+
+	patterns := []string{"tenants.example.com/*", "example.com/team-*", "exact.example.com/signer"}
+
+	for signerName, expected := range map[string]bool{
+		"tenants.example.com/team-a": true,
+		"tenants.example.com/":       true,
+		"example.com/team-a":         true,
+		"example.com/other":          false,
+		"exact.example.com/signer":   true,
+		"exact.example.com/signer-2": false,
+		"other.example.com/team-a":   false,
+	} {
+		assert.Equal(t, expected, matchesSignerNamePattern(patterns, signerName), signerName)
+	}
+
+	assert.False(t, matchesSignerNamePattern(nil, "tenants.example.com/team-a"))
+}
+
+func TestCertificateSigningRequestMatchIssuerTypeSignerNamePatterns(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name       string
+		signerName string
+
+		expectedIssuerType v1alpha1.Issuer
+		expectedIssuerName types.NamespacedName
+		expectedError      *errormatch.Matcher
+	}
+
+	testcases := []testcase{
+		{
+			name:               "resolved",
+			signerName:         "tenants.example.com/team-a",
+			expectedIssuerType: &api.TestClusterIssuer{},
+			expectedIssuerName: types.NamespacedName{Name: "issuer-team-a"},
+			expectedError:      errormatch.NoError(),
+		},
+		{
+			name:          "resolve error",
+			signerName:    "tenants.example.com/unknown",
+			expectedError: errormatch.ErrorContains("failed to resolve signer name \"tenants.example.com/unknown\": [unknown tenant]"),
+		},
+		{
+			name:          "namespaced issuer type",
+			signerName:    "tenants.example.com/namespaced",
+			expectedError: errormatch.ErrorContains("signer name \"tenants.example.com/namespaced\" resolved to \"testissuers.testing.cert-manager.io\", which is a namespaced issuer type"),
+		},
+		{
+			name:          "unknown issuer type",
+			signerName:    "tenants.example.com/foreign",
+			expectedError: errormatch.ErrorContains("signer name \"tenants.example.com/foreign\" resolved to unknown issuer type \"foreign.example.com\""),
+		},
+		{
+			name:               "default format still works",
+			signerName:         "testclusterissuers.testing.cert-manager.io/name",
+			expectedIssuerType: &api.TestClusterIssuer{},
+			expectedIssuerName: types.NamespacedName{Name: "name"},
+			expectedError:      errormatch.NoError(),
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, api.AddToScheme(scheme))
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			crr := &CertificateSigningRequestReconciler{
+				RequestController: RequestController{
+					IssuerTypes:        []v1alpha1.Issuer{&api.TestIssuer{}},
+					ClusterIssuerTypes: []v1alpha1.Issuer{&api.TestClusterIssuer{}},
+				},
+				SignerNamePatterns: []string{"tenants.example.com/*"},
+				ResolveSignerName: func(signerName string) (string, string, error) {
+					tenant := strings.TrimPrefix(signerName, "tenants.example.com/")
+					switch tenant {
+					case "unknown":
+						return "", "", errors.New("[unknown tenant]")
+					case "namespaced":
+						return "testissuers.testing.cert-manager.io", "issuer-" + tenant, nil
+					case "foreign":
+						return "foreign.example.com", "issuer-" + tenant, nil
+					default:
+						return "testclusterissuers.testing.cert-manager.io", "issuer-" + tenant, nil
+					}
+				},
+			}
+			require.NoError(t, crr.setAllIssuerTypesWithGroupVersionKind(scheme))
+
+			issuerType, issuerName, err := crr.matchIssuerType(&certificatesv1.CertificateSigningRequest{
+				Spec: certificatesv1.CertificateSigningRequestSpec{
+					SignerName: tc.signerName,
+				},
+			})
+
+			if tc.expectedIssuerType != nil {
+				require.NoError(t, kubeutil.SetGroupVersionKind(scheme, tc.expectedIssuerType))
+			}
+
+			assert.Equal(t, tc.expectedIssuerType, issuerType)
+			assert.Equal(t, tc.expectedIssuerName, issuerName)
+			(*tc.expectedError)(t, err)
+		})
+	}
+}
@@ -0,0 +1,333 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// StatusPatchCoalescingPolicy delays the status patches of requests that don't
+// finish the request (eg. Pending conditions) for Window, and merges the patches
+// of the same request that are applied within the window into a single
+// server-side apply patch. This reduces the number of writes to the API server
+// when many requests are retried at the same time.
+//
+// The patches are merged field by field and the last patch wins: nested objects
+// are merged recursively and conditions are merged by type. Status patches that
+// finish a request (Issued, Failed or Denied) are applied immediately, together
+// with the pending patch of the request, so that a request is never signed twice
+// because its result was not written yet. Status patches of reconciles that
+// requeue the request within the window (or return an error) are also applied
+// immediately, so that a requeued reconcile never reads a status without the
+// pending patch. A request whose delayed patch is rejected is reconciled again
+// with backoff.
+type StatusPatchCoalescingPolicy struct {
+	// Window is the duration that a status patch is delayed for, defaults to 1
+	// second.
+	Window time.Duration
+}
+
+// isFinalStatusPatch returns true if the status patch finishes the request, ie.
+// if the request is Issued, Failed or Denied.
+func isFinalStatusPatch(statusPatch RequestPatch) bool {
+	if patch, ok := statusPatch.(timeInStatePatch); ok && patch.timeInState() != nil {
+		return true
+	}
+	if patch, ok := statusPatch.(deniedPatch); ok && patch.patchDenied() {
+		return true
+	}
+	return false
+}
+
+// statusPatchKey identifies the pending status patch of a request. Patches with
+// different field owners are never merged, since they own different fields.
+type statusPatchKey struct {
+	namespacedName types.NamespacedName
+	fieldOwner     string
+}
+
+type pendingStatusPatch struct {
+	obj   client.Object
+	data  map[string]interface{}
+	timer clock.Timer
+}
+
+// applyStatusPatchFunc applies a status patch, the coalescer only uses the
+// returned error to decide whether the request has to be reconciled again.
+type applyStatusPatchFunc func(ctx context.Context, obj client.Object, patch client.Patch, fieldOwner string) error
+
+// statusPatchCoalescer delays and merges the status patches of a controller
+// according to a StatusPatchCoalescingPolicy. A nil statusPatchCoalescer applies
+// all patches immediately.
+type statusPatchCoalescer struct {
+	name   string
+	window time.Duration
+	clock  clock.WithDelayedExecution
+	apply  applyStatusPatchFunc
+
+	mu sync.Mutex
+	// ctx is the context of the manager, it is set when the coalescer is started.
+	ctx context.Context
+	// queue is the work queue of the controller, it is set when the controller
+	// creates its queue.
+	queue   workqueue.TypedRateLimitingInterface[reconcile.Request]
+	pending map[statusPatchKey]*pendingStatusPatch
+}
+
+var _ manager.Runnable = &statusPatchCoalescer{}
+
+// newStatusPatchCoalescer returns a statusPatchCoalescer for the controller of
+// the given kind, which applies the merged patches using the apply function.
+func newStatusPatchCoalescer(kind string, policy StatusPatchCoalescingPolicy, clock clock.WithDelayedExecution, apply applyStatusPatchFunc) *statusPatchCoalescer {
+	window := policy.Window
+	if window == 0 {
+		window = time.Second
+	}
+
+	return &statusPatchCoalescer{
+		name:    strings.ToLower(kind),
+		window:  window,
+		clock:   clock,
+		apply:   apply,
+		ctx:     context.Background(),
+		pending: map[statusPatchKey]*pendingStatusPatch{},
+	}
+}
+
+// wrapNewQueue wraps the function that creates the work queue of the controller,
+// so that requests whose delayed patch was rejected can be added to the queue
+// again. A nil newQueue creates the default queue of controller-runtime.
+func (c *statusPatchCoalescer) wrapNewQueue(
+	newQueue func(string, workqueue.TypedRateLimiter[reconcile.Request]) workqueue.TypedRateLimitingInterface[reconcile.Request],
+) func(string, workqueue.TypedRateLimiter[reconcile.Request]) workqueue.TypedRateLimitingInterface[reconcile.Request] {
+	return func(controllerName string, rateLimiter workqueue.TypedRateLimiter[reconcile.Request]) workqueue.TypedRateLimitingInterface[reconcile.Request] {
+		var queue workqueue.TypedRateLimitingInterface[reconcile.Request]
+		if newQueue != nil {
+			queue = newQueue(controllerName, rateLimiter)
+		} else {
+			queue = workqueue.NewTypedRateLimitingQueueWithConfig(rateLimiter, workqueue.TypedRateLimitingQueueConfig[reconcile.Request]{
+				Name: controllerName,
+			})
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.queue = queue
+		return queue
+	}
+}
+
+// requeuesBeforeFlush returns true if the result of a reconcile requeues the
+// request before a delayed patch would be applied, ie. if the reconcile returned
+// an error, or requeues the request with backoff or within the window.
+func (c *statusPatchCoalescer) requeuesBeforeFlush(result reconcile.Result, err error) bool {
+	if c == nil {
+		return false
+	}
+
+	return err != nil || result.Requeue || (result.RequeueAfter > 0 && result.RequeueAfter <= c.window)
+}
+
+// coalesce merges the patch with the pending patch of the object. If the patch
+// is not immediate, it is delayed and coalesce returns true; the merged patch is
+// applied once the window has passed. Otherwise, the pending patch is cancelled
+// and the merged patch is returned, to be applied immediately by the caller.
+func (c *statusPatchCoalescer) coalesce(obj client.Object, patch client.Patch, fieldOwner string, immediate bool) (client.Patch, bool, error) {
+	if c == nil {
+		return patch, false, nil
+	}
+
+	raw, err := patch.Data(obj)
+	if err != nil {
+		return nil, false, err
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, false, err
+	}
+
+	key := statusPatchKey{
+		namespacedName: client.ObjectKeyFromObject(obj),
+		fieldOwner:     fieldOwner,
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.pending[key]
+	if ok {
+		data = mergeApplyPatches(entry.data, data)
+		statusPatchesCoalesced.WithLabelValues(c.name).Inc()
+	}
+
+	if immediate {
+		if ok {
+			entry.timer.Stop()
+			delete(c.pending, key)
+
+			if raw, err = json.Marshal(data); err != nil {
+				return nil, false, err
+			}
+		}
+		return client.RawPatch(types.ApplyPatchType, raw), false, nil
+	}
+
+	if ok {
+		entry.obj = obj
+		entry.data = data
+		return nil, true, nil
+	}
+
+	c.pending[key] = &pendingStatusPatch{
+		obj:  obj,
+		data: data,
+		timer: c.clock.AfterFunc(c.window, func() {
+			c.flush(key)
+		}),
+	}
+	return nil, true, nil
+}
+
+// flush applies the pending patch of the key, if it was not cancelled.
+func (c *statusPatchCoalescer) flush(key statusPatchKey) {
+	c.mu.Lock()
+	entry, ok := c.pending[key]
+	delete(c.pending, key)
+	ctx, queue := c.ctx, c.queue
+	c.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	raw, err := json.Marshal(entry.data)
+	if err == nil {
+		err = c.apply(ctx, entry.obj, client.RawPatch(types.ApplyPatchType, raw), key.fieldOwner)
+	}
+	if err != nil {
+		log.FromContext(ctx).V(1).Info("Failed to apply the delayed status patch", "name", key.namespacedName, "error", err.Error())
+		if queue != nil {
+			queue.AddRateLimited(reconcile.Request{NamespacedName: key.namespacedName})
+		}
+	}
+}
+
+// Start records the context of the manager, and applies the pending patches
+// when the context is cancelled.
+func (c *statusPatchCoalescer) Start(ctx context.Context) error {
+	c.mu.Lock()
+	c.ctx = ctx
+	c.mu.Unlock()
+
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+
+	c.mu.Lock()
+	c.ctx = shutdownCtx
+	keys := make([]statusPatchKey, 0, len(c.pending))
+	for key, entry := range c.pending {
+		entry.timer.Stop()
+		keys = append(keys, key)
+	}
+	c.mu.Unlock()
+
+	for _, key := range keys {
+		c.flush(key)
+	}
+	return nil
+}
+
+// mergeApplyPatches merges the patch into the base patch and returns the result,
+// without modifying either of them. Fields of the patch replace the fields of
+// the base, nested objects are merged recursively and lists of objects with a
+// "type" field (eg. conditions) are merged by type.
+func mergeApplyPatches(base, patch map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(patch))
+	for field, value := range base {
+		merged[field] = value
+	}
+
+	for field, value := range patch {
+		switch value := value.(type) {
+		case map[string]interface{}:
+			if baseValue, ok := merged[field].(map[string]interface{}); ok {
+				merged[field] = mergeApplyPatches(baseValue, value)
+				continue
+			}
+		case []interface{}:
+			if baseValue, ok := merged[field].([]interface{}); ok && isTypedList(baseValue) && isTypedList(value) {
+				merged[field] = mergeTypedLists(baseValue, value)
+				continue
+			}
+		}
+		merged[field] = value
+	}
+	return merged
+}
+
+// isTypedList returns true if all items of the list are objects with a "type"
+// field.
+func isTypedList(list []interface{}) bool {
+	for _, item := range list {
+		object, ok := item.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if _, ok := object["type"].(string); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeTypedLists merges the items of the patch into the items of the base with
+// the same type, and appends the items with new types.
+func mergeTypedLists(base, patch []interface{}) []interface{} {
+	merged := make([]interface{}, len(base), len(base)+len(patch))
+	copy(merged, base)
+
+	for _, item := range patch {
+		object := item.(map[string]interface{})
+		found := false
+		for i, baseItem := range merged {
+			baseObject := baseItem.(map[string]interface{})
+			if baseObject["type"] == object["type"] {
+				merged[i] = mergeApplyPatches(baseObject, object)
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, object)
+		}
+	}
+	return merged
+}
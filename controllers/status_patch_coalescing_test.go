@@ -0,0 +1,316 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/util/workqueue"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/cert-manager/issuer-lib/ssapatch"
+)
+
+func TestMergeApplyPatches(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name     string
+		base     string
+		patch    string
+		expected string
+	}
+
+	tests := []testcase{
+		{
+			name:     "replace-fields",
+			base:     `{"status":{"certificate":"a","ca":"b"}}`,
+			patch:    `{"status":{"certificate":"c"}}`,
+			expected: `{"status":{"certificate":"c","ca":"b"}}`,
+		},
+		{
+			name:     "merge-conditions-by-type",
+			base:     `{"status":{"conditions":[{"type":"Ready","status":"False","reason":"Pending"},{"type":"Custom","status":"True"}]}}`,
+			patch:    `{"status":{"conditions":[{"type":"Ready","reason":"Failed"},{"type":"Other","status":"True"}]}}`,
+			expected: `{"status":{"conditions":[{"type":"Ready","status":"False","reason":"Failed"},{"type":"Custom","status":"True"},{"type":"Other","status":"True"}]}}`,
+		},
+		{
+			name:     "replace-untyped-lists",
+			base:     `{"status":{"items":["a","b"]}}`,
+			patch:    `{"status":{"items":["c"]}}`,
+			expected: `{"status":{"items":["c"]}}`,
+		},
+		{
+			name:     "replace-object-with-value",
+			base:     `{"status":{"nested":{"a":"b"}}}`,
+			patch:    `{"status":{"nested":"c"}}`,
+			expected: `{"status":{"nested":"c"}}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var base, patch map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(tc.base), &base))
+			require.NoError(t, json.Unmarshal([]byte(tc.patch), &patch))
+
+			merged, err := json.Marshal(mergeApplyPatches(base, patch))
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.expected, string(merged))
+
+			// The inputs are not modified.
+			original, err := json.Marshal(base)
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.base, string(original))
+		})
+	}
+}
+
+type appliedStatusPatch struct {
+	name       string
+	fieldOwner string
+	patch      string
+}
+
+type fakeStatusPatchApplier struct {
+	mu      sync.Mutex
+	err     error
+	applied []appliedStatusPatch
+}
+
+func (a *fakeStatusPatchApplier) apply(_ context.Context, obj client.Object, patch client.Patch, fieldOwner string) error {
+	data, err := patch.Data(obj)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.applied = append(a.applied, appliedStatusPatch{name: obj.GetName(), fieldOwner: fieldOwner, patch: string(data)})
+	return a.err
+}
+
+func (a *fakeStatusPatchApplier) get() []appliedStatusPatch {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]appliedStatusPatch(nil), a.applied...)
+}
+
+func readyConditionPatch(t *testing.T, name string, reason string, message string) (client.Object, client.Patch) {
+	t.Helper()
+
+	cr, patch, err := ssapatch.CertificateRequestStatus(name, "ns1", &cmapi.CertificateRequestStatus{
+		Conditions: []cmapi.CertificateRequestCondition{
+			{
+				Type:    cmapi.CertificateRequestConditionReady,
+				Status:  cmmeta.ConditionFalse,
+				Reason:  reason,
+				Message: message,
+			},
+		},
+	})
+	require.NoError(t, err)
+	return cr, patch
+}
+
+func readyMessage(t *testing.T, patch string) string {
+	t.Helper()
+
+	var cr cmapi.CertificateRequest
+	require.NoError(t, json.Unmarshal([]byte(patch), &cr))
+	require.Len(t, cr.Status.Conditions, 1)
+	return cr.Status.Conditions[0].Message
+}
+
+func TestStatusPatchCoalescer(t *testing.T) {
+	t.Parallel()
+
+	fakeClock := clocktesting.NewFakeClock(randomTime())
+	applier := &fakeStatusPatchApplier{}
+	c := newStatusPatchCoalescer("TestCoalescer", StatusPatchCoalescingPolicy{Window: time.Second}, fakeClock, applier.apply)
+
+	// Non-final patches of the same request and field owner are merged.
+	obj, patch := readyConditionPatch(t, "cr1", "Pending", "attempt 1")
+	_, deferred, err := c.coalesce(obj, patch, "owner", false)
+	require.NoError(t, err)
+	assert.True(t, deferred)
+
+	obj, patch = readyConditionPatch(t, "cr1", "Pending", "attempt 2")
+	_, deferred, err = c.coalesce(obj, patch, "owner", false)
+	require.NoError(t, err)
+	assert.True(t, deferred)
+
+	obj, patch = readyConditionPatch(t, "cr1", "Pending", "other owner")
+	_, deferred, err = c.coalesce(obj, patch, "other-owner", false)
+	require.NoError(t, err)
+	assert.True(t, deferred)
+
+	assert.Empty(t, applier.get())
+	fakeClock.Step(time.Second)
+
+	applied := applier.get()
+	require.Len(t, applied, 2)
+	for _, patch := range applied {
+		switch patch.fieldOwner {
+		case "owner":
+			assert.Equal(t, "attempt 2", readyMessage(t, patch.patch))
+		case "other-owner":
+			assert.Equal(t, "other owner", readyMessage(t, patch.patch))
+		default:
+			t.Fatalf("unexpected field owner %q", patch.fieldOwner)
+		}
+	}
+
+	// A final patch is returned immediately, merged with the pending patch,
+	// and the pending patch is cancelled.
+	obj, patch = readyConditionPatch(t, "cr2", "Pending", "attempt 1")
+	_, deferred, err = c.coalesce(obj, patch, "owner", false)
+	require.NoError(t, err)
+	assert.True(t, deferred)
+
+	obj, patch = readyConditionPatch(t, "cr2", "Failed", "done")
+	finalPatch, deferred, err := c.coalesce(obj, patch, "owner", true)
+	require.NoError(t, err)
+	assert.False(t, deferred)
+	data, err := finalPatch.Data(obj)
+	require.NoError(t, err)
+	assert.Equal(t, "done", readyMessage(t, string(data)))
+
+	fakeClock.Step(time.Second)
+	assert.Len(t, applier.get(), 2)
+}
+
+func TestStatusPatchCoalescerRequeuesBeforeFlush(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name     string
+		result   reconcile.Result
+		err      error
+		expected bool
+	}
+
+	tests := []testcase{
+		{
+			name:     "done",
+			result:   reconcile.Result{},
+			expected: false,
+		},
+		{
+			name:     "error",
+			result:   reconcile.Result{},
+			err:      errors.New("[error]"),
+			expected: true,
+		},
+		{
+			name:     "requeue-with-backoff",
+			result:   reconcile.Result{Requeue: true},
+			expected: true,
+		},
+		{
+			name:     "requeue-within-window",
+			result:   reconcile.Result{RequeueAfter: time.Second},
+			expected: true,
+		},
+		{
+			name:     "requeue-after-window",
+			result:   reconcile.Result{RequeueAfter: time.Minute},
+			expected: false,
+		},
+	}
+
+	c := newStatusPatchCoalescer("TestRequeuesBeforeFlush", StatusPatchCoalescingPolicy{Window: time.Second}, clocktesting.NewFakeClock(randomTime()), (&fakeStatusPatchApplier{}).apply)
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, c.requeuesBeforeFlush(tc.result, tc.err))
+		})
+	}
+
+	var disabled *statusPatchCoalescer
+	assert.False(t, disabled.requeuesBeforeFlush(reconcile.Result{Requeue: true}, nil))
+}
+
+func TestStatusPatchCoalescerRequeuesRejectedPatches(t *testing.T) {
+	t.Parallel()
+
+	fakeClock := clocktesting.NewFakeClock(randomTime())
+	applier := &fakeStatusPatchApplier{err: errors.New("[rejected]")}
+	c := newStatusPatchCoalescer("TestRequeue", StatusPatchCoalescingPolicy{}, fakeClock, applier.apply)
+	queue := c.wrapNewQueue(nil)("test-requeue", workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+	defer queue.ShutDown()
+
+	obj, patch := readyConditionPatch(t, "cr1", "Pending", "attempt 1")
+	_, deferred, err := c.coalesce(obj, patch, "owner", false)
+	require.NoError(t, err)
+	assert.True(t, deferred)
+
+	// The window defaults to 1 second.
+	fakeClock.Step(time.Second)
+	require.Len(t, applier.get(), 1)
+
+	assert.Eventually(t, func() bool { return queue.Len() == 1 }, 5*time.Second, 5*time.Millisecond)
+	item, _ := queue.Get()
+	assert.Equal(t, testRequest("cr1"), item)
+}
+
+func TestStatusPatchCoalescerFlushesOnShutdown(t *testing.T) {
+	t.Parallel()
+
+	applier := &fakeStatusPatchApplier{}
+	c := newStatusPatchCoalescer("TestShutdown", StatusPatchCoalescingPolicy{Window: time.Hour}, clocktesting.NewFakeClock(randomTime()), applier.apply)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	done := make(chan error)
+	go func() {
+		done <- c.Start(ctx)
+	}()
+
+	obj, patch := readyConditionPatch(t, "cr1", "Pending", "attempt 1")
+	_, deferred, err := c.coalesce(obj, patch, "owner", false)
+	require.NoError(t, err)
+	assert.True(t, deferred)
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.Len(t, applier.get(), 1)
+}
+
+func TestNilStatusPatchCoalescer(t *testing.T) {
+	t.Parallel()
+
+	var c *statusPatchCoalescer
+	obj, patch := readyConditionPatch(t, "cr1", "Pending", "attempt 1")
+	result, deferred, err := c.coalesce(obj, patch, "owner", false)
+	require.NoError(t, err)
+	assert.False(t, deferred)
+	assert.Equal(t, patch, result)
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	cmgen "github.com/cert-manager/cert-manager/test/unit/gen"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/kubeutil"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+	"github.com/cert-manager/issuer-lib/internal/testapi/testutil"
+	"github.com/cert-manager/issuer-lib/testing/ssafake"
+	"github.com/cert-manager/issuer-lib/testing/statussnapshot"
+)
+
+// TestCertificateRequestStatusSnapshots compares the status patches applied for
+// each Sign scenario with the golden files in testdata/status-snapshots. Run the
+// test with UPDATE_GOLDEN_FILES=true to update the golden files after an
+// intended change.
+func TestCertificateRequestStatusSnapshots(t *testing.T) {
+	t.Parallel()
+
+	for _, scenario := range statussnapshot.SignScenarios() {
+		t.Run(scenario.Name, func(t *testing.T) {
+			t.Parallel()
+
+			fakeClock := clocktesting.NewFakeClock(randomTime().Truncate(time.Second))
+
+			issuer := testutil.TestIssuer(
+				"issuer-1",
+				testutil.SetTestIssuerNamespace("ns1"),
+				testutil.SetTestIssuerStatusCondition(
+					fakeClock,
+					cmapi.IssuerConditionReady,
+					cmmeta.ConditionTrue,
+					v1alpha1.IssuerConditionReasonChecked,
+					"Succeeded checking the issuer",
+				),
+			)
+
+			cr := cmgen.CertificateRequest(
+				"cr1",
+				cmgen.SetCertificateRequestNamespace("ns1"),
+				cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+					Name:  issuer.Name,
+					Group: api.SchemeGroupVersion.Group,
+				}),
+				cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+					Type:   cmapi.CertificateRequestConditionApproved,
+					Status: cmmeta.ConditionTrue,
+				}),
+			)
+
+			scheme := runtime.NewScheme()
+			require.NoError(t, setupCertificateRequestReconcilerScheme(scheme))
+			require.NoError(t, api.AddToScheme(scheme))
+
+			recorder := statussnapshot.NewRecorder()
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(cr, issuer).
+				WithStatusSubresource(cr).
+				WithInterceptorFuncs(recorder.InterceptorFuncs(ssafake.NewApplier().InterceptorFuncs())).
+				Build()
+
+			controller := (&CertificateRequestReconciler{
+				RequestController: RequestController{
+					IssuerTypes:      []v1alpha1.Issuer{&api.TestIssuer{}},
+					FieldOwner:       "test-status-snapshots",
+					MaxRetryDuration: time.Hour,
+					EventSource:      kubeutil.NewEventStore(),
+					Client:           fakeClient,
+					Sign: func(_ context.Context, _ signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+						return signer.PEMBundle{ChainPEM: []byte("cert")}, scenario.Err
+					},
+					EventRecorder: record.NewFakeRecorder(100),
+					Clock:         fakeClock,
+				},
+			}).Init()
+			require.NoError(t, controller.setAllIssuerTypesWithGroupVersionKind(scheme))
+
+			_, _ = controller.Reconcile(context.TODO(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cr)})
+
+			snapshot, err := recorder.Snapshot()
+			require.NoError(t, err)
+			statussnapshot.AssertGoldenFile(t, filepath.Join("testdata", "status-snapshots", "certificaterequest", scenario.Name+".json"), snapshot)
+		})
+	}
+}
+
+// TestIssuerStatusSnapshots compares the status patches applied for each Check
+// scenario with the golden files in testdata/status-snapshots.
+func TestIssuerStatusSnapshots(t *testing.T) {
+	t.Parallel()
+
+	for _, scenario := range statussnapshot.CheckScenarios() {
+		t.Run(scenario.Name, func(t *testing.T) {
+			t.Parallel()
+
+			issuer := testutil.TestIssuer(
+				"issuer-1",
+				testutil.SetTestIssuerNamespace("ns1"),
+			)
+
+			scheme := runtime.NewScheme()
+			require.NoError(t, api.AddToScheme(scheme))
+
+			recorder := statussnapshot.NewRecorder()
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(issuer).
+				WithStatusSubresource(issuer).
+				WithInterceptorFuncs(recorder.InterceptorFuncs(ssafake.NewApplier().InterceptorFuncs())).
+				Build()
+
+			forObject := &api.TestIssuer{}
+			require.NoError(t, kubeutil.SetGroupVersionKind(scheme, forObject))
+
+			controller := IssuerReconciler{
+				ForObject:   forObject,
+				FieldOwner:  "test-status-snapshots",
+				EventSource: kubeutil.NewEventStore(),
+				Client:      fakeClient,
+				Check: func(_ context.Context, _ v1alpha1.Issuer) error {
+					return scenario.Err
+				},
+				EventRecorder: record.NewFakeRecorder(100),
+				Clock:         clocktesting.NewFakeClock(randomTime()),
+			}
+
+			_, _ = controller.Reconcile(context.TODO(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(issuer)})
+
+			snapshot, err := recorder.Snapshot()
+			require.NoError(t, err)
+			statussnapshot.AssertGoldenFile(t, filepath.Join("testdata", "status-snapshots", "issuer", scenario.Name+".json"), snapshot)
+		})
+	}
+}
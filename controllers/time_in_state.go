@@ -0,0 +1,143 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+)
+
+const (
+	timeInStateOutcomeIssued = "Issued"
+	timeInStateOutcomeFailed = "Failed"
+)
+
+// timeInState contains the time that a request spent in the Initializing and
+// Pending states before it was Issued or Failed.
+type timeInState struct {
+	outcome      string
+	initializing time.Duration
+	pending      time.Duration
+}
+
+// timeInStatePatch is implemented by the RequestPatch implementations that
+// record the time in state when the request transitions to Issued or Failed.
+type timeInStatePatch interface {
+	timeInState() *timeInState
+}
+
+// certificateRequestTimeInState derives the time in state from the Ready condition
+// of the CertificateRequest before the transition to the outcome. A CertificateRequest
+// is Initializing from its creation until the Ready condition becomes False, and is
+// Pending from then on.
+func certificateRequestTimeInState(cr *cmapi.CertificateRequest, now time.Time, outcome string) *timeInState {
+	created := cr.CreationTimestamp.Time
+	tis := &timeInState{
+		outcome:      outcome,
+		initializing: now.Sub(created),
+	}
+
+	for _, cond := range cr.Status.Conditions {
+		if cond.Type != cmapi.CertificateRequestConditionReady ||
+			cond.Status != cmmeta.ConditionFalse ||
+			cond.LastTransitionTime == nil {
+			continue
+		}
+
+		tis.initializing = cond.LastTransitionTime.Sub(created)
+		tis.pending = now.Sub(cond.LastTransitionTime.Time)
+	}
+
+	tis.initializing = max(tis.initializing, 0)
+	tis.pending = max(tis.pending, 0)
+	return tis
+}
+
+// certificateSigningRequestTimeInState derives the time in state of a Kubernetes
+// CSR. Kubernetes CSRs have no Ready condition, so a Kubernetes CSR is Pending from
+// its creation until the transition to the outcome.
+func certificateSigningRequestTimeInState(csr *certificatesv1.CertificateSigningRequest, now time.Time, outcome string) *timeInState {
+	return &timeInState{
+		outcome: outcome,
+		pending: max(now.Sub(csr.CreationTimestamp.Time), 0),
+	}
+}
+
+// annotationValue renders the value of the RequestTimeInStateAnnotationKey annotation.
+func (tis *timeInState) annotationValue() (string, error) {
+	value, err := json.Marshal(struct {
+		Outcome             string  `json:"outcome"`
+		InitializingSeconds float64 `json:"initializingSeconds"`
+		PendingSeconds      float64 `json:"pendingSeconds"`
+	}{
+		Outcome:             tis.outcome,
+		InitializingSeconds: tis.initializing.Seconds(),
+		PendingSeconds:      tis.pending.Seconds(),
+	})
+	return string(value), err
+}
+
+// recordTimeInState observes the time in state metrics after the request
+// transitioned to Issued or Failed, and sets the RequestTimeInStateAnnotationKey
+// annotation on the request if SetTimeInStateAnnotation is enabled.
+func (r *RequestController) recordTimeInState(ctx context.Context, obj client.Object, statusPatch RequestPatch) error {
+	patch, ok := statusPatch.(timeInStatePatch)
+	if !ok {
+		return nil
+	}
+
+	tis := patch.timeInState()
+	if tis == nil {
+		return nil
+	}
+
+	kind := requestKind(obj)
+	requestStateDuration.WithLabelValues(kind, "Initializing", tis.outcome).Observe(tis.initializing.Seconds())
+	requestStateDuration.WithLabelValues(kind, "Pending", tis.outcome).Observe(tis.pending.Seconds())
+	r.labelPropagation.recordOutcome(obj, tis.outcome)
+
+	if !r.SetTimeInStateAnnotation {
+		return nil
+	}
+
+	value, err := tis.annotationValue()
+	if err != nil {
+		return err
+	}
+
+	annotationPatch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				v1alpha1.RequestTimeInStateAnnotationKey: value,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return client.IgnoreNotFound(r.Client.Patch(ctx, obj, client.RawPatch(types.MergePatchType, annotationPatch)))
+}
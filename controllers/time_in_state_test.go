@@ -0,0 +1,200 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	cmgen "github.com/cert-manager/cert-manager/test/unit/gen"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/kubeutil"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+	"github.com/cert-manager/issuer-lib/internal/testapi/testutil"
+	"github.com/cert-manager/issuer-lib/testing/ssafake"
+)
+
+func TestCertificateRequestTimeInState(t *testing.T) {
+	t.Parallel()
+
+	created := randomTime().Truncate(time.Second)
+	pendingSince := metav1.NewTime(created.Add(2 * time.Second))
+	now := created.Add(time.Minute)
+
+	type testCase struct {
+		name     string
+		cr       *cmapi.CertificateRequest
+		expected *timeInState
+	}
+
+	tests := []testCase{
+		{
+			name: "initializing-only",
+			cr: cmgen.CertificateRequest(
+				"cr1",
+				cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+					Type:   cmapi.CertificateRequestConditionReady,
+					Status: cmmeta.ConditionUnknown,
+					Reason: v1alpha1.CertificateRequestConditionReasonInitializing,
+				}),
+			),
+			expected: &timeInState{
+				outcome:      timeInStateOutcomeIssued,
+				initializing: time.Minute,
+			},
+		},
+		{
+			name: "initializing-and-pending",
+			cr: cmgen.CertificateRequest(
+				"cr1",
+				cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+					Type:               cmapi.CertificateRequestConditionReady,
+					Status:             cmmeta.ConditionFalse,
+					Reason:             cmapi.CertificateRequestReasonPending,
+					LastTransitionTime: &pendingSince,
+				}),
+			),
+			expected: &timeInState{
+				outcome:      timeInStateOutcomeIssued,
+				initializing: 2 * time.Second,
+				pending:      58 * time.Second,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			tc.cr.CreationTimestamp = metav1.NewTime(created)
+			assert.Equal(t, tc.expected, certificateRequestTimeInState(tc.cr, now, timeInStateOutcomeIssued))
+		})
+	}
+}
+
+func TestCertificateRequestReconcilerTimeInState(t *testing.T) {
+	t.Parallel()
+
+	fieldOwner := "test-certificate-request-reconciler-time-in-state"
+
+	created := randomTime().Truncate(time.Second)
+	pendingSince := metav1.NewTime(created.Add(2 * time.Second))
+	fakeClock := clocktesting.NewFakeClock(created.Add(time.Minute))
+
+	issuer := testutil.TestIssuer(
+		"issuer-1",
+		testutil.SetTestIssuerNamespace("ns1"),
+		testutil.SetTestIssuerStatusCondition(
+			fakeClock,
+			cmapi.IssuerConditionReady,
+			cmmeta.ConditionTrue,
+			v1alpha1.IssuerConditionReasonChecked,
+			"Succeeded checking the issuer",
+		),
+	)
+
+	cr1 := cmgen.CertificateRequest(
+		"cr1",
+		cmgen.SetCertificateRequestNamespace("ns1"),
+		cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+			Name:  issuer.Name,
+			Group: api.SchemeGroupVersion.Group,
+		}),
+		cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+			Type:               cmapi.CertificateRequestConditionReady,
+			Status:             cmmeta.ConditionFalse,
+			Reason:             cmapi.CertificateRequestReasonPending,
+			LastTransitionTime: &pendingSince,
+		}),
+		cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+			Type:   cmapi.CertificateRequestConditionApproved,
+			Status: cmmeta.ConditionTrue,
+		}),
+	)
+	cr1.CreationTimestamp = metav1.NewTime(created)
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, setupCertificateRequestReconcilerScheme(scheme))
+	require.NoError(t, api.AddToScheme(scheme))
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(cr1, issuer).
+		WithStatusSubresource(cr1).
+		WithInterceptorFuncs(ssafake.NewApplier().InterceptorFuncs()).
+		Build()
+
+	controller := (&CertificateRequestReconciler{
+		RequestController: RequestController{
+			IssuerTypes:              []v1alpha1.Issuer{&api.TestIssuer{}},
+			FieldOwner:               fieldOwner,
+			MaxRetryDuration:         time.Hour,
+			EventSource:              kubeutil.NewEventStore(),
+			SetTimeInStateAnnotation: true,
+			Client:                   fakeClient,
+			Sign: func(_ context.Context, _ signer.CertificateRequestObject, _ v1alpha1.Issuer) (signer.PEMBundle, error) {
+				return signer.PEMBundle{ChainPEM: []byte("cert")}, nil
+			},
+			EventRecorder: record.NewFakeRecorder(100),
+			Clock:         fakeClock,
+		},
+	}).Init()
+	require.NoError(t, controller.setAllIssuerTypesWithGroupVersionKind(scheme))
+
+	pendingObserved := histogramSampleCount(t, requestStateDuration.WithLabelValues("CertificateRequest", "Pending", timeInStateOutcomeIssued))
+
+	_, err := controller.Reconcile(context.TODO(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cr1)})
+	require.NoError(t, err)
+
+	var current cmapi.CertificateRequest
+	require.NoError(t, fakeClient.Get(context.TODO(), client.ObjectKeyFromObject(cr1), &current))
+
+	var value map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(current.Annotations[v1alpha1.RequestTimeInStateAnnotationKey]), &value))
+	assert.Equal(t, map[string]interface{}{
+		"outcome":             timeInStateOutcomeIssued,
+		"initializingSeconds": float64(2),
+		"pendingSeconds":      float64(58),
+	}, value)
+
+	assert.Greater(t, histogramSampleCount(t, requestStateDuration.WithLabelValues("CertificateRequest", "Pending", timeInStateOutcomeIssued)), pendingObserved)
+}
+
+func histogramSampleCount(t *testing.T, observer prometheus.Observer) uint64 {
+	t.Helper()
+
+	var metric dto.Metric
+	require.NoError(t, observer.(prometheus.Histogram).Write(&metric))
+	return metric.GetHistogram().GetSampleCount()
+}
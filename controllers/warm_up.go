@@ -0,0 +1,331 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"container/heap"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/go-logr/logr"
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// WarmUpPolicy rate limits the reconciles of the requests that are added to the
+// work queue while the controller starts. After a cluster was restored from a
+// backup, thousands of requests can be re-created at once; without a warm-up,
+// they are all passed to the CA as fast as the workers allow.
+//
+// During the warm-up window, added requests are held back and released to the
+// work queue at QPS. The CertificateRequests of the Certificates that expire
+// first are released first, followed by the requests whose Certificate was never
+// issued, and finally by the requests that are not owned by a Certificate (incl.
+// all Kubernetes CertificateSigningRequests) in the order in which they were
+// added. Retries are not held back. The progress is logged and reported by the
+// issuer_lib_warm_up_held_requests and issuer_lib_warm_up_released_requests_total
+// metrics.
+type WarmUpPolicy struct {
+	// Duration is the duration of the warm-up window after the controller
+	// started, defaults to 10 minutes. Requests that are added after the window
+	// are queued immediately, while the requests that are still held back
+	// continue to be released at QPS.
+	Duration time.Duration
+
+	// QPS is the number of held back requests that are released per second,
+	// defaults to 10.
+	QPS float64
+
+	// Burst is the number of held back requests that can be released at once,
+	// defaults to 1.
+	Burst int
+
+	// Reader is used to read the Certificates that own the requests, it defaults
+	// to the Client of the controller. This requires read permissions on
+	// Certificates.
+	Reader client.Reader
+}
+
+// warmUpUnowned is the expiry of requests that are not owned by a Certificate,
+// they are released after all other requests.
+var warmUpUnowned = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+// expiry returns the time at which the certificate of the Certificate that owns
+// the request expires. Requests of Certificates that were never issued return
+// the zero time, requests that are not owned by a Certificate return
+// warmUpUnowned.
+func (p *WarmUpPolicy) expiry(ctx context.Context, cl client.Reader, requestObject client.Object) time.Time {
+	certificateRequest, ok := requestObject.(*cmapi.CertificateRequest)
+	if !ok {
+		return warmUpUnowned
+	}
+
+	certificateName := owningCertificateName(certificateRequest)
+	if certificateName == "" {
+		return warmUpUnowned
+	}
+
+	if p.Reader != nil {
+		cl = p.Reader
+	}
+
+	certificate := &cmapi.Certificate{}
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: certificateRequest.Namespace, Name: certificateName}, certificate); err != nil {
+		return warmUpUnowned
+	}
+
+	if certificate.Status.NotAfter == nil {
+		return time.Time{}
+	}
+	return certificate.Status.NotAfter.Time
+}
+
+// warmUpItem is a request that is held back, items are ordered by expiry and
+// then by the order in which they were added.
+type warmUpItem struct {
+	request  reconcile.Request
+	expiry   time.Time
+	sequence uint64
+}
+
+type warmUpHeap []warmUpItem
+
+var _ heap.Interface = &warmUpHeap{}
+
+func (h warmUpHeap) Len() int { return len(h) }
+
+func (h warmUpHeap) Less(i, j int) bool {
+	if !h[i].expiry.Equal(h[j].expiry) {
+		return h[i].expiry.Before(h[j].expiry)
+	}
+	return h[i].sequence < h[j].sequence
+}
+
+func (h warmUpHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *warmUpHeap) Push(x any) { *h = append(*h, x.(warmUpItem)) }
+
+func (h *warmUpHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// warmUpProgressInterval is the number of released requests after which the
+// progress of the warm-up is logged.
+const warmUpProgressInterval = 500
+
+// warmUpQueue wraps the work queue of a controller and holds back the requests
+// that are added during the warm-up window, see WarmUpPolicy.
+type warmUpQueue struct {
+	workqueue.TypedRateLimitingInterface[reconcile.Request]
+
+	name    string
+	logger  logr.Logger
+	clock   clock.WithDelayedExecution
+	limiter *rate.Limiter
+	expiry  func(reconcile.Request) time.Time
+	end     time.Time
+
+	mu       sync.Mutex
+	held     warmUpHeap
+	heldSet  map[reconcile.Request]struct{}
+	sequence uint64
+	released int
+
+	windowEnded sync.Once
+	wake        chan struct{}
+	stop        chan struct{}
+	stopOnce    sync.Once
+}
+
+// newWarmUpQueue returns a warmUpQueue that wraps the queue. The warm-up window
+// starts now; the held back requests are only released once run is called.
+func newWarmUpQueue(
+	kind string,
+	policy WarmUpPolicy,
+	queue workqueue.TypedRateLimitingInterface[reconcile.Request],
+	logger logr.Logger,
+	clock clock.WithDelayedExecution,
+	expiry func(reconcile.Request) time.Time,
+) *warmUpQueue {
+	duration := policy.Duration
+	if duration == 0 {
+		duration = 10 * time.Minute
+	}
+	qps := policy.QPS
+	if qps == 0 {
+		qps = 10
+	}
+	burst := policy.Burst
+	if burst == 0 {
+		burst = 1
+	}
+
+	return &warmUpQueue{
+		TypedRateLimitingInterface: queue,
+
+		name:    strings.ToLower(kind),
+		logger:  logger.WithName("warm-up"),
+		clock:   clock,
+		limiter: rate.NewLimiter(rate.Limit(qps), burst),
+		expiry:  expiry,
+		end:     clock.Now().Add(duration),
+
+		heldSet: map[reconcile.Request]struct{}{},
+		wake:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+	}
+}
+
+// wrapNewQueueWithWarmUp returns a function that creates the work queue of the controller
+// using newQueue, and wraps it in a warmUpQueue. A nil newQueue creates the
+// default queue of controller-runtime.
+func wrapNewQueueWithWarmUp(
+	kind string,
+	policy WarmUpPolicy,
+	logger logr.Logger,
+	clock clock.WithDelayedExecution,
+	expiry func(reconcile.Request) time.Time,
+	newQueue func(string, workqueue.TypedRateLimiter[reconcile.Request]) workqueue.TypedRateLimitingInterface[reconcile.Request],
+) func(string, workqueue.TypedRateLimiter[reconcile.Request]) workqueue.TypedRateLimitingInterface[reconcile.Request] {
+	return func(controllerName string, rateLimiter workqueue.TypedRateLimiter[reconcile.Request]) workqueue.TypedRateLimitingInterface[reconcile.Request] {
+		var queue workqueue.TypedRateLimitingInterface[reconcile.Request]
+		if newQueue != nil {
+			queue = newQueue(controllerName, rateLimiter)
+		} else {
+			queue = workqueue.NewTypedRateLimitingQueueWithConfig(rateLimiter, workqueue.TypedRateLimitingQueueConfig[reconcile.Request]{
+				Name: controllerName,
+			})
+		}
+
+		warmUp := newWarmUpQueue(kind, policy, queue, logger, clock, expiry)
+		go warmUp.run()
+		return warmUp
+	}
+}
+
+// Add holds the request back during the warm-up window, and adds it to the
+// wrapped queue afterwards.
+func (q *warmUpQueue) Add(item reconcile.Request) {
+	if !q.clock.Now().Before(q.end) {
+		q.windowEnded.Do(q.logWindowEnd)
+		q.TypedRateLimitingInterface.Add(item)
+		return
+	}
+
+	// Determine the expiry before taking the lock, the function might have to
+	// read the request and its Certificate from the cache.
+	expiry := q.expiry(item)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.heldSet[item]; ok {
+		return
+	}
+	q.heldSet[item] = struct{}{}
+	q.sequence++
+	heap.Push(&q.held, warmUpItem{request: item, expiry: expiry, sequence: q.sequence})
+	warmUpHeldRequests.WithLabelValues(q.name).Set(float64(len(q.held)))
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (q *warmUpQueue) ShutDown() {
+	q.stopOnce.Do(func() { close(q.stop) })
+	q.TypedRateLimitingInterface.ShutDown()
+}
+
+func (q *warmUpQueue) ShutDownWithDrain() {
+	q.stopOnce.Do(func() { close(q.stop) })
+	q.TypedRateLimitingInterface.ShutDownWithDrain()
+}
+
+// run releases the held back requests to the wrapped queue at the rate of the
+// limiter, until the queue is shut down.
+func (q *warmUpQueue) run() {
+	q.logger.Info("Warm-up started, requests are released at a limited rate", "until", q.end)
+
+	for {
+		q.mu.Lock()
+		held := len(q.held)
+		q.mu.Unlock()
+
+		if held == 0 {
+			select {
+			case <-q.wake:
+				continue
+			case <-q.stop:
+				return
+			}
+		}
+
+		now := q.clock.Now()
+		if delay := q.limiter.ReserveN(now, 1).DelayFrom(now); delay > 0 {
+			select {
+			case <-q.clock.After(delay):
+			case <-q.stop:
+				return
+			}
+		}
+
+		q.release()
+	}
+}
+
+// release adds the held back request with the earliest expiry to the wrapped
+// queue.
+func (q *warmUpQueue) release() {
+	q.mu.Lock()
+	item := heap.Pop(&q.held).(warmUpItem)
+	delete(q.heldSet, item.request)
+	q.released++
+	held, released := len(q.held), q.released
+	q.mu.Unlock()
+
+	q.TypedRateLimitingInterface.Add(item.request)
+
+	warmUpHeldRequests.WithLabelValues(q.name).Set(float64(held))
+	warmUpReleasedRequests.WithLabelValues(q.name).Inc()
+
+	if held == 0 {
+		q.logger.Info("Released all held back requests", "released", released)
+	} else if released%warmUpProgressInterval == 0 {
+		q.logger.Info("Warm-up progress", "released", released, "held", held)
+	}
+}
+
+// logWindowEnd logs the end of the warm-up window, it is called when the first
+// request is added after the window.
+func (q *warmUpQueue) logWindowEnd() {
+	q.mu.Lock()
+	held, released := len(q.held), q.released
+	q.mu.Unlock()
+
+	q.logger.Info("Warm-up window ended, new requests are no longer held back", "released", released, "held", held)
+}
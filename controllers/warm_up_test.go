@@ -0,0 +1,174 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmgen "github.com/cert-manager/cert-manager/test/unit/gen"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestWarmUpPolicyExpiry(t *testing.T) {
+	t.Parallel()
+
+	notAfter := randomTime().Truncate(time.Second)
+
+	issued := cmgen.Certificate("issued",
+		cmgen.SetCertificateNamespace("ns1"),
+		cmgen.SetCertificateNotAfter(metav1.NewTime(notAfter)),
+	)
+	neverIssued := cmgen.Certificate("never-issued",
+		cmgen.SetCertificateNamespace("ns1"),
+	)
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, setupCertificateRequestReconcilerScheme(scheme))
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(issued, neverIssued).
+		Build()
+
+	type testCase struct {
+		name           string
+		requestObject  client.Object
+		expectedExpiry time.Time
+	}
+
+	tests := []testCase{
+		{
+			name: "issued",
+			requestObject: cmgen.CertificateRequest("cr1",
+				cmgen.SetCertificateRequestNamespace("ns1"),
+				cmgen.SetCertificateRequestAnnotations(map[string]string{cmapi.CertificateNameKey: "issued"}),
+			),
+			expectedExpiry: notAfter,
+		},
+		{
+			name: "never-issued",
+			requestObject: cmgen.CertificateRequest("cr1",
+				cmgen.SetCertificateRequestNamespace("ns1"),
+				cmgen.SetCertificateRequestAnnotations(map[string]string{cmapi.CertificateNameKey: "never-issued"}),
+			),
+			expectedExpiry: time.Time{},
+		},
+		{
+			name: "missing-certificate",
+			requestObject: cmgen.CertificateRequest("cr1",
+				cmgen.SetCertificateRequestNamespace("ns1"),
+				cmgen.SetCertificateRequestAnnotations(map[string]string{cmapi.CertificateNameKey: "missing"}),
+			),
+			expectedExpiry: warmUpUnowned,
+		},
+		{
+			name: "not-owned",
+			requestObject: cmgen.CertificateRequest("cr1",
+				cmgen.SetCertificateRequestNamespace("ns1"),
+			),
+			expectedExpiry: warmUpUnowned,
+		},
+		{
+			name:           "kubernetes-csr",
+			requestObject:  cmgen.CertificateSigningRequest("csr1"),
+			expectedExpiry: warmUpUnowned,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			expiry := (&WarmUpPolicy{}).expiry(context.TODO(), fakeClient, tc.requestObject)
+			assert.True(t, tc.expectedExpiry.Equal(expiry), "expected %s, got %s", tc.expectedExpiry, expiry)
+		})
+	}
+}
+
+func TestWarmUpQueue(t *testing.T) {
+	t.Parallel()
+
+	fakeClock := clocktesting.NewFakeClock(randomTime())
+
+	request := func(name string) reconcile.Request {
+		return reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "ns1", Name: name}}
+	}
+	expiries := map[string]time.Time{
+		"late":         fakeClock.Now().Add(30 * 24 * time.Hour),
+		"early":        fakeClock.Now().Add(24 * time.Hour),
+		"never-issued": {},
+		"unowned":      warmUpUnowned,
+	}
+
+	inner := workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+	q := newWarmUpQueue(
+		"CertificateRequest",
+		WarmUpPolicy{Duration: time.Minute, QPS: 1},
+		inner,
+		logr.Discard(),
+		fakeClock,
+		func(req reconcile.Request) time.Time { return expiries[req.Name] },
+	)
+
+	for _, name := range []string{"unowned", "late", "early", "never-issued", "late"} {
+		q.Add(request(name))
+	}
+	assert.Equal(t, 0, inner.Len(), "requests must be held back during the warm-up")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		q.run()
+	}()
+
+	get := func() string {
+		item, shutdown := inner.Get()
+		require.False(t, shutdown)
+		inner.Done(item)
+		return item.Name
+	}
+
+	// The first request is released immediately, the others at 1 QPS, ordered
+	// by the expiry of their Certificates.
+	assert.Equal(t, "never-issued", get())
+	for _, expected := range []string{"early", "late", "unowned"} {
+		require.Eventually(t, fakeClock.HasWaiters, 5*time.Second, time.Millisecond)
+		assert.Equal(t, 0, inner.Len())
+		fakeClock.Step(time.Second)
+		assert.Equal(t, expected, get())
+	}
+
+	// Requests that are added after the warm-up window are queued immediately.
+	fakeClock.Step(time.Minute)
+	q.Add(request("after-warm-up"))
+	assert.Equal(t, "after-warm-up", get())
+
+	q.ShutDown()
+	<-done
+}
@@ -0,0 +1,224 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package coordination contains the backends that the optional coordination
+// features of the controllers (eg. the IssuanceClaimPolicy) use to coordinate
+// between controller replicas.
+//
+// By default, issuance claims are stored in an annotation on the request. In
+// clusters where patching the requests from a second field manager or creating
+// Leases is restricted, a different Backend can be used instead, eg. one that
+// stores the locks in an external key-value store:
+//
+//	controller.IssuanceClaimPolicy = &controllers.IssuanceClaimPolicy{
+//		Identity: os.Getenv("POD_NAME"),
+//		Backend: &coordination.LeaseBackend{
+//			Client:    mgr.GetClient(),
+//			Namespace: "my-issuer-system",
+//		},
+//	}
+package coordination
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Backend stores time-limited locks that are shared between controller replicas.
+// Implementations must be safe for concurrent use.
+type Backend interface {
+	// TryAcquire acquires the lock identified by the key for the holder until
+	// now+duration, or renews the lock if it is already held by the holder. If
+	// the lock is held by another holder and has not expired, false is returned
+	// together with the duration after which the lock expires.
+	//
+	// The key is an opaque string that can be longer than a Kubernetes object
+	// name; implementations that have restrictions on the key format must map it
+	// to a valid identifier (eg. using a hash).
+	TryAcquire(ctx context.Context, key string, holder string, duration time.Duration, now time.Time) (bool, time.Duration, error)
+}
+
+// Releaser is optionally implemented by a Backend that can release a lock before
+// it expires. Locks of backends that do not implement it are held until they
+// expire.
+type Releaser interface {
+	// Release releases the lock identified by the key if it is held by the
+	// holder, locks held by other holders are not changed.
+	Release(ctx context.Context, key string, holder string) error
+}
+
+// LeaseBackend is a Backend that stores each lock in a coordination.k8s.io Lease.
+// It requires permission to get, create and update Leases in the namespace.
+// Leases are not deleted when a lock expires, use a TTL controller or a
+// periodic cleanup job to remove Leases that are no longer used.
+type LeaseBackend struct {
+	// Client is used to read and write the Leases.
+	Client client.Client
+	// Namespace is the namespace in which the Leases are created.
+	Namespace string
+	// NamePrefix is prepended to the names of the Leases, defaults to
+	// "issuer-lib-".
+	NamePrefix string
+}
+
+var _ Backend = &LeaseBackend{}
+var _ Releaser = &LeaseBackend{}
+
+// leaseName returns a valid Lease name for the key.
+func (b *LeaseBackend) leaseName(key string) string {
+	prefix := b.NamePrefix
+	if prefix == "" {
+		prefix = "issuer-lib-"
+	}
+	hash := sha256.Sum256([]byte(key))
+	return prefix + hex.EncodeToString(hash[:16])
+}
+
+func (b *LeaseBackend) TryAcquire(ctx context.Context, key string, holder string, duration time.Duration, now time.Time) (bool, time.Duration, error) {
+	lease := &coordinationv1.Lease{}
+	leaseKey := client.ObjectKey{Namespace: b.Namespace, Name: b.leaseName(key)}
+
+	if err := b.Client.Get(ctx, leaseKey, lease); apierrors.IsNotFound(err) {
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: leaseKey.Namespace,
+				Name:      leaseKey.Name,
+			},
+		}
+		setLeaseHolder(lease, holder, duration, now)
+
+		// Creating the Lease fails if another replica created it since we
+		// checked, the caller retries.
+		if err := b.Client.Create(ctx, lease); err != nil {
+			return false, 0, fmt.Errorf("failed to create lease %s: %w", leaseKey, err)
+		}
+		return true, 0, nil
+	} else if err != nil {
+		return false, 0, fmt.Errorf("failed to get lease %s: %w", leaseKey, err)
+	}
+
+	currentHolder := ptr.Deref(lease.Spec.HolderIdentity, "")
+	if lease.Spec.RenewTime != nil && lease.Spec.LeaseDurationSeconds != nil {
+		expires := lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second)
+		remaining := expires.Sub(now)
+
+		if currentHolder != holder && remaining > 0 {
+			return false, remaining, nil
+		}
+
+		// Don't renew a lock that is still valid for long enough.
+		if currentHolder == holder && remaining > duration/2 {
+			return true, 0, nil
+		}
+	}
+
+	// Updating the Lease fails with a conflict if another replica acquired the
+	// lock since we read it, the caller retries.
+	setLeaseHolder(lease, holder, duration, now)
+	if err := b.Client.Update(ctx, lease); err != nil {
+		return false, 0, fmt.Errorf("failed to update lease %s: %w", leaseKey, err)
+	}
+	return true, 0, nil
+}
+
+func (b *LeaseBackend) Release(ctx context.Context, key string, holder string) error {
+	lease := &coordinationv1.Lease{}
+	leaseKey := client.ObjectKey{Namespace: b.Namespace, Name: b.leaseName(key)}
+
+	if err := b.Client.Get(ctx, leaseKey, lease); apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get lease %s: %w", leaseKey, err)
+	}
+
+	if ptr.Deref(lease.Spec.HolderIdentity, "") != holder {
+		return nil
+	}
+
+	// Updating the Lease fails with a conflict if another replica acquired the
+	// lock since we read it.
+	lease.Spec.HolderIdentity = nil
+	lease.Spec.RenewTime = nil
+	if err := b.Client.Update(ctx, lease); err != nil {
+		return fmt.Errorf("failed to update lease %s: %w", leaseKey, err)
+	}
+	return nil
+}
+
+func setLeaseHolder(lease *coordinationv1.Lease, holder string, duration time.Duration, now time.Time) {
+	renewTime := metav1.NewMicroTime(now)
+	if currentHolder := lease.Spec.HolderIdentity; currentHolder == nil || *currentHolder != holder {
+		lease.Spec.AcquireTime = &renewTime
+		if currentHolder != nil {
+			lease.Spec.LeaseTransitions = ptr.To(ptr.Deref(lease.Spec.LeaseTransitions, 0) + 1)
+		}
+	}
+	lease.Spec.HolderIdentity = ptr.To(holder)
+	lease.Spec.RenewTime = &renewTime
+	lease.Spec.LeaseDurationSeconds = ptr.To(int32((duration + time.Second - 1) / time.Second))
+}
+
+// MemoryBackend is a Backend that stores the locks in memory. It only
+// coordinates between controllers that share the same MemoryBackend, which
+// makes it useful for tests and for running multiple controllers in a single
+// process.
+type MemoryBackend struct {
+	mu    sync.Mutex
+	locks map[string]memoryLock
+}
+
+type memoryLock struct {
+	holder  string
+	expires time.Time
+}
+
+var _ Backend = &MemoryBackend{}
+var _ Releaser = &MemoryBackend{}
+
+func (b *MemoryBackend) TryAcquire(_ context.Context, key string, holder string, duration time.Duration, now time.Time) (bool, time.Duration, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if lock, ok := b.locks[key]; ok && lock.holder != holder && lock.expires.After(now) {
+		return false, lock.expires.Sub(now), nil
+	}
+
+	if b.locks == nil {
+		b.locks = map[string]memoryLock{}
+	}
+	b.locks[key] = memoryLock{holder: holder, expires: now.Add(duration)}
+	return true, 0, nil
+}
+
+func (b *MemoryBackend) Release(_ context.Context, key string, holder string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if lock, ok := b.locks[key]; ok && lock.holder == holder {
+		delete(b.locks, key)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package coordination
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testBackend(t *testing.T, backend Backend) {
+	t.Helper()
+
+	ctx := context.TODO()
+	now := time.Unix(1700000000, 0)
+
+	acquired, _, err := backend.TryAcquire(ctx, "key-1", "replica-a", time.Minute, now)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	// The lock is held by replica-a, other keys are independent.
+	acquired, remaining, err := backend.TryAcquire(ctx, "key-1", "replica-b", time.Minute, now.Add(20*time.Second))
+	require.NoError(t, err)
+	assert.False(t, acquired)
+	assert.Equal(t, 40*time.Second, remaining)
+
+	acquired, _, err = backend.TryAcquire(ctx, "key-2", "replica-b", time.Minute, now)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	// The holder can renew the lock.
+	acquired, _, err = backend.TryAcquire(ctx, "key-1", "replica-a", time.Minute, now.Add(40*time.Second))
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	acquired, remaining, err = backend.TryAcquire(ctx, "key-1", "replica-b", time.Minute, now.Add(60*time.Second))
+	require.NoError(t, err)
+	assert.False(t, acquired)
+	assert.Equal(t, 40*time.Second, remaining)
+
+	// Another replica acquires the lock once it has expired.
+	acquired, _, err = backend.TryAcquire(ctx, "key-1", "replica-b", time.Minute, now.Add(100*time.Second))
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	acquired, _, err = backend.TryAcquire(ctx, "key-1", "replica-a", time.Minute, now.Add(110*time.Second))
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	// A lock can only be released by its holder, after which another replica
+	// acquires it before it has expired.
+	releaser, ok := backend.(Releaser)
+	require.True(t, ok)
+
+	require.NoError(t, releaser.Release(ctx, "key-2", "replica-a"))
+	acquired, _, err = backend.TryAcquire(ctx, "key-2", "replica-a", time.Minute, now.Add(10*time.Second))
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	require.NoError(t, releaser.Release(ctx, "key-2", "replica-b"))
+	acquired, _, err = backend.TryAcquire(ctx, "key-2", "replica-a", time.Minute, now.Add(10*time.Second))
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	require.NoError(t, releaser.Release(ctx, "key-3", "replica-a"))
+}
+
+func TestMemoryBackend(t *testing.T) {
+	t.Parallel()
+
+	testBackend(t, &MemoryBackend{})
+}
+
+func TestLeaseBackend(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, coordinationv1.AddToScheme(scheme))
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	backend := &LeaseBackend{
+		Client:    fakeClient,
+		Namespace: "ns1",
+	}
+	testBackend(t, backend)
+
+	var leases coordinationv1.LeaseList
+	require.NoError(t, fakeClient.List(context.TODO(), &leases))
+	require.Len(t, leases.Items, 2)
+
+	lease := &coordinationv1.Lease{}
+	require.NoError(t, fakeClient.Get(context.TODO(), client.ObjectKey{Namespace: "ns1", Name: backend.leaseName("key-1")}, lease))
+	assert.Equal(t, "replica-b", ptr.Deref(lease.Spec.HolderIdentity, ""))
+	assert.Equal(t, int32(60), ptr.Deref(lease.Spec.LeaseDurationSeconds, 0))
+	assert.Equal(t, int32(1), ptr.Deref(lease.Spec.LeaseTransitions, 0))
+	assert.Regexp(t, "^issuer-lib-[0-9a-f]{32}$", lease.Name)
+}
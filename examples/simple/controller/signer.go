@@ -47,15 +47,20 @@ import (
 
 // +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
-type Signer struct{}
+type Signer struct {
+	// ClusterResourceNamespace is the namespace in which the resources referenced
+	// by SimpleClusterIssuers are found.
+	ClusterResourceNamespace string
+}
 
 func (s Signer) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
 	return (&controllers.CombinedController{
 		IssuerTypes:        []v1alpha1.Issuer{&api.SimpleIssuer{}},
 		ClusterIssuerTypes: []v1alpha1.Issuer{&api.SimpleClusterIssuer{}},
 
-		FieldOwner:       "simpleissuer.testing.cert-manager.io",
-		MaxRetryDuration: 1 * time.Minute,
+		FieldOwner:               "simpleissuer.testing.cert-manager.io",
+		MaxRetryDuration:         1 * time.Minute,
+		ClusterResourceNamespace: s.ClusterResourceNamespace,
 
 		Sign:          s.Sign,
 		Check:         s.Check,
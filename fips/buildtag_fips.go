@@ -1,3 +1,5 @@
+//go:build issuerlib_fips
+
 /*
 Copyright 2023 The cert-manager Authors.
 
@@ -14,27 +16,6 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-package ssaclient
-
-import (
-	"k8s.io/apimachinery/pkg/types"
-	"sigs.k8s.io/controller-runtime/pkg/client"
-)
-
-type applyPatch struct {
-	patch []byte
-}
-
-var _ client.Patch = applyPatch{}
-
-func (p applyPatch) Data(_ client.Object) ([]byte, error) {
-	return p.patch, nil
-}
-
-func (p applyPatch) Type() types.PatchType {
-	return types.ApplyPatchType
-}
+package fips
 
-func (p applyPatch) String() string {
-	return string(p.patch)
-}
+const buildTagEnabled = true
@@ -0,0 +1,119 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fips restricts the crypto helpers that are provided by this library
+// (eg. the testing/simulator CA) to FIPS-approved algorithms and key sizes.
+//
+// Strict mode is enabled by building with the "issuerlib_fips" build tag, or at
+// runtime by calling Enable (eg. based on a command-line flag). The helpers then
+// fail requests that use algorithms or key sizes that are not approved with a
+// signer.PermanentError that lists the violations.
+//
+// This package only restricts the algorithms that are used; building a FIPS
+// 140-3 validated binary also requires a validated crypto module (eg. by setting
+// GOFIPS140 when building with Go 1.24 or later).
+package fips
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+// MinRSAKeySize is the minimum size in bits of the RSA keys that are approved.
+const MinRSAKeySize = 2048
+
+var enabled atomic.Bool
+
+func init() {
+	enabled.Store(buildTagEnabled)
+}
+
+// Enable enables strict mode. It cannot be disabled again, strict mode is
+// meant to be enabled once at startup.
+func Enable() {
+	enabled.Store(true)
+}
+
+// Enabled returns true if strict mode is enabled by the build tag or by Enable.
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// approvedSignatureAlgorithms are the signature algorithms that use an approved
+// hash function.
+var approvedSignatureAlgorithms = map[x509.SignatureAlgorithm]bool{
+	x509.SHA256WithRSA:    true,
+	x509.SHA384WithRSA:    true,
+	x509.SHA512WithRSA:    true,
+	x509.SHA256WithRSAPSS: true,
+	x509.SHA384WithRSAPSS: true,
+	x509.SHA512WithRSAPSS: true,
+	x509.ECDSAWithSHA256:  true,
+	x509.ECDSAWithSHA384:  true,
+	x509.ECDSAWithSHA512:  true,
+}
+
+// CheckPublicKey returns an error if the algorithm or the size of the public key
+// is not approved: RSA keys must be at least MinRSAKeySize bits and ECDSA keys
+// must use the P-256, P-384 or P-521 curve. Ed25519 keys are rejected, because
+// they are not supported by many validated crypto modules.
+func CheckPublicKey(publicKey crypto.PublicKey) error {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		if size := key.N.BitLen(); size < MinRSAKeySize {
+			return fmt.Errorf("RSA keys must be at least %d bits, got %d bits", MinRSAKeySize, size)
+		}
+	case *ecdsa.PublicKey:
+		switch key.Curve {
+		case elliptic.P256(), elliptic.P384(), elliptic.P521():
+		default:
+			return fmt.Errorf("ECDSA keys must use the P-256, P-384 or P-521 curve, got %s", key.Curve.Params().Name)
+		}
+	case ed25519.PublicKey:
+		return fmt.Errorf("the Ed25519 key algorithm is not allowed in FIPS mode, use an RSA or ECDSA key")
+	default:
+		return fmt.Errorf("%T keys are not allowed in FIPS mode", publicKey)
+	}
+	return nil
+}
+
+// CheckCertificateRequest returns a signer.PermanentError that lists the
+// algorithms and key sizes of the CSR that are not approved, or nil if the CSR
+// only uses approved algorithms. The check is done regardless of whether strict
+// mode is enabled.
+func CheckCertificateRequest(csr *x509.CertificateRequest) error {
+	var violations []string
+	if err := CheckPublicKey(csr.PublicKey); err != nil {
+		violations = append(violations, err.Error())
+	}
+	if !approvedSignatureAlgorithms[csr.SignatureAlgorithm] {
+		violations = append(violations, fmt.Sprintf("the signature algorithm %s is not allowed in FIPS mode", csr.SignatureAlgorithm))
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return signer.PermanentError{Err: fmt.Errorf("the request does not comply with FIPS mode: %s", strings.Join(violations, "; "))}
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fips
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+func TestCheckCertificateRequest(t *testing.T) {
+	t.Parallel()
+
+	publicKey := func(key crypto.Signer, err error) crypto.PublicKey {
+		require.NoError(t, err)
+		return key.Public()
+	}
+
+	rsa1024 := publicKey(rsa.GenerateKey(rand.Reader, 1024))
+	rsa2048 := publicKey(rsa.GenerateKey(rand.Reader, 2048))
+	ecdsaP224 := publicKey(ecdsa.GenerateKey(elliptic.P224(), rand.Reader))
+	ecdsaP256 := publicKey(ecdsa.GenerateKey(elliptic.P256(), rand.Reader))
+	ed25519Key, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	type testcase struct {
+		name               string
+		publicKey          crypto.PublicKey
+		signatureAlgorithm x509.SignatureAlgorithm
+		expectedError      string
+	}
+
+	tests := []testcase{
+		{
+			name:               "rsa-2048",
+			publicKey:          rsa2048,
+			signatureAlgorithm: x509.SHA256WithRSA,
+		},
+		{
+			name:               "ecdsa-p256",
+			publicKey:          ecdsaP256,
+			signatureAlgorithm: x509.ECDSAWithSHA256,
+		},
+		{
+			name:               "rsa-1024",
+			publicKey:          rsa1024,
+			signatureAlgorithm: x509.SHA256WithRSA,
+			expectedError:      "the request does not comply with FIPS mode: RSA keys must be at least 2048 bits, got 1024 bits",
+		},
+		{
+			name:               "ecdsa-p224",
+			publicKey:          ecdsaP224,
+			signatureAlgorithm: x509.ECDSAWithSHA256,
+			expectedError:      "the request does not comply with FIPS mode: ECDSA keys must use the P-256, P-384 or P-521 curve, got P-224",
+		},
+		{
+			name:               "ed25519",
+			publicKey:          ed25519Key,
+			signatureAlgorithm: x509.PureEd25519,
+			expectedError:      "the request does not comply with FIPS mode: the Ed25519 key algorithm is not allowed in FIPS mode, use an RSA or ECDSA key; the signature algorithm Ed25519 is not allowed in FIPS mode",
+		},
+		{
+			name:               "sha1-signature",
+			publicKey:          rsa2048,
+			signatureAlgorithm: x509.SHA1WithRSA,
+			expectedError:      "the request does not comply with FIPS mode: the signature algorithm SHA1-RSA is not allowed in FIPS mode",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := CheckCertificateRequest(&x509.CertificateRequest{
+				PublicKey:          tc.publicKey,
+				SignatureAlgorithm: tc.signatureAlgorithm,
+			})
+			if tc.expectedError == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			require.EqualError(t, err, tc.expectedError)
+			assert.True(t, errors.As(err, &signer.PermanentError{}))
+		})
+	}
+}
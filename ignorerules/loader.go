@@ -0,0 +1,182 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ignorerules
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+// ErrNotLoaded is returned by ConfigMapLoader.IgnoreCertificateRequest until the
+// rules have been loaded successfully for the first time. The request is retried
+// with backoff, so no request is processed before the rules are known.
+var ErrNotLoaded = errors.New("ignore rules have not been loaded yet")
+
+// ConfigMapLoader is a manager.Runnable that loads the ignore rules from a
+// ConfigMap and reloads them whenever the ConfigMap changes. Each key in the
+// data of the ConfigMap is the name of a rule and its value is the CEL
+// expression.
+//
+// A missing ConfigMap results in an empty set of rules. If the ConfigMap contains
+// an invalid rule, the error is logged and the previously loaded rules are kept;
+// if no rules were loaded yet, an empty set of rules is used until the ConfigMap
+// is fixed, so an invalid ConfigMap does not block all requests.
+type ConfigMapLoader struct {
+	// Client is used to watch the ConfigMap, only the ConfigMap with the
+	// configured name is watched.
+	Client kubernetes.Interface
+
+	// Namespace and Name of the ConfigMap containing the rules.
+	Namespace string
+	Name      string
+
+	// ResyncPeriod is the resync period of the ConfigMap informer, defaults to
+	// 10 minutes.
+	ResyncPeriod time.Duration
+
+	mu    sync.RWMutex
+	rules *Rules
+}
+
+var _ manager.Runnable = &ConfigMapLoader{}
+var _ manager.LeaderElectionRunnable = &ConfigMapLoader{}
+var _ signer.IgnoreCertificateRequest = (&ConfigMapLoader{}).IgnoreCertificateRequest
+
+// Start watches the ConfigMap until the context is cancelled.
+func (l *ConfigMapLoader) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("ignorerules").WithValues("configmap", types.NamespacedName{Namespace: l.Namespace, Name: l.Name})
+
+	resyncPeriod := l.ResyncPeriod
+	if resyncPeriod == 0 {
+		resyncPeriod = 10 * time.Minute
+	}
+
+	fieldSelector := fields.OneTermEqualSelector("metadata.name", l.Name).String()
+	configMaps := l.Client.CoreV1().ConfigMaps(l.Namespace)
+
+	_, informer := cache.NewInformerWithOptions(cache.InformerOptions{
+		ListerWatcher: &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				options.FieldSelector = fieldSelector
+				return configMaps.List(ctx, options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				options.FieldSelector = fieldSelector
+				return configMaps.Watch(ctx, options)
+			},
+		},
+		ObjectType:   &corev1.ConfigMap{},
+		ResyncPeriod: resyncPeriod,
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				l.load(logger, obj)
+			},
+			UpdateFunc: func(_, obj interface{}) {
+				l.load(logger, obj)
+			},
+			DeleteFunc: func(interface{}) {
+				l.load(logger, nil)
+			},
+		},
+	})
+
+	go informer.Run(ctx.Done())
+
+	// The initial list of a missing ConfigMap does not trigger any event, and
+	// an invalid ConfigMap is not loaded, so the empty set of rules is set once
+	// the informer has synced if no rules were loaded.
+	if cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		l.mu.Lock()
+		if l.rules == nil {
+			logger.Info("ConfigMap not found or invalid, no requests are ignored")
+			l.rules = &Rules{}
+		}
+		l.mu.Unlock()
+	}
+
+	<-ctx.Done()
+	return nil
+}
+
+// NeedLeaderElection returns false, the rules are loaded by all replicas.
+func (l *ConfigMapLoader) NeedLeaderElection() bool {
+	return false
+}
+
+func (l *ConfigMapLoader) load(logger logr.Logger, obj interface{}) {
+	var expressions map[string]string
+	if configMap, ok := obj.(*corev1.ConfigMap); ok {
+		if configMap.Name != l.Name {
+			return
+		}
+		expressions = configMap.Data
+	}
+
+	rules, err := Compile(expressions)
+	if err != nil {
+		logger.Error(err, "Invalid ignore rules, keeping the previously loaded rules")
+		return
+	}
+
+	logger.Info("Loaded ignore rules", "rules", rules.Len())
+
+	l.mu.Lock()
+	l.rules = rules
+	l.mu.Unlock()
+}
+
+// Rules returns the currently loaded rules, or nil if the rules have not been
+// loaded yet.
+func (l *ConfigMapLoader) Rules() *Rules {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.rules
+}
+
+// IgnoreCertificateRequest evaluates the currently loaded rules for the request,
+// it returns ErrNotLoaded if the rules have not been loaded yet.
+func (l *ConfigMapLoader) IgnoreCertificateRequest(
+	ctx context.Context,
+	cr signer.CertificateRequestObject,
+	issuerGvk schema.GroupVersionKind,
+	issuerName types.NamespacedName,
+) (bool, error) {
+	rules := l.Rules()
+	if rules == nil {
+		return false, ErrNotLoaded
+	}
+
+	return rules.IgnoreCertificateRequest(ctx, cr, issuerGvk, issuerName)
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ignorerules implements signer.IgnoreCertificateRequest using CEL
+// expressions, so operators can filter the requests that an issuer handles (eg.
+// by namespace, labels or SANs) without rebuilding the controller.
+//
+// Each rule is a CEL expression that must evaluate to a bool. A request is
+// ignored if any of the rules evaluates to true. The expressions have access to
+// two variables:
+//
+//	request: {
+//		kind:           "CertificateRequest" or "CertificateSigningRequest",
+//		namespace:      string (empty for Kubernetes CSRs),
+//		name:           string,
+//		labels:         map(string, string),
+//		annotations:    map(string, string),
+//		commonName:     string,
+//		dnsNames:       list(string),
+//		ipAddresses:    list(string),
+//		uris:           list(string),
+//		emailAddresses: list(string),
+//		isCA:           bool,
+//		duration:       google.protobuf.Duration,
+//	}
+//	issuerRef: {
+//		group:     string,
+//		kind:      string,
+//		namespace: string (empty for cluster-scoped issuers),
+//		name:      string,
+//	}
+//
+// For example, the following rule ignores all requests for ".internal" domains
+// that are not in the "platform" namespace:
+//
+//	request.namespace != "platform" && request.dnsNames.exists(n, n.endsWith(".internal"))
+//
+// The rules can be compiled once using Compile, or loaded from a ConfigMap and
+// reloaded whenever the ConfigMap changes using a ConfigMapLoader.
+package ignorerules
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/google/cel-go/cel"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+// Rules is a set of compiled ignore rules.
+type Rules struct {
+	rules []rule
+}
+
+type rule struct {
+	name    string
+	program cel.Program
+}
+
+var _ signer.IgnoreCertificateRequest = (&Rules{}).IgnoreCertificateRequest
+
+func newEnv() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.Variable("request", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("issuerRef", cel.MapType(cel.StringType, cel.StringType)),
+	)
+}
+
+// Compile compiles the rules, which are indexed by their name. An error is
+// returned if an expression is invalid or does not evaluate to a bool.
+func Compile(expressions map[string]string) (*Rules, error) {
+	env, err := newEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(expressions))
+	for name := range expressions {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	rules := make([]rule, 0, len(names))
+	for _, name := range names {
+		ast, issues := env.Compile(expressions[name])
+		if issues != nil && issues.Err() != nil {
+			return nil, fmt.Errorf("invalid rule %q: %w", name, issues.Err())
+		}
+
+		if ast.OutputType() != cel.BoolType {
+			return nil, fmt.Errorf("invalid rule %q: expression must evaluate to a bool, not %s", name, ast.OutputType())
+		}
+
+		program, err := env.Program(ast)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule %q: %w", name, err)
+		}
+
+		rules = append(rules, rule{name: name, program: program})
+	}
+
+	return &Rules{rules: rules}, nil
+}
+
+// Len returns the number of rules.
+func (r *Rules) Len() int {
+	return len(r.rules)
+}
+
+// IgnoreCertificateRequest returns true if any of the rules evaluates to true
+// for the request. An error is returned if a rule fails to evaluate (eg. because
+// it accesses a label that the request doesn't have, use the "in" operator to
+// check for optional keys).
+func (r *Rules) IgnoreCertificateRequest(
+	ctx context.Context,
+	cr signer.CertificateRequestObject,
+	issuerGvk schema.GroupVersionKind,
+	issuerName types.NamespacedName,
+) (bool, error) {
+	if len(r.rules) == 0 {
+		return false, nil
+	}
+
+	activation, err := newActivation(cr, issuerGvk, issuerName)
+	if err != nil {
+		return false, err
+	}
+
+	for _, rule := range r.rules {
+		result, _, err := rule.program.ContextEval(ctx, activation)
+		if err != nil {
+			return false, fmt.Errorf("failed to evaluate rule %q: %w", rule.name, err)
+		}
+
+		if ignore, ok := result.Value().(bool); ok && ignore {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func newActivation(
+	cr signer.CertificateRequestObject,
+	issuerGvk schema.GroupVersionKind,
+	issuerName types.NamespacedName,
+) (map[string]any, error) {
+	template, duration, _, err := cr.GetRequest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse request: %w", err)
+	}
+
+	// CertificateRequests are namespaced, Kubernetes CSRs are cluster-scoped.
+	kind := "CertificateRequest"
+	if cr.GetNamespace() == "" {
+		kind = "CertificateSigningRequest"
+	}
+
+	ipAddresses := make([]string, 0, len(template.IPAddresses))
+	for _, ip := range template.IPAddresses {
+		ipAddresses = append(ipAddresses, ip.String())
+	}
+
+	uris := make([]string, 0, len(template.URIs))
+	for _, uri := range template.URIs {
+		uris = append(uris, uri.String())
+	}
+
+	return map[string]any{
+		"request": map[string]any{
+			"kind":           kind,
+			"namespace":      cr.GetNamespace(),
+			"name":           cr.GetName(),
+			"labels":         nonNil(cr.GetLabels()),
+			"annotations":    nonNil(cr.GetAnnotations()),
+			"commonName":     template.Subject.CommonName,
+			"dnsNames":       nonNilList(template.DNSNames),
+			"ipAddresses":    ipAddresses,
+			"uris":           uris,
+			"emailAddresses": nonNilList(template.EmailAddresses),
+			"isCA":           template.BasicConstraintsValid && template.IsCA,
+			"duration":       duration,
+		},
+		"issuerRef": map[string]string{
+			"group":     issuerGvk.Group,
+			"kind":      issuerGvk.Kind,
+			"namespace": issuerName.Namespace,
+			"name":      issuerName.Name,
+		},
+	}, nil
+}
+
+func nonNil(m map[string]string) map[string]string {
+	if m == nil {
+		return map[string]string{}
+	}
+	return m
+}
+
+func nonNilList(l []string) []string {
+	if l == nil {
+		return []string{}
+	}
+	return l
+}
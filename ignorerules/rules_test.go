@@ -0,0 +1,310 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ignorerules
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+func testRequest(t *testing.T, namespace string, labels map[string]string, dnsNames ...string) signer.CertificateRequestObject {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: "test"},
+		DNSNames: dnsNames,
+	}, key)
+	require.NoError(t, err)
+
+	return signer.CertificateRequestObjectFromCertificateRequest(&cmapi.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      "cr1",
+			Labels:    labels,
+		},
+		Spec: cmapi.CertificateRequestSpec{
+			Request:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER}),
+			Duration: &metav1.Duration{Duration: time.Hour},
+		},
+	})
+}
+
+var (
+	testIssuerGvk  = schema.GroupVersionKind{Group: "testing.cert-manager.io", Version: "api", Kind: "TestIssuer"}
+	testIssuerName = types.NamespacedName{Namespace: "ns1", Name: "issuer1"}
+)
+
+func TestCompile(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name          string
+		expressions   map[string]string
+		expectedError string
+	}
+
+	tests := []testCase{
+		{
+			name:        "no-rules",
+			expressions: nil,
+		},
+		{
+			name: "valid-rules",
+			expressions: map[string]string{
+				"namespace": `request.namespace == "kube-system"`,
+				"issuer":    `issuerRef.kind == "TestIssuer" && issuerRef.name.startsWith("test-")`,
+			},
+		},
+		{
+			name: "syntax-error",
+			expressions: map[string]string{
+				"broken": `request.namespace ==`,
+			},
+			expectedError: `invalid rule "broken": `,
+		},
+		{
+			name: "unknown-variable",
+			expressions: map[string]string{
+				"unknown": `certificate.namespace == "ns1"`,
+			},
+			expectedError: `invalid rule "unknown": `,
+		},
+		{
+			name: "not-a-bool",
+			expressions: map[string]string{
+				"string": `issuerRef.name`,
+			},
+			expectedError: `invalid rule "string": expression must evaluate to a bool, not string`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			rules, err := Compile(tc.expressions)
+			if tc.expectedError != "" {
+				require.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, len(tc.expressions), rules.Len())
+		})
+	}
+}
+
+func TestRulesIgnoreCertificateRequest(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name           string
+		expressions    map[string]string
+		request        signer.CertificateRequestObject
+		expectedIgnore bool
+		expectedError  string
+	}
+
+	tests := []testCase{
+		{
+			name:           "no-rules",
+			request:        testRequest(t, "ns1", nil, "example.com"),
+			expectedIgnore: false,
+		},
+		{
+			name: "namespace-matches",
+			expressions: map[string]string{
+				"namespace": `request.namespace == "ns1" && request.kind == "CertificateRequest"`,
+			},
+			request:        testRequest(t, "ns1", nil, "example.com"),
+			expectedIgnore: true,
+		},
+		{
+			name: "namespace-does-not-match",
+			expressions: map[string]string{
+				"namespace": `request.namespace == "ns2"`,
+			},
+			request:        testRequest(t, "ns1", nil, "example.com"),
+			expectedIgnore: false,
+		},
+		{
+			name: "label-matches",
+			expressions: map[string]string{
+				"label": `"team" in request.labels && request.labels["team"] == "a"`,
+			},
+			request:        testRequest(t, "ns1", map[string]string{"team": "a"}, "example.com"),
+			expectedIgnore: true,
+		},
+		{
+			name: "san-pattern-matches",
+			expressions: map[string]string{
+				"namespace": `request.namespace == "ns2"`,
+				"san":       `request.dnsNames.exists(n, n.endsWith(".internal"))`,
+			},
+			request:        testRequest(t, "ns1", nil, "example.com", "db.internal"),
+			expectedIgnore: true,
+		},
+		{
+			name: "issuer-and-duration",
+			expressions: map[string]string{
+				"issuer": `issuerRef.namespace == "ns1" && issuerRef.name == "issuer1" && request.duration > duration("30m")`,
+			},
+			request:        testRequest(t, "ns1", nil, "example.com"),
+			expectedIgnore: true,
+		},
+		{
+			name: "missing-label",
+			expressions: map[string]string{
+				"label": `request.labels["team"] == "a"`,
+			},
+			request:       testRequest(t, "ns1", nil, "example.com"),
+			expectedError: `failed to evaluate rule "label": no such key: team`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			rules, err := Compile(tc.expressions)
+			require.NoError(t, err)
+
+			ignore, err := rules.IgnoreCertificateRequest(context.TODO(), tc.request, testIssuerGvk, testIssuerName)
+			if tc.expectedError != "" {
+				require.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedIgnore, ignore)
+		})
+	}
+}
+
+func TestConfigMapLoader(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	clientset := fake.NewSimpleClientset()
+	loader := &ConfigMapLoader{
+		Client:    clientset,
+		Namespace: "issuer-system",
+		Name:      "ignore-rules",
+	}
+
+	request := testRequest(t, "ns1", nil, "example.com")
+	ignore := func() bool {
+		ignore, err := loader.IgnoreCertificateRequest(ctx, request, testIssuerGvk, testIssuerName)
+		return err == nil && ignore
+	}
+
+	_, err := loader.IgnoreCertificateRequest(ctx, request, testIssuerGvk, testIssuerName)
+	require.ErrorIs(t, err, ErrNotLoaded)
+
+	go func() {
+		_ = loader.Start(ctx)
+	}()
+
+	// A missing ConfigMap results in an empty set of rules.
+	require.Eventually(t, func() bool { return loader.Rules() != nil }, 5*time.Second, 10*time.Millisecond)
+	assert.False(t, ignore())
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "issuer-system", Name: "ignore-rules"},
+		Data: map[string]string{
+			"namespace": `request.namespace == "ns1"`,
+		},
+	}
+	configMap, err = clientset.CoreV1().ConfigMaps("issuer-system").Create(ctx, configMap, metav1.CreateOptions{})
+	require.NoError(t, err)
+	require.Eventually(t, ignore, 5*time.Second, 10*time.Millisecond)
+
+	// Invalid rules are not loaded, the previous rules are kept.
+	configMap.Data = map[string]string{"broken": `request.namespace ==`}
+	configMap, err = clientset.CoreV1().ConfigMaps("issuer-system").Update(ctx, configMap, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, ignore())
+
+	configMap.Data = map[string]string{"namespace": `request.namespace == "ns2"`}
+	_, err = clientset.CoreV1().ConfigMaps("issuer-system").Update(ctx, configMap, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return !ignore() }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestConfigMapLoaderInvalidAtStartup(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "issuer-system", Name: "ignore-rules"},
+		Data:       map[string]string{"broken": `request.namespace ==`},
+	}
+	clientset := fake.NewSimpleClientset(configMap)
+	loader := &ConfigMapLoader{
+		Client:    clientset,
+		Namespace: "issuer-system",
+		Name:      "ignore-rules",
+	}
+
+	request := testRequest(t, "ns1", nil, "example.com")
+
+	go func() {
+		_ = loader.Start(ctx)
+	}()
+
+	// An invalid ConfigMap at startup results in an empty set of rules instead
+	// of blocking all requests.
+	require.Eventually(t, func() bool { return loader.Rules() != nil }, 5*time.Second, 10*time.Millisecond)
+	ignore, err := loader.IgnoreCertificateRequest(ctx, request, testIssuerGvk, testIssuerName)
+	require.NoError(t, err)
+	assert.False(t, ignore)
+
+	// The rules are loaded once the ConfigMap is fixed.
+	configMap.Data = map[string]string{"namespace": `request.namespace == "ns1"`}
+	_, err = clientset.CoreV1().ConfigMaps("issuer-system").Update(ctx, configMap, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		ignore, err := loader.IgnoreCertificateRequest(ctx, request, testIssuerGvk, testIssuerName)
+		return err == nil && ignore
+	}, 5*time.Second, 10*time.Millisecond)
+}
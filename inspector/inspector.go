@@ -0,0 +1,393 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package inspector serves a read-only web UI that gives operators a quick view
+// of the issuers and requests of the controller, without Grafana or kubectl. It
+// lists the issuers with their readiness and last error, the CertificateRequests
+// that are still pending and the CertificateRequests that failed recently.
+//
+// The data is read using the configured client, which is usually the cached
+// client of the manager, so the UI does not cause additional load on the API
+// server. The same data is served as JSON at "/overview.json".
+//
+// The UI does not authenticate its users: bind it to localhost (eg. "127.0.0.1:8090")
+// and use "kubectl port-forward" to access it.
+package inspector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/conditions"
+)
+
+// Overview is the data that is shown by the UI.
+type Overview struct {
+	Issuers         []IssuerSummary  `json:"issuers"`
+	PendingRequests []RequestSummary `json:"pendingRequests"`
+	RecentFailures  []RequestSummary `json:"recentFailures"`
+}
+
+// IssuerSummary contains the readiness of an issuer. Reason and Message are
+// those of the Ready condition, the Message contains the last error of an
+// issuer that is not ready.
+type IssuerSummary struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+
+	Ready              bool      `json:"ready"`
+	Reason             string    `json:"reason,omitempty"`
+	Message            string    `json:"message,omitempty"`
+	LastTransitionTime time.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// RequestSummary contains the state of a CertificateRequest. Reason and
+// Message are those of the Ready condition.
+type RequestSummary struct {
+	Namespace  string `json:"namespace"`
+	Name       string `json:"name"`
+	IssuerKind string `json:"issuerKind"`
+	IssuerName string `json:"issuerName"`
+
+	Reason             string    `json:"reason,omitempty"`
+	Message            string    `json:"message,omitempty"`
+	CreationTime       time.Time `json:"creationTime"`
+	LastTransitionTime time.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// Handler is a read-only http.Handler that serves the UI.
+type Handler struct {
+	IssuerTypes        []v1alpha1.Issuer
+	ClusterIssuerTypes []v1alpha1.Issuer
+
+	// Client is used to read the issuers and CertificateRequests.
+	Client client.Reader
+	// Scheme is used to look up the list types of the issuer types.
+	Scheme *runtime.Scheme
+
+	// MaxRecentFailures is the maximum number of failed CertificateRequests that
+	// are shown, defaults to 20.
+	MaxRecentFailures int
+}
+
+var _ http.Handler = &Handler{}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.URL.Path != "/" && r.URL.Path != "/overview.json" {
+		http.NotFound(w, r)
+		return
+	}
+
+	overview, err := h.Overview(r.Context())
+	if err != nil {
+		log.FromContext(r.Context()).Error(err, "Failed to get the overview")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	if r.URL.Path == "/overview.json" {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(overview)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = overviewTemplate.Execute(w, overview)
+}
+
+// Overview returns the issuers, the pending CertificateRequests and the
+// recently failed CertificateRequests of the issuer types.
+func (h *Handler) Overview(ctx context.Context) (*Overview, error) {
+	overview := &Overview{
+		Issuers:         []IssuerSummary{},
+		PendingRequests: []RequestSummary{},
+		RecentFailures:  []RequestSummary{},
+	}
+
+	var issuerKinds []schema.GroupKind
+	for _, issuerType := range append(slices.Clone(h.IssuerTypes), h.ClusterIssuerTypes...) {
+		issuers, groupKind, err := h.listIssuers(ctx, issuerType)
+		if err != nil {
+			return nil, err
+		}
+		issuerKinds = append(issuerKinds, groupKind)
+
+		for _, issuer := range issuers {
+			overview.Issuers = append(overview.Issuers, summarizeIssuer(groupKind.Kind, issuer))
+		}
+	}
+
+	var crs cmapi.CertificateRequestList
+	if err := h.Client.List(ctx, &crs); err != nil {
+		return nil, fmt.Errorf("failed to list CertificateRequests: %w", err)
+	}
+
+	for i := range crs.Items {
+		cr := &crs.Items[i]
+		issuerKind, ok := matchIssuerKind(issuerKinds, cr.Spec.IssuerRef)
+		if !ok {
+			continue
+		}
+
+		readyCondition := readyCondition(cr)
+		summary := RequestSummary{
+			Namespace:    cr.Namespace,
+			Name:         cr.Name,
+			IssuerKind:   issuerKind,
+			IssuerName:   cr.Spec.IssuerRef.Name,
+			CreationTime: cr.CreationTimestamp.Time,
+		}
+		if readyCondition != nil {
+			summary.Reason = readyCondition.Reason
+			summary.Message = readyCondition.Message
+			if readyCondition.LastTransitionTime != nil {
+				summary.LastTransitionTime = readyCondition.LastTransitionTime.Time
+			}
+		}
+
+		switch {
+		case readyCondition != nil && readyCondition.Status == cmmeta.ConditionTrue:
+			// issued
+		case summary.Reason == cmapi.CertificateRequestReasonFailed:
+			overview.RecentFailures = append(overview.RecentFailures, summary)
+		case summary.Reason == cmapi.CertificateRequestReasonDenied:
+			// denied requests will never be issued, they are not pending
+		default:
+			overview.PendingRequests = append(overview.PendingRequests, summary)
+		}
+	}
+
+	slices.SortFunc(overview.Issuers, func(a, b IssuerSummary) int {
+		return strings.Compare(a.Kind+"/"+a.Namespace+"/"+a.Name, b.Kind+"/"+b.Namespace+"/"+b.Name)
+	})
+	slices.SortFunc(overview.PendingRequests, func(a, b RequestSummary) int {
+		return a.CreationTime.Compare(b.CreationTime)
+	})
+	slices.SortFunc(overview.RecentFailures, func(a, b RequestSummary) int {
+		return b.LastTransitionTime.Compare(a.LastTransitionTime)
+	})
+
+	maxRecentFailures := h.MaxRecentFailures
+	if maxRecentFailures == 0 {
+		maxRecentFailures = 20
+	}
+	if len(overview.RecentFailures) > maxRecentFailures {
+		overview.RecentFailures = overview.RecentFailures[:maxRecentFailures]
+	}
+
+	return overview, nil
+}
+
+// listIssuers returns the issuers of the issuer type, together with the group
+// and kind of the issuer type.
+func (h *Handler) listIssuers(ctx context.Context, issuerType v1alpha1.Issuer) ([]v1alpha1.Issuer, schema.GroupKind, error) {
+	gvk, err := apiutil.GVKForObject(issuerType, h.Scheme)
+	if err != nil {
+		return nil, schema.GroupKind{}, err
+	}
+
+	listObject, err := h.Scheme.New(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err != nil {
+		return nil, schema.GroupKind{}, err
+	}
+
+	list, ok := listObject.(client.ObjectList)
+	if !ok {
+		return nil, schema.GroupKind{}, fmt.Errorf("%T is not a list", listObject)
+	}
+
+	if err := h.Client.List(ctx, list); err != nil {
+		return nil, schema.GroupKind{}, fmt.Errorf("failed to list %s: %w", gvk.Kind, err)
+	}
+
+	objects, err := meta.ExtractList(list)
+	if err != nil {
+		return nil, schema.GroupKind{}, err
+	}
+
+	issuers := make([]v1alpha1.Issuer, 0, len(objects))
+	for _, object := range objects {
+		if issuer, ok := object.(v1alpha1.Issuer); ok {
+			issuers = append(issuers, issuer)
+		}
+	}
+
+	return issuers, gvk.GroupKind(), nil
+}
+
+// matchIssuerKind returns the kind of the issuer type that the issuer reference
+// points to. Like the CertificateRequest controller, a reference without a kind
+// matches the first issuer type of the group.
+func matchIssuerKind(issuerKinds []schema.GroupKind, issuerRef cmmeta.ObjectReference) (string, bool) {
+	for _, groupKind := range issuerKinds {
+		if issuerRef.Group == groupKind.Group && (issuerRef.Kind == "" || issuerRef.Kind == groupKind.Kind) {
+			return groupKind.Kind, true
+		}
+	}
+	return "", false
+}
+
+func summarizeIssuer(kind string, issuer v1alpha1.Issuer) IssuerSummary {
+	summary := IssuerSummary{
+		Kind:      kind,
+		Namespace: issuer.GetNamespace(),
+		Name:      issuer.GetName(),
+	}
+
+	readyCondition := conditions.GetIssuerStatusCondition(issuer.GetStatus().Conditions, cmapi.IssuerConditionReady)
+	if readyCondition == nil {
+		return summary
+	}
+
+	summary.Ready = issuer.GetStatus().IsReady(issuer.GetGeneration())
+	summary.Reason = readyCondition.Reason
+	summary.Message = readyCondition.Message
+	if readyCondition.LastTransitionTime != nil {
+		summary.LastTransitionTime = readyCondition.LastTransitionTime.Time
+	}
+
+	return summary
+}
+
+func readyCondition(cr *cmapi.CertificateRequest) *cmapi.CertificateRequestCondition {
+	for i := range cr.Status.Conditions {
+		if cr.Status.Conditions[i].Type == cmapi.CertificateRequestConditionReady {
+			return &cr.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+var overviewTemplate = template.Must(template.New("overview").Funcs(template.FuncMap{
+	"age": func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return time.Since(t).Round(time.Second).String()
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>issuer-lib inspector</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+.ready { color: #080; }
+.not-ready { color: #b00; }
+</style>
+</head>
+<body>
+<h1>Issuers</h1>
+<table>
+<tr><th>Kind</th><th>Namespace</th><th>Name</th><th>Ready</th><th>Reason</th><th>Message</th><th>Since</th></tr>
+{{- range .Issuers }}
+<tr><td>{{ .Kind }}</td><td>{{ .Namespace }}</td><td>{{ .Name }}</td>
+<td class="{{ if .Ready }}ready{{ else }}not-ready{{ end }}">{{ .Ready }}</td>
+<td>{{ .Reason }}</td><td>{{ .Message }}</td><td>{{ age .LastTransitionTime }}</td></tr>
+{{- end }}
+</table>
+<h1>Pending requests</h1>
+<table>
+<tr><th>Namespace</th><th>Name</th><th>Issuer</th><th>Reason</th><th>Message</th><th>Age</th></tr>
+{{- range .PendingRequests }}
+<tr><td>{{ .Namespace }}</td><td>{{ .Name }}</td><td>{{ .IssuerKind }}/{{ .IssuerName }}</td>
+<td>{{ .Reason }}</td><td>{{ .Message }}</td><td>{{ age .CreationTime }}</td></tr>
+{{- end }}
+</table>
+<h1>Recent failures</h1>
+<table>
+<tr><th>Namespace</th><th>Name</th><th>Issuer</th><th>Message</th><th>Failed</th></tr>
+{{- range .RecentFailures }}
+<tr><td>{{ .Namespace }}</td><td>{{ .Name }}</td><td>{{ .IssuerKind }}/{{ .IssuerName }}</td>
+<td>{{ .Message }}</td><td>{{ age .LastTransitionTime }} ago</td></tr>
+{{- end }}
+</table>
+</body>
+</html>
+`))
+
+// Server is a manager.Runnable that serves the Handler over HTTP.
+type Server struct {
+	// BindAddress is the address the server listens on, eg. "127.0.0.1:8090".
+	BindAddress string
+
+	Handler *Handler
+}
+
+var _ manager.Runnable = &Server{}
+var _ manager.LeaderElectionRunnable = &Server{}
+
+// Start serves the UI until the context is cancelled.
+func (s *Server) Start(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.BindAddress)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{
+		Handler:           s.Handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext: func(net.Listener) context.Context {
+			return ctx
+		},
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// NeedLeaderElection returns false, all replicas serve the UI.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
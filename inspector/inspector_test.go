@@ -0,0 +1,169 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inspector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	cmgen "github.com/cert-manager/cert-manager/test/unit/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+	"github.com/cert-manager/issuer-lib/internal/testapi/testutil"
+)
+
+func testRequest(name string, issuerKind string, transitionTime time.Time, status cmmeta.ConditionStatus, reason string) *cmapi.CertificateRequest {
+	return cmgen.CertificateRequest(
+		name,
+		cmgen.SetCertificateRequestNamespace("ns1"),
+		cmgen.SetCertificateRequestIssuer(cmmeta.ObjectReference{
+			Group: api.SchemeGroupVersion.Group,
+			Kind:  issuerKind,
+			Name:  "issuer-1",
+		}),
+		cmgen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+			Type:               cmapi.CertificateRequestConditionReady,
+			Status:             status,
+			Reason:             reason,
+			Message:            name + " " + reason,
+			LastTransitionTime: &metav1.Time{Time: transitionTime},
+		}),
+	)
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	fakeClock := clocktesting.NewFakeClock(time.Now().Truncate(time.Second))
+	now := fakeClock.Now()
+
+	readyIssuer := testutil.TestIssuer(
+		"issuer-1",
+		testutil.SetTestIssuerNamespace("ns1"),
+		testutil.SetTestIssuerStatusCondition(
+			fakeClock,
+			cmapi.IssuerConditionReady,
+			cmmeta.ConditionTrue,
+			v1alpha1.IssuerConditionReasonChecked,
+			"Succeeded checking the issuer",
+		),
+	)
+
+	failingClusterIssuer := testutil.TestClusterIssuer(
+		"cluster-issuer-1",
+		testutil.SetTestClusterIssuerStatusCondition(
+			fakeClock,
+			cmapi.IssuerConditionReady,
+			cmmeta.ConditionFalse,
+			v1alpha1.IssuerConditionReasonFailed,
+			"<b>CA is unreachable</b>",
+		),
+	)
+
+	otherIssuerRequest := testRequest("other", "", now, cmmeta.ConditionFalse, cmapi.CertificateRequestReasonFailed)
+	otherIssuerRequest.Spec.IssuerRef.Group = "other.example.com"
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, api.AddToScheme(scheme))
+	require.NoError(t, cmapi.AddToScheme(scheme))
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			readyIssuer,
+			failingClusterIssuer,
+			testRequest("issued", "TestIssuer", now, cmmeta.ConditionTrue, cmapi.CertificateRequestReasonIssued),
+			testRequest("pending", "", now, cmmeta.ConditionFalse, cmapi.CertificateRequestReasonPending),
+			testRequest("denied", "TestIssuer", now, cmmeta.ConditionFalse, cmapi.CertificateRequestReasonDenied),
+			testRequest("failed-old", "TestClusterIssuer", now.Add(-time.Hour), cmmeta.ConditionFalse, cmapi.CertificateRequestReasonFailed),
+			testRequest("failed-new", "TestClusterIssuer", now, cmmeta.ConditionFalse, cmapi.CertificateRequestReasonFailed),
+			testRequest("failed-oldest", "TestIssuer", now.Add(-2*time.Hour), cmmeta.ConditionFalse, cmapi.CertificateRequestReasonFailed),
+			otherIssuerRequest,
+		).
+		Build()
+
+	handler := &Handler{
+		IssuerTypes:        []v1alpha1.Issuer{&api.TestIssuer{}},
+		ClusterIssuerTypes: []v1alpha1.Issuer{&api.TestClusterIssuer{}},
+		Client:             fakeClient,
+		Scheme:             scheme,
+		MaxRecentFailures:  2,
+	}
+
+	overview, err := handler.Overview(context.TODO())
+	require.NoError(t, err)
+
+	require.Len(t, overview.Issuers, 2)
+	assert.Equal(t, IssuerSummary{
+		Kind:               "TestClusterIssuer",
+		Name:               "cluster-issuer-1",
+		Ready:              false,
+		Reason:             v1alpha1.IssuerConditionReasonFailed,
+		Message:            "<b>CA is unreachable</b>",
+		LastTransitionTime: now,
+	}, overview.Issuers[0])
+	assert.Equal(t, "TestIssuer", overview.Issuers[1].Kind)
+	assert.True(t, overview.Issuers[1].Ready)
+
+	// A reference without a kind matches the first issuer type of the group.
+	require.Len(t, overview.PendingRequests, 1)
+	assert.Equal(t, "pending", overview.PendingRequests[0].Name)
+	assert.Equal(t, "TestIssuer", overview.PendingRequests[0].IssuerKind)
+
+	// The most recent failures are shown first, up to MaxRecentFailures.
+	var failures []string
+	for _, failure := range overview.RecentFailures {
+		failures = append(failures, failure.Name)
+	}
+	assert.Equal(t, []string{"failed-new", "failed-old"}, failures)
+
+	get := func(method string, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec
+	}
+
+	rec := get(http.MethodGet, "/")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), "cluster-issuer-1")
+	assert.Contains(t, rec.Body.String(), "failed-new")
+	// Messages are escaped.
+	assert.Contains(t, rec.Body.String(), "&lt;b&gt;CA is unreachable&lt;/b&gt;")
+
+	rec = get(http.MethodGet, "/overview.json")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var decoded Overview
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &decoded))
+	assert.Len(t, decoded.Issuers, 2)
+	assert.Len(t, decoded.PendingRequests, 1)
+
+	assert.Equal(t, http.StatusNotFound, get(http.MethodGet, "/unknown").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, get(http.MethodPost, "/").Code)
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package eventbus contains an in-process publish/subscribe bus that delivers
+// events to subscribers asynchronously, so that slow subscribers do not block
+// the publishers.
+package eventbus
+
+import (
+	"context"
+	"sync"
+)
+
+// Bus delivers the published events to all subscribers. Each subscriber has a
+// buffer of BufferSize events that are delivered in order by a separate
+// goroutine; events that do not fit in the buffer are dropped. A nil *Bus
+// drops all events.
+type Bus[T any] struct {
+	bufferSize int
+	onDrop     func()
+
+	mu          sync.RWMutex
+	subscribers map[*subscriber[T]]struct{}
+}
+
+type subscriber[T any] struct {
+	events  chan T
+	handler func(T)
+}
+
+// New returns a Bus with the given buffer size per subscriber. The optional
+// onDrop function is called for every event that is dropped because the
+// buffer of a subscriber is full.
+func New[T any](bufferSize int, onDrop func()) *Bus[T] {
+	return &Bus[T]{
+		bufferSize:  bufferSize,
+		onDrop:      onDrop,
+		subscribers: map[*subscriber[T]]struct{}{},
+	}
+}
+
+// Subscribe calls the handler for every event that is published until the
+// context is cancelled. The handler is called sequentially, in the order in
+// which the events were published.
+func (b *Bus[T]) Subscribe(ctx context.Context, handler func(T)) {
+	s := &subscriber[T]{
+		events:  make(chan T, b.bufferSize),
+		handler: handler,
+	}
+
+	b.mu.Lock()
+	b.subscribers[s] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		defer func() {
+			b.mu.Lock()
+			delete(b.subscribers, s)
+			b.mu.Unlock()
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event := <-s.events:
+				s.handler(event)
+			}
+		}
+	}()
+}
+
+// HasSubscribers returns true if the bus has at least one subscriber, it can be
+// used to skip building events that nobody would receive.
+func (b *Bus[T]) HasSubscribers() bool {
+	if b == nil {
+		return false
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return len(b.subscribers) > 0
+}
+
+// Publish delivers the event to all subscribers without blocking.
+func (b *Bus[T]) Publish(event T) {
+	if b == nil {
+		return
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for s := range b.subscribers {
+		select {
+		case s.events <- event:
+		default:
+			if b.onDrop != nil {
+				b.onDrop()
+			}
+		}
+	}
+}
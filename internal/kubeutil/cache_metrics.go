@@ -0,0 +1,34 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeutil
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// CacheEntries is the number of entries in the in-memory caches of the
+// controllers, per cache. The caches update it with deltas, so the entries of
+// multiple instances of a cache are summed.
+var CacheEntries = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "issuer_lib_cache_entries",
+	Help: "Current number of entries in the in-memory caches of the controllers.",
+}, []string{"cache"})
+
+func init() {
+	metrics.Registry.MustRegister(CacheEntries)
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeutil
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+var linkedResourceStaleIndexEntries = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "issuer_lib_linked_resource_stale_index_entries",
+	Help: "Number of resources that link to a resource that does not exist in the cache, as observed by the last resync.",
+}, []string{"resource", "linked_resource"})
+
+func init() {
+	metrics.Registry.MustRegister(linkedResourceStaleIndexEntries)
+}
+
+// ResyncSource returns a source.Source that periodically enqueues the resources
+// that link to the resources of the linkedType that pass the filter (eg. the
+// requests of all ready issuers). A nil filter passes all linked resources.
+//
+// The informers already resume their watches using bookmarks after a watch
+// restart, but an event that is missed or filtered out (eg. an issuer becoming
+// ready while the request was in a long backoff) would otherwise leave the
+// linked resources waiting until their next backoff. The resync also counts the
+// resources that link to a resource that does not exist in the cache, in the
+// issuer_lib_linked_resource_stale_index_entries metric.
+func (r *LinkedResourceHandler) ResyncSource(
+	linkedType client.Object,
+	period time.Duration,
+	filter func(obj client.Object) bool,
+) source.Source {
+	return &linkedResourceResync{
+		handler:    r,
+		linkedType: linkedType,
+		period:     period,
+		filter:     filter,
+	}
+}
+
+type linkedResourceResync struct {
+	handler    *LinkedResourceHandler
+	linkedType client.Object
+	period     time.Duration
+	filter     func(obj client.Object) bool
+}
+
+var _ source.Source = &linkedResourceResync{}
+
+func (s *linkedResourceResync) String() string {
+	return fmt.Sprintf("LinkedResourceResync: %s -> %s", s.linkedType.GetObjectKind().GroupVersionKind().Kind, s.handler.objType.GetObjectKind().GroupVersionKind().Kind)
+}
+
+// Start implements Source and should only be called by the Controller. The first
+// resync happens one period after the caches have synced, the initial list of the
+// informers already enqueues all resources.
+func (s *linkedResourceResync) Start(ctx context.Context, queue workqueue.TypedRateLimitingInterface[reconcile.Request]) error {
+	go func() {
+		if !s.handler.cache.WaitForCacheSync(ctx) {
+			return
+		}
+
+		ticker := time.NewTicker(s.period)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if err := s.resync(ctx, queue); err != nil {
+				s.handler.logger.Error(err, "Failed to resync linked resources", "source", s.String())
+			}
+		}
+	}()
+
+	return nil
+}
+
+// resync enqueues the resources that link to the linked resources that pass the
+// filter, and updates the stale index entries metric.
+func (s *linkedResourceResync) resync(ctx context.Context, queue workqueue.TypedRateLimitingInterface[reconcile.Request]) error {
+	linkedGvk := s.linkedType.GetObjectKind().GroupVersionKind()
+	linkedList, err := NewListObject(s.handler.scheme, linkedGvk)
+	if err != nil {
+		return err
+	}
+	if err := s.handler.cache.List(ctx, linkedList); err != nil {
+		return err
+	}
+
+	linkedIds := map[string]struct{}{}
+	reqs := map[reconcile.Request]struct{}{}
+	if err := apimeta.EachListItem(linkedList, func(object runtime.Object) error {
+		linkedObj, ok := object.(client.Object)
+		if !ok {
+			return fmt.Errorf("object %T cannot be converted to client.Object", object)
+		}
+
+		linkedIds[fmt.Sprintf("%s/%s", linkedObj.GetNamespace(), linkedObj.GetName())] = struct{}{}
+		if s.filter == nil || s.filter(linkedObj) {
+			s.handler.mapAndEnqueue(ctx, queue, linkedObj, reqs)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	objGvk := s.handler.objType.GetObjectKind().GroupVersionKind()
+	objList, err := NewListObject(s.handler.scheme, objGvk)
+	if err != nil {
+		return err
+	}
+	if err := s.handler.cache.List(ctx, objList); err != nil {
+		return err
+	}
+
+	stale := 0
+	if err := apimeta.EachListItem(objList, func(object runtime.Object) error {
+		obj, ok := object.(client.Object)
+		if !ok {
+			return fmt.Errorf("object %T cannot be converted to client.Object", object)
+		}
+
+		for _, id := range s.handler.toId(obj) {
+			if _, ok := linkedIds[id]; !ok {
+				stale++
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	linkedResourceStaleIndexEntries.WithLabelValues(objGvk.Kind, linkedGvk.Kind).Set(float64(stale))
+	return nil
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeutil
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ReadinessRegistry records the readiness of issuers as determined by the issuer
+// controller, so the request controllers don't have to derive it from the
+// (possibly stale) conditions of the cached issuer. The readiness is recorded per
+// generation, a new generation of the issuer is never considered to be ready.
+type ReadinessRegistry interface {
+	SetReadiness(gvk schema.GroupVersionKind, namespacedName types.NamespacedName, generation int64, ready bool)
+	IsReady(gvk schema.GroupVersionKind, namespacedName types.NamespacedName, generation int64) bool
+	Forget(gvk schema.GroupVersionKind, namespacedName types.NamespacedName)
+}
+
+type readiness struct {
+	generation int64
+	ready      bool
+}
+
+type readinessRegistry struct {
+	readiness sync.Map
+}
+
+func NewReadinessRegistry() ReadinessRegistry {
+	return &readinessRegistry{}
+}
+
+func (rr *readinessRegistry) SetReadiness(gvk schema.GroupVersionKind, namespacedName types.NamespacedName, generation int64, ready bool) {
+	rr.readiness.Store(resource{
+		gvk:            gvk,
+		namespacedName: namespacedName,
+	}, readiness{
+		generation: generation,
+		ready:      ready,
+	})
+}
+
+func (rr *readinessRegistry) IsReady(gvk schema.GroupVersionKind, namespacedName types.NamespacedName, generation int64) bool {
+	value, ok := rr.readiness.Load(resource{
+		gvk:            gvk,
+		namespacedName: namespacedName,
+	})
+	if !ok {
+		return false
+	}
+
+	r := value.(readiness)
+	return r.ready && r.generation == generation
+}
+
+func (rr *readinessRegistry) Forget(gvk schema.GroupVersionKind, namespacedName types.NamespacedName) {
+	rr.readiness.Delete(resource{
+		gvk:            gvk,
+		namespacedName: namespacedName,
+	})
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeutil
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// ReportedError is an error that was reported for a resource using an EventSource.
+type ReportedError struct {
+	GVK            schema.GroupVersionKind
+	NamespacedName types.NamespacedName
+	Message        string
+}
+
+// ReportedErrorBackend persists the errors reported to an EventSource, so that
+// they survive restarts of the controller.
+type ReportedErrorBackend interface {
+	// Load returns all persisted errors.
+	Load(ctx context.Context) ([]ReportedError, error)
+	// Store persists an error that was reported for a resource.
+	Store(ctx context.Context, reportedError ReportedError) error
+	// Delete removes the persisted error of a resource, once it was consumed.
+	Delete(ctx context.Context, gvk schema.GroupVersionKind, namespacedName types.NamespacedName) error
+}
+
+// backendTimeout is the timeout of the calls to the ReportedErrorBackend.
+const backendTimeout = 30 * time.Second
+
+// NewPersistentEventStore returns an EventSource that persists the reported
+// errors using the backend. The persisted errors are restored when the reported
+// errors are first read, so errors that were reported before a restart are still
+// processed by the issuer controller after the restart. Persisting the errors is
+// best-effort, failures are logged.
+func NewPersistentEventStore(backend ReportedErrorBackend) EventSource {
+	return NewEventStoreWithLimits(backend, EventStoreLimits{})
+}
+
+// restore loads the persisted errors once, errors reported since the start of
+// the controller take precedence over the persisted errors. The TTL of the
+// restored errors starts when they are restored.
+func (es *eventSource) restore() {
+	if es.backend == nil {
+		return
+	}
+
+	es.restoreOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+		defer cancel()
+
+		reportedErrors, err := es.backend.Load(ctx)
+		if err != nil {
+			log.Log.WithName("event-source").Error(err, "Failed to restore the reported errors")
+			return
+		}
+
+		for _, reportedError := range reportedErrors {
+			es.store(resource{
+				gvk:            reportedError.GVK,
+				namespacedName: reportedError.NamespacedName,
+			}, errors.New(reportedError.Message), false)
+		}
+	})
+}
+
+func (es *eventSource) persist(gvk schema.GroupVersionKind, namespacedName types.NamespacedName, err error) {
+	if es.backend == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+	defer cancel()
+
+	if err := es.backend.Store(ctx, ReportedError{
+		GVK:            gvk,
+		NamespacedName: namespacedName,
+		Message:        err.Error(),
+	}); err != nil {
+		log.Log.WithName("event-source").Error(err, "Failed to persist the reported error", "gvk", gvk, "resource", namespacedName)
+	}
+}
+
+func (es *eventSource) forget(gvk schema.GroupVersionKind, namespacedName types.NamespacedName) {
+	if es.backend == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+	defer cancel()
+
+	if err := es.backend.Delete(ctx, gvk, namespacedName); err != nil {
+		log.Log.WithName("event-source").Error(err, "Failed to delete the persisted reported error", "gvk", gvk, "resource", namespacedName)
+	}
+}
@@ -20,10 +20,12 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 )
@@ -34,32 +36,100 @@ type EventSource interface {
 	HasReportedError(gvk schema.GroupVersionKind, namespacedName types.NamespacedName) error
 }
 
+// ReportedErrorPeeker is optionally implemented by an EventSource that can
+// return the error that was reported for a resource without consuming it. The
+// EventSources returned by the constructors of this package implement it.
+type ReportedErrorPeeker interface {
+	PeekReportedError(gvk schema.GroupVersionKind, namespacedName types.NamespacedName) error
+}
+
 type resource struct {
 	gvk            schema.GroupVersionKind
 	namespacedName types.NamespacedName
 }
 
+// EventStoreLimits bounds the memory used by the reported errors of an
+// EventSource. Reported errors are normally consumed by the issuer controller
+// shortly after they were reported, but the errors reported for resources that
+// are never reconciled again (eg. deleted issuers) would be kept forever.
+type EventStoreLimits struct {
+	// MaxEntries is the maximum number of reported errors, defaults to 10000.
+	// When the limit is reached, the oldest reported error is evicted.
+	MaxEntries int
+
+	// TTL is the duration after which a reported error that was not consumed is
+	// evicted, defaults to 1 hour.
+	TTL time.Duration
+
+	// Clock defaults to the real clock.
+	Clock clock.PassiveClock
+}
+
+type reportedError struct {
+	err        error
+	reportedAt time.Time
+}
+
 type eventSource struct {
-	mu         sync.RWMutex
-	dest       map[schema.GroupVersionKind]workqueue.TypedRateLimitingInterface[reconcile.Request]
-	invalidate sync.Map
+	mu   sync.RWMutex
+	dest map[schema.GroupVersionKind]workqueue.TypedRateLimitingInterface[reconcile.Request]
+
+	limits     EventStoreLimits
+	errorsMu   sync.Mutex
+	invalidate map[resource]reportedError
+
+	// backend optionally persists the reported errors, see NewPersistentEventStore.
+	backend     ReportedErrorBackend
+	restoreOnce sync.Once
 }
 
 func NewEventStore() EventSource {
+	return NewEventStoreWithLimits(nil, EventStoreLimits{})
+}
+
+// NewEventStoreWithLimits returns an EventSource that evicts the reported errors
+// according to the limits, and that persists the reported errors using the
+// backend if it is not nil (see NewPersistentEventStore).
+func NewEventStoreWithLimits(backend ReportedErrorBackend, limits EventStoreLimits) EventSource {
+	if limits.MaxEntries == 0 {
+		limits.MaxEntries = 10000
+	}
+	if limits.TTL == 0 {
+		limits.TTL = time.Hour
+	}
+	if limits.Clock == nil {
+		limits.Clock = clock.RealClock{}
+	}
+
 	return &eventSource{
-		dest: make(map[schema.GroupVersionKind]workqueue.TypedRateLimitingInterface[reconcile.Request]),
+		dest:       make(map[schema.GroupVersionKind]workqueue.TypedRateLimitingInterface[reconcile.Request]),
+		limits:     limits,
+		invalidate: map[resource]reportedError{},
+		backend:    backend,
 	}
 }
 
 func (es *eventSource) HasReportedError(gvk schema.GroupVersionKind, namespacedName types.NamespacedName) error {
-	err, ok := es.invalidate.LoadAndDelete(resource{
-		gvk:            gvk,
-		namespacedName: namespacedName,
-	})
-	if !ok {
-		return nil
+	es.restore()
+
+	entry, removed := es.load(resource{gvk: gvk, namespacedName: namespacedName}, true)
+	if removed {
+		es.forget(gvk, namespacedName)
+	}
+	return entry.err
+}
+
+// PeekReportedError returns the error that was reported for the resource, without
+// clearing it. It returns nil if no error was reported or if the reported error was
+// already consumed by HasReportedError.
+func (es *eventSource) PeekReportedError(gvk schema.GroupVersionKind, namespacedName types.NamespacedName) error {
+	es.restore()
+
+	entry, removed := es.load(resource{gvk: gvk, namespacedName: namespacedName}, false)
+	if removed {
+		es.forget(gvk, namespacedName)
 	}
-	return err.(error)
+	return entry.err
 }
 
 func (es *eventSource) ReportError(gvk schema.GroupVersionKind, namespacedName types.NamespacedName, err error) error {
@@ -69,16 +139,92 @@ func (es *eventSource) ReportError(gvk schema.GroupVersionKind, namespacedName t
 	if queue, ok := es.dest[gvk]; !ok {
 		return fmt.Errorf("consumer for %v does not exist", gvk)
 	} else {
-		es.invalidate.Store(resource{
-			gvk:            gvk,
-			namespacedName: namespacedName,
-		}, err)
+		es.store(resource{gvk: gvk, namespacedName: namespacedName}, err, true)
+		es.persist(gvk, namespacedName, err)
 
 		queue.Add(reconcile.Request{NamespacedName: namespacedName})
 		return nil
 	}
 }
 
+// load returns the unexpired error that was reported for the resource, and
+// removes it if consume is true. Expired errors are always removed. The second
+// return value is true if an error was removed.
+func (es *eventSource) load(key resource, consume bool) (reportedError, bool) {
+	es.errorsMu.Lock()
+	defer es.errorsMu.Unlock()
+
+	entry, ok := es.invalidate[key]
+	if !ok {
+		return reportedError{}, false
+	}
+
+	if es.expired(entry) {
+		es.delete(key)
+		return reportedError{}, true
+	}
+
+	if consume {
+		es.delete(key)
+	}
+	return entry, consume
+}
+
+// store adds the reported error, replacing an existing error for the resource if
+// overwrite is true. Expired errors are evicted first, then the oldest errors
+// until there is room for the new error. Evicted errors are also removed from
+// the backend.
+func (es *eventSource) store(key resource, err error, overwrite bool) {
+	var evicted []resource
+	defer func() {
+		for _, key := range evicted {
+			es.forget(key.gvk, key.namespacedName)
+		}
+	}()
+
+	es.errorsMu.Lock()
+	defer es.errorsMu.Unlock()
+
+	if _, ok := es.invalidate[key]; ok {
+		if overwrite {
+			es.invalidate[key] = reportedError{err: err, reportedAt: es.limits.Clock.Now()}
+		}
+		return
+	}
+
+	for existingKey, entry := range es.invalidate {
+		if es.expired(entry) {
+			es.delete(existingKey)
+			evicted = append(evicted, existingKey)
+		}
+	}
+
+	for len(es.invalidate) >= es.limits.MaxEntries {
+		var oldestKey resource
+		var oldest *reportedError
+		for existingKey, entry := range es.invalidate {
+			if oldest == nil || entry.reportedAt.Before(oldest.reportedAt) {
+				oldestKey, oldest = existingKey, &entry
+			}
+		}
+		es.delete(oldestKey)
+		evicted = append(evicted, oldestKey)
+	}
+
+	es.invalidate[key] = reportedError{err: err, reportedAt: es.limits.Clock.Now()}
+	CacheEntries.WithLabelValues("reported_errors").Inc()
+}
+
+// delete must be called with the errorsMu lock held.
+func (es *eventSource) delete(key resource) {
+	delete(es.invalidate, key)
+	CacheEntries.WithLabelValues("reported_errors").Dec()
+}
+
+func (es *eventSource) expired(entry reportedError) bool {
+	return !es.limits.Clock.Now().Before(entry.reportedAt.Add(es.limits.TTL))
+}
+
 func (es *eventSource) AddConsumer(gvk schema.GroupVersionKind) source.Source {
 	return &eventConsumer{
 		register: func(queue workqueue.TypedRateLimitingInterface[reconcile.Request]) error {
@@ -33,9 +33,12 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
-type linkedResourceHandler struct {
+// LinkedResourceHandler is the handler.EventHandler returned by
+// NewLinkedResourceHandler.
+type LinkedResourceHandler struct {
 	cache      cache.Cache
 	objType    client.Object
+	toId       func(obj client.Object) []string
 	addToQueue func(q workqueue.TypedRateLimitingInterface[reconcile.Request], req reconcile.Request)
 
 	refField string
@@ -63,6 +66,8 @@ type linkedResourceHandler struct {
 // makes it possible to, instead of adding the event in the queue, post events on a channel.
 // The default nil value for `addToQueue` results in just using the `q.Add(req)` function to
 // add events to the queue.
+// Use ResyncSource to also enqueue the linked resources periodically, in case a
+// watch event was missed.
 func NewLinkedResourceHandler(
 	cacheCtx context.Context,
 	logger logr.Logger,
@@ -71,7 +76,7 @@ func NewLinkedResourceHandler(
 	objType client.Object,
 	toId func(obj client.Object) []string,
 	addToQueue func(q workqueue.TypedRateLimitingInterface[reconcile.Request], req reconcile.Request),
-) (handler.EventHandler, error) {
+) (*LinkedResourceHandler, error) {
 	// a random index name prevents collisions with other indexes
 	refField := fmt.Sprintf(".x-index.%s", rand.String(10))
 
@@ -86,11 +91,12 @@ func NewLinkedResourceHandler(
 		return nil, err
 	}
 
-	return &linkedResourceHandler{
+	return &LinkedResourceHandler{
 		logger:     logger,
 		scheme:     scheme,
 		cache:      cache,
 		objType:    objType,
+		toId:       toId,
 		addToQueue: addToQueue,
 
 		refField: refField,
@@ -104,7 +110,7 @@ func NewLinkedResourceHandler(
 // interface. See
 // https://github.com/kubernetes-sigs/controller-runtime/issues/1996
 // https://github.com/kubernetes-sigs/controller-runtime/issues/1923
-func (r *linkedResourceHandler) findObjectsForKind(ctx context.Context, object client.Object) []reconcile.Request {
+func (r *LinkedResourceHandler) findObjectsForKind(ctx context.Context, object client.Object) []reconcile.Request {
 	logger := r.logger.WithName("FindObjectsForKind").WithValues(
 		"object", client.ObjectKeyFromObject(object),
 		"objectType", fmt.Sprintf("%T", object),
@@ -143,36 +149,36 @@ func (r *linkedResourceHandler) findObjectsForKind(ctx context.Context, object c
 }
 
 // Based on https://github.com/kubernetes-sigs/controller-runtime/blob/00f2425ce068525e0ff674dba51c3e76ee6ad2da/pkg/handler/enqueue_mapped.go
-// Copied to this linkedResourceHandler type such that dependencies can be injected.
+// Copied to this LinkedResourceHandler type such that dependencies can be injected.
 
-var _ handler.EventHandler = &linkedResourceHandler{}
+var _ handler.EventHandler = &LinkedResourceHandler{}
 
 // Create implements EventHandler.
-func (e *linkedResourceHandler) Create(ctx context.Context, evt event.CreateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+func (e *LinkedResourceHandler) Create(ctx context.Context, evt event.CreateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
 	reqs := map[reconcile.Request]struct{}{}
 	e.mapAndEnqueue(ctx, q, evt.Object, reqs)
 }
 
 // Update implements EventHandler.
-func (e *linkedResourceHandler) Update(ctx context.Context, evt event.UpdateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+func (e *LinkedResourceHandler) Update(ctx context.Context, evt event.UpdateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
 	reqs := map[reconcile.Request]struct{}{}
 	e.mapAndEnqueue(ctx, q, evt.ObjectOld, reqs)
 	e.mapAndEnqueue(ctx, q, evt.ObjectNew, reqs)
 }
 
 // Delete implements EventHandler.
-func (e *linkedResourceHandler) Delete(ctx context.Context, evt event.DeleteEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+func (e *LinkedResourceHandler) Delete(ctx context.Context, evt event.DeleteEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
 	reqs := map[reconcile.Request]struct{}{}
 	e.mapAndEnqueue(ctx, q, evt.Object, reqs)
 }
 
 // Generic implements EventHandler.
-func (e *linkedResourceHandler) Generic(ctx context.Context, evt event.GenericEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+func (e *LinkedResourceHandler) Generic(ctx context.Context, evt event.GenericEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
 	reqs := map[reconcile.Request]struct{}{}
 	e.mapAndEnqueue(ctx, q, evt.Object, reqs)
 }
 
-func (e *linkedResourceHandler) mapAndEnqueue(ctx context.Context, q workqueue.TypedRateLimitingInterface[reconcile.Request], object client.Object, reqs map[reconcile.Request]struct{}) {
+func (e *LinkedResourceHandler) mapAndEnqueue(ctx context.Context, q workqueue.TypedRateLimitingInterface[reconcile.Request], object client.Object, reqs map[reconcile.Request]struct{}) {
 	for _, req := range e.findObjectsForKind(ctx, object) {
 		_, ok := reqs[req]
 		if !ok {
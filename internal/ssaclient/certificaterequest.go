@@ -17,44 +17,17 @@ limitations under the License.
 package ssaclient
 
 import (
-	"encoding/json"
-
 	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	v1 "k8s.io/client-go/applyconfigurations/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-)
 
-type certificateRequestStatusApplyConfiguration struct {
-	v1.TypeMetaApplyConfiguration    `json:",inline"`
-	*v1.ObjectMetaApplyConfiguration `json:"metadata,omitempty"`
-	Status                           *cmapi.CertificateRequestStatus `json:"status,omitempty"`
-}
+	"github.com/cert-manager/issuer-lib/ssapatch"
+)
 
 func GenerateCertificateRequestStatusPatch(
 	name string,
 	namespace string,
 	status *cmapi.CertificateRequestStatus,
 ) (cmapi.CertificateRequest, client.Patch, error) {
-	// This object is used to deduce the name & namespace + unmarshall the return value in
-	cr := cmapi.CertificateRequest{
-		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
-	}
-
-	// This object is used to render the patch
-	b := &certificateRequestStatusApplyConfiguration{
-		ObjectMetaApplyConfiguration: &v1.ObjectMetaApplyConfiguration{},
-	}
-	b.WithName(name)
-	b.WithNamespace(namespace)
-	b.WithKind(cmapi.CertificateRequestKind)
-	b.WithAPIVersion(cmapi.SchemeGroupVersion.Identifier())
-	b.Status = status
-
-	encodedPatch, err := json.Marshal(b)
-	if err != nil {
-		return cr, nil, err
-	}
-
-	return cr, applyPatch{encodedPatch}, nil
+	cr, patch, err := ssapatch.CertificateRequestStatus(name, namespace, status)
+	return *cr, patch, err
 }
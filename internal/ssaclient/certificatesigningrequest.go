@@ -17,44 +17,18 @@ limitations under the License.
 package ssaclient
 
 import (
-	"encoding/json"
-
 	certificatesv1 "k8s.io/api/certificates/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	v1 "k8s.io/client-go/applyconfigurations/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-)
 
-type certificateSigningRequestStatusApplyConfiguration struct {
-	v1.TypeMetaApplyConfiguration    `json:",inline"`
-	*v1.ObjectMetaApplyConfiguration `json:"metadata,omitempty"`
-	Status                           *certificatesv1.CertificateSigningRequestStatus `json:"status,omitempty"`
-}
+	"github.com/cert-manager/issuer-lib/ssapatch"
+)
 
 func GenerateCertificateSigningRequestStatusPatch(
 	name string,
 	namespace string,
 	status *certificatesv1.CertificateSigningRequestStatus,
 ) (certificatesv1.CertificateSigningRequest, client.Patch, error) {
-	// This object is used to deduce the name & namespace + unmarshall the return value in
-	cr := certificatesv1.CertificateSigningRequest{
-		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
-	}
-
-	// This object is used to render the patch
-	b := &certificateSigningRequestStatusApplyConfiguration{
-		ObjectMetaApplyConfiguration: &v1.ObjectMetaApplyConfiguration{},
-	}
-	b.WithName(name)
-	b.WithNamespace(namespace)
-	b.WithKind("CertificateSigningRequest")
-	b.WithAPIVersion(certificatesv1.SchemeGroupVersion.Identifier())
-	b.Status = status
-
-	encodedPatch, err := json.Marshal(b)
-	if err != nil {
-		return cr, nil, err
-	}
-
-	return cr, applyPatch{encodedPatch}, nil
+	csr := certificatesv1.CertificateSigningRequest{}
+	patch, err := ssapatch.ObjectStatus(&csr, certificatesv1.SchemeGroupVersion.WithKind("CertificateSigningRequest"), name, namespace, status)
+	return csr, patch, err
 }
@@ -17,20 +17,12 @@ limitations under the License.
 package ssaclient
 
 import (
-	"encoding/json"
-
-	v1 "k8s.io/client-go/applyconfigurations/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/ssapatch"
 )
 
-type issuerApplyConfiguration struct {
-	v1.TypeMetaApplyConfiguration    `json:",inline"`
-	*v1.ObjectMetaApplyConfiguration `json:"metadata,omitempty"`
-	Status                           *v1alpha1.IssuerStatus `json:"status,omitempty"`
-}
-
 func GenerateIssuerStatusPatch(
 	issuerType v1alpha1.Issuer,
 	name string,
@@ -42,25 +34,7 @@ func GenerateIssuerStatusPatch(
 		panic("first call kubeutil.SetGroupVersionKind on issuerType before passing it to GenerateIssuerStatusPatch")
 	}
 
-	// This object is used to deduce the name & namespace + unmarshall the return value in
 	issuerObject := issuerType.DeepCopyObject().(v1alpha1.Issuer)
-	issuerObject.SetName(name)
-	issuerObject.SetNamespace(namespace)
-
-	// This object is used to render the patch
-	b := &issuerApplyConfiguration{
-		ObjectMetaApplyConfiguration: &v1.ObjectMetaApplyConfiguration{},
-	}
-	b.WithName(name)
-	b.WithNamespace(namespace)
-	b.WithKind(gvk.Kind)
-	b.WithAPIVersion(gvk.GroupVersion().Identifier())
-	b.Status = status
-
-	encodedPatch, err := json.Marshal(b)
-	if err != nil {
-		return issuerObject, nil, err
-	}
-
-	return issuerObject, applyPatch{encodedPatch}, nil
+	patch, err := ssapatch.ObjectStatus(issuerObject, gvk, name, namespace, status)
+	return issuerObject, patch, err
 }
@@ -64,6 +64,12 @@ func SetTestIssuerGeneration(generation int64) TestIssuerModifier {
 	}
 }
 
+func SetTestIssuerAnnotations(annotations map[string]string) TestIssuerModifier {
+	return func(si *api.TestIssuer) {
+		si.Annotations = annotations
+	}
+}
+
 func SetTestIssuerStatusCondition(
 	clock clock.PassiveClock,
 	conditionType cmapi.IssuerConditionType,
@@ -116,6 +122,12 @@ func SetTestClusterIssuerGeneration(generation int64) TestClusterIssuerModifier
 	}
 }
 
+func SetTestClusterIssuerAnnotations(annotations map[string]string) TestClusterIssuerModifier {
+	return func(si *api.TestClusterIssuer) {
+		si.Annotations = annotations
+	}
+}
+
 func SetTestClusterIssuerStatusCondition(
 	clock clock.PassiveClock,
 	conditionType cmapi.IssuerConditionType,
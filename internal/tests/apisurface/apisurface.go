@@ -0,0 +1,200 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Package apisurface describes the exported API of a Go package as sorted lines
+// of text, so that tests can compare it with a golden file and detect changes
+// of the API that downstream issuers depend on.
+package apisurface
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"slices"
+	"strings"
+)
+
+// Describe returns the exported API of the Go package in the directory, one
+// declaration per line and sorted. Test files are ignored. Exported struct
+// fields and interface methods are described on separate lines, so that a diff
+// of two descriptions shows the individual changes.
+func Describe(dir string) ([]byte, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(info fs.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected exactly one package in %s, found %d", dir, len(pkgs))
+	}
+
+	var lines []string
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				lines = append(lines, describeDecl(decl)...)
+			}
+		}
+	}
+
+	slices.Sort(lines)
+	return []byte(strings.Join(lines, "\n") + "\n"), nil
+}
+
+func describeDecl(decl ast.Decl) []string {
+	switch decl := decl.(type) {
+	case *ast.FuncDecl:
+		if !decl.Name.IsExported() {
+			return nil
+		}
+		if decl.Recv == nil {
+			return []string{"func " + decl.Name.Name + strings.TrimPrefix(types.ExprString(decl.Type), "func")}
+		}
+		receiver := types.ExprString(decl.Recv.List[0].Type)
+		if !ast.IsExported(receiverName(decl.Recv.List[0].Type)) {
+			return nil
+		}
+		return []string{"method (" + receiver + ") " + decl.Name.Name + strings.TrimPrefix(types.ExprString(decl.Type), "func")}
+	case *ast.GenDecl:
+		var lines []string
+		var previous *ast.ValueSpec
+		for i, spec := range decl.Specs {
+			switch spec := spec.(type) {
+			case *ast.TypeSpec:
+				lines = append(lines, describeType(spec)...)
+			case *ast.ValueSpec:
+				// Constants without values repeat the type and values of the
+				// previous constant, with the next value of iota.
+				if decl.Tok == token.CONST && len(spec.Values) == 0 && previous != nil {
+					spec = &ast.ValueSpec{Names: spec.Names, Type: previous.Type, Values: previous.Values}
+				} else {
+					previous = spec
+				}
+				lines = append(lines, describeValue(decl.Tok, spec, i)...)
+			}
+		}
+		return lines
+	default:
+		return nil
+	}
+}
+
+func describeType(spec *ast.TypeSpec) []string {
+	if !spec.Name.IsExported() {
+		return nil
+	}
+
+	name := spec.Name.Name
+	if spec.TypeParams != nil {
+		name += "[" + fieldList(spec.TypeParams) + "]"
+	}
+	if spec.Assign.IsValid() {
+		return []string{"type " + name + " = " + types.ExprString(spec.Type)}
+	}
+
+	switch typ := spec.Type.(type) {
+	case *ast.StructType:
+		lines := []string{"type " + name + " struct"}
+		for _, field := range typ.Fields.List {
+			if len(field.Names) == 0 {
+				if ast.IsExported(receiverName(field.Type)) {
+					lines = append(lines, "field "+spec.Name.Name+" embedded "+types.ExprString(field.Type))
+				}
+				continue
+			}
+			for _, fieldName := range field.Names {
+				if fieldName.IsExported() {
+					lines = append(lines, "field "+spec.Name.Name+"."+fieldName.Name+" "+types.ExprString(field.Type))
+				}
+			}
+		}
+		return lines
+	case *ast.InterfaceType:
+		lines := []string{"type " + name + " interface"}
+		for _, method := range typ.Methods.List {
+			if len(method.Names) == 0 {
+				lines = append(lines, "method "+spec.Name.Name+" embedded "+types.ExprString(method.Type))
+				continue
+			}
+			for _, methodName := range method.Names {
+				lines = append(lines, "method "+spec.Name.Name+"."+methodName.Name+strings.TrimPrefix(types.ExprString(method.Type), "func"))
+			}
+		}
+		return lines
+	default:
+		return []string{"type " + name + " " + types.ExprString(spec.Type)}
+	}
+}
+
+func describeValue(tok token.Token, spec *ast.ValueSpec, iota int) []string {
+	var lines []string
+	for i, name := range spec.Names {
+		if !name.IsExported() {
+			continue
+		}
+
+		line := tok.String() + " " + name.Name
+		if spec.Type != nil {
+			line += " " + types.ExprString(spec.Type)
+		}
+		// The values of constants are part of the API (eg. condition reasons),
+		// the values of variables are not.
+		if tok == token.CONST && i < len(spec.Values) {
+			value := types.ExprString(spec.Values[i])
+			line += " = " + value
+			if strings.Contains(value, "iota") {
+				line += fmt.Sprintf(" (iota %d)", iota)
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func fieldList(fields *ast.FieldList) string {
+	parts := make([]string, 0, len(fields.List))
+	for _, field := range fields.List {
+		names := make([]string, 0, len(field.Names))
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+		parts = append(parts, strings.Join(names, ", ")+" "+types.ExprString(field.Type))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// receiverName returns the name of the (possibly pointer, generic or
+// package-qualified) type.
+func receiverName(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(expr.X)
+	case *ast.IndexExpr:
+		return receiverName(expr.X)
+	case *ast.IndexListExpr:
+		return receiverName(expr.X)
+	case *ast.SelectorExpr:
+		return expr.Sel.Name
+	case *ast.Ident:
+		return expr.Name
+	default:
+		return ""
+	}
+}
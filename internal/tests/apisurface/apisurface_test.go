@@ -0,0 +1,49 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apisurface
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/cert-manager/issuer-lib/testing/statussnapshot"
+)
+
+// TestAPISurface compares the exported API of the stable packages with the
+// golden files in testdata. A difference means that the API changed: additions
+// only require updating the golden files, but removals and changed signatures
+// break downstream issuers and must follow the deprecation policy in the README.
+func TestAPISurface(t *testing.T) {
+	t.Parallel()
+
+	for name, dir := range map[string]string{
+		"conditions":  "../../../conditions",
+		"controllers": "../../../controllers",
+		"signer":      "../../../controllers/signer",
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			description, err := Describe(dir)
+			require.NoError(t, err)
+
+			statussnapshot.AssertGoldenFile(t, filepath.Join("testdata", name+".txt"), description)
+		})
+	}
+}
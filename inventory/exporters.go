@@ -0,0 +1,106 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inventory
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// JSONExporter writes the metadata of each certificate as a single line of
+// JSON, eg. to the stdout of the controller from where it is shipped by the log
+// collector.
+type JSONExporter struct {
+	// Writer is the writer to which the metadata is written, defaults to
+	// os.Stdout.
+	Writer io.Writer
+
+	mu sync.Mutex
+}
+
+var _ Exporter = &JSONExporter{}
+
+// Export writes the metadata as a line of JSON.
+func (e *JSONExporter) Export(_ context.Context, metadata CertificateMetadata) error {
+	line, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	writer := e.Writer
+	if writer == nil {
+		writer = os.Stdout
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, err = writer.Write(line)
+	return err
+}
+
+// WebhookExporter POSTs the metadata of each certificate as JSON to a webhook,
+// which has to respond with a 2xx status.
+type WebhookExporter struct {
+	// URL is the URL of the webhook.
+	URL string
+
+	// Client is the HTTP client used to call the webhook, defaults to a client
+	// with a 30 second timeout. Use a custom client to configure TLS or
+	// authentication.
+	Client *http.Client
+}
+
+var _ Exporter = &WebhookExporter{}
+
+// Export POSTs the metadata to the webhook.
+func (e *WebhookExporter) Export(ctx context.Context, metadata CertificateMetadata) error {
+	body, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+
+	client := e.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	httpResponse, err := client.Do(httpRequest)
+	if err != nil {
+		return fmt.Errorf("failed to call the inventory webhook: %w", err)
+	}
+	defer httpResponse.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(httpResponse.Body, 1<<20))
+
+	if httpResponse.StatusCode < 200 || httpResponse.StatusCode > 299 {
+		return fmt.Errorf("the inventory webhook returned an unexpected status %s", httpResponse.Status)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inventory
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONExporter(t *testing.T) {
+	t.Parallel()
+
+	metadata := MetadataFromCertificate(createTestCertificate(t))
+	metadata.RequestKind = "CertificateRequest"
+	metadata.Namespace = "ns1"
+	metadata.Name = "cr1"
+
+	var buffer bytes.Buffer
+	exporter := &JSONExporter{Writer: &buffer}
+	require.NoError(t, exporter.Export(context.TODO(), metadata))
+	require.NoError(t, exporter.Export(context.TODO(), metadata))
+
+	lines := strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n")
+	require.Len(t, lines, 2)
+
+	var decoded CertificateMetadata
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &decoded))
+	assert.Equal(t, metadata, decoded)
+}
+
+func TestWebhookExporter(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name          string
+		status        int
+		expectedError string
+	}
+
+	tests := []testCase{
+		{
+			name:   "success",
+			status: http.StatusNoContent,
+		},
+		{
+			name:          "error-status",
+			status:        http.StatusServiceUnavailable,
+			expectedError: "the inventory webhook returned an unexpected status 503 Service Unavailable",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			metadata := MetadataFromCertificate(createTestCertificate(t))
+			metadata.Name = "cr1"
+
+			var received CertificateMetadata
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+				w.WriteHeader(tc.status)
+			}))
+			defer server.Close()
+
+			exporter := &WebhookExporter{URL: server.URL, Client: server.Client()}
+			err := exporter.Export(context.TODO(), metadata)
+			if tc.expectedError != "" {
+				require.EqualError(t, err, tc.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, metadata, received)
+		})
+	}
+}
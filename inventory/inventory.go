@@ -0,0 +1,121 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package inventory exports the metadata of the certificates that are issued by
+// the request controllers to external inventory or CMDB systems. Set the
+// InventoryExporter option of the controllers to one of the reference exporters
+// (JSONExporter, WebhookExporter) or to an own implementation of Exporter.
+package inventory
+
+import (
+	"context"
+	"crypto/sha1" //nolint:gosec // SHA-1 fingerprints are only used to identify certificates.
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"time"
+)
+
+// Exporter exports the metadata of an issued certificate. The controllers call
+// Export synchronously after a certificate was signed and before the request is
+// marked as Issued, so slow exporters delay the reconciles. Errors are logged and
+// counted, but Export is not retried. Export can be called more than once for
+// the same certificate (eg. if marking the request as Issued failed), so
+// exporters should use the fingerprint to deduplicate.
+type Exporter interface {
+	Export(ctx context.Context, metadata CertificateMetadata) error
+}
+
+// ExporterFunc is a function that implements Exporter.
+type ExporterFunc func(ctx context.Context, metadata CertificateMetadata) error
+
+// Export calls the function.
+func (f ExporterFunc) Export(ctx context.Context, metadata CertificateMetadata) error {
+	return f(ctx, metadata)
+}
+
+// IssuerRef identifies the issuer that signed a certificate.
+type IssuerRef struct {
+	Group     string `json:"group"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+// CertificateMetadata is the normalized metadata of an issued certificate.
+type CertificateMetadata struct {
+	// RequestKind is "CertificateRequest" or "CertificateSigningRequest".
+	RequestKind string `json:"requestKind"`
+	// Namespace, Name and UID of the request. Kubernetes CSRs have no namespace.
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	UID       string `json:"uid"`
+
+	IssuerRef IssuerRef `json:"issuerRef"`
+
+	// SerialNumber is the hex encoded serial number of the certificate.
+	SerialNumber string `json:"serialNumber"`
+	// Subject and Issuer are the distinguished names of the subject and
+	// issuer of the certificate.
+	Subject string `json:"subject"`
+	Issuer  string `json:"issuer"`
+
+	CommonName     string   `json:"commonName,omitempty"`
+	DNSNames       []string `json:"dnsNames,omitempty"`
+	IPAddresses    []string `json:"ipAddresses,omitempty"`
+	URIs           []string `json:"uris,omitempty"`
+	EmailAddresses []string `json:"emailAddresses,omitempty"`
+	IsCA           bool     `json:"isCA"`
+
+	// SHA256Fingerprint and SHA1Fingerprint are the hex encoded hashes of the
+	// DER encoded certificate.
+	SHA256Fingerprint string `json:"sha256Fingerprint"`
+	SHA1Fingerprint   string `json:"sha1Fingerprint"`
+
+	NotBefore time.Time `json:"notBefore"`
+	NotAfter  time.Time `json:"notAfter"`
+	// TTLSeconds is the lifetime of the certificate (NotAfter - NotBefore).
+	TTLSeconds int64 `json:"ttlSeconds"`
+}
+
+// MetadataFromCertificate returns the metadata of the certificate, the request
+// and issuer fields are left empty.
+func MetadataFromCertificate(certificate *x509.Certificate) CertificateMetadata {
+	sha256Fingerprint := sha256.Sum256(certificate.Raw)
+	sha1Fingerprint := sha1.Sum(certificate.Raw) //nolint:gosec // only used to identify the certificate
+
+	metadata := CertificateMetadata{
+		SerialNumber:      hex.EncodeToString(certificate.SerialNumber.Bytes()),
+		Subject:           certificate.Subject.String(),
+		Issuer:            certificate.Issuer.String(),
+		CommonName:        certificate.Subject.CommonName,
+		DNSNames:          certificate.DNSNames,
+		EmailAddresses:    certificate.EmailAddresses,
+		IsCA:              certificate.BasicConstraintsValid && certificate.IsCA,
+		SHA256Fingerprint: hex.EncodeToString(sha256Fingerprint[:]),
+		SHA1Fingerprint:   hex.EncodeToString(sha1Fingerprint[:]),
+		NotBefore:         certificate.NotBefore,
+		NotAfter:          certificate.NotAfter,
+		TTLSeconds:        int64(certificate.NotAfter.Sub(certificate.NotBefore).Seconds()),
+	}
+	for _, ip := range certificate.IPAddresses {
+		metadata.IPAddresses = append(metadata.IPAddresses, ip.String())
+	}
+	for _, uri := range certificate.URIs {
+		metadata.URIs = append(metadata.URIs, uri.String())
+	}
+	return metadata
+}
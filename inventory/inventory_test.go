@@ -0,0 +1,86 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inventory
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestCertificate(t *testing.T) *x509.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	notBefore := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	template := &x509.Certificate{
+		SerialNumber:   big.NewInt(0x1234),
+		Subject:        pkix.Name{CommonName: "example.com", Organization: []string{"Example"}},
+		DNSNames:       []string{"example.com", "www.example.com"},
+		IPAddresses:    []net.IP{net.ParseIP("10.0.0.1")},
+		URIs:           []*url.URL{{Scheme: "spiffe", Host: "example.com", Path: "/workload"}},
+		EmailAddresses: []string{"admin@example.com"},
+		NotBefore:      notBefore,
+		NotAfter:       notBefore.Add(24 * time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+
+	certificate, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return certificate
+}
+
+func TestMetadataFromCertificate(t *testing.T) {
+	t.Parallel()
+
+	certificate := createTestCertificate(t)
+
+	metadata := MetadataFromCertificate(certificate)
+
+	assert.Equal(t, "1234", metadata.SerialNumber)
+	assert.Equal(t, "CN=example.com,O=Example", metadata.Subject)
+	assert.Equal(t, "CN=example.com,O=Example", metadata.Issuer)
+	assert.Equal(t, "example.com", metadata.CommonName)
+	assert.Equal(t, []string{"example.com", "www.example.com"}, metadata.DNSNames)
+	assert.Equal(t, []string{"10.0.0.1"}, metadata.IPAddresses)
+	assert.Equal(t, []string{"spiffe://example.com/workload"}, metadata.URIs)
+	assert.Equal(t, []string{"admin@example.com"}, metadata.EmailAddresses)
+	assert.False(t, metadata.IsCA)
+	assert.Len(t, metadata.SHA256Fingerprint, 64)
+	assert.Len(t, metadata.SHA1Fingerprint, 40)
+	assert.Equal(t, certificate.NotBefore, metadata.NotBefore)
+	assert.Equal(t, certificate.NotAfter, metadata.NotAfter)
+	assert.Equal(t, int64(24*60*60), metadata.TTLSeconds)
+
+	assert.Empty(t, metadata.RequestKind)
+	assert.Empty(t, metadata.Name)
+	assert.Equal(t, IssuerRef{}, metadata.IssuerRef)
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package issuerinformer provides typed, cached access to issuer resources (the
+// v1alpha1.Issuer implementations) for consumers that don't use controller-runtime,
+// such as CLIs and dashboards.
+//
+// Issuer types are defined by the issuers that are built using issuer-lib, so
+// there is no generated clientset for them. Instead, an Informer wraps a dynamic
+// informer and converts the issuer resources to the typed issuer once, when they
+// are added to the cache:
+//
+//	informer, err := issuerinformer.New(dynamicClient, scheme, &v1alpha1.SimpleIssuer{}, metav1.NamespaceAll, 10*time.Minute)
+//	if err != nil {
+//		return err
+//	}
+//	go informer.Run(ctx)
+//	if !informer.WaitForCacheSync(ctx) {
+//		return ctx.Err()
+//	}
+//
+//	issuers, err := informer.List(metav1.NamespaceAll, labels.Everything())
+package issuerinformer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/conditions"
+)
+
+// Informer is a shared informer for an issuer type, whose cache contains the
+// typed issuers. The issuers returned by the Informer are shared with the cache
+// and must not be modified.
+type Informer[T v1alpha1.Issuer] struct {
+	informer  cache.SharedIndexInformer
+	resource  schema.GroupVersionResource
+	newIssuer func() (T, error)
+}
+
+// New creates an Informer for the issuer type of issuerType, which must be
+// registered in the scheme. The resource of the issuer type is derived from
+// its GetIssuerTypeIdentifier method. Use metav1.NamespaceAll as namespace to
+// watch the issuers in all namespaces, and for cluster-scoped issuer types.
+func New[T v1alpha1.Issuer](
+	client dynamic.Interface,
+	scheme *runtime.Scheme,
+	issuerType T,
+	namespace string,
+	resyncPeriod time.Duration,
+) (*Informer[T], error) {
+	gvk, err := apiutil.GVKForObject(issuerType, scheme)
+	if err != nil {
+		return nil, err
+	}
+
+	resource, _, found := strings.Cut(issuerType.GetIssuerTypeIdentifier(), ".")
+	if !found || resource == "" {
+		return nil, fmt.Errorf("invalid issuer type identifier %q", issuerType.GetIssuerTypeIdentifier())
+	}
+
+	i := &Informer[T]{
+		resource: gvk.GroupVersion().WithResource(resource),
+		newIssuer: func() (T, error) {
+			obj, err := scheme.New(gvk)
+			if err != nil {
+				var zero T
+				return zero, err
+			}
+			return obj.(T), nil
+		},
+	}
+
+	i.informer = dynamicinformer.NewFilteredDynamicInformer(
+		client, i.resource, namespace, resyncPeriod,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+		nil,
+	).Informer()
+	if err := i.informer.SetTransform(i.transform); err != nil {
+		return nil, err
+	}
+
+	return i, nil
+}
+
+// transform converts the unstructured issuers to the typed issuers before they
+// are added to the cache.
+func (i *Informer[T]) transform(obj interface{}) (interface{}, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return obj, nil
+	}
+
+	issuer, err := i.newIssuer()
+	if err != nil {
+		return nil, err
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), issuer); err != nil {
+		return nil, fmt.Errorf("failed to convert %s %s: %w", i.resource.Resource, u.GetName(), err)
+	}
+
+	return issuer, nil
+}
+
+// Informer returns the underlying shared informer, eg. to add event handlers.
+// The objects passed to the event handlers are the typed issuers.
+func (i *Informer[T]) Informer() cache.SharedIndexInformer {
+	return i.informer
+}
+
+// Run starts the informer and blocks until the context is cancelled.
+func (i *Informer[T]) Run(ctx context.Context) {
+	i.informer.Run(ctx.Done())
+}
+
+// WaitForCacheSync waits until the cache is synced, it returns false if the
+// context was cancelled first.
+func (i *Informer[T]) WaitForCacheSync(ctx context.Context) bool {
+	return cache.WaitForCacheSync(ctx.Done(), i.informer.HasSynced)
+}
+
+// List returns the cached issuers in the namespace that match the selector. Use
+// metav1.NamespaceAll to list the issuers in all namespaces.
+func (i *Informer[T]) List(namespace string, selector labels.Selector) ([]T, error) {
+	var issuers []T
+	appendIssuer := func(obj interface{}) {
+		if issuer, ok := obj.(T); ok {
+			issuers = append(issuers, issuer)
+		}
+	}
+
+	var err error
+	if namespace == "" {
+		err = cache.ListAll(i.informer.GetIndexer(), selector, appendIssuer)
+	} else {
+		err = cache.ListAllByNamespace(i.informer.GetIndexer(), namespace, selector, appendIssuer)
+	}
+
+	return issuers, err
+}
+
+// Get returns the cached issuer with the namespace and name, or a NotFound error.
+// The namespace of cluster-scoped issuers is empty.
+func (i *Informer[T]) Get(namespace, name string) (T, error) {
+	key := name
+	if namespace != "" {
+		key = namespace + "/" + name
+	}
+
+	var zero T
+	obj, exists, err := i.informer.GetIndexer().GetByKey(key)
+	if err != nil {
+		return zero, err
+	}
+	if !exists {
+		return zero, apierrors.NewNotFound(i.resource.GroupResource(), name)
+	}
+
+	issuer, ok := obj.(T)
+	if !ok {
+		return zero, fmt.Errorf("unexpected object of type %T in the cache", obj)
+	}
+
+	return issuer, nil
+}
+
+// ReadyCondition returns the Ready condition of the cached issuer, or nil if
+// the issuer has no Ready condition yet.
+func (i *Informer[T]) ReadyCondition(namespace, name string) (*cmapi.IssuerCondition, error) {
+	issuer, err := i.Get(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return conditions.GetIssuerStatusCondition(issuer.GetStatus().Conditions, cmapi.IssuerConditionReady), nil
+}
+
+// IsReady returns true if the cached issuer has an up-to-date Ready condition
+// with status True.
+func (i *Informer[T]) IsReady(namespace, name string) (bool, error) {
+	issuer, err := i.Get(namespace, name)
+	if err != nil {
+		return false, err
+	}
+
+	return issuer.GetStatus().IsReady(issuer.GetGeneration()), nil
+}
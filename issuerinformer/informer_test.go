@@ -0,0 +1,161 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package issuerinformer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+	"github.com/cert-manager/issuer-lib/internal/testapi/testutil"
+)
+
+func TestInformer(t *testing.T) {
+	t.Parallel()
+
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+
+	readyIssuer := testutil.TestIssuer(
+		"ready-issuer",
+		testutil.SetTestIssuerNamespace("ns1"),
+		testutil.SetTestIssuerGeneration(2),
+		testutil.SetTestIssuerStatusCondition(
+			fakeClock,
+			cmapi.IssuerConditionReady,
+			cmmeta.ConditionTrue,
+			v1alpha1.IssuerConditionReasonChecked,
+			"Succeeded checking the issuer",
+		),
+	)
+
+	outdatedIssuer := testutil.TestIssuerFrom(readyIssuer,
+		testutil.SetTestIssuerNamespace("ns2"),
+		testutil.SetTestIssuerGeneration(3),
+	)
+	outdatedIssuer.Labels = map[string]string{"team": "b"}
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, api.AddToScheme(scheme))
+
+	resource := api.SchemeGroupVersion.WithResource("testissuers")
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(
+		scheme,
+		map[schema.GroupVersionResource]string{resource: "TestIssuerList"},
+		readyIssuer, outdatedIssuer,
+	)
+
+	informer, err := New(dynamicClient, scheme, &api.TestIssuer{}, metav1.NamespaceAll, 0)
+	require.NoError(t, err)
+
+	added := make(chan *api.TestIssuer, 10)
+	_, err = informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			added <- obj.(*api.TestIssuer)
+		},
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go informer.Run(ctx)
+	require.True(t, informer.WaitForCacheSync(ctx))
+
+	issuers, err := informer.List(metav1.NamespaceAll, labels.Everything())
+	require.NoError(t, err)
+	assert.Len(t, issuers, 2)
+
+	issuers, err = informer.List("ns2", labels.Everything())
+	require.NoError(t, err)
+	require.Len(t, issuers, 1)
+	assert.Equal(t, "ns2", issuers[0].Namespace)
+
+	issuers, err = informer.List(metav1.NamespaceAll, labels.SelectorFromSet(labels.Set{"team": "b"}))
+	require.NoError(t, err)
+	require.Len(t, issuers, 1)
+	assert.Equal(t, "ns2", issuers[0].Namespace)
+
+	issuer, err := informer.Get("ns1", "ready-issuer")
+	require.NoError(t, err)
+	assert.Equal(t, readyIssuer.Spec, issuer.Spec)
+
+	readyCondition, err := informer.ReadyCondition("ns1", "ready-issuer")
+	require.NoError(t, err)
+	require.NotNil(t, readyCondition)
+	assert.Equal(t, v1alpha1.IssuerConditionReasonChecked, readyCondition.Reason)
+
+	ready, err := informer.IsReady("ns1", "ready-issuer")
+	require.NoError(t, err)
+	assert.True(t, ready)
+
+	// The Ready condition was observed for an older generation.
+	ready, err = informer.IsReady("ns2", "ready-issuer")
+	require.NoError(t, err)
+	assert.False(t, ready)
+
+	_, err = informer.Get("ns1", "missing-issuer")
+	assert.True(t, apierrors.IsNotFound(err))
+
+	// Issuers that are created later are added to the cache as typed issuers.
+	newIssuer := testutil.TestIssuer("new-issuer", testutil.SetTestIssuerNamespace("ns1"))
+	newIssuerContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(newIssuer)
+	require.NoError(t, err)
+	_, err = dynamicClient.Resource(resource).Namespace("ns1").Create(ctx, &unstructured.Unstructured{Object: newIssuerContent}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		_, err := informer.Get("ns1", "new-issuer")
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	names := map[string]bool{}
+	for len(names) < 3 {
+		select {
+		case issuer := <-added:
+			names[issuer.Namespace+"/"+issuer.Name] = true
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected 3 add events, got %v", names)
+		}
+	}
+	assert.True(t, names["ns1/new-issuer"])
+}
+
+func TestNewInvalidIssuerType(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(scheme)
+
+	// The issuer type must be registered in the scheme.
+	_, err := New(dynamicClient, scheme, &api.TestIssuer{}, metav1.NamespaceAll, 0)
+	assert.Error(t, err)
+}
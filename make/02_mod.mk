@@ -17,5 +17,7 @@ $(kind_cluster_config): make/config/kind/cluster.yaml | $(bin_dir)/scratch
 	sed -e 's|{{KIND_IMAGES}}|$(CURDIR)/$(images_tar_dir)|g' \
 	> $@
 
+include make/generate-crds.mk
 include make/test-e2e.mk
 include make/test-unit.mk
+include make/verify-apidiff.mk
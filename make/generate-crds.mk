@@ -0,0 +1,23 @@
+# Copyright 2023 The cert-manager Authors.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+.PHONY: generate-crds
+## Generate the CRD manifests of the issuer-lib API.
+## @category [shared] Generate/ Verify
+generate-crds: | $(NEEDS_CONTROLLER-GEN) $(NEEDS_YQ)
+	$(CONTROLLER-GEN) crd \
+		paths=./api/v1alpha1/... \
+		output:crd:artifacts:config=./deploy/crds
+
+shared_generate_targets += generate-crds
@@ -0,0 +1,42 @@
+# Copyright 2023 The cert-manager Authors.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+apidiff_version := v0.0.0-20240719175910-8a7402abbf56
+apidiff_base ?= origin/main
+apidiff_packages := \
+	github.com/cert-manager/issuer-lib/conditions \
+	github.com/cert-manager/issuer-lib/controllers \
+	github.com/cert-manager/issuer-lib/controllers/signer
+
+.PHONY: verify-apidiff
+## Verify that the stable packages have no incompatible API changes compared
+## to the git revision in apidiff_base (defaults to origin/main).
+## @category Testing
+#
+# `verify-apidiff` is not added to the `shared_verify_targets` variable, because
+# incompatible changes are allowed after the deprecation period described in the
+# README. The maintainers run it for PRs that change the stable packages and
+# decide whether the reported changes are acceptable.
+verify-apidiff: | $(NEEDS_GO) $(bin_dir)/scratch
+	@rm -rf $(bin_dir)/scratch/apidiff
+	@git worktree add --detach $(bin_dir)/scratch/apidiff/base $(apidiff_base) >/dev/null
+	@trap 'git worktree remove --force $(bin_dir)/scratch/apidiff/base' EXIT; \
+		for pkg in $(apidiff_packages); do \
+			name=$$(echo $${pkg} | tr / _); \
+			(cd $(bin_dir)/scratch/apidiff/base && $(GO) run golang.org/x/exp/cmd/apidiff@$(apidiff_version) -w $(CURDIR)/$(bin_dir)/scratch/apidiff/$${name}.export $${pkg}) || exit; \
+			echo "Incompatible changes of $${pkg}:"; \
+			$(GO) run golang.org/x/exp/cmd/apidiff@$(apidiff_version) -incompatible $(bin_dir)/scratch/apidiff/$${name}.export $${pkg} | tee $(bin_dir)/scratch/apidiff/$${name}.diff; \
+			! test -s $(bin_dir)/scratch/apidiff/$${name}.diff || failed=1; \
+		done; \
+		test -z "$${failed}"
@@ -0,0 +1,104 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package names generates stable, collision-resistant names for resources that
+// are derived from requests and issuers (eg. a ConfigMap per request, or a Secret
+// that stores the order ID of an external CA).
+//
+// A derived name consists of a human-readable prefix, built from the parts of
+// the name, and a hash of all parts. The prefix is truncated when the name would
+// exceed the maximum length, the hash keeps truncated names unique.
+package names
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// MaxNameLength is the maximum length of the name of most resources (eg.
+	// ConfigMaps and Secrets), which must be a DNS subdomain.
+	MaxNameLength = validation.DNS1123SubdomainMaxLength
+	// MaxLabelLength is the maximum length of names that must be a DNS label
+	// (eg. Services) and of label values.
+	MaxLabelLength = validation.DNS1123LabelMaxLength
+
+	// hashLength is the number of hex characters of the hash suffix.
+	hashLength = 16
+)
+
+// Derived returns a name for a resource that is derived from the parts, that is
+// at most maxLength characters long. The same parts always result in the same
+// name. The parts are joined using "-" and converted to a valid DNS label prefix,
+// followed by a hash of the parts, eg. "my-cert-1-config-0123456789abcdef".
+func Derived(maxLength int, parts ...string) string {
+	return derived(maxLength, parts, parts)
+}
+
+// ForObject returns a name for a resource that is derived from the object and
+// the suffix (eg. "order"), that is at most maxLength characters long. The
+// namespace of the object is included in the hash, so objects with the same name
+// in different namespaces result in different names.
+func ForObject(obj metav1.Object, maxLength int, suffix string) string {
+	return derived(
+		maxLength,
+		[]string{obj.GetName(), suffix},
+		[]string{obj.GetNamespace(), obj.GetName(), suffix},
+	)
+}
+
+func derived(maxLength int, prefixParts []string, hashParts []string) string {
+	hash := sha256.Sum256([]byte(strings.Join(hashParts, "\x00")))
+	suffix := hex.EncodeToString(hash[:])[:hashLength]
+
+	if maxLength <= hashLength {
+		return suffix[:max(maxLength, 0)]
+	}
+
+	prefix := sanitize(strings.Join(prefixParts, "-"))
+	if maxPrefixLength := maxLength - hashLength - 1; len(prefix) > maxPrefixLength {
+		prefix = strings.TrimRight(prefix[:maxPrefixLength], "-")
+	}
+	if prefix == "" {
+		return suffix
+	}
+
+	return prefix + "-" + suffix
+}
+
+// sanitize converts the value to lowercase alphanumeric characters and "-",
+// without leading or trailing "-".
+func sanitize(value string) string {
+	var builder strings.Builder
+	builder.Grow(len(value))
+
+	dash := false
+	for _, c := range strings.ToLower(value) {
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') {
+			builder.WriteRune(c)
+			dash = false
+		} else if !dash && builder.Len() > 0 {
+			builder.WriteByte('-')
+			dash = true
+		}
+	}
+
+	return strings.TrimRight(builder.String(), "-")
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package names
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func TestDerived(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name           string
+		maxLength      int
+		parts          []string
+		expectedPrefix string
+	}
+
+	tests := []testCase{
+		{
+			name:           "short-name",
+			maxLength:      MaxNameLength,
+			parts:          []string{"my-cert-1", "config"},
+			expectedPrefix: "my-cert-1-config-",
+		},
+		{
+			name:           "invalid-characters",
+			maxLength:      MaxNameLength,
+			parts:          []string{"My_Cert.1", "--config--"},
+			expectedPrefix: "my-cert-1-config-",
+		},
+		{
+			name:           "truncated-name",
+			maxLength:      MaxLabelLength,
+			parts:          []string{strings.Repeat("a", 40) + "-" + strings.Repeat("b", 40), "config"},
+			expectedPrefix: strings.Repeat("a", 40) + "-",
+		},
+		{
+			name:           "only-invalid-characters",
+			maxLength:      MaxNameLength,
+			parts:          []string{"__"},
+			expectedPrefix: "",
+		},
+		{
+			name:           "max-length-shorter-than-hash",
+			maxLength:      8,
+			parts:          []string{"my-cert-1"},
+			expectedPrefix: "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			name := Derived(tc.maxLength, tc.parts...)
+			assert.True(t, strings.HasPrefix(name, tc.expectedPrefix), name)
+			assert.LessOrEqual(t, len(name), tc.maxLength)
+			assert.Empty(t, validation.IsDNS1123Label(name))
+
+			// The name is stable.
+			assert.Equal(t, name, Derived(tc.maxLength, tc.parts...))
+		})
+	}
+}
+
+func TestDerivedUnique(t *testing.T) {
+	t.Parallel()
+
+	// Parts that result in the same prefix, or in the same truncated prefix,
+	// result in different names.
+	assert.NotEqual(t, Derived(MaxNameLength, "a-b", "c"), Derived(MaxNameLength, "a", "b-c"))
+	assert.NotEqual(t, Derived(MaxNameLength, "a.b"), Derived(MaxNameLength, "a_b"))
+
+	long := strings.Repeat("a", MaxLabelLength)
+	assert.NotEqual(t, Derived(MaxLabelLength, long, "1"), Derived(MaxLabelLength, long, "2"))
+}
+
+func TestForObject(t *testing.T) {
+	t.Parallel()
+
+	obj1 := &metav1.ObjectMeta{Namespace: "ns1", Name: "cr-1"}
+	obj2 := &metav1.ObjectMeta{Namespace: "ns2", Name: "cr-1"}
+
+	name := ForObject(obj1, MaxNameLength, "order")
+	require.True(t, strings.HasPrefix(name, "cr-1-order-"), name)
+	assert.Equal(t, name, ForObject(obj1, MaxNameLength, "order"))
+	assert.NotEqual(t, name, ForObject(obj2, MaxNameLength, "order"))
+	assert.NotEqual(t, name, ForObject(obj1, MaxNameLength, "config"))
+}
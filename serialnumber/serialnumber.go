@@ -0,0 +1,166 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package serialnumber contains the policies that determine the serial numbers
+// of the certificates that are signed by the CA helpers of issuer-lib (eg. the
+// simulator), so organizations with serial number formatting requirements can
+// comply without forking the helpers.
+//
+// RFC 5280 requires serial numbers to be positive and at most 20 octets long,
+// every serial number returned by a Policy is checked using Validate.
+package serialnumber
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"k8s.io/utils/clock"
+)
+
+// maxBits is the maximum length of a serial number in bits. RFC 5280 allows
+// 20 octets, the most significant bit must be zero for the number to be positive.
+const maxBits = 20*8 - 1
+
+// Policy returns the serial number of a certificate that is about to be signed.
+type Policy interface {
+	SerialNumber(template *x509.Certificate) (*big.Int, error)
+}
+
+// Default is the policy that is used when no policy is configured.
+var Default Policy = Random{}
+
+// PolicyFunc is a Policy implemented by a function, eg. to use serial numbers
+// that are allocated by the CA or by an external system.
+type PolicyFunc func(template *x509.Certificate) (*big.Int, error)
+
+func (f PolicyFunc) SerialNumber(template *x509.Certificate) (*big.Int, error) {
+	return f(template)
+}
+
+// Random generates random serial numbers.
+type Random struct {
+	// Bits is the number of random bits, defaults to 128.
+	Bits int
+
+	// Rand is the source of randomness, defaults to crypto/rand.Reader.
+	Rand io.Reader
+}
+
+func (p Random) SerialNumber(_ *x509.Certificate) (*big.Int, error) {
+	bits := p.Bits
+	if bits == 0 {
+		bits = 128
+	}
+
+	return randomSerialNumber(p.Rand, bits)
+}
+
+// TimestampPrefixed generates serial numbers that start with the number of
+// seconds since the Unix epoch, followed by random bits. Serial numbers of
+// certificates that were signed later compare as larger numbers.
+type TimestampPrefixed struct {
+	// RandomBits is the number of random bits after the timestamp, defaults to 64.
+	RandomBits int
+
+	// Clock is used to get the current time, defaults to the real clock.
+	Clock clock.PassiveClock
+
+	// Rand is the source of randomness, defaults to crypto/rand.Reader.
+	Rand io.Reader
+}
+
+func (p TimestampPrefixed) SerialNumber(_ *x509.Certificate) (*big.Int, error) {
+	randomBits := p.RandomBits
+	if randomBits == 0 {
+		randomBits = 64
+	}
+
+	clk := p.Clock
+	if clk == nil {
+		clk = clock.RealClock{}
+	}
+
+	random, err := randomSerialNumber(p.Rand, randomBits)
+	if err != nil {
+		return nil, err
+	}
+
+	serialNumber := new(big.Int).Lsh(big.NewInt(clk.Now().Unix()), uint(randomBits))
+	return serialNumber.Or(serialNumber, random), nil
+}
+
+// randomSerialNumber returns a positive random number of at most the given
+// number of bits.
+func randomSerialNumber(reader io.Reader, bits int) (*big.Int, error) {
+	if bits <= 0 || bits > maxBits {
+		return nil, fmt.Errorf("the number of random bits must be between 1 and %d, got %d", maxBits, bits)
+	}
+
+	if reader == nil {
+		reader = rand.Reader
+	}
+
+	for {
+		serialNumber, err := rand.Int(reader, new(big.Int).Lsh(big.NewInt(1), uint(bits)))
+		if err != nil {
+			return nil, err
+		}
+
+		// Zero is not a valid serial number.
+		if serialNumber.Sign() > 0 {
+			return serialNumber, nil
+		}
+	}
+}
+
+// Validate returns an error if the serial number is not positive or longer
+// than 20 octets.
+func Validate(serialNumber *big.Int) error {
+	switch {
+	case serialNumber == nil:
+		return errors.New("serial number is not set")
+	case serialNumber.Sign() <= 0:
+		return fmt.Errorf("serial number %s is not positive", serialNumber)
+	case serialNumber.BitLen() > maxBits:
+		return fmt.Errorf("serial number %s is longer than 20 octets", serialNumber)
+	default:
+		return nil
+	}
+}
+
+// Generate returns the serial number that the policy generates for the
+// template, using the Default policy if the policy is nil. An error is
+// returned if the serial number is invalid.
+func Generate(policy Policy, template *x509.Certificate) (*big.Int, error) {
+	if policy == nil {
+		policy = Default
+	}
+
+	serialNumber, err := policy.SerialNumber(template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+
+	if err := Validate(serialNumber); err != nil {
+		return nil, err
+	}
+
+	return serialNumber, nil
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serialnumber
+
+import (
+	"crypto/x509"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestRandom(t *testing.T) {
+	t.Parallel()
+
+	first, err := Generate(Random{}, &x509.Certificate{})
+	require.NoError(t, err)
+	assert.LessOrEqual(t, first.BitLen(), 128)
+
+	second, err := Generate(nil, &x509.Certificate{})
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+
+	small, err := Generate(Random{Bits: 8}, &x509.Certificate{})
+	require.NoError(t, err)
+	assert.LessOrEqual(t, small.BitLen(), 8)
+
+	_, err = Generate(Random{Bits: 160}, &x509.Certificate{})
+	assert.ErrorContains(t, err, "the number of random bits must be between 1 and 159, got 160")
+}
+
+func TestTimestampPrefixed(t *testing.T) {
+	t.Parallel()
+
+	fakeClock := clocktesting.NewFakeClock(time.Unix(1700000000, 0))
+	policy := TimestampPrefixed{RandomBits: 32, Clock: fakeClock}
+
+	first, err := Generate(policy, &x509.Certificate{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1700000000), new(big.Int).Rsh(first, 32).Int64())
+
+	fakeClock.Step(time.Second)
+	second, err := Generate(policy, &x509.Certificate{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, second.Cmp(first), "later serial numbers must be larger")
+
+	_, err = Generate(TimestampPrefixed{RandomBits: 150, Clock: fakeClock}, &x509.Certificate{})
+	assert.ErrorContains(t, err, "is longer than 20 octets")
+}
+
+func TestPolicyFunc(t *testing.T) {
+	t.Parallel()
+
+	caProvided := PolicyFunc(func(template *x509.Certificate) (*big.Int, error) {
+		if template.Subject.CommonName == "unavailable" {
+			return nil, errors.New("CA is unavailable")
+		}
+		return big.NewInt(42), nil
+	})
+
+	serialNumber, err := Generate(caProvided, &x509.Certificate{})
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(42), serialNumber)
+
+	template := &x509.Certificate{}
+	template.Subject.CommonName = "unavailable"
+	_, err = Generate(caProvided, template)
+	assert.EqualError(t, err, "failed to generate serial number: CA is unavailable")
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, Validate(big.NewInt(1)))
+	assert.NoError(t, Validate(new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 159), big.NewInt(1))))
+
+	assert.EqualError(t, Validate(nil), "serial number is not set")
+	assert.EqualError(t, Validate(big.NewInt(0)), "serial number 0 is not positive")
+	assert.EqualError(t, Validate(big.NewInt(-1)), "serial number -1 is not positive")
+	assert.ErrorContains(t, Validate(new(big.Int).Lsh(big.NewInt(1), 159)), "is longer than 20 octets")
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ssapatch generates server-side apply patches that set the status of
+// issuers, CertificateRequests and Kubernetes CertificateSigningRequests. These
+// are the same patches that the issuer-lib controllers apply, so they can be used
+// to patch the status outside of the controllers (eg. from CLI tools or migration
+// jobs) while still merging cleanly with the fields managed by the controllers.
+//
+// The functions in this package are covered by the same stability guarantees as
+// the controllers package: their signatures will not change within a minor release.
+//
+// The returned object only has its name and namespace set. It should be passed
+// to the Patch call together with the patch, the response of the API server is
+// decoded into it:
+//
+//	issuer, patch, err := ssapatch.IssuerStatus[api.SimpleIssuer](scheme, name, namespace, status)
+//	if err != nil {
+//		return err
+//	}
+//	err = cl.Status().Patch(ctx, issuer, patch, &client.SubResourcePatchOptions{
+//		PatchOptions: client.PatchOptions{FieldManager: "my-tool", Force: ptr.To(true)},
+//	})
+package ssapatch
+
+import (
+	"encoding/json"
+	"fmt"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	v1 "k8s.io/client-go/applyconfigurations/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+)
+
+// Object is satisfied by the pointer type PO of a Kubernetes API type O.
+type Object[O any] interface {
+	*O
+	client.Object
+}
+
+// Issuer is satisfied by the pointer type PO of an issuer API type O.
+type Issuer[O any] interface {
+	*O
+	v1alpha1.Issuer
+}
+
+// Status returns a new object of type PO with the name and namespace set, and
+// a server-side apply patch that sets the status of that object. The group,
+// version and kind of the object are looked up in the scheme.
+func Status[O any, PO Object[O], S any](
+	scheme *runtime.Scheme,
+	name string,
+	namespace string,
+	status *S,
+) (PO, client.Patch, error) {
+	obj := PO(new(O))
+
+	gvk, err := apiutil.GVKForObject(obj, scheme)
+	if err != nil {
+		return obj, nil, err
+	}
+
+	patch, err := ObjectStatus(obj, gvk, name, namespace, status)
+	return obj, patch, err
+}
+
+// IssuerStatus returns a new issuer of type PO with the name and namespace set,
+// and a server-side apply patch that sets the status of that issuer.
+func IssuerStatus[O any, PO Issuer[O]](
+	scheme *runtime.Scheme,
+	name string,
+	namespace string,
+	status *v1alpha1.IssuerStatus,
+) (PO, client.Patch, error) {
+	return Status[O, PO](scheme, name, namespace, status)
+}
+
+// CertificateRequestStatus returns a new CertificateRequest with the name and
+// namespace set, and a server-side apply patch that sets the status of that
+// CertificateRequest.
+func CertificateRequestStatus(
+	name string,
+	namespace string,
+	status *cmapi.CertificateRequestStatus,
+) (*cmapi.CertificateRequest, client.Patch, error) {
+	cr := &cmapi.CertificateRequest{}
+	patch, err := ObjectStatus(cr, cmapi.SchemeGroupVersion.WithKind(cmapi.CertificateRequestKind), name, namespace, status)
+	return cr, patch, err
+}
+
+// CertificateSigningRequestStatus returns a new CertificateSigningRequest with
+// the name set, and a server-side apply patch that sets the status of that
+// CertificateSigningRequest.
+func CertificateSigningRequestStatus(
+	name string,
+	status *certificatesv1.CertificateSigningRequestStatus,
+) (*certificatesv1.CertificateSigningRequest, client.Patch, error) {
+	csr := &certificatesv1.CertificateSigningRequest{}
+	patch, err := ObjectStatus(csr, certificatesv1.SchemeGroupVersion.WithKind("CertificateSigningRequest"), name, "", status)
+	return csr, patch, err
+}
+
+// ObjectStatus sets the name and namespace on obj and returns a server-side
+// apply patch that sets the status of that object to status. It can be used
+// when the type of the object is only known at runtime.
+func ObjectStatus(
+	obj client.Object,
+	gvk schema.GroupVersionKind,
+	name string,
+	namespace string,
+	status any,
+) (client.Patch, error) {
+	if gvk.Empty() {
+		return nil, fmt.Errorf("the group, version and kind of %T must be set to generate a status patch", obj)
+	}
+
+	// This object is used to deduce the name & namespace + unmarshall the return value in
+	obj.SetName(name)
+	obj.SetNamespace(namespace)
+
+	// This object is used to render the patch
+	b := &statusApplyConfiguration{
+		ObjectMetaApplyConfiguration: &v1.ObjectMetaApplyConfiguration{},
+	}
+	b.WithName(name)
+	b.WithNamespace(namespace)
+	b.WithKind(gvk.Kind)
+	b.WithAPIVersion(gvk.GroupVersion().Identifier())
+	b.Status = status
+
+	encodedPatch, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+
+	return applyPatch{encodedPatch}, nil
+}
+
+type statusApplyConfiguration struct {
+	v1.TypeMetaApplyConfiguration    `json:",inline"`
+	*v1.ObjectMetaApplyConfiguration `json:"metadata,omitempty"`
+	Status                           any `json:"status,omitempty"`
+}
+
+type applyPatch struct {
+	patch []byte
+}
+
+var _ client.Patch = applyPatch{}
+
+func (p applyPatch) Data(_ client.Object) ([]byte, error) {
+	return p.patch, nil
+}
+
+func (p applyPatch) Type() types.PatchType {
+	return types.ApplyPatchType
+}
+
+func (p applyPatch) String() string {
+	return string(p.patch)
+}
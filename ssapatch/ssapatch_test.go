@@ -0,0 +1,117 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ssapatch_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+	"github.com/cert-manager/issuer-lib/ssapatch"
+	"github.com/cert-manager/issuer-lib/testing/ssafake"
+)
+
+func TestIssuerStatus(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, api.AddToScheme(scheme))
+
+	issuer := &api.TestIssuer{
+		ObjectMeta: metav1.ObjectMeta{Name: "issuer-1", Namespace: "ns1"},
+	}
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(issuer).
+		WithStatusSubresource(issuer).
+		WithInterceptorFuncs(ssafake.NewApplier().InterceptorFuncs()).
+		Build()
+
+	patchedIssuer, patch, err := ssapatch.IssuerStatus[api.TestIssuer](scheme, "issuer-1", "ns1", &v1alpha1.IssuerStatus{
+		Conditions: []cmapi.IssuerCondition{
+			{Type: cmapi.IssuerConditionReady, Status: cmmeta.ConditionTrue, Reason: "Checked"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, client.ObjectKeyFromObject(issuer), client.ObjectKeyFromObject(patchedIssuer))
+	assert.JSONEq(t, `{
+		"kind": "TestIssuer",
+		"apiVersion": "testing.cert-manager.io/api",
+		"metadata": {"name": "issuer-1", "namespace": "ns1"},
+		"status": {"conditions": [{"type": "Ready", "status": "True", "reason": "Checked"}]}
+	}`, fmt.Sprint(patch))
+
+	require.NoError(t, cl.Status().Patch(context.TODO(), patchedIssuer, patch, &client.SubResourcePatchOptions{
+		PatchOptions: client.PatchOptions{FieldManager: "test", Force: ptr.To(true)},
+	}))
+	require.Len(t, patchedIssuer.Status.Conditions, 1)
+	assert.Equal(t, cmmeta.ConditionTrue, patchedIssuer.Status.Conditions[0].Status)
+}
+
+func TestStatusUnknownType(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := ssapatch.IssuerStatus[api.TestIssuer](runtime.NewScheme(), "issuer-1", "ns1", &v1alpha1.IssuerStatus{})
+	require.Error(t, err)
+}
+
+func TestCertificateSigningRequestStatus(t *testing.T) {
+	t.Parallel()
+
+	csr, patch, err := ssapatch.CertificateSigningRequestStatus("csr-1", &certificatesv1.CertificateSigningRequestStatus{
+		Certificate: []byte("cert"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "csr-1", csr.Name)
+	assert.JSONEq(t, `{
+		"kind": "CertificateSigningRequest",
+		"apiVersion": "certificates.k8s.io/v1",
+		"metadata": {"name": "csr-1", "namespace": ""},
+		"status": {"certificate": "Y2VydA=="}
+	}`, fmt.Sprint(patch))
+}
+
+func ExampleCertificateRequestStatus() {
+	cr, patch, err := ssapatch.CertificateRequestStatus("cr-1", "ns1", &cmapi.CertificateRequestStatus{
+		Conditions: []cmapi.CertificateRequestCondition{
+			{Type: cmapi.CertificateRequestConditionReady, Status: cmmeta.ConditionFalse, Reason: cmapi.CertificateRequestReasonPending},
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	// Apply the patch using eg. cl.Status().Patch(ctx, cr, patch, ...).
+	fmt.Println(cr.Namespace, cr.Name)
+	fmt.Println(patch)
+	// Output:
+	// ns1 cr-1
+	// {"kind":"CertificateRequest","apiVersion":"cert-manager.io/v1","metadata":{"name":"cr-1","namespace":"ns1"},"status":{"conditions":[{"type":"Ready","status":"False","reason":"Pending"}]}}
+}
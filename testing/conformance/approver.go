@@ -0,0 +1,64 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conformance
+
+import (
+	"context"
+
+	cmutil "github.com/cert-manager/cert-manager/pkg/api/util"
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Approver approves every CertificateRequest that is neither approved nor
+// denied, in place of the cert-manager approver, which does not run in an
+// envtest API server. It must only be used in test environments.
+type Approver struct {
+	// Client updates the status of the requests.
+	Client client.Client
+}
+
+// Reconcile approves the request if it was not approved or denied yet.
+func (a *Approver) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	cr := &cmapi.CertificateRequest{}
+	if err := a.Client.Get(ctx, req.NamespacedName, cr); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if cmutil.CertificateRequestIsApproved(cr) || cmutil.CertificateRequestIsDenied(cr) {
+		return ctrl.Result{}, nil
+	}
+
+	cmutil.SetCertificateRequestCondition(
+		cr,
+		cmapi.CertificateRequestConditionApproved,
+		cmmeta.ConditionTrue,
+		"conformance.cert-manager.io",
+		"Approved by the conformance test approver",
+	)
+	return ctrl.Result{}, a.Client.Status().Update(ctx, cr)
+}
+
+// SetupWithManager sets up the approver with the Manager.
+func (a *Approver) SetupWithManager(_ context.Context, mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("conformance-approver").
+		For(&cmapi.CertificateRequest{}).
+		Complete(a)
+}
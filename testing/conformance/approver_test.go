@@ -0,0 +1,74 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conformance
+
+import (
+	"context"
+	"testing"
+
+	cmutil "github.com/cert-manager/cert-manager/pkg/api/util"
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestApprover(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name     string
+		denied   bool
+		approved bool
+	}
+
+	tests := []testCase{
+		{name: "pending-request-is-approved", approved: true},
+		{name: "denied-request-stays-denied", denied: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cl := newGenerationClient(t)
+			cr := &cmapi.CertificateRequest{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "cr1"}}
+			if tc.denied {
+				cmutil.SetCertificateRequestCondition(cr, cmapi.CertificateRequestConditionDenied, cmmeta.ConditionTrue, "Denied", "")
+			}
+			require.NoError(t, cl.Create(context.TODO(), cr))
+
+			approver := &Approver{Client: cl}
+			_, err := approver.Reconcile(context.TODO(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(cr)})
+			require.NoError(t, err)
+
+			require.NoError(t, cl.Get(context.TODO(), client.ObjectKeyFromObject(cr), cr))
+			require.Equal(t, tc.approved, cmutil.CertificateRequestIsApproved(cr))
+			require.Equal(t, tc.denied, cmutil.CertificateRequestIsDenied(cr))
+		})
+	}
+
+	t.Run("deleted-request-is-ignored", func(t *testing.T) {
+		t.Parallel()
+
+		approver := &Approver{Client: newGenerationClient(t)}
+		_, err := approver.Reconcile(context.TODO(), ctrl.Request{NamespacedName: client.ObjectKey{Namespace: "ns1", Name: "cr1"}})
+		require.NoError(t, err)
+	})
+}
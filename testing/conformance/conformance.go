@@ -0,0 +1,343 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package conformance runs a batch of conformance tests against one or more
+// issuer types and produces a machine-readable capability report: the features
+// that every issuer type declares as supported and the result and duration of
+// every test. The report can be written as JSON or YAML, eg. for publishing in
+// the README of an issuer project or for the automation of a catalog of
+// supported issuers:
+//
+//	report := (&conformance.Runner{Name: "example.com/my-issuer", Tests: tests}).
+//		Run(ctx, &api.MyIssuer{...}, &api.MyClusterIssuer{...})
+//	report.ReportFailures(t)
+//	if err := report.WriteFile("conformance.yaml"); err != nil { ... }
+package conformance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+// Result is the outcome of a conformance test.
+type Result string
+
+const (
+	// ResultPassed means that the test returned no error.
+	ResultPassed Result = "passed"
+	// ResultSkipped means that the issuer does not support the feature of the
+	// test, or that the test returned an error created by Skip.
+	ResultSkipped Result = "skipped"
+	// ResultFailed means that the test returned an error, panicked or did not
+	// return within the timeout.
+	ResultFailed Result = "failed"
+)
+
+// knownFeatures are the features that are listed in the capability report of
+// every issuer type.
+var knownFeatures = []signer.Feature{
+	signer.FeatureEd25519,
+	signer.FeatureIPSANs,
+	signer.FeatureLiteralSubject,
+	signer.FeatureIsCA,
+	signer.FeatureSignResult,
+}
+
+// Test is a conformance test that is run for every issuer type.
+type Test struct {
+	// Name identifies the test in the report.
+	Name string
+
+	// Feature is the optional feature that is tested. The test is skipped for
+	// issuer types that implement signer.FeatureSetProvider and do not declare
+	// the feature as supported. Leave it empty for tests of mandatory behaviour.
+	Feature signer.Feature
+
+	// Run runs the test for an issuer. The test fails if it returns an error,
+	// unless the error was created by Skip.
+	Run func(ctx context.Context, issuerObject v1alpha1.Issuer) error
+}
+
+type skipError struct {
+	reason string
+}
+
+func (e skipError) Error() string {
+	return e.reason
+}
+
+// Skip returns an error that marks a test as skipped, with the formatted reason
+// as message in the report.
+func Skip(format string, args ...any) error {
+	return skipError{reason: fmt.Sprintf(format, args...)}
+}
+
+// FeatureReport describes whether an issuer type supports a feature.
+type FeatureReport struct {
+	Feature   signer.Feature `json:"feature"`
+	Supported bool           `json:"supported"`
+}
+
+// TestReport is the outcome of a test for an issuer type.
+type TestReport struct {
+	Name            string         `json:"name"`
+	Feature         signer.Feature `json:"feature,omitempty"`
+	Result          Result         `json:"result"`
+	DurationSeconds float64        `json:"durationSeconds"`
+	Message         string         `json:"message,omitempty"`
+}
+
+// Summary counts the test results.
+type Summary struct {
+	Passed  int `json:"passed"`
+	Skipped int `json:"skipped"`
+	Failed  int `json:"failed"`
+}
+
+func (s *Summary) add(result Result) {
+	switch result {
+	case ResultPassed:
+		s.Passed++
+	case ResultSkipped:
+		s.Skipped++
+	case ResultFailed:
+		s.Failed++
+	}
+}
+
+// IssuerReport is the capability report of an issuer type.
+type IssuerReport struct {
+	IssuerType string `json:"issuerType"`
+	// FeaturesDeclared is false if the issuer type does not implement
+	// signer.FeatureSetProvider, in which case all features are assumed to be
+	// supported.
+	FeaturesDeclared bool            `json:"featuresDeclared"`
+	Features         []FeatureReport `json:"features"`
+	// MaxDuration is the maximum duration of the certificates, empty if it is
+	// not limited.
+	MaxDuration string       `json:"maxDuration,omitempty"`
+	Tests       []TestReport `json:"tests"`
+	Summary     Summary      `json:"summary"`
+}
+
+// Report is the capability report of all issuer types of a project.
+type Report struct {
+	Name        string         `json:"name,omitempty"`
+	GeneratedAt time.Time      `json:"generatedAt"`
+	Issuers     []IssuerReport `json:"issuers"`
+	Summary     Summary        `json:"summary"`
+}
+
+// Failed returns true if any test failed.
+func (r Report) Failed() bool {
+	return r.Summary.Failed > 0
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WriteYAML writes the report as YAML, using the same field names as the JSON
+// encoding.
+func (r Report) WriteYAML(w io.Writer) error {
+	data, err := yaml.Marshal(r)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// WriteFile writes the report to a file, as YAML if the file has a .yaml or
+// .yml extension and as JSON otherwise.
+func (r Report) WriteFile(path string) (err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, f.Close())
+	}()
+
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		return r.WriteYAML(f)
+	default:
+		return r.WriteJSON(f)
+	}
+}
+
+// TestingT is the subset of testing.TB that is used by ReportFailures.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// ReportFailures reports every failed test as a test error.
+func (r Report) ReportFailures(t TestingT) {
+	t.Helper()
+
+	for _, issuerReport := range r.Issuers {
+		for _, testReport := range issuerReport.Tests {
+			if testReport.Result == ResultFailed {
+				t.Errorf("%s/%s: %s", issuerReport.IssuerType, testReport.Name, testReport.Message)
+			}
+		}
+	}
+}
+
+// Runner runs the conformance tests for a set of issuer types.
+type Runner struct {
+	// Name identifies the issuer project in the report, eg. its Go module path.
+	Name string
+
+	// Tests are the tests that are run for every issuer type, in order.
+	Tests []Test
+
+	// Timeout is the timeout of every test, defaults to 1 minute. The context
+	// passed to a test is cancelled after the timeout, a test that does not
+	// return in time is failed and not waited for.
+	Timeout time.Duration
+
+	// Clock returns the current time, defaults to time.Now.
+	Clock func() time.Time
+}
+
+func (r *Runner) now() time.Time {
+	if r.Clock != nil {
+		return r.Clock()
+	}
+	return time.Now()
+}
+
+// Run runs all tests for every issuer and returns the capability report. The
+// issuers are example objects of the issuer types, typically one namespaced
+// and one cluster-scoped issuer.
+func (r *Runner) Run(ctx context.Context, issuers ...v1alpha1.Issuer) Report {
+	report := Report{
+		Name:        r.Name,
+		GeneratedAt: r.now().UTC(),
+		Issuers:     make([]IssuerReport, 0, len(issuers)),
+	}
+
+	for _, issuerObject := range issuers {
+		issuerReport := r.runIssuer(ctx, issuerObject)
+		report.Summary.Passed += issuerReport.Summary.Passed
+		report.Summary.Skipped += issuerReport.Summary.Skipped
+		report.Summary.Failed += issuerReport.Summary.Failed
+		report.Issuers = append(report.Issuers, issuerReport)
+	}
+
+	return report
+}
+
+func (r *Runner) runIssuer(ctx context.Context, issuerObject v1alpha1.Issuer) IssuerReport {
+	issuerReport := IssuerReport{
+		IssuerType: issuerObject.GetIssuerTypeIdentifier(),
+		Features:   make([]FeatureReport, 0, len(knownFeatures)),
+		Tests:      make([]TestReport, 0, len(r.Tests)),
+	}
+
+	provider, declared := issuerObject.(signer.FeatureSetProvider)
+	var featureSet signer.FeatureSet
+	if declared {
+		featureSet = provider.FeatureSet()
+		if featureSet.MaxDuration > 0 {
+			issuerReport.MaxDuration = featureSet.MaxDuration.String()
+		}
+	}
+	issuerReport.FeaturesDeclared = declared
+	supports := func(feature signer.Feature) bool {
+		return !declared || featureSet.Supports(feature)
+	}
+
+	for _, feature := range knownFeatures {
+		issuerReport.Features = append(issuerReport.Features, FeatureReport{
+			Feature:   feature,
+			Supported: supports(feature),
+		})
+	}
+
+	for _, test := range r.Tests {
+		testReport := TestReport{
+			Name:    test.Name,
+			Feature: test.Feature,
+		}
+		if test.Feature != "" && !supports(test.Feature) {
+			testReport.Result = ResultSkipped
+			testReport.Message = fmt.Sprintf("the %s issuer does not support the %s feature", issuerReport.IssuerType, test.Feature)
+		} else {
+			start := r.now()
+			testReport.Result, testReport.Message = r.runTest(ctx, test, issuerObject)
+			testReport.DurationSeconds = r.now().Sub(start).Seconds()
+		}
+		issuerReport.Summary.add(testReport.Result)
+		issuerReport.Tests = append(issuerReport.Tests, testReport)
+	}
+
+	return issuerReport
+}
+
+// runTest runs a single test with the timeout and recovers from panics.
+func (r *Runner) runTest(ctx context.Context, test Test, issuerObject v1alpha1.Issuer) (Result, string) {
+	timeout := r.Timeout
+	if timeout == 0 {
+		timeout = time.Minute
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				errCh <- fmt.Errorf("test panicked: %v", recovered)
+			}
+		}()
+		errCh <- test.Run(ctx, issuerObject)
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		return ResultFailed, fmt.Sprintf("test did not return within %s", timeout)
+	}
+
+	var skip skipError
+	switch {
+	case err == nil:
+		return ResultPassed, ""
+	case errors.As(err, &skip):
+		return ResultSkipped, skip.reason
+	default:
+		return ResultFailed, err.Error()
+	}
+}
@@ -0,0 +1,269 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+)
+
+type featureSetIssuer struct {
+	*api.TestIssuer
+	featureSet signer.FeatureSet
+}
+
+func (i featureSetIssuer) FeatureSet() signer.FeatureSet {
+	return i.featureSet
+}
+
+// steppingClock returns a clock that advances by one second on every call.
+func steppingClock() func() time.Time {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return func() time.Time {
+		current := now
+		now = now.Add(time.Second)
+		return current
+	}
+}
+
+func testRunner() *Runner {
+	return &Runner{
+		Name:  "example.com/issuer",
+		Clock: steppingClock(),
+		Tests: []Test{
+			{
+				Name: "sign",
+				Run:  func(context.Context, v1alpha1.Issuer) error { return nil },
+			},
+			{
+				Name:    "ed25519",
+				Feature: signer.FeatureEd25519,
+				Run:     func(context.Context, v1alpha1.Issuer) error { return nil },
+			},
+			{
+				Name: "explicit-skip",
+				Run: func(context.Context, v1alpha1.Issuer) error {
+					return fmt.Errorf("wrapped: %w", Skip("no CA available for %s", "test"))
+				},
+			},
+			{
+				Name: "fails",
+				Run: func(context.Context, v1alpha1.Issuer) error {
+					return errors.New("[error]")
+				},
+			},
+		},
+	}
+}
+
+func testIssuers() []v1alpha1.Issuer {
+	return []v1alpha1.Issuer{
+		featureSetIssuer{
+			TestIssuer: &api.TestIssuer{},
+			featureSet: signer.FeatureSet{
+				Features:    []signer.Feature{signer.FeatureIPSANs},
+				MaxDuration: 90 * 24 * time.Hour,
+			},
+		},
+		&api.TestClusterIssuer{},
+	}
+}
+
+func TestRun(t *testing.T) {
+	report := testRunner().Run(context.TODO(), testIssuers()...)
+
+	require.Equal(t, "example.com/issuer", report.Name)
+	require.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), report.GeneratedAt)
+	require.Equal(t, Summary{Passed: 3, Skipped: 3, Failed: 2}, report.Summary)
+	require.True(t, report.Failed())
+	require.Len(t, report.Issuers, 2)
+
+	declared := report.Issuers[0]
+	require.Equal(t, "testissuers.testing.cert-manager.io", declared.IssuerType)
+	require.True(t, declared.FeaturesDeclared)
+	require.Equal(t, "2160h0m0s", declared.MaxDuration)
+	require.Equal(t, []FeatureReport{
+		{Feature: signer.FeatureEd25519, Supported: false},
+		{Feature: signer.FeatureIPSANs, Supported: true},
+		{Feature: signer.FeatureLiteralSubject, Supported: false},
+		{Feature: signer.FeatureIsCA, Supported: false},
+		{Feature: signer.FeatureSignResult, Supported: false},
+	}, declared.Features)
+	require.Equal(t, []TestReport{
+		{Name: "sign", Result: ResultPassed, DurationSeconds: 1},
+		{Name: "ed25519", Feature: signer.FeatureEd25519, Result: ResultSkipped, Message: "the testissuers.testing.cert-manager.io issuer does not support the Ed25519 feature"},
+		{Name: "explicit-skip", Result: ResultSkipped, DurationSeconds: 1, Message: "no CA available for test"},
+		{Name: "fails", Result: ResultFailed, DurationSeconds: 1, Message: "[error]"},
+	}, declared.Tests)
+	require.Equal(t, Summary{Passed: 1, Skipped: 2, Failed: 1}, declared.Summary)
+
+	// Issuers that do not declare their features are assumed to support all
+	// features.
+	undeclared := report.Issuers[1]
+	require.False(t, undeclared.FeaturesDeclared)
+	require.Empty(t, undeclared.MaxDuration)
+	for _, feature := range undeclared.Features {
+		require.True(t, feature.Supported, feature.Feature)
+	}
+	require.Equal(t, ResultPassed, undeclared.Tests[1].Result)
+	require.Equal(t, Summary{Passed: 2, Skipped: 1, Failed: 1}, undeclared.Summary)
+}
+
+func TestRunFailsPanicsAndTimeouts(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	report := (&Runner{
+		Timeout: 10 * time.Millisecond,
+		Tests: []Test{
+			{
+				Name: "panics",
+				Run:  func(context.Context, v1alpha1.Issuer) error { panic("[panic]") },
+			},
+			{
+				Name: "hangs",
+				Run: func(context.Context, v1alpha1.Issuer) error {
+					<-block
+					return nil
+				},
+			},
+		},
+	}).Run(context.TODO(), &api.TestIssuer{})
+
+	tests := report.Issuers[0].Tests
+	require.Equal(t, ResultFailed, tests[0].Result)
+	require.Equal(t, "test panicked: [panic]", tests[0].Message)
+	require.Equal(t, ResultFailed, tests[1].Result)
+	require.Equal(t, "test did not return within 10ms", tests[1].Message)
+}
+
+func TestWriteJSON(t *testing.T) {
+	report := (&Runner{
+		Name:  "example.com/issuer",
+		Clock: steppingClock(),
+		Tests: []Test{{
+			Name: "sign",
+			Run:  func(context.Context, v1alpha1.Issuer) error { return nil },
+		}},
+	}).Run(context.TODO(), &api.TestIssuer{})
+
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteJSON(&buf))
+	require.Equal(t, `{
+  "name": "example.com/issuer",
+  "generatedAt": "2024-01-01T00:00:00Z",
+  "issuers": [
+    {
+      "issuerType": "testissuers.testing.cert-manager.io",
+      "featuresDeclared": false,
+      "features": [
+        {
+          "feature": "Ed25519",
+          "supported": true
+        },
+        {
+          "feature": "IPSANs",
+          "supported": true
+        },
+        {
+          "feature": "LiteralSubject",
+          "supported": true
+        },
+        {
+          "feature": "IsCA",
+          "supported": true
+        },
+        {
+          "feature": "SignResult",
+          "supported": true
+        }
+      ],
+      "tests": [
+        {
+          "name": "sign",
+          "result": "passed",
+          "durationSeconds": 1
+        }
+      ],
+      "summary": {
+        "passed": 1,
+        "skipped": 0,
+        "failed": 0
+      }
+    }
+  ],
+  "summary": {
+    "passed": 1,
+    "skipped": 0,
+    "failed": 0
+  }
+}
+`, buf.String())
+}
+
+func TestWriteFile(t *testing.T) {
+	report := testRunner().Run(context.TODO(), testIssuers()...)
+	dir := t.TempDir()
+
+	yamlPath := filepath.Join(dir, "report.yaml")
+	require.NoError(t, report.WriteFile(yamlPath))
+	data, err := os.ReadFile(yamlPath)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(data), "generatedAt: \"2024-01-01T00:00:00Z\"\n"), string(data))
+	require.Contains(t, string(data), "issuerType: testissuers.testing.cert-manager.io\n")
+	require.Contains(t, string(data), "maxDuration: 2160h0m0s\n")
+
+	jsonPath := filepath.Join(dir, "report.json")
+	require.NoError(t, report.WriteFile(jsonPath))
+	data, err = os.ReadFile(jsonPath)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(data), "{\n  \"name\": \"example.com/issuer\",\n"), string(data))
+}
+
+type recordingT struct {
+	errors []string
+}
+
+func (r *recordingT) Helper() {}
+
+func (r *recordingT) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestReportFailures(t *testing.T) {
+	report := testRunner().Run(context.TODO(), testIssuers()...)
+
+	rt := &recordingT{}
+	report.ReportFailures(rt)
+	require.Equal(t, []string{
+		"testissuers.testing.cert-manager.io/fails: [error]",
+		"testclusterissuers.testing.cert-manager.io/fails: [error]",
+	}, rt.errors)
+}
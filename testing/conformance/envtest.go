@@ -0,0 +1,157 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conformance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/config"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
+)
+
+// Envtest runs the conformance tests against an envtest API server instead of
+// a cluster. The API server runs no cert-manager controllers, so an Approver
+// approves the CertificateRequests, and the issuers of a namespaced type must
+// be created in a namespace that exists in a new API server, eg. "default".
+// The tests that need more than an API server and the controllers of the
+// project are not part of SmokeTests.
+//
+// The envtest binaries are located as documented by envtest, eg. through the
+// KUBEBUILDER_ASSETS environment variable.
+type Envtest struct {
+	// CRDPaths are the paths of the CRDs that are installed, they must include
+	// the cert-manager CRDs and the CRDs of the issuer types.
+	CRDPaths []string
+
+	// Scheme must contain the issuer types and the cert-manager API types.
+	Scheme *runtime.Scheme
+
+	// Setup sets up the controllers of the project with the manager, eg. by
+	// calling SetupWithManager of a controllers.CombinedController.
+	Setup func(ctx context.Context, mgr ctrl.Manager) error
+
+	environment *envtest.Environment
+	cancel      context.CancelFunc
+	done        chan error
+}
+
+// Start starts the API server and a manager that runs the controllers set up
+// by Setup and an Approver. It returns a client for the tests, which reads from
+// the API server directly. Stop must be called once the tests have finished.
+func (e *Envtest) Start(ctx context.Context) (client.Client, error) {
+	if e.environment != nil {
+		return nil, errors.New("envtest is already started")
+	}
+	if e.Scheme == nil || e.Setup == nil {
+		return nil, errors.New("the Scheme and Setup of envtest must be set")
+	}
+
+	environment := &envtest.Environment{
+		Scheme:                e.Scheme,
+		CRDDirectoryPaths:     e.CRDPaths,
+		ErrorIfCRDPathMissing: true,
+	}
+	restConfig, err := environment.Start()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start the envtest API server: %w", err)
+	}
+	e.environment = environment
+
+	cl, err := e.startManager(ctx, restConfig)
+	if err != nil {
+		return nil, errors.Join(err, e.Stop())
+	}
+	return cl, nil
+}
+
+func (e *Envtest) startManager(ctx context.Context, restConfig *rest.Config) (client.Client, error) {
+	mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
+		Scheme:                 e.Scheme,
+		LeaderElection:         false,
+		HealthProbeBindAddress: "0",
+		Metrics: server.Options{
+			BindAddress: "0",
+		},
+		Controller: config.Controller{
+			// envtest may be started several times in a process
+			SkipNameValidation: ptr.To(true),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the manager: %w", err)
+	}
+
+	if err := e.Setup(ctx, mgr); err != nil {
+		return nil, fmt.Errorf("failed to set up the controllers: %w", err)
+	}
+	if err := (&Approver{Client: mgr.GetClient()}).SetupWithManager(ctx, mgr); err != nil {
+		return nil, fmt.Errorf("failed to set up the approver: %w", err)
+	}
+
+	mgrCtx, cancel := context.WithCancel(ctx)
+	e.cancel = cancel
+	e.done = make(chan error, 1)
+	go func() {
+		e.done <- mgr.Start(mgrCtx)
+	}()
+
+	if !mgr.GetCache().WaitForCacheSync(mgrCtx) {
+		return nil, errors.New("failed to sync the cache of the manager")
+	}
+
+	return client.New(restConfig, client.Options{Scheme: e.Scheme})
+}
+
+// Stop stops the manager and the API server.
+func (e *Envtest) Stop() error {
+	if e.environment == nil {
+		return nil
+	}
+
+	var errs []error
+	if e.cancel != nil {
+		e.cancel()
+		errs = append(errs, <-e.done)
+		e.cancel = nil
+	}
+	errs = append(errs, e.environment.Stop())
+	e.environment = nil
+	return errors.Join(errs...)
+}
+
+// SmokeTests returns the subset of the conformance tests that runs against an
+// envtest API server: the issuer becomes ready once a bad configuration is
+// fixed, and the RequestTests. The options must use the client returned by
+// Start.
+func SmokeTests(issuerOpts IssuerStatusOptions, requestOpts RequestOptions) []Test {
+	tests := make([]Test, 0, 3)
+	for _, test := range IssuerStatusTests(issuerOpts) {
+		switch test.Name {
+		case "issuer-not-ready-on-bad-config", "issuer-recovers-on-fix":
+			tests = append(tests, test)
+		}
+	}
+	return append(tests, RequestTests(requestOpts)...)
+}
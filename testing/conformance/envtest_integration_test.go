@@ -0,0 +1,99 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conformance
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+	"github.com/cert-manager/issuer-lib/testing/simulator"
+)
+
+func TestEnvtestSmokeTestsIntegration(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, cmapi.AddToScheme(scheme))
+	require.NoError(t, api.AddToScheme(scheme))
+
+	sim := &simulator.Simulator{}
+	env := &Envtest{
+		CRDPaths: []string{
+			os.Getenv("SIMPLE_CRDS"),
+			os.Getenv("CERT_MANAGER_CRDS"),
+		},
+		Scheme: scheme,
+		Setup: func(ctx context.Context, mgr ctrl.Manager) error {
+			return (&controllers.CombinedController{
+				IssuerTypes:        []v1alpha1.Issuer{&api.TestIssuer{}},
+				ClusterIssuerTypes: []v1alpha1.Issuer{&api.TestClusterIssuer{}},
+				FieldOwner:         "conformance",
+				MaxRetryDuration:   time.Minute,
+				Check: func(ctx context.Context, issuerObject v1alpha1.Issuer) error {
+					if issuerObject.GetAnnotations()[configAnnotation] == "bad" {
+						return errors.New("bad config")
+					}
+					return sim.Check(ctx, issuerObject)
+				},
+				Sign: func(ctx context.Context, cr signer.CertificateRequestObject, issuerObject v1alpha1.Issuer) (signer.PEMBundle, error) {
+					signer.SetSignResult(ctx,
+						signer.WithExtraConditions(testExtraCondition),
+						signer.WithRevocationInfo(testOCSPServer, ""),
+					)
+					return sim.Sign(ctx, cr, issuerObject)
+				},
+			}).SetupWithManager(ctx, mgr)
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cl, err := env.Start(ctx)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, env.Stop())
+	}()
+
+	report := (&Runner{
+		Timeout: time.Minute,
+		Tests: SmokeTests(
+			IssuerStatusOptions{Client: cl, Misconfigure: setConfig("bad")},
+			RequestOptions{
+				Client:          cl,
+				ExtraConditions: []signer.ExtraCondition{testExtraCondition},
+				OCSPServer:      testOCSPServer,
+			},
+		),
+	}).Run(ctx, &api.TestIssuer{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "issuer1"}})
+
+	report.ReportFailures(t)
+	require.Equal(t, Summary{Passed: 5}, report.Summary)
+}
@@ -0,0 +1,268 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conformance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/conditions"
+)
+
+// IssuerStatusOptions configures the issuer status tests. The tests create
+// issuers in a cluster (or an envtest API server) in which the issuer
+// controllers of the project run, and observe the Ready condition that the
+// controllers set. The issuer passed to Run is used as the template of a valid
+// issuer; every test creates and deletes its own copy of it.
+type IssuerStatusOptions struct {
+	// Client creates, updates, reads and deletes the issuers.
+	Client client.Client
+
+	// Misconfigure changes the spec of an issuer such that Check returns a
+	// retryable error, eg. by pointing it at an unreachable CA. The not-ready
+	// and recovery tests are skipped if it is nil.
+	Misconfigure func(issuerObject v1alpha1.Issuer)
+
+	// Break changes the spec of an issuer such that Check returns a
+	// signer.PermanentError, eg. by setting an invalid CA certificate. The
+	// permanent failure test is skipped if it is nil.
+	Break func(issuerObject v1alpha1.Issuer)
+
+	// PollInterval is the interval at which the Ready condition is read,
+	// defaults to 250 milliseconds.
+	PollInterval time.Duration
+}
+
+// IssuerStatusTests returns the tests of the issuer lifecycle: an issuer with a
+// bad configuration is not ready, it becomes ready once the configuration is
+// fixed, an unrecoverable configuration fails it permanently, and the
+// observedGeneration of the Ready condition follows the generation of the
+// issuer. The tests only use the v1alpha1.Issuer interface, so they work for
+// any issuer type.
+func IssuerStatusTests(opts IssuerStatusOptions) []Test {
+	return []Test{
+		{Name: "issuer-not-ready-on-bad-config", Run: opts.testNotReadyOnBadConfig},
+		{Name: "issuer-recovers-on-fix", Run: opts.testRecoversOnFix},
+		{Name: "issuer-fails-permanently-on-unrecoverable-config", Run: opts.testFailsPermanently},
+		{Name: "issuer-observed-generation", Run: opts.testObservedGeneration},
+	}
+}
+
+func (o IssuerStatusOptions) testNotReadyOnBadConfig(ctx context.Context, template v1alpha1.Issuer) error {
+	if o.Misconfigure == nil {
+		return Skip("no Misconfigure function is set")
+	}
+
+	issuerObject, cleanup, err := o.create(ctx, template, "not-ready", o.Misconfigure)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	return o.waitForReady(ctx, issuerObject, "Ready=False with a retryable reason", func(condition *cmapi.IssuerCondition) bool {
+		return condition.Status == cmmeta.ConditionFalse && !v1alpha1.IsIssuerConditionReasonFailed(condition.Reason)
+	})
+}
+
+func (o IssuerStatusOptions) testRecoversOnFix(ctx context.Context, template v1alpha1.Issuer) error {
+	if o.Misconfigure == nil {
+		return Skip("no Misconfigure function is set")
+	}
+
+	issuerObject, cleanup, err := o.create(ctx, template, "recovers", o.Misconfigure)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	if err := o.waitForReady(ctx, issuerObject, "Ready=False", func(condition *cmapi.IssuerCondition) bool {
+		return condition.Status == cmmeta.ConditionFalse
+	}); err != nil {
+		return err
+	}
+
+	if err := o.fix(ctx, template, issuerObject); err != nil {
+		return err
+	}
+
+	return o.waitForReady(ctx, issuerObject, "Ready=True after the configuration was fixed", func(condition *cmapi.IssuerCondition) bool {
+		return condition.Status == cmmeta.ConditionTrue
+	})
+}
+
+func (o IssuerStatusOptions) testFailsPermanently(ctx context.Context, template v1alpha1.Issuer) error {
+	if o.Break == nil {
+		return Skip("no Break function is set")
+	}
+
+	issuerObject, cleanup, err := o.create(ctx, template, "fails", o.Break)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	return o.waitForReady(ctx, issuerObject, "Ready=False with a permanent failure reason", func(condition *cmapi.IssuerCondition) bool {
+		return condition.Status == cmmeta.ConditionFalse && v1alpha1.IsIssuerConditionReasonFailed(condition.Reason)
+	})
+}
+
+func (o IssuerStatusOptions) testObservedGeneration(ctx context.Context, template v1alpha1.Issuer) error {
+	if o.Misconfigure == nil {
+		return Skip("no Misconfigure function is set")
+	}
+
+	issuerObject, cleanup, err := o.create(ctx, template, "generation", nil)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	if err := o.waitForReady(ctx, issuerObject, "Ready=True", func(condition *cmapi.IssuerCondition) bool {
+		return condition.Status == cmmeta.ConditionTrue
+	}); err != nil {
+		return err
+	}
+
+	// Changing the spec increments the generation, the Ready condition must
+	// be updated for the new generation.
+	if err := o.update(ctx, issuerObject, mutate(o.Misconfigure)); err != nil {
+		return err
+	}
+
+	return o.waitForReady(ctx, issuerObject, "Ready=False for the new generation", func(condition *cmapi.IssuerCondition) bool {
+		return condition.Status == cmmeta.ConditionFalse
+	})
+}
+
+// create creates a copy of the template with the suffix appended to its name,
+// after applying the mutate function. The returned cleanup function deletes the
+// issuer, also after the context of the test was cancelled.
+func (o IssuerStatusOptions) create(ctx context.Context, template v1alpha1.Issuer, suffix string, mutate func(v1alpha1.Issuer)) (v1alpha1.Issuer, func(), error) {
+	issuerObject := template.DeepCopyObject().(v1alpha1.Issuer)
+	issuerObject.SetName(fmt.Sprintf("%s-%s", template.GetName(), suffix))
+	issuerObject.SetResourceVersion("")
+	issuerObject.SetUID("")
+	*issuerObject.GetStatus() = v1alpha1.IssuerStatus{}
+	if mutate != nil {
+		mutate(issuerObject)
+	}
+
+	if err := o.Client.Create(ctx, issuerObject); err != nil {
+		return nil, nil, fmt.Errorf("failed to create the issuer: %w", err)
+	}
+
+	cleanup := func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+		defer cancel()
+		_ = client.IgnoreNotFound(o.Client.Delete(ctx, issuerObject))
+	}
+	return issuerObject, cleanup, nil
+}
+
+// update reads the issuer and updates it with the issuer returned by build,
+// which can modify and return the issuer that it is passed. The update is
+// retried if the controllers updated the status in the meantime.
+func (o IssuerStatusOptions) update(ctx context.Context, issuerObject v1alpha1.Issuer, build func(current v1alpha1.Issuer) v1alpha1.Issuer) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current := issuerObject.DeepCopyObject().(v1alpha1.Issuer)
+		if err := o.Client.Get(ctx, client.ObjectKeyFromObject(issuerObject), current); err != nil {
+			return err
+		}
+		return o.Client.Update(ctx, build(current))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update the issuer: %w", err)
+	}
+	return nil
+}
+
+// mutate returns a build function for update that applies the mutate function.
+func mutate(mutate func(v1alpha1.Issuer)) func(v1alpha1.Issuer) v1alpha1.Issuer {
+	return func(current v1alpha1.Issuer) v1alpha1.Issuer {
+		mutate(current)
+		return current
+	}
+}
+
+// fix replaces the spec, labels and annotations of the issuer with those of the
+// template.
+func (o IssuerStatusOptions) fix(ctx context.Context, template v1alpha1.Issuer, issuerObject v1alpha1.Issuer) error {
+	return o.update(ctx, issuerObject, func(current v1alpha1.Issuer) v1alpha1.Issuer {
+		fixed := template.DeepCopyObject().(v1alpha1.Issuer)
+		fixed.SetName(current.GetName())
+		fixed.SetNamespace(current.GetNamespace())
+		fixed.SetUID(current.GetUID())
+		fixed.SetResourceVersion(current.GetResourceVersion())
+		fixed.SetGeneration(current.GetGeneration())
+		*fixed.GetStatus() = *current.GetStatus()
+		return fixed
+	})
+}
+
+// waitForReady waits until the Ready condition is up-to-date with the
+// generation of the issuer and matches the expectation. The observedGeneration
+// of the condition must never be ahead of the generation of the issuer.
+func (o IssuerStatusOptions) waitForReady(
+	ctx context.Context,
+	issuerObject v1alpha1.Issuer,
+	expectation string,
+	matches func(*cmapi.IssuerCondition) bool,
+) error {
+	interval := o.PollInterval
+	if interval == 0 {
+		interval = 250 * time.Millisecond
+	}
+
+	var last string
+	errObservedGeneration := errors.New("observedGeneration is ahead of the generation")
+	err := wait.PollUntilContextCancel(ctx, interval, true, func(ctx context.Context) (bool, error) {
+		if err := o.Client.Get(ctx, client.ObjectKeyFromObject(issuerObject), issuerObject); err != nil {
+			last = fmt.Sprintf("failed to get the issuer: %v", err)
+			return false, nil
+		}
+
+		condition := conditions.GetIssuerStatusCondition(issuerObject.GetStatus().Conditions, cmapi.IssuerConditionReady)
+		if condition == nil {
+			last = "the issuer has no Ready condition"
+			return false, nil
+		}
+
+		generation := issuerObject.GetGeneration()
+		last = fmt.Sprintf("Ready=%s, reason %q, observedGeneration %d, generation %d, message %q",
+			condition.Status, condition.Reason, condition.ObservedGeneration, generation, condition.Message)
+		if condition.ObservedGeneration > generation {
+			return false, errObservedGeneration
+		}
+		return condition.ObservedGeneration == generation && matches(condition), nil
+	})
+	switch {
+	case errors.Is(err, errObservedGeneration):
+		return fmt.Errorf("%s: %s", errObservedGeneration, last)
+	case err != nil:
+		return fmt.Errorf("the issuer did not become %s: %s", expectation, last)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conformance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/conditions"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+)
+
+const configAnnotation = "conformance.test/config"
+
+// runFakeIssuerController sets the Ready condition of the TestIssuers based on
+// the configAnnotation, like the issuer controllers would based on the result
+// of Check. If stale is true, the observedGeneration is never updated.
+func runFakeIssuerController(ctx context.Context, cl client.Client, stale bool) {
+	for ctx.Err() == nil {
+		time.Sleep(time.Millisecond)
+
+		issuers := &api.TestIssuerList{}
+		if err := cl.List(ctx, issuers); err != nil {
+			continue
+		}
+
+		for i := range issuers.Items {
+			issuer := &issuers.Items[i]
+			status, reason := cmmeta.ConditionTrue, v1alpha1.IssuerConditionReasonChecked
+			switch issuer.Annotations[configAnnotation] {
+			case "bad":
+				status, reason = cmmeta.ConditionFalse, v1alpha1.IssuerConditionReasonPending
+			case "broken":
+				status, reason = cmmeta.ConditionFalse, v1alpha1.IssuerConditionReasonFailed
+			}
+
+			observedGeneration := issuer.Generation
+			if current := conditions.GetIssuerStatusCondition(issuer.Status.Conditions, cmapi.IssuerConditionReady); current != nil {
+				if current.Status == status && current.Reason == reason && current.ObservedGeneration == observedGeneration {
+					continue
+				}
+				if stale {
+					observedGeneration = current.ObservedGeneration
+				}
+			}
+
+			issuer.Status.Conditions = []cmapi.IssuerCondition{{
+				Type:               cmapi.IssuerConditionReady,
+				Status:             status,
+				Reason:             reason,
+				ObservedGeneration: observedGeneration,
+			}}
+			_ = cl.Status().Update(ctx, issuer)
+		}
+	}
+}
+
+// newGenerationClient returns a fake client that sets the generation of the
+// objects on create and increments it on update, like the API server does for
+// changes of the spec.
+func newGenerationClient(t *testing.T) client.Client {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, api.AddToScheme(scheme))
+	require.NoError(t, cmapi.AddToScheme(scheme))
+
+	return fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&api.TestIssuer{}, &cmapi.CertificateRequest{}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, cl client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				obj.SetGeneration(1)
+				return cl.Create(ctx, obj, opts...)
+			},
+			Update: func(ctx context.Context, cl client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+				obj.SetGeneration(obj.GetGeneration() + 1)
+				return cl.Update(ctx, obj, opts...)
+			},
+		}).
+		Build()
+}
+
+func setConfig(config string) func(v1alpha1.Issuer) {
+	return func(issuerObject v1alpha1.Issuer) {
+		issuerObject.SetAnnotations(map[string]string{configAnnotation: config})
+	}
+}
+
+func TestIssuerStatusTests(t *testing.T) {
+	t.Parallel()
+
+	cl := newGenerationClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go runFakeIssuerController(ctx, cl, false)
+
+	report := (&Runner{
+		Timeout: 10 * time.Second,
+		Tests: IssuerStatusTests(IssuerStatusOptions{
+			Client:       cl,
+			Misconfigure: setConfig("bad"),
+			Break:        setConfig("broken"),
+			PollInterval: time.Millisecond,
+		}),
+	}).Run(ctx, &api.TestIssuer{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "issuer1"}})
+
+	report.ReportFailures(t)
+	require.Equal(t, Summary{Passed: 4}, report.Summary)
+
+	// The tests delete the issuers that they created.
+	issuers := &api.TestIssuerList{}
+	require.NoError(t, cl.List(ctx, issuers))
+	require.Empty(t, issuers.Items)
+}
+
+func TestIssuerStatusTestsSkipped(t *testing.T) {
+	t.Parallel()
+
+	report := (&Runner{
+		Tests: IssuerStatusTests(IssuerStatusOptions{Client: newGenerationClient(t)}),
+	}).Run(context.TODO(), &api.TestIssuer{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "issuer1"}})
+
+	require.Equal(t, Summary{Skipped: 4}, report.Summary)
+}
+
+func TestIssuerStatusTestsDetectStaleObservedGeneration(t *testing.T) {
+	t.Parallel()
+
+	cl := newGenerationClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go runFakeIssuerController(ctx, cl, true)
+
+	report := (&Runner{
+		Timeout: 200 * time.Millisecond,
+		Tests: []Test{IssuerStatusTests(IssuerStatusOptions{
+			Client:       cl,
+			Misconfigure: setConfig("bad"),
+			PollInterval: time.Millisecond,
+		})[3]},
+	}).Run(ctx, &api.TestIssuer{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "issuer1"}})
+
+	require.Equal(t, Summary{Failed: 1}, report.Summary)
+}
@@ -0,0 +1,312 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conformance
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	cmutil "github.com/cert-manager/cert-manager/pkg/api/util"
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/cert-manager/cert-manager/pkg/util/pki"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/testing/validation"
+)
+
+// RequestOptions configures the request tests. The tests create an issuer and
+// CertificateRequests that reference it in a cluster (or an envtest API server)
+// in which the issuer and request controllers of the project run, and observe
+// the status that the controllers set on the requests. The issuer passed to Run
+// is used as the template of a valid issuer; every test creates and deletes its
+// own copy of it.
+//
+// The CertificateRequests must be approved by cert-manager, or by an Approver if
+// cert-manager does not run.
+type RequestOptions struct {
+	// Client creates, reads and deletes the issuers and requests. Its scheme
+	// must contain the issuer types and the cert-manager API types.
+	Client client.Client
+
+	// Namespace is the namespace of the requests for cluster-scoped issuers,
+	// defaults to "default". The requests for namespaced issuers are created in
+	// the namespace of the issuer.
+	Namespace string
+
+	// PollInterval is the interval at which the issuer and the requests are
+	// read, defaults to 250 milliseconds.
+	PollInterval time.Duration
+
+	// ExtraConditions are the conditions that Sign records for every request
+	// using signer.WithExtraConditions. The extra conditions test is skipped if
+	// it is empty.
+	ExtraConditions []signer.ExtraCondition
+
+	// OCSPServer and CRLDistributionPoint are the revocation endpoints of the
+	// CA that Sign records for every request using signer.WithRevocationInfo.
+	// The CA annotations test checks that the request is annotated with exactly
+	// these endpoints, so empty values mean that the annotation must be absent.
+	OCSPServer           string
+	CRLDistributionPoint string
+}
+
+// RequestTests returns the tests of the request lifecycle: an approved request
+// for a ready issuer is issued with a certificate for the requested key and
+// SANs, and the metadata that Sign records using signer.SetSignResult is
+// surfaced on the request. The tests of the metadata are skipped for issuer
+// types whose feature set does not include signer.FeatureSignResult, eg.
+// issuers whose Sign function only returns the PEM bundle.
+func RequestTests(opts RequestOptions) []Test {
+	return []Test{
+		{Name: "request-issued", Run: opts.testIssued},
+		{Name: "request-extra-conditions", Feature: signer.FeatureSignResult, Run: opts.testExtraConditions},
+		{Name: "request-ca-annotations", Feature: signer.FeatureSignResult, Run: opts.testCAAnnotations},
+	}
+}
+
+func (o RequestOptions) testIssued(ctx context.Context, template v1alpha1.Issuer) error {
+	cr, keyPEM, cleanup, err := o.issue(ctx, template, "issued")
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	if err := validation.ValidateChainOrder(cr.Status.Certificate); err != nil {
+		return err
+	}
+	if err := validation.ValidateKeyMatchesLeaf(keyPEM, cr.Status.Certificate); err != nil {
+		return err
+	}
+	return validation.ValidateSANsMatchRequest(cr.Status.Certificate, cr.Spec.Request)
+}
+
+func (o RequestOptions) testExtraConditions(ctx context.Context, template v1alpha1.Issuer) error {
+	if len(o.ExtraConditions) == 0 {
+		return Skip("no ExtraConditions are set")
+	}
+
+	cr, _, cleanup, err := o.issue(ctx, template, "extra-conditions")
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	for _, expected := range o.ExtraConditions {
+		condition := cmutil.GetCertificateRequestCondition(cr, expected.Type)
+		if condition == nil {
+			return fmt.Errorf("the request has no %s condition", expected.Type)
+		}
+		if condition.Status != expected.Status || condition.Reason != expected.Reason || condition.Message != expected.Message {
+			return fmt.Errorf(
+				"expected the %s condition to be %s with reason %q and message %q, got %s with reason %q and message %q",
+				expected.Type, expected.Status, expected.Reason, expected.Message,
+				condition.Status, condition.Reason, condition.Message,
+			)
+		}
+	}
+	return nil
+}
+
+func (o RequestOptions) testCAAnnotations(ctx context.Context, template v1alpha1.Issuer) error {
+	cr, _, cleanup, err := o.issue(ctx, template, "ca-annotations")
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	// The annotations are set before the request is marked as issued.
+	for key, expected := range map[string]string{
+		v1alpha1.RequestOCSPServerAnnotationKey:           o.OCSPServer,
+		v1alpha1.RequestCRLDistributionPointAnnotationKey: o.CRLDistributionPoint,
+	} {
+		if actual := cr.Annotations[key]; actual != expected {
+			return fmt.Errorf("expected the %s annotation to be %q, got %q", key, expected, actual)
+		}
+	}
+	return nil
+}
+
+// issue creates a ready copy of the template and a request for it, and waits
+// until the request is issued. The returned cleanup function deletes the
+// request and the issuer.
+func (o RequestOptions) issue(ctx context.Context, template v1alpha1.Issuer, suffix string) (*cmapi.CertificateRequest, []byte, func(), error) {
+	issuerObject, cleanupIssuer, err := o.createReadyIssuer(ctx, template, suffix)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	cr, keyPEM, cleanupRequest, err := o.createRequest(ctx, issuerObject, suffix)
+	if err != nil {
+		cleanupIssuer()
+		return nil, nil, nil, err
+	}
+
+	cleanup := func() {
+		cleanupRequest()
+		cleanupIssuer()
+	}
+	if err := o.waitForIssued(ctx, cr); err != nil {
+		cleanup()
+		return nil, nil, nil, err
+	}
+	return cr, keyPEM, cleanup, nil
+}
+
+func (o RequestOptions) issuerStatusOptions() IssuerStatusOptions {
+	return IssuerStatusOptions{
+		Client:       o.Client,
+		PollInterval: o.PollInterval,
+	}
+}
+
+// createReadyIssuer creates a copy of the template with the suffix appended to
+// its name and waits until it is ready. The returned cleanup function deletes
+// the issuer.
+func (o RequestOptions) createReadyIssuer(ctx context.Context, template v1alpha1.Issuer, suffix string) (v1alpha1.Issuer, func(), error) {
+	issuerStatus := o.issuerStatusOptions()
+
+	issuerObject, cleanup, err := issuerStatus.create(ctx, template, suffix, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := issuerStatus.waitForReady(ctx, issuerObject, "Ready=True", func(condition *cmapi.IssuerCondition) bool {
+		return condition.Status == cmmeta.ConditionTrue
+	}); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return issuerObject, cleanup, nil
+}
+
+// createRequest creates a CertificateRequest for the issuer with a CSR for a
+// new ECDSA key and returns the PEM encoded key. The returned cleanup function
+// deletes the request, also after the context of the test was cancelled.
+func (o RequestOptions) createRequest(ctx context.Context, issuerObject v1alpha1.Issuer, suffix string) (*cmapi.CertificateRequest, []byte, func(), error) {
+	gvk, err := apiutil.GVKForObject(issuerObject, o.Client.Scheme())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	key, err := pki.GenerateECPrivateKey(pki.ECCurve256)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	keyPEM, err := pki.EncodePKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	name := fmt.Sprintf("%s-%s", issuerObject.GetName(), suffix)
+	csrDER, err := pki.EncodeCSR(&x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: name + ".conformance.example.com"},
+		DNSNames: []string{name + ".conformance.example.com"},
+	}, key)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	namespace := issuerObject.GetNamespace()
+	if namespace == "" {
+		namespace = o.Namespace
+	}
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	cr := &cmapi.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Spec: cmapi.CertificateRequestSpec{
+			Request:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER}),
+			Duration: &metav1.Duration{Duration: time.Hour},
+			Usages:   []cmapi.KeyUsage{cmapi.UsageDigitalSignature, cmapi.UsageServerAuth},
+			IssuerRef: cmmeta.ObjectReference{
+				Name:  issuerObject.GetName(),
+				Kind:  gvk.Kind,
+				Group: gvk.Group,
+			},
+		},
+	}
+	if err := o.Client.Create(ctx, cr); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to create the request: %w", err)
+	}
+
+	cleanup := func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+		defer cancel()
+		_ = client.IgnoreNotFound(o.Client.Delete(ctx, cr))
+	}
+	return cr, keyPEM, cleanup, nil
+}
+
+// waitForIssued waits until the request is Ready. It fails early if the request
+// was failed or denied.
+func (o RequestOptions) waitForIssued(ctx context.Context, cr *cmapi.CertificateRequest) error {
+	interval := o.PollInterval
+	if interval == 0 {
+		interval = 250 * time.Millisecond
+	}
+
+	var last string
+	errFinished := errors.New("the request was not issued")
+	err := wait.PollUntilContextCancel(ctx, interval, true, func(ctx context.Context) (bool, error) {
+		if err := o.Client.Get(ctx, client.ObjectKeyFromObject(cr), cr); err != nil {
+			last = fmt.Sprintf("failed to get the request: %v", err)
+			return false, nil
+		}
+
+		condition := cmutil.GetCertificateRequestCondition(cr, cmapi.CertificateRequestConditionReady)
+		switch {
+		case cmutil.CertificateRequestIsDenied(cr):
+			last = "the request was denied"
+			return false, errFinished
+		case !cmutil.CertificateRequestIsApproved(cr):
+			last = "the request is not approved"
+			return false, nil
+		case condition == nil:
+			last = "the request has no Ready condition"
+			return false, nil
+		}
+
+		last = fmt.Sprintf("Ready=%s, reason %q, message %q", condition.Status, condition.Reason, condition.Message)
+		if condition.Reason == cmapi.CertificateRequestReasonFailed {
+			return false, errFinished
+		}
+		return condition.Status == cmmeta.ConditionTrue && len(cr.Status.Certificate) > 0, nil
+	})
+	switch {
+	case errors.Is(err, errFinished):
+		return fmt.Errorf("%s: %s", errFinished, last)
+	case err != nil:
+		return fmt.Errorf("the request was not issued in time: %s", last)
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conformance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cmutil "github.com/cert-manager/cert-manager/pkg/api/util"
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+	"github.com/cert-manager/issuer-lib/testing/simulator"
+)
+
+// testExtraCondition is the extra condition that runFakeRequestController sets
+// on the issued requests.
+var testExtraCondition = signer.ExtraCondition{
+	Type:    "conformance.test/Policy",
+	Status:  cmmeta.ConditionTrue,
+	Reason:  "PolicyApplied",
+	Message: "the CA applied the server policy",
+}
+
+const testOCSPServer = "http://ocsp.example.com"
+
+// runFakeRequestController approves the CertificateRequests with an Approver,
+// or denies them if deny is true, and signs the approved requests with a
+// Simulator, like the request controllers would. The issued requests get the
+// testExtraCondition and the annotation of the testOCSPServer.
+func runFakeRequestController(ctx context.Context, cl client.Client, deny bool) {
+	approver := &Approver{Client: cl}
+	sim := &simulator.Simulator{}
+
+	for ctx.Err() == nil {
+		time.Sleep(time.Millisecond)
+
+		crs := &cmapi.CertificateRequestList{}
+		if err := cl.List(ctx, crs); err != nil {
+			continue
+		}
+
+		for i := range crs.Items {
+			cr := &crs.Items[i]
+			if cmutil.CertificateRequestIsDenied(cr) || cmutil.GetCertificateRequestCondition(cr, cmapi.CertificateRequestConditionReady) != nil {
+				continue
+			}
+
+			if deny {
+				cmutil.SetCertificateRequestCondition(cr, cmapi.CertificateRequestConditionDenied, cmmeta.ConditionTrue, "Denied", "denied by the test")
+				_ = cl.Status().Update(ctx, cr)
+				continue
+			}
+
+			if !cmutil.CertificateRequestIsApproved(cr) {
+				_, _ = approver.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(cr)})
+				continue
+			}
+
+			issuer := &api.TestIssuer{}
+			if err := cl.Get(ctx, client.ObjectKey{Namespace: cr.Namespace, Name: cr.Spec.IssuerRef.Name}, issuer); err != nil {
+				continue
+			}
+
+			bundle, err := sim.Sign(ctx, signer.CertificateRequestObjectFromCertificateRequest(cr), issuer)
+			if err != nil {
+				continue
+			}
+
+			cr.Annotations = map[string]string{v1alpha1.RequestOCSPServerAnnotationKey: testOCSPServer}
+			if err := cl.Update(ctx, cr); err != nil {
+				continue
+			}
+
+			cr.Status.Certificate = bundle.ChainPEM
+			cr.Status.CA = bundle.CAPEM
+			cmutil.SetCertificateRequestCondition(cr, testExtraCondition.Type, testExtraCondition.Status, testExtraCondition.Reason, testExtraCondition.Message)
+			cmutil.SetCertificateRequestCondition(cr, cmapi.CertificateRequestConditionReady, cmmeta.ConditionTrue, cmapi.CertificateRequestReasonIssued, "issued")
+			_ = cl.Status().Update(ctx, cr)
+		}
+	}
+}
+
+func TestRequestTests(t *testing.T) {
+	t.Parallel()
+
+	signResultOptions := RequestOptions{
+		ExtraConditions: []signer.ExtraCondition{testExtraCondition},
+		OCSPServer:      testOCSPServer,
+	}
+
+	type testCase struct {
+		name        string
+		deny        bool
+		issuer      v1alpha1.Issuer
+		opts        RequestOptions
+		summary     Summary
+		failedTests []string
+	}
+
+	tests := []testCase{
+		{
+			name:    "approved-request-is-issued",
+			opts:    signResultOptions,
+			summary: Summary{Passed: 3},
+		},
+		{
+			name:    "extra-conditions-test-skipped-without-extra-conditions",
+			opts:    RequestOptions{OCSPServer: testOCSPServer},
+			summary: Summary{Passed: 2, Skipped: 1},
+		},
+		{
+			name: "sign-result-tests-skipped-for-legacy-issuer",
+			issuer: featureSetIssuer{
+				TestIssuer: &api.TestIssuer{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "issuer1"}},
+			},
+			opts:    signResultOptions,
+			summary: Summary{Passed: 1, Skipped: 2},
+		},
+		{
+			name: "unexpected-extra-condition-fails",
+			opts: RequestOptions{
+				ExtraConditions: []signer.ExtraCondition{{Type: testExtraCondition.Type, Status: cmmeta.ConditionFalse}},
+				OCSPServer:      testOCSPServer,
+			},
+			summary:     Summary{Passed: 2, Failed: 1},
+			failedTests: []string{"request-extra-conditions"},
+		},
+		{
+			name:        "unexpected-ca-annotation-fails",
+			opts:        RequestOptions{ExtraConditions: []signer.ExtraCondition{testExtraCondition}},
+			summary:     Summary{Passed: 2, Failed: 1},
+			failedTests: []string{"request-ca-annotations"},
+		},
+		{
+			name:        "denied-request-fails",
+			deny:        true,
+			opts:        signResultOptions,
+			summary:     Summary{Failed: 3},
+			failedTests: []string{"request-issued", "request-extra-conditions", "request-ca-annotations"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cl := newGenerationClient(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go runFakeIssuerController(ctx, cl, false)
+			go runFakeRequestController(ctx, cl, tc.deny)
+
+			issuerObject := tc.issuer
+			if issuerObject == nil {
+				issuerObject = &api.TestIssuer{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "issuer1"}}
+			}
+
+			opts := tc.opts
+			opts.Client = cl
+			opts.PollInterval = time.Millisecond
+
+			report := (&Runner{
+				Timeout: 10 * time.Second,
+				Tests:   RequestTests(opts),
+			}).Run(ctx, issuerObject)
+
+			require.Equal(t, tc.summary, report.Summary)
+
+			var failedTests []string
+			for _, test := range report.Issuers[0].Tests {
+				if test.Result == ResultFailed {
+					failedTests = append(failedTests, test.Name)
+				}
+			}
+			require.Equal(t, tc.failedTests, failedTests)
+
+			// The tests delete the issuers and requests that they created.
+			issuers := &api.TestIssuerList{}
+			require.NoError(t, cl.List(ctx, issuers))
+			require.Empty(t, issuers.Items)
+
+			crs := &cmapi.CertificateRequestList{}
+			require.NoError(t, cl.List(ctx, crs))
+			require.Empty(t, crs.Items)
+		})
+	}
+}
+
+func TestSmokeTests(t *testing.T) {
+	t.Parallel()
+
+	tests := SmokeTests(IssuerStatusOptions{}, RequestOptions{})
+
+	names := make([]string, 0, len(tests))
+	for _, test := range tests {
+		names = append(names, test.Name)
+	}
+	require.Equal(t, []string{"issuer-not-ready-on-bad-config", "issuer-recovers-on-fix", "request-issued", "request-extra-conditions", "request-ca-annotations"}, names)
+}
@@ -0,0 +1,292 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package errorclass contains a table-driven test harness that checks how the
+// Sign function of an issuer classifies common failures of its CA into the
+// signer error types. Downstream projects run the matrix against their Sign
+// implementation (with the CA or a fake of it simulating each scenario), so
+// that all issuers handle eg. rate limiting or invalid CSRs consistently:
+//
+//	ca := errorclass.NewFakeCA(errorclass.ScenarioRateLimited)
+//	defer ca.Close()
+//
+//	errorclass.RunMatrix(t, classifier, []errorclass.Case{{
+//		Scenario: errorclass.ScenarioRateLimited,
+//		Sign: func(ctx context.Context) error {
+//			_, err := newSigner(ca.URL).Sign(ctx, cr, issuer)
+//			return err
+//		},
+//	}})
+package errorclass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+// Class is the way in which the request controllers handle an error returned by
+// Sign.
+type Class string
+
+const (
+	// ClassSucceeded means that Sign did not return an error.
+	ClassSucceeded Class = "Succeeded"
+	// ClassIssuerError means that the issuer is marked as not ready and is
+	// checked again (signer.IssuerError).
+	ClassIssuerError Class = "IssuerError"
+	// ClassPending means that the request is retried without a deadline
+	// (signer.PendingError).
+	ClassPending Class = "Pending"
+	// ClassPermanent means that the request is failed (signer.PermanentError).
+	ClassPermanent Class = "Permanent"
+	// ClassRetryable means that the request is retried with backoff until the
+	// MaxRetryDuration has passed (any other error).
+	ClassRetryable Class = "Retryable"
+)
+
+// Classify returns the class of the error, checking the signer error types in
+// the same order as the request controllers.
+func Classify(err error) Class {
+	switch {
+	case err == nil:
+		return ClassSucceeded
+	case errors.As(err, &signer.IssuerError{}):
+		return ClassIssuerError
+	case errors.As(err, &signer.PendingError{}):
+		return ClassPending
+	case errors.As(err, &signer.PermanentError{}):
+		return ClassPermanent
+	default:
+		return ClassRetryable
+	}
+}
+
+// Scenario is a common failure of a CA.
+type Scenario string
+
+const (
+	// ScenarioNetworkTimeout: the CA does not respond before the context of
+	// Sign is done.
+	ScenarioNetworkTimeout Scenario = "NetworkTimeout"
+	// ScenarioUnauthorized: the CA rejects the credentials of the issuer (eg.
+	// HTTP 401).
+	ScenarioUnauthorized Scenario = "Unauthorized"
+	// ScenarioRateLimited: the CA asks to retry the request later (eg. HTTP 429
+	// with a Retry-After header).
+	ScenarioRateLimited Scenario = "RateLimited"
+	// ScenarioInvalidCSR: the CSR of the request cannot be signed (eg. HTTP 400,
+	// or a CSR that cannot be parsed).
+	ScenarioInvalidCSR Scenario = "InvalidCSR"
+)
+
+// Scenarios returns all well-known scenarios.
+func Scenarios() []Scenario {
+	return []Scenario{
+		ScenarioNetworkTimeout,
+		ScenarioUnauthorized,
+		ScenarioRateLimited,
+		ScenarioInvalidCSR,
+	}
+}
+
+// ExpectedClasses returns the classes that are accepted for a well-known
+// scenario, or nil for an unknown scenario.
+func ExpectedClasses(scenario Scenario) []Class {
+	switch scenario {
+	case ScenarioNetworkTimeout:
+		return []Class{ClassRetryable, ClassPending, ClassIssuerError}
+	case ScenarioUnauthorized:
+		return []Class{ClassIssuerError, ClassRetryable}
+	case ScenarioRateLimited:
+		return []Class{ClassPending, ClassRetryable}
+	case ScenarioInvalidCSR:
+		return []Class{ClassPermanent}
+	default:
+		return nil
+	}
+}
+
+// hint returns a suggestion for the way in which the errors of the scenario
+// should be classified.
+func hint(scenario Scenario) string {
+	switch scenario {
+	case ScenarioNetworkTimeout:
+		return "return the error as-is to retry it with backoff, or wrap it in a signer.PendingError; a timeout must not fail the request"
+	case ScenarioUnauthorized:
+		return "wrap the error in a signer.IssuerError, so that the issuer is marked as not ready until its credentials are fixed"
+	case ScenarioRateLimited:
+		return "wrap the error in a signer.PendingError and set RetryAfter to the delay requested by the CA"
+	case ScenarioInvalidCSR:
+		return "wrap the error in a signer.PermanentError, retrying an invalid CSR never succeeds"
+	default:
+		return ""
+	}
+}
+
+// Case is an entry of the test matrix.
+type Case struct {
+	// Scenario is the failure that is simulated.
+	Scenario Scenario
+
+	// Expected are the accepted classes, it defaults to the ExpectedClasses of
+	// the scenario and must be set for custom scenarios.
+	Expected []Class
+
+	// Sign calls the Sign function while the CA (or a fake of it) simulates
+	// the scenario, and returns the error of Sign.
+	Sign func(ctx context.Context) error
+
+	// Timeout is the timeout of the context passed to Sign, defaults to 5
+	// seconds. The NetworkTimeout scenario relies on it to abort the call.
+	Timeout time.Duration
+}
+
+// Check calls Sign for the case, applies the classifier in the same way as the
+// request controllers, and returns an error that describes the mismatch if the
+// class of the returned error is not one of the expected classes.
+func Check(classifier signer.ErrorClassifier, c Case) error {
+	expected := c.Expected
+	if len(expected) == 0 {
+		expected = ExpectedClasses(c.Scenario)
+	}
+	if len(expected) == 0 {
+		return fmt.Errorf("scenario %s: no expected classes are set for this custom scenario", c.Scenario)
+	}
+
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := classify(classifier, c.Sign(ctx))
+
+	class := Classify(err)
+	if slices.Contains(expected, class) {
+		return nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "scenario %s: Sign returned a %s result, expected one of %s", c.Scenario, class, formatClasses(expected))
+	if err != nil {
+		fmt.Fprintf(&sb, "\n\terror: %s", err)
+	}
+	if hint := hint(c.Scenario); hint != "" {
+		fmt.Fprintf(&sb, "\n\thint:  %s", hint)
+	}
+	return errors.New(sb.String())
+}
+
+// TestingT is the subset of testing.TB that is used by RunMatrix.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// RunMatrix checks all cases and reports the cases with an unexpected class as
+// test errors. The classifier is the ErrorClassifier that is configured on the
+// controllers, it can be nil.
+func RunMatrix(t TestingT, classifier signer.ErrorClassifier, cases []Case) {
+	t.Helper()
+
+	for _, c := range cases {
+		if err := Check(classifier, c); err != nil {
+			t.Errorf("%s", err)
+		}
+	}
+}
+
+// classify applies the classifier to errors that are not already one of the
+// signer error types, like the request controllers do.
+func classify(classifier signer.ErrorClassifier, err error) error {
+	if classifier == nil || err == nil || Classify(err) != ClassRetryable ||
+		errors.As(err, &signer.SetCertificateRequestConditionError{}) {
+		return err
+	}
+
+	if classifiedErr := classifier(err); classifiedErr != nil {
+		return classifiedErr
+	}
+	return err
+}
+
+func formatClasses(classes []Class) string {
+	names := make([]string, 0, len(classes))
+	for _, class := range classes {
+		names = append(names, string(class))
+	}
+	return "[" + strings.Join(names, ", ") + "]"
+}
+
+// NewFakeCA returns a started HTTP server that simulates the scenario for every
+// request, for Sign functions that call a HTTP API. The caller must close the
+// server. For the NetworkTimeout scenario, the server does not respond until
+// the request is cancelled by the client.
+func NewFakeCA(scenario Scenario) *httptest.Server {
+	return httptest.NewServer(Handler(scenario))
+}
+
+// Handler returns a HTTP handler that simulates the scenario, see NewFakeCA.
+// Unknown scenarios respond with HTTP 500.
+func Handler(scenario Scenario) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError := func(status int, message string) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			_, _ = fmt.Fprintf(w, "{\"error\":%q}\n", message)
+		}
+
+		switch scenario {
+		case ScenarioNetworkTimeout:
+			<-r.Context().Done()
+		case ScenarioUnauthorized:
+			writeError(http.StatusUnauthorized, "invalid credentials")
+		case ScenarioRateLimited:
+			w.Header().Set("Retry-After", "30")
+			writeError(http.StatusTooManyRequests, "rate limit exceeded")
+		case ScenarioInvalidCSR:
+			writeError(http.StatusBadRequest, "invalid CSR")
+		default:
+			writeError(http.StatusInternalServerError, fmt.Sprintf("unknown scenario %s", scenario))
+		}
+	})
+}
+
+// InvalidCSRRequest returns a CertificateRequest whose CSR cannot be parsed, for
+// the InvalidCSR scenario of Sign functions that validate the CSR themselves.
+func InvalidCSRRequest() signer.CertificateRequestObject {
+	return signer.CertificateRequestObjectFromCertificateRequest(&cmapi.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "invalid-csr",
+			Namespace: "default",
+		},
+		Spec: cmapi.CertificateRequestSpec{
+			Request: []byte("-----BEGIN CERTIFICATE REQUEST-----\ninvalid\n-----END CERTIFICATE REQUEST-----\n"),
+		},
+	})
+}
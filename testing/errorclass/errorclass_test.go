@@ -0,0 +1,153 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errorclass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cert-manager/cert-manager/pkg/util/pki"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+type recordingT struct {
+	errors []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+// httpSign is a minimal Sign function that calls a HTTP CA, and returns the
+// errors of the CA unclassified.
+func httpSign(url string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+		if err != nil {
+			return err
+		}
+		httpResponse, err := http.DefaultClient.Do(httpRequest)
+		if err != nil {
+			return err
+		}
+		defer httpResponse.Body.Close()
+		body, _ := io.ReadAll(httpResponse.Body)
+
+		err = fmt.Errorf("CA returned %s", httpResponse.Status)
+		if retryAfter, parseErr := strconv.Atoi(httpResponse.Header.Get("Retry-After")); parseErr == nil {
+			err = signer.PendingError{Err: err, RetryAfter: time.Duration(retryAfter) * time.Second}
+		}
+		return signer.WrapCAError(err, httpResponse.StatusCode, body, "")
+	}
+}
+
+// statusClassifier classifies the CAErrors by their status code.
+func statusClassifier(err error) error {
+	caError := signer.CAError{}
+	if !errors.As(err, &caError) {
+		return err
+	}
+	switch caError.StatusCode {
+	case http.StatusUnauthorized:
+		return signer.IssuerError{Err: err}
+	case http.StatusBadRequest:
+		return signer.PermanentError{Err: err}
+	default:
+		return err
+	}
+}
+
+func TestClassify(t *testing.T) {
+	t.Parallel()
+
+	err := errors.New("error")
+
+	assert.Equal(t, ClassSucceeded, Classify(nil))
+	assert.Equal(t, ClassIssuerError, Classify(signer.IssuerError{Err: err}))
+	assert.Equal(t, ClassPending, Classify(fmt.Errorf("wrapped: %w", signer.PendingError{Err: err})))
+	assert.Equal(t, ClassPermanent, Classify(signer.PermanentError{Err: err}))
+	assert.Equal(t, ClassRetryable, Classify(err))
+	assert.Equal(t, ClassRetryable, Classify(signer.CAError{Err: err, StatusCode: http.StatusServiceUnavailable}))
+}
+
+func TestRunMatrix(t *testing.T) {
+	t.Parallel()
+
+	var cases []Case
+	for _, scenario := range Scenarios() {
+		ca := NewFakeCA(scenario)
+		defer ca.Close()
+
+		cases = append(cases, Case{
+			Scenario: scenario,
+			Sign:     httpSign(ca.URL),
+			Timeout:  100 * time.Millisecond,
+		})
+	}
+
+	recorder := &recordingT{}
+	RunMatrix(recorder, statusClassifier, cases)
+	assert.Empty(t, recorder.errors)
+}
+
+func TestCheckReportsMismatch(t *testing.T) {
+	t.Parallel()
+
+	ca := NewFakeCA(ScenarioInvalidCSR)
+	defer ca.Close()
+
+	err := Check(nil, Case{
+		Scenario: ScenarioInvalidCSR,
+		Sign:     httpSign(ca.URL),
+	})
+	require.EqualError(t, err, "scenario InvalidCSR: Sign returned a Retryable result, expected one of [Permanent]\n"+
+		"\terror: CA returned 400 Bad Request (CA status 400, response \"{\\\"error\\\":\\\"invalid CSR\\\"}\")\n"+
+		"\thint:  wrap the error in a signer.PermanentError, retrying an invalid CSR never succeeds")
+
+	err = Check(nil, Case{
+		Scenario: ScenarioUnauthorized,
+		Sign:     func(context.Context) error { return nil },
+	})
+	require.ErrorContains(t, err, "scenario Unauthorized: Sign returned a Succeeded result, expected one of [IssuerError, Retryable]")
+
+	err = Check(nil, Case{
+		Scenario: "Custom",
+		Sign:     func(context.Context) error { return nil },
+	})
+	require.EqualError(t, err, "scenario Custom: no expected classes are set for this custom scenario")
+}
+
+func TestInvalidCSRRequest(t *testing.T) {
+	t.Parallel()
+
+	_, _, csr, err := InvalidCSRRequest().GetRequest()
+	if err == nil {
+		_, err = pki.DecodeX509CertificateRequestBytes(csr)
+	}
+	require.Error(t, err)
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package simulator provides an in-memory issuance backend that implements the
+// Check and Sign functions. It maintains a self-signed CA per issuer resource,
+// which makes it a hermetic backend for demos and CI runs.
+//
+// The CAs are only kept in memory, so they are regenerated when the process
+// restarts. The simulator must not be used to issue production certificates.
+//
+// In FIPS mode (see the fips package), the CSRs are checked to only use approved
+// algorithms and key sizes before they are signed.
+package simulator
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cert-manager/cert-manager/pkg/util/pki"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/fips"
+	"github.com/cert-manager/issuer-lib/serialnumber"
+)
+
+const defaultCADuration = 365 * 24 * time.Hour
+
+type issuerKey struct {
+	issuerType string
+	name       types.NamespacedName
+	uid        types.UID
+}
+
+type certificateAuthority struct {
+	cert *x509.Certificate
+	key  crypto.Signer
+}
+
+// Simulator signs certificates using an in-memory self-signed CA per issuer.
+// The zero value is ready to use.
+type Simulator struct {
+	// CADuration is the validity period of the generated CA certificates.
+	// Defaults to one year.
+	CADuration time.Duration
+
+	// SerialNumberPolicy determines the serial numbers of the CA and leaf
+	// certificates, defaults to serialnumber.Default.
+	SerialNumberPolicy serialnumber.Policy
+
+	mu  sync.Mutex
+	cas map[issuerKey]*certificateAuthority
+}
+
+var _ signer.Check = (&Simulator{}).Check
+var _ signer.Sign = (&Simulator{}).Sign
+
+// Check generates the CA for the issuer if it does not exist yet.
+func (s *Simulator) Check(_ context.Context, issuerObject v1alpha1.Issuer) error {
+	_, err := s.certificateAuthority(issuerObject)
+	return err
+}
+
+// Sign signs the request using the CA of the issuer. The requested duration,
+// key usages and isCA value are honored. The returned chain contains the leaf
+// certificate, the CA is returned separately.
+func (s *Simulator) Sign(_ context.Context, cr signer.CertificateRequestObject, issuerObject v1alpha1.Issuer) (signer.PEMBundle, error) {
+	ca, err := s.certificateAuthority(issuerObject)
+	if err != nil {
+		return signer.PEMBundle{}, err
+	}
+
+	template, _, csrPEM, err := cr.GetRequest()
+	if err != nil {
+		return signer.PEMBundle{}, signer.PermanentError{Err: err}
+	}
+
+	if fips.Enabled() {
+		csr, err := pki.DecodeX509CertificateRequestBytes(csrPEM)
+		if err != nil {
+			return signer.PEMBundle{}, signer.PermanentError{Err: err}
+		}
+		if err := fips.CheckCertificateRequest(csr); err != nil {
+			return signer.PEMBundle{}, err
+		}
+	}
+
+	template.SerialNumber, err = serialnumber.Generate(s.SerialNumberPolicy, template)
+	if err != nil {
+		return signer.PEMBundle{}, err
+	}
+
+	bundle, err := pki.SignCSRTemplate([]*x509.Certificate{ca.cert}, ca.key, template)
+	if err != nil {
+		return signer.PEMBundle{}, err
+	}
+
+	return signer.PEMBundle(bundle), nil
+}
+
+// CAPEM returns the PEM encoded CA certificate of the issuer, or nil if the CA
+// has not been generated yet.
+func (s *Simulator) CAPEM(issuerObject v1alpha1.Issuer) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ca, ok := s.cas[keyForIssuer(issuerObject)]
+	if !ok {
+		return nil
+	}
+
+	caPEM, err := pki.EncodeX509(ca.cert)
+	if err != nil {
+		return nil
+	}
+
+	return caPEM
+}
+
+func keyForIssuer(issuerObject v1alpha1.Issuer) issuerKey {
+	return issuerKey{
+		issuerType: fmt.Sprintf("%T", issuerObject),
+		name:       types.NamespacedName{Namespace: issuerObject.GetNamespace(), Name: issuerObject.GetName()},
+		uid:        issuerObject.GetUID(),
+	}
+}
+
+func (s *Simulator) certificateAuthority(issuerObject v1alpha1.Issuer) (*certificateAuthority, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := keyForIssuer(issuerObject)
+	if ca, ok := s.cas[key]; ok {
+		return ca, nil
+	}
+
+	ca, err := s.newCertificateAuthority(issuerObject)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.cas == nil {
+		s.cas = map[issuerKey]*certificateAuthority{}
+	}
+	s.cas[key] = ca
+
+	return ca, nil
+}
+
+func (s *Simulator) newCertificateAuthority(issuerObject v1alpha1.Issuer) (*certificateAuthority, error) {
+	caKey, err := pki.GenerateECPrivateKey(pki.ECCurve256)
+	if err != nil {
+		return nil, err
+	}
+
+	caDuration := s.CADuration
+	if caDuration == 0 {
+		caDuration = defaultCADuration
+	}
+
+	commonName := issuerObject.GetName()
+	if issuerObject.GetNamespace() != "" {
+		commonName = issuerObject.GetNamespace() + "/" + commonName
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		Subject: pkix.Name{
+			Organization: []string{"issuer-lib simulator"},
+			CommonName:   commonName,
+		},
+		NotBefore: now,
+		NotAfter:  now.Add(caDuration),
+
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+	}
+
+	template.SerialNumber, err = serialnumber.Generate(s.SerialNumberPolicy, template)
+	if err != nil {
+		return nil, err
+	}
+
+	_, caCert, err := pki.SignCertificate(template, template, caKey.Public(), caKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &certificateAuthority{
+		cert: caCert,
+		key:  caKey,
+	}, nil
+}
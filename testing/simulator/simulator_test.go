@@ -0,0 +1,117 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/cert-manager/cert-manager/pkg/util/pki"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/testapi/testutil"
+	"github.com/cert-manager/issuer-lib/serialnumber"
+	"github.com/cert-manager/issuer-lib/testing/validation"
+)
+
+func TestSimulatorSign(t *testing.T) {
+	key, err := pki.GenerateECPrivateKey(pki.ECCurve256)
+	require.NoError(t, err)
+
+	csrDER, err := pki.EncodeCSR(&x509.CertificateRequest{DNSNames: []string{"example.com"}}, key)
+	require.NoError(t, err)
+	csrPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER})
+
+	cr := &cmapi.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "cr1", Namespace: "ns1"},
+		Spec: cmapi.CertificateRequestSpec{
+			Request:  csrPEM,
+			Duration: &metav1.Duration{Duration: 2 * time.Hour},
+			IsCA:     true,
+			Usages:   []cmapi.KeyUsage{cmapi.UsageDigitalSignature, cmapi.UsageCertSign},
+		},
+	}
+
+	issuer1 := testutil.TestIssuer("issuer-1", testutil.SetTestIssuerNamespace("ns1"))
+	issuer2 := testutil.TestIssuer("issuer-2", testutil.SetTestIssuerNamespace("ns1"))
+
+	sim := &Simulator{}
+	require.Nil(t, sim.CAPEM(issuer1))
+	require.NoError(t, sim.Check(context.TODO(), issuer1))
+	require.NotNil(t, sim.CAPEM(issuer1))
+
+	bundle, err := sim.Sign(context.TODO(), signer.CertificateRequestObjectFromCertificateRequest(cr), issuer1)
+	require.NoError(t, err)
+	require.Equal(t, sim.CAPEM(issuer1), bundle.CAPEM)
+
+	require.NoError(t, validation.ValidateChainOrder(append(bundle.ChainPEM, bundle.CAPEM...)))
+	require.NoError(t, validation.ValidateSANsMatchRequest(bundle.ChainPEM, csrPEM))
+
+	leaf, err := pki.DecodeX509CertificateBytes(bundle.ChainPEM)
+	require.NoError(t, err)
+	require.True(t, leaf.IsCA)
+	require.Equal(t, 2*time.Hour, leaf.NotAfter.Sub(leaf.NotBefore))
+	require.Equal(t, x509.KeyUsageDigitalSignature|x509.KeyUsageCertSign, leaf.KeyUsage)
+
+	// Each issuer gets its own CA.
+	bundle2, err := sim.Sign(context.TODO(), signer.CertificateRequestObjectFromCertificateRequest(cr), issuer2)
+	require.NoError(t, err)
+	require.NotEqual(t, bundle.CAPEM, bundle2.CAPEM)
+	require.Error(t, validation.ValidateChainOrder(append(bundle2.ChainPEM, bundle.CAPEM...)))
+}
+
+func TestSimulatorSerialNumberPolicy(t *testing.T) {
+	key, err := pki.GenerateECPrivateKey(pki.ECCurve256)
+	require.NoError(t, err)
+
+	csrDER, err := pki.EncodeCSR(&x509.CertificateRequest{DNSNames: []string{"example.com"}}, key)
+	require.NoError(t, err)
+
+	cr := &cmapi.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "cr1", Namespace: "ns1"},
+		Spec: cmapi.CertificateRequestSpec{
+			Request: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER}),
+		},
+	}
+
+	next := int64(0)
+	sim := &Simulator{
+		SerialNumberPolicy: serialnumber.PolicyFunc(func(_ *x509.Certificate) (*big.Int, error) {
+			next++
+			return big.NewInt(next), nil
+		}),
+	}
+
+	issuer := testutil.TestIssuer("issuer-1", testutil.SetTestIssuerNamespace("ns1"))
+	bundle, err := sim.Sign(context.TODO(), signer.CertificateRequestObjectFromCertificateRequest(cr), issuer)
+	require.NoError(t, err)
+
+	ca, err := pki.DecodeX509CertificateBytes(bundle.CAPEM)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(1), ca.SerialNumber)
+
+	leaf, err := pki.DecodeX509CertificateBytes(bundle.ChainPEM)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(2), leaf.SerialNumber)
+}
@@ -0,0 +1,377 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ssafake
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+const listMapKey = "type"
+
+type objectKey struct {
+	objectType  string
+	uid         types.UID
+	subResource string
+}
+
+// Applier keeps track of the fields applied by each field manager, which is
+// required to remove fields that a field manager no longer applies.
+type Applier struct {
+	mu      sync.Mutex
+	managed map[objectKey]map[string]map[string][]string
+}
+
+func NewApplier() *Applier {
+	return &Applier{
+		managed: map[objectKey]map[string]map[string][]string{},
+	}
+}
+
+// InterceptorFuncs returns interceptor functions that handle apply patches for
+// objects and their status subresource. All other patches are passed through to
+// the underlying client.
+func (a *Applier) InterceptorFuncs() interceptor.Funcs {
+	return interceptor.Funcs{
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			if patch.Type() != types.ApplyPatchType {
+				return c.Patch(ctx, obj, patch, opts...)
+			}
+
+			options := (&client.PatchOptions{}).ApplyOptions(opts)
+			return a.Apply(ctx, c, "", obj, patch, options.FieldManager)
+		},
+		SubResourcePatch: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+			if patch.Type() != types.ApplyPatchType {
+				return c.SubResource(subResourceName).Patch(ctx, obj, patch, opts...)
+			}
+
+			options := (&client.SubResourcePatchOptions{}).ApplyOptions(opts)
+			return a.Apply(ctx, c, subResourceName, obj, patch, options.FieldManager)
+		},
+	}
+}
+
+// Apply applies the apply patch to the object stored by the client, using the
+// field manager to determine which previously applied fields must be removed.
+// The subResource must be either "" or "status". On success, obj is updated to
+// the resulting state of the object.
+func (a *Applier) Apply(
+	ctx context.Context,
+	c client.Client,
+	subResource string,
+	obj client.Object,
+	patch client.Patch,
+	fieldManager string,
+) error {
+	if subResource != "" && subResource != "status" {
+		return fmt.Errorf("ssafake: apply patches for the %q subresource are not supported", subResource)
+	}
+
+	data, err := patch.Data(obj)
+	if err != nil {
+		return err
+	}
+
+	applied := map[string]interface{}{}
+	if err := json.Unmarshal(data, &applied); err != nil {
+		return fmt.Errorf("ssafake: failed to decode apply patch: %w", err)
+	}
+	applied = filterSubResource(applied, subResource)
+
+	current := newObjectOfType(obj)
+	if err := c.Get(ctx, client.ObjectKeyFromObject(obj), current); apierrors.IsNotFound(err) && subResource == "" {
+		created := newObjectOfType(obj)
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(applied, created); err != nil {
+			return err
+		}
+		if err := c.Create(ctx, created); err != nil {
+			return err
+		}
+		a.setManaged(objectKeyFor(created, subResource), fieldManager, fieldPaths(nil, applied))
+		return copyInto(obj, created)
+	} else if err != nil {
+		return err
+	}
+
+	currentMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(current)
+	if err != nil {
+		return err
+	}
+
+	key := objectKeyFor(current, subResource)
+	newPaths := fieldPaths(nil, applied)
+	for _, path := range a.removedPaths(key, fieldManager, newPaths) {
+		removePath(currentMap, path)
+	}
+	mergeApplied(currentMap, applied)
+
+	result := newObjectOfType(obj)
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(currentMap, result); err != nil {
+		return err
+	}
+
+	if subResource == "status" {
+		err = c.Status().Update(ctx, result)
+	} else {
+		err = c.Update(ctx, result)
+	}
+	if err != nil {
+		return err
+	}
+
+	a.setManaged(key, fieldManager, newPaths)
+	return copyInto(obj, result)
+}
+
+// removedPaths returns the paths that were applied by the field manager in the
+// past, are no longer applied and are not applied by any other field manager.
+func (a *Applier) removedPaths(key objectKey, fieldManager string, newPaths map[string][]string) [][]string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var removed [][]string
+	for id, path := range a.managed[key][fieldManager] {
+		if _, ok := newPaths[id]; ok {
+			continue
+		}
+
+		ownedByOther := false
+		for otherManager, otherPaths := range a.managed[key] {
+			if otherManager == fieldManager {
+				continue
+			}
+			if _, ok := otherPaths[id]; ok {
+				ownedByOther = true
+				break
+			}
+		}
+
+		if !ownedByOther {
+			removed = append(removed, path)
+		}
+	}
+	return removed
+}
+
+func (a *Applier) setManaged(key objectKey, fieldManager string, paths map[string][]string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.managed[key] == nil {
+		a.managed[key] = map[string]map[string][]string{}
+	}
+	a.managed[key][fieldManager] = paths
+}
+
+func objectKeyFor(obj client.Object, subResource string) objectKey {
+	return objectKey{
+		objectType:  fmt.Sprintf("%T", obj),
+		uid:         obj.GetUID(),
+		subResource: subResource,
+	}
+}
+
+func newObjectOfType(obj client.Object) client.Object {
+	return reflect.New(reflect.TypeOf(obj).Elem()).Interface().(client.Object)
+}
+
+func copyInto(dst client.Object, src client.Object) error {
+	dstValue := reflect.ValueOf(dst).Elem()
+	srcValue := reflect.ValueOf(src).Elem()
+	if dstValue.Type() != srcValue.Type() {
+		return fmt.Errorf("ssafake: cannot copy %T into %T", src, dst)
+	}
+	dstValue.Set(srcValue)
+	return nil
+}
+
+// filterSubResource only keeps the fields of the apply patch that are relevant
+// for the subresource. The identifying fields (apiVersion, kind, name and namespace)
+// are never considered to be applied fields.
+func filterSubResource(applied map[string]interface{}, subResource string) map[string]interface{} {
+	filtered := map[string]interface{}{}
+	for key, value := range applied {
+		switch key {
+		case "apiVersion", "kind":
+			filtered[key] = value
+		case "metadata":
+			metadata, ok := value.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			filteredMetadata := map[string]interface{}{}
+			for metaKey, metaValue := range metadata {
+				if subResource == "" || metaKey == "name" || metaKey == "namespace" {
+					filteredMetadata[metaKey] = metaValue
+				}
+			}
+			filtered[key] = filteredMetadata
+		case "status":
+			if subResource == "status" {
+				filtered[key] = value
+			}
+		default:
+			if subResource == "" {
+				filtered[key] = value
+			}
+		}
+	}
+	return filtered
+}
+
+func isIdentityPath(path []string) bool {
+	switch strings.Join(path, ".") {
+	case "apiVersion", "kind", "metadata.name", "metadata.namespace":
+		return true
+	}
+	return false
+}
+
+// fieldPaths returns all the leaf paths of the applied object, indexed by a
+// string representation of the path. Items of keyed lists are treated as leaves.
+func fieldPaths(prefix []string, applied map[string]interface{}) map[string][]string {
+	paths := map[string][]string{}
+	for key, value := range applied {
+		path := append(append([]string{}, prefix...), key)
+		if isIdentityPath(path) {
+			continue
+		}
+
+		switch value := value.(type) {
+		case map[string]interface{}:
+			if len(value) == 0 {
+				paths[strings.Join(path, ".")] = path
+				continue
+			}
+			for id, subPath := range fieldPaths(path, value) {
+				paths[id] = subPath
+			}
+		case []interface{}:
+			if !isKeyedList(value) {
+				paths[strings.Join(path, ".")] = path
+				continue
+			}
+			for _, item := range value {
+				itemPath := append(append([]string{}, path...), listItemSegment(item))
+				paths[strings.Join(itemPath, ".")] = itemPath
+			}
+		default:
+			paths[strings.Join(path, ".")] = path
+		}
+	}
+	return paths
+}
+
+func listItemSegment(item interface{}) string {
+	return fmt.Sprintf("[%s=%s]", listMapKey, item.(map[string]interface{})[listMapKey])
+}
+
+func isKeyedList(list []interface{}) bool {
+	if len(list) == 0 {
+		return false
+	}
+	for _, item := range list {
+		itemMap, ok := item.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if _, ok := itemMap[listMapKey].(string); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func removePath(obj map[string]interface{}, path []string) {
+	if len(path) == 0 {
+		return
+	}
+
+	key := path[0]
+	if len(path) == 1 {
+		delete(obj, key)
+		return
+	}
+
+	switch value := obj[key].(type) {
+	case map[string]interface{}:
+		removePath(value, path[1:])
+	case []interface{}:
+		segment := path[1]
+		for i, item := range value {
+			itemMap, ok := item.(map[string]interface{})
+			if !ok || listItemSegment(itemMap) != segment {
+				continue
+			}
+			if len(path) == 2 {
+				obj[key] = append(value[:i:i], value[i+1:]...)
+			} else {
+				removePath(itemMap, path[2:])
+			}
+			return
+		}
+	}
+}
+
+// mergeApplied merges the applied fields into the current object. Maps are merged
+// recursively, keyed lists are merged by key and all other values are replaced.
+func mergeApplied(current map[string]interface{}, applied map[string]interface{}) {
+	for key, appliedValue := range applied {
+		switch appliedValue := appliedValue.(type) {
+		case map[string]interface{}:
+			if currentValue, ok := current[key].(map[string]interface{}); ok {
+				mergeApplied(currentValue, appliedValue)
+				continue
+			}
+		case []interface{}:
+			if currentValue, ok := current[key].([]interface{}); ok && isKeyedList(currentValue) && isKeyedList(appliedValue) {
+				current[key] = mergeKeyedList(currentValue, appliedValue)
+				continue
+			}
+		}
+		current[key] = runtime.DeepCopyJSONValue(appliedValue)
+	}
+}
+
+func mergeKeyedList(current []interface{}, applied []interface{}) []interface{} {
+	merged := append([]interface{}{}, current...)
+	for _, appliedItem := range applied {
+		found := false
+		for i, currentItem := range merged {
+			if listItemSegment(currentItem) == listItemSegment(appliedItem) {
+				merged[i] = runtime.DeepCopyJSONValue(appliedItem)
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, runtime.DeepCopyJSONValue(appliedItem))
+		}
+	}
+	return merged
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ssafake
+
+import (
+	"context"
+	"testing"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/cert-manager/issuer-lib/internal/ssaclient"
+)
+
+func TestApplyStatus(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, cmapi.AddToScheme(scheme))
+
+	cr := &cmapi.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "cr1", Namespace: "ns1"},
+		Status: cmapi.CertificateRequestStatus{
+			Conditions: []cmapi.CertificateRequestCondition{
+				{Type: cmapi.CertificateRequestConditionApproved, Status: cmmeta.ConditionTrue, Reason: "Approved"},
+			},
+		},
+	}
+
+	ctx := context.Background()
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(cr).
+		WithStatusSubresource(cr).
+		WithInterceptorFuncs(NewApplier().InterceptorFuncs()).
+		Build()
+
+	applyStatus := func(fieldManager string, status *cmapi.CertificateRequestStatus) {
+		obj, patch, err := ssaclient.GenerateCertificateRequestStatusPatch("cr1", "ns1", status)
+		require.NoError(t, err)
+		require.NoError(t, cl.Status().Patch(ctx, &obj, patch, &client.SubResourcePatchOptions{
+			PatchOptions: client.PatchOptions{FieldManager: fieldManager, Force: ptr.To(true)},
+		}))
+	}
+
+	applyStatus("issuer-lib", &cmapi.CertificateRequestStatus{
+		Certificate: []byte("cert"),
+		Conditions: []cmapi.CertificateRequestCondition{
+			{Type: cmapi.CertificateRequestConditionReady, Status: cmmeta.ConditionTrue, Reason: "Issued"},
+		},
+	})
+
+	var result cmapi.CertificateRequest
+	require.NoError(t, cl.Get(ctx, client.ObjectKeyFromObject(cr), &result))
+	require.Equal(t, []byte("cert"), result.Status.Certificate)
+	require.Len(t, result.Status.Conditions, 2)
+	require.Equal(t, cmapi.CertificateRequestConditionApproved, result.Status.Conditions[0].Type)
+	require.Equal(t, cmapi.CertificateRequestConditionReady, result.Status.Conditions[1].Type)
+
+	// Fields that are no longer applied by the field manager are removed.
+	applyStatus("issuer-lib", &cmapi.CertificateRequestStatus{
+		Conditions: []cmapi.CertificateRequestCondition{
+			{Type: cmapi.CertificateRequestConditionReady, Status: cmmeta.ConditionFalse, Reason: "Pending"},
+		},
+	})
+
+	require.NoError(t, cl.Get(ctx, client.ObjectKeyFromObject(cr), &result))
+	require.Empty(t, result.Status.Certificate)
+	require.Len(t, result.Status.Conditions, 2)
+	require.Equal(t, cmmeta.ConditionFalse, result.Status.Conditions[1].Status)
+
+	// Fields that are also applied by another field manager are kept.
+	applyStatus("other-manager", &cmapi.CertificateRequestStatus{
+		Conditions: []cmapi.CertificateRequestCondition{
+			{Type: cmapi.CertificateRequestConditionReady, Status: cmmeta.ConditionFalse, Reason: "Pending"},
+		},
+	})
+	applyStatus("issuer-lib", &cmapi.CertificateRequestStatus{})
+
+	require.NoError(t, cl.Get(ctx, client.ObjectKeyFromObject(cr), &result))
+	require.Len(t, result.Status.Conditions, 2)
+}
+
+func TestApplyObject(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, cmapi.AddToScheme(scheme))
+
+	ctx := context.Background()
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithInterceptorFuncs(NewApplier().InterceptorFuncs()).
+		Build()
+
+	apply := func(data string) {
+		obj := &cmapi.CertificateRequest{ObjectMeta: metav1.ObjectMeta{Name: "cr1", Namespace: "ns1"}}
+		require.NoError(t, cl.Patch(ctx, obj, client.RawPatch(types.ApplyPatchType, []byte(data)), client.FieldOwner("test")))
+	}
+
+	// Applying a non-existing object creates it.
+	apply(`{"apiVersion":"cert-manager.io/v1","kind":"CertificateRequest","metadata":{"name":"cr1","namespace":"ns1","annotations":{"a":"1","b":"2"}}}`)
+	apply(`{"apiVersion":"cert-manager.io/v1","kind":"CertificateRequest","metadata":{"name":"cr1","namespace":"ns1","annotations":{"a":"3"}}}`)
+
+	var result cmapi.CertificateRequest
+	require.NoError(t, cl.Get(ctx, types.NamespacedName{Name: "cr1", Namespace: "ns1"}, &result))
+	require.Equal(t, map[string]string{"a": "3"}, result.Annotations)
+}
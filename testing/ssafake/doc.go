@@ -0,0 +1,37 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ssafake adds best-effort server-side apply (SSA) support to the
+// controller-runtime fake client, which rejects apply patches.
+//
+// The issuer-lib controllers write all status updates using SSA. By adding the
+// interceptor returned by Applier.InterceptorFuncs to a fake client, tests can
+// run the controllers against the fake client and assert the resulting object
+// state instead of the generated patches:
+//
+//	cl := fake.NewClientBuilder().
+//		WithScheme(scheme).
+//		WithObjects(cr).
+//		WithStatusSubresource(cr).
+//		WithInterceptorFuncs(ssafake.NewApplier().InterceptorFuncs()).
+//		Build()
+//
+// The simulation is best-effort: lists whose items all have a "type" field
+// (such as status conditions) are merged by that field, all other lists are
+// replaced atomically, and the fields that a field manager stops applying are
+// removed unless another field manager also applied them. Conflicts are never
+// reported, as if every apply was forced.
+package ssafake
@@ -0,0 +1,305 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package statussnapshot records the status patches that the issuer-lib
+// controllers apply and compares them with golden files. Downstream projects
+// can use it to detect unintended changes of the conditions, annotations and
+// status fields set by the controllers when upgrading issuer-lib.
+//
+// Add the interceptor returned by Recorder.InterceptorFuncs to the client of the
+// controllers, run a reconcile for each scenario (eg. with a Sign function that
+// returns the error of one of the SignScenarios) and compare the snapshot of the
+// recorded patches with a golden file:
+//
+//	for _, scenario := range statussnapshot.SignScenarios() {
+//		recorder := statussnapshot.NewRecorder()
+//		cl := fake.NewClientBuilder().
+//			WithScheme(scheme).
+//			WithObjects(cr, issuer).
+//			WithStatusSubresource(cr).
+//			WithInterceptorFuncs(recorder.InterceptorFuncs(ssafake.NewApplier().InterceptorFuncs())).
+//			Build()
+//		// ... reconcile the request, Sign returns scenario.Err
+//		snapshot, err := recorder.Snapshot()
+//		require.NoError(t, err)
+//		statussnapshot.AssertGoldenFile(t, filepath.Join("testdata", scenario.Name+".json"), snapshot)
+//	}
+//
+// The golden files are (re)written instead of compared if the UpdateEnvVar
+// environment variable is set to "true", eg.
+// "UPDATE_GOLDEN_FILES=true go test ./...".
+package statussnapshot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+// UpdateEnvVar is the environment variable that is set to "true" to write the
+// golden files instead of comparing them.
+const UpdateEnvVar = "UPDATE_GOLDEN_FILES"
+
+// DefaultVolatileFields are the fields whose values differ between test runs,
+// they are replaced with a placeholder in the snapshots.
+var DefaultVolatileFields = []string{
+	"lastTransitionTime",
+	"lastUpdateTime",
+	"failureTime",
+}
+
+// TestingT is the subset of testing.TB that is used by AssertGoldenFile.
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+	Helper()
+}
+
+// Scenario is an error that is returned by the Sign or Check function of an
+// issuer, a nil error is the success scenario.
+type Scenario struct {
+	Name string
+	Err  error
+}
+
+// SignScenarios returns a scenario for each type of error that can be returned
+// by the Sign function.
+func SignScenarios() []Scenario {
+	return []Scenario{
+		{Name: "success"},
+		{Name: "error", Err: errors.New("[error]")},
+		{Name: "pending", Err: signer.PendingError{Err: errors.New("[pending]")}},
+		{Name: "pending-retry-after", Err: signer.PendingError{Err: errors.New("[pending]"), RetryAfter: time.Minute}},
+		{Name: "permanent", Err: signer.PermanentError{Err: errors.New("[permanent]")}},
+		{Name: "issuer-error", Err: signer.IssuerError{Err: errors.New("[issuer error]")}},
+		{Name: "set-condition-pending", Err: signer.SetCertificateRequestConditionError{
+			Err:           signer.PendingError{Err: errors.New("[pending]")},
+			ConditionType: "[condition type]",
+			Status:        cmmeta.ConditionTrue,
+			Reason:        "[reason]",
+		}},
+		{Name: "set-condition-permanent", Err: signer.SetCertificateRequestConditionError{
+			Err:           signer.PermanentError{Err: errors.New("[permanent]")},
+			ConditionType: "[condition type]",
+			Status:        cmmeta.ConditionFalse,
+			Reason:        "[reason]",
+		}},
+	}
+}
+
+// CheckScenarios returns a scenario for each type of error that can be returned
+// by the Check function.
+func CheckScenarios() []Scenario {
+	return []Scenario{
+		{Name: "success"},
+		{Name: "error", Err: errors.New("[error]")},
+		{Name: "permanent", Err: signer.PermanentError{Err: errors.New("[permanent]")}},
+		{Name: "retry-after", Err: signer.RetryAfterError{Err: errors.New("[retry after]"), RetryAfter: time.Minute}},
+		{Name: "credentials-invalid", Err: signer.CredentialsInvalidError(errors.New("[credentials invalid]"))},
+		{Name: "endpoint-unreachable", Err: signer.EndpointUnreachableError(errors.New("[endpoint unreachable]"))},
+	}
+}
+
+// Patch is an apply patch that was recorded by a Recorder.
+type Patch struct {
+	// SubResource is "status" for status patches and empty for patches of
+	// the resource itself (eg. annotations).
+	SubResource  string                 `json:"subResource,omitempty"`
+	FieldManager string                 `json:"fieldManager"`
+	Patch        map[string]interface{} `json:"patch"`
+}
+
+// Recorder records the apply patches that are sent through a client.
+type Recorder struct {
+	// VolatileFields are the fields whose values are replaced with a placeholder
+	// in the snapshots, defaults to DefaultVolatileFields.
+	VolatileFields []string
+
+	mu      sync.Mutex
+	patches []Patch
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// InterceptorFuncs returns interceptor functions that record the apply patches
+// and then pass all patches to the Patch and SubResourcePatch functions of next,
+// or to the underlying client if these are not set. The other functions of next
+// are returned unchanged.
+func (r *Recorder) InterceptorFuncs(next interceptor.Funcs) interceptor.Funcs {
+	funcs := next
+	funcs.Patch = func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+		options := (&client.PatchOptions{}).ApplyOptions(opts)
+		if err := r.record("", obj, patch, options.FieldManager); err != nil {
+			return err
+		}
+
+		if next.Patch != nil {
+			return next.Patch(ctx, c, obj, patch, opts...)
+		}
+		return c.Patch(ctx, obj, patch, opts...)
+	}
+	funcs.SubResourcePatch = func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+		options := (&client.SubResourcePatchOptions{}).ApplyOptions(opts)
+		if err := r.record(subResourceName, obj, patch, options.FieldManager); err != nil {
+			return err
+		}
+
+		if next.SubResourcePatch != nil {
+			return next.SubResourcePatch(ctx, c, subResourceName, obj, patch, opts...)
+		}
+		return c.SubResource(subResourceName).Patch(ctx, obj, patch, opts...)
+	}
+	return funcs
+}
+
+func (r *Recorder) record(subResource string, obj client.Object, patch client.Patch, fieldManager string) error {
+	if patch.Type() != types.ApplyPatchType {
+		return nil
+	}
+
+	data, err := patch.Data(obj)
+	if err != nil {
+		return err
+	}
+
+	applied := map[string]interface{}{}
+	if err := json.Unmarshal(data, &applied); err != nil {
+		return fmt.Errorf("statussnapshot: failed to decode apply patch: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.patches = append(r.patches, Patch{
+		SubResource:  subResource,
+		FieldManager: fieldManager,
+		Patch:        applied,
+	})
+	return nil
+}
+
+// Patches returns the recorded patches, in the order in which they were applied.
+func (r *Recorder) Patches() []Patch {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.patches)
+}
+
+// Reset removes the recorded patches.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.patches = nil
+}
+
+// Snapshot returns the recorded patches as indented JSON, with the values of the
+// volatile fields replaced with a placeholder.
+func (r *Recorder) Snapshot() ([]byte, error) {
+	volatileFields := r.VolatileFields
+	if len(volatileFields) == 0 {
+		volatileFields = DefaultVolatileFields
+	}
+
+	patches := r.Patches()
+	for i := range patches {
+		patches[i].Patch = replaceVolatileFields(patches[i].Patch, volatileFields).(map[string]interface{})
+	}
+	if patches == nil {
+		patches = []Patch{}
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(patches); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// replaceVolatileFields returns a copy of the value in which the non-null values
+// of the volatile fields are replaced with a placeholder.
+func replaceVolatileFields(value interface{}, volatileFields []string) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		replaced := make(map[string]interface{}, len(value))
+		for key, fieldValue := range value {
+			if fieldValue != nil && slices.Contains(volatileFields, key) {
+				replaced[key] = "<" + key + ">"
+				continue
+			}
+			replaced[key] = replaceVolatileFields(fieldValue, volatileFields)
+		}
+		return replaced
+	case []interface{}:
+		replaced := make([]interface{}, len(value))
+		for i, item := range value {
+			replaced[i] = replaceVolatileFields(item, volatileFields)
+		}
+		return replaced
+	default:
+		return value
+	}
+}
+
+// AssertGoldenFile asserts that the snapshot matches the contents of the golden
+// file at path. If the UpdateEnvVar environment variable is set to "true", the
+// golden file is written instead.
+func AssertGoldenFile(t TestingT, path string, snapshot []byte) bool {
+	t.Helper()
+
+	if os.Getenv(UpdateEnvVar) == "true" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { // #nosec G301 -- golden files are checked in
+			t.Errorf("failed to create the directory of golden file %s: %v", path, err)
+			return false
+		}
+		if err := os.WriteFile(path, snapshot, 0o644); err != nil { // #nosec G306 -- golden files are checked in
+			t.Errorf("failed to write golden file %s: %v", path, err)
+			return false
+		}
+		return true
+	}
+
+	golden, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("failed to read golden file %s (set %s=true to create it): %v", path, UpdateEnvVar, err)
+		return false
+	}
+
+	if !bytes.Equal(golden, snapshot) {
+		t.Errorf("snapshot does not match golden file %s (set %s=true to update it):\n--- golden\n%s\n+++ snapshot\n%s", path, UpdateEnvVar, golden, snapshot)
+		return false
+	}
+	return true
+}
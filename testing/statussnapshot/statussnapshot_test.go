@@ -0,0 +1,150 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statussnapshot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/cert-manager/issuer-lib/internal/ssaclient"
+	"github.com/cert-manager/issuer-lib/testing/ssafake"
+)
+
+type fakeT struct {
+	errors []string
+}
+
+func (f *fakeT) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeT) Helper() {}
+
+func TestRecorderSnapshot(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, cmapi.AddToScheme(scheme))
+
+	cr := &cmapi.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "cr1", Namespace: "ns1"},
+	}
+
+	recorder := NewRecorder()
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(cr).
+		WithStatusSubresource(cr).
+		WithInterceptorFuncs(recorder.InterceptorFuncs(ssafake.NewApplier().InterceptorFuncs())).
+		Build()
+
+	obj, patch, err := ssaclient.GenerateCertificateRequestStatusPatch("cr1", "ns1", &cmapi.CertificateRequestStatus{
+		Conditions: []cmapi.CertificateRequestCondition{
+			{
+				Type:               cmapi.CertificateRequestConditionReady,
+				Status:             cmmeta.ConditionFalse,
+				Reason:             cmapi.CertificateRequestReasonPending,
+				Message:            "[pending]",
+				LastTransitionTime: ptr.To(metav1.NewTime(time.Now())),
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, cl.Status().Patch(context.TODO(), &obj, patch, &client.SubResourcePatchOptions{
+		PatchOptions: client.PatchOptions{FieldManager: "issuer-lib", Force: ptr.To(true)},
+	}))
+
+	// The patch is passed on to the applier.
+	var result cmapi.CertificateRequest
+	require.NoError(t, cl.Get(context.TODO(), client.ObjectKeyFromObject(cr), &result))
+	require.Len(t, result.Status.Conditions, 1)
+
+	snapshot, err := recorder.Snapshot()
+	require.NoError(t, err)
+	assert.Equal(t, `[
+  {
+    "subResource": "status",
+    "fieldManager": "issuer-lib",
+    "patch": {
+      "apiVersion": "cert-manager.io/v1",
+      "kind": "CertificateRequest",
+      "metadata": {
+        "name": "cr1",
+        "namespace": "ns1"
+      },
+      "status": {
+        "conditions": [
+          {
+            "lastTransitionTime": "<lastTransitionTime>",
+            "message": "[pending]",
+            "reason": "Pending",
+            "status": "False",
+            "type": "Ready"
+          }
+        ]
+      }
+    }
+  }
+]
+`, string(snapshot))
+
+	recorder.Reset()
+	snapshot, err = recorder.Snapshot()
+	require.NoError(t, err)
+	assert.Equal(t, "[]\n", string(snapshot))
+}
+
+func TestAssertGoldenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "testdata", "snapshot.json")
+
+	// A missing golden file is reported.
+	ft := &fakeT{}
+	assert.False(t, AssertGoldenFile(ft, path, []byte("a")))
+	require.Len(t, ft.errors, 1)
+	assert.Contains(t, ft.errors[0], "set UPDATE_GOLDEN_FILES=true to create it")
+
+	// The golden file is written if the update environment variable is set.
+	t.Setenv(UpdateEnvVar, "true")
+	ft = &fakeT{}
+	assert.True(t, AssertGoldenFile(ft, path, []byte("a")))
+	assert.Empty(t, ft.errors)
+	golden, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "a", string(golden))
+
+	t.Setenv(UpdateEnvVar, "")
+	ft = &fakeT{}
+	assert.True(t, AssertGoldenFile(ft, path, []byte("a")))
+	assert.Empty(t, ft.errors)
+
+	ft = &fakeT{}
+	assert.False(t, AssertGoldenFile(ft, path, []byte("b")))
+	require.Len(t, ft.errors, 1)
+	assert.Contains(t, ft.errors[0], "does not match golden file")
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package timetravel contains a fake clock and assertions for tests of the time
+// related behavior of issuer-lib based controllers, such as the MaxRetryDuration
+// of requests and the LastTransitionTime and FailureTime fields that are set by
+// the status patches of the controllers.
+//
+// Set the Clock of the controllers to a Clock, advance it between reconciles and
+// assert the times recorded on the resources:
+//
+//	clock := timetravel.NewRandomClock()
+//	// ... reconcile, the request becomes Pending
+//	clock.AdvancePast(maxRetryDuration)
+//	// ... reconcile, the request fails
+//	timetravel.AssertCertificateRequestFailedAt(t, cr, clock.Now())
+package timetravel
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/stretchr/testify/assert"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/conditions"
+)
+
+// TestingT is the subset of testing.TB that is used by the assertions.
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+	Helper()
+}
+
+// Clock is a fake clock whose time is always truncated to seconds, which is the
+// precision of the timestamps in the Kubernetes API. This makes the times that
+// are read back from the API equal to the times of the clock.
+type Clock struct {
+	*clocktesting.FakeClock
+}
+
+// NewClock returns a Clock set to the start time, truncated to seconds.
+func NewClock(start time.Time) *Clock {
+	return &Clock{FakeClock: clocktesting.NewFakeClock(start.Truncate(time.Second))}
+}
+
+// NewRandomClock returns a Clock set to a random time between 1970 and 2070.
+// Using a random time makes incorrect uses of time.Now() in the code under test
+// likely to be detected.
+func NewRandomClock() *Clock {
+	minTime := time.Date(1970, 1, 0, 0, 0, 0, 0, time.UTC).Unix()
+	maxTime := time.Date(2070, 1, 0, 0, 0, 0, 0, time.UTC).Unix()
+
+	sec := rand.Int63n(maxTime-minTime) + minTime // #nosec: G404 -- The random time does not have to be secure.
+	return NewClock(time.Unix(sec, 0))
+}
+
+// MetaNow returns the current time of the clock as a metav1.Time.
+func (c *Clock) MetaNow() metav1.Time {
+	return metav1.NewTime(c.Now())
+}
+
+// Advance moves the clock forward by the duration (truncated to seconds), and
+// returns the new time.
+func (c *Clock) Advance(d time.Duration) metav1.Time {
+	c.SetTime(c.Now().Add(d).Truncate(time.Second))
+	return c.MetaNow()
+}
+
+// AdvancePast moves the clock forward to one second after the duration has
+// passed, eg. to make a request exceed its MaxRetryDuration, and returns the
+// new time.
+func (c *Clock) AdvancePast(d time.Duration) metav1.Time {
+	return c.Advance(d + time.Second)
+}
+
+// AssertCertificateRequestConditionTransitionedAt asserts that the condition of
+// the CertificateRequest exists and last transitioned at the time.
+func AssertCertificateRequestConditionTransitionedAt(
+	t TestingT,
+	cr *cmapi.CertificateRequest,
+	conditionType cmapi.CertificateRequestConditionType,
+	at time.Time,
+) bool {
+	t.Helper()
+
+	for _, condition := range cr.Status.Conditions {
+		if condition.Type == conditionType {
+			return assertTimeEqual(t, at, condition.LastTransitionTime, "LastTransitionTime of condition %q", conditionType)
+		}
+	}
+
+	return assert.Fail(t, "condition not found", "the CertificateRequest has no %q condition", conditionType)
+}
+
+// AssertCertificateRequestFailedAt asserts that the FailureTime of the
+// CertificateRequest is set to the time.
+func AssertCertificateRequestFailedAt(t TestingT, cr *cmapi.CertificateRequest, at time.Time) bool {
+	t.Helper()
+
+	return assertTimeEqual(t, at, cr.Status.FailureTime, "FailureTime")
+}
+
+// AssertCertificateRequestNotFailed asserts that the FailureTime of the
+// CertificateRequest is not set.
+func AssertCertificateRequestNotFailed(t TestingT, cr *cmapi.CertificateRequest) bool {
+	t.Helper()
+
+	return assert.Nil(t, cr.Status.FailureTime, "expected FailureTime to be unset")
+}
+
+// AssertCertificateSigningRequestConditionTransitionedAt asserts that the
+// condition of the Kubernetes CSR exists and last transitioned at the time.
+func AssertCertificateSigningRequestConditionTransitionedAt(
+	t TestingT,
+	csr *certificatesv1.CertificateSigningRequest,
+	conditionType certificatesv1.RequestConditionType,
+	at time.Time,
+) bool {
+	t.Helper()
+
+	for _, condition := range csr.Status.Conditions {
+		if condition.Type == conditionType {
+			return assertTimeEqual(t, at, &condition.LastTransitionTime, "LastTransitionTime of condition %q", conditionType)
+		}
+	}
+
+	return assert.Fail(t, "condition not found", "the CertificateSigningRequest has no %q condition", conditionType)
+}
+
+// AssertIssuerConditionTransitionedAt asserts that the condition of the issuer
+// exists and last transitioned at the time.
+func AssertIssuerConditionTransitionedAt(
+	t TestingT,
+	issuer v1alpha1.Issuer,
+	conditionType cmapi.IssuerConditionType,
+	at time.Time,
+) bool {
+	t.Helper()
+
+	condition := conditions.GetIssuerStatusCondition(issuer.GetStatus().Conditions, conditionType)
+	if condition == nil {
+		return assert.Fail(t, "condition not found", "the issuer has no %q condition", conditionType)
+	}
+
+	return assertTimeEqual(t, at, condition.LastTransitionTime, "LastTransitionTime of condition %q", conditionType)
+}
+
+func assertTimeEqual(t TestingT, expected time.Time, actual *metav1.Time, fieldFormat string, args ...interface{}) bool {
+	t.Helper()
+
+	field := fmt.Sprintf(fieldFormat, args...)
+	if actual == nil {
+		return assert.Fail(t, "time not set", "expected %s to be set to %s", field, expected.Format(time.RFC3339))
+	}
+
+	return assert.True(t, expected.Equal(actual.Time), "expected %s to be %s, but it is %s",
+		field, expected.Format(time.RFC3339), actual.Format(time.RFC3339))
+}
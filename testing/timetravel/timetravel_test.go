@@ -0,0 +1,140 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package timetravel
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/stretchr/testify/assert"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/internal/testapi/testutil"
+)
+
+type recordingT struct {
+	errors []string
+}
+
+func (r *recordingT) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recordingT) Helper() {}
+
+func TestClock(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2023, 1, 1, 0, 0, 0, 500, time.UTC)
+	clock := NewClock(start)
+	assert.Equal(t, start.Truncate(time.Second), clock.Now())
+	assert.Equal(t, metav1.NewTime(start.Truncate(time.Second)), clock.MetaNow())
+
+	assert.Equal(t, metav1.NewTime(start.Truncate(time.Second).Add(time.Minute)), clock.Advance(time.Minute+time.Millisecond))
+	assert.Equal(t, metav1.NewTime(start.Truncate(time.Second).Add(2*time.Minute+time.Second)), clock.AdvancePast(time.Minute))
+
+	randomClock := NewRandomClock()
+	assert.Equal(t, randomClock.Now().Truncate(time.Second), randomClock.Now())
+}
+
+func TestAssertCertificateRequest(t *testing.T) {
+	t.Parallel()
+
+	clock := NewRandomClock()
+	transitioned := clock.MetaNow()
+	failed := clock.Advance(time.Hour)
+
+	cr := &cmapi.CertificateRequest{
+		Status: cmapi.CertificateRequestStatus{
+			Conditions: []cmapi.CertificateRequestCondition{
+				{
+					Type:               cmapi.CertificateRequestConditionReady,
+					Status:             cmmeta.ConditionFalse,
+					LastTransitionTime: &transitioned,
+				},
+			},
+			FailureTime: &failed,
+		},
+	}
+
+	assert.True(t, AssertCertificateRequestConditionTransitionedAt(t, cr, cmapi.CertificateRequestConditionReady, transitioned.Time))
+	assert.True(t, AssertCertificateRequestFailedAt(t, cr, clock.Now()))
+
+	rt := &recordingT{}
+	assert.False(t, AssertCertificateRequestConditionTransitionedAt(rt, cr, cmapi.CertificateRequestConditionReady, clock.Now()))
+	assert.False(t, AssertCertificateRequestConditionTransitionedAt(rt, cr, cmapi.CertificateRequestConditionApproved, clock.Now()))
+	assert.False(t, AssertCertificateRequestFailedAt(rt, cr, transitioned.Time))
+	assert.False(t, AssertCertificateRequestNotFailed(rt, cr))
+	assert.Len(t, rt.errors, 4)
+
+	cr.Status.FailureTime = nil
+	assert.True(t, AssertCertificateRequestNotFailed(t, cr))
+	assert.False(t, AssertCertificateRequestFailedAt(rt, cr, clock.Now()))
+	assert.Len(t, rt.errors, 5)
+}
+
+func TestAssertCertificateSigningRequest(t *testing.T) {
+	t.Parallel()
+
+	clock := NewRandomClock()
+	csr := &certificatesv1.CertificateSigningRequest{
+		Status: certificatesv1.CertificateSigningRequestStatus{
+			Conditions: []certificatesv1.CertificateSigningRequestCondition{
+				{
+					Type:               certificatesv1.CertificateFailed,
+					Status:             "True",
+					LastTransitionTime: clock.MetaNow(),
+				},
+			},
+		},
+	}
+
+	assert.True(t, AssertCertificateSigningRequestConditionTransitionedAt(t, csr, certificatesv1.CertificateFailed, clock.Now()))
+
+	rt := &recordingT{}
+	assert.False(t, AssertCertificateSigningRequestConditionTransitionedAt(rt, csr, certificatesv1.CertificateFailed, clock.Now().Add(time.Second)))
+	assert.False(t, AssertCertificateSigningRequestConditionTransitionedAt(rt, csr, certificatesv1.CertificateApproved, clock.Now()))
+	assert.Len(t, rt.errors, 2)
+}
+
+func TestAssertIssuer(t *testing.T) {
+	t.Parallel()
+
+	clock := NewRandomClock()
+	issuer := testutil.TestIssuer(
+		"issuer-1",
+		testutil.SetTestIssuerStatusCondition(
+			clock,
+			cmapi.IssuerConditionReady,
+			cmmeta.ConditionTrue,
+			v1alpha1.IssuerConditionReasonChecked,
+			"Succeeded checking the issuer",
+		),
+	)
+
+	assert.True(t, AssertIssuerConditionTransitionedAt(t, issuer, cmapi.IssuerConditionReady, clock.Now()))
+
+	rt := &recordingT{}
+	assert.False(t, AssertIssuerConditionTransitionedAt(rt, issuer, cmapi.IssuerConditionReady, clock.Advance(time.Minute).Time))
+	assert.False(t, AssertIssuerConditionTransitionedAt(rt, issuer, "Unknown", clock.Now()))
+	assert.Len(t, rt.errors, 2)
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package validation contains functions that check the correctness of the
+// certificates returned by an issuer. They can be used by e2e test suites to
+// assert that an issuer behaves correctly, without depending on cert-manager
+// test internals.
+package validation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/cert-manager/cert-manager/pkg/util/pki"
+
+	"github.com/cert-manager/issuer-lib/api/v1alpha1"
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+)
+
+// TestingT is the subset of testing.TB that is used to skip tests.
+type TestingT interface {
+	Helper()
+	Skipf(format string, args ...any)
+}
+
+// SkipUnlessSupported skips the test if the issuer implements the
+// signer.FeatureSetProvider interface and does not declare the feature as
+// supported. Issuers that do not implement the interface are assumed to support
+// all features.
+func SkipUnlessSupported(t TestingT, issuerObject v1alpha1.Issuer, feature signer.Feature) {
+	t.Helper()
+
+	provider, ok := issuerObject.(signer.FeatureSetProvider)
+	if !ok || provider.FeatureSet().Supports(feature) {
+		return
+	}
+
+	t.Skipf("the %s issuer does not support the %s feature", issuerObject.GetIssuerTypeIdentifier(), feature)
+}
+
+// ValidateChainOrder checks that the PEM encoded chain starts with the leaf
+// certificate and that every certificate in the chain is signed by the
+// certificate that follows it.
+func ValidateChainOrder(chainPEM []byte) error {
+	certs, err := pki.DecodeX509CertificateSetBytes(chainPEM)
+	if err != nil {
+		return fmt.Errorf("failed to decode certificate chain: %w", err)
+	}
+
+	for i := 0; i < len(certs)-1; i++ {
+		if err := certs[i].CheckSignatureFrom(certs[i+1]); err != nil {
+			return fmt.Errorf("certificate %d in the chain (%q) is not signed by certificate %d (%q): %w",
+				i, certs[i].Subject, i+1, certs[i+1].Subject, err)
+		}
+	}
+
+	return nil
+}
+
+// ValidateKeyMatchesLeaf checks that the PEM encoded private key belongs to the
+// leaf certificate, which is the first certificate in the PEM encoded chain.
+func ValidateKeyMatchesLeaf(keyPEM []byte, chainPEM []byte) error {
+	key, err := pki.DecodePrivateKeyBytes(keyPEM)
+	if err != nil {
+		return fmt.Errorf("failed to decode private key: %w", err)
+	}
+
+	leaf, err := pki.DecodeX509CertificateBytes(chainPEM)
+	if err != nil {
+		return fmt.Errorf("failed to decode leaf certificate: %w", err)
+	}
+
+	matches, err := pki.PublicKeyMatchesCertificate(key.Public(), leaf)
+	if err != nil {
+		return fmt.Errorf("failed to compare public keys: %w", err)
+	}
+
+	if !matches {
+		return fmt.Errorf("private key does not match the public key of the leaf certificate")
+	}
+
+	return nil
+}
+
+// ValidateSANsMatchRequest checks that the leaf certificate, which is the first
+// certificate in the PEM encoded chain, contains exactly the DNS names, IP
+// addresses, URIs and email addresses requested in the PEM encoded CSR.
+func ValidateSANsMatchRequest(chainPEM []byte, csrPEM []byte) error {
+	leaf, err := pki.DecodeX509CertificateBytes(chainPEM)
+	if err != nil {
+		return fmt.Errorf("failed to decode leaf certificate: %w", err)
+	}
+
+	csr, err := pki.DecodeX509CertificateRequestBytes(csrPEM)
+	if err != nil {
+		return fmt.Errorf("failed to decode certificate request: %w", err)
+	}
+
+	if err := compareSANs("DNS names", leaf.DNSNames, csr.DNSNames); err != nil {
+		return err
+	}
+
+	if err := compareSANs("IP addresses", ipsToStrings(leaf.IPAddresses), ipsToStrings(csr.IPAddresses)); err != nil {
+		return err
+	}
+
+	if err := compareSANs("URIs", urisToStrings(leaf.URIs), urisToStrings(csr.URIs)); err != nil {
+		return err
+	}
+
+	if err := compareSANs("email addresses", leaf.EmailAddresses, csr.EmailAddresses); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ValidateRejected calls sign for a request that violates the policy of the
+// issuer (eg. an unsupported key algorithm or a disallowed SAN) and checks that
+// the request is failed permanently within the timeout. The error returned by
+// sign must be a signer.PermanentError, which fails the request with the Failed
+// reason. A signer.PendingError or any other error would keep the request
+// pending or retry it until the MaxRetryDuration has passed.
+//
+// The context passed to sign is cancelled after the timeout, a sign function
+// that does not return in time is not waited for.
+func ValidateRejected(timeout time.Duration, sign func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	result := make(chan error, 1)
+	go func() {
+		result <- sign(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-result:
+	case <-ctx.Done():
+		return fmt.Errorf("the request was not rejected within %s", timeout)
+	}
+
+	switch {
+	case err == nil:
+		return errors.New("the request was signed, but it should have been rejected")
+	case errors.As(err, &signer.PendingError{}):
+		return fmt.Errorf("the request was left pending, but it should have been rejected permanently: %w", err)
+	case !errors.As(err, &signer.PermanentError{}):
+		return fmt.Errorf("the request would be retried, but it should have been rejected permanently: %w", err)
+	}
+
+	return nil
+}
+
+func compareSANs(kind string, actual []string, expected []string) error {
+	actual = slices.Clone(actual)
+	expected = slices.Clone(expected)
+	slices.Sort(actual)
+	slices.Sort(expected)
+
+	if !slices.Equal(slices.Compact(actual), slices.Compact(expected)) {
+		return fmt.Errorf("certificate %s %v do not match requested %s %v", kind, actual, kind, expected)
+	}
+
+	return nil
+}
+
+func ipsToStrings(ips []net.IP) []string {
+	result := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		result = append(result, ip.String())
+	}
+	return result
+}
+
+func urisToStrings(uris []*url.URL) []string {
+	result := make([]string, 0, len(uris))
+	for _, uri := range uris {
+		result = append(result, uri.String())
+	}
+	return result
+}
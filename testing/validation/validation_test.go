@@ -0,0 +1,199 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/cert-manager/cert-manager/pkg/util/pki"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cert-manager/issuer-lib/controllers/signer"
+	"github.com/cert-manager/issuer-lib/internal/testapi/api"
+)
+
+type testCert struct {
+	cert   *x509.Certificate
+	key    *ecdsa.PrivateKey
+	keyPEM []byte
+}
+
+func newTestCert(t *testing.T, template *x509.Certificate, parent *testCert) testCert {
+	t.Helper()
+
+	key, err := pki.GenerateECPrivateKey(256)
+	require.NoError(t, err)
+
+	keyPEM, err := pki.EncodeECPrivateKey(key)
+	require.NoError(t, err)
+
+	template.SerialNumber = big.NewInt(1)
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+
+	parentCert, parentKey := template, key
+	if parent != nil {
+		parentCert, parentKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parentCert, key.Public(), parentKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return testCert{cert: cert, key: key, keyPEM: keyPEM}
+}
+
+func encodeChain(certs ...testCert) []byte {
+	var chain []byte
+	for _, cert := range certs {
+		chain = append(chain, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.cert.Raw})...)
+	}
+	return chain
+}
+
+func newTestChain(t *testing.T) (root, intermediate, leaf testCert) {
+	t.Helper()
+
+	root = newTestCert(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "root"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil)
+	intermediate = newTestCert(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "intermediate"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, &root)
+	leaf = newTestCert(t, &x509.Certificate{
+		Subject:  pkix.Name{CommonName: "leaf"},
+		DNSNames: []string{"example.com", "www.example.com"},
+	}, &intermediate)
+
+	return root, intermediate, leaf
+}
+
+func TestValidateChainOrder(t *testing.T) {
+	root, intermediate, leaf := newTestChain(t)
+
+	require.NoError(t, ValidateChainOrder(encodeChain(leaf, intermediate, root)))
+	require.NoError(t, ValidateChainOrder(encodeChain(leaf, intermediate)))
+	require.NoError(t, ValidateChainOrder(encodeChain(leaf)))
+	require.Error(t, ValidateChainOrder(encodeChain(intermediate, leaf)))
+	require.Error(t, ValidateChainOrder(encodeChain(leaf, root)))
+	require.Error(t, ValidateChainOrder([]byte("invalid")))
+}
+
+func TestValidateKeyMatchesLeaf(t *testing.T) {
+	_, intermediate, leaf := newTestChain(t)
+
+	require.NoError(t, ValidateKeyMatchesLeaf(leaf.keyPEM, encodeChain(leaf, intermediate)))
+	require.Error(t, ValidateKeyMatchesLeaf(intermediate.keyPEM, encodeChain(leaf, intermediate)))
+	require.Error(t, ValidateKeyMatchesLeaf([]byte("invalid"), encodeChain(leaf, intermediate)))
+}
+
+func TestValidateSANsMatchRequest(t *testing.T) {
+	_, intermediate, leaf := newTestChain(t)
+
+	createCSR := func(dnsNames ...string) []byte {
+		csr, err := pki.EncodeCSR(&x509.CertificateRequest{DNSNames: dnsNames}, leaf.key)
+		require.NoError(t, err)
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr})
+	}
+
+	chain := encodeChain(leaf, intermediate)
+	require.NoError(t, ValidateSANsMatchRequest(chain, createCSR("www.example.com", "example.com")))
+	require.Error(t, ValidateSANsMatchRequest(chain, createCSR("example.com")))
+	require.Error(t, ValidateSANsMatchRequest(chain, createCSR("example.com", "www.example.com", "other.example.com")))
+}
+
+func TestValidateRejected(t *testing.T) {
+	rejectWith := func(err error) func(context.Context) error {
+		return func(context.Context) error {
+			return err
+		}
+	}
+
+	require.NoError(t, ValidateRejected(time.Second, rejectWith(signer.PermanentError{Err: errors.New("[error]")})))
+	require.NoError(t, ValidateRejected(time.Second, rejectWith(fmt.Errorf("wrapped: %w", signer.PermanentError{Err: errors.New("[error]")}))))
+
+	require.EqualError(t, ValidateRejected(time.Second, rejectWith(nil)),
+		"the request was signed, but it should have been rejected")
+	require.EqualError(t, ValidateRejected(time.Second, rejectWith(signer.PendingError{Err: errors.New("[error]")})),
+		"the request was left pending, but it should have been rejected permanently: [error]")
+	require.EqualError(t, ValidateRejected(time.Second, rejectWith(errors.New("[error]"))),
+		"the request would be retried, but it should have been rejected permanently: [error]")
+
+	// A sign function that blocks is not waited for after the timeout.
+	block := make(chan struct{})
+	defer close(block)
+	require.EqualError(t, ValidateRejected(10*time.Millisecond, func(context.Context) error {
+		<-block
+		return signer.PermanentError{Err: errors.New("[error]")}
+	}), "the request was not rejected within 10ms")
+}
+
+type featureSetIssuer struct {
+	*api.TestIssuer
+	featureSet signer.FeatureSet
+}
+
+func (i featureSetIssuer) FeatureSet() signer.FeatureSet {
+	return i.featureSet
+}
+
+type recordingT struct {
+	skipped string
+}
+
+func (r *recordingT) Helper() {}
+
+func (r *recordingT) Skipf(format string, args ...any) {
+	r.skipped = fmt.Sprintf(format, args...)
+}
+
+func TestSkipUnlessSupported(t *testing.T) {
+	issuer := featureSetIssuer{
+		TestIssuer: &api.TestIssuer{},
+		featureSet: signer.FeatureSet{Features: []signer.Feature{signer.FeatureIPSANs}},
+	}
+
+	rt := &recordingT{}
+	SkipUnlessSupported(rt, issuer, signer.FeatureIPSANs)
+	require.Empty(t, rt.skipped)
+
+	SkipUnlessSupported(rt, issuer, signer.FeatureEd25519)
+	require.Equal(t, "the testissuers.testing.cert-manager.io issuer does not support the Ed25519 feature", rt.skipped)
+
+	// Issuers that do not declare their features are assumed to support all features.
+	rt = &recordingT{}
+	SkipUnlessSupported(rt, &api.TestIssuer{}, signer.FeatureEd25519)
+	require.Empty(t, rt.skipped)
+}
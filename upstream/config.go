@@ -0,0 +1,171 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package upstream contains the configuration of the outbound connections from
+// the Sign and Check functions to the CA (HTTP proxy, custom root CAs and minimum
+// TLS version), so that every issuer does not have to reinvent it.
+//
+// The configuration is populated from flags (see Config.BindFlags) or from a
+// ComponentConfig file, and is passed to the Sign and Check functions through the
+// context when the UpstreamConfig option of the controllers is set:
+//
+//	func sign(ctx context.Context, cr signer.CertificateRequestObject, issuerObject v1alpha1.Issuer) (signer.PEMBundle, error) {
+//		httpClient, err := upstream.FromContext(ctx).HTTPClient()
+//		...
+//	}
+package upstream
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// Config is the configuration of the outbound connections to the CA. The zero
+// value uses the proxy from the environment (HTTP_PROXY, HTTPS_PROXY and
+// NO_PROXY), the system root CAs and the default minimum TLS version.
+type Config struct {
+	// ProxyURL is the URL of the HTTP proxy used for connections to the CA. If
+	// empty, the proxy is read from the environment.
+	ProxyURL string `json:"proxyURL,omitempty"`
+
+	// CAFile is the path of a PEM file containing additional root CAs that are
+	// trusted for connections to the CA. The file is read every time a TLS
+	// configuration is created, so that rotated CAs are picked up.
+	CAFile string `json:"caFile,omitempty"`
+
+	// ExcludeSystemRoots only trusts the root CAs in CAFile, instead of also
+	// trusting the system root CAs.
+	ExcludeSystemRoots bool `json:"excludeSystemRoots,omitempty"`
+
+	// MinTLSVersion is the minimum TLS version used for connections to the CA,
+	// "1.2" or "1.3". Defaults to the minimum version of the crypto/tls package.
+	MinTLSVersion string `json:"minTLSVersion,omitempty"`
+}
+
+// BindFlags binds the configuration to flags prefixed with "upstream-".
+func (c *Config) BindFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.ProxyURL, "upstream-proxy-url", c.ProxyURL,
+		"The URL of the HTTP proxy used for connections to the CA. If empty, the proxy is read from the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.")
+	fs.StringVar(&c.CAFile, "upstream-ca-file", c.CAFile,
+		"The path of a PEM file containing additional root CAs that are trusted for connections to the CA.")
+	fs.BoolVar(&c.ExcludeSystemRoots, "upstream-exclude-system-roots", c.ExcludeSystemRoots,
+		"Only trust the root CAs in the upstream CA file for connections to the CA, instead of also trusting the system root CAs.")
+	fs.StringVar(&c.MinTLSVersion, "upstream-min-tls-version", c.MinTLSVersion,
+		"The minimum TLS version used for connections to the CA (1.2 or 1.3).")
+}
+
+// TLSConfig returns the TLS configuration for connections to the CA.
+func (c *Config) TLSConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{} // #nosec: G402 -- The minimum TLS version is set below.
+
+	switch c.MinTLSVersion {
+	case "":
+	case "1.2":
+		tlsConfig.MinVersion = tls.VersionTLS12
+	case "1.3":
+		tlsConfig.MinVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf("unsupported minimum TLS version %q, must be 1.2 or 1.3", c.MinTLSVersion)
+	}
+
+	if c.CAFile == "" {
+		if c.ExcludeSystemRoots {
+			return nil, fmt.Errorf("a CA file is required when excluding the system root CAs")
+		}
+		return tlsConfig, nil
+	}
+
+	rootCAs := x509.NewCertPool()
+	if !c.ExcludeSystemRoots {
+		systemRoots, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the system root CAs: %w", err)
+		}
+		rootCAs = systemRoots
+	}
+
+	caPEM, err := os.ReadFile(c.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the CA file: %w", err)
+	}
+
+	if !rootCAs.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("the CA file %q does not contain any PEM encoded certificates", c.CAFile)
+	}
+
+	tlsConfig.RootCAs = rootCAs
+	return tlsConfig, nil
+}
+
+// Transport returns a HTTP transport for connections to the CA, that uses the
+// proxy and TLS configuration.
+func (c *Config) Transport() (*http.Transport, error) {
+	tlsConfig, err := c.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if c.ProxyURL != "" {
+		proxyURL, err := url.Parse(c.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
+// HTTPClient returns a HTTP client for connections to the CA, that uses the
+// proxy and TLS configuration.
+func (c *Config) HTTPClient() (*http.Client, error) {
+	transport, err := c.Transport()
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   30 * time.Second,
+	}, nil
+}
+
+type contextKey struct{}
+
+// IntoContext returns a context that carries the configuration.
+func IntoContext(ctx context.Context, config *Config) context.Context {
+	return context.WithValue(ctx, contextKey{}, config)
+}
+
+// FromContext returns the configuration carried by the context, or the zero
+// configuration if the context does not carry a configuration.
+func FromContext(ctx context.Context) *Config {
+	if config, ok := ctx.Value(contextKey{}).(*Config); ok && config != nil {
+		return config
+	}
+	return &Config{}
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2023 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package upstream
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"flag"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestCAFile(t *testing.T) (string, *x509.Certificate) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	return caFile, cert
+}
+
+func TestTLSConfig(t *testing.T) {
+	t.Parallel()
+
+	caFile, caCert := writeTestCAFile(t)
+
+	tlsConfig, err := (&Config{}).TLSConfig()
+	require.NoError(t, err)
+	assert.Nil(t, tlsConfig.RootCAs)
+	assert.Equal(t, uint16(0), tlsConfig.MinVersion)
+
+	tlsConfig, err = (&Config{CAFile: caFile, ExcludeSystemRoots: true, MinTLSVersion: "1.3"}).TLSConfig()
+	require.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS13), tlsConfig.MinVersion)
+	expectedRootCAs := x509.NewCertPool()
+	expectedRootCAs.AddCert(caCert)
+	assert.True(t, expectedRootCAs.Equal(tlsConfig.RootCAs))
+
+	_, err = (&Config{MinTLSVersion: "1.1"}).TLSConfig()
+	assert.EqualError(t, err, "unsupported minimum TLS version \"1.1\", must be 1.2 or 1.3")
+
+	_, err = (&Config{ExcludeSystemRoots: true}).TLSConfig()
+	assert.EqualError(t, err, "a CA file is required when excluding the system root CAs")
+
+	_, err = (&Config{CAFile: filepath.Join(t.TempDir(), "missing.pem")}).TLSConfig()
+	assert.ErrorContains(t, err, "failed to read the CA file")
+}
+
+func TestTransportProxy(t *testing.T) {
+	t.Parallel()
+
+	transport, err := (&Config{ProxyURL: "http://proxy.example.com:3128"}).Transport()
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, "https://ca.example.com/sign", nil)
+	require.NoError(t, err)
+	proxyURL, err := transport.Proxy(req)
+	require.NoError(t, err)
+	assert.Equal(t, "http://proxy.example.com:3128", proxyURL.String())
+
+	_, err = (&Config{ProxyURL: "http://[::1"}).Transport()
+	assert.ErrorContains(t, err, "invalid proxy URL")
+}
+
+func TestContext(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, &Config{}, FromContext(context.TODO()))
+
+	config := &Config{ProxyURL: "http://proxy.example.com:3128"}
+	assert.Same(t, config, FromContext(IntoContext(context.TODO(), config)))
+}
+
+func TestBindFlags(t *testing.T) {
+	t.Parallel()
+
+	config := &Config{}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	config.BindFlags(fs)
+
+	require.NoError(t, fs.Parse([]string{
+		"--upstream-proxy-url=http://proxy.example.com:3128",
+		"--upstream-ca-file=/etc/ca/ca.pem",
+		"--upstream-exclude-system-roots",
+		"--upstream-min-tls-version=1.2",
+	}))
+
+	assert.Equal(t, &Config{
+		ProxyURL:           "http://proxy.example.com:3128",
+		CAFile:             "/etc/ca/ca.pem",
+		ExcludeSystemRoots: true,
+		MinTLSVersion:      "1.2",
+	}, config)
+}